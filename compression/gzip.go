@@ -0,0 +1,113 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// DefaultGzipPool is the GzipPool using the default compression level, to be
+// shared by the components that do not need a specific level.
+var DefaultGzipPool, _ = NewGzipPool(gzip.DefaultCompression)
+
+// GzipPool reuses gzip writers and readers across calls to reduce the
+// allocations, and the GC pressure, of compressing and decompressing payloads
+// on high throughput pipelines. It is safe for concurrent use, each writer or
+// reader is used by a single call at a time and reset between uses.
+type GzipPool struct {
+	level   int
+	writers sync.Pool
+	readers sync.Pool
+}
+
+// NewGzipPool creates a GzipPool whose writers use the given compression
+// level, see compress/gzip for the supported levels.
+func NewGzipPool(level int) (*GzipPool, error) {
+	// Validate the level upfront, so GetWriter does not have to return an error.
+	if _, err := gzip.NewWriterLevel(ioutil.Discard, level); err != nil {
+		return nil, err
+	}
+	return &GzipPool{level: level}, nil
+}
+
+// GetWriter returns a gzip writer writing to w. The writer must be closed to
+// flush the compressed data, then returned to the pool with PutWriter.
+func (p *GzipPool) GetWriter(w io.Writer) *gzip.Writer {
+	if gw, ok := p.writers.Get().(*gzip.Writer); ok {
+		gw.Reset(w)
+		return gw
+	}
+	// The level was validated by NewGzipPool.
+	gw, _ := gzip.NewWriterLevel(w, p.level)
+	return gw
+}
+
+// PutWriter returns a writer obtained from GetWriter to the pool. The writer
+// must not be used after this call.
+func (p *GzipPool) PutWriter(gw *gzip.Writer) {
+	// Drop the reference to the destination until the writer is reused.
+	gw.Reset(ioutil.Discard)
+	p.writers.Put(gw)
+}
+
+// GetReader returns a gzip reader decompressing r. It returns an error if the
+// gzip header of r cannot be read. The reader must be returned to the pool
+// with PutReader once done.
+func (p *GzipPool) GetReader(r io.Reader) (*gzip.Reader, error) {
+	if gr, ok := p.readers.Get().(*gzip.Reader); ok {
+		if err := gr.Reset(r); err != nil {
+			p.readers.Put(gr)
+			return nil, err
+		}
+		return gr, nil
+	}
+	return gzip.NewReader(r)
+}
+
+// PutReader returns a reader obtained from GetReader to the pool. The reader
+// must not be used after this call.
+func (p *GzipPool) PutReader(gr *gzip.Reader) {
+	_ = gr.Close()
+	p.readers.Put(gr)
+}
+
+// Compress writes the gzip compressed data to dst.
+func (p *GzipPool) Compress(dst io.Writer, data []byte) error {
+	gw := p.GetWriter(dst)
+	defer p.PutWriter(gw)
+	if _, err := gw.Write(data); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// Decompress returns the decompressed content of the gzip compressed src.
+func (p *GzipPool) Decompress(src io.Reader) ([]byte, error) {
+	gr, err := p.GetReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer p.PutReader(gr)
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(gr); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGzipPoolInvalidLevel(t *testing.T) {
+	p, err := NewGzipPool(42)
+	assert.Error(t, err)
+	assert.Nil(t, p)
+}
+
+func TestGzipPoolRoundTrip(t *testing.T) {
+	for _, level := range []int{gzip.NoCompression, gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression} {
+		p, err := NewGzipPool(level)
+		require.NoError(t, err)
+
+		payload := []byte(strings.Repeat("round trip ", 100))
+		var buf bytes.Buffer
+		require.NoError(t, p.Compress(&buf, payload))
+
+		// The output is a regular gzip stream.
+		gr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		got, err := ioutil.ReadAll(gr)
+		require.NoError(t, err)
+		assert.Equal(t, payload, got)
+
+		got, err = p.Decompress(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, payload, got)
+	}
+}
+
+func TestGzipPoolInvalidInput(t *testing.T) {
+	_, err := DefaultGzipPool.Decompress(strings.NewReader("not gzip"))
+	assert.Error(t, err)
+
+	// The pool keeps working after a failure.
+	var buf bytes.Buffer
+	require.NoError(t, DefaultGzipPool.Compress(&buf, []byte("ok")))
+	got, err := DefaultGzipPool.Decompress(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ok"), got)
+}
+
+func TestGzipPoolConcurrentUse(t *testing.T) {
+	const goroutines = 16
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				// Payloads differ per call, so any state shared between calls
+				// through a pooled writer or reader shows up as a mismatch.
+				payload := []byte(strings.Repeat(fmt.Sprintf("goroutine %d iteration %d;", g, i), i%50+1))
+				var buf bytes.Buffer
+				if err := DefaultGzipPool.Compress(&buf, payload); err != nil {
+					errs <- err
+					return
+				}
+				got, err := DefaultGzipPool.Decompress(&buf)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(payload, got) {
+					errs <- fmt.Errorf("goroutine %d iteration %d: corrupted payload %q", g, i, got)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+var benchPayload = []byte(strings.Repeat(`{"traceId":"0123456789abcdef","name":"span"},`, 200))
+
+func BenchmarkGzipCompress(b *testing.B) {
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			if err := DefaultGzipPool.Compress(&buf, benchPayload); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			gw := gzip.NewWriter(&buf)
+			if _, err := gw.Write(benchPayload); err != nil {
+				b.Fatal(err)
+			}
+			if err := gw.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGzipDecompress(b *testing.B) {
+	var compressed bytes.Buffer
+	if err := DefaultGzipPool.Compress(&compressed, benchPayload); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			gr, err := DefaultGzipPool.GetReader(bytes.NewReader(compressed.Bytes()))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := ioutil.ReadAll(gr); err != nil {
+				b.Fatal(err)
+			}
+			DefaultGzipPool.PutReader(gr)
+		}
+	})
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			gr, err := gzip.NewReader(bytes.NewReader(compressed.Bytes()))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := ioutil.ReadAll(gr); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
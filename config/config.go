@@ -61,6 +61,7 @@ const (
 	errUnmarshalError
 	errMissingReceivers
 	errMissingExporters
+	errInvalidReceiverConfig
 )
 
 type configError struct {
@@ -350,6 +351,15 @@ func loadReceivers(v *viper.Viper, factories map[string]receiver.Factory) (confi
 			}
 		}
 
+		if validator, ok := receiverCfg.(configmodels.Validator); ok {
+			if err = validator.Validate(); err != nil {
+				return nil, &configError{
+					code: errInvalidReceiverConfig,
+					msg:  fmt.Sprintf("invalid settings for receiver %q: %v", fullName, err),
+				}
+			}
+		}
+
 		if receivers[fullName] != nil {
 			return nil, &configError{
 				code: errDuplicateReceiverName,
@@ -56,6 +56,13 @@ type Receiver interface {
 	SetType(typeStr string)
 }
 
+// Validator is optionally implemented by the configuration of a receiver to
+// check the settings that can't be verified while decoding them. A validation
+// error fails the loading of the configuration.
+type Validator interface {
+	Validate() error
+}
+
 // Receivers is a map of names to Receivers.
 type Receivers map[string]Receiver
 
@@ -197,6 +204,16 @@ type ExporterSettings struct {
 	TypeVal  string `mapstructure:"-"`
 	NameVal  string `mapstructure:"-"`
 	Disabled bool   `mapstructure:"disabled"`
+
+	// MaxConcurrentRequests is the maximum number of requests the exporter sends
+	// concurrently, 0 means no limit. Only honored by the exporters built with the
+	// exporterhelper package.
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests"`
+
+	// ConcurrencyPolicy is what happens to the requests exceeding MaxConcurrentRequests:
+	// "queue" (the default) waits for a request to complete, "reject" fails them with
+	// a retriable error.
+	ConcurrencyPolicy string `mapstructure:"concurrency_policy"`
 }
 
 var _ Exporter = (*ExporterSettings)(nil)
@@ -226,6 +243,12 @@ func (es *ExporterSettings) IsEnabled() bool {
 	return !es.Disabled
 }
 
+// ConcurrencyLimit gets the maximum number of concurrent requests of the exporter
+// and the policy applied to the requests exceeding it.
+func (es *ExporterSettings) ConcurrencyLimit() (maxRequests int, policy string) {
+	return es.MaxConcurrentRequests, es.ConcurrencyPolicy
+}
+
 // ProcessorSettings defines common settings for a processor configuration.
 // Specific processors can embed this struct and extend it with more fields if needed.
 type ProcessorSettings struct {
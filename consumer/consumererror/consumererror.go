@@ -17,12 +17,23 @@
 // error type/instance.
 package consumererror
 
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
 // permanent is an error that will be always returned if its source
 // receives the same inputs.
 type permanent struct {
 	error
 }
 
+// Unwrap returns the wrapped error.
+func (p permanent) Unwrap() error {
+	return p.error
+}
+
 // Permanent wraps an error to indicate that it is a permanent error, i.e.: an
 // error that will be always returned if its source receives the same inputs.
 func Permanent(err error) error {
@@ -31,11 +42,42 @@ func Permanent(err error) error {
 
 // IsPermanent checks if an error was wrapped with the Permanent function, that
 // is used to indicate that a given error will always be returned in the case
-// that its sources receives the same input.
+// that its sources receives the same input. The errors wrapping it, i.e. those
+// with an Unwrap() error method, are permanent too.
 func IsPermanent(err error) bool {
-	if err != nil {
-		_, isPermanent := err.(permanent)
-		return isPermanent
+	for err != nil {
+		if _, isPermanent := err.(permanent); isPermanent {
+			return true
+		}
+		wrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = wrapper.Unwrap()
+	}
+	return false
+}
+
+// IsPermanentHTTPStatus returns true if a request failing with the given HTTP
+// status code fails again when retried: the 4xx client errors, but for the
+// request timeout and too many requests ones. The 5xx server errors, e.g. 503
+// service unavailable, are transient.
+func IsPermanentHTTPStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return code >= http.StatusBadRequest && code < http.StatusInternalServerError
+}
+
+// IsPermanentGRPCCode returns true if a call failing with the given gRPC code
+// fails again when retried, e.g. because its arguments are invalid. The codes
+// reporting an overloaded or unavailable server are transient.
+func IsPermanentGRPCCode(code codes.Code) bool {
+	switch code {
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.FailedPrecondition, codes.OutOfRange, codes.Unimplemented, codes.Unauthenticated:
+		return true
 	}
 	return false
 }
@@ -16,9 +16,11 @@ package consumererror
 
 import (
 	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
 )
 
 func TestPermanent(t *testing.T) {
@@ -33,3 +35,45 @@ func TestIsPermanent_NilError(t *testing.T) {
 	var err error
 	require.False(t, IsPermanent(err))
 }
+
+// wrappedError wraps an error the way fmt.Errorf does with the %w verb.
+type wrappedError struct {
+	msg string
+	err error
+}
+
+func (w wrappedError) Error() string {
+	return w.msg + ": " + w.err.Error()
+}
+
+func (w wrappedError) Unwrap() error {
+	return w.err
+}
+
+func TestIsPermanent_Wrapped(t *testing.T) {
+	err := wrappedError{msg: "export failed", err: Permanent(errors.New("bad data"))}
+	require.True(t, IsPermanent(err))
+	require.True(t, IsPermanent(wrappedError{msg: "again", err: err}))
+
+	err = wrappedError{msg: "export failed", err: errors.New("connection refused")}
+	require.False(t, IsPermanent(err))
+}
+
+func TestIsPermanentHTTPStatus(t *testing.T) {
+	for _, code := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusRequestEntityTooLarge} {
+		require.True(t, IsPermanentHTTPStatus(code), "code %d", code)
+	}
+	for _, code := range []int{http.StatusOK, http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable} {
+		require.False(t, IsPermanentHTTPStatus(code), "code %d", code)
+	}
+}
+
+func TestIsPermanentGRPCCode(t *testing.T) {
+	for _, code := range []codes.Code{codes.InvalidArgument, codes.PermissionDenied, codes.Unimplemented} {
+		require.True(t, IsPermanentGRPCCode(code), "code %v", code)
+	}
+	for _, code := range []codes.Code{codes.OK, codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Internal} {
+		require.False(t, IsPermanentGRPCCode(code), "code %v", code)
+	}
+}
@@ -18,6 +18,7 @@ package defaults
 import (
 	"github.com/open-telemetry/opentelemetry-service/config"
 	"github.com/open-telemetry/opentelemetry-service/exporter"
+	"github.com/open-telemetry/opentelemetry-service/exporter/googlecloudexporter"
 	"github.com/open-telemetry/opentelemetry-service/exporter/jaeger/jaegergrpcexporter"
 	"github.com/open-telemetry/opentelemetry-service/exporter/jaeger/jaegerthrifthttpexporter"
 	"github.com/open-telemetry/opentelemetry-service/exporter/loggingexporter"
@@ -30,16 +31,62 @@ import (
 	"github.com/open-telemetry/opentelemetry-service/extension/zpagesextension"
 	"github.com/open-telemetry/opentelemetry-service/oterr"
 	"github.com/open-telemetry/opentelemetry-service/processor"
+	"github.com/open-telemetry/opentelemetry-service/processor/anomalyprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/attributesprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/baggageprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/bucketboundsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/burnrateprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/collectorhostprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/collectorregionprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/conditionalattributesprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/dropsummaryprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/exemplarsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/failoverprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/groupbyresourceprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/heartbeatprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/hexkeyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/instanceidprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/instancelabelprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/intcoercionprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/labelcaseprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/labelconsistencyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/labelhashprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/loadbalancingprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/maxpayloadprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/mergeprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/metriccatalogprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/metrictyperouterprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/mindurationprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/monotonicprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/nameescapingprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/nodebatcherprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/percentilesprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/probabilisticsamplerprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/queuedprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/rateprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/requiredidentityprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/requiredlabelsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/resourcededupprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/resourceenrichmentprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/resourceprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/servicegraphprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/sortlabelsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/spanstatusprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/stalenessprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/tailsamplingprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/totalsuffixprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/tracesplitprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/typeconsistencyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/unitsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/valuefilterprocessor"
 	"github.com/open-telemetry/opentelemetry-service/receiver"
+	"github.com/open-telemetry/opentelemetry-service/receiver/collectdreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/jaegerreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/opencensusreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/prometheusreceiver"
+	"github.com/open-telemetry/opentelemetry-service/receiver/selfmetricsreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/vmmetricsreceiver"
+	"github.com/open-telemetry/opentelemetry-service/receiver/wavefrontreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/zipkinreceiver"
 )
 
@@ -66,6 +113,9 @@ func Components() (
 		&prometheusreceiver.Factory{},
 		&opencensusreceiver.Factory{},
 		&vmmetricsreceiver.Factory{},
+		&collectdreceiver.Factory{},
+		&wavefrontreceiver.Factory{},
+		&selfmetricsreceiver.Factory{},
 	)
 	if err != nil {
 		errs = append(errs, err)
@@ -78,6 +128,7 @@ func Components() (
 		&zipkinexporter.Factory{},
 		&jaegergrpcexporter.Factory{},
 		&jaegerthrifthttpexporter.Factory{},
+		&googlecloudexporter.Factory{},
 	)
 	if err != nil {
 		errs = append(errs, err)
@@ -89,6 +140,49 @@ func Components() (
 		&nodebatcherprocessor.Factory{},
 		&tailsamplingprocessor.Factory{},
 		&probabilisticsamplerprocessor.Factory{},
+		&bucketboundsprocessor.Factory{},
+		&typeconsistencyprocessor.Factory{},
+		&exemplarsprocessor.Factory{},
+		&monotonicprocessor.Factory{},
+		&resourceenrichmentprocessor.Factory{},
+		&rateprocessor.Factory{},
+		&unitsprocessor.Factory{},
+		&groupbyresourceprocessor.Factory{},
+		&valuefilterprocessor.Factory{},
+		&resourceprocessor.Factory{},
+		&mindurationprocessor.Factory{},
+		&labelhashprocessor.Factory{},
+		&failoverprocessor.Factory{},
+		&tracesplitprocessor.Factory{},
+		&instanceidprocessor.Factory{},
+		&collectorhostprocessor.Factory{},
+		&requiredlabelsprocessor.Factory{},
+		&totalsuffixprocessor.Factory{},
+		&servicegraphprocessor.Factory{},
+		&maxpayloadprocessor.Factory{},
+		&heartbeatprocessor.Factory{},
+		&labelcaseprocessor.Factory{},
+		&metriccatalogprocessor.Factory{},
+		&percentilesprocessor.Factory{},
+		&metrictyperouterprocessor.Factory{},
+		&baggageprocessor.Factory{},
+		&anomalyprocessor.Factory{},
+		&intcoercionprocessor.Factory{},
+		&mergeprocessor.Factory{},
+		&instancelabelprocessor.Factory{},
+		&hexkeyprocessor.Factory{},
+		&collectorregionprocessor.Factory{},
+		&burnrateprocessor.Factory{},
+		&sortlabelsprocessor.Factory{},
+		&resourcededupprocessor.Factory{},
+		&loadbalancingprocessor.Factory{},
+		&conditionalattributesprocessor.Factory{},
+		&nameescapingprocessor.Factory{},
+		&requiredidentityprocessor.Factory{},
+		&spanstatusprocessor.Factory{},
+		&stalenessprocessor.Factory{},
+		&dropsummaryprocessor.Factory{},
+		&labelconsistencyprocessor.Factory{},
 	)
 	if err != nil {
 		errs = append(errs, err)
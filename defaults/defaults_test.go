@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/open-telemetry/opentelemetry-service/exporter"
+	"github.com/open-telemetry/opentelemetry-service/exporter/googlecloudexporter"
 	"github.com/open-telemetry/opentelemetry-service/exporter/jaeger/jaegergrpcexporter"
 	"github.com/open-telemetry/opentelemetry-service/exporter/jaeger/jaegerthrifthttpexporter"
 	"github.com/open-telemetry/opentelemetry-service/exporter/loggingexporter"
@@ -34,16 +35,62 @@ import (
 	"github.com/open-telemetry/opentelemetry-service/extension/pprofextension"
 	"github.com/open-telemetry/opentelemetry-service/extension/zpagesextension"
 	"github.com/open-telemetry/opentelemetry-service/processor"
+	"github.com/open-telemetry/opentelemetry-service/processor/anomalyprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/attributesprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/baggageprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/bucketboundsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/burnrateprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/collectorhostprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/collectorregionprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/conditionalattributesprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/dropsummaryprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/exemplarsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/failoverprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/groupbyresourceprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/heartbeatprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/hexkeyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/instanceidprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/instancelabelprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/intcoercionprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/labelcaseprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/labelconsistencyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/labelhashprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/loadbalancingprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/maxpayloadprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/mergeprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/metriccatalogprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/metrictyperouterprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/mindurationprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/monotonicprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/nameescapingprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/nodebatcherprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/percentilesprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/probabilisticsamplerprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/queuedprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/rateprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/requiredidentityprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/requiredlabelsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/resourcededupprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/resourceenrichmentprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/resourceprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/servicegraphprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/sortlabelsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/spanstatusprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/stalenessprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/tailsamplingprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/totalsuffixprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/tracesplitprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/typeconsistencyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/unitsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/valuefilterprocessor"
 	"github.com/open-telemetry/opentelemetry-service/receiver"
+	"github.com/open-telemetry/opentelemetry-service/receiver/collectdreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/jaegerreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/opencensusreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/prometheusreceiver"
+	"github.com/open-telemetry/opentelemetry-service/receiver/selfmetricsreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/vmmetricsreceiver"
+	"github.com/open-telemetry/opentelemetry-service/receiver/wavefrontreceiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/zipkinreceiver"
 )
 
@@ -54,18 +101,64 @@ func TestDefaultComponents(t *testing.T) {
 		"zpages":       &zpagesextension.Factory{},
 	}
 	expectedReceivers := map[string]receiver.Factory{
-		"jaeger":     &jaegerreceiver.Factory{},
-		"zipkin":     &zipkinreceiver.Factory{},
-		"prometheus": &prometheusreceiver.Factory{},
-		"opencensus": &opencensusreceiver.Factory{},
-		"vmmetrics":  &vmmetricsreceiver.Factory{},
+		"jaeger":      &jaegerreceiver.Factory{},
+		"zipkin":      &zipkinreceiver.Factory{},
+		"prometheus":  &prometheusreceiver.Factory{},
+		"opencensus":  &opencensusreceiver.Factory{},
+		"vmmetrics":   &vmmetricsreceiver.Factory{},
+		"collectd":    &collectdreceiver.Factory{},
+		"wavefront":   &wavefrontreceiver.Factory{},
+		"selfmetrics": &selfmetricsreceiver.Factory{},
 	}
 	expectedProcessors := map[string]processor.Factory{
-		"attributes":            &attributesprocessor.Factory{},
-		"queued_retry":          &queuedprocessor.Factory{},
-		"batch":                 &nodebatcherprocessor.Factory{},
-		"tail_sampling":         &tailsamplingprocessor.Factory{},
-		"probabilistic_sampler": &probabilisticsamplerprocessor.Factory{},
+		"attributes":             &attributesprocessor.Factory{},
+		"queued_retry":           &queuedprocessor.Factory{},
+		"batch":                  &nodebatcherprocessor.Factory{},
+		"tail_sampling":          &tailsamplingprocessor.Factory{},
+		"probabilistic_sampler":  &probabilisticsamplerprocessor.Factory{},
+		"bucket_bounds":          &bucketboundsprocessor.Factory{},
+		"type_consistency":       &typeconsistencyprocessor.Factory{},
+		"exemplars":              &exemplarsprocessor.Factory{},
+		"monotonic":              &monotonicprocessor.Factory{},
+		"resource_enrichment":    &resourceenrichmentprocessor.Factory{},
+		"rate":                   &rateprocessor.Factory{},
+		"units":                  &unitsprocessor.Factory{},
+		"group_by_resource":      &groupbyresourceprocessor.Factory{},
+		"value_filter":           &valuefilterprocessor.Factory{},
+		"resource":               &resourceprocessor.Factory{},
+		"min_duration":           &mindurationprocessor.Factory{},
+		"label_hash":             &labelhashprocessor.Factory{},
+		"failover":               &failoverprocessor.Factory{},
+		"trace_split":            &tracesplitprocessor.Factory{},
+		"instance_id":            &instanceidprocessor.Factory{},
+		"collector_host":         &collectorhostprocessor.Factory{},
+		"required_labels":        &requiredlabelsprocessor.Factory{},
+		"total_suffix":           &totalsuffixprocessor.Factory{},
+		"service_graph":          &servicegraphprocessor.Factory{},
+		"max_payload":            &maxpayloadprocessor.Factory{},
+		"heartbeat":              &heartbeatprocessor.Factory{},
+		"label_case":             &labelcaseprocessor.Factory{},
+		"metric_catalog":         &metriccatalogprocessor.Factory{},
+		"percentiles":            &percentilesprocessor.Factory{},
+		"metric_type_router":     &metrictyperouterprocessor.Factory{},
+		"baggage":                &baggageprocessor.Factory{},
+		"anomaly":                &anomalyprocessor.Factory{},
+		"int_coercion":           &intcoercionprocessor.Factory{},
+		"merge":                  &mergeprocessor.Factory{},
+		"instance_label":         &instancelabelprocessor.Factory{},
+		"hex_key":                &hexkeyprocessor.Factory{},
+		"collector_region":       &collectorregionprocessor.Factory{},
+		"burn_rate":              &burnrateprocessor.Factory{},
+		"sort_labels":            &sortlabelsprocessor.Factory{},
+		"resource_dedup":         &resourcededupprocessor.Factory{},
+		"load_balancing":         &loadbalancingprocessor.Factory{},
+		"conditional_attributes": &conditionalattributesprocessor.Factory{},
+		"name_escaping":          &nameescapingprocessor.Factory{},
+		"required_identity":      &requiredidentityprocessor.Factory{},
+		"span_status":            &spanstatusprocessor.Factory{},
+		"staleness":              &stalenessprocessor.Factory{},
+		"drop_summary":           &dropsummaryprocessor.Factory{},
+		"label_consistency":      &labelconsistencyprocessor.Factory{},
 	}
 	expectedExporters := map[string]exporter.Factory{
 		"opencensus":         &opencensusexporter.Factory{},
@@ -74,6 +167,7 @@ func TestDefaultComponents(t *testing.T) {
 		"zipkin":             &zipkinexporter.Factory{},
 		"jaeger_grpc":        &jaegergrpcexporter.Factory{},
 		"jaeger_thrift_http": &jaegerthrifthttpexporter.Factory{},
+		"googlecloud":        &googlecloudexporter.Factory{},
 	}
 
 	factories, err := Components()
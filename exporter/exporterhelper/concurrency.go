@@ -0,0 +1,144 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+)
+
+// ConcurrencyPolicy is what happens to the requests exceeding the max concurrent requests of an exporter.
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencyPolicyQueue makes the requests exceeding the limit wait for a request to complete.
+	ConcurrencyPolicyQueue ConcurrencyPolicy = "queue"
+	// ConcurrencyPolicyReject fails the requests exceeding the limit with ErrTooManyRequests.
+	ConcurrencyPolicyReject ConcurrencyPolicy = "reject"
+)
+
+// ErrTooManyRequests is returned for the requests rejected because the exporter max concurrent requests was
+// exceeded. It is not permanent: the data can be sent again once the exporter caught up.
+var ErrTooManyRequests = errors.New("too many concurrent requests")
+
+// concurrencyLimitedConfig is implemented by the configs embedding configmodels.ExporterSettings.
+type concurrencyLimitedConfig interface {
+	ConcurrencyLimit() (maxRequests int, policy string)
+}
+
+var _ concurrencyLimitedConfig = (*configmodels.ExporterSettings)(nil)
+
+// concurrencyLimiter bounds the number of requests in flight with a semaphore.
+type concurrencyLimiter struct {
+	sem    chan struct{}
+	policy ConcurrencyPolicy
+
+	mu     sync.Mutex
+	active int
+	queued int
+}
+
+// newConcurrencyLimiter returns the limiter configured by the exporter config, nil if it sets no limit.
+func newConcurrencyLimiter(config configmodels.Exporter) (*concurrencyLimiter, error) {
+	cfg, ok := config.(concurrencyLimitedConfig)
+	if !ok {
+		return nil, nil
+	}
+	maxRequests, policy := cfg.ConcurrencyLimit()
+	if maxRequests < 0 {
+		return nil, fmt.Errorf("max_concurrent_requests must not be negative, got %d", maxRequests)
+	}
+	switch ConcurrencyPolicy(policy) {
+	case "":
+		policy = string(ConcurrencyPolicyQueue)
+	case ConcurrencyPolicyQueue, ConcurrencyPolicyReject:
+	default:
+		return nil, fmt.Errorf("unknown concurrency_policy %q, must be %q or %q",
+			policy, ConcurrencyPolicyQueue, ConcurrencyPolicyReject)
+	}
+	if maxRequests == 0 {
+		return nil, nil
+	}
+	return &concurrencyLimiter{
+		sem:    make(chan struct{}, maxRequests),
+		policy: ConcurrencyPolicy(policy),
+	}, nil
+}
+
+// acquire takes a slot for a request, waiting for one according to the policy. It must be followed by a release
+// if it returns no error.
+func (cl *concurrencyLimiter) acquire(ctx context.Context) error {
+	select {
+	case cl.sem <- struct{}{}:
+		cl.update(ctx, 1, 0)
+		return nil
+	default:
+	}
+
+	if cl.policy == ConcurrencyPolicyReject {
+		observability.RecordRejectedRequestForExporter(ctx)
+		return ErrTooManyRequests
+	}
+
+	cl.update(ctx, 0, 1)
+	select {
+	case cl.sem <- struct{}{}:
+		cl.update(ctx, 1, -1)
+		return nil
+	case <-ctx.Done():
+		cl.update(ctx, 0, -1)
+		return ctx.Err()
+	}
+}
+
+func (cl *concurrencyLimiter) release(ctx context.Context) {
+	<-cl.sem
+	cl.update(ctx, -1, 0)
+}
+
+func (cl *concurrencyLimiter) update(ctx context.Context, activeDelta int, queuedDelta int) {
+	cl.mu.Lock()
+	cl.active += activeDelta
+	cl.queued += queuedDelta
+	active, queued := cl.active, cl.queued
+	cl.mu.Unlock()
+	observability.RecordConcurrencyForExporter(ctx, active, queued)
+}
+
+func pushTraceDataWithConcurrencyLimit(next PushTraceData, cl *concurrencyLimiter) PushTraceData {
+	return func(ctx context.Context, td consumerdata.TraceData) (int, error) {
+		if err := cl.acquire(ctx); err != nil {
+			return len(td.Spans), err
+		}
+		defer cl.release(ctx)
+		return next(ctx, td)
+	}
+}
+
+func pushMetricsDataWithConcurrencyLimit(next PushMetricsData, cl *concurrencyLimiter) PushMetricsData {
+	return func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		if err := cl.acquire(ctx); err != nil {
+			return NumTimeSeries(md), err
+		}
+		defer cl.release(ctx)
+		return next(ctx, md)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+)
+
+const fakeLimitedExporterName = "fake_limited_exporter"
+
+func limitedExporterConfig(maxRequests int, policy ConcurrencyPolicy) *configmodels.ExporterSettings {
+	return &configmodels.ExporterSettings{
+		TypeVal:               fakeTraceExporterType,
+		NameVal:               fakeLimitedExporterName,
+		MaxConcurrentRequests: maxRequests,
+		ConcurrencyPolicy:     string(policy),
+	}
+}
+
+func TestConcurrencyLimit_InvalidConfig(t *testing.T) {
+	te, err := NewTraceExporter(limitedExporterConfig(-1, ""), newPushTraceData(0, nil))
+	assert.Nil(t, te)
+	assert.Error(t, err)
+
+	me, err := NewMetricsExporter(limitedExporterConfig(2, "drop"), newPushMetricsData(0, nil))
+	assert.Nil(t, me)
+	assert.Error(t, err)
+}
+
+func TestConcurrencyLimit_NeverExceeded(t *testing.T) {
+	const maxRequests = 3
+	var inFlight, maxInFlight int64
+	push := func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		n := atomic.AddInt64(&inFlight, 1)
+		for {
+			seen := atomic.LoadInt64(&maxInFlight)
+			if n <= seen || atomic.CompareAndSwapInt64(&maxInFlight, seen, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt64(&inFlight, -1)
+		return 0, nil
+	}
+	me, err := NewMetricsExporter(limitedExporterConfig(maxRequests, ConcurrencyPolicyQueue), push)
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{{Timeseries: make([]*metricspb.TimeSeries, 1)}}}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, me.ConsumeMetricsData(context.Background(), md))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, atomic.LoadInt64(&maxInFlight) <= maxRequests, "max in flight %d", maxInFlight)
+	assert.Equal(t, int64(maxRequests), atomic.LoadInt64(&maxInFlight))
+}
+
+func TestConcurrencyLimit_Queue(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	unblock := make(chan struct{})
+	push := func(ctx context.Context, td consumerdata.TraceData) (int, error) {
+		<-unblock
+		return 0, nil
+	}
+	te, err := NewTraceExporter(limitedExporterConfig(1, ""), push)
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{Spans: make([]*tracepb.Span, 2)}
+	errs := make(chan error, 2)
+	go func() { errs <- te.ConsumeTraceData(context.Background(), td) }()
+	assert.Eventually(t, func() bool {
+		return observabilitytest.CheckValueViewExporterActiveRequests(fakeLimitedExporterName, 1) == nil
+	}, time.Second, time.Millisecond)
+	go func() { errs <- te.ConsumeTraceData(context.Background(), td) }()
+	assert.Eventually(t, func() bool {
+		return observabilitytest.CheckValueViewExporterQueuedRequests(fakeLimitedExporterName, 1) == nil
+	}, time.Second, time.Millisecond)
+
+	// A queued request gives up when its context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, te.ConsumeTraceData(ctx, td))
+
+	close(unblock)
+	assert.NoError(t, <-errs)
+	assert.NoError(t, <-errs)
+	require.NoError(t, observabilitytest.CheckValueViewExporterActiveRequests(fakeLimitedExporterName, 0))
+	require.NoError(t, observabilitytest.CheckValueViewExporterQueuedRequests(fakeLimitedExporterName, 0))
+}
+
+func TestConcurrencyLimit_Reject(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	unblock := make(chan struct{})
+	push := func(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+		<-unblock
+		return 0, nil
+	}
+	me, err := NewMetricsExporter(limitedExporterConfig(2, ConcurrencyPolicyReject), push, WithMetrics(true))
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{{Timeseries: make([]*metricspb.TimeSeries, 3)}}}
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- me.ConsumeMetricsData(context.Background(), md) }()
+	}
+	assert.Eventually(t, func() bool {
+		return observabilitytest.CheckValueViewExporterActiveRequests(fakeLimitedExporterName, 2) == nil
+	}, time.Second, time.Millisecond)
+
+	ctx := observability.ContextWithReceiverName(context.Background(), fakeMetricsReceiverName)
+	assert.Equal(t, ErrTooManyRequests, me.ConsumeMetricsData(ctx, md))
+	require.NoError(t, observabilitytest.CheckValueViewExporterRejectedRequests(fakeLimitedExporterName, 1))
+	require.NoError(t, observabilitytest.CheckValueViewExporterDroppedTimeSeries(fakeMetricsReceiverName, fakeLimitedExporterName, 3))
+
+	close(unblock)
+	assert.NoError(t, <-errs)
+	assert.NoError(t, <-errs)
+	assert.NoError(t, me.ConsumeMetricsData(ctx, md))
+}
@@ -60,6 +60,14 @@ func NewMetricsExporter(config configmodels.Exporter, pushMetricsData PushMetric
 		return nil, errNilPushMetricsData
 	}
 
+	limiter, err := newConcurrencyLimiter(config)
+	if err != nil {
+		return nil, err
+	}
+	if limiter != nil {
+		pushMetricsData = pushMetricsDataWithConcurrencyLimit(pushMetricsData, limiter)
+	}
+
 	opts := newExporterOptions(options...)
 	if opts.recordMetrics {
 		pushMetricsData = pushMetricsDataWithMetrics(pushMetricsData)
@@ -60,6 +60,14 @@ func NewTraceExporter(config configmodels.Exporter, pushTraceData PushTraceData,
 		return nil, errNilPushTraceData
 	}
 
+	limiter, err := newConcurrencyLimiter(config)
+	if err != nil {
+		return nil, err
+	}
+	if limiter != nil {
+		pushTraceData = pushTraceDataWithConcurrencyLimit(pushTraceData, limiter)
+	}
+
 	opts := newExporterOptions(options...)
 	if opts.recordMetrics {
 		pushTraceData = pushTraceDataWithMetrics(pushTraceData)
@@ -0,0 +1,40 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googlecloudexporter
+
+import (
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config defines configuration settings for the Google Cloud Monitoring
+// exporter.
+type Config struct {
+	configmodels.ExporterSettings `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct.
+
+	// ProjectID is the Google Cloud project the time series are written to.
+	ProjectID string `mapstructure:"project"`
+
+	// MetricPrefix is prepended to the metric names to form the metric types.
+	MetricPrefix string `mapstructure:"metric_prefix"`
+
+	// Endpoint overrides the base URL of the Cloud Monitoring API, e.g. to go
+	// through a private endpoint.
+	Endpoint string `mapstructure:"endpoint"`
+
+	// MaxTimeSeriesPerRequest is the maximum number of time series written by
+	// a single request, the batches holding more are split in several
+	// requests. The API accepts at most 200.
+	MaxTimeSeriesPerRequest int `mapstructure:"max_timeseries_per_request"`
+}
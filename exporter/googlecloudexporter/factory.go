@@ -0,0 +1,90 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googlecloudexporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/option"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configerror"
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter"
+)
+
+const (
+	// The value of "type" key in configuration.
+	typeStr = "googlecloud"
+
+	defaultMetricPrefix = "custom.googleapis.com/opencensus/"
+
+	// maxTimeSeriesPerRequest is the limit of the CreateTimeSeries API.
+	maxTimeSeriesPerRequest = 200
+)
+
+// Factory is the factory for the Google Cloud Monitoring exporter.
+type Factory struct {
+}
+
+// Type gets the type of the Exporter config created by this factory.
+func (f *Factory) Type() string {
+	return typeStr
+}
+
+// CreateDefaultConfig creates the default configuration for exporter.
+func (f *Factory) CreateDefaultConfig() configmodels.Exporter {
+	return &Config{
+		ExporterSettings: configmodels.ExporterSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		MetricPrefix:            defaultMetricPrefix,
+		MaxTimeSeriesPerRequest: maxTimeSeriesPerRequest,
+	}
+}
+
+// CreateTraceExporter creates a trace exporter based on this config.
+func (f *Factory) CreateTraceExporter(logger *zap.Logger, config configmodels.Exporter) (exporter.TraceExporter, error) {
+	return nil, configerror.ErrDataTypeIsNotSupported
+}
+
+// CreateMetricsExporter creates a metrics exporter based on this config. The
+// exporter authenticates with the application default credentials.
+func (f *Factory) CreateMetricsExporter(logger *zap.Logger, config configmodels.Exporter) (exporter.MetricsExporter, error) {
+	cfg := config.(*Config)
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	var opts []option.ClientOption
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+	}
+	return newMetricsExporter(context.Background(), logger, cfg, opts...)
+}
+
+func validateConfig(cfg *Config) error {
+	if cfg.ProjectID == "" {
+		return errors.New("exporter config requires a non-empty 'project'")
+	}
+	if cfg.MaxTimeSeriesPerRequest <= 0 || cfg.MaxTimeSeriesPerRequest > maxTimeSeriesPerRequest {
+		return fmt.Errorf("'max_timeseries_per_request' must be between 1 and %d, got %d",
+			maxTimeSeriesPerRequest, cfg.MaxTimeSeriesPerRequest)
+	}
+	return nil
+}
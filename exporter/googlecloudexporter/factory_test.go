@@ -0,0 +1,58 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googlecloudexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configerror"
+)
+
+func TestCreateDefaultConfig(t *testing.T) {
+	factory := Factory{}
+	cfg := factory.CreateDefaultConfig()
+	assert.NotNil(t, cfg, "failed to create default config")
+}
+
+func TestCreateTraceExporter(t *testing.T) {
+	factory := Factory{}
+	cfg := factory.CreateDefaultConfig()
+
+	te, err := factory.CreateTraceExporter(zap.NewNop(), cfg)
+	assert.Equal(t, configerror.ErrDataTypeIsNotSupported, err)
+	assert.Nil(t, te)
+}
+
+func TestCreateMetricsExporterInvalidConfig(t *testing.T) {
+	factory := Factory{}
+
+	// The default config has no project.
+	cfg := factory.CreateDefaultConfig().(*Config)
+	me, err := factory.CreateMetricsExporter(zap.NewNop(), cfg)
+	assert.Error(t, err)
+	assert.Nil(t, me)
+
+	for _, max := range []int{0, -1, maxTimeSeriesPerRequest + 1} {
+		cfg = factory.CreateDefaultConfig().(*Config)
+		cfg.ProjectID = "my-project"
+		cfg.MaxTimeSeriesPerRequest = max
+		me, err = factory.CreateMetricsExporter(zap.NewNop(), cfg)
+		assert.Error(t, err, "max_timeseries_per_request %d", max)
+		assert.Nil(t, me)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googlecloudexporter
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+	monitoring "google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-service/exporter"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exporterhelper"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+// metricsExporter writes the metrics to Cloud Monitoring with the
+// CreateTimeSeries API.
+type metricsExporter struct {
+	logger        *zap.Logger
+	service       *monitoring.Service
+	projectName   string
+	converter     converter
+	maxPerRequest int
+}
+
+func newMetricsExporter(ctx context.Context, logger *zap.Logger, cfg *Config, opts ...option.ClientOption) (exporter.MetricsExporter, error) {
+	service, err := monitoring.NewService(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	me := &metricsExporter{
+		logger:      logger,
+		service:     service,
+		projectName: "projects/" + cfg.ProjectID,
+		converter: converter{
+			projectID:    cfg.ProjectID,
+			metricPrefix: cfg.MetricPrefix,
+		},
+		maxPerRequest: cfg.MaxTimeSeriesPerRequest,
+	}
+	return exporterhelper.NewMetricsExporter(
+		cfg,
+		me.pushMetricsData,
+		exporterhelper.WithTracing(true),
+		exporterhelper.WithMetrics(true),
+	)
+}
+
+// pushMetricsData writes the metrics in as many requests as needed. All the
+// requests are attempted, the error returned is permanent if all the failed
+// requests failed permanently, or if any request succeeded: retrying would
+// write its points again, which the API rejects as out of order.
+func (me *metricsExporter) pushMetricsData(ctx context.Context, md consumerdata.MetricsData) (int, error) {
+	var series []*monitoring.TimeSeries
+	dropped := 0
+	for _, metric := range md.Metrics {
+		converted, unsupported := me.converter.timeSeries(md.Node, metric)
+		series = append(series, converted...)
+		dropped += unsupported
+	}
+	if dropped > 0 {
+		me.logger.Debug("Dropped points not supported by Cloud Monitoring", zap.Int("points", dropped))
+	}
+
+	var errs []error
+	permanent := true
+	written := false
+	for _, chunk := range chunks(series, me.maxPerRequest) {
+		request := &monitoring.CreateTimeSeriesRequest{TimeSeries: chunk}
+		if _, err := me.service.Projects.TimeSeries.Create(me.projectName, request).Context(ctx).Do(); err != nil {
+			dropped += len(chunk)
+			errs = append(errs, err)
+			permanent = permanent && isPermanent(err)
+		} else {
+			written = true
+		}
+	}
+	if len(errs) == 0 {
+		return dropped, nil
+	}
+	err := oterr.CombineErrors(errs)
+	if permanent || written {
+		err = consumererror.Permanent(err)
+	}
+	return dropped, err
+}
+
+// chunks splits the time series in chunks of at most size time series. The
+// API rejects the requests holding the same time series twice, the points of
+// a time series go in successive chunks, in order.
+func chunks(series []*monitoring.TimeSeries, size int) [][]*monitoring.TimeSeries {
+	var chunks [][]*monitoring.TimeSeries
+	// next holds, for every time series, the index of the first chunk its
+	// next point can go in.
+	next := make(map[string]int)
+	for _, ts := range series {
+		key := seriesKey(ts)
+		i := next[key]
+		for i < len(chunks) && len(chunks[i]) >= size {
+			i++
+		}
+		if i == len(chunks) {
+			chunks = append(chunks, make([]*monitoring.TimeSeries, 0, size))
+		}
+		chunks[i] = append(chunks[i], ts)
+		next[key] = i + 1
+	}
+	return chunks
+}
+
+// isPermanent returns whether the request failed for a reason that retrying
+// won't fix. Throttling, quota and server errors are retriable.
+func isPermanent(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		// Transport errors.
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded", "quotaExceeded":
+			return false
+		}
+	}
+	return consumererror.IsPermanentHTTPStatus(apiErr.Code)
+}
@@ -0,0 +1,218 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googlecloudexporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	monitoring "google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-service/exporter"
+)
+
+// fakeMonitoring records the CreateTimeSeries requests and answers them with
+// the configured status codes in turn, 200 once they are exhausted.
+type fakeMonitoring struct {
+	mu       sync.Mutex
+	paths    []string
+	requests []*monitoring.CreateTimeSeriesRequest
+	statuses []int
+}
+
+func (fm *fakeMonitoring) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := &monitoring.CreateTimeSeriesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fm.mu.Lock()
+	fm.paths = append(fm.paths, r.URL.Path)
+	fm.requests = append(fm.requests, request)
+	status := http.StatusOK
+	if len(fm.statuses) > 0 {
+		status, fm.statuses = fm.statuses[0], fm.statuses[1:]
+	}
+	fm.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		fmt.Fprint(w, "{}")
+		return
+	}
+	fmt.Fprintf(w, `{"error": {"code": %d, "message": "%s"}}`, status, http.StatusText(status))
+}
+
+// newTestExporter returns an exporter writing to the fake API served by the
+// returned server.
+func newTestExporter(t *testing.T, fm *fakeMonitoring, maxPerRequest int) (exporter.MetricsExporter, *httptest.Server) {
+	server := httptest.NewServer(fm)
+
+	cfg := (&Factory{}).CreateDefaultConfig().(*Config)
+	cfg.ProjectID = "my-project"
+	cfg.MaxTimeSeriesPerRequest = maxPerRequest
+	me, err := newMetricsExporter(
+		context.Background(),
+		zap.NewNop(),
+		cfg,
+		option.WithEndpoint(server.URL+"/"),
+		option.WithoutAuthentication(),
+	)
+	require.NoError(t, err)
+	return me, server
+}
+
+func TestExportChunking(t *testing.T) {
+	fm := &fakeMonitoring{}
+	me, server := newTestExporter(t, fm, 2)
+	defer server.Close()
+
+	// Five series of one point and a series of three points.
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{
+		gauge("temperature", []string{"a", "b", "c", "d", "e"}, 1),
+		gauge("pressure", []string{"a"}, 3),
+	}}
+	require.NoError(t, me.ConsumeMetricsData(context.Background(), md))
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	// The last two points of pressure can't share a request with any other
+	// point left.
+	require.Len(t, fm.requests, 5)
+	total := 0
+	for i, request := range fm.requests {
+		assert.Equal(t, "/v3/projects/my-project/timeSeries", fm.paths[i])
+		assert.True(t, len(request.TimeSeries) <= 2, "request %d holds %d time series", i, len(request.TimeSeries))
+		keys := make(map[string]bool)
+		for _, ts := range request.TimeSeries {
+			key := seriesKey(ts)
+			assert.False(t, keys[key], "request %d holds %s twice", i, key)
+			keys[key] = true
+		}
+		total += len(request.TimeSeries)
+	}
+	assert.Equal(t, 8, total)
+
+	// The points of a time series are written in order.
+	var pressureTimes []string
+	for _, request := range fm.requests {
+		for _, ts := range request.TimeSeries {
+			if ts.Metric.Type == defaultMetricPrefix+"pressure" {
+				pressureTimes = append(pressureTimes, ts.Points[0].Interval.EndTime)
+			}
+		}
+	}
+	assert.Equal(t, []string{"1970-01-01T00:00:01Z", "1970-01-01T00:00:02Z", "1970-01-01T00:00:03Z"}, pressureTimes)
+}
+
+func TestChunks(t *testing.T) {
+	series := func(names ...string) []*monitoring.TimeSeries {
+		var series []*monitoring.TimeSeries
+		for _, name := range names {
+			series = append(series, &monitoring.TimeSeries{Metric: &monitoring.Metric{Type: name}})
+		}
+		return series
+	}
+	types := func(chunks [][]*monitoring.TimeSeries) [][]string {
+		var types [][]string
+		for _, chunk := range chunks {
+			var chunkTypes []string
+			for _, ts := range chunk {
+				chunkTypes = append(chunkTypes, ts.Metric.Type)
+			}
+			types = append(types, chunkTypes)
+		}
+		return types
+	}
+
+	assert.Empty(t, chunks(nil, 3))
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"d"}}, types(chunks(series("a", "b", "c", "d"), 3)))
+	// The repeated series go in the next chunks, the other series fill the
+	// room left.
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"a", "d"}, {"a"}}, types(chunks(series("a", "a", "a", "b", "c", "d"), 3)))
+}
+
+func TestExportErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		permanent bool
+	}{
+		{"throttled", []int{http.StatusTooManyRequests, http.StatusTooManyRequests}, false},
+		{"unavailable", []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}, false},
+		{"invalid", []int{http.StatusBadRequest}, true},
+		{"invalid_and_throttled", []int{http.StatusBadRequest, http.StatusTooManyRequests}, false},
+		{"partially_invalid", []int{http.StatusOK, http.StatusBadRequest}, true},
+		// Retrying would write the points of the first request again.
+		{"partially_throttled", []int{http.StatusOK, http.StatusTooManyRequests}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMonitoring{statuses: tt.statuses}
+			me, server := newTestExporter(t, fm, 1)
+			defer server.Close()
+
+			md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{gauge("temperature", []string{"a", "b"}, 1)}}
+			err := me.ConsumeMetricsData(context.Background(), md)
+			require.Error(t, err)
+			assert.Equal(t, tt.permanent, consumererror.IsPermanent(err))
+
+			// Every chunk is attempted.
+			fm.mu.Lock()
+			assert.Len(t, fm.requests, 2)
+			fm.mu.Unlock()
+		})
+	}
+}
+
+// gauge returns a gauge with a series per room, each holding the given number
+// of points one second apart.
+func gauge(name string, rooms []string, points int) *metricspb.Metric {
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Type:      metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys: []*metricspb.LabelKey{{Key: "room"}},
+		},
+	}
+	for _, room := range rooms {
+		ts := &metricspb.TimeSeries{
+			LabelValues: []*metricspb.LabelValue{{Value: room, HasValue: true}},
+		}
+		for i := 1; i <= points; i++ {
+			ts.Points = append(ts.Points, &metricspb.Point{
+				Timestamp: &timestamp.Timestamp{Seconds: int64(i)},
+				Value:     &metricspb.Point_DoubleValue{DoubleValue: float64(i)},
+			})
+		}
+		metric.Timeseries = append(metric.Timeseries, ts)
+	}
+	return metric
+}
@@ -0,0 +1,226 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googlecloudexporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"google.golang.org/api/googleapi"
+	monitoring "google.golang.org/api/monitoring/v3"
+)
+
+const (
+	// globalResource is the monitored resource the time series are written
+	// against, labeled with the project.
+	globalResource = "global"
+
+	// The labels identifying the node that reported a metric, for the series
+	// of different nodes not to collide.
+	serviceNameLabel = "service_name"
+	instanceLabel    = "instance"
+)
+
+// converter converts the OpenCensus metrics to Cloud Monitoring time series.
+type converter struct {
+	projectID    string
+	metricPrefix string
+}
+
+// timeSeries returns the time series of the points of the metric, one per
+// point as the API expects, and the number of points that could not be
+// converted: the points of summaries, which have no Cloud Monitoring
+// equivalent, and the cumulative points without a start time before their
+// timestamp, which the API rejects.
+func (c *converter) timeSeries(node *commonpb.Node, metric *metricspb.Metric) ([]*monitoring.TimeSeries, int) {
+	desc := metric.GetMetricDescriptor()
+	kind, valueType, ok := metricKind(desc.GetType())
+	if !ok {
+		return nil, numPoints(metric)
+	}
+
+	resource := &monitoring.MonitoredResource{
+		Type:   globalResource,
+		Labels: map[string]string{"project_id": c.projectID},
+	}
+	var series []*monitoring.TimeSeries
+	dropped := 0
+	for _, ts := range metric.Timeseries {
+		labels := metricLabels(node, desc.LabelKeys, ts.LabelValues)
+		for _, point := range ts.Points {
+			interval, ok := timeInterval(kind, ts.StartTimestamp, point.Timestamp)
+			if !ok {
+				dropped++
+				continue
+			}
+			value, ok := typedValue(point)
+			if !ok {
+				dropped++
+				continue
+			}
+			series = append(series, &monitoring.TimeSeries{
+				Metric: &monitoring.Metric{
+					Type:   c.metricPrefix + desc.Name,
+					Labels: labels,
+				},
+				Resource:   resource,
+				MetricKind: kind,
+				ValueType:  valueType,
+				Points:     []*monitoring.Point{{Interval: interval, Value: value}},
+			})
+		}
+	}
+	return series, dropped
+}
+
+// metricKind returns the Cloud Monitoring metric kind and value type of the
+// OpenCensus metric type, false if it has none.
+func metricKind(t metricspb.MetricDescriptor_Type) (string, string, bool) {
+	switch t {
+	case metricspb.MetricDescriptor_GAUGE_INT64:
+		return "GAUGE", "INT64", true
+	case metricspb.MetricDescriptor_GAUGE_DOUBLE:
+		return "GAUGE", "DOUBLE", true
+	case metricspb.MetricDescriptor_GAUGE_DISTRIBUTION:
+		return "GAUGE", "DISTRIBUTION", true
+	case metricspb.MetricDescriptor_CUMULATIVE_INT64:
+		return "CUMULATIVE", "INT64", true
+	case metricspb.MetricDescriptor_CUMULATIVE_DOUBLE:
+		return "CUMULATIVE", "DOUBLE", true
+	case metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION:
+		return "CUMULATIVE", "DISTRIBUTION", true
+	}
+	return "", "", false
+}
+
+// metricLabels returns the labels of the series, along with the labels
+// identifying the node unless the metric has labels with the same keys.
+func metricLabels(node *commonpb.Node, keys []*metricspb.LabelKey, values []*metricspb.LabelValue) map[string]string {
+	labels := make(map[string]string, len(keys)+2)
+	for i, key := range keys {
+		if i < len(values) && values[i].GetHasValue() {
+			labels[key.GetKey()] = values[i].Value
+		}
+	}
+	if _, ok := labels[serviceNameLabel]; !ok {
+		if name := node.GetServiceInfo().GetName(); name != "" {
+			labels[serviceNameLabel] = name
+		}
+	}
+	if _, ok := labels[instanceLabel]; !ok {
+		if host := node.GetIdentifier().GetHostName(); host != "" {
+			if port := node.GetAttributes()["port"]; port != "" {
+				host += ":" + port
+			}
+			labels[instanceLabel] = host
+		}
+	}
+	return labels
+}
+
+// timeInterval returns the interval of a point of the given kind. The
+// interval of a cumulative point must start before it ends, false is returned
+// otherwise.
+func timeInterval(kind string, start, end *timestamp.Timestamp) (*monitoring.TimeInterval, bool) {
+	endTime, err := ptypes.Timestamp(end)
+	if err != nil {
+		return nil, false
+	}
+	interval := &monitoring.TimeInterval{EndTime: formatTime(endTime)}
+	if kind != "CUMULATIVE" {
+		return interval, true
+	}
+	startTime, err := ptypes.Timestamp(start)
+	if err != nil || !startTime.Before(endTime) {
+		return nil, false
+	}
+	interval.StartTime = formatTime(startTime)
+	return interval, true
+}
+
+func typedValue(point *metricspb.Point) (*monitoring.TypedValue, bool) {
+	switch v := point.Value.(type) {
+	case *metricspb.Point_Int64Value:
+		return &monitoring.TypedValue{Int64Value: &v.Int64Value}, true
+	case *metricspb.Point_DoubleValue:
+		return &monitoring.TypedValue{DoubleValue: &v.DoubleValue}, true
+	case *metricspb.Point_DistributionValue:
+		if v.DistributionValue == nil {
+			return nil, false
+		}
+		return &monitoring.TypedValue{DistributionValue: distributionValue(v.DistributionValue)}, true
+	}
+	return nil, false
+}
+
+// distributionValue converts a histogram to a distribution with explicit
+// buckets.
+func distributionValue(d *metricspb.DistributionValue) *monitoring.Distribution {
+	dist := &monitoring.Distribution{
+		Count:                 d.Count,
+		SumOfSquaredDeviation: d.SumOfSquaredDeviation,
+	}
+	if d.Count > 0 {
+		dist.Mean = d.Sum / float64(d.Count)
+	}
+	if bounds := d.GetBucketOptions().GetExplicit().GetBounds(); len(bounds) > 0 {
+		dist.BucketOptions = &monitoring.BucketOptions{
+			ExplicitBuckets: &monitoring.Explicit{Bounds: bounds},
+		}
+	}
+	if len(d.Buckets) > 0 {
+		counts := make(googleapi.Int64s, len(d.Buckets))
+		for i, bucket := range d.Buckets {
+			counts[i] = bucket.Count
+		}
+		dist.BucketCounts = counts
+	}
+	return dist
+}
+
+// seriesKey identifies a time series by its metric type and labels, the
+// monitored resource being the same for all.
+func seriesKey(ts *monitoring.TimeSeries) string {
+	keys := make([]string, 0, len(ts.Metric.Labels))
+	for key := range ts.Metric.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(ts.Metric.Type)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\x00%s=%s", key, ts.Metric.Labels[key])
+	}
+	return b.String()
+}
+
+func numPoints(metric *metricspb.Metric) int {
+	n := 0
+	for _, ts := range metric.Timeseries {
+		n += len(ts.Points)
+	}
+	return n
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,196 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googlecloudexporter
+
+import (
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+	monitoring "google.golang.org/api/monitoring/v3"
+)
+
+var testConverter = converter{projectID: "my-project", metricPrefix: defaultMetricPrefix}
+
+func TestTimeSeriesGauge(t *testing.T) {
+	node := &commonpb.Node{
+		Identifier:  &commonpb.ProcessIdentifier{HostName: "10.0.0.1"},
+		ServiceInfo: &commonpb.ServiceInfo{Name: "api"},
+		Attributes:  map[string]string{"port": "9090"},
+	}
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "queue_length",
+			Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+			LabelKeys: []*metricspb.LabelKey{{Key: "queue"}, {Key: "shard"}},
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			LabelValues: []*metricspb.LabelValue{{Value: "jobs", HasValue: true}, {}},
+			Points: []*metricspb.Point{
+				{Timestamp: &timestamp.Timestamp{Seconds: 100}, Value: &metricspb.Point_Int64Value{Int64Value: 3}},
+				{Timestamp: &timestamp.Timestamp{Seconds: 110, Nanos: 5e8}, Value: &metricspb.Point_Int64Value{Int64Value: 4}},
+			},
+		}},
+	}
+
+	series, dropped := testConverter.timeSeries(node, metric)
+	assert.Equal(t, 0, dropped)
+	// One time series per point.
+	require.Len(t, series, 2)
+	for _, ts := range series {
+		assert.Equal(t, "custom.googleapis.com/opencensus/queue_length", ts.Metric.Type)
+		assert.Equal(t, map[string]string{
+			"queue":        "jobs",
+			"service_name": "api",
+			"instance":     "10.0.0.1:9090",
+		}, ts.Metric.Labels)
+		assert.Equal(t, &monitoring.MonitoredResource{
+			Type:   "global",
+			Labels: map[string]string{"project_id": "my-project"},
+		}, ts.Resource)
+		assert.Equal(t, "GAUGE", ts.MetricKind)
+		assert.Equal(t, "INT64", ts.ValueType)
+		require.Len(t, ts.Points, 1)
+		assert.Empty(t, ts.Points[0].Interval.StartTime)
+	}
+	assert.Equal(t, "1970-01-01T00:01:40Z", series[0].Points[0].Interval.EndTime)
+	assert.Equal(t, int64(3), *series[0].Points[0].Value.Int64Value)
+	assert.Equal(t, "1970-01-01T00:01:50.5Z", series[1].Points[0].Interval.EndTime)
+	assert.Equal(t, int64(4), *series[1].Points[0].Value.Int64Value)
+}
+
+func TestTimeSeriesNodeLabelsOverridden(t *testing.T) {
+	node := &commonpb.Node{
+		Identifier:  &commonpb.ProcessIdentifier{HostName: "10.0.0.1"},
+		ServiceInfo: &commonpb.ServiceInfo{Name: "api"},
+	}
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "up",
+			Type:      metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys: []*metricspb.LabelKey{{Key: "instance"}},
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			LabelValues: []*metricspb.LabelValue{{Value: "db:5432", HasValue: true}},
+			Points: []*metricspb.Point{
+				{Timestamp: &timestamp.Timestamp{Seconds: 100}, Value: &metricspb.Point_DoubleValue{DoubleValue: 1}},
+			},
+		}},
+	}
+
+	series, _ := testConverter.timeSeries(node, metric)
+	require.Len(t, series, 1)
+	assert.Equal(t, map[string]string{"instance": "db:5432", "service_name": "api"}, series[0].Metric.Labels)
+	assert.Equal(t, "DOUBLE", series[0].ValueType)
+	assert.Equal(t, 1.0, *series[0].Points[0].Value.DoubleValue)
+}
+
+func TestTimeSeriesCumulativeStartTime(t *testing.T) {
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name: "requests",
+			Type: metricspb.MetricDescriptor_CUMULATIVE_DOUBLE,
+		},
+		Timeseries: []*metricspb.TimeSeries{
+			{
+				StartTimestamp: &timestamp.Timestamp{Seconds: 50},
+				Points: []*metricspb.Point{
+					{Timestamp: &timestamp.Timestamp{Seconds: 100}, Value: &metricspb.Point_DoubleValue{DoubleValue: 10}},
+					// Not after the start time.
+					{Timestamp: &timestamp.Timestamp{Seconds: 50}, Value: &metricspb.Point_DoubleValue{DoubleValue: 0}},
+				},
+			},
+			{
+				// No start time.
+				Points: []*metricspb.Point{
+					{Timestamp: &timestamp.Timestamp{Seconds: 100}, Value: &metricspb.Point_DoubleValue{DoubleValue: 10}},
+				},
+			},
+		},
+	}
+
+	series, dropped := testConverter.timeSeries(nil, metric)
+	assert.Equal(t, 2, dropped)
+	require.Len(t, series, 1)
+	assert.Equal(t, "CUMULATIVE", series[0].MetricKind)
+	assert.Equal(t, &monitoring.TimeInterval{
+		StartTime: "1970-01-01T00:00:50Z",
+		EndTime:   "1970-01-01T00:01:40Z",
+	}, series[0].Points[0].Interval)
+	assert.Empty(t, series[0].Metric.Labels)
+}
+
+func TestTimeSeriesDistribution(t *testing.T) {
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name: "latency",
+			Type: metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION,
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			StartTimestamp: &timestamp.Timestamp{Seconds: 50},
+			Points: []*metricspb.Point{{
+				Timestamp: &timestamp.Timestamp{Seconds: 100},
+				Value: &metricspb.Point_DistributionValue{DistributionValue: &metricspb.DistributionValue{
+					Count:                 4,
+					Sum:                   10,
+					SumOfSquaredDeviation: 3,
+					BucketOptions: &metricspb.DistributionValue_BucketOptions{
+						Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+							Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: []float64{1, 5}},
+						},
+					},
+					Buckets: []*metricspb.DistributionValue_Bucket{{Count: 1}, {Count: 2}, {Count: 1}},
+				}},
+			}},
+		}},
+	}
+
+	series, dropped := testConverter.timeSeries(nil, metric)
+	assert.Equal(t, 0, dropped)
+	require.Len(t, series, 1)
+	assert.Equal(t, "DISTRIBUTION", series[0].ValueType)
+	assert.Equal(t, &monitoring.Distribution{
+		Count:                 4,
+		Mean:                  2.5,
+		SumOfSquaredDeviation: 3,
+		BucketOptions: &monitoring.BucketOptions{
+			ExplicitBuckets: &monitoring.Explicit{Bounds: []float64{1, 5}},
+		},
+		BucketCounts: googleapi.Int64s{1, 2, 1},
+	}, series[0].Points[0].Value.DistributionValue)
+}
+
+func TestTimeSeriesSummaryDropped(t *testing.T) {
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name: "rpc_latency",
+			Type: metricspb.MetricDescriptor_SUMMARY,
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			Points: []*metricspb.Point{
+				{Timestamp: &timestamp.Timestamp{Seconds: 100}, Value: &metricspb.Point_SummaryValue{}},
+				{Timestamp: &timestamp.Timestamp{Seconds: 110}, Value: &metricspb.Point_SummaryValue{}},
+			},
+		}},
+	}
+
+	series, dropped := testConverter.timeSeries(nil, metric)
+	assert.Empty(t, series)
+	assert.Equal(t, 2, dropped)
+}
@@ -19,6 +19,7 @@ import (
 
 	jaegerproto "github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
@@ -73,6 +74,9 @@ func (s *protoGRPCSender) pushTraceData(
 
 	if err != nil {
 		droppedSpans = len(protoBatch.Spans)
+		if consumererror.IsPermanentGRPCCode(status.Code(err)) {
+			err = consumererror.Permanent(err)
+		}
 	}
 
 	return droppedSpans, err
@@ -118,6 +118,9 @@ func (s *jaegerThriftHTTPSender) pushTraceData(
 			"HTTP %d %q",
 			resp.StatusCode,
 			http.StatusText(resp.StatusCode))
+		if consumererror.IsPermanentHTTPStatus(resp.StatusCode) {
+			err = consumererror.Permanent(err)
+		}
 		return len(td.Spans), err
 	}
 
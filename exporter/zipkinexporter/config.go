@@ -25,4 +25,8 @@ type Config struct {
 	// The URL to send the Zipkin trace data to (e.g.:
 	// http://some.url:9411/api/v2/spans).
 	URL string `mapstructure:"url"`
+
+	// Compression is the compression of the bodies of the requests sending the
+	// trace data, either empty, no compression, or "gzip".
+	Compression string `mapstructure:"compression"`
 }
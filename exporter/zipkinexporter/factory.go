@@ -16,9 +16,11 @@ package zipkinexporter
 
 import (
 	"errors"
+	"fmt"
 
 	"go.uber.org/zap"
 
+	"github.com/open-telemetry/opentelemetry-service/compression"
 	"github.com/open-telemetry/opentelemetry-service/config/configerror"
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
 	"github.com/open-telemetry/opentelemetry-service/exporter"
@@ -56,10 +58,14 @@ func (f *Factory) CreateTraceExporter(logger *zap.Logger, config configmodels.Ex
 		// TODO https://github.com/open-telemetry/opentelemetry-service/issues/215
 		return nil, errors.New("exporter config requires a non-empty 'url'")
 	}
+	if cfg.Compression != compression.Unsupported && cfg.Compression != compression.Gzip {
+		return nil, fmt.Errorf("%q config has an unsupported compression %q, only %q is supported",
+			cfg.Name(), cfg.Compression, compression.Gzip)
+	}
 	// <missing service name> is used if the zipkin span is not carrying the name of the service, which shouldn't happen
 	// in normal circumstances. It happens only due to (bad) conversions between formats. The current value is a
 	// clear indication that somehow the name of the service was lost in translation.
-	ze, err := newZipkinExporter(cfg.URL, "<missing service name>", 0)
+	ze, err := newZipkinExporter(cfg.URL, "<missing service name>", 0, cfg.Compression)
 	if err != nil {
 		return nil, err
 	}
@@ -59,3 +59,14 @@ func TestCreateInstanceViaFactory(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, ze)
 }
+
+func TestCreateInstanceUnsupportedCompression(t *testing.T) {
+	factory := Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.URL = "http://some.location.org:9411/api/v2/spans"
+	cfg.Compression = "snappy"
+
+	ze, err := factory.CreateTraceExporter(zap.NewNop(), cfg)
+	assert.Error(t, err)
+	assert.Nil(t, ze)
+}
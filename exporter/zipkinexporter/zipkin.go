@@ -15,8 +15,11 @@
 package zipkinexporter
 
 import (
+	"bytes"
 	"context"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"strconv"
 	"sync"
 	"time"
@@ -27,6 +30,7 @@ import (
 	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
 	"go.opencensus.io/trace"
 
+	"github.com/open-telemetry/opentelemetry-service/compression"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumererror"
 	"github.com/open-telemetry/opentelemetry-service/observability"
@@ -54,11 +58,25 @@ const (
 	DefaultZipkinEndpointURL      = "http://" + DefaultZipkinEndpointHostPort + "/api/v2/spans"
 )
 
-func newZipkinExporter(finalEndpointURI, defaultServiceName string, uploadPeriod time.Duration) (*zipkinExporter, error) {
+// defaultHTTPTimeout is the timeout of the requests sending the spans, the
+// default one of the Zipkin reporter.
+const defaultHTTPTimeout = 5 * time.Second
+
+func newZipkinExporter(finalEndpointURI, defaultServiceName string, uploadPeriod time.Duration, compressionType string) (*zipkinExporter, error) {
 	var opts []zipkinhttp.ReporterOption
 	if uploadPeriod > 0 {
 		opts = append(opts, zipkinhttp.BatchInterval(uploadPeriod))
 	}
+	if compressionType == compression.Gzip {
+		client := &http.Client{
+			Timeout: defaultHTTPTimeout,
+			Transport: &gzipTransport{
+				pool: compression.DefaultGzipPool,
+				next: http.DefaultTransport,
+			},
+		}
+		opts = append(opts, zipkinhttp.Client(client))
+	}
 	reporter := zipkinhttp.NewReporter(finalEndpointURI, opts...)
 	zle := &zipkinExporter{
 		defaultServiceName: defaultServiceName,
@@ -67,6 +85,42 @@ func newZipkinExporter(finalEndpointURI, defaultServiceName string, uploadPeriod
 	return zle, nil
 }
 
+// gzipTransport compresses the bodies of the requests sent by the reporter.
+// The gzip writers are taken from a pool shared by the concurrent requests.
+type gzipTransport struct {
+	pool *compression.GzipPool
+	next http.RoundTripper
+}
+
+var _ http.RoundTripper = (*gzipTransport)(nil)
+
+func (gt *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return gt.next.RoundTrip(req)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gt.pool.Compress(&buf, body); err != nil {
+		return nil, err
+	}
+
+	// A RoundTripper must not modify the request, send a copy.
+	gzReq := new(http.Request)
+	*gzReq = *req
+	gzReq.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		gzReq.Header[k] = v
+	}
+	gzReq.Header.Set("Content-Encoding", compression.Gzip)
+	gzReq.Body = ioutil.NopCloser(&buf)
+	gzReq.ContentLength = int64(buf.Len())
+	return gt.next.RoundTrip(gzReq)
+}
+
 func lookupAttribute(node *commonpb.Node, key string) string {
 	if node == nil {
 		return ""
@@ -16,6 +16,7 @@ package zipkinexporter
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,15 +25,26 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	zipkinmodel "github.com/openzipkin/zipkin-go/model"
 	zipkinreporter "github.com/openzipkin/zipkin-go/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/open-telemetry/opentelemetry-service/compression"
 	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
 	"github.com/open-telemetry/opentelemetry-service/internal/testutils"
 	"github.com/open-telemetry/opentelemetry-service/processor"
 	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
@@ -133,7 +145,7 @@ func TestZipkinExporter_roundtripJSON(t *testing.T) {
 	}))
 	defer cst.Close()
 
-	tes, err := newZipkinExporter(cst.URL, "", time.Millisecond)
+	tes, err := newZipkinExporter(cst.URL, "", time.Millisecond, "")
 	if err != nil {
 		t.Fatalf("Failed to create a new Zipkin receiver: %v", err)
 	}
@@ -211,6 +223,74 @@ func TestZipkinExporter_roundtripJSON(t *testing.T) {
 	}
 }
 
+func TestZipkinExporter_gzipConcurrentRequests(t *testing.T) {
+	// The Zipkin receiver decompresses the requests, count the compressed ones.
+	sink := new(exportertest.SinkTraceExporter)
+	zr, err := zipkinreceiver.New(":0", sink)
+	require.NoError(t, err)
+	var gzipped, requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Content-Encoding") == compression.Gzip {
+			atomic.AddInt32(&gzipped, 1)
+		}
+		zr.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	// A batch interval much shorter than the sends spreads the spans over
+	// many concurrent requests.
+	ze, err := newZipkinExporter(srv.URL, "frontend", time.Millisecond, compression.Gzip)
+	require.NoError(t, err)
+
+	const senders, spansPerSender = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(sender int) {
+			defer wg.Done()
+			for j := 0; j < spansPerSender; j++ {
+				td := consumerdata.TraceData{Spans: []*tracepb.Span{testSpan(sender*spansPerSender + j)}}
+				assert.NoError(t, ze.ConsumeTraceData(context.Background(), td))
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, ze.Shutdown())
+
+	assert.True(t, atomic.LoadInt32(&requests) > 1)
+	assert.Equal(t, atomic.LoadInt32(&requests), atomic.LoadInt32(&gzipped))
+
+	// No span is lost or corrupted by the reuse of the gzip writers.
+	var got []string
+	for _, td := range sink.AllTraces() {
+		for _, span := range td.Spans {
+			got = append(got, span.GetName().GetValue())
+		}
+	}
+	want := make([]string, 0, senders*spansPerSender)
+	for i := 0; i < senders*spansPerSender; i++ {
+		want = append(want, "span-"+strconv.Itoa(i))
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	assert.Equal(t, want, got)
+}
+
+func testSpan(i int) *tracepb.Span {
+	// The ids must not be zero.
+	traceID := []byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, byte(i >> 8), byte(i)}
+	spanID := []byte{1, 0, 0, 0, 0, 0, byte(i >> 8), byte(i)}
+	return &tracepb.Span{
+		TraceId:   traceID,
+		SpanId:    spanID,
+		Name:      &tracepb.TruncatableString{Value: "span-" + strconv.Itoa(i)},
+		Kind:      tracepb.Span_SERVER,
+		StartTime: &timestamp.Timestamp{Seconds: 1571000000},
+		EndTime:   &timestamp.Timestamp{Seconds: 1571000001},
+	}
+}
+
 type mockZipkinReporter struct {
 	url    string
 	client *http.Client
@@ -0,0 +1,60 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zpagesextension
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// pagesPathPrefix is the prefix of the paths of all the zPages.
+const pagesPathPrefix = "/debug/"
+
+var (
+	pagesMu sync.RWMutex
+	pages   = make(map[string]http.Handler)
+)
+
+// RegisterPage adds a zPage served by the zPages extension at the given path,
+// which must start with "/debug/". Components register their pages when they
+// start, which can happen after the extension started, and unregister them
+// with UnregisterPage when they stop.
+func RegisterPage(path string, handler http.Handler) {
+	pagesMu.Lock()
+	defer pagesMu.Unlock()
+	pages[path] = handler
+}
+
+// UnregisterPage removes the zPage registered at the given path.
+func UnregisterPage(path string) {
+	pagesMu.Lock()
+	defer pagesMu.Unlock()
+	delete(pages, path)
+}
+
+// registeredPagesHandler serves the pages added with RegisterPage.
+type registeredPagesHandler struct{}
+
+func (registeredPagesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pagesMu.RLock()
+	handler, ok := pages[strings.TrimSuffix(r.URL.Path, "/")]
+	pagesMu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
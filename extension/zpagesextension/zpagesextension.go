@@ -35,6 +35,9 @@ var _ (extension.ServiceExtension) = (*zpagesExtension)(nil)
 func (zpe *zpagesExtension) Start(host extension.Host) error {
 	zPagesMux := http.NewServeMux()
 	zpages.Handle(zPagesMux, "/debug")
+	// The pages registered by the other components are served under the same
+	// prefix, the more specific patterns of the default zPages take precedence.
+	zPagesMux.Handle(pagesPathPrefix, registeredPagesHandler{})
 
 	// Start the listener here so we can have earlier failure if port is
 	// already in use.
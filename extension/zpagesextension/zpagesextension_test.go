@@ -115,3 +115,40 @@ func TestZPagesShutdownWithoutStart(t *testing.T) {
 
 	require.NoError(t, zpagesExt.Shutdown())
 }
+
+func TestZPagesRegisteredPages(t *testing.T) {
+	config := Config{
+		Endpoint: testutils.GetAvailableLocalAddress(t),
+	}
+
+	zpagesExt, err := newServer(config, zap.NewNop())
+	require.NoError(t, err)
+
+	mh := extensiontest.NewMockHost()
+	require.NoError(t, zpagesExt.Start(mh))
+	defer zpagesExt.Shutdown()
+
+	// Pages can be registered after the extension started.
+	const path = "/debug/testz"
+	RegisterPage(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	url := "http://" + config.Endpoint + path
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	UnregisterPage(path)
+	resp, err = http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The default zPages are still served.
+	resp, err = http.Get("http://" + config.Endpoint + "/debug/tracez")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,188 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	// OpenMetricsType is the media type of the OpenMetrics text format.
+	OpenMetricsType = "application/openmetrics-text"
+	// openMetricsContentType is the content type of the responses in the
+	// OpenMetrics text format.
+	openMetricsContentType = OpenMetricsType + "; version=1.0.0; charset=utf-8"
+)
+
+// NewMetricsHandler returns an http.Handler serving the metrics of the
+// gatherer in the OpenMetrics text format to the clients accepting it, the
+// other requests being served by the given handler, e.g. in the Prometheus
+// text format.
+func NewMetricsHandler(gatherer prometheus.Gatherer, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsOpenMetrics(r.Header.Get("Accept")) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		families, err := gatherer.Gather()
+		if err != nil {
+			http.Error(w, "An error has occurred during metrics gathering:\n\n"+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", openMetricsContentType)
+		// A write error means the client went away, the response can't be fixed.
+		_ = WriteOpenMetrics(w, families)
+	})
+}
+
+// acceptsOpenMetrics returns whether the Accept header lists the OpenMetrics
+// text format, with a non zero quality.
+func acceptsOpenMetrics(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || mediaType != OpenMetricsType {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// WriteOpenMetrics writes the metric families in the OpenMetrics text format,
+// terminated by "# EOF".
+func WriteOpenMetrics(w io.Writer, families []*dto.MetricFamily) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range families {
+		writeOpenMetricsFamily(bw, family)
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+func writeOpenMetricsFamily(w *bufio.Writer, family *dto.MetricFamily) {
+	name := family.GetName()
+	var typ string
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		// The name of a counter family has no _total suffix, its samples have.
+		name = strings.TrimSuffix(name, "_total")
+		typ = "counter"
+	case dto.MetricType_GAUGE:
+		typ = "gauge"
+	case dto.MetricType_SUMMARY:
+		typ = "summary"
+	case dto.MetricType_HISTOGRAM:
+		typ = "histogram"
+	default:
+		typ = "unknown"
+	}
+
+	if family.Help != nil {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, escapeOpenMetrics(family.GetHelp()))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+	for _, metric := range family.Metric {
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			writeOpenMetricsSample(w, name+"_total", metric, "", "", metric.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			writeOpenMetricsSample(w, name, metric, "", "", metric.GetGauge().GetValue())
+		case dto.MetricType_SUMMARY:
+			summary := metric.GetSummary()
+			for _, quantile := range summary.Quantile {
+				writeOpenMetricsSample(w, name, metric, "quantile", formatOpenMetricsFloat(quantile.GetQuantile()),
+					quantile.GetValue())
+			}
+			writeOpenMetricsSample(w, name+"_sum", metric, "", "", summary.GetSampleSum())
+			writeOpenMetricsSample(w, name+"_count", metric, "", "", float64(summary.GetSampleCount()))
+		case dto.MetricType_HISTOGRAM:
+			histogram := metric.GetHistogram()
+			hasInf := false
+			for _, bucket := range histogram.Bucket {
+				if math.IsInf(bucket.GetUpperBound(), +1) {
+					hasInf = true
+				}
+				writeOpenMetricsSample(w, name+"_bucket", metric, "le", formatOpenMetricsFloat(bucket.GetUpperBound()),
+					float64(bucket.GetCumulativeCount()))
+			}
+			// OpenMetrics requires the +Inf bucket.
+			if !hasInf {
+				writeOpenMetricsSample(w, name+"_bucket", metric, "le", "+Inf", float64(histogram.GetSampleCount()))
+			}
+			writeOpenMetricsSample(w, name+"_sum", metric, "", "", histogram.GetSampleSum())
+			writeOpenMetricsSample(w, name+"_count", metric, "", "", float64(histogram.GetSampleCount()))
+		default:
+			writeOpenMetricsSample(w, name, metric, "", "", metric.GetUntyped().GetValue())
+		}
+	}
+}
+
+// writeOpenMetricsSample writes a sample line, with the labels of the metric
+// and the given extra label if its name is not empty.
+func writeOpenMetricsSample(w *bufio.Writer, name string, metric *dto.Metric, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(metric.Label) > 0 || extraName != "" {
+		w.WriteByte('{')
+		sep := ""
+		for _, label := range metric.Label {
+			fmt.Fprintf(w, `%s%s="%s"`, sep, label.GetName(), escapeOpenMetrics(label.GetValue()))
+			sep = ","
+		}
+		if extraName != "" {
+			fmt.Fprintf(w, `%s%s="%s"`, sep, extraName, escapeOpenMetrics(extraValue))
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatOpenMetricsFloat(value))
+	if metric.TimestampMs != nil {
+		// The OpenMetrics timestamps are in seconds.
+		w.WriteByte(' ')
+		w.WriteString(strconv.FormatFloat(float64(metric.GetTimestampMs())/1000, 'f', -1, 64))
+	}
+	w.WriteByte('\n')
+}
+
+var openMetricsEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeOpenMetrics(s string) string {
+	return openMetricsEscaper.Replace(s)
+}
+
+func formatOpenMetricsFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,105 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "accepted_spans",
+		Help: "Number of spans accepted.",
+	}, []string{"receiver"})
+	counter.WithLabelValues(`say "hi"`).Add(3)
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "queue_length", Help: "Current queue length."})
+	gauge.Set(7)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "batch_size",
+		Help:    "Size of the batches.",
+		Buckets: []float64{1, 10},
+	})
+	histogram.Observe(5)
+	histogram.Observe(50)
+	require.NoError(t, registry.Register(counter))
+	require.NoError(t, registry.Register(gauge))
+	require.NoError(t, registry.Register(histogram))
+	return registry
+}
+
+func TestMetricsHandler_OpenMetrics(t *testing.T) {
+	registry := newTestRegistry(t)
+	srv := httptest.NewServer(NewMetricsHandler(registry, promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	// As sent by prometheus.
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1,text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/openmetrics-text; version=1.0.0; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `# HELP accepted_spans Number of spans accepted.
+# TYPE accepted_spans counter
+accepted_spans_total{receiver="say \"hi\""} 3
+# HELP batch_size Size of the batches.
+# TYPE batch_size histogram
+batch_size_bucket{le="1"} 0
+batch_size_bucket{le="10"} 1
+batch_size_bucket{le="+Inf"} 2
+batch_size_sum 55
+batch_size_count 2
+# HELP queue_length Current queue length.
+# TYPE queue_length gauge
+queue_length 7
+# EOF
+`, string(body))
+	assert.True(t, strings.HasSuffix(string(body), "# EOF\n"))
+}
+
+func TestMetricsHandler_PrometheusText(t *testing.T) {
+	registry := newTestRegistry(t)
+	srv := httptest.NewServer(NewMetricsHandler(registry, promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+	defer srv.Close()
+
+	for _, accept := range []string{"", "text/plain;version=0.0.4", "application/openmetrics-text;q=0,text/plain"} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain"), "accept %q", accept)
+		assert.NotContains(t, string(body), "# EOF", "accept %q", accept)
+	}
+}
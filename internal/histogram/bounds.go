@@ -0,0 +1,141 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package histogram contains helpers shared by every component that reads,
+// builds or rewrites histogram (distribution) metrics. Components must use
+// these helpers so that bucket boundaries are encoded identically end to end.
+package histogram
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+)
+
+// significantDigits is the precision kept for bucket boundaries. It is the
+// largest number of decimal digits that survives a float64 round trip, so
+// boundaries like "0.1" and "0.10000000000000001" map to the same value.
+const significantDigits = 15
+
+// CanonicalBound returns the canonical float64 representation of the given
+// bucket boundary. Negative zero is folded into zero and the value is rounded
+// to a fixed number of significant digits. Infinities and NaN are returned
+// unchanged.
+func CanonicalBound(bound float64) float64 {
+	if bound == 0 {
+		return 0
+	}
+	if math.IsInf(bound, 0) || math.IsNaN(bound) {
+		return bound
+	}
+	canonical, err := strconv.ParseFloat(strconv.FormatFloat(bound, 'g', significantDigits, 64), 64)
+	if err != nil {
+		return bound
+	}
+	return canonical
+}
+
+// ParseBound parses a textual bucket boundary, e.g. the value of a prometheus
+// "le" label, and returns its canonical value. Any encoding accepted by
+// strconv.ParseFloat is supported, including "+Inf", "Inf" and exponents.
+func ParseBound(s string) (float64, error) {
+	bound, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	return CanonicalBound(bound), nil
+}
+
+// FormatBound returns the canonical textual representation of a bucket
+// boundary, using "+Inf" and "-Inf" for infinities.
+func FormatBound(bound float64) string {
+	switch {
+	case math.IsInf(bound, 1):
+		return "+Inf"
+	case math.IsInf(bound, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(CanonicalBound(bound), 'g', -1, 64)
+}
+
+// CanonicalizeDistribution canonicalizes the explicit bucket boundaries of the
+// given distribution in place. Adjacent boundaries that become equal after
+// canonicalization are collapsed and the counts of the buckets they delimited
+// are merged, so the distribution remains consistent. It returns true if the
+// distribution was modified.
+func CanonicalizeDistribution(dv *metricspb.DistributionValue) bool {
+	explicit := dv.GetBucketOptions().GetExplicit()
+	if explicit == nil || len(explicit.Bounds) == 0 {
+		return false
+	}
+
+	// Buckets are optional, only merge them if they match the bounds.
+	hasBuckets := len(dv.Buckets) == len(explicit.Bounds)+1
+
+	changed := false
+	bounds := explicit.Bounds[:0]
+	buckets := dv.Buckets[:0]
+	for i, b := range explicit.Bounds {
+		canonical := CanonicalBound(b)
+		if canonical != b {
+			changed = true
+		}
+		if len(bounds) > 0 && bounds[len(bounds)-1] == canonical {
+			// The bucket delimited by the duplicated bounds is empty, move its
+			// count to the next bucket.
+			if hasBuckets {
+				dv.Buckets[i+1] = mergeBuckets(dv.Buckets[i], dv.Buckets[i+1])
+			}
+			changed = true
+			continue
+		}
+		bounds = append(bounds, canonical)
+		if hasBuckets {
+			buckets = append(buckets, dv.Buckets[i])
+		}
+	}
+
+	explicit.Bounds = bounds
+	if hasBuckets {
+		dv.Buckets = append(buckets, dv.Buckets[len(dv.Buckets)-1])
+	}
+	return changed
+}
+
+// IsCanonicalDistribution returns whether the explicit bucket boundaries of
+// the given distribution are canonical, i.e. CanonicalizeDistribution would
+// not modify it.
+func IsCanonicalDistribution(dv *metricspb.DistributionValue) bool {
+	bounds := dv.GetBucketOptions().GetExplicit().GetBounds()
+	for i, b := range bounds {
+		canonical := CanonicalBound(b)
+		if canonical != b || (i > 0 && CanonicalBound(bounds[i-1]) == canonical) {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeBuckets(from, to *metricspb.DistributionValue_Bucket) *metricspb.DistributionValue_Bucket {
+	merged := &metricspb.DistributionValue_Bucket{
+		Count:    from.GetCount() + to.GetCount(),
+		Exemplar: to.GetExemplar(),
+	}
+	if merged.Exemplar == nil {
+		merged.Exemplar = from.GetExemplar()
+	}
+	return merged
+}
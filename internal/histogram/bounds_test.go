@@ -0,0 +1,131 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"math"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBound(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  float64
+	}{
+		{"plain", "0.1", 0.1},
+		{"trailing_zeros", "0.100", 0.1},
+		{"float_noise", "0.10000000000000001", 0.1},
+		{"exponent", "1e-1", 0.1},
+		{"upper_exponent", "1E+3", 1000},
+		{"integer", "5", 5},
+		{"spaces", " 2.5 ", 2.5},
+		{"negative_zero", "-0", 0},
+		{"plus_inf", "+Inf", math.Inf(1)},
+		{"inf", "Inf", math.Inf(1)},
+		{"lower_inf", "inf", math.Inf(1)},
+		{"minus_inf", "-Inf", math.Inf(-1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBound(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.False(t, math.Signbit(got) && got == 0, "negative zero must be folded")
+		})
+	}
+
+	_, err := ParseBound("not-a-number")
+	assert.Error(t, err)
+	_, err = ParseBound("")
+	assert.Error(t, err)
+}
+
+func TestCanonicalBound(t *testing.T) {
+	assert.Equal(t, 0.3, CanonicalBound(0.1+0.2))
+	assert.Equal(t, 1.1, CanonicalBound(1.1000000000000001))
+	assert.Equal(t, float64(0), CanonicalBound(math.Copysign(0, -1)))
+	assert.False(t, math.Signbit(CanonicalBound(math.Copysign(0, -1))))
+	assert.True(t, math.IsInf(CanonicalBound(math.Inf(1)), 1))
+	assert.True(t, math.IsNaN(CanonicalBound(math.NaN())))
+	// Idempotent.
+	for _, b := range []float64{0.1, 0.25, 1e-9, 123456.789, 1e300} {
+		assert.Equal(t, CanonicalBound(b), CanonicalBound(CanonicalBound(b)))
+	}
+}
+
+func TestFormatBound(t *testing.T) {
+	assert.Equal(t, "+Inf", FormatBound(math.Inf(1)))
+	assert.Equal(t, "-Inf", FormatBound(math.Inf(-1)))
+	assert.Equal(t, "0.3", FormatBound(0.1+0.2))
+	assert.Equal(t, "0", FormatBound(math.Copysign(0, -1)))
+	assert.Equal(t, "1000", FormatBound(1e3))
+
+	for _, s := range []string{"0.1", "0.100", "1e-1", "0.10000000000000001"} {
+		b, err := ParseBound(s)
+		require.NoError(t, err)
+		assert.Equal(t, "0.1", FormatBound(b))
+	}
+}
+
+func TestCanonicalizeDistribution(t *testing.T) {
+	dv := &metricspb.DistributionValue{
+		Count: 10,
+		BucketOptions: &metricspb.DistributionValue_BucketOptions{
+			Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+				Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{
+					Bounds: []float64{0.1, 0.10000000000000001, 0.1 + 0.2, 1},
+				},
+			},
+		},
+		Buckets: []*metricspb.DistributionValue_Bucket{
+			{Count: 1}, {Count: 2}, {Count: 3}, {Count: 1}, {Count: 3},
+		},
+	}
+
+	assert.False(t, IsCanonicalDistribution(dv))
+	assert.True(t, CanonicalizeDistribution(dv))
+	assert.True(t, IsCanonicalDistribution(dv))
+	assert.Equal(t, []float64{0.1, 0.3, 1}, dv.BucketOptions.GetExplicit().Bounds)
+	counts := make([]int64, 0, len(dv.Buckets))
+	for _, b := range dv.Buckets {
+		counts = append(counts, b.Count)
+	}
+	assert.Equal(t, []int64{1, 5, 1, 3}, counts)
+
+	// Canonical input is left untouched.
+	assert.False(t, CanonicalizeDistribution(dv))
+}
+
+func TestCanonicalizeDistributionWithoutBuckets(t *testing.T) {
+	dv := &metricspb.DistributionValue{
+		BucketOptions: &metricspb.DistributionValue_BucketOptions{
+			Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+				Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{
+					Bounds: []float64{-0.0, 1e-1, 0.1},
+				},
+			},
+		},
+	}
+	assert.True(t, CanonicalizeDistribution(dv))
+	assert.Equal(t, []float64{0, 0.1}, dv.BucketOptions.GetExplicit().Bounds)
+	assert.Nil(t, dv.Buckets)
+
+	assert.False(t, CanonicalizeDistribution(&metricspb.DistributionValue{}))
+}
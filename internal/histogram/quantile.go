@@ -0,0 +1,69 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"math"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+)
+
+// Quantile estimates the q-quantile, 0 <= q <= 1, of the observations of the
+// given distribution by linear interpolation within the bucket holding it, as
+// the prometheus histogram_quantile function does:
+//   - The lower bound of the first bucket is 0 if its upper bound is positive,
+//     otherwise the quantile is its upper bound.
+//   - A quantile in the overflow bucket is the largest explicit bound, since
+//     the overflow bucket has no upper bound to interpolate to.
+//
+// It returns false if the quantile cannot be estimated: the distribution has
+// no observations, no explicit bounds, i.e. its only bucket is the overflow
+// one, or its buckets do not match its bounds.
+func Quantile(dv *metricspb.DistributionValue, q float64) (float64, bool) {
+	bounds := dv.GetBucketOptions().GetExplicit().GetBounds()
+	if len(bounds) == 0 || len(dv.GetBuckets()) != len(bounds)+1 || math.IsNaN(q) || q < 0 || q > 1 {
+		return 0, false
+	}
+	var total int64
+	for _, bucket := range dv.Buckets {
+		total += bucket.GetCount()
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	rank := q * float64(total)
+	var cumulative int64
+	for i, bucket := range dv.Buckets {
+		count := bucket.GetCount()
+		if float64(cumulative+count) < rank || count == 0 {
+			cumulative += count
+			continue
+		}
+		if i == len(bounds) {
+			return bounds[len(bounds)-1], true
+		}
+		upper := bounds[i]
+		lower := 0.0
+		if i > 0 {
+			lower = bounds[i-1]
+		} else if upper <= 0 {
+			return upper, true
+		}
+		return lower + (upper-lower)*(rank-float64(cumulative))/float64(count), true
+	}
+	// Not reached since rank <= total.
+	return bounds[len(bounds)-1], true
+}
@@ -0,0 +1,122 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuantile(t *testing.T) {
+	// 10 observations in each bucket of width 10 from 0 to 100.
+	uniform := distribution([]float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100},
+		[]int64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 0})
+	tests := []struct {
+		name string
+		dv   *metricspb.DistributionValue
+		q    float64
+		want float64
+	}{
+		{"median", uniform, 0.5, 50},
+		{"p95", uniform, 0.95, 95},
+		{"p99", uniform, 0.99, 99},
+		{"min", uniform, 0, 0},
+		{"max", uniform, 1, 100},
+		{"first_bucket", distribution([]float64{1, 2}, []int64{4, 0, 0}), 0.5, 0.5},
+		{"negative_first_bucket", distribution([]float64{-1, 2}, []int64{4, 0, 0}), 0.5, -1},
+		{"skips_empty_buckets", distribution([]float64{1, 2, 3}, []int64{0, 0, 4, 0}), 0.5, 2.5},
+		{"overflow_bucket", distribution([]float64{1, 2}, []int64{1, 1, 8}), 0.9, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Quantile(tt.dv, tt.q)
+			assert.True(t, ok)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
+
+func TestQuantileUndefined(t *testing.T) {
+	tests := []struct {
+		name string
+		dv   *metricspb.DistributionValue
+		q    float64
+	}{
+		{"overflow_bucket_only", distribution(nil, []int64{5}), 0.5},
+		{"no_observations", distribution([]float64{1, 2}, []int64{0, 0, 0}), 0.5},
+		{"mismatched_buckets", distribution([]float64{1, 2}, []int64{1, 1}), 0.5},
+		{"negative_quantile", distribution([]float64{1}, []int64{1, 1}), -0.1},
+		{"quantile_above_one", distribution([]float64{1}, []int64{1, 1}), 1.1},
+		{"nan_quantile", distribution([]float64{1}, []int64{1, 1}), math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Quantile(tt.dv, tt.q)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestQuantileKnownDistribution(t *testing.T) {
+	// Normally distributed latencies of mean 300ms and standard deviation 50ms, in buckets of 25ms.
+	r := rand.New(rand.NewSource(42))
+	samples := make([]float64, 100000)
+	for i := range samples {
+		samples[i] = 0.3 + r.NormFloat64()*0.05
+	}
+	sort.Float64s(samples)
+
+	var bounds []float64
+	for bound := 0.1; bound < 0.5; bound += 0.025 {
+		bounds = append(bounds, CanonicalBound(bound))
+	}
+	counts := make([]int64, len(bounds)+1)
+	for _, s := range samples {
+		counts[sort.SearchFloat64s(bounds, s)]++
+	}
+	dv := distribution(bounds, counts)
+
+	for _, q := range []float64{0.5, 0.9, 0.95, 0.99} {
+		exact := samples[int(q*float64(len(samples)))]
+		got, ok := Quantile(dv, q)
+		assert.True(t, ok)
+		// The interpolation assumes the observations are uniformly distributed within a bucket, the estimate is
+		// well within a bucket width of the exact quantile.
+		assert.InDelta(t, exact, got, 0.005, "quantile %v", q)
+	}
+}
+
+func distribution(bounds []float64, counts []int64) *metricspb.DistributionValue {
+	buckets := make([]*metricspb.DistributionValue_Bucket, len(counts))
+	var total int64
+	for i, count := range counts {
+		buckets[i] = &metricspb.DistributionValue_Bucket{Count: count}
+		total += count
+	}
+	return &metricspb.DistributionValue{
+		Count: total,
+		BucketOptions: &metricspb.DistributionValue_BucketOptions{
+			Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+				Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: bounds},
+			},
+		},
+		Buckets: buckets,
+	}
+}
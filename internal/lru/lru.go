@@ -0,0 +1,117 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lru implements the bounded caches used by the components keeping a
+// state per series, service or similar unbounded key. Once full, a cache
+// evicts its least recently updated entry.
+package lru
+
+import (
+	"container/list"
+)
+
+// Key is the key of a cache entry, it may be any comparable value.
+type Key interface{}
+
+type entry struct {
+	key   Key
+	value interface{}
+}
+
+// Cache maps keys to values, holding at most a given number of entries. Only
+// Add updates the recency of an entry, reading it with Get does not. It is not
+// safe for concurrent use.
+type Cache struct {
+	maxEntries int
+	// OnEvicted, if set, is called with every entry removed from the cache,
+	// whether it was evicted or removed with Remove or RemoveOldest.
+	OnEvicted func(key Key, value interface{})
+
+	entries map[Key]*list.Element
+	// lru holds the entries ordered from the most to the least recently updated.
+	lru *list.List
+}
+
+// New creates a cache holding at most maxEntries entries.
+func New(maxEntries int) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		entries:    make(map[Key]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Add sets the value of the given key and makes it the most recently updated
+// entry, evicting the least recently updated entry if a new key doesn't fit.
+// It returns whether an entry was evicted.
+func (c *Cache) Add(key Key, value interface{}) bool {
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*entry).value = value
+		c.lru.MoveToFront(elem)
+		return false
+	}
+	evicted := false
+	if c.lru.Len() >= c.maxEntries {
+		c.RemoveOldest()
+		evicted = true
+	}
+	c.entries[key] = c.lru.PushFront(&entry{key: key, value: value})
+	return evicted
+}
+
+// Get returns the value of the given key.
+func (c *Cache) Get(key Key) (interface{}, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return elem.Value.(*entry).value, true
+}
+
+// Oldest returns the least recently updated entry.
+func (c *Cache) Oldest() (Key, interface{}, bool) {
+	elem := c.lru.Back()
+	if elem == nil {
+		return nil, nil, false
+	}
+	e := elem.Value.(*entry)
+	return e.key, e.value, true
+}
+
+// Remove removes the given key from the cache.
+func (c *Cache) Remove(key Key) {
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// RemoveOldest removes the least recently updated entry from the cache.
+func (c *Cache) RemoveOldest() {
+	if elem := c.lru.Back(); elem != nil {
+		c.removeElement(elem)
+	}
+}
+
+func (c *Cache) removeElement(elem *list.Element) {
+	e := c.lru.Remove(elem).(*entry)
+	delete(c.entries, e.key)
+	if c.OnEvicted != nil {
+		c.OnEvicted(e.key, e.value)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache) Len() int {
+	return c.lru.Len()
+}
@@ -0,0 +1,74 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheEvictsLeastRecentlyUpdated(t *testing.T) {
+	var evicted []Key
+	c := New(2)
+	c.OnEvicted = func(key Key, value interface{}) {
+		evicted = append(evicted, key)
+	}
+
+	assert.False(t, c.Add("a", 1))
+	assert.False(t, c.Add("b", 2))
+	// Reading an entry doesn't update its recency, updating it does.
+	v, ok := c.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	assert.False(t, c.Add("a", 3))
+
+	assert.True(t, c.Add("c", 4))
+	assert.Equal(t, []Key{"b"}, evicted)
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	v, ok = c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	key, value, ok := c.Oldest()
+	assert.True(t, ok)
+	assert.Equal(t, "a", key)
+	assert.Equal(t, 3, value)
+}
+
+func TestCacheRemove(t *testing.T) {
+	var evicted []Key
+	c := New(3)
+	c.OnEvicted = func(key Key, value interface{}) {
+		evicted = append(evicted, key)
+	}
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 3)
+
+	c.Remove("b")
+	c.Remove("unknown")
+	c.RemoveOldest()
+	assert.Equal(t, []Key{"b", "a"}, evicted)
+	assert.Equal(t, 1, c.Len())
+
+	c.RemoveOldest()
+	c.RemoveOldest()
+	assert.Equal(t, 0, c.Len())
+	_, _, ok := c.Oldest()
+	assert.False(t, ok)
+}
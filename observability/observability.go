@@ -20,6 +20,7 @@ package observability
 
 import (
 	"context"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -31,15 +32,32 @@ import (
 )
 
 var (
-	mReceiverReceivedSpans      = stats.Int64("otelsvc/receiver/received_spans", "Counts the number of spans received by the receiver", "1")
-	mReceiverDroppedSpans       = stats.Int64("otelsvc/receiver/dropped_spans", "Counts the number of spans dropped by the receiver", "1")
-	mReceiverReceivedTimeSeries = stats.Int64("otelsvc/receiver/received_timeseries", "Counts the number of timeseries received by the receiver", "1")
-	mReceiverDroppedTimeSeries  = stats.Int64("otelsvc/receiver/dropped_timeseries", "Counts the number of timeseries dropped by the receiver", "1")
+	mReceiverReceivedSpans       = stats.Int64("otelsvc/receiver/received_spans", "Counts the number of spans received by the receiver", "1")
+	mReceiverDroppedSpans        = stats.Int64("otelsvc/receiver/dropped_spans", "Counts the number of spans dropped by the receiver", "1")
+	mReceiverReceivedTimeSeries  = stats.Int64("otelsvc/receiver/received_timeseries", "Counts the number of timeseries received by the receiver", "1")
+	mReceiverDroppedTimeSeries   = stats.Int64("otelsvc/receiver/dropped_timeseries", "Counts the number of timeseries dropped by the receiver", "1")
+	mReceiverEmptyScrapes        = stats.Int64("otelsvc/receiver/empty_scrapes", "Counts the number of successful scrapes that returned no data", "1")
+	mReceiverPartialScrapes      = stats.Int64("otelsvc/receiver/partial_scrapes", "Counts the number of scrapes with invalid content whose samples parsed before it were committed", "1")
+	mReceiverDroppedTargets      = stats.Int64("otelsvc/receiver/dropped_targets", "Number of discovered targets dropped because the receiver max targets was exceeded", "1")
+	mReceiverEvictedTargets      = stats.Int64("otelsvc/receiver/evicted_targets", "Counts the number of targets evicted because they failed to be scraped for too long", "1")
+	mReceiverScrapeJobDisabled   = stats.Int64("otelsvc/receiver/scrape_job_disabled", "Whether the scrape job is disabled (1) or enabled (0)", "1")
+	mReceiverConfigHash          = stats.Int64("otelsvc/receiver/config_hash", "Whether the config with the hash of the label is the one applied (1) or was replaced (0)", "1")
+	mReceiverScrapeSize          = stats.Int64("otelsvc/receiver/scrape_size", "Size of the samples of the scrapes in the Prometheus text format", "By")
+	mReceiverScrapeSeries        = stats.Int64("otelsvc/receiver/scrape_series", "Number of series exposed by the scraped targets", "1")
+	mReceiverScrapeBackoff       = stats.Int64("otelsvc/receiver/scrape_backoff", "How long the scrapes are paused for because the consumer is persistently slow, 0 once it caught up", "ms")
+	mReceiverRepairedHistograms  = stats.Int64("otelsvc/receiver/repaired_histograms", "Counts the number of histogram series missing their _count or _sum which were repaired", "1")
+	mReceiverNaNBucketHistograms = stats.Int64("otelsvc/receiver/nan_bucket_histograms", "Counts the number of histogram series with a bucket whose value is NaN", "1")
+	mReceiverDiscoveryReadyTime  = stats.Int64("otelsvc/receiver/discovery_ready_time", "Time from applying the service discovery config of a scrape job to the discovery of its first targets", "ms")
+	mReceiverScrapePhase         = stats.Float64("otelsvc/receiver/scrape_phase", "Duration of the phases of the scrape requests: connect, TLS handshake, first byte and body read", "ms")
+	mReceiverEvictedSeries       = stats.Int64("otelsvc/receiver/evicted_series", "Counts the number of series whose start time tracking was evicted because the receiver max tracked series was exceeded", "1")
 
 	mExporterReceivedSpans      = stats.Int64("otelsvc/exporter/received_spans", "Counts the number of spans received by the exporter", "1")
 	mExporterDroppedSpans       = stats.Int64("otelsvc/exporter/dropped_spans", "Counts the number of spans received by the exporter", "1")
 	mExporterReceivedTimeSeries = stats.Int64("otelsvc/exporter/received_timeseries", "Counts the number of timeseries received by the exporter", "1")
 	mExporterDroppedTimeSeries  = stats.Int64("otelsvc/exporter/dropped_timeseries", "Counts the number of timeseries received by the exporter", "1")
+	mExporterActiveRequests     = stats.Int64("otelsvc/exporter/active_requests", "Number of requests the exporter is currently sending", "1")
+	mExporterQueuedRequests     = stats.Int64("otelsvc/exporter/queued_requests", "Number of requests waiting for the exporter max concurrent requests to allow them", "1")
+	mExporterRejectedRequests   = stats.Int64("otelsvc/exporter/rejected_requests", "Counts the number of requests rejected because the exporter max concurrent requests was exceeded", "1")
 )
 
 // TagKeyReceiver defines tag key for Receiver.
@@ -48,6 +66,18 @@ var TagKeyReceiver, _ = tag.NewKey("otelsvc_receiver")
 // TagKeyExporter defines tag key for Exporter.
 var TagKeyExporter, _ = tag.NewKey("otelsvc_exporter")
 
+// TagKeyPipeline defines tag key for Pipeline.
+var TagKeyPipeline, _ = tag.NewKey("otelsvc_pipeline")
+
+// TagKeyScrapeJob defines tag key for the scrape job of a metrics Receiver.
+var TagKeyScrapeJob, _ = tag.NewKey("otelsvc_scrape_job")
+
+// TagKeyScrapePhase defines tag key for the phase of the scrape requests of a metrics Receiver.
+var TagKeyScrapePhase, _ = tag.NewKey("otelsvc_scrape_phase")
+
+// TagKeyConfigHash defines tag key for the hash of the config of a Receiver.
+var TagKeyConfigHash, _ = tag.NewKey("otelsvc_config_hash")
+
 // ViewReceiverReceivedSpans defines the view for the receiver received spans metric.
 var ViewReceiverReceivedSpans = &view.View{
 	Name:        mReceiverReceivedSpans.Name(),
@@ -84,13 +114,22 @@ var ViewReceiverDroppedTimeSeries = &view.View{
 	TagKeys:     []tag.Key{TagKeyReceiver},
 }
 
+// ViewReceiverEmptyScrapes defines the view for the receiver empty scrapes metric.
+var ViewReceiverEmptyScrapes = &view.View{
+	Name:        mReceiverEmptyScrapes.Name(),
+	Description: mReceiverEmptyScrapes.Description(),
+	Measure:     mReceiverEmptyScrapes,
+	Aggregation: view.Sum(),
+	TagKeys:     []tag.Key{TagKeyReceiver},
+}
+
 // ViewExporterReceivedSpans defines the view for the exporter received spans metric.
 var ViewExporterReceivedSpans = &view.View{
 	Name:        mExporterReceivedSpans.Name(),
 	Description: mExporterReceivedSpans.Description(),
 	Measure:     mExporterReceivedSpans,
 	Aggregation: view.Sum(),
-	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyExporter},
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyPipeline, TagKeyExporter},
 }
 
 // ViewExporterDroppedSpans defines the view for the exporter dropped spans metric.
@@ -99,7 +138,7 @@ var ViewExporterDroppedSpans = &view.View{
 	Description: mExporterDroppedSpans.Description(),
 	Measure:     mExporterDroppedSpans,
 	Aggregation: view.Sum(),
-	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyExporter},
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyPipeline, TagKeyExporter},
 }
 
 // ViewExporterReceivedTimeSeries defines the view for the exporter received timeseries metric.
@@ -108,7 +147,7 @@ var ViewExporterReceivedTimeSeries = &view.View{
 	Description: mExporterReceivedTimeSeries.Description(),
 	Measure:     mExporterReceivedTimeSeries,
 	Aggregation: view.Sum(),
-	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyExporter},
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyPipeline, TagKeyExporter},
 }
 
 // ViewExporterDroppedTimeSeries defines the view for the exporter dropped timeseries metric.
@@ -117,7 +156,156 @@ var ViewExporterDroppedTimeSeries = &view.View{
 	Description: mExporterDroppedTimeSeries.Description(),
 	Measure:     mExporterDroppedTimeSeries,
 	Aggregation: view.Sum(),
-	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyExporter},
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyPipeline, TagKeyExporter},
+}
+
+// ViewExporterActiveRequests defines the view for the exporter active requests metric.
+var ViewExporterActiveRequests = &view.View{
+	Name:        mExporterActiveRequests.Name(),
+	Description: mExporterActiveRequests.Description(),
+	Measure:     mExporterActiveRequests,
+	Aggregation: view.LastValue(),
+	TagKeys:     []tag.Key{TagKeyExporter},
+}
+
+// ViewExporterQueuedRequests defines the view for the exporter queued requests metric.
+var ViewExporterQueuedRequests = &view.View{
+	Name:        mExporterQueuedRequests.Name(),
+	Description: mExporterQueuedRequests.Description(),
+	Measure:     mExporterQueuedRequests,
+	Aggregation: view.LastValue(),
+	TagKeys:     []tag.Key{TagKeyExporter},
+}
+
+// ViewExporterRejectedRequests defines the view for the exporter rejected requests metric.
+var ViewExporterRejectedRequests = &view.View{
+	Name:        mExporterRejectedRequests.Name(),
+	Description: mExporterRejectedRequests.Description(),
+	Measure:     mExporterRejectedRequests,
+	Aggregation: view.Sum(),
+	TagKeys:     []tag.Key{TagKeyExporter},
+}
+
+// ViewReceiverPartialScrapes defines the view for the receiver partial scrapes metric.
+var ViewReceiverPartialScrapes = &view.View{
+	Name:        mReceiverPartialScrapes.Name(),
+	Description: mReceiverPartialScrapes.Description(),
+	Measure:     mReceiverPartialScrapes,
+	Aggregation: view.Sum(),
+	TagKeys:     []tag.Key{TagKeyReceiver},
+}
+
+// ViewReceiverDroppedTargets defines the view for the receiver dropped targets metric. It holds the number of targets
+// dropped by the last discovery update.
+var ViewReceiverDroppedTargets = &view.View{
+	Name:        mReceiverDroppedTargets.Name(),
+	Description: mReceiverDroppedTargets.Description(),
+	Measure:     mReceiverDroppedTargets,
+	Aggregation: view.LastValue(),
+	TagKeys:     []tag.Key{TagKeyReceiver},
+}
+
+// ViewReceiverEvictedTargets defines the view for the receiver evicted targets metric.
+var ViewReceiverEvictedTargets = &view.View{
+	Name:        mReceiverEvictedTargets.Name(),
+	Description: mReceiverEvictedTargets.Description(),
+	Measure:     mReceiverEvictedTargets,
+	Aggregation: view.Sum(),
+	TagKeys:     []tag.Key{TagKeyReceiver},
+}
+
+// ViewReceiverScrapeJobDisabled defines the view for the receiver scrape job disabled metric.
+var ViewReceiverScrapeJobDisabled = &view.View{
+	Name:        mReceiverScrapeJobDisabled.Name(),
+	Description: mReceiverScrapeJobDisabled.Description(),
+	Measure:     mReceiverScrapeJobDisabled,
+	Aggregation: view.LastValue(),
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyScrapeJob},
+}
+
+// ViewReceiverConfigHash defines the view for the receiver config hash metric. The hash of the applied config has
+// the value 1, the hashes of the configs it replaced have the value 0.
+var ViewReceiverConfigHash = &view.View{
+	Name:        mReceiverConfigHash.Name(),
+	Description: mReceiverConfigHash.Description(),
+	Measure:     mReceiverConfigHash,
+	Aggregation: view.LastValue(),
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyConfigHash},
+}
+
+// ViewReceiverScrapeSize defines the view for the receiver scrape size metric, a histogram per scrape job.
+var ViewReceiverScrapeSize = &view.View{
+	Name:        mReceiverScrapeSize.Name(),
+	Description: mReceiverScrapeSize.Description(),
+	Measure:     mReceiverScrapeSize,
+	// From 1KiB to 64MiB.
+	Aggregation: view.Distribution(1<<10, 1<<12, 1<<14, 1<<16, 1<<18, 1<<20, 1<<22, 1<<24, 1<<26),
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyScrapeJob},
+}
+
+// ViewReceiverScrapeSeries defines the view for the receiver scrape series metric, a histogram per scrape job.
+var ViewReceiverScrapeSeries = &view.View{
+	Name:        mReceiverScrapeSeries.Name(),
+	Description: mReceiverScrapeSeries.Description(),
+	Measure:     mReceiverScrapeSeries,
+	Aggregation: view.Distribution(10, 100, 1000, 5000, 10000, 50000, 100000, 500000, 1000000),
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyScrapeJob},
+}
+
+// ViewReceiverScrapeBackoff defines the view for the receiver scrape backoff metric. It holds the last pause of the
+// scrapes applied because of a slow consumer.
+var ViewReceiverScrapeBackoff = &view.View{
+	Name:        mReceiverScrapeBackoff.Name(),
+	Description: mReceiverScrapeBackoff.Description(),
+	Measure:     mReceiverScrapeBackoff,
+	Aggregation: view.LastValue(),
+	TagKeys:     []tag.Key{TagKeyReceiver},
+}
+
+// ViewReceiverRepairedHistograms defines the view for the receiver repaired histograms metric.
+var ViewReceiverRepairedHistograms = &view.View{
+	Name:        mReceiverRepairedHistograms.Name(),
+	Description: mReceiverRepairedHistograms.Description(),
+	Measure:     mReceiverRepairedHistograms,
+	Aggregation: view.Sum(),
+	TagKeys:     []tag.Key{TagKeyReceiver},
+}
+
+// ViewReceiverNaNBucketHistograms defines the view for the receiver NaN bucket histograms metric.
+var ViewReceiverNaNBucketHistograms = &view.View{
+	Name:        mReceiverNaNBucketHistograms.Name(),
+	Description: mReceiverNaNBucketHistograms.Description(),
+	Measure:     mReceiverNaNBucketHistograms,
+	Aggregation: view.Sum(),
+	TagKeys:     []tag.Key{TagKeyReceiver},
+}
+
+// ViewReceiverEvictedSeries defines the view for the receiver evicted series metric.
+var ViewReceiverEvictedSeries = &view.View{
+	Name:        mReceiverEvictedSeries.Name(),
+	Description: mReceiverEvictedSeries.Description(),
+	Measure:     mReceiverEvictedSeries,
+	Aggregation: view.Sum(),
+	TagKeys:     []tag.Key{TagKeyReceiver},
+}
+
+// ViewReceiverDiscoveryReadyTime defines the view for the receiver discovery ready time metric. It holds, per scrape
+// job, how long the service discovery took to list the targets of the job the last time its config was applied.
+var ViewReceiverDiscoveryReadyTime = &view.View{
+	Name:        mReceiverDiscoveryReadyTime.Name(),
+	Description: mReceiverDiscoveryReadyTime.Description(),
+	Measure:     mReceiverDiscoveryReadyTime,
+	Aggregation: view.LastValue(),
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyScrapeJob},
+}
+
+// ViewReceiverScrapePhase defines the view for the receiver scrape phase metric, a histogram per scrape job and phase.
+var ViewReceiverScrapePhase = &view.View{
+	Name:        mReceiverScrapePhase.Name(),
+	Description: mReceiverScrapePhase.Description(),
+	Measure:     mReceiverScrapePhase,
+	Aggregation: view.Distribution(1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000),
+	TagKeys:     []tag.Key{TagKeyReceiver, TagKeyScrapeJob, TagKeyScrapePhase},
 }
 
 // AllViews has the views for the metrics provided by the agent.
@@ -126,10 +314,27 @@ var AllViews = []*view.View{
 	ViewReceiverDroppedSpans,
 	ViewReceiverReceivedTimeSeries,
 	ViewReceiverDroppedTimeSeries,
+	ViewReceiverEmptyScrapes,
+	ViewReceiverPartialScrapes,
+	ViewReceiverDroppedTargets,
+	ViewReceiverEvictedTargets,
+	ViewReceiverScrapeJobDisabled,
+	ViewReceiverConfigHash,
+	ViewReceiverScrapeBackoff,
+	ViewReceiverScrapeSize,
+	ViewReceiverScrapeSeries,
+	ViewReceiverRepairedHistograms,
+	ViewReceiverNaNBucketHistograms,
+	ViewReceiverEvictedSeries,
+	ViewReceiverDiscoveryReadyTime,
+	ViewReceiverScrapePhase,
 	ViewExporterReceivedSpans,
 	ViewExporterDroppedSpans,
 	ViewExporterReceivedTimeSeries,
 	ViewExporterDroppedTimeSeries,
+	ViewExporterActiveRequests,
+	ViewExporterQueuedRequests,
+	ViewExporterRejectedRequests,
 }
 
 // ContextWithReceiverName adds the tag "otelsvc_receiver" and the name of the receiver as the value,
@@ -152,6 +357,133 @@ func RecordMetricsForMetricsReceiver(ctxWithTraceReceiverName context.Context, r
 	stats.Record(ctxWithTraceReceiverName, mReceiverReceivedTimeSeries.M(int64(receivedTimeSeries)), mReceiverDroppedTimeSeries.M(int64(droppedTimeSeries)))
 }
 
+// RecordEmptyScrapeForMetricsReceiver records a successful scrape that returned no data.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordEmptyScrapeForMetricsReceiver(ctxWithMetricsReceiverName context.Context) {
+	stats.Record(ctxWithMetricsReceiverName, mReceiverEmptyScrapes.M(1))
+}
+
+// RecordPartialScrapeForMetricsReceiver records a scrape with invalid content whose samples parsed before it were
+// committed.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordPartialScrapeForMetricsReceiver(ctxWithMetricsReceiverName context.Context) {
+	stats.Record(ctxWithMetricsReceiverName, mReceiverPartialScrapes.M(1))
+}
+
+// RecordDroppedTargetsForMetricsReceiver records the number of targets dropped by the last discovery update.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordDroppedTargetsForMetricsReceiver(ctxWithMetricsReceiverName context.Context, numDroppedTargets int) {
+	stats.Record(ctxWithMetricsReceiverName, mReceiverDroppedTargets.M(int64(numDroppedTargets)))
+}
+
+// RecordEvictedTargetsForMetricsReceiver records the number of targets evicted because they failed to be scraped
+// for too long.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordEvictedTargetsForMetricsReceiver(ctxWithMetricsReceiverName context.Context, numEvictedTargets int) {
+	stats.Record(ctxWithMetricsReceiverName, mReceiverEvictedTargets.M(int64(numEvictedTargets)))
+}
+
+// RecordScrapeJobStateForMetricsReceiver records whether the given scrape job is disabled.
+// The job is subject to the limit set by SetMaxTagValues.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordScrapeJobStateForMetricsReceiver(ctxWithMetricsReceiverName context.Context, job string, disabled bool) {
+	ctx, _ := tag.New(ctxWithMetricsReceiverName,
+		tag.Upsert(TagKeyScrapeJob, LimitTagValue(TagKeyScrapeJob, job), tag.WithTTL(tag.TTLNoPropagation)))
+	state := int64(0)
+	if disabled {
+		state = 1
+	}
+	stats.Record(ctx, mReceiverScrapeJobDisabled.M(state))
+}
+
+// RecordConfigHashForMetricsReceiver records whether the config with the given hash is the one applied.
+// The hash is subject to the limit set by SetMaxTagValues.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordConfigHashForMetricsReceiver(ctxWithMetricsReceiverName context.Context, hash string, applied bool) {
+	ctx, _ := tag.New(ctxWithMetricsReceiverName,
+		tag.Upsert(TagKeyConfigHash, LimitTagValue(TagKeyConfigHash, hash), tag.WithTTL(tag.TTLNoPropagation)))
+	state := int64(0)
+	if applied {
+		state = 1
+	}
+	stats.Record(ctx, mReceiverConfigHash.M(state))
+}
+
+// RecordScrapeBackoffForMetricsReceiver records how long the scrapes are paused for because the consumer is
+// persistently slow, 0 once it caught up.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordScrapeBackoffForMetricsReceiver(ctxWithMetricsReceiverName context.Context, backoff time.Duration) {
+	stats.Record(ctxWithMetricsReceiverName, mReceiverScrapeBackoff.M(int64(backoff/time.Millisecond)))
+}
+
+// RecordScrapeSizeForMetricsReceiver records the size in bytes of the samples of a scrape of the given job.
+// The job is subject to the limit set by SetMaxTagValues.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordScrapeSizeForMetricsReceiver(ctxWithMetricsReceiverName context.Context, job string, size int) {
+	ctx, _ := tag.New(ctxWithMetricsReceiverName,
+		tag.Upsert(TagKeyScrapeJob, LimitTagValue(TagKeyScrapeJob, job), tag.WithTTL(tag.TTLNoPropagation)))
+	stats.Record(ctx, mReceiverScrapeSize.M(int64(size)))
+}
+
+// RecordScrapeSeriesForMetricsReceiver records the number of series exposed by a target of the given job.
+// The job is subject to the limit set by SetMaxTagValues.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordScrapeSeriesForMetricsReceiver(ctxWithMetricsReceiverName context.Context, job string, numSeries int) {
+	ctx, _ := tag.New(ctxWithMetricsReceiverName,
+		tag.Upsert(TagKeyScrapeJob, LimitTagValue(TagKeyScrapeJob, job), tag.WithTTL(tag.TTLNoPropagation)))
+	stats.Record(ctx, mReceiverScrapeSeries.M(int64(numSeries)))
+}
+
+// RecordRepairedHistogramsForMetricsReceiver records the number of histogram series missing their _count or _sum
+// which were repaired.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordRepairedHistogramsForMetricsReceiver(ctxWithMetricsReceiverName context.Context, numRepaired int) {
+	stats.Record(ctxWithMetricsReceiverName, mReceiverRepairedHistograms.M(int64(numRepaired)))
+}
+
+// RecordNaNBucketHistogramsForMetricsReceiver records the number of histogram series with a bucket whose value is
+// NaN.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordNaNBucketHistogramsForMetricsReceiver(ctxWithMetricsReceiverName context.Context, numHistograms int) {
+	stats.Record(ctxWithMetricsReceiverName, mReceiverNaNBucketHistograms.M(int64(numHistograms)))
+}
+
+// RecordEvictedSeriesForMetricsReceiver records the number of series whose start time tracking was evicted because
+// the receiver max tracked series was exceeded.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordEvictedSeriesForMetricsReceiver(ctxWithMetricsReceiverName context.Context, numEvictedSeries int) {
+	stats.Record(ctxWithMetricsReceiverName, mReceiverEvictedSeries.M(int64(numEvictedSeries)))
+}
+
+// RecordDiscoveryReadyTimeForMetricsReceiver records how long the service discovery took to list the targets of the
+// given job after its config was applied.
+// The job is subject to the limit set by SetMaxTagValues.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordDiscoveryReadyTimeForMetricsReceiver(ctxWithMetricsReceiverName context.Context, job string, readyTime time.Duration) {
+	ctx, _ := tag.New(ctxWithMetricsReceiverName,
+		tag.Upsert(TagKeyScrapeJob, LimitTagValue(TagKeyScrapeJob, job), tag.WithTTL(tag.TTLNoPropagation)))
+	stats.Record(ctx, mReceiverDiscoveryReadyTime.M(int64(readyTime/time.Millisecond)))
+}
+
+// RecordScrapePhaseForMetricsReceiver records the duration of a phase, e.g. the TLS handshake, of a scrape request of
+// the given job.
+// The job is subject to the limit set by SetMaxTagValues.
+// Use it with a context.Context generated using ContextWithReceiverName().
+func RecordScrapePhaseForMetricsReceiver(ctxWithMetricsReceiverName context.Context, job string, phase string, duration time.Duration) {
+	ctx, _ := tag.New(ctxWithMetricsReceiverName,
+		tag.Upsert(TagKeyScrapeJob, LimitTagValue(TagKeyScrapeJob, job), tag.WithTTL(tag.TTLNoPropagation)),
+		tag.Upsert(TagKeyScrapePhase, phase, tag.WithTTL(tag.TTLNoPropagation)))
+	stats.Record(ctx, mReceiverScrapePhase.M(float64(duration)/float64(time.Millisecond)))
+}
+
+// ContextWithPipelineName adds the tag "otelsvc_pipeline" and the name of the pipeline as the value,
+// and returns the newly created context. The exporter metrics recorded with a context derived from it
+// are attributed to the pipeline, which distinguishes the data of the pipelines sharing an exporter.
+func ContextWithPipelineName(ctx context.Context, pipelineName string) context.Context {
+	ctx, _ = tag.New(ctx, tag.Upsert(TagKeyPipeline, pipelineName, tag.WithTTL(tag.TTLNoPropagation)))
+	return ctx
+}
+
 // ContextWithExporterName adds the tag "otelsvc_exporter" and the name of the exporter as the value,
 // and returns the newly created context. For exporters that can export multiple signals it is
 // recommended to encode the signal as suffix (e.g. "oc_trace" and "oc_metrics").
@@ -172,6 +504,20 @@ func RecordMetricsForMetricsExporter(ctx context.Context, receivedTimeSeries int
 	stats.Record(ctx, mExporterReceivedTimeSeries.M(int64(receivedTimeSeries)), mExporterDroppedTimeSeries.M(int64(droppedTimeSeries)))
 }
 
+// RecordConcurrencyForExporter records the number of requests the exporter is sending and the number of requests
+// waiting for its max concurrent requests to allow them.
+// Use it with a context.Context generated using ContextWithExporterName().
+func RecordConcurrencyForExporter(ctx context.Context, activeRequests int, queuedRequests int) {
+	stats.Record(ctx, mExporterActiveRequests.M(int64(activeRequests)), mExporterQueuedRequests.M(int64(queuedRequests)))
+}
+
+// RecordRejectedRequestForExporter records a request rejected because the exporter max concurrent requests was
+// exceeded.
+// Use it with a context.Context generated using ContextWithExporterName().
+func RecordRejectedRequestForExporter(ctx context.Context) {
+	stats.Record(ctx, mExporterRejectedRequests.M(1))
+}
+
 // GRPCServerWithObservabilityEnabled creates a gRPC server that at a bare minimum has
 // the OpenCensus ocgrpc server stats handler enabled for tracing and stats.
 // Use it instead of invoking grpc.NewServer directly.
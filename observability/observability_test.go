@@ -18,7 +18,9 @@ package observability_test
 
 import (
 	"context"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -74,3 +76,296 @@ func TestMetricsPieplineRecordedMetrics(t *testing.T) {
 	err = observabilitytest.CheckValueViewExporterDroppedTimeSeries(receiverName, exporterName, 23)
 	require.Nil(t, err, "When check exporter dropped timeseries")
 }
+
+func TestEmptyScrapesRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordEmptyScrapeForMetricsReceiver(receiverCtx)
+	observability.RecordEmptyScrapeForMetricsReceiver(receiverCtx)
+
+	err := observabilitytest.CheckValueViewReceiverEmptyScrapes(receiverName, 2)
+	require.Nil(t, err, "When check receiver empty scrapes")
+}
+
+func TestPartialScrapesRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordPartialScrapeForMetricsReceiver(receiverCtx)
+	observability.RecordPartialScrapeForMetricsReceiver(receiverCtx)
+
+	err := observabilitytest.CheckValueViewReceiverPartialScrapes(receiverName, 2)
+	require.Nil(t, err, "When check receiver partial scrapes")
+}
+
+func TestDroppedTargetsRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordDroppedTargetsForMetricsReceiver(receiverCtx, 5)
+	observability.RecordDroppedTargetsForMetricsReceiver(receiverCtx, 3)
+
+	err := observabilitytest.CheckValueViewReceiverDroppedTargets(receiverName, 3)
+	require.Nil(t, err, "When check receiver dropped targets")
+}
+
+func TestEvictedTargetsRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordEvictedTargetsForMetricsReceiver(receiverCtx, 2)
+	observability.RecordEvictedTargetsForMetricsReceiver(receiverCtx, 1)
+
+	err := observabilitytest.CheckValueViewReceiverEvictedTargets(receiverName, 3)
+	require.Nil(t, err, "When check receiver evicted targets")
+}
+
+func TestScrapeJobStateRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordScrapeJobStateForMetricsReceiver(receiverCtx, "job_a", true)
+	observability.RecordScrapeJobStateForMetricsReceiver(receiverCtx, "job_b", true)
+	observability.RecordScrapeJobStateForMetricsReceiver(receiverCtx, "job_b", false)
+
+	err := observabilitytest.CheckValueViewReceiverScrapeJobDisabled(receiverName, "job_a", 1)
+	require.Nil(t, err, "When check receiver scrape job disabled")
+	err = observabilitytest.CheckValueViewReceiverScrapeJobDisabled(receiverName, "job_b", 0)
+	require.Nil(t, err, "When check receiver scrape job disabled")
+}
+
+func TestScrapeJobStateJobsAreCapped(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+	observability.SetMaxTagValues(2)
+	defer observability.SetMaxTagValues(0)
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordScrapeJobStateForMetricsReceiver(receiverCtx, "job_a", true)
+	observability.RecordScrapeJobStateForMetricsReceiver(receiverCtx, "job_b", true)
+	observability.RecordScrapeJobStateForMetricsReceiver(receiverCtx, "job_c", true)
+	observability.RecordScrapeJobStateForMetricsReceiver(receiverCtx, "job_d", false)
+
+	err := observabilitytest.CheckValueViewReceiverScrapeJobDisabled(receiverName, "job_a", 1)
+	require.Nil(t, err, "When check receiver scrape job disabled")
+	err = observabilitytest.CheckValueViewReceiverScrapeJobDisabled(receiverName, "job_b", 1)
+	require.Nil(t, err, "When check receiver scrape job disabled")
+	err = observabilitytest.CheckValueViewReceiverScrapeJobDisabled(receiverName, observability.OtherTagValue, 0)
+	require.Nil(t, err, "When check receiver scrape job disabled")
+	err = observabilitytest.CheckValueViewReceiverScrapeJobDisabled(receiverName, "job_c", 1)
+	require.NotNil(t, err, "The jobs beyond the limit must not be recorded")
+}
+
+func TestScrapeSizeRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordScrapeSizeForMetricsReceiver(receiverCtx, "job_a", 2000)
+	observability.RecordScrapeSizeForMetricsReceiver(receiverCtx, "job_a", 3000)
+	observability.RecordScrapeSizeForMetricsReceiver(receiverCtx, "job_b", 1<<20)
+	observability.RecordScrapeSeriesForMetricsReceiver(receiverCtx, "job_a", 20)
+
+	err := observabilitytest.CheckValueViewReceiverScrapeSize(receiverName, "job_a", 2)
+	require.Nil(t, err, "When check receiver scrape size")
+	err = observabilitytest.CheckValueViewReceiverScrapeSize(receiverName, "job_b", 1)
+	require.Nil(t, err, "When check receiver scrape size")
+	err = observabilitytest.CheckValueViewReceiverScrapeSeries(receiverName, "job_a", 1)
+	require.Nil(t, err, "When check receiver scrape series")
+}
+
+func TestScrapeSizeJobsAreCapped(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+	observability.SetMaxTagValues(1)
+	defer observability.SetMaxTagValues(0)
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	for _, job := range []string{"job_a", "job_b", "job_c"} {
+		observability.RecordScrapeSizeForMetricsReceiver(receiverCtx, job, 2000)
+		observability.RecordScrapeSeriesForMetricsReceiver(receiverCtx, job, 20)
+	}
+
+	err := observabilitytest.CheckValueViewReceiverScrapeSize(receiverName, "job_a", 1)
+	require.Nil(t, err, "When check receiver scrape size")
+	err = observabilitytest.CheckValueViewReceiverScrapeSize(receiverName, observability.OtherTagValue, 2)
+	require.Nil(t, err, "When check receiver scrape size")
+	err = observabilitytest.CheckValueViewReceiverScrapeSeries(receiverName, "job_a", 1)
+	require.Nil(t, err, "When check receiver scrape series")
+	err = observabilitytest.CheckValueViewReceiverScrapeSeries(receiverName, observability.OtherTagValue, 2)
+	require.Nil(t, err, "When check receiver scrape series")
+}
+
+func TestScrapePhaseRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordScrapePhaseForMetricsReceiver(receiverCtx, "job_a", "connect", time.Millisecond)
+	observability.RecordScrapePhaseForMetricsReceiver(receiverCtx, "job_a", "connect", 2*time.Millisecond)
+	observability.RecordScrapePhaseForMetricsReceiver(receiverCtx, "job_a", "tls_handshake", 30*time.Millisecond)
+
+	err := observabilitytest.CheckValueViewReceiverScrapePhase(receiverName, "job_a", "connect", 2)
+	require.Nil(t, err, "When check receiver scrape phase")
+	err = observabilitytest.CheckValueViewReceiverScrapePhase(receiverName, "job_a", "tls_handshake", 1)
+	require.Nil(t, err, "When check receiver scrape phase")
+}
+
+func TestScrapePhaseJobsAreCapped(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+	observability.SetMaxTagValues(1)
+	defer observability.SetMaxTagValues(0)
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	for _, job := range []string{"job_a", "job_b", "job_c"} {
+		observability.RecordScrapePhaseForMetricsReceiver(receiverCtx, job, "connect", time.Millisecond)
+	}
+
+	err := observabilitytest.CheckValueViewReceiverScrapePhase(receiverName, "job_a", "connect", 1)
+	require.Nil(t, err, "When check receiver scrape phase")
+	err = observabilitytest.CheckValueViewReceiverScrapePhase(receiverName, observability.OtherTagValue, "connect", 2)
+	require.Nil(t, err, "When check receiver scrape phase")
+}
+
+func TestConfigHashRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordConfigHashForMetricsReceiver(receiverCtx, "0123abcd", true)
+	observability.RecordConfigHashForMetricsReceiver(receiverCtx, "0123abcd", false)
+	observability.RecordConfigHashForMetricsReceiver(receiverCtx, "4567ef01", true)
+
+	err := observabilitytest.CheckValueViewReceiverConfigHash(receiverName, "0123abcd", 0)
+	require.Nil(t, err, "When check receiver config hash")
+	err = observabilitytest.CheckValueViewReceiverConfigHash(receiverName, "4567ef01", 1)
+	require.Nil(t, err, "When check receiver config hash")
+}
+
+func TestScrapeBackoffRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordScrapeBackoffForMetricsReceiver(receiverCtx, 2*time.Second)
+	observability.RecordScrapeBackoffForMetricsReceiver(receiverCtx, 500*time.Millisecond)
+
+	err := observabilitytest.CheckValueViewReceiverScrapeBackoff(receiverName, 500)
+	require.Nil(t, err, "When check receiver scrape backoff")
+}
+
+func TestRepairedHistogramsRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordRepairedHistogramsForMetricsReceiver(receiverCtx, 2)
+	observability.RecordRepairedHistogramsForMetricsReceiver(receiverCtx, 3)
+
+	err := observabilitytest.CheckValueViewReceiverRepairedHistograms(receiverName, 5)
+	require.Nil(t, err, "When check receiver repaired histograms")
+}
+
+func TestNaNBucketHistogramsRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordNaNBucketHistogramsForMetricsReceiver(receiverCtx, 1)
+	observability.RecordNaNBucketHistogramsForMetricsReceiver(receiverCtx, 2)
+
+	err := observabilitytest.CheckValueViewReceiverNaNBucketHistograms(receiverName, 3)
+	require.Nil(t, err, "When check receiver NaN bucket histograms")
+}
+
+func TestEvictedSeriesRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordEvictedSeriesForMetricsReceiver(receiverCtx, 4)
+	observability.RecordEvictedSeriesForMetricsReceiver(receiverCtx, 6)
+
+	err := observabilitytest.CheckValueViewReceiverEvictedSeries(receiverName, 10)
+	require.Nil(t, err, "When check receiver evicted series")
+}
+
+func TestExporterConcurrencyRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	exporterCtx := observability.ContextWithExporterName(context.Background(), exporterName)
+	observability.RecordConcurrencyForExporter(exporterCtx, 4, 2)
+	observability.RecordConcurrencyForExporter(exporterCtx, 3, 0)
+	observability.RecordRejectedRequestForExporter(exporterCtx)
+	observability.RecordRejectedRequestForExporter(exporterCtx)
+
+	err := observabilitytest.CheckValueViewExporterActiveRequests(exporterName, 3)
+	require.Nil(t, err, "When check exporter active requests")
+	err = observabilitytest.CheckValueViewExporterQueuedRequests(exporterName, 0)
+	require.Nil(t, err, "When check exporter queued requests")
+	err = observabilitytest.CheckValueViewExporterRejectedRequests(exporterName, 2)
+	require.Nil(t, err, "When check exporter rejected requests")
+}
+
+func TestDiscoveryReadyTimeRecordedMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordDiscoveryReadyTimeForMetricsReceiver(receiverCtx, "job_a", 3*time.Second)
+	observability.RecordDiscoveryReadyTimeForMetricsReceiver(receiverCtx, "job_a", 1500*time.Millisecond)
+	observability.RecordDiscoveryReadyTimeForMetricsReceiver(receiverCtx, "job_b", 20*time.Millisecond)
+
+	err := observabilitytest.CheckValueViewReceiverDiscoveryReadyTime(receiverName, "job_a", 1500)
+	require.Nil(t, err, "When check receiver discovery ready time")
+	err = observabilitytest.CheckValueViewReceiverDiscoveryReadyTime(receiverName, "job_b", 20)
+	require.Nil(t, err, "When check receiver discovery ready time")
+}
+
+func TestTagValuesCardinalityIsCapped(t *testing.T) {
+	const maxTagValues = 3
+	observability.SetMaxTagValues(maxTagValues)
+	defer observability.SetMaxTagValues(0)
+
+	// More jobs than the limit are scraped, the first ones are admitted.
+	for i := 0; i < 10; i++ {
+		job := "job" + strconv.Itoa(i)
+		want := job
+		if i >= maxTagValues {
+			want = observability.OtherTagValue
+		}
+		require.Equal(t, want, observability.LimitTagValue(observability.TagKeyScrapeJob, job))
+	}
+	// The admitted jobs keep being recorded.
+	require.Equal(t, "job0", observability.LimitTagValue(observability.TagKeyScrapeJob, "job0"))
+	// Each tag has its own limit.
+	require.Equal(t, "job9", observability.LimitTagValue(observability.TagKeyConfigHash, "job9"))
+
+	observability.SetMaxTagValues(0)
+	require.Equal(t, "job9", observability.LimitTagValue(observability.TagKeyScrapeJob, "job9"))
+}
+
+func TestDiscoveryReadyTimeJobsAreCapped(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+	observability.SetMaxTagValues(1)
+	defer observability.SetMaxTagValues(0)
+
+	receiverCtx := observability.ContextWithReceiverName(context.Background(), receiverName)
+	observability.RecordDiscoveryReadyTimeForMetricsReceiver(receiverCtx, "job_a", 3*time.Second)
+	observability.RecordDiscoveryReadyTimeForMetricsReceiver(receiverCtx, "job_b", 20*time.Millisecond)
+	observability.RecordDiscoveryReadyTimeForMetricsReceiver(receiverCtx, "job_c", 40*time.Millisecond)
+
+	err := observabilitytest.CheckValueViewReceiverDiscoveryReadyTime(receiverName, "job_a", 3000)
+	require.Nil(t, err, "When check receiver discovery ready time")
+	err = observabilitytest.CheckValueViewReceiverDiscoveryReadyTime(receiverName, observability.OtherTagValue, 40)
+	require.Nil(t, err, "When check receiver discovery ready time")
+}
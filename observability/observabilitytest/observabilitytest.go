@@ -44,6 +44,14 @@ func CheckValueViewExporterReceivedSpans(receiverName string, exporterTagName st
 		wantsTagsForExporterView(receiverName, exporterTagName), int64(value))
 }
 
+// CheckValueViewPipelineExporterReceivedSpans checks that for the current exported value in the ViewExporterReceivedSpans
+// for {TagKeyReceiver: receiverName, TagKeyPipeline: pipelineName, TagKeyExporter: exporterTagName} is equal to "value".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewPipelineExporterReceivedSpans(receiverName string, pipelineName string, exporterTagName string, value int) error {
+	return checkValueForView(observability.ViewExporterReceivedSpans.Name,
+		wantsTagsForPipelineExporterView(receiverName, pipelineName, exporterTagName), int64(value))
+}
+
 // CheckValueViewExporterDroppedSpans checks that for the current exported value in the ViewExporterDroppedSpans
 // for {TagKeyReceiver: receiverName} is equal to "value".
 // In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
@@ -60,6 +68,15 @@ func CheckValueViewExporterReceivedTimeSeries(receiverName string, exporterTagNa
 		wantsTagsForExporterView(receiverName, exporterTagName), int64(value))
 }
 
+// CheckValueViewPipelineExporterReceivedTimeSeries checks that for the current exported value in the
+// ViewExporterReceivedTimeSeries for {TagKeyReceiver: receiverName, TagKeyPipeline: pipelineName,
+// TagKeyExporter: exporterTagName} is equal to "value".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewPipelineExporterReceivedTimeSeries(receiverName string, pipelineName string, exporterTagName string, value int) error {
+	return checkValueForView(observability.ViewExporterReceivedTimeSeries.Name,
+		wantsTagsForPipelineExporterView(receiverName, pipelineName, exporterTagName), int64(value))
+}
+
 // CheckValueViewExporterDroppedTimeSeries checks that for the current exported value in the ViewExporterDroppedTimeSeries
 // for {TagKeyReceiver: receiverName} is equal to "value".
 // In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
@@ -68,6 +85,30 @@ func CheckValueViewExporterDroppedTimeSeries(receiverName string, exporterTagNam
 		wantsTagsForExporterView(receiverName, exporterTagName), int64(value))
 }
 
+// CheckValueViewExporterActiveRequests checks that for the current exported value in the ViewExporterActiveRequests
+// for {TagKeyExporter: exporterTagName} is equal to "value".
+// In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewExporterActiveRequests(exporterTagName string, value int) error {
+	return checkValueForView(observability.ViewExporterActiveRequests.Name,
+		wantsTagsForExporterOnlyView(exporterTagName), int64(value))
+}
+
+// CheckValueViewExporterQueuedRequests checks that for the current exported value in the ViewExporterQueuedRequests
+// for {TagKeyExporter: exporterTagName} is equal to "value".
+// In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewExporterQueuedRequests(exporterTagName string, value int) error {
+	return checkValueForView(observability.ViewExporterQueuedRequests.Name,
+		wantsTagsForExporterOnlyView(exporterTagName), int64(value))
+}
+
+// CheckValueViewExporterRejectedRequests checks that for the current exported value in the
+// ViewExporterRejectedRequests for {TagKeyExporter: exporterTagName} is equal to "value".
+// In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewExporterRejectedRequests(exporterTagName string, value int) error {
+	return checkValueForView(observability.ViewExporterRejectedRequests.Name,
+		wantsTagsForExporterOnlyView(exporterTagName), int64(value))
+}
+
 // CheckValueViewReceiverReceivedSpans checks that for the current exported value in the ViewReceiverReceivedSpans
 // for {TagKeyReceiver: receiverName, TagKeyExporter: exporterTagName} is equal to "value".
 // In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
@@ -100,6 +141,139 @@ func CheckValueViewReceiverDroppedTimeSeries(receiverName string, value int) err
 		wantsTagsForReceiverView(receiverName), int64(value))
 }
 
+// CheckValueViewReceiverEmptyScrapes checks that for the current exported value in the ViewReceiverEmptyScrapes
+// for {TagKeyReceiver: receiverName} is equal to "value".
+// In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverEmptyScrapes(receiverName string, value int) error {
+	return checkValueForView(observability.ViewReceiverEmptyScrapes.Name,
+		wantsTagsForReceiverView(receiverName), int64(value))
+}
+
+// CheckValueViewReceiverPartialScrapes checks that for the current exported value in the ViewReceiverPartialScrapes
+// for {TagKeyReceiver: receiverName} is equal to "value".
+// In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverPartialScrapes(receiverName string, value int) error {
+	return checkValueForView(observability.ViewReceiverPartialScrapes.Name,
+		wantsTagsForReceiverView(receiverName), int64(value))
+}
+
+// CheckValueViewReceiverDroppedTargets checks that for the current exported value in the ViewReceiverDroppedTargets
+// for {TagKeyReceiver: receiverName} is equal to "value".
+// In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverDroppedTargets(receiverName string, value int) error {
+	return checkValueForView(observability.ViewReceiverDroppedTargets.Name,
+		wantsTagsForReceiverView(receiverName), int64(value))
+}
+
+// CheckValueViewReceiverEvictedTargets checks that for the current exported value in the ViewReceiverEvictedTargets
+// for {TagKeyReceiver: receiverName} is equal to "value".
+// In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverEvictedTargets(receiverName string, value int) error {
+	return checkValueForView(observability.ViewReceiverEvictedTargets.Name,
+		wantsTagsForReceiverView(receiverName), int64(value))
+}
+
+// CheckValueViewReceiverScrapeJobDisabled checks that for the current exported value in the ViewReceiverScrapeJobDisabled
+// for {TagKeyReceiver: receiverName, TagKeyScrapeJob: job} is equal to "value".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverScrapeJobDisabled(receiverName string, job string, value int) error {
+	return checkValueForView(observability.ViewReceiverScrapeJobDisabled.Name,
+		[]tag.Tag{
+			{Key: observability.TagKeyReceiver, Value: receiverName},
+			{Key: observability.TagKeyScrapeJob, Value: job},
+		}, int64(value))
+}
+
+// CheckValueViewReceiverScrapeSize checks that for the current exported value in the ViewReceiverScrapeSize
+// for {TagKeyReceiver: receiverName, TagKeyScrapeJob: job} the number of recorded sizes is equal to "count".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverScrapeSize(receiverName string, job string, count int) error {
+	return checkValueForView(observability.ViewReceiverScrapeSize.Name,
+		[]tag.Tag{
+			{Key: observability.TagKeyReceiver, Value: receiverName},
+			{Key: observability.TagKeyScrapeJob, Value: job},
+		}, int64(count))
+}
+
+// CheckValueViewReceiverScrapeSeries checks that for the current exported value in the ViewReceiverScrapeSeries
+// for {TagKeyReceiver: receiverName, TagKeyScrapeJob: job} the number of recorded series counts is equal to "count".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverScrapeSeries(receiverName string, job string, count int) error {
+	return checkValueForView(observability.ViewReceiverScrapeSeries.Name,
+		[]tag.Tag{
+			{Key: observability.TagKeyReceiver, Value: receiverName},
+			{Key: observability.TagKeyScrapeJob, Value: job},
+		}, int64(count))
+}
+
+// CheckValueViewReceiverScrapePhase checks that for the current exported value in the ViewReceiverScrapePhase
+// for {TagKeyReceiver: receiverName, TagKeyScrapeJob: job, TagKeyScrapePhase: phase} the number of recorded durations
+// is equal to "count".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverScrapePhase(receiverName string, job string, phase string, count int) error {
+	return checkValueForView(observability.ViewReceiverScrapePhase.Name,
+		[]tag.Tag{
+			{Key: observability.TagKeyReceiver, Value: receiverName},
+			{Key: observability.TagKeyScrapeJob, Value: job},
+			{Key: observability.TagKeyScrapePhase, Value: phase},
+		}, int64(count))
+}
+
+// CheckValueViewReceiverConfigHash checks that for the current exported value in the ViewReceiverConfigHash
+// for {TagKeyReceiver: receiverName, TagKeyConfigHash: hash} is equal to "value".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverConfigHash(receiverName string, hash string, value int) error {
+	return checkValueForView(observability.ViewReceiverConfigHash.Name,
+		[]tag.Tag{
+			{Key: observability.TagKeyReceiver, Value: receiverName},
+			{Key: observability.TagKeyConfigHash, Value: hash},
+		}, int64(value))
+}
+
+// CheckValueViewReceiverScrapeBackoff checks that for the current exported value in the ViewReceiverScrapeBackoff
+// for {TagKeyReceiver: receiverName} is equal to "value", in milliseconds.
+// In tests that this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverScrapeBackoff(receiverName string, value int) error {
+	return checkValueForView(observability.ViewReceiverScrapeBackoff.Name,
+		wantsTagsForReceiverView(receiverName), int64(value))
+}
+
+// CheckValueViewReceiverRepairedHistograms checks that for the current exported value in the
+// ViewReceiverRepairedHistograms for {TagKeyReceiver: receiverName} is equal to "value".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverRepairedHistograms(receiverName string, value int) error {
+	return checkValueForView(observability.ViewReceiverRepairedHistograms.Name,
+		wantsTagsForReceiverView(receiverName), int64(value))
+}
+
+// CheckValueViewReceiverNaNBucketHistograms checks that for the current exported value in the
+// ViewReceiverNaNBucketHistograms for {TagKeyReceiver: receiverName} is equal to "value".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverNaNBucketHistograms(receiverName string, value int) error {
+	return checkValueForView(observability.ViewReceiverNaNBucketHistograms.Name,
+		wantsTagsForReceiverView(receiverName), int64(value))
+}
+
+// CheckValueViewReceiverEvictedSeries checks that for the current exported value in the ViewReceiverEvictedSeries
+// for {TagKeyReceiver: receiverName} is equal to "value".
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverEvictedSeries(receiverName string, value int) error {
+	return checkValueForView(observability.ViewReceiverEvictedSeries.Name,
+		wantsTagsForReceiverView(receiverName), int64(value))
+}
+
+// CheckValueViewReceiverDiscoveryReadyTime checks that for the current exported value in the
+// ViewReceiverDiscoveryReadyTime for {TagKeyReceiver: receiverName, TagKeyScrapeJob: job} is equal to "value", in
+// milliseconds.
+// When this function is called it is required to also call SetupRecordedMetricsTest as first thing.
+func CheckValueViewReceiverDiscoveryReadyTime(receiverName string, job string, value int) error {
+	return checkValueForView(observability.ViewReceiverDiscoveryReadyTime.Name,
+		[]tag.Tag{
+			{Key: observability.TagKeyReceiver, Value: receiverName},
+			{Key: observability.TagKeyScrapeJob, Value: job},
+		}, int64(value))
+}
+
 func checkValueForView(vName string, wantTags []tag.Tag, value int64) error {
 	// Make sure the tags slice is sorted by tag keys.
 	sortTags(wantTags)
@@ -113,9 +287,18 @@ func checkValueForView(vName string, wantTags []tag.Tag, value int64) error {
 		// Make sure the tags slice is sorted by tag keys.
 		sortTags(row.Tags)
 		if reflect.DeepEqual(wantTags, row.Tags) {
-			sum := row.Data.(*view.SumData)
-			if float64(value) != sum.Value {
-				return fmt.Errorf("different recorded value: want %v got %v", float64(value), sum.Value)
+			var got float64
+			switch data := row.Data.(type) {
+			case *view.SumData:
+				got = data.Value
+			case *view.LastValueData:
+				got = data.Value
+			case *view.DistributionData:
+				// The number of values recorded.
+				got = float64(data.Count)
+			}
+			if float64(value) != got {
+				return fmt.Errorf("different recorded value: want %v got %v", float64(value), got)
 			}
 			// We found the result
 			return nil
@@ -131,6 +314,20 @@ func wantsTagsForExporterView(receiverName string, exporterTagName string) []tag
 	}
 }
 
+func wantsTagsForPipelineExporterView(receiverName string, pipelineName string, exporterTagName string) []tag.Tag {
+	return []tag.Tag{
+		{Key: observability.TagKeyReceiver, Value: receiverName},
+		{Key: observability.TagKeyPipeline, Value: pipelineName},
+		{Key: observability.TagKeyExporter, Value: exporterTagName},
+	}
+}
+
+func wantsTagsForExporterOnlyView(exporterTagName string) []tag.Tag {
+	return []tag.Tag{
+		{Key: observability.TagKeyExporter, Value: exporterTagName},
+	}
+}
+
 func wantsTagsForReceiverView(receiverName string) []tag.Tag {
 	return []tag.Tag{
 		{Key: observability.TagKeyReceiver, Value: receiverName},
@@ -0,0 +1,80 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observability
+
+import (
+	"sync"
+
+	"go.opencensus.io/tag"
+)
+
+// OtherTagValue is the value recorded instead of the values of a tag seen once the limit set by SetMaxTagValues is
+// reached.
+const OtherTagValue = "__other__"
+
+var tagValues = &tagValueLimiter{}
+
+// SetMaxTagValues limits the number of distinct values of each tag whose values come from the data or the config,
+// e.g. the service names or the scrape jobs, recorded by the telemetry to keep its cardinality bounded. The values
+// seen once the limit of their tag is reached are recorded as OtherTagValue. The values are never expired: the first
+// ones seen keep their own tag value until the limit is reset. Zero, the default, disables the limit.
+func SetMaxTagValues(max int) {
+	tagValues.reset(max)
+}
+
+// LimitTagValue returns the given value of the tag if it is within the limit set by SetMaxTagValues, OtherTagValue
+// otherwise. Every tag value coming from the data or the config must go through it.
+func LimitTagValue(key tag.Key, value string) string {
+	return tagValues.value(key, value)
+}
+
+// tagValueLimiter caps the number of distinct values of each tag, the values are admitted on a first seen basis.
+type tagValueLimiter struct {
+	mu   sync.RWMutex
+	max  int
+	seen map[tag.Key]map[string]bool
+}
+
+func (l *tagValueLimiter) reset(max int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max = max
+	l.seen = make(map[tag.Key]map[string]bool)
+}
+
+func (l *tagValueLimiter) value(key tag.Key, v string) string {
+	l.mu.RLock()
+	max, admitted := l.max, l.seen[key][v]
+	l.mu.RUnlock()
+	if max <= 0 || admitted {
+		return v
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	seen := l.seen[key]
+	if seen[v] {
+		return v
+	}
+	if len(seen) >= l.max {
+		return OtherTagValue
+	}
+	if seen == nil {
+		seen = make(map[string]bool)
+		l.seen[key] = seen
+	}
+	seen[v] = true
+	return v
+}
@@ -0,0 +1,227 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalyprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type anomalyProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	alpha        float64
+	threshold    float64
+	warmupPoints int
+	label        string
+	statsTags    []tag.Mutator
+
+	mu     sync.Mutex
+	series *seriesCache
+}
+
+var _ processor.MetricsProcessor = (*anomalyProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that flags the
+// points of the gauges deviating from the
+// exponential moving average of their series by more than the configured
+// number of standard deviations, setting the configured label to "true". The
+// label is added to every metric checked, without a value for the points not
+// flagged, so that the label keys of a metric do not depend on the points. No
+// point is dropped.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if cfg.Alpha <= 0 || cfg.Alpha > 1 {
+		return nil, fmt.Errorf("alpha must be in (0, 1], got %v", cfg.Alpha)
+	}
+	if cfg.Threshold <= 0 {
+		return nil, fmt.Errorf("threshold must be positive, got %v", cfg.Threshold)
+	}
+	if cfg.WarmupPoints < 0 {
+		return nil, fmt.Errorf("warmup_points must be positive, got %d", cfg.WarmupPoints)
+	}
+	if cfg.Label == "" {
+		return nil, errors.New("label must not be empty")
+	}
+
+	maxSeries := cfg.MaxSeries
+	if maxSeries == 0 {
+		maxSeries = defaultMaxSeries
+	}
+	if maxSeries < 0 {
+		return nil, fmt.Errorf("max_series must be positive, got %d", cfg.MaxSeries)
+	}
+
+	return &anomalyProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		alpha:        cfg.Alpha,
+		threshold:    cfg.Threshold,
+		warmupPoints: cfg.WarmupPoints,
+		label:        cfg.Label,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+		series:       newSeriesCache(maxSeries),
+	}, nil
+}
+
+func (ap *anomalyProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	if len(md.Metrics) == 0 {
+		return ap.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	metrics := make([]*metricspb.Metric, len(md.Metrics))
+	flagged := 0
+	ap.mu.Lock()
+	for i, metric := range md.Metrics {
+		var n int
+		metrics[i], n = ap.flagMetric(md.Node, metric)
+		flagged += n
+	}
+	ap.mu.Unlock()
+
+	if flagged > 0 {
+		ap.logger.Debug("Flagged anomalous points",
+			zap.String("processor", ap.name),
+			zap.Int("points", flagged))
+		stats.RecordWithTags(context.Background(), ap.statsTags, statFlaggedPoints.M(int64(flagged)))
+	}
+
+	md.Metrics = metrics
+	return ap.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// flagMetric returns a copy of the metric with the label added, its points
+// flagged, and the number of flagged points. The metrics of the other types,
+// and those already having the label, are returned as is.
+func (ap *anomalyProcessor) flagMetric(node *commonpb.Node, metric *metricspb.Metric) (*metricspb.Metric, int) {
+	desc := metric.GetMetricDescriptor()
+	if !isChecked(desc) {
+		return metric, 0
+	}
+	for _, key := range desc.LabelKeys {
+		if key.GetKey() == ap.label {
+			return metric, 0
+		}
+	}
+
+	flaggedDesc := *desc
+	flaggedDesc.LabelKeys = make([]*metricspb.LabelKey, len(desc.LabelKeys), len(desc.LabelKeys)+1)
+	copy(flaggedDesc.LabelKeys, desc.LabelKeys)
+	flaggedDesc.LabelKeys = append(flaggedDesc.LabelKeys, &metricspb.LabelKey{Key: ap.label})
+
+	flaggedMetric := *metric
+	flaggedMetric.MetricDescriptor = &flaggedDesc
+	flaggedMetric.Timeseries = make([]*metricspb.TimeSeries, 0, len(metric.Timeseries))
+	flagged := 0
+	for _, ts := range metric.Timeseries {
+		if ts == nil {
+			continue
+		}
+		state := ap.series.get(seriesKey(node, desc.Name, ts))
+		var normal, anomalous []*metricspb.Point
+		for _, point := range ts.Points {
+			value, ok := pointValue(point)
+			if ok && state.observe(value, ap.alpha, ap.threshold, ap.warmupPoints) {
+				anomalous = append(anomalous, point)
+			} else {
+				normal = append(normal, point)
+			}
+		}
+		if len(normal) > 0 || len(anomalous) == 0 {
+			flaggedMetric.Timeseries = append(flaggedMetric.Timeseries, withLabelValue(ts, normal, &metricspb.LabelValue{}))
+		}
+		if len(anomalous) > 0 {
+			flaggedMetric.Timeseries = append(flaggedMetric.Timeseries,
+				withLabelValue(ts, anomalous, &metricspb.LabelValue{Value: "true", HasValue: true}))
+			flagged += len(anomalous)
+		}
+	}
+	return &flaggedMetric, flagged
+}
+
+// isChecked returns whether the points of the metrics of the given type are
+// checked. The cumulative metrics are not, their values only grow, the rate of
+// a counter is to be checked instead.
+func isChecked(desc *metricspb.MetricDescriptor) bool {
+	switch desc.GetType() {
+	case metricspb.MetricDescriptor_GAUGE_INT64, metricspb.MetricDescriptor_GAUGE_DOUBLE:
+		return true
+	}
+	return false
+}
+
+// withLabelValue returns a copy of the series with the given points and the
+// given label value appended to its label values.
+func withLabelValue(ts *metricspb.TimeSeries, points []*metricspb.Point, value *metricspb.LabelValue) *metricspb.TimeSeries {
+	labelValues := make([]*metricspb.LabelValue, len(ts.LabelValues), len(ts.LabelValues)+1)
+	copy(labelValues, ts.LabelValues)
+	return &metricspb.TimeSeries{
+		StartTimestamp: ts.StartTimestamp,
+		LabelValues:    append(labelValues, value),
+		Points:         points,
+	}
+}
+
+// pointValue returns the value of an int64 or double point, the NaN values
+// being skipped.
+func pointValue(point *metricspb.Point) (float64, bool) {
+	switch v := point.GetValue().(type) {
+	case *metricspb.Point_Int64Value:
+		return float64(v.Int64Value), true
+	case *metricspb.Point_DoubleValue:
+		if math.IsNaN(v.DoubleValue) || math.IsInf(v.DoubleValue, 0) {
+			return 0, false
+		}
+		return v.DoubleValue, true
+	}
+	return 0, false
+}
+
+// seriesKey identifies a series by the node that reported it, the metric name
+// and the label values.
+func seriesKey(node *commonpb.Node, name string, ts *metricspb.TimeSeries) string {
+	var b strings.Builder
+	b.WriteString(processor.ServiceNameForNode(node))
+	b.WriteByte(0)
+	b.WriteString(node.GetIdentifier().GetHostName())
+	b.WriteByte(0)
+	b.WriteString(name)
+	for _, v := range ts.LabelValues {
+		b.WriteByte(0)
+		if v.GetHasValue() {
+			b.WriteString(v.Value)
+		}
+	}
+	return b.String()
+}
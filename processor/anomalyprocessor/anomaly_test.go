@@ -0,0 +1,232 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalyprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func newConfig() Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		Alpha:        defaultAlpha,
+		Threshold:    defaultThreshold,
+		WarmupPoints: defaultWarmupPoints,
+		Label:        defaultLabel,
+		MaxSeries:    defaultMaxSeries,
+	}
+}
+
+var node = &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "svc"}}
+
+// gauge returns a batch with a point of the given value for each of the given
+// series of a gauge, keyed by host.
+func gauge(typ metricspb.MetricDescriptor_Type, values map[string]float64) consumerdata.MetricsData {
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "cpu_temperature",
+			Type:      typ,
+			LabelKeys: []*metricspb.LabelKey{{Key: "host"}},
+		},
+	}
+	for _, host := range []string{"a", "b", "c"} {
+		value, ok := values[host]
+		if !ok {
+			continue
+		}
+		point := &metricspb.Point{Value: &metricspb.Point_DoubleValue{DoubleValue: value}}
+		if typ == metricspb.MetricDescriptor_GAUGE_INT64 {
+			point = &metricspb.Point{Value: &metricspb.Point_Int64Value{Int64Value: int64(value)}}
+		}
+		metric.Timeseries = append(metric.Timeseries, &metricspb.TimeSeries{
+			LabelValues: []*metricspb.LabelValue{{Value: host, HasValue: true}},
+			Points:      []*metricspb.Point{point},
+		})
+	}
+	return consumerdata.MetricsData{Node: node, Metrics: []*metricspb.Metric{metric}}
+}
+
+// anomalies returns the hosts of the flagged series of the last batch.
+func anomalies(t *testing.T, sink *exportertest.SinkMetricsExporter) []string {
+	all := sink.AllMetrics()
+	require.NotEmpty(t, all)
+	metric := all[len(all)-1].Metrics[0]
+	require.Len(t, metric.MetricDescriptor.LabelKeys, 2)
+	require.Equal(t, "anomaly", metric.MetricDescriptor.LabelKeys[1].Key)
+
+	var hosts []string
+	for _, ts := range metric.Timeseries {
+		require.Len(t, ts.LabelValues, 2)
+		if ts.LabelValues[1].HasValue {
+			assert.Equal(t, "true", ts.LabelValues[1].Value)
+			hosts = append(hosts, ts.LabelValues[0].Value)
+		}
+	}
+	return hosts
+}
+
+// stableValue is a series oscillating around 50.
+func stableValue(i int) float64 {
+	return 50 + float64(i%5) - 2
+}
+
+func TestNewMetricsProcessor(t *testing.T) {
+	next := exportertest.NewNopMetricsExporter()
+
+	_, err := NewMetricsProcessor(zap.NewNop(), nil, newConfig())
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	tests := []struct {
+		name  string
+		tweak func(cfg *Config)
+	}{
+		{name: "zero alpha", tweak: func(cfg *Config) { cfg.Alpha = 0 }},
+		{name: "alpha above 1", tweak: func(cfg *Config) { cfg.Alpha = 1.1 }},
+		{name: "zero threshold", tweak: func(cfg *Config) { cfg.Threshold = 0 }},
+		{name: "negative warmup", tweak: func(cfg *Config) { cfg.WarmupPoints = -1 }},
+		{name: "empty label", tweak: func(cfg *Config) { cfg.Label = "" }},
+		{name: "negative max series", tweak: func(cfg *Config) { cfg.MaxSeries = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig()
+			tt.tweak(&cfg)
+			mp, err := NewMetricsProcessor(zap.NewNop(), next, cfg)
+			assert.Nil(t, mp)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAnomalyStableSeries(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig())
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		md := gauge(metricspb.MetricDescriptor_GAUGE_DOUBLE, map[string]float64{"a": stableValue(i)})
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+		assert.Empty(t, anomalies(t, sink), "point %d of a stable series flagged", i)
+	}
+}
+
+func TestAnomalySpike(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig())
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		md := gauge(metricspb.MetricDescriptor_GAUGE_INT64, map[string]float64{"a": stableValue(i), "b": stableValue(i)})
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	// The spike of a series is flagged, the points are all kept.
+	md := gauge(metricspb.MetricDescriptor_GAUGE_INT64, map[string]float64{"a": stableValue(50), "b": 95})
+	original := proto.Clone(md.Metrics[0])
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Equal(t, []string{"b"}, anomalies(t, sink))
+	all := sink.AllMetrics()
+	assert.Len(t, all[len(all)-1].Metrics[0].Timeseries, 2)
+	assert.True(t, proto.Equal(original, md.Metrics[0]), "the metrics may be shared, they must not be modified")
+
+	// The series is back to normal afterwards.
+	md = gauge(metricspb.MetricDescriptor_GAUGE_INT64, map[string]float64{"a": stableValue(51), "b": stableValue(51)})
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Empty(t, anomalies(t, sink))
+}
+
+func TestAnomalyWarmup(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig())
+	require.NoError(t, err)
+
+	// The points of a new series are not flagged until the warmup is over.
+	values := []float64{10, 100, 5, 80}
+	for _, value := range values {
+		md := gauge(metricspb.MetricDescriptor_GAUGE_DOUBLE, map[string]float64{"a": value})
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+		assert.Empty(t, anomalies(t, sink))
+	}
+}
+
+func TestAnomalySplitsSeriesPoints(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig())
+	require.NoError(t, err)
+
+	var points []*metricspb.Point
+	for i := 0; i < 30; i++ {
+		points = append(points, &metricspb.Point{Value: &metricspb.Point_DoubleValue{DoubleValue: stableValue(i)}})
+	}
+	spike := &metricspb.Point{Value: &metricspb.Point_DoubleValue{DoubleValue: 1000}}
+	points = append(points, spike, &metricspb.Point{Value: &metricspb.Point_DoubleValue{DoubleValue: 50}})
+	md := consumerdata.MetricsData{Node: node, Metrics: []*metricspb.Metric{{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: "queue_size", Type: metricspb.MetricDescriptor_GAUGE_DOUBLE},
+		Timeseries:       []*metricspb.TimeSeries{{Points: points}},
+	}}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	metric := sink.AllMetrics()[0].Metrics[0]
+	require.Len(t, metric.Timeseries, 2)
+	assert.Len(t, metric.Timeseries[0].Points, 31)
+	assert.False(t, metric.Timeseries[0].LabelValues[0].HasValue)
+	assert.Equal(t, []*metricspb.Point{spike}, metric.Timeseries[1].Points)
+	assert.Equal(t, &metricspb.LabelValue{Value: "true", HasValue: true}, metric.Timeseries[1].LabelValues[0])
+}
+
+func TestAnomalySkippedMetrics(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig())
+	require.NoError(t, err)
+
+	counter := &metricspb.Metric{MetricDescriptor: &metricspb.MetricDescriptor{
+		Name: "requests", Type: metricspb.MetricDescriptor_CUMULATIVE_INT64}}
+	labeled := &metricspb.Metric{MetricDescriptor: &metricspb.MetricDescriptor{
+		Name: "labeled", Type: metricspb.MetricDescriptor_GAUGE_DOUBLE, LabelKeys: []*metricspb.LabelKey{{Key: "anomaly"}}}}
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{counter, labeled}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()[0].Metrics
+	assert.True(t, counter == got[0])
+	assert.True(t, labeled == got[1])
+}
+
+func TestSeriesCacheEviction(t *testing.T) {
+	sc := newSeriesCache(2)
+	sc.get("a").observe(1, 0.5, 3, 0)
+	sc.get("b")
+	sc.get("a")
+	sc.get("c")
+	assert.Equal(t, 2, sc.len())
+	_, ok := sc.entries.Get("b")
+	assert.False(t, ok, "the least recently updated series must be evicted")
+	assert.Equal(t, 1, sc.get("a").count)
+}
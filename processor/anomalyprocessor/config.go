@@ -0,0 +1,37 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalyprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the anomaly processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Alpha is the smoothing factor of the exponential moving average and
+	// variance of each series, in (0, 1]. The higher, the faster the average
+	// follows the recent points.
+	Alpha float64 `mapstructure:"alpha"`
+	// Threshold is the number of standard deviations from the moving average
+	// beyond which a point is flagged.
+	Threshold float64 `mapstructure:"threshold"`
+	// WarmupPoints is the number of points of a series observed before its
+	// points can be flagged.
+	WarmupPoints int `mapstructure:"warmup_points"`
+	// Label is the key of the label set to "true" on the flagged points.
+	Label string `mapstructure:"label"`
+	// MaxSeries is the maximum number of series whose moving average is
+	// tracked. The least recently updated series are forgotten first.
+	MaxSeries int `mapstructure:"max_series"`
+}
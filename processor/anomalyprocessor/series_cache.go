@@ -0,0 +1,78 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalyprocessor
+
+import (
+	"math"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/lru"
+)
+
+// seriesState is the exponential moving average and variance of a series.
+type seriesState struct {
+	mean     float64
+	variance float64
+	// count is the number of points observed, capped at the warmup points.
+	count int
+}
+
+// observe returns whether the value deviates from the moving average by more
+// than threshold standard deviations, once the warmup is over, and then
+// updates the moving average and variance with the value.
+func (s *seriesState) observe(value, alpha, threshold float64, warmupPoints int) bool {
+	if s.count == 0 {
+		s.mean = value
+		s.count++
+		return false
+	}
+
+	diff := value - s.mean
+	anomalous := s.count >= warmupPoints && math.Abs(diff) > threshold*math.Sqrt(s.variance)
+	incr := alpha * diff
+	s.mean += incr
+	s.variance = (1 - alpha) * (s.variance + diff*incr)
+	if s.count < warmupPoints {
+		s.count++
+	}
+	return anomalous
+}
+
+// seriesCache is a bounded cache of the state of the series. It is not safe
+// for concurrent use.
+type seriesCache struct {
+	entries *lru.Cache
+}
+
+func newSeriesCache(maxSeries int) *seriesCache {
+	return &seriesCache{entries: lru.New(maxSeries)}
+}
+
+// get returns the state of the given series, created if needed, evicting the
+// least recently updated series as needed to stay within bounds.
+func (sc *seriesCache) get(key string) *seriesState {
+	var state *seriesState
+	if value, ok := sc.entries.Get(key); ok {
+		state = value.(*seriesState)
+	} else {
+		state = &seriesState{}
+	}
+	sc.entries.Add(key, state)
+	return state
+}
+
+// len returns the number of series in the cache.
+func (sc *seriesCache) len() int {
+	return sc.entries.Len()
+}
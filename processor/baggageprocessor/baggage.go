@@ -0,0 +1,130 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggageprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type baggageProcessor struct {
+	nextConsumer consumer.TraceConsumer
+	// attributes maps the promoted keys to the name of their attribute.
+	attributes map[string]string
+}
+
+var _ processor.TraceProcessor = (*baggageProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that sets the
+// configured entries of the W3C trace context carried by the spans, their
+// tracestate, as span attributes named after the entry key with the
+// configured prefix. The attributes already set on a span are kept.
+func NewTraceProcessor(nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("keys must list at least one key")
+	}
+
+	attributes := make(map[string]string, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		if key == "" {
+			return nil, errors.New("keys must not be empty")
+		}
+		if _, ok := attributes[key]; ok {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+		attributes[key] = cfg.Prefix + key
+	}
+
+	return &baggageProcessor{
+		nextConsumer: nextConsumer,
+		attributes:   attributes,
+	}, nil
+}
+
+func (bp *baggageProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	var spans []*tracepb.Span
+	for i, span := range td.Spans {
+		promoted, ok := bp.promote(span)
+		if !ok {
+			continue
+		}
+		if spans == nil {
+			spans = make([]*tracepb.Span, len(td.Spans))
+			copy(spans, td.Spans)
+		}
+		spans[i] = promoted
+	}
+	if spans != nil {
+		td.Spans = spans
+	}
+	return bp.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+// promote returns a copy of the span with the configured trace context entries
+// set as attributes, and whether any was set.
+func (bp *baggageProcessor) promote(span *tracepb.Span) (*tracepb.Span, bool) {
+	entries := span.GetTracestate().GetEntries()
+	if len(entries) == 0 {
+		return span, false
+	}
+
+	attrs := span.GetAttributes().GetAttributeMap()
+	var promoted map[string]*tracepb.AttributeValue
+	for _, entry := range entries {
+		name, ok := bp.attributes[entry.GetKey()]
+		if !ok {
+			continue
+		}
+		if _, ok := attrs[name]; ok {
+			continue
+		}
+		if _, ok := promoted[name]; ok {
+			// The first entry of a key wins, as in the W3C trace context.
+			continue
+		}
+		if promoted == nil {
+			promoted = make(map[string]*tracepb.AttributeValue, len(attrs)+len(bp.attributes))
+			for key, value := range attrs {
+				promoted[key] = value
+			}
+		}
+		promoted[name] = &tracepb.AttributeValue{
+			Value: &tracepb.AttributeValue_StringValue{
+				StringValue: &tracepb.TruncatableString{Value: entry.Value},
+			},
+		}
+	}
+	if promoted == nil {
+		return span, false
+	}
+
+	promotedSpan := *span
+	promotedSpan.Attributes = &tracepb.Span_Attributes{
+		AttributeMap:           promoted,
+		DroppedAttributesCount: span.GetAttributes().GetDroppedAttributesCount(),
+	}
+	return &promotedSpan, true
+}
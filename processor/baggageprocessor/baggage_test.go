@@ -0,0 +1,154 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggageprocessor
+
+import (
+	"context"
+	"testing"
+
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func newConfig(keys ...string) Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		Keys:   keys,
+		Prefix: "baggage.",
+	}
+}
+
+func stringAttr(value string) *tracepb.AttributeValue {
+	return &tracepb.AttributeValue{
+		Value: &tracepb.AttributeValue_StringValue{StringValue: &tracepb.TruncatableString{Value: value}},
+	}
+}
+
+func tracestate(kvs ...string) *tracepb.Span_Tracestate {
+	ts := &tracepb.Span_Tracestate{}
+	for i := 0; i < len(kvs); i += 2 {
+		ts.Entries = append(ts.Entries, &tracepb.Span_Tracestate_Entry{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return ts
+}
+
+func TestNewTraceProcessor(t *testing.T) {
+	next := exportertest.NewNopTraceExporter()
+
+	_, err := NewTraceProcessor(nil, newConfig("tenant"))
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	for _, keys := range [][]string{nil, {""}, {"tenant", "tenant"}} {
+		tp, err := NewTraceProcessor(next, newConfig(keys...))
+		assert.Nil(t, tp)
+		assert.Error(t, err, "keys %q", keys)
+	}
+}
+
+func TestBaggageProcessor(t *testing.T) {
+	sink := &exportertest.SinkTraceExporter{}
+	tp, err := NewTraceProcessor(sink, newConfig("tenant", "region@acme"))
+	require.NoError(t, err)
+
+	spans := []*tracepb.Span{
+		{
+			Name:       &tracepb.TruncatableString{Value: "promoted"},
+			Tracestate: tracestate("tenant", "t1", "region@acme", "eu", "congo", "t61rcWkgMzE"),
+			Attributes: &tracepb.Span_Attributes{
+				AttributeMap:           map[string]*tracepb.AttributeValue{"http.method": stringAttr("GET")},
+				DroppedAttributesCount: 2,
+			},
+		},
+		{
+			// The attribute already set is kept.
+			Name:       &tracepb.TruncatableString{Value: "own_attribute"},
+			Tracestate: tracestate("tenant", "t1"),
+			Attributes: &tracepb.Span_Attributes{
+				AttributeMap: map[string]*tracepb.AttributeValue{"baggage.tenant": stringAttr("t2")},
+			},
+		},
+		{
+			// The first entry of a key wins.
+			Name:       &tracepb.TruncatableString{Value: "duplicate_entries"},
+			Tracestate: tracestate("tenant", "t1", "tenant", "t2"),
+		},
+		{Name: &tracepb.TruncatableString{Value: "unlisted_entries"}, Tracestate: tracestate("congo", "t61rcWkgMzE")},
+		{Name: &tracepb.TruncatableString{Value: "no_tracestate"}},
+		nil,
+	}
+	td := consumerdata.TraceData{Spans: spans}
+	originalSpans := make([]*tracepb.Span, len(spans))
+	for i, span := range spans {
+		if span != nil {
+			originalSpans[i] = proto.Clone(span).(*tracepb.Span)
+		}
+	}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	gotSpans := got[0].Spans
+	require.Len(t, gotSpans, len(spans))
+
+	assert.Equal(t, &tracepb.Span_Attributes{
+		AttributeMap: map[string]*tracepb.AttributeValue{
+			"http.method":         stringAttr("GET"),
+			"baggage.tenant":      stringAttr("t1"),
+			"baggage.region@acme": stringAttr("eu"),
+		},
+		DroppedAttributesCount: 2,
+	}, gotSpans[0].Attributes)
+	assert.Equal(t, spans[0].Tracestate, gotSpans[0].Tracestate, "the trace context is left alone")
+	assert.Equal(t, map[string]*tracepb.AttributeValue{"baggage.tenant": stringAttr("t2")},
+		gotSpans[1].Attributes.AttributeMap)
+	assert.Equal(t, map[string]*tracepb.AttributeValue{"baggage.tenant": stringAttr("t1")},
+		gotSpans[2].Attributes.AttributeMap)
+	for i := 3; i < len(spans); i++ {
+		assert.True(t, spans[i] == gotSpans[i], "span %d without promoted entries must be passed as is", i)
+	}
+
+	// The spans may be shared, they must not be modified.
+	for i, span := range spans {
+		if span != nil {
+			assert.True(t, proto.Equal(originalSpans[i], span), "span %d was modified", i)
+		}
+	}
+}
+
+func TestBaggageProcessorNoTracestate(t *testing.T) {
+	sink := &exportertest.SinkTraceExporter{}
+	tp, err := NewTraceProcessor(sink, newConfig("tenant"))
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{
+		{Name: &tracepb.TruncatableString{Value: "a"}},
+		{Name: &tracepb.TruncatableString{Value: "b"}},
+	}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	assert.Equal(t, td, got[0])
+}
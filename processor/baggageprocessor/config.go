@@ -0,0 +1,27 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggageprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the baggage processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Keys are the keys of the trace context entries set as span attributes,
+	// the other entries are left alone.
+	Keys []string `mapstructure:"keys"`
+	// Prefix is prepended to the keys to name the span attributes.
+	Prefix string `mapstructure:"prefix"`
+}
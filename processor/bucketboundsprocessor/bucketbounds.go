@@ -0,0 +1,104 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bucketboundsprocessor
+
+import (
+	"context"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/internal/histogram"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type bucketBoundsProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+}
+
+var _ processor.MetricsProcessor = (*bucketBoundsProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that canonicalizes
+// the explicit bucket boundaries of every distribution point it receives.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	return &bucketBoundsProcessor{nextConsumer: nextConsumer}, nil
+}
+
+func (bbp *bucketBoundsProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		canonical, ok := canonicalizeMetric(metric)
+		if !ok {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = canonical
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return bbp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// canonicalizeMetric returns a copy of the metric with the bucket boundaries
+// of its distribution points canonicalized, and whether any was changed. The
+// metric is not modified.
+func canonicalizeMetric(metric *metricspb.Metric) (*metricspb.Metric, bool) {
+	var timeseries []*metricspb.TimeSeries
+	for i, ts := range metric.GetTimeseries() {
+		var points []*metricspb.Point
+		for j, point := range ts.GetPoints() {
+			dv := point.GetDistributionValue()
+			if dv == nil || histogram.IsCanonicalDistribution(dv) {
+				continue
+			}
+			canonical := proto.Clone(dv).(*metricspb.DistributionValue)
+			histogram.CanonicalizeDistribution(canonical)
+			if points == nil {
+				points = make([]*metricspb.Point, len(ts.Points))
+				copy(points, ts.Points)
+			}
+			points[j] = &metricspb.Point{
+				Timestamp: point.Timestamp,
+				Value:     &metricspb.Point_DistributionValue{DistributionValue: canonical},
+			}
+		}
+		if points == nil {
+			continue
+		}
+		if timeseries == nil {
+			timeseries = make([]*metricspb.TimeSeries, len(metric.Timeseries))
+			copy(timeseries, metric.Timeseries)
+		}
+		canonicalTs := *ts
+		canonicalTs.Points = points
+		timeseries[i] = &canonicalTs
+	}
+	if timeseries == nil {
+		return nil, false
+	}
+	canonicalMetric := *metric
+	canonicalMetric.Timeseries = timeseries
+	return &canonicalMetric, true
+}
@@ -0,0 +1,129 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bucketboundsprocessor
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+)
+
+func TestBucketBoundsCoherentAcrossProcessors(t *testing.T) {
+	// The same logical boundaries encoded differently by two sources.
+	encodingsA := []string{"-0", "0.30000000000000004", "2.5000000000000004", "1e+1"}
+	encodingsB := []string{"0", "0.3", "2.5", "10.000"}
+
+	sinkA := &exportertest.SinkMetricsExporter{}
+	sinkB := &exportertest.SinkMetricsExporter{}
+	// Chain processors on the first path to check the canonicalization is
+	// stable when applied more than once.
+	second, err := NewMetricsProcessor(sinkA)
+	require.NoError(t, err)
+	first, err := NewMetricsProcessor(second)
+	require.NoError(t, err)
+	other, err := NewMetricsProcessor(sinkB)
+	require.NoError(t, err)
+
+	require.NoError(t, first.ConsumeMetricsData(context.Background(), histogramData(t, encodingsA)))
+	require.NoError(t, other.ConsumeMetricsData(context.Background(), histogramData(t, encodingsB)))
+
+	boundsA := exportedBounds(t, sinkA)
+	boundsB := exportedBounds(t, sinkB)
+	assert.Equal(t, []float64{0, 0.3, 2.5, 10}, boundsA)
+	assert.Equal(t, boundsA, boundsB)
+}
+
+func TestBucketBoundsMergesCollapsedBuckets(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	bbp, err := NewMetricsProcessor(sink)
+	require.NoError(t, err)
+
+	md := histogramData(t, []string{"0.3", "0.30000000000000004", "1"})
+	original := proto.Clone(md.Metrics[0])
+	require.NoError(t, bbp.ConsumeMetricsData(context.Background(), md))
+	assert.True(t, proto.Equal(original, md.Metrics[0]), "the metrics may be shared, they must not be modified")
+
+	assert.Equal(t, []float64{0.3, 1}, exportedBounds(t, sink))
+	dv := sink.AllMetrics()[0].Metrics[0].Timeseries[0].Points[0].GetDistributionValue()
+	var total int64
+	for _, b := range dv.Buckets {
+		total += b.Count
+	}
+	assert.Len(t, dv.Buckets, 3)
+	assert.Equal(t, dv.Count, total)
+}
+
+func TestBucketBoundsNonDistributionUntouched(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	bbp, err := NewMetricsProcessor(sink)
+	require.NoError(t, err)
+
+	gauge := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: "gauge", Type: metricspb.MetricDescriptor_GAUGE_DOUBLE},
+		Timeseries: []*metricspb.TimeSeries{{
+			Points: []*metricspb.Point{{Value: &metricspb.Point_DoubleValue{DoubleValue: 0.30000000000000004}}},
+		}},
+	}
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{gauge}}
+	require.NoError(t, bbp.ConsumeMetricsData(context.Background(), md))
+	assert.Equal(t, 0.30000000000000004, sink.AllMetrics()[0].Metrics[0].Timeseries[0].Points[0].GetDoubleValue())
+}
+
+func histogramData(t *testing.T, encodedBounds []string) consumerdata.MetricsData {
+	bounds := make([]float64, 0, len(encodedBounds))
+	buckets := make([]*metricspb.DistributionValue_Bucket, 0, len(encodedBounds)+1)
+	for _, s := range encodedBounds {
+		b, err := strconv.ParseFloat(s, 64)
+		require.NoError(t, err)
+		bounds = append(bounds, b)
+		buckets = append(buckets, &metricspb.DistributionValue_Bucket{Count: 1})
+	}
+	buckets = append(buckets, &metricspb.DistributionValue_Bucket{Count: 1})
+
+	dv := &metricspb.DistributionValue{
+		Count: int64(len(buckets)),
+		BucketOptions: &metricspb.DistributionValue_BucketOptions{
+			Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+				Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: bounds},
+			},
+		},
+		Buckets: buckets,
+	}
+	return consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{{
+			MetricDescriptor: &metricspb.MetricDescriptor{
+				Name: "latency",
+				Type: metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION,
+			},
+			Timeseries: []*metricspb.TimeSeries{{
+				Points: []*metricspb.Point{{Value: &metricspb.Point_DistributionValue{DistributionValue: dv}}},
+			}},
+		}},
+	}
+}
+
+func exportedBounds(t *testing.T, sink *exportertest.SinkMetricsExporter) []float64 {
+	mds := sink.AllMetrics()
+	require.Len(t, mds, 1)
+	return mds[0].Metrics[0].Timeseries[0].Points[0].GetDistributionValue().GetBucketOptions().GetExplicit().GetBounds()
+}
@@ -0,0 +1,264 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burnrateprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	sloLabel    = "slo"
+	windowLabel = "window"
+)
+
+type burnRateProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	slos         []SLO
+	windows      []time.Duration
+	maxWindow    time.Duration
+	metricName   string
+
+	mu        sync.Mutex
+	histories *historyMap
+}
+
+var _ processor.MetricsProcessor = (*burnRateProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that computes the
+// error budget burn rate of every configured SLO over every window, from the
+// good and total counters reported by each node, and exports the burn rates
+// as a gauge along with the received metrics.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if cfg.MetricName == "" {
+		return nil, errors.New("metric_name must be set")
+	}
+	if err := validateSLOs(cfg.SLOs); err != nil {
+		return nil, err
+	}
+
+	windows := cfg.Windows
+	if len(windows) == 0 {
+		windows = defaultWindows
+	}
+	var maxWindow time.Duration
+	for _, window := range windows {
+		if window <= 0 {
+			return nil, fmt.Errorf("windows must be positive, got %v", window)
+		}
+		if window > maxWindow {
+			maxWindow = window
+		}
+	}
+
+	gcInterval := cfg.GCInterval
+	if gcInterval == 0 {
+		gcInterval = defaultGCInterval
+	}
+
+	return &burnRateProcessor{
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		slos:         cfg.SLOs,
+		windows:      windows,
+		maxWindow:    maxWindow,
+		metricName:   cfg.MetricName,
+		histories:    newHistoryMap(gcInterval),
+	}, nil
+}
+
+func validateSLOs(slos []SLO) error {
+	if len(slos) == 0 {
+		return errors.New("at least one SLO must be configured")
+	}
+	names := make(map[string]bool, len(slos))
+	for _, slo := range slos {
+		if slo.Name == "" {
+			return errors.New("every SLO must have a name")
+		}
+		if names[slo.Name] {
+			return fmt.Errorf("SLO %q is configured more than once", slo.Name)
+		}
+		names[slo.Name] = true
+		if slo.GoodMetric == "" || slo.TotalMetric == "" {
+			return fmt.Errorf("SLO %q must set both good_metric and total_metric", slo.Name)
+		}
+		// An objective of 1 leaves no error budget to burn.
+		if slo.Objective <= 0 || slo.Objective >= 1 {
+			return fmt.Errorf("the objective of SLO %q must be strictly between 0 and 1, got %v", slo.Name, slo.Objective)
+		}
+	}
+	return nil
+}
+
+func (bp *burnRateProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var timeseries []*metricspb.TimeSeries
+
+	bp.mu.Lock()
+	for _, slo := range bp.slos {
+		good, goodTime, ok := sumCounter(md.Metrics, slo.GoodMetric)
+		if !ok {
+			continue
+		}
+		total, totalTime, ok := sumCounter(md.Metrics, slo.TotalMetric)
+		if !ok {
+			continue
+		}
+		t := goodTime
+		if totalTime > t {
+			t = totalTime
+		}
+
+		h := bp.histories.get(historyKey(md.Node, slo.Name))
+		if !h.add(sample{t: t, good: good, total: total}, bp.maxWindow) {
+			continue
+		}
+		for _, window := range bp.windows {
+			rate, ok := h.burnRate(window, 1-slo.Objective)
+			if !ok {
+				continue
+			}
+			timeseries = append(timeseries, &metricspb.TimeSeries{
+				LabelValues: []*metricspb.LabelValue{
+					{Value: slo.Name, HasValue: true},
+					{Value: formatWindow(window), HasValue: true},
+				},
+				Points: []*metricspb.Point{{
+					Timestamp: &timestamp.Timestamp{Seconds: t / 1e9, Nanos: int32(t % 1e9)},
+					Value:     &metricspb.Point_DoubleValue{DoubleValue: rate},
+				}},
+			})
+		}
+	}
+	bp.histories.maybeGC(time.Now())
+	bp.mu.Unlock()
+
+	if len(timeseries) == 0 {
+		return bp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	metrics := make([]*metricspb.Metric, 0, len(md.Metrics)+1)
+	metrics = append(metrics, md.Metrics...)
+	metrics = append(metrics, &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        bp.metricName,
+			Description: "Rate the error budget of the SLO is consumed at over the window",
+			Unit:        "1",
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys:   []*metricspb.LabelKey{{Key: sloLabel}, {Key: windowLabel}},
+		},
+		Timeseries: timeseries,
+	})
+	md.Metrics = metrics
+	return bp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// sumCounter returns the sum of the latest points of all the series of the
+// cumulative counter with the given name, and the timestamp of the newest of
+// these points in nanoseconds since epoch. It returns false if the batch holds
+// no point of the counter.
+func sumCounter(metrics []*metricspb.Metric, name string) (float64, int64, bool) {
+	var sum float64
+	var t int64
+	found := false
+	for _, metric := range metrics {
+		desc := metric.GetMetricDescriptor()
+		if desc.GetName() != name || !isCounter(desc) {
+			continue
+		}
+		for _, ts := range metric.Timeseries {
+			if len(ts.Points) == 0 {
+				continue
+			}
+			point := ts.Points[len(ts.Points)-1]
+			value, ok := pointValue(point)
+			if !ok || point.GetTimestamp() == nil {
+				continue
+			}
+			sum += value
+			if pt := timestampNanos(point.Timestamp); pt > t {
+				t = pt
+			}
+			found = true
+		}
+	}
+	return sum, t, found
+}
+
+func isCounter(desc *metricspb.MetricDescriptor) bool {
+	switch desc.GetType() {
+	case metricspb.MetricDescriptor_CUMULATIVE_INT64, metricspb.MetricDescriptor_CUMULATIVE_DOUBLE:
+		return true
+	}
+	return false
+}
+
+func pointValue(point *metricspb.Point) (float64, bool) {
+	switch v := point.Value.(type) {
+	case *metricspb.Point_Int64Value:
+		return float64(v.Int64Value), true
+	case *metricspb.Point_DoubleValue:
+		return v.DoubleValue, true
+	}
+	return 0, false
+}
+
+// historyKey identifies the history of an SLO by the node that reported its
+// counters and the SLO name.
+func historyKey(node *commonpb.Node, slo string) string {
+	var b strings.Builder
+	b.WriteString(processor.ServiceNameForNode(node))
+	b.WriteByte(0)
+	b.WriteString(node.GetIdentifier().GetHostName())
+	b.WriteByte(0)
+	b.WriteString(slo)
+	return b.String()
+}
+
+// formatWindow formats the window in its largest whole unit, e.g. 1h rather
+// than 1h0m0s.
+func formatWindow(window time.Duration) string {
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	case window%time.Second == 0:
+		return fmt.Sprintf("%ds", window/time.Second)
+	}
+	return window.String()
+}
+
+func timestampNanos(t *timestamp.Timestamp) int64 {
+	return t.GetSeconds()*1e9 + int64(t.GetNanos())
+}
@@ -0,0 +1,240 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burnrateprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+var testSLO = SLO{
+	Name:        "checkout",
+	GoodMetric:  "requests_good",
+	TotalMetric: "requests_total",
+	Objective:   0.99,
+}
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), nil, testConfig())
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestHealthySLO(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, testConfig())
+	require.NoError(t, err)
+
+	// 100 requests a minute, 0.5% of them failing: half of the 1% budget.
+	for minute := int64(0); minute <= 60; minute++ {
+		md := batch(minute*60, 99.5*float64(minute), 100*float64(minute))
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 61)
+	// A single sample has no window to compute a burn rate over.
+	assert.Len(t, got[0].Metrics, 2)
+	for _, md := range got[1:] {
+		require.Len(t, md.Metrics, 3)
+		rates := burnRates(t, md)
+		assert.InDelta(t, 0.5, rates["5m"], 1e-9)
+		assert.InDelta(t, 0.5, rates["1h"], 1e-9)
+	}
+}
+
+func TestBurningSLO(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, testConfig())
+	require.NoError(t, err)
+
+	// 100 requests a minute, all good for 55 minutes then 10% failing.
+	good, total := 0.0, 0.0
+	for minute := int64(0); minute <= 60; minute++ {
+		if minute > 0 {
+			total += 100
+			good += 100
+			if minute > 55 {
+				good -= 10
+			}
+		}
+		md := batch(minute*60, good, total)
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 61)
+
+	// Before the errors, nothing burns.
+	rates := burnRates(t, got[55])
+	assert.Equal(t, 0.0, rates["5m"])
+	assert.Equal(t, 0.0, rates["1h"])
+
+	// The short window sees the 10% error ratio, ten times the budget.
+	rates = burnRates(t, got[60])
+	assert.InDelta(t, 10, rates["5m"], 1e-9)
+	// The long window dilutes the 50 failures over the 6000 requests of the
+	// hour.
+	assert.InDelta(t, 50.0/6000/0.01, rates["1h"], 1e-9)
+}
+
+func TestPartialWindow(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, testConfig())
+	require.NoError(t, err)
+
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(0, 0, 0)))
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(60, 95, 100)))
+
+	// Both windows start at the oldest sample until the history covers them.
+	rates := burnRates(t, sink.AllMetrics()[1])
+	assert.InDelta(t, 5, rates["5m"], 1e-9)
+	assert.InDelta(t, 5, rates["1h"], 1e-9)
+}
+
+func TestNoTraffic(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, testConfig())
+	require.NoError(t, err)
+
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(0, 90, 100)))
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(60, 90, 100)))
+
+	// No event, no budget consumed rather than a division by zero.
+	rates := burnRates(t, sink.AllMetrics()[1])
+	assert.Equal(t, map[string]float64{"5m": 0, "1h": 0}, rates)
+}
+
+func TestMissingOperand(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, testConfig())
+	require.NoError(t, err)
+
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(0, 0, 0)))
+	// Only the total counter is reported.
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{counter("requests_total", 60, 100)}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	assert.Equal(t, md, got[1])
+
+	// The batch missing an operand was not recorded in the history.
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(120, 190, 200)))
+	rates := burnRates(t, sink.AllMetrics()[2])
+	assert.InDelta(t, 5, rates["5m"], 1e-9)
+}
+
+func TestCounterReset(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, testConfig())
+	require.NoError(t, err)
+
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(0, 500, 1000)))
+	// The counters went down, the service restarted.
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(60, 99, 100)))
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), batch(120, 198, 200)))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 3)
+	assert.Len(t, got[1].Metrics, 2, "no burn rate right after a reset")
+	rates := burnRates(t, got[2])
+	assert.InDelta(t, 1, rates["5m"], 1e-9)
+	assert.InDelta(t, 1, rates["1h"], 1e-9)
+}
+
+func TestHistoryTrimmed(t *testing.T) {
+	h := &history{}
+	for minute := int64(0); minute <= 120; minute++ {
+		require.True(t, h.add(sample{t: minute * 60e9, good: float64(minute), total: float64(minute)}, time.Hour))
+	}
+	// The samples of the last hour and the base of the hour long window.
+	require.Len(t, h.samples, 61)
+	assert.Equal(t, int64(60*60e9), h.samples[0].t)
+
+	assert.False(t, h.add(sample{t: 120 * 60e9, good: 200, total: 200}, time.Hour), "sample not after the latest one")
+}
+
+func TestFormatWindow(t *testing.T) {
+	assert.Equal(t, "6h", formatWindow(6*time.Hour))
+	assert.Equal(t, "90m", formatWindow(90*time.Minute))
+	assert.Equal(t, "30s", formatWindow(30*time.Second))
+	assert.Equal(t, "1.5s", formatWindow(1500*time.Millisecond))
+}
+
+func testConfig() Config {
+	return Config{
+		SLOs:       []SLO{testSLO},
+		Windows:    []time.Duration{5 * time.Minute, time.Hour},
+		MetricName: defaultMetricName,
+	}
+}
+
+func batch(seconds int64, good, total float64) consumerdata.MetricsData {
+	return consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			counter("requests_good", seconds, good),
+			counter("requests_total", seconds, total),
+		},
+	}
+}
+
+func counter(name string, seconds int64, value float64) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name: name,
+			Type: metricspb.MetricDescriptor_CUMULATIVE_DOUBLE,
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			Points: []*metricspb.Point{{
+				Timestamp: &timestamp.Timestamp{Seconds: seconds},
+				Value:     &metricspb.Point_DoubleValue{DoubleValue: value},
+			}},
+		}},
+	}
+}
+
+// burnRates returns the burn rates of the test SLO in the batch, keyed by
+// window.
+func burnRates(t *testing.T, md consumerdata.MetricsData) map[string]float64 {
+	var burnRate *metricspb.Metric
+	for _, metric := range md.Metrics {
+		if metric.MetricDescriptor.Name == defaultMetricName {
+			burnRate = metric
+		}
+	}
+	require.NotNil(t, burnRate, "no burn rate metric")
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, burnRate.MetricDescriptor.Type)
+
+	rates := make(map[string]float64)
+	for _, ts := range burnRate.Timeseries {
+		require.Len(t, ts.LabelValues, 2)
+		assert.Equal(t, testSLO.Name, ts.LabelValues[0].Value)
+		require.Len(t, ts.Points, 1)
+		rates[ts.LabelValues[1].Value] = ts.Points[0].GetDoubleValue()
+	}
+	return rates
+}
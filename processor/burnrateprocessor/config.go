@@ -0,0 +1,51 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burnrateprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config defines configuration for the burn rate processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// SLOs are the service level objectives the burn rate is computed for.
+	SLOs []SLO `mapstructure:"slos"`
+	// Windows are the windows the burn rate of every SLO is computed over.
+	// Defaults to 5m, 30m, 1h and 6h.
+	Windows []time.Duration `mapstructure:"windows"`
+	// MetricName is the name of the gauge holding the burn rates, labeled with
+	// the SLO and the window.
+	MetricName string `mapstructure:"metric_name"`
+	// GCInterval is how often the SLO histories that were not updated since the
+	// previous collection are forgotten.
+	GCInterval time.Duration `mapstructure:"gc_interval"`
+}
+
+// SLO is a service level objective measured by the ratio of the good events
+// to the total events, both counted by cumulative counters.
+type SLO struct {
+	// Name is the value of the slo label of the burn rates.
+	Name string `mapstructure:"name"`
+	// GoodMetric is the name of the counter of the good events.
+	GoodMetric string `mapstructure:"good_metric"`
+	// TotalMetric is the name of the counter of all the events.
+	TotalMetric string `mapstructure:"total_metric"`
+	// Objective is the targeted ratio of good events, e.g. 0.999. It must be
+	// strictly between 0 and 1, the error budget being 1 - objective.
+	Objective float64 `mapstructure:"objective"`
+}
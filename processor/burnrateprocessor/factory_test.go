@@ -0,0 +1,81 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burnrateprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+)
+
+func TestCreateDefaultConfig(t *testing.T) {
+	factory := &Factory{}
+
+	cfg := factory.CreateDefaultConfig()
+	assert.NotNil(t, cfg, "failed to create default config")
+}
+
+func TestCreateProcessor(t *testing.T) {
+	factory := &Factory{}
+
+	cfg := factory.CreateDefaultConfig()
+
+	tp, err := factory.CreateTraceProcessor(zap.NewNop(), exportertest.NewNopTraceExporter(), cfg)
+	assert.Nil(t, tp)
+	assert.Error(t, err, "should not be able to create trace processor")
+
+	// The default config has no SLO.
+	mp, err := factory.CreateMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), cfg)
+	assert.Nil(t, mp)
+	assert.Error(t, err, "should not be able to create metrics processor without SLO")
+
+	oCfg := cfg.(*Config)
+	oCfg.SLOs = []SLO{testSLO}
+	mp, err = factory.CreateMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), oCfg)
+	assert.NotNil(t, mp)
+	assert.NoError(t, err, "cannot create metrics processor")
+}
+
+func TestCreateProcessorInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+	}{
+		{"no_metric_name", func(cfg *Config) { cfg.MetricName = "" }},
+		{"no_slo_name", func(cfg *Config) { cfg.SLOs[0].Name = "" }},
+		{"duplicate_slo", func(cfg *Config) { cfg.SLOs = append(cfg.SLOs, testSLO) }},
+		{"no_good_metric", func(cfg *Config) { cfg.SLOs[0].GoodMetric = "" }},
+		{"no_total_metric", func(cfg *Config) { cfg.SLOs[0].TotalMetric = "" }},
+		{"no_error_budget", func(cfg *Config) { cfg.SLOs[0].Objective = 1 }},
+		{"no_objective", func(cfg *Config) { cfg.SLOs[0].Objective = 0 }},
+		{"zero_window", func(cfg *Config) { cfg.Windows = []time.Duration{time.Hour, 0} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &Factory{}
+			cfg := factory.CreateDefaultConfig().(*Config)
+			cfg.SLOs = []SLO{testSLO}
+			tt.modify(cfg)
+
+			mp, err := factory.CreateMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), cfg)
+			assert.Nil(t, mp)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package burnrateprocessor
+
+import (
+	"time"
+)
+
+// sample is the value of the good and total counters of an SLO at a time, in
+// nanoseconds since epoch.
+type sample struct {
+	t     int64
+	good  float64
+	total float64
+}
+
+// history holds the samples of an SLO reported by a node, oldest first, over
+// the longest window.
+type history struct {
+	mark    bool
+	samples []sample
+}
+
+// add appends the sample to the history and returns whether it was accepted.
+// A sample not after the latest one is ignored. A decreasing counter means the
+// counters were reset, the history restarts from the sample. The samples older
+// than maxWindow are dropped, except the latest of them that is the base of
+// the longest window.
+func (h *history) add(s sample, maxWindow time.Duration) bool {
+	if n := len(h.samples); n > 0 {
+		last := h.samples[n-1]
+		if s.t <= last.t {
+			return false
+		}
+		if s.good < last.good || s.total < last.total {
+			h.samples = h.samples[:0]
+		}
+	}
+	h.samples = append(h.samples, s)
+
+	start := s.t - maxWindow.Nanoseconds()
+	drop := 0
+	for drop+1 < len(h.samples) && h.samples[drop+1].t <= start {
+		drop++
+	}
+	if drop > 0 {
+		h.samples = append(h.samples[:0], h.samples[drop:]...)
+	}
+	return true
+}
+
+// burnRate returns the rate the error budget was consumed at over the window
+// ending at the latest sample, 1 meaning the budget is consumed exactly over
+// the SLO period. The window starts at the latest sample at or before its
+// start, or at the oldest sample while the history is shorter than the
+// window. No rate is returned until the history holds two samples. A window
+// without any event did not consume budget, its burn rate is 0.
+func (h *history) burnRate(window time.Duration, errorBudget float64) (float64, bool) {
+	n := len(h.samples)
+	if n < 2 {
+		return 0, false
+	}
+	current := h.samples[n-1]
+	start := current.t - window.Nanoseconds()
+	base := h.samples[0]
+	for _, s := range h.samples[1 : n-1] {
+		if s.t > start {
+			break
+		}
+		base = s
+	}
+
+	total := current.total - base.total
+	if total <= 0 {
+		return 0, true
+	}
+	errorRatio := 1 - (current.good-base.good)/total
+	// The counters are not scraped atomically, the good events may be ahead.
+	if errorRatio < 0 {
+		errorRatio = 0
+	} else if errorRatio > 1 {
+		errorRatio = 1
+	}
+	return errorRatio / errorBudget, true
+}
+
+// historyMap tracks the history of every SLO reported by every node. Like the
+// prometheus receiver JobsMap it uses a mark-and-sweep strategy: every access
+// marks the history, and every gcInterval the histories that were not marked
+// since the previous collection are removed. It is not safe for concurrent
+// use.
+type historyMap struct {
+	gcInterval time.Duration
+	lastGC     time.Time
+	histories  map[string]*history
+}
+
+func newHistoryMap(gcInterval time.Duration) *historyMap {
+	return &historyMap{
+		gcInterval: gcInterval,
+		lastGC:     time.Now(),
+		histories:  make(map[string]*history),
+	}
+}
+
+// get returns the history with the given key, creating it if needed.
+func (hm *historyMap) get(key string) *history {
+	h, ok := hm.histories[key]
+	if !ok {
+		h = &history{}
+		hm.histories[key] = h
+	}
+	h.mark = true
+	return h
+}
+
+// maybeGC removes the histories that have aged out if the last collection is
+// older than the gc interval.
+func (hm *historyMap) maybeGC(now time.Time) {
+	if now.Sub(hm.lastGC) <= hm.gcInterval {
+		return
+	}
+	for key, h := range hm.histories {
+		if !h.mark {
+			delete(hm.histories, key)
+		} else {
+			h.mark = false
+		}
+	}
+	hm.lastGC = now
+}
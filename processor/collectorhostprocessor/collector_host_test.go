@@ -0,0 +1,102 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectorhostprocessor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+)
+
+func TestTraceProcessorAddsHostname(t *testing.T) {
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	sink := new(exportertest.SinkTraceExporter)
+	tp, err := NewTraceProcessor(sink, *(&Factory{}).CreateDefaultConfig().(*Config))
+	require.NoError(t, err)
+
+	resource := &resourcepb.Resource{Type: "host", Labels: map[string]string{"service": "checkout"}}
+	tds := []consumerdata.TraceData{
+		{Resource: resource, Spans: []*tracepb.Span{{}}},
+		{Spans: []*tracepb.Span{{}}},
+	}
+	for _, td := range tds {
+		require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	}
+
+	got := sink.AllTraces()
+	require.Len(t, got, 2)
+	assert.Equal(t, &resourcepb.Resource{
+		Type:   "host",
+		Labels: map[string]string{"service": "checkout", defaultHostnameKey: host},
+	}, got[0].Resource)
+	assert.Equal(t, map[string]string{defaultHostnameKey: host}, got[1].Resource.Labels)
+	// The resource of the incoming data is left untouched.
+	assert.Equal(t, map[string]string{"service": "checkout"}, resource.Labels)
+}
+
+func TestMetricsProcessorAddsNamesFromEnv(t *testing.T) {
+	require.NoError(t, os.Setenv("COLLECTOR_HOST_TEST_POD", "collector-0"))
+	defer os.Unsetenv("COLLECTOR_HOST_TEST_POD")
+	defer func(f func() (string, error)) { hostname = f }(hostname)
+	resolved := 0
+	hostname = func() (string, error) {
+		resolved++
+		return "collector-host", nil
+	}
+
+	cfg := (&Factory{}).CreateDefaultConfig().(*Config)
+	cfg.HostnameKey = "collector"
+	cfg.PodNameEnv = "COLLECTOR_HOST_TEST_POD"
+	// Unset variables are ignored.
+	cfg.NodeNameEnv = "COLLECTOR_HOST_TEST_NODE"
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, *cfg)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{}))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	for _, md := range got {
+		assert.Equal(t, map[string]string{
+			"collector":       "collector-host",
+			defaultPodNameKey: "collector-0",
+		}, md.Resource.Labels)
+	}
+	// The host name is resolved once, when the processor is created.
+	assert.Equal(t, 1, resolved)
+}
+
+func TestHostnameResolutionError(t *testing.T) {
+	defer func(f func() (string, error)) { hostname = f }(hostname)
+	hostname = func() (string, error) { return "", errors.New("no host name") }
+
+	tp, err := NewTraceProcessor(new(exportertest.SinkTraceExporter), *(&Factory{}).CreateDefaultConfig().(*Config))
+	assert.Nil(t, tp)
+	assert.Error(t, err)
+}
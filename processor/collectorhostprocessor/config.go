@@ -0,0 +1,33 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectorhostprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the collector host processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// HostnameKey is the resource attribute set to the host name of the
+	// collector.
+	HostnameKey string `mapstructure:"hostname_key"`
+	// PodNameEnv and NodeNameEnv are the environment variables holding the pod
+	// and node names of the collector, e.g. set with the kubernetes downward
+	// API. When set, their values are added as the PodNameKey and NodeNameKey
+	// resource attributes.
+	PodNameEnv  string `mapstructure:"pod_name_env"`
+	PodNameKey  string `mapstructure:"pod_name_key"`
+	NodeNameEnv string `mapstructure:"node_name_env"`
+	NodeNameKey string `mapstructure:"node_name_key"`
+}
@@ -0,0 +1,127 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectorregionprocessor
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// regionAttributes returns the resource attributes locating the collector,
+// the values set in the config override the ones of the environment. They
+// are resolved once, when the processor is created.
+func regionAttributes(cfg Config) (map[string]string, error) {
+	attrs := make(map[string]string)
+	for _, location := range []struct{ value, env, key, name string }{
+		{cfg.Region, cfg.RegionEnv, cfg.RegionKey, "region"},
+		{cfg.Zone, cfg.ZoneEnv, cfg.ZoneKey, "zone"},
+	} {
+		value := location.value
+		if value == "" && location.env != "" {
+			value = os.Getenv(location.env)
+		}
+		if value == "" {
+			continue
+		}
+		if location.key == "" {
+			return nil, fmt.Errorf("no resource attribute key for the %s %q", location.name, value)
+		}
+		attrs[location.key] = value
+	}
+	return attrs, nil
+}
+
+// withAttributes returns a copy of the resource with the given attributes
+// added.
+func withAttributes(resource *resourcepb.Resource, attrs map[string]string) *resourcepb.Resource {
+	if len(attrs) == 0 {
+		return resource
+	}
+	labels := make(map[string]string, len(resource.GetLabels())+len(attrs))
+	for k, v := range resource.GetLabels() {
+		labels[k] = v
+	}
+	for k, v := range attrs {
+		labels[k] = v
+	}
+	return &resourcepb.Resource{
+		Type:   resource.GetType(),
+		Labels: labels,
+	}
+}
+
+type traceProcessor struct {
+	nextConsumer consumer.TraceConsumer
+	attrs        map[string]string
+}
+
+var _ processor.TraceProcessor = (*traceProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that adds the region
+// and zone of the collector to the resource of every batch.
+func NewTraceProcessor(nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	attrs, err := regionAttributes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &traceProcessor{
+		nextConsumer: nextConsumer,
+		attrs:        attrs,
+	}, nil
+}
+
+func (tp *traceProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	td.Resource = withAttributes(td.Resource, tp.attrs)
+	return tp.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+type metricsProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	attrs        map[string]string
+}
+
+var _ processor.MetricsProcessor = (*metricsProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that adds the
+// region and zone of the collector to the resource of every batch.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	attrs, err := regionAttributes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsProcessor{
+		nextConsumer: nextConsumer,
+		attrs:        attrs,
+	}, nil
+}
+
+func (mp *metricsProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	md.Resource = withAttributes(md.Resource, mp.attrs)
+	return mp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
@@ -0,0 +1,117 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectorregionprocessor
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewProcessorNilNext(t *testing.T) {
+	tp, err := NewTraceProcessor(nil, Config{})
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	mp, err := NewMetricsProcessor(nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestTraceProcessorAddsRegionFromEnv(t *testing.T) {
+	require.NoError(t, os.Setenv("COLLECTOR_REGION_TEST_REGION", "eu-west-1"))
+	defer os.Unsetenv("COLLECTOR_REGION_TEST_REGION")
+	require.NoError(t, os.Setenv("COLLECTOR_REGION_TEST_ZONE", "eu-west-1a"))
+	defer os.Unsetenv("COLLECTOR_REGION_TEST_ZONE")
+
+	cfg := (&Factory{}).CreateDefaultConfig().(*Config)
+	cfg.RegionEnv = "COLLECTOR_REGION_TEST_REGION"
+	cfg.ZoneEnv = "COLLECTOR_REGION_TEST_ZONE"
+	sink := new(exportertest.SinkTraceExporter)
+	tp, err := NewTraceProcessor(sink, *cfg)
+	require.NoError(t, err)
+
+	resource := &resourcepb.Resource{Type: "host", Labels: map[string]string{"service": "checkout"}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), consumerdata.TraceData{
+		Resource: resource,
+		Spans:    []*tracepb.Span{{}},
+	}))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	assert.Equal(t, &resourcepb.Resource{
+		Type: "host",
+		Labels: map[string]string{
+			"service":        "checkout",
+			defaultRegionKey: "eu-west-1",
+			defaultZoneKey:   "eu-west-1a",
+		},
+	}, got[0].Resource)
+	// The resource of the incoming data is left untouched.
+	assert.Equal(t, map[string]string{"service": "checkout"}, resource.Labels)
+}
+
+func TestMetricsProcessorConfigOverridesEnv(t *testing.T) {
+	require.NoError(t, os.Setenv("COLLECTOR_REGION_TEST_REGION", "eu-west-1"))
+	defer os.Unsetenv("COLLECTOR_REGION_TEST_REGION")
+	require.NoError(t, os.Setenv("COLLECTOR_REGION_TEST_ZONE", "eu-west-1a"))
+	defer os.Unsetenv("COLLECTOR_REGION_TEST_ZONE")
+
+	cfg := (&Factory{}).CreateDefaultConfig().(*Config)
+	cfg.RegionEnv = "COLLECTOR_REGION_TEST_REGION"
+	cfg.ZoneEnv = "COLLECTOR_REGION_TEST_ZONE"
+	cfg.Zone = "eu-west-1c"
+	cfg.ZoneKey = "cloud.zone"
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, *cfg)
+	require.NoError(t, err)
+
+	// The attributes are resolved when the processor is created.
+	require.NoError(t, os.Setenv("COLLECTOR_REGION_TEST_REGION", "us-east-1"))
+	for i := 0; i < 2; i++ {
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{}))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	for _, md := range got {
+		assert.Equal(t, map[string]string{
+			defaultRegionKey: "eu-west-1",
+			"cloud.zone":     "eu-west-1c",
+		}, md.Resource.Labels)
+	}
+}
+
+func TestNoRegionPassedAsIs(t *testing.T) {
+	cfg := (&Factory{}).CreateDefaultConfig().(*Config)
+	cfg.RegionEnv = "COLLECTOR_REGION_TEST_UNSET"
+	cfg.ZoneEnv = "COLLECTOR_REGION_TEST_UNSET"
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, *cfg)
+	require.NoError(t, err)
+
+	resource := &resourcepb.Resource{Labels: map[string]string{"service": "checkout"}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Resource: resource}))
+	assert.True(t, resource == sink.AllMetrics()[0].Resource)
+}
@@ -0,0 +1,34 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectorregionprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the collector region processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Region and Zone are the region and zone of the collector. When empty,
+	// they are read from the RegionEnv and ZoneEnv environment variables.
+	Region string `mapstructure:"region"`
+	Zone   string `mapstructure:"zone"`
+	// RegionEnv and ZoneEnv are the environment variables holding the region
+	// and zone of the collector, e.g. set by the deployment tooling.
+	RegionEnv string `mapstructure:"region_env"`
+	ZoneEnv   string `mapstructure:"zone_env"`
+	// RegionKey and ZoneKey are the resource attributes set to the region and
+	// zone of the collector.
+	RegionKey string `mapstructure:"region_key"`
+	ZoneKey   string `mapstructure:"zone_key"`
+}
@@ -0,0 +1,133 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditionalattributesprocessor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// predicate is a compiled Condition.
+type predicate interface {
+	// matches returns whether the attributes looked up with get match.
+	matches(get func(key string) (string, bool)) bool
+}
+
+type keyPredicate struct {
+	key string
+	// values are the values matching, nil if any value does.
+	values map[string]bool
+}
+
+func (p *keyPredicate) matches(get func(key string) (string, bool)) bool {
+	value, ok := get(p.key)
+	return ok && (p.values == nil || p.values[value])
+}
+
+type allPredicate []predicate
+
+func (p allPredicate) matches(get func(key string) (string, bool)) bool {
+	for _, sub := range p {
+		if !sub.matches(get) {
+			return false
+		}
+	}
+	return true
+}
+
+type anyPredicate []predicate
+
+func (p anyPredicate) matches(get func(key string) (string, bool)) bool {
+	for _, sub := range p {
+		if sub.matches(get) {
+			return true
+		}
+	}
+	return false
+}
+
+// compile validates the condition and returns its predicate.
+func compile(cond Condition) (predicate, error) {
+	set := 0
+	if cond.Key != "" {
+		set++
+	}
+	if len(cond.All) > 0 {
+		set++
+	}
+	if len(cond.Any) > 0 {
+		set++
+	}
+	if set != 1 {
+		return nil, errors.New("a condition must set exactly one of key, all or any")
+	}
+	if cond.Key == "" && len(cond.Values) > 0 {
+		return nil, errors.New("values require a key")
+	}
+
+	switch {
+	case cond.Key != "":
+		p := &keyPredicate{key: cond.Key}
+		if len(cond.Values) > 0 {
+			p.values = make(map[string]bool, len(cond.Values))
+			for _, value := range cond.Values {
+				p.values[value] = true
+			}
+		}
+		return p, nil
+	case len(cond.All) > 0:
+		p, err := compileAll(cond.All)
+		return allPredicate(p), err
+	default:
+		p, err := compileAll(cond.Any)
+		return anyPredicate(p), err
+	}
+}
+
+func compileAll(conds []Condition) ([]predicate, error) {
+	predicates := make([]predicate, len(conds))
+	for i, cond := range conds {
+		p, err := compile(cond)
+		if err != nil {
+			return nil, err
+		}
+		predicates[i] = p
+	}
+	return predicates, nil
+}
+
+// rule is a compiled Rule.
+type rule struct {
+	when       predicate
+	attributes map[string]string
+}
+
+func compileRules(rules []Rule) ([]rule, error) {
+	if len(rules) == 0 {
+		return nil, errors.New("rules must list at least one rule")
+	}
+	compiled := make([]rule, len(rules))
+	for i, r := range rules {
+		when, err := compile(r.When)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition of rule %d: %v", i, err)
+		}
+		if len(r.Attributes) == 0 {
+			return nil, fmt.Errorf("rule %d has no attributes", i)
+		}
+		compiled[i] = rule{when: when, attributes: r.Attributes}
+	}
+	return compiled, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditionalattributesprocessor
+
+import (
+	"context"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type traceConditionalAttributesProcessor struct {
+	nextConsumer consumer.TraceConsumer
+	rules        []rule
+}
+
+var _ processor.TraceProcessor = (*traceConditionalAttributesProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that inserts the
+// attributes of the rules whose condition the batch matches in its resource.
+func NewTraceProcessor(nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return &traceConditionalAttributesProcessor{
+		nextConsumer: nextConsumer,
+		rules:        rules,
+	}, nil
+}
+
+func (tcp *traceConditionalAttributesProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	td.Resource = applyRules(tcp.rules, td.Node, td.Resource)
+	return tcp.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+type metricsConditionalAttributesProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	rules        []rule
+}
+
+var _ processor.MetricsProcessor = (*metricsConditionalAttributesProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that inserts the
+// attributes of the rules whose condition the batch matches in its resource.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsConditionalAttributesProcessor{
+		nextConsumer: nextConsumer,
+		rules:        rules,
+	}, nil
+}
+
+func (mcp *metricsConditionalAttributesProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	md.Resource = applyRules(mcp.rules, md.Node, md.Resource)
+	return mcp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// applyRules returns the resource with the attributes of the matching rules
+// inserted. The conditions are evaluated against the attributes of the batch as
+// received, the labels of the resource taking precedence over the attributes of
+// the node. A new resource is returned if any label is inserted.
+func applyRules(rules []rule, node *commonpb.Node, resource *resourcepb.Resource) *resourcepb.Resource {
+	get := func(key string) (string, bool) {
+		if value, ok := resource.GetLabels()[key]; ok {
+			return value, true
+		}
+		value, ok := node.GetAttributes()[key]
+		return value, ok
+	}
+
+	var labels map[string]string
+	for _, r := range rules {
+		if !r.when.matches(get) {
+			continue
+		}
+		for key, value := range r.attributes {
+			if _, exists := get(key); exists {
+				continue
+			}
+			if _, inserted := labels[key]; inserted {
+				// Inserted by a previous rule.
+				continue
+			}
+			if labels == nil {
+				labels = make(map[string]string, len(resource.GetLabels())+len(r.attributes))
+				for k, v := range resource.GetLabels() {
+					labels[k] = v
+				}
+			}
+			labels[key] = value
+		}
+	}
+
+	if labels == nil {
+		return resource
+	}
+	return &resourcepb.Resource{
+		Type:   resource.GetType(),
+		Labels: labels,
+	}
+}
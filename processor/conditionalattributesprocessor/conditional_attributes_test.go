@@ -0,0 +1,183 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditionalattributesprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+var costCenterRule = Rule{
+	When:       Condition{Key: "team", Values: []string{"payments"}},
+	Attributes: map[string]string{"cost_center": "x"},
+}
+
+func TestMatchingAndNonMatchingBatches(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(sink, Config{Rules: []Rule{costCenterRule}})
+	require.NoError(t, err)
+
+	payments := &resourcepb.Resource{Type: "k8s", Labels: map[string]string{"team": "payments"}}
+	search := &resourcepb.Resource{Type: "k8s", Labels: map[string]string{"team": "search"}}
+	metrics := []*metricspb.Metric{{MetricDescriptor: &metricspb.MetricDescriptor{Name: "requests"}}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Resource: payments, Metrics: metrics}))
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Resource: search, Metrics: metrics}))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	assert.Equal(t, &resourcepb.Resource{
+		Type:   "k8s",
+		Labels: map[string]string{"team": "payments", "cost_center": "x"},
+	}, got[0].Resource)
+	assert.Equal(t, map[string]string{"team": "payments"}, payments.Labels, "the resource must not be modified")
+	assert.True(t, search == got[1].Resource, "the non matching batch must pass unchanged")
+	assert.Equal(t, metrics, got[1].Metrics)
+}
+
+func TestNodeAttributesAndExistingAttributes(t *testing.T) {
+	sink := &exportertest.SinkTraceExporter{}
+	tp, err := NewTraceProcessor(sink, Config{Rules: []Rule{costCenterRule}})
+	require.NoError(t, err)
+
+	node := &commonpb.Node{Attributes: map[string]string{"team": "payments"}}
+	spans := []*tracepb.Span{{Name: &tracepb.TruncatableString{Value: "checkout"}}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), consumerdata.TraceData{Node: node, Spans: spans}))
+
+	// The attribute already set is kept.
+	existing := &resourcepb.Resource{Labels: map[string]string{"team": "payments", "cost_center": "y"}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), consumerdata.TraceData{Resource: existing, Spans: spans}))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 2)
+	assert.Equal(t, map[string]string{"cost_center": "x"}, got[0].Resource.Labels)
+	assert.Equal(t, spans, got[0].Spans)
+	assert.True(t, existing == got[1].Resource)
+}
+
+func TestConditionComposition(t *testing.T) {
+	cfg := Config{Rules: []Rule{
+		costCenterRule,
+		{
+			When: Condition{All: []Condition{
+				{Key: "region"},
+				{Any: []Condition{
+					{Key: "env", Values: []string{"prod"}},
+					{Key: "tier", Values: []string{"critical", "high"}},
+				}},
+			}},
+			Attributes: map[string]string{"oncall": "sre"},
+		},
+	}}
+	rules, err := compileRules(cfg.Rules)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   map[string]string
+	}{
+		{
+			name:   "both",
+			labels: map[string]string{"team": "payments", "region": "eu", "env": "prod"},
+			want:   map[string]string{"team": "payments", "region": "eu", "env": "prod", "cost_center": "x", "oncall": "sre"},
+		},
+		{
+			name:   "any second",
+			labels: map[string]string{"region": "eu", "env": "dev", "tier": "high"},
+			want:   map[string]string{"region": "eu", "env": "dev", "tier": "high", "oncall": "sre"},
+		},
+		{
+			name:   "all missing key",
+			labels: map[string]string{"env": "prod"},
+			want:   map[string]string{"env": "prod"},
+		},
+		{
+			name:   "any none",
+			labels: map[string]string{"region": "eu", "env": "dev", "tier": "low"},
+			want:   map[string]string{"region": "eu", "env": "dev", "tier": "low"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyRules(rules, nil, &resourcepb.Resource{Labels: tt.labels})
+			assert.Equal(t, tt.want, got.Labels)
+		})
+	}
+
+	// A batch without resource gets one.
+	got := applyRules(rules, &commonpb.Node{Attributes: map[string]string{"team": "payments"}}, nil)
+	assert.Equal(t, map[string]string{"cost_center": "x"}, got.GetLabels())
+	assert.Nil(t, applyRules(rules, nil, nil))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	attributes := map[string]string{"a": "b"}
+	tests := []struct {
+		name    string
+		rules   []Rule
+		wantErr string
+	}{
+		{
+			name:    "no rules",
+			wantErr: "rules must list at least one rule",
+		},
+		{
+			name:    "empty condition",
+			rules:   []Rule{{Attributes: attributes}},
+			wantErr: "invalid condition of rule 0: a condition must set exactly one of key, all or any",
+		},
+		{
+			name:    "key and all",
+			rules:   []Rule{{When: Condition{Key: "a", All: []Condition{{Key: "b"}}}, Attributes: attributes}},
+			wantErr: "invalid condition of rule 0: a condition must set exactly one of key, all or any",
+		},
+		{
+			name:    "values without key",
+			rules:   []Rule{{When: Condition{Values: []string{"a"}, Any: []Condition{{Key: "b"}}}, Attributes: attributes}},
+			wantErr: "invalid condition of rule 0: values require a key",
+		},
+		{
+			name:    "invalid nested",
+			rules:   []Rule{costCenterRule, {When: Condition{Any: []Condition{{Key: "a"}, {}}}, Attributes: attributes}},
+			wantErr: "invalid condition of rule 1: a condition must set exactly one of key, all or any",
+		},
+		{
+			name:    "no attributes",
+			rules:   []Rule{{When: Condition{Key: "a"}}},
+			wantErr: "rule 0 has no attributes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMetricsProcessor(exportertest.NewNopMetricsExporter(), Config{Rules: tt.rules})
+			assert.Nil(t, mp)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := NewTraceProcessor(nil, Config{Rules: []Rule{costCenterRule}})
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
@@ -0,0 +1,48 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditionalattributesprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the conditional attributes processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Rules are the attributes to insert and the conditions to insert them
+	// on, applied in order.
+	Rules []Rule `mapstructure:"rules"`
+}
+
+// Rule inserts attributes in the batches matching its condition.
+type Rule struct {
+	// When is the condition the batch attributes must match.
+	When Condition `mapstructure:"when"`
+	// Attributes are the attributes inserted in the resource of the matching
+	// batches, the attributes the batch already has are kept.
+	Attributes map[string]string `mapstructure:"attributes"`
+}
+
+// Condition is a predicate over the attributes of a batch: the labels of its
+// resource and the attributes of its node. Exactly one of Key, All or Any must
+// be set.
+type Condition struct {
+	// Key matches the batches having the attribute, with one of Values if
+	// set.
+	Key    string   `mapstructure:"key"`
+	Values []string `mapstructure:"values"`
+	// All matches the batches matching all of the conditions.
+	All []Condition `mapstructure:"all"`
+	// Any matches the batches matching any of the conditions.
+	Any []Condition `mapstructure:"any"`
+}
@@ -0,0 +1,121 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+)
+
+// DropObserver is notified of the data dropped by the processors, see
+// RecordDropped.
+type DropObserver interface {
+	// ObserveDropped is called when count items of the given name, e.g. the
+	// series of a metric or the spans of an operation, were dropped by the
+	// named processor for the given reason. It must not block.
+	ObserveDropped(processorName, reason, name string, count int64)
+}
+
+var dropObservers = &dropObserverList{}
+
+// AddDropObserver registers an observer notified of the data dropped by the
+// processors, and returns a function unregistering it.
+func AddDropObserver(o DropObserver) func() {
+	return dropObservers.add(o)
+}
+
+// RecordDropped notifies the registered observers that count items of the
+// given name were dropped by the named processor for the given reason. It is
+// a no-op when no observer is registered.
+func RecordDropped(processorName, reason, name string, count int64) {
+	if count <= 0 {
+		return
+	}
+	for _, o := range dropObservers.get() {
+		o.ObserveDropped(processorName, reason, name, count)
+	}
+}
+
+// RecordDroppedMetrics records the series of the given metrics as dropped, by
+// metric name, see RecordDropped.
+func RecordDroppedMetrics(processorName, reason string, metrics []*metricspb.Metric) {
+	if len(dropObservers.get()) == 0 {
+		return
+	}
+	for _, metric := range metrics {
+		RecordDropped(processorName, reason, metric.GetMetricDescriptor().GetName(), int64(len(metric.GetTimeseries())))
+	}
+}
+
+// RecordDroppedSpans records the given spans as dropped, by span name, see
+// RecordDropped.
+func RecordDroppedSpans(processorName, reason string, spans []*tracepb.Span) {
+	if len(dropObservers.get()) == 0 {
+		return
+	}
+	counts := make(map[string]int64)
+	for _, span := range spans {
+		counts[span.GetName().GetValue()]++
+	}
+	for name, count := range counts {
+		RecordDropped(processorName, reason, name, count)
+	}
+}
+
+// dropObserverList is a copy on write list of observers, read on every drop.
+type dropObserverList struct {
+	mu        sync.RWMutex
+	observers []*dropObserverEntry
+}
+
+// dropObserverEntry wraps an observer so that it can be removed even if the
+// same observer is registered twice.
+type dropObserverEntry struct {
+	DropObserver
+}
+
+func (l *dropObserverList) add(o DropObserver) func() {
+	entry := &dropObserverEntry{o}
+	l.mu.Lock()
+	observers := make([]*dropObserverEntry, len(l.observers), len(l.observers)+1)
+	copy(observers, l.observers)
+	l.observers = append(observers, entry)
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.remove(entry) })
+	}
+}
+
+func (l *dropObserverList) remove(entry *dropObserverEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	observers := make([]*dropObserverEntry, 0, len(l.observers))
+	for _, o := range l.observers {
+		if o != entry {
+			observers = append(observers, o)
+		}
+	}
+	l.observers = observers
+}
+
+func (l *dropObserverList) get() []*dropObserverEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.observers
+}
@@ -0,0 +1,82 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"sync"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+type dropRecorder struct {
+	mu    sync.Mutex
+	drops map[string]int64
+}
+
+func (r *dropRecorder) ObserveDropped(processorName, reason, name string, count int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.drops == nil {
+		r.drops = make(map[string]int64)
+	}
+	r.drops[processorName+"/"+reason+"/"+name] += count
+}
+
+func TestRecordDropped(t *testing.T) {
+	// Nothing is recorded without observers.
+	RecordDropped("proc", "reason", "name", 1)
+
+	r := &dropRecorder{}
+	remove := AddDropObserver(r)
+	RecordDropped("proc", "reason", "name", 2)
+	RecordDropped("proc", "reason", "name", 0)
+	RecordDroppedMetrics("proc", "missing_labels", []*metricspb.Metric{
+		{
+			MetricDescriptor: &metricspb.MetricDescriptor{Name: "cpu"},
+			Timeseries:       []*metricspb.TimeSeries{{}, {}},
+		},
+	})
+	RecordDroppedSpans("proc", "sampled_out", []*tracepb.Span{
+		{Name: &tracepb.TruncatableString{Value: "GET"}},
+		{Name: &tracepb.TruncatableString{Value: "GET"}},
+		{Name: &tracepb.TruncatableString{Value: "POST"}},
+	})
+	remove()
+	remove()
+	RecordDropped("proc", "reason", "name", 5)
+
+	assert.Equal(t, map[string]int64{
+		"proc/reason/name":        2,
+		"proc/missing_labels/cpu": 2,
+		"proc/sampled_out/GET":    2,
+		"proc/sampled_out/POST":   1,
+	}, r.drops)
+}
+
+func TestAddDropObserverTwice(t *testing.T) {
+	r := &dropRecorder{}
+	removeFirst := AddDropObserver(r)
+	removeSecond := AddDropObserver(r)
+	RecordDropped("proc", "reason", "name", 1)
+	removeFirst()
+	RecordDropped("proc", "reason", "name", 1)
+	removeSecond()
+	RecordDropped("proc", "reason", "name", 1)
+
+	assert.Equal(t, int64(3), r.drops["proc/reason/name"])
+}
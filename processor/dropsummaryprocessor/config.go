@@ -0,0 +1,32 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dropsummaryprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config defines configuration for the drop summary processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Interval is how often the summary of the dropped data is reported.
+	Interval time.Duration `mapstructure:"interval"`
+	// MaxNames is the maximum number of distinct names of the dropped data
+	// reported per processor and reason. The names seen once the limit is
+	// reached are reported as "__other__".
+	MaxNames int `mapstructure:"max_names"`
+}
@@ -0,0 +1,233 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dropsummaryprocessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	// droppedMetricName is the name of the metric counting the dropped data.
+	droppedMetricName = "dropped_data"
+
+	// otherName is the name reported for the names of the dropped data seen
+	// once the limit of names is reached.
+	otherName = "__other__"
+
+	processorLabel = "processor"
+	reasonLabel    = "reason"
+	nameLabel      = "name"
+)
+
+// dropKey identifies the data dropped by a processor for a reason.
+type dropKey struct {
+	processor string
+	reason    string
+	name      string
+}
+
+type dropSummaryProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	maxNames     int
+	statsTags    []tag.Mutator
+	startTime    *timestamp.Timestamp
+	now          func() time.Time
+
+	mu     sync.Mutex
+	counts map[dropKey]int64
+	// names counts the distinct names per processor and reason.
+	names map[dropKey]int
+
+	removeObserver func()
+	stopCh         chan struct{}
+	doneCh         chan struct{}
+	stopOnce       sync.Once
+}
+
+var _ processor.MetricsProcessor = (*dropSummaryProcessor)(nil)
+var _ processor.Shutdowner = (*dropSummaryProcessor)(nil)
+var _ processor.DropObserver = (*dropSummaryProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor reporting at every
+// interval, down its pipeline, the number of items dropped by the processors
+// of all the pipelines since it was created, per processor, reason and name of
+// the data, see processor.RecordDropped. The data of its pipeline is passed
+// through.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %v", cfg.Interval)
+	}
+	if cfg.MaxNames <= 0 {
+		return nil, fmt.Errorf("max_names must be positive, got %d", cfg.MaxNames)
+	}
+
+	startTime, _ := ptypes.TimestampProto(time.Now())
+	dsp := &dropSummaryProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		maxNames:     cfg.MaxNames,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+		startTime:    startTime,
+		now:          time.Now,
+		counts:       make(map[dropKey]int64),
+		names:        make(map[dropKey]int),
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+	dsp.removeObserver = processor.AddDropObserver(dsp)
+	go dsp.reportEvery(cfg.Interval)
+	return dsp, nil
+}
+
+func (dsp *dropSummaryProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	return dsp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// ObserveDropped counts the dropped data, the names beyond the limit being
+// counted as otherName.
+func (dsp *dropSummaryProcessor) ObserveDropped(processorName, reason, name string, count int64) {
+	key := dropKey{processor: processorName, reason: reason, name: name}
+	namesKey := dropKey{processor: processorName, reason: reason}
+
+	dsp.mu.Lock()
+	defer dsp.mu.Unlock()
+	if _, ok := dsp.counts[key]; !ok {
+		if dsp.names[namesKey] >= dsp.maxNames {
+			key.name = otherName
+		} else {
+			dsp.names[namesKey]++
+		}
+	}
+	dsp.counts[key] += count
+}
+
+// Shutdown stops the reporting, after reporting the data dropped so far.
+func (dsp *dropSummaryProcessor) Shutdown() error {
+	var err error
+	dsp.stopOnce.Do(func() {
+		dsp.removeObserver()
+		close(dsp.stopCh)
+		<-dsp.doneCh
+		err = dsp.report(context.Background())
+	})
+	return err
+}
+
+func (dsp *dropSummaryProcessor) reportEvery(interval time.Duration) {
+	defer close(dsp.doneCh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-dsp.stopCh:
+			return
+		case <-ticker.C:
+			if err := dsp.report(context.Background()); err != nil {
+				dsp.logger.Warn("Failed to send the summary of the dropped data",
+					zap.String("processor", dsp.name), zap.Error(err))
+			}
+		}
+	}
+}
+
+// report sends the summary of the data dropped so far down the pipeline,
+// nothing if no data was dropped.
+func (dsp *dropSummaryProcessor) report(ctx context.Context) error {
+	metric := dsp.summary()
+	if metric == nil {
+		return nil
+	}
+	err := dsp.nextConsumer.ConsumeMetricsData(ctx, consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric}})
+	if err != nil {
+		stats.RecordWithTags(context.Background(), dsp.statsTags, statSummaryFailures.M(1))
+	}
+	return err
+}
+
+// summary returns the cumulative counts of the dropped data, nil if none.
+func (dsp *dropSummaryProcessor) summary() *metricspb.Metric {
+	dsp.mu.Lock()
+	defer dsp.mu.Unlock()
+	if len(dsp.counts) == 0 {
+		return nil
+	}
+
+	keys := make([]dropKey, 0, len(dsp.counts))
+	for key := range dsp.counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].processor != keys[j].processor {
+			return keys[i].processor < keys[j].processor
+		}
+		if keys[i].reason != keys[j].reason {
+			return keys[i].reason < keys[j].reason
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	ts, _ := ptypes.TimestampProto(dsp.now())
+	timeseries := make([]*metricspb.TimeSeries, 0, len(keys))
+	for _, key := range keys {
+		timeseries = append(timeseries, &metricspb.TimeSeries{
+			StartTimestamp: dsp.startTime,
+			LabelValues: []*metricspb.LabelValue{
+				{Value: key.processor, HasValue: true},
+				{Value: key.reason, HasValue: true},
+				{Value: key.name, HasValue: true},
+			},
+			Points: []*metricspb.Point{{
+				Timestamp: ts,
+				Value:     &metricspb.Point_Int64Value{Int64Value: dsp.counts[key]},
+			}},
+		})
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        droppedMetricName,
+			Description: "Number of items dropped by the processors",
+			Unit:        "1",
+			Type:        metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys: []*metricspb.LabelKey{
+				{Key: processorLabel},
+				{Key: reasonLabel},
+				{Key: nameLabel},
+			},
+		},
+		Timeseries: timeseries,
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dropsummaryprocessor
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+	"github.com/open-telemetry/opentelemetry-service/processor/requiredlabelsprocessor"
+)
+
+func newConfig() Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		Interval: time.Hour,
+		MaxNames: defaultMaxNames,
+	}
+}
+
+// summaryCounts returns the counts of the last summary received by the sink,
+// keyed by processor, reason and name joined by "/".
+func summaryCounts(t *testing.T, sink *exportertest.SinkMetricsExporter) map[string]int64 {
+	all := sink.AllMetrics()
+	require.NotEmpty(t, all)
+	metrics := all[len(all)-1].Metrics
+	require.Len(t, metrics, 1)
+	require.Equal(t, droppedMetricName, metrics[0].MetricDescriptor.Name)
+
+	counts := make(map[string]int64)
+	for _, ts := range metrics[0].Timeseries {
+		key := ts.LabelValues[0].Value + "/" + ts.LabelValues[1].Value + "/" + ts.LabelValues[2].Value
+		counts[key] = ts.Points[0].GetInt64Value()
+	}
+	return counts
+}
+
+func TestNewMetricsProcessor(t *testing.T) {
+	next := exportertest.NewNopMetricsExporter()
+
+	_, err := NewMetricsProcessor(zap.NewNop(), nil, newConfig())
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	tests := []struct {
+		name  string
+		tweak func(cfg *Config)
+	}{
+		{name: "zero interval", tweak: func(cfg *Config) { cfg.Interval = 0 }},
+		{name: "zero max names", tweak: func(cfg *Config) { cfg.MaxNames = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig()
+			tt.tweak(&cfg)
+			mp, err := NewMetricsProcessor(zap.NewNop(), next, cfg)
+			assert.Nil(t, mp)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDropSummaryAfterInducedDrops(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	cfg := newConfig()
+	cfg.Interval = 10 * time.Millisecond
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+	defer mp.(processor.Shutdowner).Shutdown()
+
+	// A pipeline dropping the series missing the "env" label.
+	rlp, err := requiredlabelsprocessor.NewMetricsProcessor(exportertest.NewNopMetricsExporter(), nil, requiredlabelsprocessor.Config{
+		ProcessorSettings: configmodels.ProcessorSettings{TypeVal: "required_labels", NameVal: "required_labels"},
+		Labels:            []string{"env"},
+		Policy:            requiredlabelsprocessor.DropPolicy,
+	})
+	require.NoError(t, err)
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "requests",
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys: []*metricspb.LabelKey{{Key: "env"}},
+		},
+		Timeseries: []*metricspb.TimeSeries{
+			{LabelValues: []*metricspb.LabelValue{{Value: "prod", HasValue: true}}},
+			{LabelValues: []*metricspb.LabelValue{{}}},
+			{LabelValues: []*metricspb.LabelValue{{}}},
+		},
+	}}}
+	require.NoError(t, rlp.ConsumeMetricsData(context.Background(), md))
+
+	assert.Eventually(t, func() bool { return len(sink.AllMetrics()) > 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, map[string]int64{"required_labels/missing_required_labels/requests": 2}, summaryCounts(t, sink))
+}
+
+func TestDropSummaryIsCumulative(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig())
+	require.NoError(t, err)
+	dsp := mp.(*dropSummaryProcessor)
+	defer dsp.Shutdown()
+
+	require.NoError(t, dsp.report(context.Background()))
+	assert.Empty(t, sink.AllMetrics(), "nothing is reported until data is dropped")
+
+	processor.RecordDropped("filter", "value_filter", "cpu", 3)
+	require.NoError(t, dsp.report(context.Background()))
+	processor.RecordDropped("filter", "value_filter", "cpu", 2)
+	processor.RecordDropped("catalog", "not_in_catalog", "debug", 1)
+	require.NoError(t, dsp.report(context.Background()))
+
+	assert.Equal(t, map[string]int64{
+		"catalog/not_in_catalog/debug": 1,
+		"filter/value_filter/cpu":      5,
+	}, summaryCounts(t, sink))
+}
+
+func TestDropSummaryBoundsNames(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	cfg := newConfig()
+	cfg.MaxNames = 2
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+	dsp := mp.(*dropSummaryProcessor)
+
+	for i := 0; i < 5; i++ {
+		processor.RecordDropped("filter", "value_filter", "metric"+strconv.Itoa(i), 1)
+	}
+	processor.RecordDropped("filter", "value_filter", "metric0", 1)
+	processor.RecordDropped("filter", "other_reason", "metric4", 1)
+	require.NoError(t, dsp.Shutdown())
+
+	assert.Equal(t, map[string]int64{
+		"filter/value_filter/metric0":   2,
+		"filter/value_filter/metric1":   1,
+		"filter/value_filter/__other__": 3,
+		"filter/other_reason/metric4":   1,
+	}, summaryCounts(t, sink))
+
+	processor.RecordDropped("filter", "value_filter", "metric0", 1)
+	assert.Len(t, sink.AllMetrics(), 1, "nothing is reported once shut down")
+}
+
+func TestDropSummaryPassesDataThrough(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig())
+	require.NoError(t, err)
+	defer mp.(processor.Shutdowner).Shutdown()
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{{MetricDescriptor: &metricspb.MetricDescriptor{Name: "cpu"}}}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Equal(t, []consumerdata.MetricsData{md}, sink.AllMetrics())
+}
@@ -0,0 +1,36 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exemplarsprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config defines configuration for the exemplars processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// LatencyThreshold is the minimum duration of the spans sampled as exemplars.
+	LatencyThreshold time.Duration `mapstructure:"latency_threshold"`
+	// OperationLabel is the label of the histogram time series holding the
+	// operation name, matched against the span names.
+	OperationLabel string `mapstructure:"operation_label"`
+	// MaxKeys is the maximum number of service/operation pairs kept in the
+	// span sample cache, the least recently updated pair is evicted first.
+	MaxKeys int `mapstructure:"max_keys"`
+	// SamplesPerKey is the maximum number of spans kept for each pair.
+	SamplesPerKey int `mapstructure:"samples_per_key"`
+}
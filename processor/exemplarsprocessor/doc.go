@@ -0,0 +1,19 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exemplarsprocessor contains the logic to attach trace exemplars to
+// histogram metrics. High latency spans seen by the trace side of the
+// processor are sampled into a bounded cache keyed by service and operation,
+// the metrics side attaches them to the histogram buckets they fall into.
+package exemplarsprocessor
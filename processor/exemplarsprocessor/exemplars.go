@@ -0,0 +1,285 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exemplarsprocessor
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/internal"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// Attachment keys of the exemplars added by the processor.
+const (
+	TraceIDAttachment = "trace_id"
+	SpanIDAttachment  = "span_id"
+)
+
+type traceExemplarsProcessor struct {
+	nextConsumer     consumer.TraceConsumer
+	latencyThreshold time.Duration
+	cache            *spanCache
+}
+
+var _ processor.TraceProcessor = (*traceExemplarsProcessor)(nil)
+
+func newTraceProcessor(nextConsumer consumer.TraceConsumer, cfg Config, cache *spanCache) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	return &traceExemplarsProcessor{
+		nextConsumer:     nextConsumer,
+		latencyThreshold: cfg.LatencyThreshold,
+		cache:            cache,
+	}, nil
+}
+
+// ConsumeTraceData samples the high latency spans into the cache and passes the
+// data unchanged to the next consumer.
+func (tep *traceExemplarsProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	service := processor.ServiceNameForNode(td.Node)
+	for _, span := range td.Spans {
+		if span == nil || span.Name == nil || len(span.TraceId) == 0 {
+			continue
+		}
+		start, err := ptypes.Timestamp(span.StartTime)
+		if err != nil {
+			continue
+		}
+		end, err := ptypes.Timestamp(span.EndTime)
+		if err != nil {
+			continue
+		}
+		duration := end.Sub(start)
+		if duration < tep.latencyThreshold {
+			continue
+		}
+		tep.cache.add(service, span.Name.Value, spanSample{
+			traceID:  span.TraceId,
+			spanID:   span.SpanId,
+			duration: duration,
+			endTime:  end,
+		})
+	}
+	return tep.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+type metricsExemplarsProcessor struct {
+	nextConsumer   consumer.MetricsConsumer
+	operationLabel string
+	cache          *spanCache
+}
+
+var _ processor.MetricsProcessor = (*metricsExemplarsProcessor)(nil)
+
+func newMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config, cache *spanCache) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	operationLabel := cfg.OperationLabel
+	if operationLabel == "" {
+		operationLabel = defaultOperationLabel
+	}
+	return &metricsExemplarsProcessor{
+		nextConsumer:   nextConsumer,
+		operationLabel: operationLabel,
+		cache:          cache,
+	}, nil
+}
+
+// ConsumeMetricsData attaches the cached span samples of the same service and
+// operation to the buckets of the histogram points without exemplars.
+func (mep *metricsExemplarsProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	service := processor.ServiceNameForNode(md.Node)
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		exemplified, ok := mep.exemplifyMetric(service, metric)
+		if !ok {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = exemplified
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return mep.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// exemplifyMetric returns a copy of the histogram metric with the samples of
+// its operations attached, and whether any was attached. The metric is not
+// modified.
+func (mep *metricsExemplarsProcessor) exemplifyMetric(service string, metric *metricspb.Metric) (*metricspb.Metric, bool) {
+	desc := metric.GetMetricDescriptor()
+	if desc == nil {
+		return nil, false
+	}
+	if desc.Type != metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION &&
+		desc.Type != metricspb.MetricDescriptor_GAUGE_DISTRIBUTION {
+		return nil, false
+	}
+	labelIdx := -1
+	for i, key := range desc.LabelKeys {
+		if key.GetKey() == mep.operationLabel {
+			labelIdx = i
+			break
+		}
+	}
+	if labelIdx < 0 {
+		return nil, false
+	}
+	unit := durationUnit(desc.Unit)
+
+	var timeseries []*metricspb.TimeSeries
+	for i, ts := range metric.Timeseries {
+		if labelIdx >= len(ts.GetLabelValues()) || !ts.LabelValues[labelIdx].GetHasValue() {
+			continue
+		}
+		samples := mep.cache.get(service, ts.LabelValues[labelIdx].Value)
+		if len(samples) == 0 {
+			continue
+		}
+		var points []*metricspb.Point
+		for j, point := range ts.Points {
+			dv := point.GetDistributionValue()
+			if dv == nil {
+				continue
+			}
+			exemplified, ok := attachExemplars(dv, samples, unit)
+			if !ok {
+				continue
+			}
+			if points == nil {
+				points = make([]*metricspb.Point, len(ts.Points))
+				copy(points, ts.Points)
+			}
+			points[j] = &metricspb.Point{
+				Timestamp: point.Timestamp,
+				Value:     &metricspb.Point_DistributionValue{DistributionValue: exemplified},
+			}
+		}
+		if points == nil {
+			continue
+		}
+		if timeseries == nil {
+			timeseries = make([]*metricspb.TimeSeries, len(metric.Timeseries))
+			copy(timeseries, metric.Timeseries)
+		}
+		exemplifiedTs := *ts
+		exemplifiedTs.Points = points
+		timeseries[i] = &exemplifiedTs
+	}
+	if timeseries == nil {
+		return nil, false
+	}
+	exemplifiedMetric := *metric
+	exemplifiedMetric.Timeseries = timeseries
+	return &exemplifiedMetric, true
+}
+
+// attachExemplars returns a copy of the distribution with the most recent
+// sample falling into each non-empty bucket without an exemplar set as the
+// exemplar of the bucket, and whether any was set. The distribution is not
+// modified.
+func attachExemplars(dv *metricspb.DistributionValue, samples []spanSample, unit time.Duration) (*metricspb.DistributionValue, bool) {
+	bounds := dv.GetBucketOptions().GetExplicit().GetBounds()
+	if len(dv.Buckets) != len(bounds)+1 {
+		return nil, false
+	}
+	var buckets []*metricspb.DistributionValue_Bucket
+	for _, sample := range samples {
+		value := float64(sample.duration) / float64(unit)
+		idx := bucketIndex(bounds, value)
+		bucket := dv.Buckets[idx]
+		if buckets != nil {
+			bucket = buckets[idx]
+		}
+		if bucket == nil || bucket.Count == 0 {
+			continue
+		}
+		if bucket.Exemplar != nil && !isSampleExemplar(bucket.Exemplar) {
+			// Keep exemplars not added by this processor.
+			continue
+		}
+		if bucket.Exemplar != nil {
+			current, err := ptypes.Timestamp(bucket.Exemplar.Timestamp)
+			if err == nil && current.After(sample.endTime) {
+				continue
+			}
+		}
+		if buckets == nil {
+			buckets = make([]*metricspb.DistributionValue_Bucket, len(dv.Buckets))
+			copy(buckets, dv.Buckets)
+		}
+		exemplified := *bucket
+		exemplified.Exemplar = &metricspb.DistributionValue_Exemplar{
+			Value:     value,
+			Timestamp: internal.TimeToTimestamp(sample.endTime),
+			Attachments: map[string]string{
+				TraceIDAttachment: hex.EncodeToString(sample.traceID),
+				SpanIDAttachment:  hex.EncodeToString(sample.spanID),
+			},
+		}
+		buckets[idx] = &exemplified
+	}
+	if buckets == nil {
+		return nil, false
+	}
+	exemplifiedDv := *dv
+	exemplifiedDv.Buckets = buckets
+	return &exemplifiedDv, true
+}
+
+func isSampleExemplar(e *metricspb.DistributionValue_Exemplar) bool {
+	_, ok := e.Attachments[TraceIDAttachment]
+	return ok
+}
+
+// bucketIndex returns the index of the bucket the value falls into, bucket i
+// holds the values in [bounds[i-1], bounds[i]).
+func bucketIndex(bounds []float64, value float64) int {
+	for i, b := range bounds {
+		if value < b {
+			return i
+		}
+	}
+	return len(bounds)
+}
+
+// durationUnit returns the duration unit of histogram values with the given
+// unit, defaulting to milliseconds.
+func durationUnit(unit string) time.Duration {
+	switch unit {
+	case "ns":
+		return time.Nanosecond
+	case "us", "µs":
+		return time.Microsecond
+	case "s":
+		return time.Second
+	default:
+		return time.Millisecond
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exemplarsprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal"
+)
+
+func TestExemplarAttachedFromSpans(t *testing.T) {
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.LatencyThreshold = 100 * time.Millisecond
+
+	traceSink := &exportertest.SinkTraceExporter{}
+	tp, err := factory.CreateTraceProcessor(zap.NewNop(), traceSink, cfg)
+	require.NoError(t, err)
+	metricsSink := &exportertest.SinkMetricsExporter{}
+	mp, err := factory.CreateMetricsProcessor(zap.NewNop(), metricsSink, cfg)
+	require.NoError(t, err)
+
+	node := &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "checkout"}}
+	end := time.Unix(1500000000, 0)
+	td := consumerdata.TraceData{
+		Node: node,
+		Spans: []*tracepb.Span{
+			// Slow span for the operation, must be used as exemplar.
+			span("pay", []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, end, 750*time.Millisecond),
+			// Fast span, below the threshold.
+			span("pay", []byte{2, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, end, 10*time.Millisecond),
+			// Slow span of another operation.
+			span("refund", []byte{3, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, end, 2*time.Second),
+		},
+	}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	require.Len(t, traceSink.AllTraces(), 1)
+
+	md := consumerdata.MetricsData{
+		Node:    node,
+		Metrics: []*metricspb.Metric{latencyHistogram("pay")},
+	}
+	original := proto.Clone(md.Metrics[0])
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.True(t, proto.Equal(original, md.Metrics[0]), "the metrics may be shared, they must not be modified")
+
+	got := metricsSink.AllMetrics()
+	require.Len(t, got, 1)
+	buckets := got[0].Metrics[0].Timeseries[0].Points[0].GetDistributionValue().Buckets
+	require.Len(t, buckets, 3)
+	assert.Nil(t, buckets[0].Exemplar)
+	assert.Nil(t, buckets[2].Exemplar)
+	require.NotNil(t, buckets[1].Exemplar)
+	assert.Equal(t, float64(750), buckets[1].Exemplar.Value)
+	assert.Equal(t, internal.TimeToTimestamp(end), buckets[1].Exemplar.Timestamp)
+	assert.Equal(t, map[string]string{
+		TraceIDAttachment: "0102030405060708090a0b0c0d0e0f10",
+		SpanIDAttachment:  "0102030405060708",
+	}, buckets[1].Exemplar.Attachments)
+}
+
+func TestNoExemplarForOtherService(t *testing.T) {
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.LatencyThreshold = 0
+
+	tp, err := factory.CreateTraceProcessor(zap.NewNop(), exportertest.NewNopTraceExporter(), cfg)
+	require.NoError(t, err)
+	metricsSink := &exportertest.SinkMetricsExporter{}
+	mp, err := factory.CreateMetricsProcessor(zap.NewNop(), metricsSink, cfg)
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{
+		Node:  &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "frontend"}},
+		Spans: []*tracepb.Span{span("pay", []byte{1}, time.Unix(1, 0), 750*time.Millisecond)},
+	}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	md := consumerdata.MetricsData{
+		Node:    &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "checkout"}},
+		Metrics: []*metricspb.Metric{latencyHistogram("pay")},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	for _, b := range metricsSink.AllMetrics()[0].Metrics[0].Timeseries[0].Points[0].GetDistributionValue().Buckets {
+		assert.Nil(t, b.Exemplar)
+	}
+}
+
+func span(name string, traceID []byte, end time.Time, duration time.Duration) *tracepb.Span {
+	return &tracepb.Span{
+		TraceId:   traceID,
+		SpanId:    traceID[:len(traceID)/2],
+		Name:      &tracepb.TruncatableString{Value: name},
+		StartTime: internal.TimeToTimestamp(end.Add(-duration)),
+		EndTime:   internal.TimeToTimestamp(end),
+	}
+}
+
+// latencyHistogram returns a histogram, in milliseconds, with buckets
+// [0, 100), [100, 1000) and [1000, +Inf).
+func latencyHistogram(operation string) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "latency",
+			Unit:      "ms",
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION,
+			LabelKeys: []*metricspb.LabelKey{{Key: "operation"}},
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			LabelValues: []*metricspb.LabelValue{{Value: operation, HasValue: true}},
+			Points: []*metricspb.Point{{
+				Value: &metricspb.Point_DistributionValue{DistributionValue: &metricspb.DistributionValue{
+					Count: 6,
+					BucketOptions: &metricspb.DistributionValue_BucketOptions{
+						Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+							Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: []float64{100, 1000}},
+						},
+					},
+					Buckets: []*metricspb.DistributionValue_Bucket{{Count: 3}, {Count: 2}, {Count: 1}},
+				}},
+			}},
+		}},
+	}
+}
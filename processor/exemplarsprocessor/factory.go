@@ -0,0 +1,106 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exemplarsprocessor
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	// typeStr is the value of "type" key in configuration.
+	typeStr = "exemplars"
+
+	defaultLatencyThreshold = time.Second
+	defaultOperationLabel   = "operation"
+	defaultMaxKeys          = 1000
+	defaultSamplesPerKey    = 10
+)
+
+// Factory is the factory for the exemplars processor. The trace and metrics
+// processors created for the same configuration share their span cache.
+type Factory struct {
+	mu     sync.Mutex
+	caches map[string]*spanCache
+}
+
+// Type gets the type of the config created by this factory.
+func (f *Factory) Type() string {
+	return typeStr
+}
+
+// CreateDefaultConfig creates the default configuration for the processor.
+func (f *Factory) CreateDefaultConfig() configmodels.Processor {
+	return &Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		LatencyThreshold: defaultLatencyThreshold,
+		OperationLabel:   defaultOperationLabel,
+		MaxKeys:          defaultMaxKeys,
+		SamplesPerKey:    defaultSamplesPerKey,
+	}
+}
+
+// CreateTraceProcessor creates a trace processor based on this config.
+func (f *Factory) CreateTraceProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.TraceConsumer,
+	cfg configmodels.Processor,
+) (processor.TraceProcessor, error) {
+	oCfg := cfg.(*Config)
+	return newTraceProcessor(nextConsumer, *oCfg, f.cacheFor(oCfg))
+}
+
+// CreateMetricsProcessor creates a metrics processor based on this config.
+func (f *Factory) CreateMetricsProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.MetricsConsumer,
+	cfg configmodels.Processor,
+) (processor.MetricsProcessor, error) {
+	oCfg := cfg.(*Config)
+	return newMetricsProcessor(nextConsumer, *oCfg, f.cacheFor(oCfg))
+}
+
+// cacheFor returns the span cache shared by the processors of the given config.
+func (f *Factory) cacheFor(cfg *Config) *spanCache {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.caches == nil {
+		f.caches = make(map[string]*spanCache)
+	}
+	sc, ok := f.caches[cfg.Name()]
+	if !ok {
+		maxKeys := cfg.MaxKeys
+		if maxKeys <= 0 {
+			maxKeys = defaultMaxKeys
+		}
+		samplesPerKey := cfg.SamplesPerKey
+		if samplesPerKey <= 0 {
+			samplesPerKey = defaultSamplesPerKey
+		}
+		sc = newSpanCache(maxKeys, samplesPerKey)
+		f.caches[cfg.Name()] = sc
+	}
+	return sc
+}
@@ -0,0 +1,103 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exemplarsprocessor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/lru"
+)
+
+// spanSample is a span retained to be used as an exemplar.
+type spanSample struct {
+	traceID  []byte
+	spanID   []byte
+	duration time.Duration
+	endTime  time.Time
+}
+
+type cacheKey struct {
+	service   string
+	operation string
+}
+
+type cacheEntry struct {
+	samples []spanSample
+	// next is the index where the next sample is written once samples is full.
+	next int
+}
+
+// spanCache is a bounded cache of span samples keyed by service and operation.
+// It is shared by the trace and metrics processors created for the same
+// configuration and is safe for concurrent use.
+type spanCache struct {
+	samplesPerKey int
+
+	mu      sync.Mutex
+	entries *lru.Cache
+}
+
+func newSpanCache(maxKeys, samplesPerKey int) *spanCache {
+	return &spanCache{
+		samplesPerKey: samplesPerKey,
+		entries:       lru.New(maxKeys),
+	}
+}
+
+// add stores a sample for the given service and operation, replacing the
+// oldest sample of the pair and evicting the least recently updated pair as
+// needed to stay within bounds.
+func (sc *spanCache) add(service, operation string, sample spanSample) {
+	key := cacheKey{service: service, operation: operation}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	var entry *cacheEntry
+	if value, ok := sc.entries.Get(key); ok {
+		entry = value.(*cacheEntry)
+	} else {
+		entry = &cacheEntry{samples: make([]spanSample, 0, sc.samplesPerKey)}
+	}
+	sc.entries.Add(key, entry)
+
+	if len(entry.samples) < sc.samplesPerKey {
+		entry.samples = append(entry.samples, sample)
+		return
+	}
+	entry.samples[entry.next] = sample
+	entry.next = (entry.next + 1) % sc.samplesPerKey
+}
+
+// get returns a copy of the samples for the given service and operation.
+func (sc *spanCache) get(service, operation string) []spanSample {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	value, ok := sc.entries.Get(cacheKey{service: service, operation: operation})
+	if !ok {
+		return nil
+	}
+	samples := value.(*cacheEntry).samples
+	return append([]spanSample(nil), samples...)
+}
+
+// len returns the number of service/operation pairs in the cache.
+func (sc *spanCache) len() int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.entries.Len()
+}
@@ -0,0 +1,48 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exemplarsprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpanCacheBounds(t *testing.T) {
+	sc := newSpanCache(2, 2)
+
+	sc.add("svc", "a", spanSample{duration: 1})
+	sc.add("svc", "a", spanSample{duration: 2})
+	sc.add("svc", "a", spanSample{duration: 3})
+	durations := func(samples []spanSample) []time.Duration {
+		var d []time.Duration
+		for _, s := range samples {
+			d = append(d, s.duration)
+		}
+		return d
+	}
+	// The oldest sample of a key is replaced.
+	assert.ElementsMatch(t, []time.Duration{2, 3}, durations(sc.get("svc", "a")))
+
+	sc.add("svc", "b", spanSample{duration: 4})
+	// "a" is now the least recently updated key and gets evicted.
+	sc.add("svc", "c", spanSample{duration: 5})
+	assert.Equal(t, 2, sc.len())
+	assert.Nil(t, sc.get("svc", "a"))
+	assert.Len(t, sc.get("svc", "b"), 1)
+	assert.Len(t, sc.get("svc", "c"), 1)
+	assert.Nil(t, sc.get("other", "b"))
+}
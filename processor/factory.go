@@ -44,6 +44,81 @@ type Factory interface {
 		cfg configmodels.Processor) (MetricsProcessor, error)
 }
 
+// FallbackConfig is implemented by the configs of the processors forwarding the
+// data to fallback exporters, other than the ones of their pipeline. The service
+// builds these exporters along with the exporters of the pipelines.
+type FallbackConfig interface {
+	// FallbackExporterNames returns the names of the fallback exporters.
+	FallbackExporterNames() []string
+}
+
+// FallbackFactory is implemented by the factories of the processors forwarding
+// the data to fallback exporters, see FallbackConfig. The processors are created
+// with a consumer fanning out the data to the fallback exporters, nil if the
+// config names none.
+type FallbackFactory interface {
+	Factory
+
+	// CreateTraceFallbackProcessor creates a trace processor based on this config,
+	// forwarding the data to the fallback consumer as needed.
+	CreateTraceFallbackProcessor(logger *zap.Logger, nextConsumer, fallbackConsumer consumer.TraceConsumer,
+		cfg configmodels.Processor) (TraceProcessor, error)
+
+	// CreateMetricsFallbackProcessor creates a metrics processor based on this config,
+	// forwarding the data to the fallback consumer as needed.
+	CreateMetricsFallbackProcessor(logger *zap.Logger, nextConsumer, fallbackConsumer consumer.MetricsConsumer,
+		cfg configmodels.Processor) (MetricsProcessor, error)
+}
+
+// MetricsEmitterConfig is implemented by the configs of the trace processors
+// deriving metrics from the spans they pass through. The metrics are sent to the
+// metrics exporters named by the config, which the service builds along with
+// the exporters of the pipelines.
+type MetricsEmitterConfig interface {
+	// MetricsExporterNames returns the names of the exporters the derived
+	// metrics are sent to.
+	MetricsExporterNames() []string
+}
+
+// MetricsEmitterFactory is implemented by the factories of the trace processors
+// deriving metrics from the spans, see MetricsEmitterConfig. The processors are
+// created with a consumer fanning out the metrics to the metrics exporters, nil
+// if the config names none.
+type MetricsEmitterFactory interface {
+	Factory
+
+	// CreateTraceMetricsEmitterProcessor creates a trace processor based on this
+	// config, sending the metrics derived from the spans to the metrics consumer.
+	CreateTraceMetricsEmitterProcessor(logger *zap.Logger, nextConsumer consumer.TraceConsumer,
+		metricsConsumer consumer.MetricsConsumer, cfg configmodels.Processor) (TraceProcessor, error)
+}
+
+// RouterConfig is implemented by the configs of the processors routing the
+// data to exporters chosen per item, other than the ones of their pipeline. The
+// service builds these exporters along with the exporters of the pipelines.
+type RouterConfig interface {
+	// RouteExporterNames returns the names of the exporters of each route,
+	// keyed by route.
+	RouteExporterNames() map[string][]string
+}
+
+// RouterFactory is implemented by the factories of the processors routing the
+// data to exporters, see RouterConfig. The processors are created with a
+// consumer per route, fanning out the data to the exporters of the route.
+type RouterFactory interface {
+	Factory
+
+	// CreateTraceRouterProcessor creates a trace processor based on this config,
+	// routing the data to the consumers of the routes.
+	CreateTraceRouterProcessor(logger *zap.Logger, nextConsumer consumer.TraceConsumer,
+		routes map[string]consumer.TraceConsumer, cfg configmodels.Processor) (TraceProcessor, error)
+
+	// CreateMetricsRouterProcessor creates a metrics processor based on this
+	// config, routing the data to the consumers of the routes.
+	CreateMetricsRouterProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer,
+		routes map[string]consumer.MetricsConsumer, cfg configmodels.Processor) (MetricsProcessor, error)
+}
+
 // Build takes a list of processor factories and returns a map of type map[string]Factory
 // with factory type as keys. It returns a non-nil error when more than one factories
 // have the same type.
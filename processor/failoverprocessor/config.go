@@ -0,0 +1,54 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// FailoverOn is the kind of errors of the primary consumer triggering a failover.
+type FailoverOn string
+
+const (
+	// AllErrors fails over on any error.
+	AllErrors FailoverOn = "all"
+	// TransientErrors fails over on the errors not marked as permanent, the
+	// data failing permanently would fail on the fallback exporters too.
+	TransientErrors FailoverOn = "transient"
+	// PermanentErrors fails over only on the errors marked as permanent.
+	PermanentErrors FailoverOn = "permanent"
+)
+
+// Config defines configuration for the failover processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// FallbackExporters are the names of the exporters the data is forwarded
+	// to when the primary consumer, i.e. the rest of the pipeline, fails.
+	FallbackExporters []string `mapstructure:"fallback_exporters"`
+	// FailoverOn is the kind of errors triggering a failover: "all" (the
+	// default), "transient" or "permanent".
+	FailoverOn FailoverOn `mapstructure:"failover_on"`
+	// RetryInterval is how long the data goes directly to the fallback
+	// exporters after a failover, before the primary consumer is tried again.
+	// Zero, the default, tries the primary consumer with every batch.
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
+}
+
+// FallbackExporterNames returns the names of the fallback exporters.
+func (cfg *Config) FallbackExporterNames() []string {
+	return cfg.FallbackExporters
+}
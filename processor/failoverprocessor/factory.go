@@ -0,0 +1,92 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverprocessor
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	// typeStr is the value of "type" key in configuration.
+	typeStr = "failover"
+)
+
+// Factory is the factory for the failover processor.
+type Factory struct {
+}
+
+var _ processor.FallbackFactory = (*Factory)(nil)
+
+// Type gets the type of the config created by this factory.
+func (f *Factory) Type() string {
+	return typeStr
+}
+
+// CreateDefaultConfig creates the default configuration for the processor.
+func (f *Factory) CreateDefaultConfig() configmodels.Processor {
+	return &Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		FailoverOn: AllErrors,
+	}
+}
+
+// CreateTraceProcessor fails, the processor requires its fallback exporters,
+// see CreateTraceFallbackProcessor.
+func (f *Factory) CreateTraceProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.TraceConsumer,
+	cfg configmodels.Processor,
+) (processor.TraceProcessor, error) {
+	return f.CreateTraceFallbackProcessor(logger, nextConsumer, nil, cfg)
+}
+
+// CreateMetricsProcessor fails, the processor requires its fallback exporters,
+// see CreateMetricsFallbackProcessor.
+func (f *Factory) CreateMetricsProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.MetricsConsumer,
+	cfg configmodels.Processor,
+) (processor.MetricsProcessor, error) {
+	return f.CreateMetricsFallbackProcessor(logger, nextConsumer, nil, cfg)
+}
+
+// CreateTraceFallbackProcessor creates a trace processor based on this config.
+func (f *Factory) CreateTraceFallbackProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.TraceConsumer,
+	fallbackConsumer consumer.TraceConsumer,
+	cfg configmodels.Processor,
+) (processor.TraceProcessor, error) {
+	oCfg := cfg.(*Config)
+	return NewTraceProcessor(logger, nextConsumer, fallbackConsumer, *oCfg)
+}
+
+// CreateMetricsFallbackProcessor creates a metrics processor based on this config.
+func (f *Factory) CreateMetricsFallbackProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.MetricsConsumer,
+	fallbackConsumer consumer.MetricsConsumer,
+	cfg configmodels.Processor,
+) (processor.MetricsProcessor, error) {
+	oCfg := cfg.(*Config)
+	return NewMetricsProcessor(logger, nextConsumer, fallbackConsumer, *oCfg)
+}
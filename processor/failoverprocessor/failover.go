@@ -0,0 +1,199 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var errNilFallbackConsumer = errors.New("nil fallback consumer, the failover processor requires fallback exporters")
+
+// failover holds the state shared by the trace and metrics processors: whether
+// the primary consumer is failing and until when it is bypassed.
+type failover struct {
+	name          string
+	logger        *zap.Logger
+	failoverOn    FailoverOn
+	retryInterval time.Duration
+	statsTags     []tag.Mutator
+
+	mu          sync.Mutex
+	failing     bool
+	bypassUntil time.Time
+}
+
+func newFailover(logger *zap.Logger, cfg Config) (*failover, error) {
+	failoverOn := cfg.FailoverOn
+	switch failoverOn {
+	case "":
+		failoverOn = AllErrors
+	case AllErrors, TransientErrors, PermanentErrors:
+	default:
+		return nil, fmt.Errorf("invalid failover_on %q, must be one of %q, %q or %q",
+			failoverOn, AllErrors, TransientErrors, PermanentErrors)
+	}
+	if cfg.RetryInterval < 0 {
+		return nil, fmt.Errorf("retry_interval must not be negative, got %v", cfg.RetryInterval)
+	}
+
+	return &failover{
+		name:          cfg.Name(),
+		logger:        logger,
+		failoverOn:    failoverOn,
+		retryInterval: cfg.RetryInterval,
+		statsTags:     []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+// consume sends the data with the primary function, unless the primary
+// consumer is bypassed, and with the fallback function if the primary one fails
+// with an error triggering a failover.
+func (f *failover) consume(primary, fallback func() error) error {
+	if !f.bypassingPrimary() {
+		err := primary()
+		if err == nil {
+			f.primarySucceeded()
+			return nil
+		}
+		if !f.triggersFailover(err) {
+			return err
+		}
+		f.primaryFailed(err)
+	}
+
+	stats.RecordWithTags(context.Background(), f.statsTags, statFailovers.M(1))
+	return fallback()
+}
+
+func (f *failover) bypassingPrimary() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failing && time.Now().Before(f.bypassUntil)
+}
+
+func (f *failover) primarySucceeded() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing {
+		f.failing = false
+		f.logger.Info("Primary consumer recovered, no longer failing over", zap.String("processor", f.name))
+	}
+}
+
+func (f *failover) primaryFailed(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.failing {
+		f.failing = true
+		f.logger.Warn("Primary consumer failed, failing over to the fallback exporters",
+			zap.String("processor", f.name), zap.Error(err))
+	}
+	f.bypassUntil = time.Now().Add(f.retryInterval)
+}
+
+func (f *failover) triggersFailover(err error) bool {
+	switch f.failoverOn {
+	case TransientErrors:
+		return !consumererror.IsPermanent(err)
+	case PermanentErrors:
+		return consumererror.IsPermanent(err)
+	default:
+		return true
+	}
+}
+
+type traceFailoverProcessor struct {
+	*failover
+	nextConsumer     consumer.TraceConsumer
+	fallbackConsumer consumer.TraceConsumer
+}
+
+var _ processor.TraceProcessor = (*traceFailoverProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that forwards the data
+// to the next consumer and, when it fails, to the fallback consumer.
+func NewTraceProcessor(logger *zap.Logger, nextConsumer, fallbackConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if fallbackConsumer == nil {
+		return nil, errNilFallbackConsumer
+	}
+	f, err := newFailover(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &traceFailoverProcessor{
+		failover:         f,
+		nextConsumer:     nextConsumer,
+		fallbackConsumer: fallbackConsumer,
+	}, nil
+}
+
+func (tfp *traceFailoverProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	return tfp.consume(
+		func() error { return tfp.nextConsumer.ConsumeTraceData(ctx, td) },
+		func() error { return tfp.fallbackConsumer.ConsumeTraceData(ctx, td) },
+	)
+}
+
+type metricsFailoverProcessor struct {
+	*failover
+	nextConsumer     consumer.MetricsConsumer
+	fallbackConsumer consumer.MetricsConsumer
+}
+
+var _ processor.MetricsProcessor = (*metricsFailoverProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that forwards the
+// data to the next consumer and, when it fails, to the fallback consumer.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer, fallbackConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if fallbackConsumer == nil {
+		return nil, errNilFallbackConsumer
+	}
+	f, err := newFailover(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsFailoverProcessor{
+		failover:         f,
+		nextConsumer:     nextConsumer,
+		fallbackConsumer: fallbackConsumer,
+	}, nil
+}
+
+func (mfp *metricsFailoverProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	return mfp.consume(
+		func() error { return mfp.nextConsumer.ConsumeMetricsData(ctx, md) },
+		func() error { return mfp.fallbackConsumer.ConsumeMetricsData(ctx, md) },
+	)
+}
@@ -0,0 +1,196 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverprocessor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewProcessorNilConsumers(t *testing.T) {
+	tp, err := NewTraceProcessor(zap.NewNop(), nil, &exportertest.SinkTraceExporter{}, Config{})
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	mp, err := NewMetricsProcessor(zap.NewNop(), &exportertest.SinkMetricsExporter{}, nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, errNilFallbackConsumer, err)
+}
+
+// flakyConsumer fails with the configured error, if any.
+type flakyConsumer struct {
+	mu  sync.Mutex
+	err error
+	exportertest.SinkTraceExporter
+	metrics exportertest.SinkMetricsExporter
+}
+
+func (fc *flakyConsumer) setErr(err error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.err = err
+}
+
+func (fc *flakyConsumer) getErr() error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.err
+}
+
+func (fc *flakyConsumer) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	if err := fc.getErr(); err != nil {
+		return err
+	}
+	return fc.SinkTraceExporter.ConsumeTraceData(ctx, td)
+}
+
+func (fc *flakyConsumer) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	if err := fc.getErr(); err != nil {
+		return err
+	}
+	return fc.metrics.ConsumeMetricsData(ctx, md)
+}
+
+func TestFailoverAndRecovery(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	primary := &flakyConsumer{}
+	fallback := &exportertest.SinkTraceExporter{}
+	cfg := Config{ProcessorSettings: configmodels.ProcessorSettings{NameVal: "failover/local"}}
+	tp, err := NewTraceProcessor(zap.NewNop(), primary, fallback, cfg)
+	require.NoError(t, err)
+
+	send := func(name string) {
+		td := consumerdata.TraceData{Spans: []*tracepb.Span{{Name: &tracepb.TruncatableString{Value: name}}}}
+		require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	}
+
+	send("before")
+	primary.setErr(errors.New("unavailable"))
+	send("during-1")
+	send("during-2")
+	primary.setErr(nil)
+	send("after")
+
+	assert.Equal(t, []string{"before", "after"}, spanNames(primary.AllTraces()))
+	assert.Equal(t, []string{"during-1", "during-2"}, spanNames(fallback.AllTraces()))
+
+	rows, err := view.RetrieveData(statFailovers.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestFailoverFallbackError(t *testing.T) {
+	primary := &flakyConsumer{}
+	primary.setErr(errors.New("unavailable"))
+	fallback := &flakyConsumer{}
+	fallback.setErr(errors.New("disk full"))
+	mp, err := NewMetricsProcessor(zap.NewNop(), primary, fallback, Config{})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{{}}}
+	assert.EqualError(t, mp.ConsumeMetricsData(context.Background(), md), "disk full")
+}
+
+func TestFailoverOn(t *testing.T) {
+	transient := errors.New("unavailable")
+	permanent := consumererror.Permanent(errors.New("bad data"))
+	tests := []struct {
+		failoverOn   FailoverOn
+		err          error
+		wantFailover bool
+	}{
+		{failoverOn: "", err: transient, wantFailover: true},
+		{failoverOn: AllErrors, err: permanent, wantFailover: true},
+		{failoverOn: TransientErrors, err: transient, wantFailover: true},
+		{failoverOn: TransientErrors, err: permanent, wantFailover: false},
+		{failoverOn: PermanentErrors, err: transient, wantFailover: false},
+		{failoverOn: PermanentErrors, err: permanent, wantFailover: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.failoverOn)+"/"+tt.err.Error(), func(t *testing.T) {
+			primary := &flakyConsumer{}
+			primary.setErr(tt.err)
+			fallback := &exportertest.SinkMetricsExporter{}
+			mp, err := NewMetricsProcessor(zap.NewNop(), primary, fallback, Config{FailoverOn: tt.failoverOn})
+			require.NoError(t, err)
+
+			err = mp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{})
+			if tt.wantFailover {
+				assert.NoError(t, err)
+				assert.Len(t, fallback.AllMetrics(), 1)
+			} else {
+				assert.Equal(t, tt.err, err)
+				assert.Empty(t, fallback.AllMetrics())
+			}
+		})
+	}
+}
+
+func TestFailoverRetryInterval(t *testing.T) {
+	primary := &flakyConsumer{}
+	fallback := &exportertest.SinkTraceExporter{}
+	tp, err := NewTraceProcessor(zap.NewNop(), primary, fallback, Config{RetryInterval: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	send := func(name string) {
+		td := consumerdata.TraceData{Spans: []*tracepb.Span{{Name: &tracepb.TruncatableString{Value: name}}}}
+		require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	}
+
+	primary.setErr(errors.New("unavailable"))
+	send("failed")
+	primary.setErr(nil)
+	// The primary consumer is bypassed until the retry interval elapses.
+	send("bypassed")
+	assert.Empty(t, primary.AllTraces())
+
+	time.Sleep(150 * time.Millisecond)
+	send("retried")
+	send("recovered")
+	assert.Equal(t, []string{"retried", "recovered"}, spanNames(primary.AllTraces()))
+	assert.Equal(t, []string{"failed", "bypassed"}, spanNames(fallback.AllTraces()))
+}
+
+func spanNames(tds []consumerdata.TraceData) []string {
+	var names []string
+	for _, td := range tds {
+		for _, span := range td.Spans {
+			names = append(names, span.Name.GetValue())
+		}
+	}
+	return names
+}
@@ -24,6 +24,11 @@ import (
 
 // This file contains implementations of Trace/Metrics connectors
 // that fan out the data to multiple other consumers.
+//
+// Every consumer receives the same data: the slices of metrics and spans,
+// the metrics, spans and resources they point to are shared by all the
+// pipelines of a receiver. Processors must not modify the data they receive,
+// they pass down copies of the parts they change instead.
 
 // NewMetricsFanOutConnector wraps multiple metrics consumers in a single one.
 func NewMetricsFanOutConnector(mcs []consumer.MetricsConsumer) MetricsProcessor {
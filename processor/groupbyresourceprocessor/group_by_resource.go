@@ -0,0 +1,134 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groupbyresourceprocessor
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type groupByResourceProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+}
+
+var _ processor.MetricsProcessor = (*groupByResourceProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that splits every
+// batch by the effective resource of its metrics, i.e. the resource of the
+// metric or, if it has none, the resource of the batch. Each group is sent as
+// its own batch, with the resource set once at the batch level.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	return &groupByResourceProcessor{nextConsumer: nextConsumer}, nil
+}
+
+func (gp *groupByResourceProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var errs []error
+	for _, group := range groupByResource(md) {
+		if err := gp.nextConsumer.ConsumeMetricsData(ctx, group); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return oterr.CombineErrors(errs)
+}
+
+// groupByResource splits the batch into one batch per distinct effective
+// resource, in the order the resources are first seen. The metrics of a group
+// share the first resource object seen for it and have no resource of their
+// own. A batch already normalized is returned as is.
+func groupByResource(md consumerdata.MetricsData) []consumerdata.MetricsData {
+	if isNormalized(md) {
+		return []consumerdata.MetricsData{md}
+	}
+
+	var groups []consumerdata.MetricsData
+	indexes := make(map[string]int)
+	for _, metric := range md.Metrics {
+		resource := md.Resource
+		if metric.GetResource() != nil {
+			resource = metric.Resource
+			metricCopy := *metric
+			metricCopy.Resource = nil
+			metric = &metricCopy
+		}
+		if isEmptyResource(resource) {
+			resource = nil
+		}
+
+		key := resourceKey(resource)
+		i, ok := indexes[key]
+		if !ok {
+			i = len(groups)
+			indexes[key] = i
+			groups = append(groups, consumerdata.MetricsData{Node: md.Node, Resource: resource})
+		}
+		groups[i].Metrics = append(groups[i].Metrics, metric)
+	}
+	return groups
+}
+
+// isNormalized returns true if none of the metrics overrides the batch resource.
+func isNormalized(md consumerdata.MetricsData) bool {
+	for _, metric := range md.Metrics {
+		if metric.GetResource() != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func isEmptyResource(resource *resourcepb.Resource) bool {
+	return resource.GetType() == "" && len(resource.GetLabels()) == 0
+}
+
+// resourceKey returns a key identifying the resource by its type and labels.
+// The lengths of the strings are part of the key, so that separators within
+// them cannot make two different resources collide.
+func resourceKey(resource *resourcepb.Resource) string {
+	if resource == nil {
+		return ""
+	}
+
+	keys := make([]string, 0, len(resource.Labels))
+	for k := range resource.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	writeKeyPart(&b, resource.Type)
+	for _, k := range keys {
+		writeKeyPart(&b, k)
+		writeKeyPart(&b, resource.Labels[k])
+	}
+	return b.String()
+}
+
+func writeKeyPart(b *strings.Builder, s string) {
+	b.WriteString(strconv.Itoa(len(s)))
+	b.WriteByte(':')
+	b.WriteString(s)
+}
@@ -0,0 +1,150 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groupbyresourceprocessor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestGroupByResource(t *testing.T) {
+	node := &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "svc"}}
+	batchResource := &resourcepb.Resource{Type: "host", Labels: map[string]string{"host": "a"}}
+	podA := &resourcepb.Resource{Type: "k8s", Labels: map[string]string{"pod": "a", "ns": "default"}}
+	podB := &resourcepb.Resource{Type: "k8s", Labels: map[string]string{"pod": "b", "ns": "default"}}
+
+	md := consumerdata.MetricsData{
+		Node:     node,
+		Resource: batchResource,
+		Metrics: []*metricspb.Metric{
+			metric("m1", nil),
+			metric("m2", podA),
+			metric("m3", podB),
+			// Same labels as podA but a different object.
+			metric("m4", &resourcepb.Resource{Type: "k8s", Labels: map[string]string{"ns": "default", "pod": "a"}}),
+			// Same as the batch resource.
+			metric("m5", &resourcepb.Resource{Type: "host", Labels: map[string]string{"host": "a"}}),
+			metric("m6", podB),
+		},
+	}
+
+	sink := &exportertest.SinkMetricsExporter{}
+	gp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+	require.NoError(t, gp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 3)
+	assert.Equal(t, batchResource, got[0].Resource)
+	assert.Equal(t, []string{"m1", "m5"}, names(got[0]))
+	assert.Equal(t, podA, got[1].Resource)
+	assert.Equal(t, []string{"m2", "m4"}, names(got[1]))
+	assert.Equal(t, podB, got[2].Resource)
+	assert.Equal(t, []string{"m3", "m6"}, names(got[2]))
+	for _, g := range got {
+		assert.Equal(t, node, g.Node)
+		for _, m := range g.Metrics {
+			assert.Nil(t, m.Resource)
+		}
+	}
+
+	// The input metrics are left untouched.
+	assert.Equal(t, podA, md.Metrics[1].Resource)
+}
+
+func TestGroupByResourceEmptyResources(t *testing.T) {
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			metric("m1", nil),
+			metric("m2", &resourcepb.Resource{}),
+		},
+	}
+	got := groupByResource(md)
+	require.Len(t, got, 1)
+	assert.Nil(t, got[0].Resource)
+	assert.Equal(t, []string{"m1", "m2"}, names(got[0]))
+}
+
+func TestGroupByResourceNormalizedBatch(t *testing.T) {
+	md := consumerdata.MetricsData{
+		Resource: &resourcepb.Resource{Type: "host"},
+		Metrics:  []*metricspb.Metric{metric("m1", nil), metric("m2", nil)},
+	}
+	got := groupByResource(md)
+	require.Len(t, got, 1)
+	assert.Equal(t, md, got[0])
+}
+
+func TestResourceKey(t *testing.T) {
+	// Separators within the labels must not make different resources collide.
+	r1 := &resourcepb.Resource{Labels: map[string]string{"a": "b:c"}}
+	r2 := &resourcepb.Resource{Labels: map[string]string{"a:b": "c"}}
+	assert.NotEqual(t, resourceKey(r1), resourceKey(r2))
+	assert.NotEqual(t, resourceKey(nil), resourceKey(&resourcepb.Resource{Type: "0:"}))
+}
+
+func BenchmarkGroupByResource(b *testing.B) {
+	resources := make([]*resourcepb.Resource, 10)
+	for i := range resources {
+		resources[i] = &resourcepb.Resource{
+			Type:   "k8s",
+			Labels: map[string]string{"pod": fmt.Sprintf("pod-%d", i), "ns": "default", "node": "node-1"},
+		}
+	}
+	md := consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 1000)}
+	for i := range md.Metrics {
+		// Use distinct but identical resource objects, as received from the wire.
+		r := *resources[i%len(resources)]
+		md.Metrics[i] = metric(fmt.Sprintf("m%d", i), &r)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		groupByResource(md)
+	}
+}
+
+func metric(name string, resource *resourcepb.Resource) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Type: metricspb.MetricDescriptor_GAUGE_INT64},
+		Resource:         resource,
+	}
+}
+
+func names(md consumerdata.MetricsData) []string {
+	var names []string
+	for _, m := range md.Metrics {
+		names = append(names, m.MetricDescriptor.Name)
+	}
+	return names
+}
@@ -0,0 +1,163 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heartbeatprocessor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type heartbeat struct {
+	name     string
+	now      func() time.Time
+	stopCh   chan struct{}
+	stopOnce sync.Once
+
+	mu        sync.RWMutex
+	statsTags []tag.Mutator
+}
+
+type traceHeartbeatProcessor struct {
+	*heartbeat
+	nextConsumer consumer.TraceConsumer
+}
+
+type metricsHeartbeatProcessor struct {
+	*heartbeat
+	nextConsumer consumer.MetricsConsumer
+}
+
+var _ processor.TraceProcessor = (*traceHeartbeatProcessor)(nil)
+var _ processor.MetricsProcessor = (*metricsHeartbeatProcessor)(nil)
+var _ processor.PipelineAware = (*heartbeat)(nil)
+var _ processor.Shutdowner = (*heartbeat)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor reporting a heartbeat
+// at every interval, whether data flows or not, and the time it last received
+// data. The data is passed through.
+func NewTraceProcessor(nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	hb, err := newHeartbeat(cfg, time.Now)
+	if err != nil {
+		return nil, err
+	}
+	return &traceHeartbeatProcessor{heartbeat: hb, nextConsumer: nextConsumer}, nil
+}
+
+// NewMetricsProcessor returns a processor.MetricsProcessor reporting a
+// heartbeat at every interval, whether data flows or not, and the time it last
+// received data. The data is passed through.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	hb, err := newHeartbeat(cfg, time.Now)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsHeartbeatProcessor{heartbeat: hb, nextConsumer: nextConsumer}, nil
+}
+
+// newHeartbeat starts reporting the heartbeat at the configured interval, the
+// time is given by now. The heartbeat is reported right away once the pipeline
+// of the processor is set, which tags its stats.
+func newHeartbeat(cfg Config, now func() time.Time) (*heartbeat, error) {
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %v", cfg.Interval)
+	}
+
+	hb := &heartbeat{
+		name:      cfg.Name(),
+		now:       now,
+		stopCh:    make(chan struct{}),
+		statsTags: []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hb.stopCh:
+				return
+			case <-ticker.C:
+				hb.beat()
+			}
+		}
+	}()
+	return hb, nil
+}
+
+func (tp *traceHeartbeatProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	tp.dataSeen(ctx)
+	return tp.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+func (mp *metricsHeartbeatProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	mp.dataSeen(ctx)
+	return mp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// SetPipelineName tags the stats of the processor with the name of its
+// pipeline, and reports the heartbeat.
+func (hb *heartbeat) SetPipelineName(name string) {
+	hb.mu.Lock()
+	hb.statsTags = []tag.Mutator{
+		tag.Upsert(processor.TagExporterNameKey, hb.name),
+		tag.Upsert(observability.TagKeyPipeline, name),
+	}
+	hb.mu.Unlock()
+	hb.beat()
+}
+
+// Shutdown halts the reporting of the heartbeat.
+func (hb *heartbeat) Shutdown() error {
+	hb.stopOnce.Do(func() {
+		close(hb.stopCh)
+	})
+	return nil
+}
+
+func (hb *heartbeat) tags() []tag.Mutator {
+	hb.mu.RLock()
+	defer hb.mu.RUnlock()
+	return hb.statsTags
+}
+
+func (hb *heartbeat) beat() {
+	stats.RecordWithTags(context.Background(), hb.tags(), statHeartbeat.M(unixSeconds(hb.now())))
+}
+
+// dataSeen records the time the data was seen, the context of the data carries
+// the name of its pipeline.
+func (hb *heartbeat) dataSeen(ctx context.Context) {
+	stats.RecordWithTags(ctx, hb.tags(), statLastDataSeen.M(unixSeconds(hb.now())))
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
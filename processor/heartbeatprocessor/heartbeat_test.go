@@ -0,0 +1,162 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heartbeatprocessor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+func TestNewProcessorNilNext(t *testing.T) {
+	tp, err := NewTraceProcessor(nil, Config{Interval: time.Second})
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	mp, err := NewMetricsProcessor(nil, Config{Interval: time.Second})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestHeartbeat(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	// The clock advances by a second at every reading.
+	var mu sync.Mutex
+	clock := time.Unix(1000, 0)
+	now := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "heartbeat"},
+		Interval:          10 * time.Millisecond,
+	}
+	hb, err := newHeartbeat(cfg, now)
+	require.NoError(t, err)
+	defer hb.Shutdown()
+	sink := new(exportertest.SinkMetricsExporter)
+	mp := &metricsHeartbeatProcessor{heartbeat: hb, nextConsumer: sink}
+
+	// The heartbeat is reported as soon as the pipeline of the processor is
+	// set, and advances on the timer without any data.
+	mp.SetPipelineName("metrics")
+	first, ok := lastValue(t, statHeartbeat.Name(), cfg.Name(), "metrics")
+	require.True(t, ok)
+	require.Eventually(t, func() bool {
+		value, _ := lastValue(t, statHeartbeat.Name(), cfg.Name(), "metrics")
+		return value > first
+	}, 5*time.Second, 10*time.Millisecond)
+	_, ok = lastValue(t, statLastDataSeen.Name(), cfg.Name(), "metrics")
+	assert.False(t, ok, "no data was seen yet")
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{{}}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	seen, ok := lastValue(t, statLastDataSeen.Name(), cfg.Name(), "metrics")
+	require.True(t, ok)
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	seenAgain, _ := lastValue(t, statLastDataSeen.Name(), cfg.Name(), "metrics")
+	assert.True(t, seenAgain > seen, "expecting the data seen time to advance on traffic")
+
+	// The data is passed through.
+	assert.Equal(t, []consumerdata.MetricsData{md, md}, sink.AllMetrics())
+}
+
+func TestHeartbeatShutdown(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "heartbeat"},
+		Interval:          time.Millisecond,
+	}
+	var beats int32
+	hb, err := newHeartbeat(cfg, func() time.Time {
+		return time.Unix(int64(atomic.AddInt32(&beats, 1)), 0)
+	})
+	require.NoError(t, err)
+	hb.SetPipelineName("traces")
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&beats) > 2 }, 5*time.Second, time.Millisecond)
+
+	// The heartbeat stops on shutdown, once the pipelines are shut down.
+	require.NoError(t, hb.Shutdown())
+	require.NoError(t, hb.Shutdown())
+	<-time.After(10 * time.Millisecond)
+	stopped := atomic.LoadInt32(&beats)
+	<-time.After(20 * time.Millisecond)
+	assert.Equal(t, stopped, atomic.LoadInt32(&beats))
+}
+
+func TestTraceProcessorDataSeen(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "heartbeat/traces"},
+		Interval:          time.Hour,
+	}
+	sink := new(exportertest.SinkTraceExporter)
+	tp, err := NewTraceProcessor(sink, cfg)
+	require.NoError(t, err)
+	defer tp.(processor.Shutdowner).Shutdown()
+
+	// The context of the data carries the name of its pipeline.
+	before := time.Now()
+	ctx := observability.ContextWithPipelineName(context.Background(), "traces")
+	require.NoError(t, tp.ConsumeTraceData(ctx, consumerdata.TraceData{}))
+	seen, ok := lastValue(t, statLastDataSeen.Name(), cfg.Name(), "traces")
+	require.True(t, ok)
+	assert.True(t, seen >= unixSeconds(before))
+	assert.Len(t, sink.AllTraces(), 1)
+}
+
+// lastValue returns the last value recorded by the processor of the pipeline for the view.
+func lastValue(t *testing.T, viewName, processorName, pipelineName string) (float64, bool) {
+	rows, err := view.RetrieveData(viewName)
+	require.NoError(t, err)
+	for _, row := range rows {
+		var processorMatches, pipelineMatches bool
+		for _, tg := range row.Tags {
+			processorMatches = processorMatches || (tg.Key == processor.TagExporterNameKey && tg.Value == processorName)
+			pipelineMatches = pipelineMatches || (tg.Key == observability.TagKeyPipeline && tg.Value == pipelineName)
+		}
+		if processorMatches && pipelineMatches {
+			return row.Data.(*view.LastValueData).Value, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heartbeatprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	statHeartbeat    = stats.Float64("heartbeat_timestamp", "Unix time of the last heartbeat of the pipeline, reported at every interval", "s")
+	statLastDataSeen = stats.Float64("heartbeat_last_data_timestamp", "Unix time the pipeline last received data", "s")
+)
+
+// MetricViews returns the metrics views related to the liveness of the
+// pipelines.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	tagKeys := []tag.Key{processor.TagExporterNameKey, observability.TagKeyPipeline}
+	heartbeatView := &view.View{
+		Name:        statHeartbeat.Name(),
+		Measure:     statHeartbeat,
+		Description: statHeartbeat.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.LastValue(),
+	}
+	lastDataSeenView := &view.View{
+		Name:        statLastDataSeen.Name(),
+		Measure:     statLastDataSeen,
+		Description: statLastDataSeen.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.LastValue(),
+	}
+
+	return []*view.View{heartbeatView, lastDataSeenView}
+}
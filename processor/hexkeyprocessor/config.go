@@ -0,0 +1,38 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hexkeyprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Action is what the processor does with the keys.
+type Action string
+
+const (
+	// Encode hex-encodes the keys with disallowed characters.
+	Encode Action = "encode"
+	// Decode restores the keys encoded by Encode.
+	Decode Action = "decode"
+)
+
+// Config defines configuration for the hex key processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Action is what is done with the keys, either "encode" or "decode".
+	// Defaults to encode.
+	Action Action `mapstructure:"action"`
+	// Prefix starts the encoded keys, it must be made of allowed characters.
+	// Defaults to "hex_".
+	Prefix string `mapstructure:"prefix"`
+}
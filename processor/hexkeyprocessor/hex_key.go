@@ -0,0 +1,187 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hexkeyprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type hexKey struct {
+	convert func(string) string
+}
+
+type traceHexKeyProcessor struct {
+	hexKey
+	nextConsumer consumer.TraceConsumer
+}
+
+type metricsHexKeyProcessor struct {
+	hexKey
+	nextConsumer consumer.MetricsConsumer
+}
+
+var _ processor.TraceProcessor = (*traceHexKeyProcessor)(nil)
+var _ processor.MetricsProcessor = (*metricsHexKeyProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that encodes, or
+// decodes, the keys of the span attributes according to the config.
+func NewTraceProcessor(nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	hk, err := newHexKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &traceHexKeyProcessor{hexKey: hk, nextConsumer: nextConsumer}, nil
+}
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that encodes, or
+// decodes, the label keys of the metrics according to the config.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	hk, err := newHexKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsHexKeyProcessor{hexKey: hk, nextConsumer: nextConsumer}, nil
+}
+
+func newHexKey(cfg Config) (hexKey, error) {
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	if !isSafe(prefix) {
+		return hexKey{}, errors.New("prefix must be made of ASCII letters, digits and underscores")
+	}
+	switch cfg.Action {
+	case "", Encode:
+		return hexKey{convert: func(key string) string { return EncodeKey(prefix, key) }}, nil
+	case Decode:
+		return hexKey{convert: func(key string) string { return DecodeKey(prefix, key) }}, nil
+	}
+	return hexKey{}, fmt.Errorf("unknown action %q, must be either %q or %q", cfg.Action, Encode, Decode)
+}
+
+func (mp *metricsHexKeyProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		converted := mp.convertMetric(metric)
+		if converted == metric {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = converted
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return mp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+func (tp *traceHexKeyProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	var spans []*tracepb.Span
+	for i, span := range td.Spans {
+		converted := tp.convertSpan(span)
+		if converted == span {
+			continue
+		}
+		if spans == nil {
+			spans = make([]*tracepb.Span, len(td.Spans))
+			copy(spans, td.Spans)
+		}
+		spans[i] = converted
+	}
+	if spans != nil {
+		td.Spans = spans
+	}
+	return tp.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+// convertMetric returns the metric with its label keys converted. The metric
+// is returned as is if unchanged, otherwise a copy is returned. The conversion
+// is one-to-one, the keys never collide and the label values are left in
+// place.
+func (hk *hexKey) convertMetric(metric *metricspb.Metric) *metricspb.Metric {
+	desc := metric.GetMetricDescriptor()
+	if desc == nil {
+		return metric
+	}
+
+	var keys []*metricspb.LabelKey
+	for i, labelKey := range desc.LabelKeys {
+		key := hk.convert(labelKey.GetKey())
+		if key == labelKey.GetKey() {
+			continue
+		}
+		if keys == nil {
+			keys = make([]*metricspb.LabelKey, len(desc.LabelKeys))
+			copy(keys, desc.LabelKeys)
+		}
+		keys[i] = &metricspb.LabelKey{Key: key, Description: labelKey.GetDescription()}
+	}
+	if keys == nil {
+		return metric
+	}
+
+	convertedDesc := *desc
+	convertedDesc.LabelKeys = keys
+	converted := *metric
+	converted.MetricDescriptor = &convertedDesc
+	return &converted
+}
+
+// convertSpan returns the span with its attribute keys converted. The span is
+// returned as is if unchanged, otherwise a copy is returned.
+func (hk *hexKey) convertSpan(span *tracepb.Span) *tracepb.Span {
+	attrs := span.GetAttributes().GetAttributeMap()
+	changed := false
+	for key := range attrs {
+		if hk.convert(key) != key {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return span
+	}
+
+	converted := make(map[string]*tracepb.AttributeValue, len(attrs))
+	for key, value := range attrs {
+		converted[hk.convert(key)] = value
+	}
+	convertedSpan := *span
+	convertedSpan.Attributes = &tracepb.Span_Attributes{
+		AttributeMap:           converted,
+		DroppedAttributesCount: span.Attributes.DroppedAttributesCount,
+	}
+	return &convertedSpan
+}
@@ -0,0 +1,169 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hexkeyprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewProcessorNilNext(t *testing.T) {
+	tp, err := NewTraceProcessor(nil, Config{})
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	mp, err := NewMetricsProcessor(nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestEncodeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "http_method", want: "http_method"},
+		{key: "http.method", want: "hex_687474702e6d6574686f64"},
+		{key: "k8s/pod-name", want: "hex_6b38732f706f642d6e616d65"},
+		{key: "région", want: "hex_72c3a967696f6e"},
+		{key: "a b", want: "hex_612062"},
+		// The keys looking encoded are encoded too, to be decoded back as is.
+		{key: "hex_41", want: "hex_6865785f3431"},
+		{key: "", want: "hex_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, EncodeKey("hex_", tt.key))
+			assert.Equal(t, tt.key, DecodeKey("hex_", EncodeKey("hex_", tt.key)))
+		})
+	}
+	// The keys not encoded are decoded as is.
+	assert.Equal(t, "hex_zz", DecodeKey("hex_", "hex_zz"))
+	assert.Equal(t, "http_method", DecodeKey("hex_", "http_method"))
+}
+
+func TestEncodeMetricKeys(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	m := metric([]string{"http.method", "status_code"}, []string{"GET", "200"})
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{m}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	assert.Equal(t, metric([]string{"hex_687474702e6d6574686f64", "status_code"}, []string{"GET", "200"}), got[0].Metrics[0])
+	// The received metric is shared with other pipelines, it is not modified.
+	assert.Equal(t, metric([]string{"http.method", "status_code"}, []string{"GET", "200"}), m)
+}
+
+func TestRoundTripMetricKeys(t *testing.T) {
+	decoded := new(exportertest.SinkMetricsExporter)
+	decoder, err := NewMetricsProcessor(decoded, Config{Action: Decode, Prefix: "enc_"})
+	require.NoError(t, err)
+	encoder, err := NewMetricsProcessor(decoder, Config{Action: Encode, Prefix: "enc_"})
+	require.NoError(t, err)
+
+	keys := []string{"http.method", "k8s/pod-name", "région", "enc_id", "status_code"}
+	values := []string{"GET", "api-0", "eu", "42", "200"}
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric(keys, values)}}
+	require.NoError(t, encoder.ConsumeMetricsData(context.Background(), md))
+
+	assert.Equal(t, []consumerdata.MetricsData{md}, decoded.AllMetrics())
+}
+
+func TestUnchangedMetricPassedAsIs(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric([]string{"region"}, []string{"EU"})}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.True(t, md.Metrics[0] == sink.AllMetrics()[0].Metrics[0])
+}
+
+func TestRoundTripSpanKeys(t *testing.T) {
+	decoded := new(exportertest.SinkTraceExporter)
+	decoder, err := NewTraceProcessor(decoded, Config{Action: Decode})
+	require.NoError(t, err)
+	encoded := new(exportertest.SinkTraceExporter)
+	encoder, err := NewTraceProcessor(encoded, Config{})
+	require.NoError(t, err)
+
+	span := &tracepb.Span{
+		Name: &tracepb.TruncatableString{Value: "checkout"},
+		Attributes: &tracepb.Span_Attributes{
+			AttributeMap: map[string]*tracepb.AttributeValue{
+				"http.url":    stringAttribute("/cart"),
+				"db.instance": stringAttribute("orders"),
+				"component":   stringAttribute("http"),
+			},
+			DroppedAttributesCount: 1,
+		},
+	}
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{span}}
+	require.NoError(t, encoder.ConsumeTraceData(context.Background(), td))
+
+	got := encoded.AllTraces()
+	require.Len(t, got, 1)
+	attrs := got[0].Spans[0].Attributes
+	assert.Equal(t, map[string]*tracepb.AttributeValue{
+		"hex_687474702e75726c":       stringAttribute("/cart"),
+		"hex_64622e696e7374616e6365": stringAttribute("orders"),
+		"component":                  stringAttribute("http"),
+	}, attrs.AttributeMap)
+	assert.Equal(t, int32(1), attrs.DroppedAttributesCount)
+	// The received span is shared with other pipelines, it is not modified.
+	assert.Contains(t, span.Attributes.AttributeMap, "http.url")
+
+	require.NoError(t, decoder.ConsumeTraceData(context.Background(), got[0]))
+	assert.Equal(t, []consumerdata.TraceData{td}, decoded.AllTraces())
+}
+
+func metric(keys, values []string) *metricspb.Metric {
+	labelKeys := make([]*metricspb.LabelKey, 0, len(keys))
+	for _, key := range keys {
+		labelKeys = append(labelKeys, &metricspb.LabelKey{Key: key})
+	}
+	labelValues := make([]*metricspb.LabelValue, 0, len(values))
+	for _, value := range values {
+		labelValues = append(labelValues, &metricspb.LabelValue{Value: value, HasValue: true})
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "requests",
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys: labelKeys,
+		},
+		Timeseries: []*metricspb.TimeSeries{{LabelValues: labelValues}},
+	}
+}
+
+func stringAttribute(value string) *tracepb.AttributeValue {
+	return &tracepb.AttributeValue{
+		Value: &tracepb.AttributeValue_StringValue{StringValue: &tracepb.TruncatableString{Value: value}},
+	}
+}
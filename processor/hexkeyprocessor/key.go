@@ -0,0 +1,63 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hexkeyprocessor
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// isAllowed returns whether the character is allowed in the keys by strict
+// backends: ASCII letters, digits and underscores.
+func isAllowed(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func isSafe(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isAllowed(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeKey returns the key as is if it is made of allowed characters, ASCII
+// letters, digits and underscores, otherwise the prefix followed by the
+// lowercase hex encoding of its bytes, e.g. "hex_687474702e6d6574686f64" for
+// "http.method". The keys starting with the prefix are encoded too, so that
+// every encoded key is decoded back to the original one by DecodeKey.
+func EncodeKey(prefix, key string) string {
+	if isSafe(key) && !strings.HasPrefix(key, prefix) {
+		return key
+	}
+	return prefix + hex.EncodeToString([]byte(key))
+}
+
+// DecodeKey returns the original key of a key encoded by EncodeKey with the
+// same prefix. The keys not encoded are returned as is.
+func DecodeKey(prefix, key string) string {
+	if !strings.HasPrefix(key, prefix) {
+		return key
+	}
+	decoded, err := hex.DecodeString(key[len(prefix):])
+	if err != nil {
+		return key
+	}
+	return string(decoded)
+}
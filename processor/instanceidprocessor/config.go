@@ -0,0 +1,29 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instanceidprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the instance id processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// DerivationOrder lists the sources the instance id is synthesized from,
+	// the first one available being used:
+	//  - "host_port": the host name and port of the node, e.g. of a scraped
+	//    target, as host:port.
+	//  - "pod_name": the k8s.pod.name resource label or node attribute.
+	//  - "host_name": the host name of the node.
+	DerivationOrder []string `mapstructure:"derivation_order"`
+}
@@ -0,0 +1,139 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instanceidprocessor
+
+import (
+	"context"
+	"fmt"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// Source is a source the instance id can be synthesized from.
+type Source string
+
+const (
+	// SourceHostPort synthesizes the instance id from the host name and port of the node.
+	SourceHostPort Source = "host_port"
+	// SourcePodName uses the k8s pod name as the instance id.
+	SourcePodName Source = "pod_name"
+	// SourceHostName uses the host name of the node as the instance id.
+	SourceHostName Source = "host_name"
+)
+
+const (
+	// InstanceIDKey is the resource attribute holding the instance id.
+	InstanceIDKey = "service.instance.id"
+
+	podNameKey = "k8s.pod.name"
+	// portKey is the node attribute holding the port of the scraped targets.
+	portKey = "port"
+)
+
+type instanceIDProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	sources      []Source
+}
+
+var _ processor.MetricsProcessor = (*instanceIDProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that sets the
+// service.instance.id resource attribute of the metrics lacking it.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if len(cfg.DerivationOrder) == 0 {
+		return nil, fmt.Errorf("derivation_order must list at least one source")
+	}
+
+	sources := make([]Source, 0, len(cfg.DerivationOrder))
+	for _, s := range cfg.DerivationOrder {
+		switch source := Source(s); source {
+		case SourceHostPort, SourcePodName, SourceHostName:
+			sources = append(sources, source)
+		default:
+			return nil, fmt.Errorf("unknown instance id source %q, must be one of %q, %q or %q",
+				s, SourceHostPort, SourcePodName, SourceHostName)
+		}
+	}
+
+	return &instanceIDProcessor{
+		nextConsumer: nextConsumer,
+		sources:      sources,
+	}, nil
+}
+
+// ConsumeMetricsData adds the instance id to the resource of the batch, unless
+// the resource or the node already has one or none of the sources is available.
+func (iip *instanceIDProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	if hasInstanceID(md) {
+		return iip.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+	id, ok := iip.instanceID(md.Node, md.Resource)
+	if !ok {
+		return iip.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	labels := make(map[string]string, len(md.Resource.GetLabels())+1)
+	for k, v := range md.Resource.GetLabels() {
+		labels[k] = v
+	}
+	labels[InstanceIDKey] = id
+	md.Resource = &resourcepb.Resource{
+		Type:   md.Resource.GetType(),
+		Labels: labels,
+	}
+	return iip.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+func hasInstanceID(md consumerdata.MetricsData) bool {
+	if md.Resource.GetLabels()[InstanceIDKey] != "" {
+		return true
+	}
+	return md.Node.GetAttributes()[InstanceIDKey] != ""
+}
+
+// instanceID returns the instance id synthesized from the first available
+// source.
+func (iip *instanceIDProcessor) instanceID(node *commonpb.Node, resource *resourcepb.Resource) (string, bool) {
+	host := node.GetIdentifier().GetHostName()
+	for _, source := range iip.sources {
+		switch source {
+		case SourceHostPort:
+			if port := node.GetAttributes()[portKey]; host != "" && port != "" {
+				return host + ":" + port, true
+			}
+		case SourcePodName:
+			if pod := resource.GetLabels()[podNameKey]; pod != "" {
+				return pod, true
+			}
+			if pod := node.GetAttributes()[podNameKey]; pod != "" {
+				return pod, true
+			}
+		case SourceHostName:
+			if host != "" {
+				return host, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,131 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instanceidprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+)
+
+func TestInstanceIDFromHostPort(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, *(&Factory{}).CreateDefaultConfig().(*Config))
+	require.NoError(t, err)
+
+	resource := &resourcepb.Resource{Type: "k8s", Labels: map[string]string{podNameKey: "checkout-1"}}
+	md := consumerdata.MetricsData{
+		Node:     scrapedNode("10.0.0.1", "8080"),
+		Resource: resource,
+		Metrics:  []*metricspb.Metric{{MetricDescriptor: &metricspb.MetricDescriptor{Name: "up"}}},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, &resourcepb.Resource{
+		Type:   "k8s",
+		Labels: map[string]string{podNameKey: "checkout-1", InstanceIDKey: "10.0.0.1:8080"},
+	}, got[0].Resource)
+	// The resource of the incoming data is left untouched.
+	assert.Equal(t, map[string]string{podNameKey: "checkout-1"}, resource.Labels)
+}
+
+func TestInstanceIDPreservesExistingValue(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, *(&Factory{}).CreateDefaultConfig().(*Config))
+	require.NoError(t, err)
+
+	fromResource := consumerdata.MetricsData{
+		Node:     scrapedNode("10.0.0.1", "8080"),
+		Resource: &resourcepb.Resource{Labels: map[string]string{InstanceIDKey: "instance-a"}},
+	}
+	fromNode := consumerdata.MetricsData{Node: scrapedNode("10.0.0.2", "8080")}
+	fromNode.Node.Attributes[InstanceIDKey] = "instance-b"
+	for _, md := range []consumerdata.MetricsData{fromResource, fromNode} {
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	assert.Equal(t, map[string]string{InstanceIDKey: "instance-a"}, got[0].Resource.Labels)
+	assert.Nil(t, got[1].Resource)
+}
+
+func TestInstanceIDDerivationOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    []string
+		node     *commonpb.Node
+		resource *resourcepb.Resource
+		want     string
+	}{
+		{
+			name:     "pod name first",
+			order:    []string{"pod_name", "host_port"},
+			node:     scrapedNode("10.0.0.1", "8080"),
+			resource: &resourcepb.Resource{Labels: map[string]string{podNameKey: "checkout-1"}},
+			want:     "checkout-1",
+		},
+		{
+			name:  "pod name on node",
+			order: []string{"pod_name"},
+			node:  &commonpb.Node{Attributes: map[string]string{podNameKey: "checkout-2"}},
+			want:  "checkout-2",
+		},
+		{
+			name:  "fallback to host name",
+			order: []string{"host_port", "pod_name", "host_name"},
+			node:  &commonpb.Node{Identifier: &commonpb.ProcessIdentifier{HostName: "host-1"}},
+			want:  "host-1",
+		},
+		{
+			name:  "no source available",
+			order: []string{"host_port", "pod_name"},
+			node:  &commonpb.Node{Identifier: &commonpb.ProcessIdentifier{HostName: "host-1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := new(exportertest.SinkMetricsExporter)
+			mp, err := NewMetricsProcessor(sink, Config{DerivationOrder: tt.order})
+			require.NoError(t, err)
+
+			md := consumerdata.MetricsData{Node: tt.node, Resource: tt.resource}
+			require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+			got := sink.AllMetrics()
+			require.Len(t, got, 1)
+			assert.Equal(t, tt.want, got[0].Resource.GetLabels()[InstanceIDKey])
+		})
+	}
+}
+
+// scrapedNode returns a node as built by the prometheus receiver for a target.
+func scrapedNode(host, port string) *commonpb.Node {
+	return &commonpb.Node{
+		ServiceInfo: &commonpb.ServiceInfo{Name: "job"},
+		Identifier:  &commonpb.ProcessIdentifier{HostName: host},
+		Attributes:  map[string]string{portKey: port, "scheme": "http"},
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instancelabelprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Policy defines what is done with the instance label.
+type Policy string
+
+const (
+	// Rewrite sets the label to the value of an attribute of the metrics.
+	Rewrite Policy = "rewrite"
+	// Drop removes the label.
+	Drop Policy = "drop"
+)
+
+// Config defines configuration for the instance label processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Label is the key of the instance label. Defaults to "instance".
+	Label string `mapstructure:"label"`
+	// Policy is what is done with the label, either "rewrite" or "drop".
+	// Defaults to drop.
+	Policy Policy `mapstructure:"policy"`
+	// FromAttribute is the resource label or node attribute, e.g. the
+	// deployment name, whose value the label is rewritten to. The label has
+	// no value on the metrics lacking the attribute.
+	FromAttribute string `mapstructure:"from_attribute"`
+}
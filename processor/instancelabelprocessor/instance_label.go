@@ -0,0 +1,160 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instancelabelprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type instanceLabelProcessor struct {
+	nextConsumer  consumer.MetricsConsumer
+	label         string
+	policy        Policy
+	fromAttribute string
+}
+
+var _ processor.MetricsProcessor = (*instanceLabelProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that rewrites the
+// instance label of the metrics to the value of the configured attribute, or
+// drops it, so that the churning instances of autoscaled services do not
+// multiply the series. Every series of a metric gets the same value, for the
+// series of a distribution to stay consistent.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if cfg.Label == "" {
+		return nil, errors.New("label must not be empty")
+	}
+
+	policy := cfg.Policy
+	if policy == "" {
+		policy = Drop
+	}
+	switch policy {
+	case Drop:
+	case Rewrite:
+		if cfg.FromAttribute == "" {
+			return nil, errors.New("from_attribute must be set to rewrite the label")
+		}
+	default:
+		return nil, fmt.Errorf("unknown policy %q, must be %q or %q", cfg.Policy, Rewrite, Drop)
+	}
+
+	return &instanceLabelProcessor{
+		nextConsumer:  nextConsumer,
+		label:         cfg.Label,
+		policy:        policy,
+		fromAttribute: cfg.FromAttribute,
+	}, nil
+}
+
+func (ilp *instanceLabelProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var value *metricspb.LabelValue
+	if ilp.policy == Rewrite {
+		value = ilp.stableValue(md.Node, md.Resource)
+	}
+
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		index := labelIndex(metric.GetMetricDescriptor(), ilp.label)
+		if index < 0 {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = rewriteMetric(metric, index, value)
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return ilp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// stableValue returns the value the label is rewritten to, from the resource
+// label or the node attribute, no value if the batch has neither.
+func (ilp *instanceLabelProcessor) stableValue(node *commonpb.Node, resource *resourcepb.Resource) *metricspb.LabelValue {
+	if v, ok := resource.GetLabels()[ilp.fromAttribute]; ok {
+		return &metricspb.LabelValue{Value: v, HasValue: true}
+	}
+	if v, ok := node.GetAttributes()[ilp.fromAttribute]; ok {
+		return &metricspb.LabelValue{Value: v, HasValue: true}
+	}
+	return &metricspb.LabelValue{}
+}
+
+func labelIndex(desc *metricspb.MetricDescriptor, label string) int {
+	for i, key := range desc.GetLabelKeys() {
+		if key.GetKey() == label {
+			return i
+		}
+	}
+	return -1
+}
+
+// rewriteMetric returns a copy of the metric whose label at the given index is
+// set to the given value in every series, or removed if the value is nil.
+func rewriteMetric(metric *metricspb.Metric, index int, value *metricspb.LabelValue) *metricspb.Metric {
+	rewritten := *metric
+	if value == nil {
+		desc := *metric.MetricDescriptor
+		desc.LabelKeys = removeKeyAt(desc.LabelKeys, index)
+		rewritten.MetricDescriptor = &desc
+	}
+
+	rewritten.Timeseries = make([]*metricspb.TimeSeries, len(metric.Timeseries))
+	for i, ts := range metric.Timeseries {
+		if ts == nil || index >= len(ts.LabelValues) {
+			rewritten.Timeseries[i] = ts
+			continue
+		}
+		rewrittenTs := *ts
+		if value == nil {
+			rewrittenTs.LabelValues = removeValueAt(ts.LabelValues, index)
+		} else {
+			rewrittenTs.LabelValues = make([]*metricspb.LabelValue, len(ts.LabelValues))
+			copy(rewrittenTs.LabelValues, ts.LabelValues)
+			rewrittenTs.LabelValues[index] = value
+		}
+		rewritten.Timeseries[i] = &rewrittenTs
+	}
+	return &rewritten
+}
+
+func removeKeyAt(keys []*metricspb.LabelKey, index int) []*metricspb.LabelKey {
+	removed := make([]*metricspb.LabelKey, 0, len(keys)-1)
+	removed = append(removed, keys[:index]...)
+	return append(removed, keys[index+1:]...)
+}
+
+func removeValueAt(values []*metricspb.LabelValue, index int) []*metricspb.LabelValue {
+	removed := make([]*metricspb.LabelValue, 0, len(values)-1)
+	removed = append(removed, values[:index]...)
+	return append(removed, values[index+1:]...)
+}
@@ -0,0 +1,155 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instancelabelprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(nil, Config{Label: defaultLabel})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestRewriteInstance(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Label: defaultLabel, Policy: Rewrite, FromAttribute: "deployment"})
+	require.NoError(t, err)
+
+	m := gauge([]string{"instance", "method"}, []string{"10.0.0.7:8080", "GET"}, []string{"10.0.0.8:8080", "POST"})
+	md := consumerdata.MetricsData{
+		Resource: &resourcepb.Resource{Labels: map[string]string{"deployment": "checkout"}},
+		Metrics:  []*metricspb.Metric{m},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	assert.Equal(t, gauge([]string{"instance", "method"}, []string{"checkout", "GET"}, []string{"checkout", "POST"}), got[0].Metrics[0])
+	// The received metric is shared with other pipelines, it is not modified.
+	assert.Equal(t, gauge([]string{"instance", "method"}, []string{"10.0.0.7:8080", "GET"}, []string{"10.0.0.8:8080", "POST"}), m)
+}
+
+func TestRewriteInstanceFromNodeAttribute(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Label: defaultLabel, Policy: Rewrite, FromAttribute: "deployment"})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Node:    &commonpb.Node{Attributes: map[string]string{"deployment": "checkout"}},
+		Metrics: []*metricspb.Metric{gauge([]string{"instance"}, []string{"10.0.0.7:8080"})},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Equal(t, gauge([]string{"instance"}, []string{"checkout"}), sink.AllMetrics()[0].Metrics[0])
+
+	// Without the attribute, the label has no value.
+	md = consumerdata.MetricsData{Metrics: []*metricspb.Metric{gauge([]string{"instance"}, []string{"10.0.0.7:8080"})}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	want := gauge([]string{"instance"}, []string{""})
+	want.Timeseries[0].LabelValues[0].HasValue = false
+	assert.Equal(t, want, sink.AllMetrics()[1].Metrics[0])
+}
+
+func TestDropInstance(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Label: defaultLabel, Policy: Drop})
+	require.NoError(t, err)
+
+	m := gauge([]string{"method", "instance"}, []string{"GET", "10.0.0.7:8080"}, []string{"POST", "10.0.0.8:8080"})
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{m}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, gauge([]string{"method"}, []string{"GET"}, []string{"POST"}), got[0].Metrics[0])
+	assert.Equal(t, gauge([]string{"method", "instance"}, []string{"GET", "10.0.0.7:8080"}, []string{"POST", "10.0.0.8:8080"}), m)
+}
+
+func TestDropInstanceFromDistribution(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Label: defaultLabel, Policy: Drop})
+	require.NoError(t, err)
+
+	m := gauge([]string{"instance"}, []string{"10.0.0.7:8080"})
+	m.MetricDescriptor.Type = metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION
+	m.Timeseries[0].Points = []*metricspb.Point{{
+		Value: &metricspb.Point_DistributionValue{DistributionValue: &metricspb.DistributionValue{
+			Count:   3,
+			Sum:     1.5,
+			Buckets: []*metricspb.DistributionValue_Bucket{{Count: 1}, {Count: 2}},
+			BucketOptions: &metricspb.DistributionValue_BucketOptions{
+				Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+					Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: []float64{0.5}},
+				},
+			},
+		}},
+	}}
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{m}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()[0].Metrics[0]
+	assert.Empty(t, got.MetricDescriptor.LabelKeys)
+	require.Len(t, got.Timeseries, 1)
+	assert.Empty(t, got.Timeseries[0].LabelValues)
+	// Every bucket of the distribution remains in the same series.
+	assert.Equal(t, m.Timeseries[0].Points, got.Timeseries[0].Points)
+}
+
+func TestMetricWithoutLabelPassedAsIs(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Label: defaultLabel, Policy: Drop})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{gauge([]string{"method"}, []string{"GET"})}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Equal(t, []consumerdata.MetricsData{md}, sink.AllMetrics())
+	assert.True(t, md.Metrics[0] == sink.AllMetrics()[0].Metrics[0])
+}
+
+func gauge(keys []string, series ...[]string) *metricspb.Metric {
+	labelKeys := make([]*metricspb.LabelKey, 0, len(keys))
+	for _, key := range keys {
+		labelKeys = append(labelKeys, &metricspb.LabelKey{Key: key})
+	}
+	timeseries := make([]*metricspb.TimeSeries, 0, len(series))
+	for _, values := range series {
+		labelValues := make([]*metricspb.LabelValue, 0, len(values))
+		for _, value := range values {
+			labelValues = append(labelValues, &metricspb.LabelValue{Value: value, HasValue: true})
+		}
+		timeseries = append(timeseries, &metricspb.TimeSeries{LabelValues: labelValues})
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "requests",
+			Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+			LabelKeys: labelKeys,
+		},
+		Timeseries: timeseries,
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intcoercionprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// CoercionMode defines how the double values are converted to integers.
+type CoercionMode string
+
+const (
+	// Round converts the values to the nearest integer, halfway values being
+	// rounded away from zero.
+	Round CoercionMode = "round"
+	// Truncate converts the values to their integer part.
+	Truncate CoercionMode = "truncate"
+)
+
+// Config defines configuration for the int coercion processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Mode is how the values are converted to integers, either "round" or
+	// "truncate". Defaults to "round".
+	Mode CoercionMode `mapstructure:"mode"`
+	// Metrics are the names of the double gauges and counters always
+	// converted, whatever their values.
+	Metrics []string `mapstructure:"metrics"`
+	// DetectCounters converts the double counters whose values are all
+	// integers, up to the tolerance, as these are most likely counts.
+	DetectCounters bool `mapstructure:"detect_counters"`
+	// Tolerance is the difference between a value and its conversion below
+	// which the value is deemed an integer. The conversions changing a value
+	// by more are counted.
+	Tolerance float64 `mapstructure:"tolerance"`
+}
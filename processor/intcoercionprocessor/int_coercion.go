@@ -0,0 +1,190 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intcoercionprocessor
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// intTypes maps the double metric types to their int64 counterpart.
+var intTypes = map[metricspb.MetricDescriptor_Type]metricspb.MetricDescriptor_Type{
+	metricspb.MetricDescriptor_GAUGE_DOUBLE:      metricspb.MetricDescriptor_GAUGE_INT64,
+	metricspb.MetricDescriptor_CUMULATIVE_DOUBLE: metricspb.MetricDescriptor_CUMULATIVE_INT64,
+}
+
+type intCoercionProcessor struct {
+	name           string
+	nextConsumer   consumer.MetricsConsumer
+	logger         *zap.Logger
+	coerce         func(float64) float64
+	metrics        map[string]bool
+	detectCounters bool
+	tolerance      float64
+	statsTags      []tag.Mutator
+}
+
+var _ processor.MetricsProcessor = (*intCoercionProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that converts the
+// configured double gauges and counters, and the double counters whose values
+// are all integers if detection is enabled, to int64 metrics. The metrics
+// having a value that can't be represented as an int64, e.g. NaN, are left as
+// is.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	var coerce func(float64) float64
+	switch cfg.Mode {
+	case Round, "":
+		coerce = math.Round
+	case Truncate:
+		coerce = math.Trunc
+	default:
+		return nil, fmt.Errorf("unknown mode %q, must be %q or %q", cfg.Mode, Round, Truncate)
+	}
+	if cfg.Tolerance < 0 {
+		return nil, fmt.Errorf("tolerance must be positive, got %v", cfg.Tolerance)
+	}
+
+	metrics := make(map[string]bool, len(cfg.Metrics))
+	for _, name := range cfg.Metrics {
+		metrics[name] = true
+	}
+
+	return &intCoercionProcessor{
+		name:           cfg.Name(),
+		nextConsumer:   nextConsumer,
+		logger:         logger,
+		coerce:         coerce,
+		metrics:        metrics,
+		detectCounters: cfg.DetectCounters,
+		tolerance:      cfg.Tolerance,
+		statsTags:      []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (icp *intCoercionProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	changed := 0
+	for i, metric := range md.Metrics {
+		coerced, n, ok := icp.coerceMetric(metric)
+		if !ok {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = coerced
+		changed += n
+	}
+	if metrics == nil {
+		return icp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	if changed > 0 {
+		icp.logger.Debug("Changed values converting them to integers",
+			zap.String("processor", icp.name),
+			zap.Int("points", changed))
+		stats.RecordWithTags(context.Background(), icp.statsTags, statChangedPoints.M(int64(changed)))
+	}
+
+	md.Metrics = metrics
+	return icp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// coerceMetric returns a copy of the metric converted to int64, the number of
+// points whose value was changed by more than the tolerance and whether the
+// metric was converted.
+func (icp *intCoercionProcessor) coerceMetric(metric *metricspb.Metric) (*metricspb.Metric, int, bool) {
+	desc := metric.GetMetricDescriptor()
+	intType, ok := intTypes[desc.GetType()]
+	if !ok {
+		return nil, 0, false
+	}
+	flagged := icp.metrics[desc.Name]
+	if !flagged && !(icp.detectCounters && desc.Type == metricspb.MetricDescriptor_CUMULATIVE_DOUBLE) {
+		return nil, 0, false
+	}
+
+	// Check every point first, the metric is converted whole or not at all.
+	for _, ts := range metric.Timeseries {
+		for _, point := range ts.GetPoints() {
+			v, ok := point.GetValue().(*metricspb.Point_DoubleValue)
+			if !ok {
+				return nil, 0, false
+			}
+			coerced := icp.coerce(v.DoubleValue)
+			if !inInt64Range(coerced) {
+				return nil, 0, false
+			}
+			if !flagged && math.Abs(coerced-v.DoubleValue) > icp.tolerance {
+				// A counter with a fractional value is not a count.
+				return nil, 0, false
+			}
+		}
+	}
+
+	coercedDesc := *desc
+	coercedDesc.Type = intType
+	coercedMetric := *metric
+	coercedMetric.MetricDescriptor = &coercedDesc
+	coercedMetric.Timeseries = make([]*metricspb.TimeSeries, len(metric.Timeseries))
+	changed := 0
+	for i, ts := range metric.Timeseries {
+		if ts == nil {
+			continue
+		}
+		coercedTs := *ts
+		coercedTs.Points = make([]*metricspb.Point, len(ts.Points))
+		for j, point := range ts.Points {
+			if point == nil {
+				continue
+			}
+			value := point.GetDoubleValue()
+			coerced := icp.coerce(value)
+			if math.Abs(coerced-value) > icp.tolerance {
+				changed++
+			}
+			coercedTs.Points[j] = &metricspb.Point{
+				Timestamp: point.Timestamp,
+				Value:     &metricspb.Point_Int64Value{Int64Value: int64(coerced)},
+			}
+		}
+		coercedMetric.Timeseries[i] = &coercedTs
+	}
+	return &coercedMetric, changed, true
+}
+
+// inInt64Range returns whether the integer value can be represented as an
+// int64, the NaN and infinite values cannot.
+func inInt64Range(v float64) bool {
+	// -2^63 is an int64, 2^63 is not.
+	return v >= math.MinInt64 && v < math.MaxInt64
+}
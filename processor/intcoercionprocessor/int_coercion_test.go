@@ -0,0 +1,178 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intcoercionprocessor
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func newConfig() Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		Mode:           Round,
+		DetectCounters: true,
+		Tolerance:      defaultTolerance,
+	}
+}
+
+func doubleMetric(name string, typ metricspb.MetricDescriptor_Type, values ...float64) *metricspb.Metric {
+	points := make([]*metricspb.Point, len(values))
+	for i, value := range values {
+		points[i] = &metricspb.Point{Value: &metricspb.Point_DoubleValue{DoubleValue: value}}
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Type: typ},
+		Timeseries:       []*metricspb.TimeSeries{{Points: points}},
+	}
+}
+
+// int64Values returns the type and the int64 values of the metric.
+func int64Values(t *testing.T, metric *metricspb.Metric) (metricspb.MetricDescriptor_Type, []int64) {
+	var values []int64
+	for _, point := range metric.Timeseries[0].Points {
+		v, ok := point.Value.(*metricspb.Point_Int64Value)
+		require.True(t, ok, "expecting an int64 point, got %v", point)
+		values = append(values, v.Int64Value)
+	}
+	return metric.MetricDescriptor.Type, values
+}
+
+func TestNewMetricsProcessor(t *testing.T) {
+	_, err := NewMetricsProcessor(zap.NewNop(), nil, newConfig())
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	cfg := newConfig()
+	cfg.Tolerance = -1
+	_, err = NewMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), cfg)
+	assert.Error(t, err)
+}
+
+func TestIntegerCounter(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	icp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig())
+	require.NoError(t, err)
+
+	counter := doubleMetric("requests_total", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE, 3, 7, 12)
+	original := proto.Clone(counter)
+	require.NoError(t, icp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{counter},
+	}))
+
+	got := sink.AllMetrics()[0].Metrics[0]
+	typ, values := int64Values(t, got)
+	assert.Equal(t, metricspb.MetricDescriptor_CUMULATIVE_INT64, typ)
+	assert.Equal(t, []int64{3, 7, 12}, values)
+	// The received metric is left untouched.
+	assert.True(t, proto.Equal(original, counter))
+}
+
+func TestFractionalValue(t *testing.T) {
+	tests := []struct {
+		name string
+		mode CoercionMode
+		want []int64
+	}{
+		{name: "round", mode: Round, want: []int64{3, -3, 2}},
+		{name: "truncate", mode: Truncate, want: []int64{2, -2, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig()
+			cfg.Mode = tt.mode
+			cfg.Metrics = []string{"queue_length"}
+			sink := new(exportertest.SinkMetricsExporter)
+			icp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+			require.NoError(t, err)
+
+			require.NoError(t, icp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{
+				Metrics: []*metricspb.Metric{
+					doubleMetric("queue_length", metricspb.MetricDescriptor_GAUGE_DOUBLE, 2.6, -2.5, 2),
+				},
+			}))
+
+			typ, values := int64Values(t, sink.AllMetrics()[0].Metrics[0])
+			assert.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, typ)
+			assert.Equal(t, tt.want, values)
+		})
+	}
+}
+
+func TestChangedPoints(t *testing.T) {
+	cfg := newConfig()
+	cfg.Metrics = []string{"queue_length"}
+	icp, err := NewMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), cfg)
+	require.NoError(t, err)
+
+	// Only the values changed by more than the tolerance are counted.
+	metric := doubleMetric("queue_length", metricspb.MetricDescriptor_GAUGE_DOUBLE, 2.6, 4, 5+1e-12)
+	_, changed, ok := icp.(*intCoercionProcessor).coerceMetric(metric)
+	assert.True(t, ok)
+	assert.Equal(t, 1, changed)
+}
+
+func TestNotCoerced(t *testing.T) {
+	cfg := newConfig()
+	cfg.Metrics = []string{"queue_length"}
+	sink := new(exportertest.SinkMetricsExporter)
+	icp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	metrics := []*metricspb.Metric{
+		// A counter with a fractional value is not detected.
+		doubleMetric("cpu_seconds_total", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE, 3, 7.25),
+		// The gauges are only converted when configured.
+		doubleMetric("temperature", metricspb.MetricDescriptor_GAUGE_DOUBLE, 20),
+		// A value that can't be an int64 leaves the metric as is.
+		doubleMetric("queue_length", metricspb.MetricDescriptor_GAUGE_DOUBLE, 2, math.NaN()),
+		doubleMetric("queue_length", metricspb.MetricDescriptor_GAUGE_DOUBLE, 1e19),
+	}
+	require.NoError(t, icp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Metrics: metrics}))
+
+	got := sink.AllMetrics()[0].Metrics
+	require.Len(t, got, len(metrics))
+	for i := range metrics {
+		assert.Same(t, metrics[i], got[i])
+	}
+}
+
+func TestDetectCountersDisabled(t *testing.T) {
+	cfg := newConfig()
+	cfg.DetectCounters = false
+	sink := new(exportertest.SinkMetricsExporter)
+	icp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	counter := doubleMetric("requests_total", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE, 3)
+	require.NoError(t, icp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{counter},
+	}))
+	assert.Same(t, counter, sink.AllMetrics()[0].Metrics[0])
+}
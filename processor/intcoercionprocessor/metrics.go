@@ -0,0 +1,45 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intcoercionprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	statChangedPoints = stats.Int64("int_coercion_changed_points", "Number of points whose value was changed by the conversion to an integer", stats.UnitDimensionless)
+)
+
+// MetricViews returns the metrics views related to the int coercion.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	changedPointsView := &view.View{
+		Name:        statChangedPoints.Name(),
+		Measure:     statChangedPoints,
+		Description: statChangedPoints.Description(),
+		TagKeys:     []tag.Key{processor.TagExporterNameKey},
+		Aggregation: view.Sum(),
+	}
+
+	return []*view.View{changedPointsView}
+}
@@ -0,0 +1,52 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelcaseprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Case is the case the keys are converted to.
+type Case string
+
+const (
+	// LowerCase converts the keys to lower case.
+	LowerCase Case = "lower"
+	// UpperCase converts the keys to upper case.
+	UpperCase Case = "upper"
+)
+
+// CollisionPolicy defines what is done when several keys of a metric or span
+// differ only by their case.
+type CollisionPolicy string
+
+const (
+	// MergeCollisions keeps a single key, with the value of the key already in
+	// the target case if any, otherwise of the first key.
+	MergeCollisions CollisionPolicy = "merge"
+	// ErrorCollisions drops the metric or span and reports an error.
+	ErrorCollisions CollisionPolicy = "error"
+)
+
+// Config defines configuration for the label case processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Case is the case the keys are converted to, either "lower" or "upper".
+	// Defaults to lower.
+	Case Case `mapstructure:"case"`
+	// Collision is what is done with the keys differing only by their case,
+	// either "merge" or "error". Defaults to merge.
+	Collision CollisionPolicy `mapstructure:"collision"`
+	// Values also converts the label values and the string attribute values.
+	Values bool `mapstructure:"values"`
+}
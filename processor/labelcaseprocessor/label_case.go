@@ -0,0 +1,316 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelcaseprocessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type labelCase struct {
+	convert   func(string) string
+	collision CollisionPolicy
+	values    bool
+	statsTags []tag.Mutator
+}
+
+type traceLabelCaseProcessor struct {
+	labelCase
+	nextConsumer consumer.TraceConsumer
+}
+
+type metricsLabelCaseProcessor struct {
+	labelCase
+	nextConsumer consumer.MetricsConsumer
+}
+
+var _ processor.TraceProcessor = (*traceLabelCaseProcessor)(nil)
+var _ processor.MetricsProcessor = (*metricsLabelCaseProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that converts the keys
+// of the span attributes, and optionally their string values, to the case of
+// the config.
+func NewTraceProcessor(nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	lc, err := newLabelCase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &traceLabelCaseProcessor{labelCase: lc, nextConsumer: nextConsumer}, nil
+}
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that converts the
+// label keys of the metrics, and optionally their values, to the case of the
+// config.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	lc, err := newLabelCase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsLabelCaseProcessor{labelCase: lc, nextConsumer: nextConsumer}, nil
+}
+
+func newLabelCase(cfg Config) (labelCase, error) {
+	lc := labelCase{
+		collision: cfg.Collision,
+		values:    cfg.Values,
+		statsTags: []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}
+	switch cfg.Case {
+	case "", LowerCase:
+		lc.convert = strings.ToLower
+	case UpperCase:
+		lc.convert = strings.ToUpper
+	default:
+		return labelCase{}, fmt.Errorf("unknown case %q, must be either %q or %q", cfg.Case, LowerCase, UpperCase)
+	}
+	switch cfg.Collision {
+	case "":
+		lc.collision = MergeCollisions
+	case MergeCollisions, ErrorCollisions:
+	default:
+		return labelCase{}, fmt.Errorf("unknown collision policy %q, must be either %q or %q",
+			cfg.Collision, MergeCollisions, ErrorCollisions)
+	}
+	return lc, nil
+}
+
+func (mp *metricsLabelCaseProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	collisions := 0
+	for i, metric := range md.Metrics {
+		converted, collided := mp.convertMetric(metric)
+		if collided {
+			collisions++
+		}
+		if converted == metric && metrics == nil {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, 0, len(md.Metrics))
+			metrics = append(metrics, md.Metrics[:i]...)
+		}
+		if converted != nil {
+			metrics = append(metrics, converted)
+		}
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+
+	err := mp.nextConsumer.ConsumeMetricsData(ctx, md)
+	return mp.collisionsError(collisions, "metrics", err)
+}
+
+func (tp *traceLabelCaseProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	var spans []*tracepb.Span
+	collisions := 0
+	for i, span := range td.Spans {
+		converted, collided := tp.convertSpan(span)
+		if collided {
+			collisions++
+		}
+		if converted == span && spans == nil {
+			continue
+		}
+		if spans == nil {
+			spans = make([]*tracepb.Span, 0, len(td.Spans))
+			spans = append(spans, td.Spans[:i]...)
+		}
+		if converted != nil {
+			spans = append(spans, converted)
+		}
+	}
+	if spans != nil {
+		td.Spans = spans
+	}
+
+	err := tp.nextConsumer.ConsumeTraceData(ctx, td)
+	return tp.collisionsError(collisions, "spans", err)
+}
+
+// collisionsError records the collisions and returns the error of the next
+// consumer, combined with an error for the dropped items if collisions are
+// errors.
+func (lc *labelCase) collisionsError(collisions int, items string, err error) error {
+	if collisions == 0 {
+		return err
+	}
+	stats.RecordWithTags(context.Background(), lc.statsTags, statKeyCollisions.M(int64(collisions)))
+	if lc.collision != ErrorCollisions {
+		return err
+	}
+	errs := []error{fmt.Errorf("dropped %d %s with keys differing only by their case", collisions, items)}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return oterr.CombineErrors(errs)
+}
+
+// convertMetric returns the metric with its label keys converted, nil if it
+// has colliding keys which are errors, and whether it has colliding keys. The
+// metric is returned as is if unchanged, otherwise a copy is returned as the
+func (lc *labelCase) convertMetric(metric *metricspb.Metric) (*metricspb.Metric, bool) {
+	desc := metric.GetMetricDescriptor()
+	if desc == nil {
+		return metric, false
+	}
+
+	// sources holds, for each converted key, the indexes of the original keys
+	// converted to it, the key already in the target case first.
+	var keys []*metricspb.LabelKey
+	var sources [][]int
+	indexes := make(map[string]int, len(desc.LabelKeys))
+	changed, collided := false, false
+	for i, labelKey := range desc.LabelKeys {
+		key := lc.convert(labelKey.GetKey())
+		if key != labelKey.GetKey() {
+			changed = true
+		}
+		j, ok := indexes[key]
+		if !ok {
+			indexes[key] = len(keys)
+			keys = append(keys, &metricspb.LabelKey{Key: key, Description: labelKey.GetDescription()})
+			sources = append(sources, []int{i})
+			continue
+		}
+		collided = true
+		if key == labelKey.GetKey() {
+			sources[j] = append([]int{i}, sources[j]...)
+			keys[j].Description = labelKey.GetDescription()
+		} else {
+			sources[j] = append(sources[j], i)
+		}
+	}
+	if collided && lc.collision == ErrorCollisions {
+		return nil, true
+	}
+	if !changed && !lc.values {
+		return metric, collided
+	}
+
+	converted := &metricspb.Metric{Resource: metric.Resource}
+	convertedDesc := *desc
+	convertedDesc.LabelKeys = keys
+	converted.MetricDescriptor = &convertedDesc
+	converted.Timeseries = make([]*metricspb.TimeSeries, len(metric.Timeseries))
+	for i, ts := range metric.Timeseries {
+		convertedTs := *ts
+		convertedTs.LabelValues = make([]*metricspb.LabelValue, len(sources))
+		for j, indexes := range sources {
+			convertedTs.LabelValues[j] = lc.mergeLabelValues(ts.LabelValues, indexes)
+		}
+		converted.Timeseries[i] = &convertedTs
+	}
+	return converted, collided
+}
+
+// mergeLabelValues returns the first set value at the given indexes, converted
+// if values are converted.
+func (lc *labelCase) mergeLabelValues(values []*metricspb.LabelValue, indexes []int) *metricspb.LabelValue {
+	var merged *metricspb.LabelValue
+	for _, i := range indexes {
+		if i < len(values) && values[i].GetHasValue() {
+			merged = values[i]
+			break
+		}
+	}
+	if merged == nil {
+		return &metricspb.LabelValue{}
+	}
+	if lc.values {
+		return &metricspb.LabelValue{Value: lc.convert(merged.Value), HasValue: true}
+	}
+	return merged
+}
+
+// convertSpan returns the span with its attribute keys converted, nil if it
+// has colliding keys which are errors, and whether it has colliding keys. The
+// span is returned as is if unchanged, otherwise a copy is returned as the
+func (lc *labelCase) convertSpan(span *tracepb.Span) (*tracepb.Span, bool) {
+	attrs := span.GetAttributes().GetAttributeMap()
+	if len(attrs) == 0 {
+		return span, false
+	}
+
+	// Merge the keys in order for the result not to depend on the map order.
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	converted := make(map[string]*tracepb.AttributeValue, len(attrs))
+	changed, collided := false, false
+	for _, key := range keys {
+		convertedKey := lc.convert(key)
+		if convertedKey != key {
+			changed = true
+		}
+		if _, ok := converted[convertedKey]; ok {
+			collided = true
+			// The key already in the target case wins.
+			if convertedKey != key {
+				continue
+			}
+		}
+		converted[convertedKey] = lc.convertAttributeValue(attrs[key])
+	}
+	if collided && lc.collision == ErrorCollisions {
+		return nil, true
+	}
+	if !changed && !lc.values {
+		return span, collided
+	}
+
+	convertedSpan := *span
+	convertedSpan.Attributes = &tracepb.Span_Attributes{
+		AttributeMap:           converted,
+		DroppedAttributesCount: span.Attributes.DroppedAttributesCount,
+	}
+	return &convertedSpan, collided
+}
+
+func (lc *labelCase) convertAttributeValue(value *tracepb.AttributeValue) *tracepb.AttributeValue {
+	str, ok := value.GetValue().(*tracepb.AttributeValue_StringValue)
+	if !lc.values || !ok || str.StringValue == nil {
+		return value
+	}
+	return &tracepb.AttributeValue{
+		Value: &tracepb.AttributeValue_StringValue{
+			StringValue: &tracepb.TruncatableString{
+				Value:              lc.convert(str.StringValue.Value),
+				TruncatedByteCount: str.StringValue.TruncatedByteCount,
+			},
+		},
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelcaseprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewProcessorNilNext(t *testing.T) {
+	tp, err := NewTraceProcessor(nil, Config{})
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	mp, err := NewMetricsProcessor(nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestLowerCaseMetricKeys(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	m := metric([]string{"Region", "HTTP_Method"}, []string{"EU", "GET"})
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{m}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	assert.Equal(t, metric([]string{"region", "http_method"}, []string{"EU", "GET"}), got[0].Metrics[0])
+	// The received metric is shared with other pipelines, it is not modified.
+	assert.Equal(t, metric([]string{"Region", "HTTP_Method"}, []string{"EU", "GET"}), m)
+}
+
+func TestUnchangedMetricPassedAsIs(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric([]string{"region"}, []string{"EU"})}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Equal(t, []consumerdata.MetricsData{md}, sink.AllMetrics())
+	assert.True(t, md.Metrics[0] == sink.AllMetrics()[0].Metrics[0])
+}
+
+func TestMergeMetricKeyCollision(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkMetricsExporter)
+	cfg := Config{ProcessorSettings: configmodels.ProcessorSettings{NameVal: "label_case"}}
+	mp, err := NewMetricsProcessor(sink, cfg)
+	require.NoError(t, err)
+
+	m := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "requests",
+			LabelKeys: []*metricspb.LabelKey{{Key: "Region"}, {Key: "region"}},
+		},
+		Timeseries: []*metricspb.TimeSeries{
+			// The value of the key already in lower case wins.
+			{LabelValues: []*metricspb.LabelValue{{Value: "EU", HasValue: true}, {Value: "eu-west", HasValue: true}}},
+			// Otherwise the value set.
+			{LabelValues: []*metricspb.LabelValue{{Value: "US", HasValue: true}, {}}},
+			{LabelValues: []*metricspb.LabelValue{{}, {}}},
+		},
+	}
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{m}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	merged := got[0].Metrics[0]
+	assert.Equal(t, []*metricspb.LabelKey{{Key: "region"}}, merged.MetricDescriptor.LabelKeys)
+	require.Len(t, merged.Timeseries, 3)
+	assert.Equal(t, []*metricspb.LabelValue{{Value: "eu-west", HasValue: true}}, merged.Timeseries[0].LabelValues)
+	assert.Equal(t, []*metricspb.LabelValue{{Value: "US", HasValue: true}}, merged.Timeseries[1].LabelValues)
+	assert.Equal(t, []*metricspb.LabelValue{{}}, merged.Timeseries[2].LabelValues)
+	assert.Len(t, m.MetricDescriptor.LabelKeys, 2)
+
+	rows, err := view.RetrieveData(statKeyCollisions.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestErrorMetricKeyCollision(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Collision: ErrorCollisions})
+	require.NoError(t, err)
+
+	colliding := metric([]string{"Region", "region"}, []string{"EU", "eu-west"})
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{
+		colliding,
+		metric([]string{"Zone"}, []string{"a"}),
+	}}
+	assert.Error(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, []*metricspb.Metric{metric([]string{"zone"}, []string{"a"})}, got[0].Metrics)
+	assert.Len(t, md.Metrics, 2)
+}
+
+func TestUpperCaseMetricValues(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Case: UpperCase, Values: true})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric([]string{"region"}, []string{"eu"})}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, []*metricspb.Metric{metric([]string{"REGION"}, []string{"EU"})}, got[0].Metrics)
+}
+
+func TestLowerCaseSpanAttributes(t *testing.T) {
+	sink := new(exportertest.SinkTraceExporter)
+	tp, err := NewTraceProcessor(sink, Config{Values: true})
+	require.NoError(t, err)
+
+	s := span(map[string]*tracepb.AttributeValue{
+		"Region": stringValue("EU"),
+		"Retry":  {Value: &tracepb.AttributeValue_BoolValue{BoolValue: true}},
+	})
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{s}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	assert.Equal(t, []*tracepb.Span{span(map[string]*tracepb.AttributeValue{
+		"region": stringValue("eu"),
+		"retry":  {Value: &tracepb.AttributeValue_BoolValue{BoolValue: true}},
+	})}, got[0].Spans)
+	assert.Contains(t, s.Attributes.AttributeMap, "Region")
+	assert.Equal(t, "EU", s.Attributes.AttributeMap["Region"].GetStringValue().Value)
+}
+
+func TestSpanAttributeKeyCollision(t *testing.T) {
+	attrs := func() map[string]*tracepb.AttributeValue {
+		return map[string]*tracepb.AttributeValue{
+			"Region": stringValue("EU"),
+			"region": stringValue("eu-west"),
+		}
+	}
+
+	sink := new(exportertest.SinkTraceExporter)
+	tp, err := NewTraceProcessor(sink, Config{})
+	require.NoError(t, err)
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{span(attrs())}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	assert.Equal(t, []*tracepb.Span{span(map[string]*tracepb.AttributeValue{
+		"region": stringValue("eu-west"),
+	})}, got[0].Spans)
+
+	sink = new(exportertest.SinkTraceExporter)
+	tp, err = NewTraceProcessor(sink, Config{Collision: ErrorCollisions})
+	require.NoError(t, err)
+	td = consumerdata.TraceData{Spans: []*tracepb.Span{span(attrs()), span(nil)}}
+	assert.Error(t, tp.ConsumeTraceData(context.Background(), td))
+	got = sink.AllTraces()
+	require.Len(t, got, 1)
+	assert.Equal(t, []*tracepb.Span{span(nil)}, got[0].Spans)
+}
+
+func metric(keys, values []string) *metricspb.Metric {
+	labelKeys := make([]*metricspb.LabelKey, len(keys))
+	for i, key := range keys {
+		labelKeys[i] = &metricspb.LabelKey{Key: key}
+	}
+	labelValues := make([]*metricspb.LabelValue, len(values))
+	for i, value := range values {
+		labelValues[i] = &metricspb.LabelValue{Value: value, HasValue: true}
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "requests",
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys: labelKeys,
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			LabelValues: labelValues,
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}},
+		}},
+	}
+}
+
+func span(attrs map[string]*tracepb.AttributeValue) *tracepb.Span {
+	s := &tracepb.Span{
+		TraceId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanId:  []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Name:    &tracepb.TruncatableString{Value: "checkout"},
+	}
+	if attrs != nil {
+		s.Attributes = &tracepb.Span_Attributes{AttributeMap: attrs}
+	}
+	return s
+}
+
+func stringValue(value string) *tracepb.AttributeValue {
+	return &tracepb.AttributeValue{
+		Value: &tracepb.AttributeValue_StringValue{StringValue: &tracepb.TruncatableString{Value: value}},
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelconsistencyprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Policy is how the series missing some of the labels of their metric name
+// are handled.
+type Policy string
+
+const (
+	// FillPolicy sets the missing labels of the series to the default value.
+	FillPolicy Policy = "fill"
+	// DropPolicy drops the series missing labels.
+	DropPolicy Policy = "drop"
+)
+
+// Config defines configuration for the label consistency processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Policy is how the series missing some of the labels of their metric
+	// name are handled: "fill" (the default) or "drop".
+	Policy Policy `mapstructure:"policy"`
+	// DefaultValue is the value the missing labels are set to, with the fill
+	// policy.
+	DefaultValue string `mapstructure:"default_value"`
+}
@@ -0,0 +1,244 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelconsistencyprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// dropReason is the reason the series dropped are recorded with, see
+// processor.RecordDropped.
+const dropReason = "inconsistent_labels"
+
+type labelConsistencyProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	policy       Policy
+	defaultValue string
+	statsTags    []tag.Mutator
+}
+
+var _ processor.MetricsProcessor = (*labelConsistencyProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that makes the
+// series of each metric name of a batch have values for the same label keys:
+// the keys having a value in at least one series of the name. The series
+// missing some of these labels have them set to the default value, or are
+// dropped, according to the policy of the config.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	policy := cfg.Policy
+	switch policy {
+	case "":
+		policy = FillPolicy
+	case FillPolicy, DropPolicy:
+	default:
+		return nil, fmt.Errorf("unknown policy %q, must be either %q or %q", cfg.Policy, FillPolicy, DropPolicy)
+	}
+	if policy == FillPolicy && cfg.DefaultValue == "" {
+		return nil, errors.New("default_value must be set with the fill policy")
+	}
+
+	return &labelConsistencyProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		policy:       policy,
+		defaultValue: cfg.DefaultValue,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (lcp *labelConsistencyProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	families := familyLabels(md.Metrics)
+
+	metrics := make([]*metricspb.Metric, 0, len(md.Metrics))
+	inconsistent := 0
+	for _, metric := range md.Metrics {
+		keys := families[metric.GetMetricDescriptor().GetName()]
+		if metric.GetMetricDescriptor() == nil || len(keys) == 0 {
+			metrics = append(metrics, metric)
+			continue
+		}
+		consistent, n := lcp.enforce(metric, keys)
+		if n > 0 {
+			lcp.logger.Debug("Series missing labels of their metric name",
+				zap.String("processor", lcp.name),
+				zap.String("metric", metric.MetricDescriptor.Name),
+				zap.Int("timeseries", n),
+				zap.String("policy", string(lcp.policy)))
+		}
+		inconsistent += n
+		if consistent != nil {
+			metrics = append(metrics, consistent)
+		}
+	}
+	if inconsistent == 0 {
+		return lcp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	stat := statFilledTimeseries
+	if lcp.policy == DropPolicy {
+		stat = statDroppedTimeseries
+	}
+	stats.RecordWithTags(context.Background(), lcp.statsTags, stat.M(int64(inconsistent)))
+
+	if len(metrics) == 0 {
+		// Every series in the batch was dropped.
+		return nil
+	}
+	md.Metrics = metrics
+	return lcp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// enforce returns the metric with its series made consistent with the label
+// keys of its name, nil if all its series were dropped, and the number of
+// series missing labels. The metric is returned as is if none is, otherwise a
+func (lcp *labelConsistencyProcessor) enforce(metric *metricspb.Metric, keys []string) (*metricspb.Metric, int) {
+	desc := metric.MetricDescriptor
+	indexes := make([]int, len(keys))
+	for i, key := range keys {
+		indexes[i] = labelIndex(desc, key)
+	}
+
+	inconsistent := 0
+	for _, ts := range metric.Timeseries {
+		if !hasLabels(ts, indexes) {
+			inconsistent++
+		}
+	}
+	if inconsistent == 0 {
+		return metric, 0
+	}
+
+	if lcp.policy == DropPolicy {
+		kept := make([]*metricspb.TimeSeries, 0, len(metric.Timeseries)-inconsistent)
+		var dropped []*metricspb.TimeSeries
+		for _, ts := range metric.Timeseries {
+			if hasLabels(ts, indexes) {
+				kept = append(kept, ts)
+			} else {
+				dropped = append(dropped, ts)
+			}
+		}
+		processor.RecordDroppedMetrics(lcp.name, dropReason, []*metricspb.Metric{{MetricDescriptor: desc, Timeseries: dropped}})
+		if len(kept) == 0 {
+			return nil, inconsistent
+		}
+		return &metricspb.Metric{MetricDescriptor: desc, Resource: metric.Resource, Timeseries: kept}, inconsistent
+	}
+
+	// Append the keys of the name missing from the descriptor of the metric.
+	filledDesc := *desc
+	filledDesc.LabelKeys = append([]*metricspb.LabelKey(nil), desc.LabelKeys...)
+	for i, key := range keys {
+		if indexes[i] < 0 {
+			indexes[i] = len(filledDesc.LabelKeys)
+			filledDesc.LabelKeys = append(filledDesc.LabelKeys, &metricspb.LabelKey{Key: key})
+		}
+	}
+
+	timeseries := make([]*metricspb.TimeSeries, 0, len(metric.Timeseries))
+	for _, ts := range metric.Timeseries {
+		if hasLabels(ts, indexes) && len(ts.LabelValues) == len(filledDesc.LabelKeys) {
+			timeseries = append(timeseries, ts)
+			continue
+		}
+		tsCopy := *ts
+		tsCopy.LabelValues = make([]*metricspb.LabelValue, len(filledDesc.LabelKeys))
+		copy(tsCopy.LabelValues, ts.LabelValues)
+		for i, lv := range tsCopy.LabelValues {
+			if lv == nil {
+				tsCopy.LabelValues[i] = &metricspb.LabelValue{}
+			}
+		}
+		for _, index := range indexes {
+			if !tsCopy.LabelValues[index].GetHasValue() {
+				tsCopy.LabelValues[index] = &metricspb.LabelValue{Value: lcp.defaultValue, HasValue: true}
+			}
+		}
+		timeseries = append(timeseries, &tsCopy)
+	}
+	return &metricspb.Metric{MetricDescriptor: &filledDesc, Resource: metric.Resource, Timeseries: timeseries}, inconsistent
+}
+
+// familyLabels returns the label keys of each metric name of the batch: the
+// keys having a value in at least one series of the name, in the order they
+// are first seen.
+func familyLabels(metrics []*metricspb.Metric) map[string][]string {
+	families := make(map[string][]string)
+	seen := make(map[string]map[string]bool)
+	for _, metric := range metrics {
+		desc := metric.GetMetricDescriptor()
+		if desc == nil {
+			continue
+		}
+		keys := seen[desc.Name]
+		if keys == nil {
+			keys = make(map[string]bool)
+			seen[desc.Name] = keys
+		}
+		for i, key := range desc.LabelKeys {
+			if keys[key.GetKey()] {
+				continue
+			}
+			for _, ts := range metric.Timeseries {
+				if i < len(ts.LabelValues) && ts.LabelValues[i].GetHasValue() {
+					keys[key.GetKey()] = true
+					families[desc.Name] = append(families[desc.Name], key.GetKey())
+					break
+				}
+			}
+		}
+	}
+	return families
+}
+
+// labelIndex returns the index of the label key in the descriptor, -1 if it
+// does not have it.
+func labelIndex(desc *metricspb.MetricDescriptor, key string) int {
+	for i, k := range desc.LabelKeys {
+		if k.GetKey() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasLabels returns whether the series has a value for each of the label
+// indexes.
+func hasLabels(ts *metricspb.TimeSeries, indexes []int) bool {
+	for _, index := range indexes {
+		if index < 0 || index >= len(ts.LabelValues) || !ts.LabelValues[index].GetHasValue() {
+			return false
+		}
+	}
+	return true
+}
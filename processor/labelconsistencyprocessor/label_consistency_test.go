@@ -0,0 +1,176 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelconsistencyprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func newConfig(policy Policy) Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		Policy:       policy,
+		DefaultValue: defaultDefaultValue,
+	}
+}
+
+func labelValue(value string) *metricspb.LabelValue {
+	if value == "" {
+		return &metricspb.LabelValue{}
+	}
+	return &metricspb.LabelValue{Value: value, HasValue: true}
+}
+
+// series returns a series with the given label values, an empty value being
+// missing.
+func series(values ...string) *metricspb.TimeSeries {
+	ts := &metricspb.TimeSeries{Points: []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}}}
+	for _, v := range values {
+		ts.LabelValues = append(ts.LabelValues, labelValue(v))
+	}
+	return ts
+}
+
+func metric(name string, keys []string, timeseries ...*metricspb.TimeSeries) *metricspb.Metric {
+	desc := &metricspb.MetricDescriptor{Name: name, Type: metricspb.MetricDescriptor_CUMULATIVE_INT64}
+	for _, key := range keys {
+		desc.LabelKeys = append(desc.LabelKeys, &metricspb.LabelKey{Key: key})
+	}
+	return &metricspb.Metric{MetricDescriptor: desc, Timeseries: timeseries}
+}
+
+// inconsistentFamily returns a batch where the "requests" series have
+// inconsistent label sets: one lacks the code, and a second metric of the
+// same name lacks the code key altogether.
+func inconsistentFamily() consumerdata.MetricsData {
+	return consumerdata.MetricsData{Metrics: []*metricspb.Metric{
+		metric("requests", []string{"method", "code"},
+			series("GET", "200"),
+			series("POST", "")),
+		metric("requests", []string{"method"},
+			series("PUT")),
+		metric("temperature", []string{"room"},
+			series("kitchen")),
+	}}
+}
+
+// labels returns the labels of the series of the metrics, by metric.
+func labels(metrics []*metricspb.Metric) [][]map[string]string {
+	var got [][]map[string]string
+	for _, metric := range metrics {
+		var metricLabels []map[string]string
+		for _, ts := range metric.Timeseries {
+			tsLabels := make(map[string]string)
+			for i, lv := range ts.LabelValues {
+				if lv.GetHasValue() {
+					tsLabels[metric.MetricDescriptor.LabelKeys[i].Key] = lv.Value
+				}
+			}
+			metricLabels = append(metricLabels, tsLabels)
+		}
+		got = append(got, metricLabels)
+	}
+	return got
+}
+
+func TestNewMetricsProcessor(t *testing.T) {
+	next := exportertest.NewNopMetricsExporter()
+
+	_, err := NewMetricsProcessor(zap.NewNop(), nil, newConfig(FillPolicy))
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	cfg := newConfig(FillPolicy)
+	cfg.DefaultValue = ""
+	mp, err := NewMetricsProcessor(zap.NewNop(), next, cfg)
+	assert.Nil(t, mp)
+	assert.Error(t, err, "the fill policy requires a default value")
+
+	cfg.Policy = DropPolicy
+	_, err = NewMetricsProcessor(zap.NewNop(), next, cfg)
+	assert.NoError(t, err, "the drop policy does not require a default value")
+
+	_, err = NewMetricsProcessor(zap.NewNop(), next, newConfig(""))
+	assert.NoError(t, err, "the policy defaults to fill")
+}
+
+func TestFillPolicy(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	lcp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig(FillPolicy))
+	require.NoError(t, err)
+
+	md := inconsistentFamily()
+	original := proto.Clone(md.Metrics[0]).(*metricspb.Metric)
+	require.NoError(t, lcp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()[0].Metrics
+	assert.Equal(t, [][]map[string]string{
+		{{"method": "GET", "code": "200"}, {"method": "POST", "code": "unknown"}},
+		{{"method": "PUT", "code": "unknown"}},
+		{{"room": "kitchen"}},
+	}, labels(got))
+	assert.True(t, md.Metrics[2] == got[2], "the consistent metrics must be passed as is")
+	assert.True(t, proto.Equal(original, md.Metrics[0]), "the input metrics must not be modified")
+}
+
+func TestDropPolicy(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	lcp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig(DropPolicy))
+	require.NoError(t, err)
+
+	md := inconsistentFamily()
+	require.NoError(t, lcp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()[0].Metrics
+	assert.Equal(t, [][]map[string]string{
+		{{"method": "GET", "code": "200"}},
+		{{"room": "kitchen"}},
+	}, labels(got))
+	assert.Len(t, md.Metrics[0].Timeseries, 2, "the input metrics must not be modified")
+
+	// A batch of inconsistent series only is dropped entirely.
+	require.NoError(t, lcp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Metrics: []*metricspb.Metric{
+		metric("requests", []string{"code"}, series("500")),
+		metric("requests", []string{"method"}, series("GET")),
+	}}))
+	assert.Len(t, sink.AllMetrics(), 1)
+}
+
+func TestConsistentBatchPassedAsIs(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	lcp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig(DropPolicy))
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{
+		// The keys without a value in any series are not required.
+		metric("requests", []string{"method", "shard"}, series("GET", ""), series("POST", "")),
+	}}
+	require.NoError(t, lcp.ConsumeMetricsData(context.Background(), md))
+	assert.Equal(t, []consumerdata.MetricsData{md}, sink.AllMetrics())
+}
@@ -0,0 +1,53 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelconsistencyprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	statFilledTimeseries  = stats.Int64("label_consistency_filled_timeseries", "Number of timeseries whose missing labels were set to the default value", stats.UnitDimensionless)
+	statDroppedTimeseries = stats.Int64("label_consistency_dropped_timeseries", "Number of timeseries dropped because they were missing labels of their metric name", stats.UnitDimensionless)
+)
+
+// MetricViews returns the metrics views related to label consistency.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	filledTimeseriesView := &view.View{
+		Name:        statFilledTimeseries.Name(),
+		Measure:     statFilledTimeseries,
+		Description: statFilledTimeseries.Description(),
+		TagKeys:     []tag.Key{processor.TagExporterNameKey},
+		Aggregation: view.Sum(),
+	}
+	droppedTimeseriesView := &view.View{
+		Name:        statDroppedTimeseries.Name(),
+		Measure:     statDroppedTimeseries,
+		Description: statDroppedTimeseries.Description(),
+		TagKeys:     []tag.Key{processor.TagExporterNameKey},
+		Aggregation: view.Sum(),
+	}
+
+	return []*view.View{filledTimeseriesView, droppedTimeseriesView}
+}
@@ -0,0 +1,123 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelhashprocessor
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type labelHashProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	labels       map[string]bool
+}
+
+var _ processor.MetricsProcessor = (*labelHashProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that replaces the
+// values of the configured labels by their hash.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	labels := make(map[string]bool, len(cfg.Labels))
+	for _, key := range cfg.Labels {
+		if key == "" {
+			return nil, fmt.Errorf("empty label key")
+		}
+		labels[key] = true
+	}
+
+	return &labelHashProcessor{
+		nextConsumer: nextConsumer,
+		labels:       labels,
+	}, nil
+}
+
+func (lhp *labelHashProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		indexes := lhp.hashedIndexes(metric.GetMetricDescriptor())
+		if len(indexes) == 0 {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = hashLabelValues(metric, indexes)
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return lhp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// hashedIndexes returns the indexes of the labels of the metric whose values
+// are hashed.
+func (lhp *labelHashProcessor) hashedIndexes(desc *metricspb.MetricDescriptor) []int {
+	var indexes []int
+	for i, key := range desc.GetLabelKeys() {
+		if lhp.labels[key.GetKey()] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// hashLabelValues returns a copy of the metric with the values of the labels
+// at the given indexes replaced by their hash, the metric is not modified.
+// Missing values are left as is.
+func hashLabelValues(metric *metricspb.Metric, indexes []int) *metricspb.Metric {
+	hashedMetric := *metric
+	hashedMetric.Timeseries = make([]*metricspb.TimeSeries, len(metric.Timeseries))
+	for i, ts := range metric.Timeseries {
+		if ts == nil {
+			continue
+		}
+		hashedTs := *ts
+		hashedTs.LabelValues = make([]*metricspb.LabelValue, len(ts.LabelValues))
+		copy(hashedTs.LabelValues, ts.LabelValues)
+		for _, j := range indexes {
+			if j >= len(ts.LabelValues) {
+				continue
+			}
+			lv := ts.LabelValues[j]
+			if lv == nil || !lv.HasValue {
+				continue
+			}
+			hashedTs.LabelValues[j] = &metricspb.LabelValue{Value: hashValue(lv.Value), HasValue: true}
+		}
+		hashedMetric.Timeseries[i] = &hashedTs
+	}
+	return &hashedMetric
+}
+
+// hashValue returns the 64-bit FNV-1a hash of the value as 16 hex digits. The
+// hash is not seeded, so a value is hashed the same by every collector.
+func hashValue(value string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
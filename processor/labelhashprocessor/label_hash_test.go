@@ -0,0 +1,120 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelhashprocessor
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestHashLabelValues(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(sink, Config{Labels: []string{"user_id"}})
+	require.NoError(t, err)
+
+	shared := &metricspb.LabelValue{Value: "alice", HasValue: true}
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			metric("logins", []string{"region", "user_id"},
+				[]*metricspb.LabelValue{{Value: "eu", HasValue: true}, shared},
+				[]*metricspb.LabelValue{{Value: "us", HasValue: true}, {Value: "bob", HasValue: true}},
+				[]*metricspb.LabelValue{{Value: "us", HasValue: true}, {}},
+			),
+			metric("purchases", []string{"user_id"},
+				[]*metricspb.LabelValue{shared},
+			),
+			metric("errors", []string{"region"},
+				[]*metricspb.LabelValue{{Value: "eu", HasValue: true}},
+			),
+		},
+	}
+	original := proto.Clone(md.Metrics[0])
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.True(t, proto.Equal(original, md.Metrics[0]), "the metrics may be shared, they must not be modified")
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	logins := got[0].Metrics[0].Timeseries
+	assert.Equal(t, "eu", logins[0].LabelValues[0].Value)
+	assert.Equal(t, hashValue("alice"), logins[0].LabelValues[1].Value)
+	assert.Len(t, logins[0].LabelValues[1].Value, 16)
+	assert.Equal(t, hashValue("bob"), logins[1].LabelValues[1].Value)
+	// Missing values are not hashed.
+	assert.Equal(t, &metricspb.LabelValue{}, logins[2].LabelValues[1])
+	// The same value is hashed the same across metrics, and a shared label
+	// value is hashed only once.
+	assert.Equal(t, logins[0].LabelValues[1].Value, got[0].Metrics[1].Timeseries[0].LabelValues[0].Value)
+	assert.Equal(t, "alice", shared.Value)
+	assert.Equal(t, "eu", got[0].Metrics[2].Timeseries[0].LabelValues[0].Value)
+}
+
+func TestHashValueDeterministic(t *testing.T) {
+	// The hash must not change across versions and collectors.
+	assert.Equal(t, "cbf29ce484222325", hashValue(""))
+	assert.Equal(t, "508b2abb65a03907", hashValue("alice"))
+	assert.Equal(t, hashValue("user-42"), hashValue("user-42"))
+}
+
+func TestHashValueCollisions(t *testing.T) {
+	const n = 100000
+	seen := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		value := "user-" + strconv.Itoa(i)
+		h := hashValue(value)
+		require.Len(t, h, 16)
+		if other, ok := seen[h]; ok {
+			t.Fatalf("%q and %q have the same hash %s", value, other, h)
+		}
+		seen[h] = value
+	}
+}
+
+func metric(name string, keys []string, series ...[]*metricspb.LabelValue) *metricspb.Metric {
+	labelKeys := make([]*metricspb.LabelKey, 0, len(keys))
+	for _, key := range keys {
+		labelKeys = append(labelKeys, &metricspb.LabelKey{Key: key})
+	}
+	timeseries := make([]*metricspb.TimeSeries, 0, len(series))
+	for _, labelValues := range series {
+		timeseries = append(timeseries, &metricspb.TimeSeries{
+			LabelValues: labelValues,
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}},
+		})
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys: labelKeys,
+		},
+		Timeseries: timeseries,
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancingprocessor
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	defaultUnhealthyAfter      = 3
+	defaultHealthCheckInterval = 30 * time.Second
+)
+
+// endpoint is the state of an endpoint of the balancer.
+type endpoint struct {
+	name   string
+	weight int
+	// seed mixes the endpoint in the hashes of the consistent routing.
+	seed uint64
+	// current is the current weight of the endpoint in the smooth weighted
+	// round robin.
+	current int
+	// failures is the number of failures in a row.
+	failures int
+	healthy  bool
+	// checkAt is when an unhealthy endpoint is sent data again.
+	checkAt time.Time
+}
+
+// balancer distributes the data over the endpoints and tracks their health:
+// an endpoint failing several times in a row is skipped until its next health
+// check, a health check being the data sent to the endpoint once the interval
+// elapsed. An endpoint succeeding is healthy again.
+type balancer struct {
+	name                string
+	logger              *zap.Logger
+	unhealthyAfter      int
+	healthCheckInterval time.Duration
+	// now returns the current time, replaced in tests.
+	now func() time.Time
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+}
+
+func newBalancer(logger *zap.Logger, cfg Config) *balancer {
+	b := &balancer{
+		name:                cfg.Name(),
+		logger:              logger,
+		unhealthyAfter:      cfg.UnhealthyAfter,
+		healthCheckInterval: cfg.HealthCheckInterval,
+		now:                 time.Now,
+		endpoints:           make([]*endpoint, len(cfg.Endpoints)),
+	}
+	if b.unhealthyAfter == 0 {
+		b.unhealthyAfter = defaultUnhealthyAfter
+	}
+	if b.healthCheckInterval == 0 {
+		b.healthCheckInterval = defaultHealthCheckInterval
+	}
+	for i, e := range cfg.Endpoints {
+		weight := e.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		b.endpoints[i] = &endpoint{
+			name:    e.Name,
+			weight:  weight,
+			seed:    hashString(e.Name),
+			healthy: true,
+		}
+	}
+	return b
+}
+
+// candidates returns the indexes of the endpoints the data can be sent to: the
+// healthy ones and the unhealthy ones due for a health check, whose next health
+// check is then scheduled. All the endpoints are candidates when none is, the
+// data would be dropped otherwise.
+func (b *balancer) candidates() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	candidates := make([]int, 0, len(b.endpoints))
+	for i, e := range b.endpoints {
+		if e.healthy {
+			candidates = append(candidates, i)
+		} else if !now.Before(e.checkAt) {
+			e.checkAt = now.Add(b.healthCheckInterval)
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range b.endpoints {
+			candidates = append(candidates, i)
+		}
+	}
+	return candidates
+}
+
+// next returns the endpoint the next batch is sent to among the candidates.
+// An unhealthy candidate, due for a health check, gets the batch, the others
+// are chosen by a smooth weighted round robin.
+func (b *balancer) next(candidates []int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, i := range candidates {
+		if !b.endpoints[i].healthy {
+			return i
+		}
+	}
+	best := -1
+	total := 0
+	for _, i := range candidates {
+		e := b.endpoints[i]
+		e.current += e.weight
+		total += e.weight
+		if best < 0 || e.current > b.endpoints[best].current {
+			best = i
+		}
+	}
+	b.endpoints[best].current -= total
+	return best
+}
+
+// pick returns the endpoint the data of the given key is sent to among the
+// candidates, using a weighted rendezvous hashing: a key keeps its endpoint as
+// long as the endpoint is a candidate, and only the keys of an endpoint leaving
+// the candidates move.
+func (b *balancer) pick(candidates []int, key uint64) int {
+	best := -1
+	bestScore := 0.0
+	for _, i := range candidates {
+		e := b.endpoints[i]
+		// A uniform value in (0, 1) from the key and the endpoint.
+		u := (float64(mix(key^e.seed)>>11) + 0.5) / (1 << 53)
+		score := -float64(e.weight) / math.Log(u)
+		if best < 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// export sends the data to the given endpoint and, when it fails with an error
+// that is not permanent, to the other candidates in turn until one succeeds.
+func (b *balancer) export(first int, candidates []int, send func(i int) error) error {
+	err := b.try(first, send)
+	if err == nil || consumererror.IsPermanent(err) {
+		return err
+	}
+	for _, i := range candidates {
+		if i == first {
+			continue
+		}
+		err = b.try(i, send)
+		if err == nil || consumererror.IsPermanent(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (b *balancer) try(i int, send func(i int) error) error {
+	err := send(i)
+	if err == nil {
+		b.succeeded(i)
+	} else if !consumererror.IsPermanent(err) {
+		// The data failing permanently would fail on any endpoint.
+		b.failed(i, err)
+	}
+	return err
+}
+
+func (b *balancer) succeeded(i int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := b.endpoints[i]
+	e.failures = 0
+	if !e.healthy {
+		e.healthy = true
+		// Start the round robin afresh rather than from the weight of the
+		// endpoint before it was unhealthy.
+		e.current = 0
+		b.logger.Info("Endpoint recovered, sending data to it again",
+			zap.String("processor", b.name), zap.String("endpoint", e.name))
+	}
+}
+
+func (b *balancer) failed(i int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := b.endpoints[i]
+	stats.RecordWithTags(context.Background(),
+		[]tag.Mutator{tag.Upsert(processor.TagExporterNameKey, b.name), tag.Upsert(tagEndpointKey, e.name)},
+		statEndpointFailures.M(1))
+	e.failures++
+	if e.healthy && e.failures >= b.unhealthyAfter {
+		e.healthy = false
+		b.logger.Warn("Endpoint is unhealthy, skipping it until its next health check",
+			zap.String("processor", b.name), zap.String("endpoint", e.name),
+			zap.Duration("health_check_interval", b.healthCheckInterval), zap.Error(err))
+	}
+	if !e.healthy {
+		e.checkAt = b.now().Add(b.healthCheckInterval)
+	}
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix scrambles the bits of the given value, as in splitmix64.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,68 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancingprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+type RoutingPolicy string
+
+const (
+	// WeightedRouting sends every batch as a whole to an endpoint, the
+	// endpoints getting a share of the batches proportional to their weight.
+	WeightedRouting RoutingPolicy = "weighted"
+	// ConsistentRouting splits the batches by series, for the metrics, or by
+	// trace, for the spans, always sending the same series or trace to the same
+	// endpoint while the endpoints are healthy.
+	ConsistentRouting RoutingPolicy = "consistent"
+)
+
+// Endpoint is an endpoint the data is distributed to.
+type Endpoint struct {
+	// Name identifies the endpoint.
+	Name string `mapstructure:"name"`
+	// Exporters are the names of the exporters sending the data to the
+	// endpoint.
+	Exporters []string `mapstructure:"exporters"`
+	// Weight is the share of the data the endpoint gets relative to the other
+	// endpoints. 0 means 1.
+	Weight int `mapstructure:"weight"`
+}
+
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Endpoints are the endpoints the data is distributed to.
+	Endpoints []Endpoint `mapstructure:"endpoints"`
+	// Routing is how the data is distributed: "weighted" (the default) or
+	// "consistent".
+	Routing RoutingPolicy `mapstructure:"routing"`
+	// UnhealthyAfter is the number of failures in a row after which an
+	// endpoint is unhealthy and skipped. 0 means 3.
+	UnhealthyAfter int `mapstructure:"unhealthy_after"`
+	// HealthCheckInterval is how long an unhealthy endpoint is skipped before
+	// data is sent to it again to check whether it recovered. 0 means 30s.
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+}
+
+func (cfg *Config) RouteExporterNames() map[string][]string {
+	routes := make(map[string][]string, len(cfg.Endpoints))
+	for _, endpoint := range cfg.Endpoints {
+		routes[endpoint.Name] = endpoint.Exporters
+	}
+	return routes
+}
@@ -0,0 +1,277 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancingprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// newLoadBalancer validates the config and returns the balancer of its
+// endpoints along with the routing policy. hasRoute returns whether the named
+// endpoint has a consumer.
+func newLoadBalancer(logger *zap.Logger, cfg Config, hasRoute func(name string) bool) (*balancer, RoutingPolicy, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, "", errors.New("endpoints must list at least one endpoint")
+	}
+	names := make(map[string]bool, len(cfg.Endpoints))
+	for _, e := range cfg.Endpoints {
+		if e.Name == "" {
+			return nil, "", errors.New("endpoints must have a name")
+		}
+		if names[e.Name] {
+			return nil, "", fmt.Errorf("duplicate endpoint %q", e.Name)
+		}
+		names[e.Name] = true
+		if len(e.Exporters) == 0 {
+			return nil, "", fmt.Errorf("endpoint %q has no exporters", e.Name)
+		}
+		if e.Weight < 0 {
+			return nil, "", fmt.Errorf("weight of endpoint %q must not be negative, got %d", e.Name, e.Weight)
+		}
+		if !hasRoute(e.Name) {
+			return nil, "", fmt.Errorf("nil consumer for endpoint %q, the endpoint requires exporters", e.Name)
+		}
+	}
+
+	routing := cfg.Routing
+	switch routing {
+	case "":
+		routing = WeightedRouting
+	case WeightedRouting, ConsistentRouting:
+	default:
+		return nil, "", fmt.Errorf("unknown routing %q, must be either %q or %q",
+			cfg.Routing, WeightedRouting, ConsistentRouting)
+	}
+	if cfg.UnhealthyAfter < 0 {
+		return nil, "", fmt.Errorf("unhealthy_after must not be negative, got %d", cfg.UnhealthyAfter)
+	}
+	if cfg.HealthCheckInterval < 0 {
+		return nil, "", fmt.Errorf("health_check_interval must not be negative, got %v", cfg.HealthCheckInterval)
+	}
+
+	return newBalancer(logger, cfg), routing, nil
+}
+
+type traceLoadBalancingProcessor struct {
+	*balancer
+	routing RoutingPolicy
+	// routes are the consumers of the endpoints, in the order of the endpoints.
+	routes []consumer.TraceConsumer
+}
+
+var _ processor.TraceProcessor = (*traceLoadBalancingProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that distributes the
+// spans over the consumers of the endpoints, by weight. With the consistent
+// routing, the spans of a trace always go to the same endpoint while it is
+// healthy. The spans are not passed down the pipeline.
+func NewTraceProcessor(logger *zap.Logger, nextConsumer consumer.TraceConsumer, routes map[string]consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	b, routing, err := newLoadBalancer(logger, cfg, func(name string) bool { return routes[name] != nil })
+	if err != nil {
+		return nil, err
+	}
+	tlb := &traceLoadBalancingProcessor{
+		balancer: b,
+		routing:  routing,
+		routes:   make([]consumer.TraceConsumer, len(cfg.Endpoints)),
+	}
+	for i, e := range cfg.Endpoints {
+		tlb.routes[i] = routes[e.Name]
+	}
+	return tlb, nil
+}
+
+func (tlb *traceLoadBalancingProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	candidates := tlb.candidates()
+	if tlb.routing == WeightedRouting {
+		return tlb.export(tlb.next(candidates), candidates, func(i int) error {
+			return tlb.routes[i].ConsumeTraceData(ctx, td)
+		})
+	}
+
+	partitions := make([][]*tracepb.Span, len(tlb.routes))
+	for _, span := range td.Spans {
+		i := tlb.pick(candidates, hashBytes(span.GetTraceId()))
+		partitions[i] = append(partitions[i], span)
+	}
+	var errs []error
+	for i, spans := range partitions {
+		if len(spans) == 0 {
+			continue
+		}
+		partition := consumerdata.TraceData{
+			Node:         td.Node,
+			Resource:     td.Resource,
+			Spans:        spans,
+			SourceFormat: td.SourceFormat,
+		}
+		if err := tlb.export(i, candidates, func(i int) error {
+			return tlb.routes[i].ConsumeTraceData(ctx, partition)
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return oterr.CombineErrors(errs)
+}
+
+type metricsLoadBalancingProcessor struct {
+	*balancer
+	routing RoutingPolicy
+	// routes are the consumers of the endpoints, in the order of the endpoints.
+	routes []consumer.MetricsConsumer
+}
+
+var _ processor.MetricsProcessor = (*metricsLoadBalancingProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that distributes the
+// metrics over the consumers of the endpoints, by weight. With the consistent
+// routing, a series always goes to the same endpoint while it is healthy. The
+// metrics are not passed down the pipeline.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, routes map[string]consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	b, routing, err := newLoadBalancer(logger, cfg, func(name string) bool { return routes[name] != nil })
+	if err != nil {
+		return nil, err
+	}
+	mlb := &metricsLoadBalancingProcessor{
+		balancer: b,
+		routing:  routing,
+		routes:   make([]consumer.MetricsConsumer, len(cfg.Endpoints)),
+	}
+	for i, e := range cfg.Endpoints {
+		mlb.routes[i] = routes[e.Name]
+	}
+	return mlb, nil
+}
+
+func (mlb *metricsLoadBalancingProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	candidates := mlb.candidates()
+	if mlb.routing == WeightedRouting {
+		return mlb.export(mlb.next(candidates), candidates, func(i int) error {
+			return mlb.routes[i].ConsumeMetricsData(ctx, md)
+		})
+	}
+
+	var errs []error
+	for i, metrics := range mlb.partitionMetrics(md, candidates) {
+		if len(metrics) == 0 {
+			continue
+		}
+		partition := consumerdata.MetricsData{
+			Node:     md.Node,
+			Resource: md.Resource,
+			Metrics:  metrics,
+		}
+		if err := mlb.export(i, candidates, func(i int) error {
+			return mlb.routes[i].ConsumeMetricsData(ctx, partition)
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return oterr.CombineErrors(errs)
+}
+
+// partitionMetrics splits the metrics by the endpoint of their series, indexed
+// by endpoint. A metric whose series go to several endpoints is split in as
+// many copies.
+func (mlb *metricsLoadBalancingProcessor) partitionMetrics(md consumerdata.MetricsData, candidates []int) [][]*metricspb.Metric {
+	partitions := make([][]*metricspb.Metric, len(mlb.routes))
+	node := nodeKey(md.Node)
+	for _, metric := range md.Metrics {
+		if metric == nil {
+			continue
+		}
+		name := metric.GetMetricDescriptor().GetName()
+		if len(metric.Timeseries) == 0 {
+			i := mlb.pick(candidates, seriesHash(node, name, nil))
+			partitions[i] = append(partitions[i], metric)
+			continue
+		}
+
+		series := make([][]*metricspb.TimeSeries, len(mlb.routes))
+		split := false
+		first := -1
+		for _, ts := range metric.Timeseries {
+			i := mlb.pick(candidates, seriesHash(node, name, ts.GetLabelValues()))
+			if first < 0 {
+				first = i
+			} else if i != first {
+				split = true
+			}
+			series[i] = append(series[i], ts)
+		}
+		if !split {
+			partitions[first] = append(partitions[first], metric)
+			continue
+		}
+		for i, timeseries := range series {
+			if len(timeseries) == 0 {
+				continue
+			}
+			partitions[i] = append(partitions[i], &metricspb.Metric{
+				MetricDescriptor: metric.MetricDescriptor,
+				Resource:         metric.Resource,
+				Timeseries:       timeseries,
+			})
+		}
+	}
+	return partitions
+}
+
+// nodeKey identifies the node reporting the metrics, the same series reported
+// by several nodes being different series.
+func nodeKey(node *commonpb.Node) string {
+	return processor.ServiceNameForNode(node) + "\x00" + node.GetIdentifier().GetHostName()
+}
+
+func seriesHash(node, name string, labelValues []*metricspb.LabelValue) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(node))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+	for _, lv := range labelValues {
+		if lv.GetHasValue() {
+			h.Write([]byte{0, 1})
+		} else {
+			h.Write([]byte{0, 0})
+		}
+		h.Write([]byte(lv.GetValue()))
+	}
+	return h.Sum64()
+}
+
+func hashBytes(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64()
+}
@@ -0,0 +1,401 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancingprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+)
+
+// flakyConsumer is the consumer of an endpoint, failing while an error is set.
+type flakyConsumer struct {
+	exportertest.SinkTraceExporter
+	metrics exportertest.SinkMetricsExporter
+
+	mu       sync.Mutex
+	err      error
+	attempts int
+}
+
+func (fc *flakyConsumer) setErr(err error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.err = err
+}
+
+func (fc *flakyConsumer) attempt() error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.attempts++
+	return fc.err
+}
+
+func (fc *flakyConsumer) getAttempts() int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.attempts
+}
+
+func (fc *flakyConsumer) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	if err := fc.attempt(); err != nil {
+		return err
+	}
+	return fc.SinkTraceExporter.ConsumeTraceData(ctx, td)
+}
+
+func (fc *flakyConsumer) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	if err := fc.attempt(); err != nil {
+		return err
+	}
+	return fc.metrics.ConsumeMetricsData(ctx, md)
+}
+
+// fakeClock is the clock of the balancer in the tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newConfig(routing RoutingPolicy, endpoints ...Endpoint) Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		Endpoints:           endpoints,
+		Routing:             routing,
+		UnhealthyAfter:      2,
+		HealthCheckInterval: 10 * time.Second,
+	}
+}
+
+func newEndpoint(name string, weight int) Endpoint {
+	return Endpoint{Name: name, Exporters: []string{"exampleexporter/" + name}, Weight: weight}
+}
+
+// newTestMetricsProcessor returns a metrics processor over the given config
+// along with the consumers of its endpoints and its clock.
+func newTestMetricsProcessor(t *testing.T, cfg Config) (*metricsLoadBalancingProcessor, map[string]*flakyConsumer, *fakeClock) {
+	consumers := make(map[string]*flakyConsumer)
+	routes := make(map[string]consumer.MetricsConsumer)
+	for _, e := range cfg.Endpoints {
+		consumers[e.Name] = &flakyConsumer{}
+		routes[e.Name] = consumers[e.Name]
+	}
+	mp, err := NewMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), routes, cfg)
+	require.NoError(t, err)
+	mlb := mp.(*metricsLoadBalancingProcessor)
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	mlb.now = clock.Now
+	return mlb, consumers, clock
+}
+
+func newMetrics(name string, series int) consumerdata.MetricsData {
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Type:      metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys: []*metricspb.LabelKey{{Key: "id"}},
+		},
+	}
+	for i := 0; i < series; i++ {
+		metric.Timeseries = append(metric.Timeseries, &metricspb.TimeSeries{
+			LabelValues: []*metricspb.LabelValue{{Value: fmt.Sprintf("%d", i), HasValue: true}},
+		})
+	}
+	return consumerdata.MetricsData{
+		Node:    &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "svc"}},
+		Metrics: []*metricspb.Metric{metric},
+	}
+}
+
+// seriesIDs returns the ids of the series received by the consumer.
+func seriesIDs(fc *flakyConsumer) []string {
+	var ids []string
+	for _, md := range fc.metrics.AllMetrics() {
+		for _, metric := range md.Metrics {
+			for _, ts := range metric.Timeseries {
+				ids = append(ids, ts.LabelValues[0].Value)
+			}
+		}
+	}
+	return ids
+}
+
+func TestWeightedDistribution(t *testing.T) {
+	mlb, consumers, _ := newTestMetricsProcessor(t, newConfig(WeightedRouting,
+		newEndpoint("a", 3), newEndpoint("b", 1), newEndpoint("c", 0)))
+
+	var order []string
+	for i := 0; i < 500; i++ {
+		before := consumers["a"].getAttempts()
+		beforeB := consumers["b"].getAttempts()
+		require.NoError(t, mlb.ConsumeMetricsData(context.Background(), newMetrics("m", 1)))
+		if i < 10 {
+			switch {
+			case consumers["a"].getAttempts() > before:
+				order = append(order, "a")
+			case consumers["b"].getAttempts() > beforeB:
+				order = append(order, "b")
+			default:
+				order = append(order, "c")
+			}
+		}
+	}
+
+	assert.Equal(t, 300, len(consumers["a"].metrics.AllMetrics()))
+	assert.Equal(t, 100, len(consumers["b"].metrics.AllMetrics()))
+	assert.Equal(t, 100, len(consumers["c"].metrics.AllMetrics()), "a weight of 0 means 1")
+	// The batches of the heaviest endpoint are interleaved with the others.
+	assert.Equal(t, []string{"a", "b", "a", "c", "a", "a", "b", "a", "c", "a"}, order)
+}
+
+func TestUnhealthyEndpointSkippedUntilHealthy(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	mlb, consumers, clock := newTestMetricsProcessor(t, newConfig(WeightedRouting,
+		newEndpoint("a", 1), newEndpoint("b", 1)))
+	send := func() {
+		require.NoError(t, mlb.ConsumeMetricsData(context.Background(), newMetrics("m", 1)))
+	}
+
+	consumers["b"].setErr(errors.New("unavailable"))
+	for i := 0; i < 4; i++ {
+		send()
+	}
+	// b failed twice, the batches being exported by a instead, and is then
+	// skipped.
+	assert.Equal(t, 2, consumers["b"].getAttempts())
+	assert.Equal(t, 4, len(consumers["a"].metrics.AllMetrics()))
+
+	clock.Advance(9 * time.Second)
+	send()
+	assert.Equal(t, 2, consumers["b"].getAttempts(), "skipped until its health check")
+
+	// The health check fails, b stays unhealthy.
+	clock.Advance(time.Second)
+	send()
+	send()
+	assert.Equal(t, 3, consumers["b"].getAttempts())
+	assert.Equal(t, 7, len(consumers["a"].metrics.AllMetrics()))
+
+	// b recovered, the next health check restores it.
+	consumers["b"].setErr(nil)
+	clock.Advance(5 * time.Second)
+	send()
+	assert.Equal(t, 3, consumers["b"].getAttempts())
+	clock.Advance(5 * time.Second)
+	send()
+	assert.Equal(t, 1, len(consumers["b"].metrics.AllMetrics()))
+	for i := 0; i < 4; i++ {
+		send()
+	}
+	assert.Equal(t, 3, len(consumers["b"].metrics.AllMetrics()))
+	assert.Equal(t, 10, len(consumers["a"].metrics.AllMetrics()))
+
+	rows, err := view.RetrieveData(statEndpointFailures.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(3), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestAllEndpointsUnhealthy(t *testing.T) {
+	mlb, consumers, _ := newTestMetricsProcessor(t, newConfig(WeightedRouting,
+		newEndpoint("a", 1), newEndpoint("b", 1)))
+	consumers["a"].setErr(errors.New("unavailable"))
+	consumers["b"].setErr(errors.New("unavailable"))
+
+	for i := 0; i < 3; i++ {
+		assert.EqualError(t, mlb.ConsumeMetricsData(context.Background(), newMetrics("m", 1)), "unavailable")
+	}
+	// Both are unhealthy after the second batch, they are still tried rather
+	// than dropping the data.
+	assert.Equal(t, 3, consumers["a"].getAttempts())
+	assert.Equal(t, 3, consumers["b"].getAttempts())
+}
+
+func TestPermanentErrorNotRetried(t *testing.T) {
+	mlb, consumers, _ := newTestMetricsProcessor(t, newConfig(WeightedRouting,
+		newEndpoint("a", 1), newEndpoint("b", 1)))
+	consumers["a"].setErr(consumererror.Permanent(errors.New("bad data")))
+
+	// The batches alternate between a and b, the data failing permanently on a
+	// is not retried on b and a stays healthy.
+	for i := 0; i < 4; i++ {
+		err := mlb.ConsumeMetricsData(context.Background(), newMetrics("m", 1))
+		if i%2 == 0 {
+			assert.True(t, consumererror.IsPermanent(err))
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, 2, consumers["a"].getAttempts())
+	assert.Equal(t, 2, consumers["b"].getAttempts())
+}
+
+func TestConsistentMetricsRouting(t *testing.T) {
+	mlb, consumers, clock := newTestMetricsProcessor(t, newConfig(ConsistentRouting,
+		newEndpoint("a", 1), newEndpoint("b", 1)))
+
+	md := newMetrics("m", 100)
+	require.NoError(t, mlb.ConsumeMetricsData(context.Background(), md))
+	require.NoError(t, mlb.ConsumeMetricsData(context.Background(), md))
+	assert.Len(t, md.Metrics[0].Timeseries, 100, "the metric must not be modified")
+
+	aIDs := seriesIDs(consumers["a"])
+	bIDs := seriesIDs(consumers["b"])
+	assert.Len(t, append(aIDs, bIDs...), 200)
+	assert.True(t, len(aIDs) > 60 && len(bIDs) > 60, "got %d and %d series", len(aIDs), len(bIDs))
+	// Every series went to the same endpoint both times.
+	half := len(aIDs) / 2
+	assert.Equal(t, aIDs[:half], aIDs[half:])
+	bSeries := make(map[string]bool)
+	for _, id := range bIDs {
+		bSeries[id] = true
+	}
+	for _, id := range aIDs {
+		assert.False(t, bSeries[id], "series %s sent to both endpoints", id)
+	}
+
+	// b is unhealthy, its series move to a.
+	consumers["b"].setErr(errors.New("unavailable"))
+	require.NoError(t, mlb.ConsumeMetricsData(context.Background(), md))
+	require.NoError(t, mlb.ConsumeMetricsData(context.Background(), md))
+	require.NoError(t, mlb.ConsumeMetricsData(context.Background(), md))
+	assert.Len(t, seriesIDs(consumers["a"]), len(aIDs)+300)
+
+	// b is restored, the series of a stay on a.
+	consumers["b"].setErr(nil)
+	clock.Advance(10 * time.Second)
+	require.NoError(t, mlb.ConsumeMetricsData(context.Background(), md))
+	assert.Len(t, seriesIDs(consumers["a"]), len(aIDs)+300+half)
+	assert.Len(t, seriesIDs(consumers["b"]), len(bIDs)+len(bIDs)/2)
+}
+
+func TestConsistentTraceRouting(t *testing.T) {
+	consumers := map[string]*flakyConsumer{"a": {}, "b": {}, "c": {}}
+	routes := map[string]consumer.TraceConsumer{"a": consumers["a"], "b": consumers["b"], "c": consumers["c"]}
+	tp, err := NewTraceProcessor(zap.NewNop(), exportertest.NewNopTraceExporter(), routes, newConfig(ConsistentRouting,
+		newEndpoint("a", 1), newEndpoint("b", 1), newEndpoint("c", 1)))
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		td := consumerdata.TraceData{}
+		for trace := 0; trace < 10; trace++ {
+			td.Spans = append(td.Spans, &tracepb.Span{
+				TraceId: []byte{byte(trace), 1, 2, 3},
+				SpanId:  []byte{byte(i)},
+			})
+		}
+		require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	}
+
+	endpoints := make(map[byte]string)
+	for name, fc := range consumers {
+		for _, td := range fc.AllTraces() {
+			for _, span := range td.Spans {
+				trace := span.TraceId[0]
+				if endpoint, ok := endpoints[trace]; ok {
+					assert.Equal(t, endpoint, name, "trace %d sent to several endpoints", trace)
+				}
+				endpoints[trace] = name
+			}
+		}
+	}
+	assert.Len(t, endpoints, 10)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			name:    "no endpoints",
+			cfg:     newConfig(WeightedRouting),
+			wantErr: "endpoints must list at least one endpoint",
+		},
+		{
+			name:    "no name",
+			cfg:     newConfig(WeightedRouting, Endpoint{Exporters: []string{"exampleexporter"}}),
+			wantErr: "endpoints must have a name",
+		},
+		{
+			name:    "duplicate",
+			cfg:     newConfig(WeightedRouting, newEndpoint("a", 1), newEndpoint("a", 2)),
+			wantErr: `duplicate endpoint "a"`,
+		},
+		{
+			name:    "no exporters",
+			cfg:     newConfig(WeightedRouting, Endpoint{Name: "a"}),
+			wantErr: `endpoint "a" has no exporters`,
+		},
+		{
+			name:    "negative weight",
+			cfg:     newConfig(WeightedRouting, newEndpoint("a", -1)),
+			wantErr: `weight of endpoint "a" must not be negative, got -1`,
+		},
+		{
+			name:    "no consumer",
+			cfg:     newConfig(WeightedRouting, newEndpoint("unknown", 1)),
+			wantErr: `nil consumer for endpoint "unknown", the endpoint requires exporters`,
+		},
+		{
+			name:    "unknown routing",
+			cfg:     newConfig("random", newEndpoint("a", 1)),
+			wantErr: `unknown routing "random", must be either "weighted" or "consistent"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := map[string]consumer.MetricsConsumer{"a": exportertest.NewNopMetricsExporter()}
+			mp, err := NewMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), routes, tt.cfg)
+			assert.Nil(t, mp)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := NewMetricsProcessor(zap.NewNop(), nil, nil, newConfig(WeightedRouting, newEndpoint("a", 1)))
+	assert.Error(t, err)
+}
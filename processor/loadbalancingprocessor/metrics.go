@@ -0,0 +1,46 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancingprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	tagEndpointKey, _ = tag.NewKey("endpoint")
+
+	statEndpointFailures = stats.Int64("load_balancing_endpoint_failures", "Number of batches an endpoint failed to export", stats.UnitDimensionless)
+)
+
+// MetricViews returns the metrics views related to the load balancing.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	failuresView := &view.View{
+		Name:        statEndpointFailures.Name(),
+		Measure:     statEndpointFailures,
+		Description: statEndpointFailures.Description(),
+		TagKeys:     []tag.Key{processor.TagExporterNameKey, tagEndpointKey},
+		Aggregation: view.Sum(),
+	}
+	return []*view.View{failuresView}
+}
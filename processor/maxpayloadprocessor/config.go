@@ -0,0 +1,40 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maxpayloadprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Policy defines what is done with a batch over the maximum size.
+type Policy string
+
+const (
+	// SplitPolicy sends an oversized batch in several batches under the
+	// maximum size.
+	SplitPolicy Policy = "split"
+	// DropPolicy sends the spans or metrics of an oversized batch within the
+	// maximum size and drops the others.
+	DropPolicy Policy = "drop"
+)
+
+// Config defines configuration for the max payload processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// MaxBytes is the maximum estimated serialized size of a batch. Defaults
+	// to 4MiB.
+	MaxBytes int `mapstructure:"max_bytes"`
+	// Policy is what is done with the batches over the maximum size, either
+	// "split" or "drop". Defaults to split.
+	Policy Policy `mapstructure:"policy"`
+}
@@ -0,0 +1,290 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maxpayloadprocessor
+
+import (
+	"context"
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/golang/protobuf/proto"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// fieldOverhead approximates the size of the tag and length prefix of an
+// embedded message, generous enough for the messages up to 256MiB.
+const fieldOverhead = 6
+
+type maxPayloadProcessor struct {
+	name      string
+	logger    *zap.Logger
+	maxBytes  int
+	policy    Policy
+	statsTags []tag.Mutator
+}
+
+type tracePayloadProcessor struct {
+	maxPayloadProcessor
+	nextConsumer consumer.TraceConsumer
+}
+
+type metricsPayloadProcessor struct {
+	maxPayloadProcessor
+	nextConsumer consumer.MetricsConsumer
+}
+
+var _ processor.TraceProcessor = (*tracePayloadProcessor)(nil)
+var _ processor.MetricsProcessor = (*metricsPayloadProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that keeps the batches
+// under the configured maximum size, splitting them or dropping their spans
+// beyond it according to the policy.
+func NewTraceProcessor(logger *zap.Logger, nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	mpp, err := newMaxPayloadProcessor(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &tracePayloadProcessor{maxPayloadProcessor: mpp, nextConsumer: nextConsumer}, nil
+}
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that keeps the
+// batches under the configured maximum size, splitting them or dropping their
+// metrics beyond it according to the policy.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	mpp, err := newMaxPayloadProcessor(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsPayloadProcessor{maxPayloadProcessor: mpp, nextConsumer: nextConsumer}, nil
+}
+
+func newMaxPayloadProcessor(logger *zap.Logger, cfg Config) (maxPayloadProcessor, error) {
+	if cfg.MaxBytes <= 0 {
+		return maxPayloadProcessor{}, fmt.Errorf("max_bytes must be positive, got %d", cfg.MaxBytes)
+	}
+	policy := cfg.Policy
+	switch policy {
+	case "":
+		policy = SplitPolicy
+	case SplitPolicy, DropPolicy:
+	default:
+		return maxPayloadProcessor{}, fmt.Errorf("unknown policy %q, must be either %q or %q",
+			cfg.Policy, SplitPolicy, DropPolicy)
+	}
+
+	return maxPayloadProcessor{
+		name:      cfg.Name(),
+		logger:    logger,
+		maxBytes:  cfg.MaxBytes,
+		policy:    policy,
+		statsTags: []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (tpp *tracePayloadProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	overhead := batchOverhead(td.Node, td.Resource)
+	sizes := make([]int, len(td.Spans))
+	total := overhead
+	for i, span := range td.Spans {
+		sizes[i] = messageSize(span)
+		total += sizes[i]
+	}
+	if total <= tpp.maxBytes {
+		return tpp.nextConsumer.ConsumeTraceData(ctx, td)
+	}
+
+	if tpp.policy == DropPolicy {
+		kept := keptWithin(sizes, tpp.maxBytes-overhead)
+		tpp.recordDropped(len(td.Spans) - kept)
+		if kept == 0 {
+			return nil
+		}
+		// Cap the slice, appending to it must not overwrite the received spans.
+		td.Spans = td.Spans[:kept:kept]
+		return tpp.nextConsumer.ConsumeTraceData(ctx, td)
+	}
+
+	batches := pack(sizes, tpp.maxBytes-overhead)
+	tpp.recordSplit(len(batches))
+	var errs []error
+	for _, batch := range batches {
+		split := td
+		split.Spans = make([]*tracepb.Span, batch.end-batch.start)
+		copy(split.Spans, td.Spans[batch.start:batch.end])
+		if err := tpp.nextConsumer.ConsumeTraceData(ctx, split); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return oterr.CombineErrors(errs)
+}
+
+func (mpp *metricsPayloadProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	overhead := batchOverhead(md.Node, md.Resource)
+	sizes := make([]int, len(md.Metrics))
+	total := overhead
+	for i, metric := range md.Metrics {
+		sizes[i] = messageSize(metric)
+		total += sizes[i]
+	}
+	if total <= mpp.maxBytes {
+		return mpp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	if mpp.policy == DropPolicy {
+		kept := keptWithin(sizes, mpp.maxBytes-overhead)
+		dropped := 0
+		for _, metric := range md.Metrics[kept:] {
+			dropped += len(metric.GetTimeseries())
+		}
+		mpp.recordDropped(dropped)
+		if kept == 0 {
+			return nil
+		}
+		// Cap the slice, appending to it must not overwrite the received metrics.
+		md.Metrics = md.Metrics[:kept:kept]
+		return mpp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	// The metrics too large on their own are sent in parts holding some of
+	// their timeseries.
+	budget := mpp.maxBytes - overhead
+	metrics := make([]*metricspb.Metric, 0, len(md.Metrics))
+	metricSizes := make([]int, 0, len(md.Metrics))
+	for i, metric := range md.Metrics {
+		if sizes[i] <= budget || len(metric.GetTimeseries()) <= 1 {
+			metrics = append(metrics, metric)
+			metricSizes = append(metricSizes, sizes[i])
+			continue
+		}
+		parts, partSizes := splitMetric(metric, budget)
+		metrics = append(metrics, parts...)
+		metricSizes = append(metricSizes, partSizes...)
+	}
+
+	batches := pack(metricSizes, budget)
+	mpp.recordSplit(len(batches))
+	var errs []error
+	for _, batch := range batches {
+		split := md
+		split.Metrics = make([]*metricspb.Metric, batch.end-batch.start)
+		copy(split.Metrics, metrics[batch.start:batch.end])
+		if err := mpp.nextConsumer.ConsumeMetricsData(ctx, split); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return oterr.CombineErrors(errs)
+}
+
+func (mpp *maxPayloadProcessor) recordSplit(batches int) {
+	mpp.logger.Debug("Splitting oversized batch",
+		zap.String("processor", mpp.name),
+		zap.Int("batches", batches))
+	stats.RecordWithTags(context.Background(), mpp.statsTags, statSplitBatches.M(1))
+}
+
+func (mpp *maxPayloadProcessor) recordDropped(items int) {
+	mpp.logger.Debug("Dropping the overflow of an oversized batch",
+		zap.String("processor", mpp.name),
+		zap.Int("items", items))
+	stats.RecordWithTags(context.Background(), mpp.statsTags, statDroppedItems.M(int64(items)))
+}
+
+// splitMetric returns copies of the metric holding consecutive timeseries of
+// it, each within the budget unless it holds a single timeseries, along with
+// their estimated sizes.
+func splitMetric(metric *metricspb.Metric, budget int) ([]*metricspb.Metric, []int) {
+	header := *metric
+	header.Timeseries = nil
+	headerSize := messageSize(&header)
+
+	sizes := make([]int, len(metric.Timeseries))
+	for i, ts := range metric.Timeseries {
+		sizes[i] = messageSize(ts)
+	}
+
+	var parts []*metricspb.Metric
+	var partSizes []int
+	for _, batch := range pack(sizes, budget-headerSize) {
+		part := header
+		part.Timeseries = make([]*metricspb.TimeSeries, batch.end-batch.start)
+		copy(part.Timeseries, metric.Timeseries[batch.start:batch.end])
+		parts = append(parts, &part)
+		partSizes = append(partSizes, headerSize+batch.size)
+	}
+	return parts, partSizes
+}
+
+// batch is a range of items whose estimated sizes add up to size.
+type batch struct {
+	start, end int
+	size       int
+}
+
+// pack groups the consecutive items into batches within the budget. An item
+// over the budget on its own is sent in its own batch.
+func pack(sizes []int, budget int) []batch {
+	var batches []batch
+	current := batch{}
+	for i, size := range sizes {
+		if current.end > current.start && current.size+size > budget {
+			batches = append(batches, current)
+			current = batch{start: i, end: i}
+		}
+		current.end = i + 1
+		current.size += size
+	}
+	if current.end > current.start {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// keptWithin returns the number of leading items whose estimated sizes add
+// up to at most the budget.
+func keptWithin(sizes []int, budget int) int {
+	total := 0
+	for i, size := range sizes {
+		total += size
+		if total > budget {
+			return i
+		}
+	}
+	return len(sizes)
+}
+
+// batchOverhead estimates the size of a batch without its spans or metrics.
+func batchOverhead(node, resource proto.Message) int {
+	return messageSize(node) + messageSize(resource)
+}
+
+// messageSize estimates the serialized size of the message, as a field of
+// its parent message. proto.Size computes it without serializing it.
+func messageSize(msg proto.Message) int {
+	return proto.Size(msg) + fieldOverhead
+}
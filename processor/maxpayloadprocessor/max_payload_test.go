@@ -0,0 +1,214 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maxpayloadprocessor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+var testNode = &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "checkout"}}
+
+func TestNewProcessorNilNext(t *testing.T) {
+	tp, err := NewTraceProcessor(zap.NewNop(), nil, Config{MaxBytes: 1})
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	mp, err := NewMetricsProcessor(zap.NewNop(), nil, Config{MaxBytes: 1})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestBatchWithinLimit(t *testing.T) {
+	sink := new(exportertest.SinkTraceExporter)
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, Config{MaxBytes: 1 << 20})
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{Node: testNode, Spans: []*tracepb.Span{span(1), span(2)}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	assert.Equal(t, []consumerdata.TraceData{td}, sink.AllTraces())
+}
+
+func TestSplitTraceBatch(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	spans := []*tracepb.Span{span(1), span(2), span(3), span(4), span(5)}
+	// Room for two spans per batch.
+	maxBytes := batchOverhead(testNode, nil) + 2*messageSize(spans[0]) + 10
+	sink := new(exportertest.SinkTraceExporter)
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "max_payload/split"},
+		MaxBytes:          maxBytes,
+	}
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{Node: testNode, SourceFormat: "test", Spans: spans}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	batches := sink.AllTraces()
+	require.Len(t, batches, 3)
+	assert.Equal(t, spans[0:2], batches[0].Spans)
+	assert.Equal(t, spans[2:4], batches[1].Spans)
+	assert.Equal(t, spans[4:], batches[2].Spans)
+	for _, batch := range batches {
+		assert.Equal(t, testNode, batch.Node)
+		assert.Equal(t, "test", batch.SourceFormat)
+	}
+
+	rows, err := view.RetrieveData(statSplitBatches.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestDropTraceOverflow(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	spans := []*tracepb.Span{span(1), span(2), span(3), span(4), span(5)}
+	maxBytes := batchOverhead(testNode, nil) + 2*messageSize(spans[0]) + 10
+	sink := new(exportertest.SinkTraceExporter)
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "max_payload/drop"},
+		MaxBytes:          maxBytes,
+		Policy:            DropPolicy,
+	}
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{Node: testNode, Spans: spans}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	batches := sink.AllTraces()
+	require.Len(t, batches, 1)
+	assert.Equal(t, spans[0:2], batches[0].Spans)
+	// The received batch is shared with other pipelines, it is not modified.
+	assert.Len(t, td.Spans, 5)
+
+	rows, err := view.RetrieveData(statDroppedItems.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(3), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestSplitMetricsBatch(t *testing.T) {
+	small := metric("small", 1)
+	large := metric("large", 6)
+	// Room for the small metric and two timeseries of the large one.
+	header := *large
+	header.Timeseries = nil
+	maxBytes := batchOverhead(testNode, nil) + messageSize(&header) + 2*messageSize(large.Timeseries[0]) + 10
+	require.True(t, messageSize(small) < messageSize(&header)+2*messageSize(large.Timeseries[0]))
+
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{MaxBytes: maxBytes})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Node: testNode, Metrics: []*metricspb.Metric{small, large}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	batches := sink.AllMetrics()
+	require.Len(t, batches, 4)
+	assert.Equal(t, []*metricspb.Metric{small}, batches[0].Metrics)
+	for i, batch := range batches[1:] {
+		assert.Equal(t, testNode, batch.Node)
+		require.Len(t, batch.Metrics, 1)
+		assert.Equal(t, large.MetricDescriptor, batch.Metrics[0].MetricDescriptor)
+		assert.Equal(t, large.Timeseries[2*i:2*i+2], batch.Metrics[0].Timeseries)
+	}
+	// The received metric is shared with other pipelines, it is not modified.
+	assert.Len(t, large.Timeseries, 6)
+}
+
+func TestDropMetricsOverflow(t *testing.T) {
+	first := metric("first", 2)
+	second := metric("second", 3)
+	maxBytes := batchOverhead(testNode, nil) + messageSize(first) + 10
+
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{MaxBytes: maxBytes, Policy: DropPolicy})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Node: testNode, Metrics: []*metricspb.Metric{first, second}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	batches := sink.AllMetrics()
+	require.Len(t, batches, 1)
+	assert.Equal(t, []*metricspb.Metric{first}, batches[0].Metrics)
+}
+
+func TestPack(t *testing.T) {
+	tests := []struct {
+		name   string
+		sizes  []int
+		budget int
+		want   []batch
+	}{
+		{name: "empty", budget: 10},
+		{name: "fits", sizes: []int{3, 3, 3}, budget: 10, want: []batch{{0, 3, 9}}},
+		{name: "split", sizes: []int{4, 4, 4}, budget: 10, want: []batch{{0, 2, 8}, {2, 3, 4}}},
+		{name: "oversized_item", sizes: []int{4, 20, 4}, budget: 10, want: []batch{{0, 1, 4}, {1, 2, 20}, {2, 3, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pack(tt.sizes, tt.budget))
+		})
+	}
+}
+
+func span(id byte) *tracepb.Span {
+	return &tracepb.Span{
+		TraceId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanId:  []byte{0, 0, 0, 0, 0, 0, 0, id},
+		Name:    &tracepb.TruncatableString{Value: strings.Repeat("x", 100)},
+	}
+}
+
+func metric(name string, numTimeseries int) *metricspb.Metric {
+	m := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+			LabelKeys: []*metricspb.LabelKey{{Key: "path"}},
+		},
+	}
+	for i := 0; i < numTimeseries; i++ {
+		m.Timeseries = append(m.Timeseries, &metricspb.TimeSeries{
+			LabelValues: []*metricspb.LabelValue{{Value: strings.Repeat("p", 50+i%2), HasValue: true}},
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: int64(i)}}},
+		})
+	}
+	return m
+}
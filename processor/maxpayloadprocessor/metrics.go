@@ -0,0 +1,54 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maxpayloadprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	statSplitBatches = stats.Int64("max_payload_split_batches", "Number of batches split because of their estimated size", stats.UnitDimensionless)
+	statDroppedItems = stats.Int64("max_payload_dropped_items", "Number of spans and timeseries dropped because of the estimated size of their batch", stats.UnitDimensionless)
+)
+
+// MetricViews returns the metrics views related to oversized batches.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	tagKeys := []tag.Key{processor.TagExporterNameKey}
+	splitBatchesView := &view.View{
+		Name:        statSplitBatches.Name(),
+		Measure:     statSplitBatches,
+		Description: statSplitBatches.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	droppedItemsView := &view.View{
+		Name:        statDroppedItems.Name(),
+		Measure:     statDroppedItems,
+		Description: statDroppedItems.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	return []*view.View{splitBatchesView, droppedItemsView}
+}
@@ -0,0 +1,50 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mergeprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// MergePolicy defines how the points of a series with the same timestamp are
+// merged.
+type MergePolicy string
+
+const (
+	// Last keeps the point received last.
+	Last MergePolicy = "last"
+	// Sum adds the values of the points.
+	Sum MergePolicy = "sum"
+	// Error keeps the point received first and reports the others as an
+	// error to the sender.
+	Error MergePolicy = "error"
+)
+
+// Config defines configuration for the merge processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Window is how long the points are held, waiting for the points of the
+	// same series and timestamp to merge with, before being sent. Defaults to
+	// 1s.
+	Window time.Duration `mapstructure:"window"`
+	// Policy is how the points are merged, either "last", "sum" or "error".
+	// Defaults to "last".
+	Policy MergePolicy `mapstructure:"policy"`
+	// MaxPoints is the maximum number of points held, the points are sent
+	// early when it is reached. Defaults to 10000.
+	MaxPoints int `mapstructure:"max_points"`
+}
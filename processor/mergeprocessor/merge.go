@@ -0,0 +1,181 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mergeprocessor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type mergeProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	window       time.Duration
+	policy       MergePolicy
+	maxPoints    int
+	statsTags    []tag.Mutator
+
+	mu sync.Mutex
+	// pending holds the points of the current window, nil if there are none.
+	pending *pendingData
+	timer   *time.Timer
+	stopped bool
+}
+
+var _ processor.MetricsProcessor = (*mergeProcessor)(nil)
+var _ processor.Shutdowner = (*mergeProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that holds the
+// points it receives for the configured window, merging the points of the same
+// series and timestamp according to the configured policy, before sending
+// them. The points held are sent on shutdown.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if cfg.Window <= 0 {
+		return nil, fmt.Errorf("window must be positive, got %v", cfg.Window)
+	}
+
+	policy := cfg.Policy
+	if policy == "" {
+		policy = Last
+	}
+	if policy != Last && policy != Sum && policy != Error {
+		return nil, fmt.Errorf("unknown policy %q, must be %q, %q or %q", cfg.Policy, Last, Sum, Error)
+	}
+
+	maxPoints := cfg.MaxPoints
+	if maxPoints == 0 {
+		maxPoints = defaultMaxPoints
+	}
+	if maxPoints < 0 {
+		return nil, fmt.Errorf("max_points must be positive, got %d", cfg.MaxPoints)
+	}
+
+	return &mergeProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		window:       cfg.Window,
+		policy:       policy,
+		maxPoints:    maxPoints,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (mp *mergeProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	mp.mu.Lock()
+	if mp.stopped {
+		mp.mu.Unlock()
+		return mp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+	if mp.pending == nil {
+		pending := newPendingData()
+		mp.pending = pending
+		mp.timer = time.AfterFunc(mp.window, func() { mp.flush(pending) })
+	}
+	merged, rejected := mp.pending.add(md, mp.policy)
+	var full *pendingData
+	if mp.pending.numPoints >= mp.maxPoints {
+		full = mp.takePending()
+	}
+	mp.mu.Unlock()
+
+	if merged+rejected > 0 {
+		mp.logger.Debug("Merged points of the same series and timestamp",
+			zap.String("processor", mp.name),
+			zap.String("policy", string(mp.policy)),
+			zap.Int("points", merged+rejected))
+		stats.RecordWithTags(context.Background(), mp.statsTags, statMergedPoints.M(int64(merged+rejected)))
+	}
+	if full != nil {
+		mp.send(full)
+	}
+	if rejected > 0 {
+		return fmt.Errorf("rejected %d points of series already having a point with the same timestamp", rejected)
+	}
+	return nil
+}
+
+// Shutdown sends the points held and lets the data received afterwards
+// through as is.
+func (mp *mergeProcessor) Shutdown() error {
+	mp.mu.Lock()
+	mp.stopped = true
+	pending := mp.takePending()
+	mp.mu.Unlock()
+
+	if pending == nil {
+		return nil
+	}
+	return mp.send(pending)
+}
+
+// takePending returns the points of the current window and ends it. The lock
+// must be held.
+func (mp *mergeProcessor) takePending() *pendingData {
+	pending := mp.pending
+	mp.pending = nil
+	if mp.timer != nil {
+		mp.timer.Stop()
+		mp.timer = nil
+	}
+	return pending
+}
+
+// flush sends the points of the given window at its end, unless they were
+// already sent.
+func (mp *mergeProcessor) flush(pending *pendingData) {
+	mp.mu.Lock()
+	if mp.pending != pending {
+		mp.mu.Unlock()
+		return
+	}
+	mp.takePending()
+	mp.mu.Unlock()
+
+	mp.send(pending)
+}
+
+// send sends the points held, the errors being logged as the data was
+// received before.
+func (mp *mergeProcessor) send(pending *pendingData) error {
+	var errs []error
+	for _, md := range pending.metricsData() {
+		if err := mp.nextConsumer.ConsumeMetricsData(context.Background(), md); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := oterr.CombineErrors(errs)
+	if err != nil {
+		mp.logger.Warn("Failed to send the merged points",
+			zap.String("processor", mp.name),
+			zap.Error(err))
+	}
+	return err
+}
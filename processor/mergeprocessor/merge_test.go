@@ -0,0 +1,221 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mergeprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func newConfig(policy MergePolicy) Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		// Long enough for the tests to control the sending with Shutdown.
+		Window:    time.Hour,
+		Policy:    policy,
+		MaxPoints: defaultMaxPoints,
+	}
+}
+
+var node = &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "svc"}}
+
+// requests returns a batch with a point of the given value at the given
+// second for each of the given hosts.
+func requests(second int64, values map[string]float64) consumerdata.MetricsData {
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "requests",
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_DOUBLE,
+			LabelKeys: []*metricspb.LabelKey{{Key: "host"}},
+		},
+	}
+	for _, host := range []string{"a", "b"} {
+		value, ok := values[host]
+		if !ok {
+			continue
+		}
+		metric.Timeseries = append(metric.Timeseries, &metricspb.TimeSeries{
+			LabelValues: []*metricspb.LabelValue{{Value: host, HasValue: true}},
+			Points: []*metricspb.Point{{
+				Timestamp: &timestamp.Timestamp{Seconds: second},
+				Value:     &metricspb.Point_DoubleValue{DoubleValue: value},
+			}},
+		})
+	}
+	return consumerdata.MetricsData{Node: node, Metrics: []*metricspb.Metric{metric}}
+}
+
+// sentValues returns the values sent, keyed by host and second.
+func sentValues(t *testing.T, sink *exportertest.SinkMetricsExporter) map[string]map[int64]float64 {
+	values := make(map[string]map[int64]float64)
+	for _, md := range sink.AllMetrics() {
+		for _, metric := range md.Metrics {
+			for _, ts := range metric.Timeseries {
+				host := ts.LabelValues[0].Value
+				if values[host] == nil {
+					values[host] = make(map[int64]float64)
+				}
+				for _, point := range ts.Points {
+					_, dup := values[host][point.Timestamp.Seconds]
+					require.False(t, dup, "duplicate point sent for host %s at %ds", host, point.Timestamp.Seconds)
+					values[host][point.Timestamp.Seconds] = point.GetDoubleValue()
+				}
+			}
+		}
+	}
+	return values
+}
+
+func TestNewMetricsProcessor(t *testing.T) {
+	_, err := NewMetricsProcessor(zap.NewNop(), nil, newConfig(Last))
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	cfg := newConfig(Last)
+	cfg.Window = 0
+	_, err = NewMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), cfg)
+	assert.Error(t, err)
+}
+
+func TestMergePolicies(t *testing.T) {
+	tests := []struct {
+		policy  MergePolicy
+		wantA   float64
+		wantErr bool
+	}{
+		{policy: Last, wantA: 5},
+		{policy: Sum, wantA: 8},
+		{policy: Error, wantA: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			sink := new(exportertest.SinkMetricsExporter)
+			mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig(tt.policy))
+			require.NoError(t, err)
+
+			// The same series of host a arrives from two paths.
+			require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(10, map[string]float64{"a": 3, "b": 1})))
+			err = mp.ConsumeMetricsData(context.Background(), requests(10, map[string]float64{"a": 5}))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			// A point of another timestamp is not merged.
+			require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(20, map[string]float64{"a": 7})))
+			assert.Empty(t, sink.AllMetrics(), "points sent before the end of the window")
+
+			require.NoError(t, mp.(*mergeProcessor).Shutdown())
+			// The points of the same node are sent as a single batch.
+			require.Len(t, sink.AllMetrics(), 1)
+			assert.Equal(t, map[string]map[int64]float64{
+				"a": {10: tt.wantA, 20: 7},
+				"b": {10: 1},
+			}, sentValues(t, sink))
+		})
+	}
+}
+
+func TestSumKeepsReceivedPoints(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig(Sum))
+	require.NoError(t, err)
+
+	first := requests(10, map[string]float64{"a": 3})
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), first))
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(10, map[string]float64{"a": 5})))
+	require.NoError(t, mp.(*mergeProcessor).Shutdown())
+
+	// The received points may be shared with other pipelines, they are left as is.
+	assert.Equal(t, 3.0, first.Metrics[0].Timeseries[0].Points[0].GetDoubleValue())
+	assert.Equal(t, 8.0, sentValues(t, sink)["a"][10])
+}
+
+func TestWindowEnd(t *testing.T) {
+	cfg := newConfig(Last)
+	cfg.Window = 10 * time.Millisecond
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(10, map[string]float64{"a": 3})))
+	require.Eventually(t, func() bool { return len(sink.AllMetrics()) == 1 }, 5*time.Second, 5*time.Millisecond)
+
+	// The next window starts with the next batch.
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(10, map[string]float64{"a": 5})))
+	require.Eventually(t, func() bool { return len(sink.AllMetrics()) == 2 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 5.0, sink.AllMetrics()[1].Metrics[0].Timeseries[0].Points[0].GetDoubleValue())
+}
+
+func TestMaxPoints(t *testing.T) {
+	cfg := newConfig(Last)
+	cfg.MaxPoints = 3
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(10, map[string]float64{"a": 3, "b": 1})))
+	// A merged point does not count.
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(10, map[string]float64{"a": 4})))
+	assert.Empty(t, sink.AllMetrics())
+
+	// The third point held sends the points early.
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(20, map[string]float64{"a": 5})))
+	require.Len(t, sink.AllMetrics(), 1)
+	assert.Equal(t, map[string]map[int64]float64{
+		"a": {10: 4, 20: 5},
+		"b": {10: 1},
+	}, sentValues(t, sink))
+}
+
+func TestShutdown(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, newConfig(Last))
+	require.NoError(t, err)
+
+	otherNode := &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "other"}}
+	other := requests(10, map[string]float64{"a": 2})
+	other.Node = otherNode
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), requests(10, map[string]float64{"a": 3})))
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), other))
+
+	require.NoError(t, mp.(*mergeProcessor).Shutdown())
+	// The points of each node are sent in their own batch.
+	all := sink.AllMetrics()
+	require.Len(t, all, 2)
+	assert.Equal(t, node, all[0].Node)
+	assert.Equal(t, otherNode, all[1].Node)
+
+	// The data received after the shutdown goes through as is.
+	late := requests(20, map[string]float64{"a": 5})
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), late))
+	require.Len(t, sink.AllMetrics(), 3)
+	assert.Same(t, late.Metrics[0], sink.AllMetrics()[2].Metrics[0])
+}
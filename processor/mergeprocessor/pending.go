@@ -0,0 +1,213 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mergeprocessor
+
+import (
+	"strconv"
+	"strings"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+)
+
+// pendingData holds the points waiting to be sent, grouped by node and
+// resource, metric and series in the order they were first received.
+type pendingData struct {
+	batches   []*pendingBatch
+	byKey     map[string]*pendingBatch
+	numPoints int
+}
+
+type pendingBatch struct {
+	node     *commonpb.Node
+	resource *resourcepb.Resource
+	metrics  []*pendingMetric
+	byKey    map[string]*pendingMetric
+}
+
+type pendingMetric struct {
+	// metric is the metric first received, its descriptor and resource are
+	// sent.
+	metric *metricspb.Metric
+	series []*pendingSeries
+	byKey  map[string]*pendingSeries
+}
+
+type pendingSeries struct {
+	// ts is the series first received, its start timestamp and label values
+	// are sent.
+	ts     *metricspb.TimeSeries
+	points []*metricspb.Point
+	// byTimestamp holds the index of the point of each timestamp.
+	byTimestamp map[int64]int
+}
+
+func newPendingData() *pendingData {
+	return &pendingData{byKey: make(map[string]*pendingBatch)}
+}
+
+// add adds the points of the batch, merging those of a series having a point
+// with the same timestamp according to the policy. It returns the number of
+// points merged and the number of points rejected, by the error policy.
+func (pd *pendingData) add(md consumerdata.MetricsData, policy MergePolicy) (merged, rejected int) {
+	batchKey := protoKey(md.Node) + protoKey(md.Resource)
+	batch, ok := pd.byKey[batchKey]
+	if !ok {
+		batch = &pendingBatch{node: md.Node, resource: md.Resource, byKey: make(map[string]*pendingMetric)}
+		pd.byKey[batchKey] = batch
+		pd.batches = append(pd.batches, batch)
+	}
+
+	for _, metric := range md.Metrics {
+		if metric == nil {
+			continue
+		}
+		pm := batch.metric(metric)
+		for _, ts := range metric.Timeseries {
+			if ts == nil {
+				continue
+			}
+			ps := pm.seriesOf(ts)
+			for _, point := range ts.Points {
+				if point == nil {
+					continue
+				}
+				timestamp := point.GetTimestamp().GetSeconds()*1e9 + int64(point.GetTimestamp().GetNanos())
+				i, ok := ps.byTimestamp[timestamp]
+				if !ok {
+					ps.byTimestamp[timestamp] = len(ps.points)
+					ps.points = append(ps.points, point)
+					pd.numPoints++
+					continue
+				}
+				if policy == Error {
+					rejected++
+					continue
+				}
+				ps.points[i] = mergePoints(ps.points[i], point, policy)
+				merged++
+			}
+		}
+	}
+	return merged, rejected
+}
+
+// metric returns the pending metric of the same name, type and label keys as
+// the given metric, adding it if there is none.
+func (pb *pendingBatch) metric(metric *metricspb.Metric) *pendingMetric {
+	desc := metric.GetMetricDescriptor()
+	var b strings.Builder
+	b.WriteString(desc.GetName())
+	b.WriteByte(0)
+	b.WriteString(desc.GetType().String())
+	for _, key := range desc.GetLabelKeys() {
+		b.WriteByte(0)
+		b.WriteString(key.GetKey())
+	}
+	key := b.String()
+
+	pm, ok := pb.byKey[key]
+	if !ok {
+		pm = &pendingMetric{metric: metric, byKey: make(map[string]*pendingSeries)}
+		pb.byKey[key] = pm
+		pb.metrics = append(pb.metrics, pm)
+	}
+	return pm
+}
+
+// seriesOf returns the pending series of the same label values as the given
+// series, adding it if there is none.
+func (pm *pendingMetric) seriesOf(ts *metricspb.TimeSeries) *pendingSeries {
+	var b strings.Builder
+	for _, v := range ts.LabelValues {
+		if v.GetHasValue() {
+			b.WriteString(strconv.Quote(v.Value))
+		}
+		b.WriteByte(0)
+	}
+	key := b.String()
+
+	ps, ok := pm.byKey[key]
+	if !ok {
+		ps = &pendingSeries{ts: ts, byTimestamp: make(map[int64]int)}
+		pm.byKey[key] = ps
+		pm.series = append(pm.series, ps)
+	}
+	return ps
+}
+
+// metricsData returns the pending points as batches, one per node and
+// resource.
+func (pd *pendingData) metricsData() []consumerdata.MetricsData {
+	mds := make([]consumerdata.MetricsData, 0, len(pd.batches))
+	for _, batch := range pd.batches {
+		metrics := make([]*metricspb.Metric, 0, len(batch.metrics))
+		for _, pm := range batch.metrics {
+			metric := *pm.metric
+			metric.Timeseries = make([]*metricspb.TimeSeries, 0, len(pm.series))
+			for _, ps := range pm.series {
+				metric.Timeseries = append(metric.Timeseries, &metricspb.TimeSeries{
+					StartTimestamp: ps.ts.StartTimestamp,
+					LabelValues:    ps.ts.LabelValues,
+					Points:         ps.points,
+				})
+			}
+			metrics = append(metrics, &metric)
+		}
+		mds = append(mds, consumerdata.MetricsData{Node: batch.node, Resource: batch.resource, Metrics: metrics})
+	}
+	return mds
+}
+
+// mergePoints returns the point merging the given points according to the
+// policy. The sum of points of different value types, or of distributions or
+// summaries, is the last point.
+func mergePoints(existing, received *metricspb.Point, policy MergePolicy) *metricspb.Point {
+	if policy != Sum {
+		return received
+	}
+	switch v := existing.Value.(type) {
+	case *metricspb.Point_Int64Value:
+		if r, ok := received.Value.(*metricspb.Point_Int64Value); ok {
+			return &metricspb.Point{
+				Timestamp: existing.Timestamp,
+				Value:     &metricspb.Point_Int64Value{Int64Value: v.Int64Value + r.Int64Value},
+			}
+		}
+	case *metricspb.Point_DoubleValue:
+		if r, ok := received.Value.(*metricspb.Point_DoubleValue); ok {
+			return &metricspb.Point{
+				Timestamp: existing.Timestamp,
+				Value:     &metricspb.Point_DoubleValue{DoubleValue: v.DoubleValue + r.DoubleValue},
+			}
+		}
+	}
+	return received
+}
+
+// protoKey returns a key identifying the message by its content.
+func protoKey(msg proto.Message) string {
+	var b proto.Buffer
+	b.SetDeterministic(true)
+	if err := b.Marshal(msg); err != nil {
+		return ""
+	}
+	// Prefix the length, so that the keys can be concatenated.
+	return strconv.Itoa(len(b.Bytes())) + ":" + string(b.Bytes())
+}
@@ -0,0 +1,55 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metriccatalogprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Policy is how the metrics missing from the catalog are handled.
+type Policy string
+
+const (
+	// DropPolicy drops the metrics missing from the catalog.
+	DropPolicy Policy = "drop"
+	// TagPolicy passes the metrics missing from the catalog, with a label
+	// flagging them as unapproved.
+	TagPolicy Policy = "tag"
+)
+
+// Config defines configuration for the metric catalog processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Metrics are the names of the approved metrics, in addition to the ones
+	// of the file and endpoint.
+	Metrics []string `mapstructure:"metrics"`
+	// File is the path of a file listing the names of the approved metrics,
+	// one per line. Empty lines and lines starting with "#" are ignored.
+	File string `mapstructure:"file"`
+	// Endpoint is the URL of an HTTP endpoint serving the names of the
+	// approved metrics, in the format of the file.
+	Endpoint string `mapstructure:"endpoint"`
+	// ReloadInterval is how often the catalog is reloaded from the file and
+	// the endpoint. 0 loads the catalog only once.
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+	// Policy is how the metrics missing from the catalog are handled: "drop"
+	// (the default) or "tag".
+	Policy Policy `mapstructure:"policy"`
+	// TagLabel is the key of the label set to "true" on the metrics missing
+	// from the catalog, with the tag policy.
+	TagLabel string `mapstructure:"tag_label"`
+}
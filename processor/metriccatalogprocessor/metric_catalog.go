@@ -0,0 +1,276 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metriccatalogprocessor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// endpointTimeout bounds the time taken to fetch the catalog from the endpoint.
+const endpointTimeout = 10 * time.Second
+
+// dropReason is the reason the metrics dropped are recorded with, see
+// processor.RecordDropped.
+const dropReason = "not_in_catalog"
+
+type metricCatalogProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	metrics      []string
+	file         string
+	endpoint     string
+	client       *http.Client
+	policy       Policy
+	tagLabel     string
+	statsTags    []tag.Mutator
+
+	mu       sync.RWMutex
+	approved map[string]bool
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	reloads  sync.WaitGroup
+}
+
+var _ processor.MetricsProcessor = (*metricCatalogProcessor)(nil)
+var _ processor.Shutdowner = (*metricCatalogProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that drops or tags
+// the metrics whose name is missing from the catalog of approved metrics. The
+// catalog is loaded from the config, the file and the endpoint, and reloaded
+// at the configured interval.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if len(cfg.Metrics) == 0 && cfg.File == "" && cfg.Endpoint == "" {
+		return nil, errors.New("the catalog must be set by metrics, file or endpoint")
+	}
+	if cfg.ReloadInterval < 0 {
+		return nil, fmt.Errorf("reload_interval must be positive, got %v", cfg.ReloadInterval)
+	}
+	policy := cfg.Policy
+	switch policy {
+	case "":
+		policy = DropPolicy
+	case DropPolicy:
+	case TagPolicy:
+		if cfg.TagLabel == "" {
+			return nil, errors.New("tag_label must be set with the tag policy")
+		}
+	default:
+		return nil, fmt.Errorf("unknown policy %q, must be either %q or %q", cfg.Policy, DropPolicy, TagPolicy)
+	}
+
+	mcp := &metricCatalogProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		metrics:      cfg.Metrics,
+		file:         cfg.File,
+		endpoint:     cfg.Endpoint,
+		client:       &http.Client{Timeout: endpointTimeout},
+		policy:       policy,
+		tagLabel:     cfg.TagLabel,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+		stopCh:       make(chan struct{}),
+	}
+	if err := mcp.Reload(); err != nil {
+		return nil, err
+	}
+	if cfg.ReloadInterval > 0 && (cfg.File != "" || cfg.Endpoint != "") {
+		mcp.reloads.Add(1)
+		go mcp.reloadEvery(cfg.ReloadInterval)
+	}
+	return mcp, nil
+}
+
+// Reload loads the catalog again from the file and the endpoint. The current
+// catalog is kept if either cannot be loaded.
+func (mcp *metricCatalogProcessor) Reload() error {
+	approved := make(map[string]bool, len(mcp.metrics))
+	for _, name := range mcp.metrics {
+		approved[name] = true
+	}
+	if mcp.file != "" {
+		if err := mcp.loadFile(approved); err != nil {
+			return fmt.Errorf("cannot load the metric catalog file %q: %v", mcp.file, err)
+		}
+	}
+	if mcp.endpoint != "" {
+		if err := mcp.loadEndpoint(approved); err != nil {
+			return fmt.Errorf("cannot load the metric catalog from %q: %v", mcp.endpoint, err)
+		}
+	}
+
+	mcp.mu.Lock()
+	mcp.approved = approved
+	mcp.mu.Unlock()
+	return nil
+}
+
+// Shutdown halts the periodic reload of the catalog, it returns once the
+// reload in progress, if any, is done.
+func (mcp *metricCatalogProcessor) Shutdown() error {
+	mcp.stopOnce.Do(func() {
+		close(mcp.stopCh)
+	})
+	mcp.reloads.Wait()
+	return nil
+}
+
+func (mcp *metricCatalogProcessor) reloadEvery(interval time.Duration) {
+	defer mcp.reloads.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-mcp.stopCh:
+			return
+		case <-ticker.C:
+			if err := mcp.Reload(); err != nil {
+				mcp.logger.Warn("Failed to reload the metric catalog, keeping the current one",
+					zap.String("processor", mcp.name), zap.Error(err))
+				stats.RecordWithTags(context.Background(), mcp.statsTags, statReloadFailures.M(1))
+			}
+		}
+	}
+}
+
+func (mcp *metricCatalogProcessor) loadFile(approved map[string]bool) error {
+	f, err := os.Open(mcp.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return parseCatalog(f, approved)
+}
+
+func (mcp *metricCatalogProcessor) loadEndpoint(approved map[string]bool) error {
+	resp, err := mcp.client.Get(mcp.endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q", resp.Status)
+	}
+	return parseCatalog(resp.Body, approved)
+}
+
+// parseCatalog adds the metric names listed by r, one per line, to approved.
+// Empty lines and lines starting with "#" are ignored.
+func parseCatalog(r io.Reader, approved map[string]bool) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		approved[name] = true
+	}
+	return scanner.Err()
+}
+
+func (mcp *metricCatalogProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	mcp.mu.RLock()
+	approved := mcp.approved
+	mcp.mu.RUnlock()
+
+	metrics := make([]*metricspb.Metric, 0, len(md.Metrics))
+	violations := 0
+	for _, metric := range md.Metrics {
+		if approved[metric.GetMetricDescriptor().GetName()] {
+			metrics = append(metrics, metric)
+			continue
+		}
+		violations++
+		if mcp.policy == TagPolicy {
+			metrics = append(metrics, mcp.tagged(metric))
+		} else {
+			processor.RecordDroppedMetrics(mcp.name, dropReason, []*metricspb.Metric{metric})
+		}
+	}
+	if violations == 0 {
+		return mcp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	stats.RecordWithTags(context.Background(), mcp.statsTags, statViolations.M(int64(violations)))
+	if len(metrics) == 0 {
+		return nil
+	}
+	md.Metrics = metrics
+	return mcp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// tagged returns a copy of the metric with the tag label set on all its
+// series. Metrics already having the label are returned as is.
+func (mcp *metricCatalogProcessor) tagged(metric *metricspb.Metric) *metricspb.Metric {
+	desc := metric.GetMetricDescriptor()
+	if desc == nil {
+		return metric
+	}
+	for _, key := range desc.LabelKeys {
+		if key.GetKey() == mcp.tagLabel {
+			return metric
+		}
+	}
+
+	descCopy := *desc
+	descCopy.LabelKeys = make([]*metricspb.LabelKey, len(desc.LabelKeys), len(desc.LabelKeys)+1)
+	copy(descCopy.LabelKeys, desc.LabelKeys)
+	descCopy.LabelKeys = append(descCopy.LabelKeys, &metricspb.LabelKey{Key: mcp.tagLabel})
+
+	timeseries := make([]*metricspb.TimeSeries, 0, len(metric.Timeseries))
+	for _, ts := range metric.Timeseries {
+		tsCopy := *ts
+		tsCopy.LabelValues = make([]*metricspb.LabelValue, len(desc.LabelKeys), len(desc.LabelKeys)+1)
+		copy(tsCopy.LabelValues, ts.LabelValues)
+		for i, lv := range tsCopy.LabelValues {
+			if lv == nil {
+				// The series was missing the values of some of its own labels.
+				tsCopy.LabelValues[i] = &metricspb.LabelValue{}
+			}
+		}
+		tsCopy.LabelValues = append(tsCopy.LabelValues, &metricspb.LabelValue{Value: "true", HasValue: true})
+		timeseries = append(timeseries, &tsCopy)
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: &descCopy,
+		Resource:         metric.Resource,
+		Timeseries:       timeseries,
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metriccatalogprocessor
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+func TestNewProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), nil, Config{Metrics: []string{"up"}})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestNewProcessorMissingFile(t *testing.T) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(),
+		Config{File: filepath.Join("testdata", "missing.txt")})
+	assert.Nil(t, mp)
+	assert.Error(t, err)
+}
+
+func TestDropUnknownMetric(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkMetricsExporter)
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "metric_catalog"},
+		Metrics:           []string{"http_requests_total"},
+	}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	approved := metric("http_requests_total")
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{approved, metric("debug_cache_entries")}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, []*metricspb.Metric{approved}, got[0].Metrics)
+	// The received batch is shared with other pipelines, it is not modified.
+	assert.Len(t, md.Metrics, 2)
+
+	rows, err := view.RetrieveData(statViolations.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestApprovedMetricsPassedAsIs(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{Metrics: []string{"http_requests_total"}})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric("http_requests_total")}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Equal(t, []consumerdata.MetricsData{md}, sink.AllMetrics())
+}
+
+func TestTagUnknownMetric(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{
+		Metrics:  []string{"http_requests_total"},
+		Policy:   TagPolicy,
+		TagLabel: "unapproved_metric",
+	})
+	require.NoError(t, err)
+
+	unknown := metric("debug_cache_entries")
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{unknown}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	tagged := got[0].Metrics[0]
+	assert.Equal(t, []*metricspb.LabelKey{{Key: "method"}, {Key: "unapproved_metric"}}, tagged.MetricDescriptor.LabelKeys)
+	assert.Equal(t, []*metricspb.LabelValue{{Value: "GET", HasValue: true}, {Value: "true", HasValue: true}},
+		tagged.Timeseries[0].LabelValues)
+	assert.Equal(t, unknown.Timeseries[0].Points, tagged.Timeseries[0].Points)
+	assert.Len(t, unknown.MetricDescriptor.LabelKeys, 1)
+}
+
+func TestReloadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metric_catalog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "catalog.txt")
+	require.NoError(t, ioutil.WriteFile(file, []byte("# Approved metrics\nhttp_requests_total\n\n"), 0600))
+
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{File: file})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric("http_requests_total"), metric("queue_size")}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	require.Len(t, sink.AllMetrics(), 1)
+	assert.Equal(t, md.Metrics[:1], sink.AllMetrics()[0].Metrics)
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("http_requests_total\nqueue_size\n"), 0600))
+	require.NoError(t, mp.(*metricCatalogProcessor).Reload())
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	require.Len(t, sink.AllMetrics(), 2)
+	assert.Equal(t, md.Metrics, sink.AllMetrics()[1].Metrics)
+
+	// A catalog that cannot be loaded keeps the current one.
+	require.NoError(t, os.Remove(file))
+	assert.Error(t, mp.(*metricCatalogProcessor).Reload())
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	require.Len(t, sink.AllMetrics(), 3)
+	assert.Equal(t, md.Metrics, sink.AllMetrics()[2].Metrics)
+}
+
+func TestPeriodicReloadEndpoint(t *testing.T) {
+	var mu sync.Mutex
+	catalog := "http_requests_total\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = rw.Write([]byte(catalog))
+	}))
+	defer srv.Close()
+
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{Endpoint: srv.URL, ReloadInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer mp.(processor.Shutdowner).Shutdown()
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric("queue_size")}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Empty(t, sink.AllMetrics())
+
+	mu.Lock()
+	catalog = "http_requests_total\nqueue_size\n"
+	mu.Unlock()
+	require.Eventually(t, func() bool {
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+		return len(sink.AllMetrics()) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestShutdownStopsReloads(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = rw.Write([]byte("http_requests_total\n"))
+	}))
+	defer srv.Close()
+
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{Endpoint: srv.URL, ReloadInterval: time.Millisecond})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&requests) > 2 }, 5*time.Second, time.Millisecond)
+
+	// The pipelines shut down the processor, the catalog is no longer reloaded.
+	require.NoError(t, mp.(processor.Shutdowner).Shutdown())
+	require.NoError(t, mp.(processor.Shutdowner).Shutdown())
+	stopped := atomic.LoadInt32(&requests)
+	<-time.After(20 * time.Millisecond)
+	assert.Equal(t, stopped, atomic.LoadInt32(&requests))
+}
+
+func metric(name string) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys: []*metricspb.LabelKey{{Key: "method"}},
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			LabelValues: []*metricspb.LabelValue{{Value: "GET", HasValue: true}},
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}},
+		}},
+	}
+}
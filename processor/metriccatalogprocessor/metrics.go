@@ -0,0 +1,53 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metriccatalogprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	statViolations     = stats.Int64("metric_catalog_violations", "Number of metrics missing from the catalog of approved metrics", stats.UnitDimensionless)
+	statReloadFailures = stats.Int64("metric_catalog_reload_failures", "Number of failed reloads of the catalog of approved metrics", stats.UnitDimensionless)
+)
+
+// MetricViews returns the metrics views related to the metric catalog.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	tagKeys := []tag.Key{processor.TagExporterNameKey}
+	violationsView := &view.View{
+		Name:        statViolations.Name(),
+		Measure:     statViolations,
+		Description: statViolations.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	reloadFailuresView := &view.View{
+		Name:        statReloadFailures.Name(),
+		Measure:     statReloadFailures,
+		Description: statReloadFailures.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	return []*view.View{violationsView, reloadFailuresView}
+}
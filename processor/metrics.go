@@ -21,6 +21,7 @@ import (
 	"go.opencensus.io/tag"
 
 	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/observability"
 )
 
 // Keys and stats for telemetry.
@@ -139,10 +140,11 @@ func ServiceNameForNode(node *commonpb.Node) string {
 }
 
 // StatsTagsForBatch gets the stat tags based on the specified processorName, serviceName, and spanFormat.
+// The service name is subject to the limit set by observability.SetMaxTagValues.
 func StatsTagsForBatch(processorName, serviceName, spanFormat string) []tag.Mutator {
 	statsTags := []tag.Mutator{
 		tag.Upsert(TagSourceFormatKey, spanFormat),
-		tag.Upsert(TagServiceNameKey, serviceName),
+		tag.Upsert(TagServiceNameKey, observability.LimitTagValue(TagServiceNameKey, serviceName)),
 		tag.Upsert(TagExporterNameKey, processorName),
 	}
 
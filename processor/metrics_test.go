@@ -0,0 +1,71 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+)
+
+func TestServiceNamesCardinalityIsCapped(t *testing.T) {
+	const maxServiceNames = 10
+	observability.SetMaxTagValues(maxServiceNames)
+	defer observability.SetMaxTagValues(0)
+
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	// Every prometheus job is reported as a different service.
+	for i := 0; i < 100; i++ {
+		statsTags := StatsTagsForBatch("test", "job"+strconv.Itoa(i), "prometheus")
+		require.NoError(t, stats.RecordWithTags(context.Background(), statsTags, StatReceivedSpanCount.M(1)))
+	}
+	// Services admitted before the limit was reached keep being recorded.
+	statsTags := StatsTagsForBatch("test", "job0", "prometheus")
+	require.NoError(t, stats.RecordWithTags(context.Background(), statsTags, StatReceivedSpanCount.M(1)))
+
+	rows, err := view.RetrieveData(StatReceivedSpanCount.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, maxServiceNames+1)
+
+	got := make(map[string]float64)
+	for _, row := range rows {
+		for _, tg := range row.Tags {
+			if tg.Key == TagServiceNameKey {
+				got[tg.Value] = row.Data.(*view.SumData).Value
+			}
+		}
+	}
+	assert.Equal(t, float64(2), got["job0"])
+	assert.Equal(t, float64(1), got["job9"])
+	assert.Equal(t, float64(90), got[observability.OtherTagValue])
+}
+
+func TestServiceNamesWithoutLimit(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		name := "job" + strconv.Itoa(i)
+		assert.Equal(t, name, observability.LimitTagValue(TagServiceNameKey, name))
+	}
+}
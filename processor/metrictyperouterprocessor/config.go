@@ -0,0 +1,55 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrictyperouterprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// The routes, one per metric type.
+const (
+	// GaugeRoute routes the int64 and double gauges.
+	GaugeRoute = "gauge"
+	// CounterRoute routes the cumulative int64 and double metrics.
+	CounterRoute = "counter"
+	// HistogramRoute routes the cumulative and gauge distributions.
+	HistogramRoute = "histogram"
+	// SummaryRoute routes the summaries.
+	SummaryRoute = "summary"
+)
+
+// UnroutedPolicy is how the metrics of the types without a route are handled.
+type UnroutedPolicy string
+
+const (
+	// PipelineUnrouted passes the unrouted metrics down the pipeline.
+	PipelineUnrouted UnroutedPolicy = "pipeline"
+	// DropUnrouted drops the unrouted metrics.
+	DropUnrouted UnroutedPolicy = "drop"
+)
+
+// Config defines configuration for the metric type router processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Routes are the names of the exporters each metric type is routed to,
+	// keyed by route: "gauge", "counter", "histogram" or "summary".
+	Routes map[string][]string `mapstructure:"routes"`
+	// Unrouted is how the metrics of the types without a route are handled:
+	// "pipeline" (the default) or "drop".
+	Unrouted UnroutedPolicy `mapstructure:"unrouted"`
+}
+
+// RouteExporterNames returns the names of the exporters of each route.
+func (cfg *Config) RouteExporterNames() map[string][]string {
+	return cfg.Routes
+}
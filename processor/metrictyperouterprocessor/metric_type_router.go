@@ -0,0 +1,151 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrictyperouterprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// routeOrder is the order the partitions are sent to their route in.
+var routeOrder = []string{GaugeRoute, CounterRoute, HistogramRoute, SummaryRoute}
+
+type metricTypeRouterProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	routes       map[string]consumer.MetricsConsumer
+	unrouted     UnroutedPolicy
+	statsTags    []tag.Mutator
+}
+
+var _ processor.MetricsProcessor = (*metricTypeRouterProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that partitions the
+// metrics by type and sends each partition to the consumer of its route. A
+// metric is never split, so the series of a histogram or a summary stay
+// together. The metrics of the types without a route are passed down the
+// pipeline or dropped, according to the config.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, routes map[string]consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if len(cfg.Routes) == 0 {
+		return nil, errors.New("routes must route at least one metric type")
+	}
+	for route, exporters := range cfg.Routes {
+		if !isRoute(route) {
+			return nil, fmt.Errorf("unknown route %q, must be one of %q, %q, %q or %q",
+				route, GaugeRoute, CounterRoute, HistogramRoute, SummaryRoute)
+		}
+		if len(exporters) == 0 {
+			return nil, fmt.Errorf("route %q has no exporters", route)
+		}
+		if routes[route] == nil {
+			return nil, fmt.Errorf("nil consumer for route %q, the route requires exporters", route)
+		}
+	}
+
+	unrouted := cfg.Unrouted
+	switch unrouted {
+	case "":
+		unrouted = PipelineUnrouted
+	case PipelineUnrouted, DropUnrouted:
+	default:
+		return nil, fmt.Errorf("unknown unrouted policy %q, must be either %q or %q",
+			cfg.Unrouted, PipelineUnrouted, DropUnrouted)
+	}
+
+	return &metricTypeRouterProcessor{
+		nextConsumer: nextConsumer,
+		routes:       routes,
+		unrouted:     unrouted,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func isRoute(route string) bool {
+	for _, r := range routeOrder {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
+// metricRoute returns the route of the given metric, empty for the metrics of
+// unknown type.
+func metricRoute(metric *metricspb.Metric) string {
+	switch metric.GetMetricDescriptor().GetType() {
+	case metricspb.MetricDescriptor_GAUGE_INT64, metricspb.MetricDescriptor_GAUGE_DOUBLE:
+		return GaugeRoute
+	case metricspb.MetricDescriptor_CUMULATIVE_INT64, metricspb.MetricDescriptor_CUMULATIVE_DOUBLE:
+		return CounterRoute
+	case metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION, metricspb.MetricDescriptor_GAUGE_DISTRIBUTION:
+		return HistogramRoute
+	case metricspb.MetricDescriptor_SUMMARY:
+		return SummaryRoute
+	}
+	return ""
+}
+
+func (mtr *metricTypeRouterProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	partitions := make(map[string][]*metricspb.Metric, len(mtr.routes))
+	var unrouted []*metricspb.Metric
+	for _, metric := range md.Metrics {
+		route := metricRoute(metric)
+		if _, ok := mtr.routes[route]; ok {
+			partitions[route] = append(partitions[route], metric)
+		} else {
+			unrouted = append(unrouted, metric)
+		}
+	}
+
+	var errs []error
+	for _, route := range routeOrder {
+		metrics := partitions[route]
+		if len(metrics) == 0 {
+			continue
+		}
+		partition := consumerdata.MetricsData{
+			Node:     md.Node,
+			Resource: md.Resource,
+			Metrics:  metrics,
+		}
+		if err := mtr.routes[route].ConsumeMetricsData(ctx, partition); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(unrouted) > 0 {
+		if mtr.unrouted == DropUnrouted {
+			stats.RecordWithTags(context.Background(), mtr.statsTags, statDroppedMetrics.M(int64(len(unrouted))))
+		} else {
+			md.Metrics = unrouted
+			if err := mtr.nextConsumer.ConsumeMetricsData(ctx, md); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return oterr.CombineErrors(errs)
+}
@@ -0,0 +1,223 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrictyperouterprocessor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func newConfig(routes map[string][]string, unrouted UnroutedPolicy) Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		Routes:   routes,
+		Unrouted: unrouted,
+	}
+}
+
+func newMetric(name string, typ metricspb.MetricDescriptor_Type) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Type: typ},
+		Timeseries: []*metricspb.TimeSeries{
+			{LabelValues: []*metricspb.LabelValue{{Value: "a", HasValue: true}}},
+			{LabelValues: []*metricspb.LabelValue{{Value: "b", HasValue: true}}},
+		},
+	}
+}
+
+func metricNames(sink *exportertest.SinkMetricsExporter) []string {
+	var names []string
+	for _, md := range sink.AllMetrics() {
+		for _, metric := range md.Metrics {
+			names = append(names, metric.MetricDescriptor.Name)
+		}
+	}
+	return names
+}
+
+func mixedBatch() consumerdata.MetricsData {
+	return consumerdata.MetricsData{
+		Node: &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "svc"}},
+		Metrics: []*metricspb.Metric{
+			newMetric("temperature", metricspb.MetricDescriptor_GAUGE_DOUBLE),
+			newMetric("requests", metricspb.MetricDescriptor_CUMULATIVE_INT64),
+			newMetric("latency", metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION),
+			newMetric("queue_size", metricspb.MetricDescriptor_GAUGE_INT64),
+			newMetric("rpc_duration", metricspb.MetricDescriptor_SUMMARY),
+			newMetric("sizes", metricspb.MetricDescriptor_GAUGE_DISTRIBUTION),
+			newMetric("bytes", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE),
+			newMetric("unknown", metricspb.MetricDescriptor_UNSPECIFIED),
+		},
+	}
+}
+
+func TestNewMetricsProcessor(t *testing.T) {
+	next := &exportertest.SinkMetricsExporter{}
+	gauges := map[string]consumer.MetricsConsumer{GaugeRoute: next}
+
+	_, err := NewMetricsProcessor(nil, gauges, newConfig(map[string][]string{GaugeRoute: {"e"}}, ""))
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	tests := []struct {
+		name     string
+		routes   map[string][]string
+		unrouted UnroutedPolicy
+	}{
+		{name: "no routes"},
+		{name: "unknown route", routes: map[string][]string{"exemplar": {"e"}}},
+		{name: "route without exporters", routes: map[string][]string{GaugeRoute: {}}},
+		{name: "route without consumer", routes: map[string][]string{CounterRoute: {"e"}}},
+		{name: "unknown unrouted policy", routes: map[string][]string{GaugeRoute: {"e"}}, unrouted: "reject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMetricsProcessor(next, gauges, newConfig(tt.routes, tt.unrouted))
+			assert.Nil(t, mp)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMetricTypeRouter(t *testing.T) {
+	next := &exportertest.SinkMetricsExporter{}
+	gauges := &exportertest.SinkMetricsExporter{}
+	counters := &exportertest.SinkMetricsExporter{}
+	histograms := &exportertest.SinkMetricsExporter{}
+	summaries := &exportertest.SinkMetricsExporter{}
+	routes := map[string]consumer.MetricsConsumer{
+		GaugeRoute:     gauges,
+		CounterRoute:   counters,
+		HistogramRoute: histograms,
+		SummaryRoute:   summaries,
+	}
+	cfg := newConfig(map[string][]string{
+		GaugeRoute:     {"gauges"},
+		CounterRoute:   {"counters"},
+		HistogramRoute: {"histograms"},
+		SummaryRoute:   {"summaries"},
+	}, "")
+
+	mp, err := NewMetricsProcessor(next, routes, cfg)
+	require.NoError(t, err)
+
+	md := mixedBatch()
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	assert.Equal(t, []string{"temperature", "queue_size"}, metricNames(gauges))
+	assert.Equal(t, []string{"requests", "bytes"}, metricNames(counters))
+	assert.Equal(t, []string{"latency", "sizes"}, metricNames(histograms))
+	assert.Equal(t, []string{"rpc_duration"}, metricNames(summaries))
+	assert.Equal(t, []string{"unknown"}, metricNames(next))
+
+	// The partitions keep the node and the series of the metrics together.
+	for _, sink := range []*exportertest.SinkMetricsExporter{gauges, counters, histograms, summaries, next} {
+		got := sink.AllMetrics()
+		require.Len(t, got, 1)
+		assert.Equal(t, md.Node, got[0].Node)
+		for _, metric := range got[0].Metrics {
+			assert.Len(t, metric.Timeseries, 2)
+		}
+	}
+
+	// The batch is shared, it must not be modified.
+	assert.Equal(t, mixedBatch(), md)
+}
+
+func TestMetricTypeRouterUnrouted(t *testing.T) {
+	tests := []struct {
+		name     string
+		unrouted UnroutedPolicy
+		want     []string
+	}{
+		{
+			name:     "pipeline",
+			unrouted: PipelineUnrouted,
+			want:     []string{"temperature", "requests", "queue_size", "bytes", "unknown"},
+		},
+		{
+			name:     "drop",
+			unrouted: DropUnrouted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &exportertest.SinkMetricsExporter{}
+			histograms := &exportertest.SinkMetricsExporter{}
+			routes := map[string]consumer.MetricsConsumer{
+				HistogramRoute: histograms,
+				SummaryRoute:   histograms,
+			}
+			cfg := newConfig(map[string][]string{
+				HistogramRoute: {"histograms"},
+				SummaryRoute:   {"histograms"},
+			}, tt.unrouted)
+
+			mp, err := NewMetricsProcessor(next, routes, cfg)
+			require.NoError(t, err)
+			require.NoError(t, mp.ConsumeMetricsData(context.Background(), mixedBatch()))
+
+			assert.Equal(t, []string{"latency", "sizes", "rpc_duration"}, metricNames(histograms))
+			assert.Equal(t, tt.want, metricNames(next))
+			if tt.want == nil {
+				assert.Empty(t, next.AllMetrics())
+			}
+		})
+	}
+}
+
+type errMetricsConsumer struct {
+	err error
+}
+
+func (e *errMetricsConsumer) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	return e.err
+}
+
+func TestMetricTypeRouterErrors(t *testing.T) {
+	next := &exportertest.SinkMetricsExporter{}
+	routes := map[string]consumer.MetricsConsumer{
+		GaugeRoute:   &errMetricsConsumer{err: errors.New("gauges failed")},
+		CounterRoute: &errMetricsConsumer{err: errors.New("counters failed")},
+	}
+	cfg := newConfig(map[string][]string{
+		GaugeRoute:   {"gauges"},
+		CounterRoute: {"counters"},
+	}, "")
+
+	mp, err := NewMetricsProcessor(next, routes, cfg)
+	require.NoError(t, err)
+
+	err = mp.ConsumeMetricsData(context.Background(), mixedBatch())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gauges failed")
+	assert.Contains(t, err.Error(), "counters failed")
+	// The other partitions are still delivered.
+	assert.Equal(t, []string{"latency", "rpc_duration", "sizes", "unknown"}, metricNames(next))
+}
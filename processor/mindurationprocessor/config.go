@@ -0,0 +1,35 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mindurationprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config defines configuration for the min duration processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// MinDuration is the duration below which the spans are dropped. Zero,
+	// the default, keeps every span.
+	MinDuration time.Duration `mapstructure:"min_duration"`
+	// KeepErrors keeps the short spans with an error status, so that only the
+	// spans without errors are dropped.
+	KeepErrors bool `mapstructure:"keep_errors"`
+	// DropRootSpans allows dropping the short root spans, i.e. the spans
+	// without a parent. By default root spans are always kept.
+	DropRootSpans bool `mapstructure:"drop_root_spans"`
+}
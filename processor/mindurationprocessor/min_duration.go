@@ -0,0 +1,149 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mindurationprocessor
+
+import (
+	"context"
+	"time"
+
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/golang/protobuf/ptypes"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type minDurationProcessor struct {
+	name          string
+	nextConsumer  consumer.TraceConsumer
+	logger        *zap.Logger
+	minDuration   time.Duration
+	keepErrors    bool
+	dropRootSpans bool
+	statsTags     []tag.Mutator
+}
+
+var _ processor.TraceProcessor = (*minDurationProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that drops the spans
+// shorter than the configured minimum duration. The retained spans whose
+// parent was dropped are linked to their closest retained ancestor.
+func NewTraceProcessor(logger *zap.Logger, nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	return &minDurationProcessor{
+		name:          cfg.Name(),
+		nextConsumer:  nextConsumer,
+		logger:        logger,
+		minDuration:   cfg.MinDuration,
+		keepErrors:    cfg.KeepErrors,
+		dropRootSpans: cfg.DropRootSpans,
+		statsTags:     []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (mdp *minDurationProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	if mdp.minDuration <= 0 {
+		return mdp.nextConsumer.ConsumeTraceData(ctx, td)
+	}
+
+	// droppedParents maps the id of each dropped span to the id of its parent,
+	// so that the children of dropped spans can be relinked.
+	var droppedParents map[string][]byte
+	kept := make([]*tracepb.Span, 0, len(td.Spans))
+	for _, span := range td.Spans {
+		if !mdp.shouldDrop(span) {
+			kept = append(kept, span)
+			continue
+		}
+		if droppedParents == nil {
+			droppedParents = make(map[string][]byte)
+		}
+		droppedParents[string(span.SpanId)] = span.ParentSpanId
+	}
+
+	if len(droppedParents) == 0 {
+		return mdp.nextConsumer.ConsumeTraceData(ctx, td)
+	}
+
+	mdp.logger.Debug("Dropped spans shorter than the minimum duration",
+		zap.String("processor", mdp.name),
+		zap.Int("spans", len(droppedParents)))
+	stats.RecordWithTags(context.Background(), mdp.statsTags, statDroppedShortSpans.M(int64(len(droppedParents))))
+
+	if len(kept) == 0 {
+		// Every span in the batch was dropped.
+		return nil
+	}
+
+	for i, span := range kept {
+		parent, ok := retainedAncestor(droppedParents, span.ParentSpanId)
+		if !ok {
+			continue
+		}
+		relinked := *span
+		relinked.ParentSpanId = parent
+		kept[i] = &relinked
+	}
+	td.Spans = kept
+	return mdp.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+// shouldDrop returns whether the span is shorter than the minimum duration and
+// is not exempt from being dropped.
+func (mdp *minDurationProcessor) shouldDrop(span *tracepb.Span) bool {
+	if span == nil {
+		return false
+	}
+	if len(span.ParentSpanId) == 0 && !mdp.dropRootSpans {
+		return false
+	}
+	if mdp.keepErrors && span.Status != nil && span.Status.Code != 0 {
+		return false
+	}
+	start, err := ptypes.Timestamp(span.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := ptypes.Timestamp(span.EndTime)
+	if err != nil {
+		return false
+	}
+	return end.Sub(start) < mdp.minDuration
+}
+
+// retainedAncestor returns the id of the closest ancestor not dropped, walking
+// up from the given parent id, and whether it differs from that parent id. An
+// ancestor outside of the batch is assumed to be retained.
+func retainedAncestor(droppedParents map[string][]byte, parent []byte) ([]byte, bool) {
+	relinked := false
+	// Bound the walk by the number of dropped spans, in case of a cycle.
+	for i := 0; i <= len(droppedParents) && len(parent) > 0; i++ {
+		grandParent, dropped := droppedParents[string(parent)]
+		if !dropped {
+			break
+		}
+		parent = grandParent
+		relinked = true
+	}
+	return parent, relinked
+}
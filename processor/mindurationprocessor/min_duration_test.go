@@ -0,0 +1,160 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mindurationprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewTraceProcessorNilNext(t *testing.T) {
+	tp, err := NewTraceProcessor(zap.NewNop(), nil, Config{})
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestDropShortSpans(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := &exportertest.SinkTraceExporter{}
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "min_duration/internal"},
+		MinDuration:       5 * time.Millisecond,
+		KeepErrors:        true,
+	}
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	// root (short) -> handler -> cache (short) -> lookup (short) -> query
+	//                         -> parse (short, error)
+	root := span(1, 0, time.Millisecond)
+	handler := span(2, 1, 20*time.Millisecond)
+	cache := span(3, 2, time.Millisecond)
+	lookup := span(4, 3, 2*time.Millisecond)
+	query := span(5, 4, 10*time.Millisecond)
+	parse := span(6, 2, time.Millisecond)
+	parse.Status = &tracepb.Status{Code: 13, Message: "internal"}
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{root, handler, cache, lookup, query, parse}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	spans := got[0].Spans
+	require.Len(t, spans, 4)
+	// The short root span is exempt.
+	assert.Equal(t, root, spans[0])
+	assert.Equal(t, handler, spans[1])
+	// The query is relinked to the closest retained ancestor.
+	assert.Equal(t, spanID(5), spans[2].SpanId)
+	assert.Equal(t, spanID(2), spans[2].ParentSpanId)
+	// The short error span is kept.
+	assert.Equal(t, parse, spans[3])
+	// The incoming span is left untouched.
+	assert.Equal(t, spanID(4), query.ParentSpanId)
+
+	rows, err := view.RetrieveData(statDroppedShortSpans.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestDropShortErrorSpans(t *testing.T) {
+	sink := &exportertest.SinkTraceExporter{}
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, Config{MinDuration: 5 * time.Millisecond})
+	require.NoError(t, err)
+
+	failed := span(2, 1, time.Millisecond)
+	failed.Status = &tracepb.Status{Code: 2}
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{span(1, 0, 10*time.Millisecond), failed}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Spans, 1)
+	assert.Equal(t, spanID(1), got[0].Spans[0].SpanId)
+}
+
+func TestDropShortRootSpans(t *testing.T) {
+	sink := &exportertest.SinkTraceExporter{}
+	cfg := Config{MinDuration: 5 * time.Millisecond, DropRootSpans: true}
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{
+		span(1, 0, time.Millisecond),
+		span(2, 1, 10*time.Millisecond),
+	}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Spans, 1)
+	assert.Equal(t, spanID(2), got[0].Spans[0].SpanId)
+	// The child of the dropped root becomes a root span.
+	assert.Empty(t, got[0].Spans[0].ParentSpanId)
+
+	// A batch whose spans are all dropped is not sent downstream.
+	td = consumerdata.TraceData{Spans: []*tracepb.Span{span(3, 0, time.Millisecond)}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	assert.Len(t, sink.AllTraces(), 1)
+}
+
+func TestKeepSpansWithoutTimes(t *testing.T) {
+	sink := &exportertest.SinkTraceExporter{}
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, Config{MinDuration: 5 * time.Millisecond})
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{{SpanId: spanID(2), ParentSpanId: spanID(1)}}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	assert.Equal(t, td.Spans, got[0].Spans)
+}
+
+func spanID(id byte) []byte {
+	if id == 0 {
+		return nil
+	}
+	return []byte{0, 0, 0, 0, 0, 0, 0, id}
+}
+
+func span(id, parent byte, duration time.Duration) *tracepb.Span {
+	start := time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+	return &tracepb.Span{
+		TraceId:      []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanId:       spanID(id),
+		ParentSpanId: spanID(parent),
+		StartTime:    internal.TimeToTimestamp(start),
+		EndTime:      internal.TimeToTimestamp(start.Add(duration)),
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monotonicprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the monotonic timestamps processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// MaxSeries is the maximum number of series whose last exported timestamp
+	// is tracked. The least recently updated series are forgotten first.
+	MaxSeries int `mapstructure:"max_series"`
+	// Reorder sorts the points of each series by timestamp within a batch
+	// before checking them, instead of dropping the points out of order.
+	Reorder bool `mapstructure:"reorder"`
+}
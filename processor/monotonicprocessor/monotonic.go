@@ -0,0 +1,209 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monotonicprocessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type monotonicProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	reorder      bool
+	statsTags    []tag.Mutator
+
+	mu     sync.Mutex
+	series *seriesCache
+}
+
+var _ processor.MetricsProcessor = (*monotonicProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that drops the
+// points whose timestamp is not strictly after the last point exported for the
+// same series.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	maxSeries := cfg.MaxSeries
+	if maxSeries == 0 {
+		maxSeries = defaultMaxSeries
+	}
+	if maxSeries < 0 {
+		return nil, fmt.Errorf("max_series must be positive, got %d", cfg.MaxSeries)
+	}
+
+	return &monotonicProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		reorder:      cfg.Reorder,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+		series:       newSeriesCache(maxSeries),
+	}, nil
+}
+
+func (mp *monotonicProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	kept := make([]*metricspb.Metric, 0, len(md.Metrics))
+	dropped := 0
+
+	mp.mu.Lock()
+	for _, metric := range md.Metrics {
+		desc := metric.GetMetricDescriptor()
+		if desc == nil {
+			kept = append(kept, metric)
+			continue
+		}
+
+		resource := metric.Resource
+		if resource == nil {
+			resource = md.Resource
+		}
+		timeseries := make([]*metricspb.TimeSeries, 0, len(metric.Timeseries))
+		for _, ts := range metric.Timeseries {
+			filtered, n := mp.filterPoints(seriesKey(md.Node, resource, desc, ts), ts)
+			dropped += n
+			if len(filtered.Points) > 0 {
+				timeseries = append(timeseries, filtered)
+			}
+		}
+		if len(timeseries) > 0 {
+			kept = append(kept, &metricspb.Metric{
+				MetricDescriptor: desc,
+				Resource:         metric.Resource,
+				Timeseries:       timeseries,
+			})
+		}
+	}
+	mp.mu.Unlock()
+
+	if dropped > 0 {
+		mp.logger.Debug("Dropped out of order points",
+			zap.String("processor", mp.name),
+			zap.Int("points", dropped))
+		stats.RecordWithTags(context.Background(), mp.statsTags, statOutOfOrderPoints.M(int64(dropped)))
+	}
+
+	if len(kept) == 0 && len(md.Metrics) > 0 {
+		// Every point in the batch was dropped.
+		return nil
+	}
+	md.Metrics = kept
+	return mp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// filterPoints returns a copy of the series without the points that are not
+// strictly after the last exported one, and how many points were removed.
+// Points without a timestamp are kept as is.
+func (mp *monotonicProcessor) filterPoints(key string, ts *metricspb.TimeSeries) (*metricspb.TimeSeries, int) {
+	points := make([]*metricspb.Point, len(ts.Points))
+	copy(points, ts.Points)
+	if mp.reorder {
+		sort.SliceStable(points, func(i, j int) bool {
+			return timestampNanos(points[i].GetTimestamp()) < timestampNanos(points[j].GetTimestamp())
+		})
+	}
+
+	last, seen := mp.series.get(key)
+	kept := points[:0]
+	for _, point := range points {
+		if point.GetTimestamp() == nil {
+			kept = append(kept, point)
+			continue
+		}
+		t := timestampNanos(point.Timestamp)
+		if seen && t <= last {
+			continue
+		}
+		last, seen = t, true
+		kept = append(kept, point)
+	}
+	if seen {
+		mp.series.set(key, last)
+	}
+
+	return &metricspb.TimeSeries{
+		StartTimestamp: ts.StartTimestamp,
+		LabelValues:    ts.LabelValues,
+		Points:         kept,
+	}, len(ts.Points) - len(kept)
+}
+
+// seriesKey identifies a series by the node that reported it, its resource,
+// the metric name and the label keys and values. Every part is prefixed by its
+// length so that values containing separators can't collide.
+func seriesKey(node *commonpb.Node, resource *resourcepb.Resource, desc *metricspb.MetricDescriptor, ts *metricspb.TimeSeries) string {
+	var b strings.Builder
+	writeKeyPart(&b, processor.ServiceNameForNode(node))
+	writeKeyPart(&b, node.GetIdentifier().GetHostName())
+
+	writeKeyPart(&b, resource.GetType())
+	resourceKeys := make([]string, 0, len(resource.GetLabels()))
+	for k := range resource.GetLabels() {
+		resourceKeys = append(resourceKeys, k)
+	}
+	sort.Strings(resourceKeys)
+	b.WriteString(strconv.Itoa(len(resourceKeys)))
+	b.WriteByte(':')
+	for _, k := range resourceKeys {
+		writeKeyPart(&b, k)
+		writeKeyPart(&b, resource.Labels[k])
+	}
+
+	writeKeyPart(&b, desc.GetName())
+	for i, v := range ts.LabelValues {
+		var key string
+		if i < len(desc.GetLabelKeys()) {
+			key = desc.LabelKeys[i].GetKey()
+		}
+		writeKeyPart(&b, key)
+		var value string
+		if v.GetHasValue() {
+			value = v.Value
+		}
+		writeKeyPart(&b, value)
+	}
+	return b.String()
+}
+
+func writeKeyPart(b *strings.Builder, s string) {
+	b.WriteString(strconv.Itoa(len(s)))
+	b.WriteByte(':')
+	b.WriteString(s)
+}
+
+func timestampNanos(t *timestamp.Timestamp) int64 {
+	return t.GetSeconds()*1e9 + int64(t.GetNanos())
+}
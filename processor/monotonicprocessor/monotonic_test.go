@@ -0,0 +1,192 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monotonicprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestInOrderPointsPass(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{})
+	require.NoError(t, err)
+
+	for _, batch := range [][]int64{{1, 2}, {3}, {4, 5, 6}} {
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), metricsData(gauge("a", batch...))))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 3)
+	assert.Equal(t, []int64{1, 2}, pointSeconds(got[0].Metrics[0]))
+	assert.Equal(t, []int64{3}, pointSeconds(got[1].Metrics[0]))
+	assert.Equal(t, []int64{4, 5, 6}, pointSeconds(got[2].Metrics[0]))
+}
+
+func TestOutOfOrderPointsDropped(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := &exportertest.SinkMetricsExporter{}
+	cfg := Config{ProcessorSettings: configmodels.ProcessorSettings{NameVal: "monotonic/drop"}}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	batches := []consumerdata.MetricsData{
+		metricsData(gauge("a", 10), gauge("b", 10)),
+		// "a" goes back in time and repeats a timestamp, "b" is a separate series.
+		metricsData(gauge("a", 9, 10, 11), gauge("b", 11)),
+		// Every point is dropped, nothing is forwarded.
+		metricsData(gauge("a", 5)),
+	}
+	for _, md := range batches {
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	require.Len(t, got[1].Metrics, 2)
+	assert.Equal(t, []int64{11}, pointSeconds(got[1].Metrics[0]))
+	assert.Equal(t, []int64{11}, pointSeconds(got[1].Metrics[1]))
+
+	rows, err := view.RetrieveData(statOutOfOrderPoints.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(3), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestSeriesAreKeyedByNodeAndLabels(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{})
+	require.NoError(t, err)
+
+	withLabel := func(m *metricspb.Metric, value string) *metricspb.Metric {
+		m.Timeseries[0].LabelValues = []*metricspb.LabelValue{{Value: value, HasValue: true}}
+		return m
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), metricsData(withLabel(gauge("a", 10), "x"))))
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), metricsData(withLabel(gauge("a", 5), "y"))))
+
+	other := metricsData(withLabel(gauge("a", 5), "x"))
+	other.Node = &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "other"}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), other))
+
+	assert.Len(t, sink.AllMetrics(), 3)
+}
+
+func TestSeriesAreKeyedByResourceAndLabelKeys(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{})
+	require.NoError(t, err)
+
+	withLabel := func(m *metricspb.Metric, key, value string) *metricspb.Metric {
+		m.MetricDescriptor.LabelKeys = []*metricspb.LabelKey{{Key: key}}
+		m.Timeseries[0].LabelValues = []*metricspb.LabelValue{{Value: value, HasValue: true}}
+		return m
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), metricsData(withLabel(gauge("a", 10), "k", "x"))))
+	// Another label key with the same value is another series.
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), metricsData(withLabel(gauge("a", 5), "l", "x"))))
+	// So is the same series of another resource.
+	other := withLabel(gauge("a", 5), "k", "x")
+	other.Resource = &resourcepb.Resource{Type: "container", Labels: map[string]string{"id": "1"}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), metricsData(other)))
+
+	assert.Len(t, sink.AllMetrics(), 3)
+}
+
+func TestReorderWithinBatch(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{Reorder: true})
+	require.NoError(t, err)
+
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), metricsData(gauge("a", 3))))
+	received := gauge("a", 6, 4, 2, 5)
+	original := proto.Clone(received)
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), metricsData(received)))
+	assert.True(t, proto.Equal(original, received), "the metrics may be shared, they must not be modified")
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	assert.Equal(t, []int64{4, 5, 6}, pointSeconds(got[1].Metrics[0]))
+}
+
+func TestSeriesStateIsBounded(t *testing.T) {
+	sc := newSeriesCache(2)
+	sc.set("a", 1)
+	sc.set("b", 1)
+	sc.set("a", 2)
+	sc.set("c", 1)
+
+	assert.Equal(t, 2, sc.len())
+	_, ok := sc.get("b")
+	assert.False(t, ok, "least recently updated series must be evicted")
+	last, ok := sc.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), last)
+}
+
+func metricsData(metrics ...*metricspb.Metric) consumerdata.MetricsData {
+	return consumerdata.MetricsData{
+		Node:    &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "svc"}},
+		Metrics: metrics,
+	}
+}
+
+func gauge(name string, seconds ...int64) *metricspb.Metric {
+	points := make([]*metricspb.Point, 0, len(seconds))
+	for _, s := range seconds {
+		points = append(points, &metricspb.Point{
+			Timestamp: &timestamp.Timestamp{Seconds: s},
+			Value:     &metricspb.Point_DoubleValue{DoubleValue: float64(s)},
+		})
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Type: metricspb.MetricDescriptor_GAUGE_DOUBLE},
+		Timeseries:       []*metricspb.TimeSeries{{Points: points}},
+	}
+}
+
+func pointSeconds(m *metricspb.Metric) []int64 {
+	var seconds []int64
+	for _, p := range m.Timeseries[0].Points {
+		seconds = append(seconds, p.Timestamp.Seconds)
+	}
+	return seconds
+}
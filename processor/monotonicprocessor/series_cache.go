@@ -0,0 +1,49 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monotonicprocessor
+
+import (
+	"github.com/open-telemetry/opentelemetry-service/internal/lru"
+)
+
+// seriesCache is a bounded cache of the last exported timestamp, in
+// nanoseconds since epoch, per series. It is not safe for concurrent use.
+type seriesCache struct {
+	entries *lru.Cache
+}
+
+func newSeriesCache(maxSeries int) *seriesCache {
+	return &seriesCache{entries: lru.New(maxSeries)}
+}
+
+// get returns the last exported timestamp of the given series.
+func (sc *seriesCache) get(key string) (int64, bool) {
+	last, ok := sc.entries.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return last.(int64), true
+}
+
+// set records the last exported timestamp of the given series, evicting the
+// least recently updated series as needed to stay within bounds.
+func (sc *seriesCache) set(key string, last int64) {
+	sc.entries.Add(key, last)
+}
+
+// len returns the number of series in the cache.
+func (sc *seriesCache) len() int {
+	return sc.entries.Len()
+}
@@ -0,0 +1,39 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nameescapingprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Direction is how the processor converts the metric names.
+type Direction string
+
+const (
+	// EscapeDirection escapes the metric names which are not valid legacy
+	// Prometheus names, for backends only supporting those.
+	EscapeDirection Direction = "escape"
+	// UnescapeDirection restores the metric names escaped by EscapeDirection.
+	UnescapeDirection Direction = "unescape"
+)
+
+// Config defines configuration for the name escaping processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Direction is either "escape", the default, or "unescape". The names are
+	// escaped reversibly: they get the "U__" prefix, underscores are doubled
+	// and the other characters not allowed in legacy names are replaced by
+	// their code point in hexadecimal between underscores, e.g. "http.status"
+	// becomes "U__http_2e_status".
+	Direction Direction `mapstructure:"direction"`
+}
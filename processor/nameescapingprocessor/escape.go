@@ -0,0 +1,110 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nameescapingprocessor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// escapedPrefix starts the escaped names, telling them from the names which
+// were valid legacy names already.
+const escapedPrefix = "U__"
+
+// escapeName returns the legacy Prometheus name escaping the given name. The
+// valid legacy names are returned as they are, except those starting with the
+// escaped prefix, which are escaped for unescapeName to restore them as well.
+func escapeName(name string) string {
+	if name == "" || (isLegacyName(name) && !strings.HasPrefix(name, escapedPrefix)) {
+		return name
+	}
+
+	var b strings.Builder
+	b.WriteString(escapedPrefix)
+	for i, r := range name {
+		switch {
+		case r == '_':
+			b.WriteString("__")
+		case isLegacyRune(r, i):
+			b.WriteRune(r)
+		case r == utf8.RuneError:
+			// Invalid UTF-8 can't be restored, it is escaped as the
+			// replacement character.
+			fmt.Fprintf(&b, "_%X_", utf8.RuneError)
+		default:
+			fmt.Fprintf(&b, "_%x_", r)
+		}
+	}
+	return b.String()
+}
+
+// unescapeName restores the name escaped by escapeName. The names without the
+// escaped prefix are returned as they are, false is returned for the names
+// with the prefix which are not validly escaped.
+func unescapeName(name string) (string, bool) {
+	if !strings.HasPrefix(name, escapedPrefix) {
+		return name, true
+	}
+
+	escaped := name[len(escapedPrefix):]
+	var b strings.Builder
+	for i := 0; i < len(escaped); {
+		c := escaped[i]
+		if c != '_' {
+			if !isLegacyRune(rune(c), i) {
+				return name, false
+			}
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if i+1 < len(escaped) && escaped[i+1] == '_' {
+			b.WriteByte('_')
+			i += 2
+			continue
+		}
+		end := strings.IndexByte(escaped[i+1:], '_')
+		if end <= 0 {
+			return name, false
+		}
+		code, err := strconv.ParseUint(escaped[i+1:i+1+end], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return name, false
+		}
+		b.WriteRune(rune(code))
+		i += end + 2
+	}
+	if b.Len() == 0 {
+		return name, false
+	}
+	return b.String(), true
+}
+
+func isLegacyName(name string) bool {
+	for i, r := range name {
+		if !isLegacyRune(r, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLegacyRune returns whether the rune at index i is allowed in a legacy
+// Prometheus metric name.
+func isLegacyRune(r rune, i int) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || r == ':' || (r >= '0' && r <= '9' && i > 0)
+}
@@ -0,0 +1,64 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nameescapingprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeName(t *testing.T) {
+	tests := []struct {
+		name    string
+		escaped string
+	}{
+		{name: "http_requests_total", escaped: "http_requests_total"},
+		{name: "http.server.duration", escaped: "U__http_2e_server_2e_duration"},
+		{name: "queue length", escaped: "U__queue_20_length"},
+		{name: "queue_length bytes", escaped: "U__queue__length_20_bytes"},
+		{name: "température", escaped: "U__temp_e9_rature"},
+		{name: "延迟", escaped: "U___5ef6__8fdf_"},
+		{name: "2xx_responses", escaped: "U___32_xx__responses"},
+		{name: "U__legacy", escaped: "U__U____legacy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escaped := escapeName(tt.name)
+			assert.Equal(t, tt.escaped, escaped)
+			assert.True(t, isLegacyName(escaped))
+
+			unescaped, ok := unescapeName(escaped)
+			assert.True(t, ok)
+			assert.Equal(t, tt.name, unescaped)
+		})
+	}
+}
+
+func TestUnescapeInvalidName(t *testing.T) {
+	for _, name := range []string{"U__", "U__a_", "U__a_zz_b", "U___110000_", "U__a.b"} {
+		unescaped, ok := unescapeName(name)
+		assert.False(t, ok, name)
+		assert.Equal(t, name, unescaped)
+	}
+}
+
+func TestEscapeInvalidUTF8(t *testing.T) {
+	escaped := escapeName("bad\xffname")
+	assert.Equal(t, "U__bad_FFFD_name", escaped)
+	unescaped, ok := unescapeName(escaped)
+	assert.True(t, ok)
+	assert.Equal(t, "bad�name", unescaped)
+}
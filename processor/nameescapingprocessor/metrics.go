@@ -0,0 +1,53 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nameescapingprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	statRenamedMetrics = stats.Int64("name_escaping_renamed_metrics", "Number of metrics whose name was escaped or unescaped", stats.UnitDimensionless)
+	statNameCollisions = stats.Int64("name_escaping_name_collisions", "Number of metrics not renamed because the new name was already used by another metric", stats.UnitDimensionless)
+)
+
+// MetricViews returns the metrics views related to the name escaping processor.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	tagKeys := []tag.Key{processor.TagExporterNameKey}
+	renamedView := &view.View{
+		Name:        statRenamedMetrics.Name(),
+		Measure:     statRenamedMetrics,
+		Description: statRenamedMetrics.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	collisionsView := &view.View{
+		Name:        statNameCollisions.Name(),
+		Measure:     statNameCollisions,
+		Description: statNameCollisions.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	return []*view.View{renamedView, collisionsView}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nameescapingprocessor
+
+import (
+	"context"
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type nameEscapingProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	direction    Direction
+	statsTags    []tag.Mutator
+}
+
+var _ processor.MetricsProcessor = (*nameEscapingProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that escapes the
+// UTF-8 metric names into legacy Prometheus names, or unescapes them,
+// depending on the direction of the config.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	direction := cfg.Direction
+	switch direction {
+	case "":
+		direction = EscapeDirection
+	case EscapeDirection, UnescapeDirection:
+	default:
+		return nil, fmt.Errorf("unknown direction %q, must be either %q or %q",
+			cfg.Direction, EscapeDirection, UnescapeDirection)
+	}
+
+	return &nameEscapingProcessor{
+		nextConsumer: nextConsumer,
+		direction:    direction,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (nep *nameEscapingProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	names := make(map[string]bool, len(md.Metrics))
+	for _, metric := range md.Metrics {
+		names[metric.GetMetricDescriptor().GetName()] = true
+	}
+
+	var metrics []*metricspb.Metric
+	renamed, collisions := 0, 0
+	for i, metric := range md.Metrics {
+		desc := metric.GetMetricDescriptor()
+		if desc.GetName() == "" {
+			continue
+		}
+		name := nep.rename(desc.Name)
+		if name == desc.Name {
+			continue
+		}
+		if names[name] {
+			// Another metric of the batch already has the name, renaming the
+			// metric would mix both.
+			collisions++
+			continue
+		}
+
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = withName(metric, name)
+		renamed++
+	}
+
+	if renamed > 0 {
+		stats.RecordWithTags(context.Background(), nep.statsTags, statRenamedMetrics.M(int64(renamed)))
+		md.Metrics = metrics
+	}
+	if collisions > 0 {
+		stats.RecordWithTags(context.Background(), nep.statsTags, statNameCollisions.M(int64(collisions)))
+	}
+	return nep.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// rename returns the name of the metric according to the direction. The names
+// which are not validly escaped are left as they are.
+func (nep *nameEscapingProcessor) rename(name string) string {
+	if nep.direction == UnescapeDirection {
+		unescaped, _ := unescapeName(name)
+		return unescaped
+	}
+	return escapeName(name)
+}
+
+// withName returns a copy of the metric with the given name.
+func withName(metric *metricspb.Metric, name string) *metricspb.Metric {
+	desc := *metric.MetricDescriptor
+	desc.Name = name
+	return &metricspb.Metric{
+		MetricDescriptor: &desc,
+		Resource:         metric.Resource,
+		Timeseries:       metric.Timeseries,
+	}
+}
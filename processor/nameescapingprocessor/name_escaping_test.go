@@ -0,0 +1,145 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nameescapingprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+)
+
+func TestEscapeDirection(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			testMetric("http.server.duration"),
+			testMetric("queue length"),
+			testMetric("requests_total"),
+		},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, []string{"U__http_2e_server_2e_duration", "U__queue_20_length", "requests_total"},
+		metricNames(got[0]))
+	// The received metric is shared with other pipelines, it is not modified.
+	assert.Equal(t, "http.server.duration", md.Metrics[0].MetricDescriptor.Name)
+	assert.Equal(t, md.Metrics[0].Timeseries, got[0].Metrics[0].Timeseries)
+}
+
+func TestUnescapeDirection(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Direction: UnescapeDirection})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			testMetric("U__http_2e_server_2e_duration"),
+			testMetric("U__a_zz_b"),
+			testMetric("requests_total"),
+		},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	// The names which are not validly escaped are left as they are.
+	assert.Equal(t, []string{"http.server.duration", "U__a_zz_b", "requests_total"}, metricNames(got[0]))
+}
+
+func TestRoundTrip(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	unescape, err := NewMetricsProcessor(sink, Config{Direction: UnescapeDirection})
+	require.NoError(t, err)
+	escape, err := NewMetricsProcessor(unescape, Config{Direction: EscapeDirection})
+	require.NoError(t, err)
+
+	names := []string{"queue length", "température", "延迟 ms", "http.server.duration", "requests_total", "U__legacy"}
+	md := consumerdata.MetricsData{}
+	for _, name := range names {
+		md.Metrics = append(md.Metrics, testMetric(name))
+	}
+	require.NoError(t, escape.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, names, metricNames(got[0]))
+}
+
+func TestNameCollisions(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkMetricsExporter)
+	cfg := Config{ProcessorSettings: configmodels.ProcessorSettings{NameVal: "name_escaping/collisions"}}
+	mp, err := NewMetricsProcessor(sink, cfg)
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			testMetric("queue length"),
+			testMetric("U__queue_20_length"),
+			testMetric("queue.size"),
+		},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	// "U__queue_20_length" is a legacy name starting with the escaped prefix, it is escaped as well.
+	assert.Equal(t, []string{"queue length", "U__U____queue__20__length", "U__queue_2e_size"}, metricNames(got[0]))
+
+	rows, err := view.RetrieveData(statNameCollisions.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+
+	rows, err = view.RetrieveData(statRenamedMetrics.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func testMetric(name string) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Type: metricspb.MetricDescriptor_GAUGE_INT64},
+		Timeseries: []*metricspb.TimeSeries{
+			{Points: []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}}},
+		},
+	}
+}
+
+func metricNames(md consumerdata.MetricsData) []string {
+	var names []string
+	for _, metric := range md.Metrics {
+		names = append(names, metric.MetricDescriptor.Name)
+	}
+	return names
+}
@@ -0,0 +1,28 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package percentilesprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the percentiles processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Percentiles are the percentiles computed from every histogram, between 0
+	// and 100. Defaults to 50, 95 and 99.
+	Percentiles []float64 `mapstructure:"percentiles"`
+	// KeepHistograms passes the histograms along with their percentiles,
+	// otherwise the histograms are replaced by their percentiles.
+	KeepHistograms bool `mapstructure:"keep_histograms"`
+}
@@ -0,0 +1,161 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package percentilesprocessor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/internal/histogram"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// defaultPercentiles are the percentiles computed when none are configured.
+var defaultPercentiles = []float64{50, 95, 99}
+
+type percentilesProcessor struct {
+	nextConsumer   consumer.MetricsConsumer
+	percentiles    []float64
+	suffixes       []string
+	keepHistograms bool
+}
+
+var _ processor.MetricsProcessor = (*percentilesProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that computes the
+// configured percentiles of every histogram, by interpolation within its
+// buckets, and emits each of them as a gauge named after the histogram and
+// the percentile, e.g. "latency_p95", with the labels of the histogram.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	percentiles := cfg.Percentiles
+	if len(percentiles) == 0 {
+		percentiles = defaultPercentiles
+	}
+	suffixes := make([]string, len(percentiles))
+	seen := make(map[string]bool, len(percentiles))
+	for i, p := range percentiles {
+		if math.IsNaN(p) || p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile %v must be between 0 and 100", p)
+		}
+		suffixes[i] = percentileSuffix(p)
+		if seen[suffixes[i]] {
+			return nil, fmt.Errorf("duplicate percentile %v", p)
+		}
+		seen[suffixes[i]] = true
+	}
+
+	return &percentilesProcessor{
+		nextConsumer:   nextConsumer,
+		percentiles:    percentiles,
+		suffixes:       suffixes,
+		keepHistograms: cfg.KeepHistograms,
+	}, nil
+}
+
+// percentileSuffix returns the suffix of the name of the gauges of the given
+// percentile, e.g. "_p95" or "_p99_9" for 99.9.
+func percentileSuffix(p float64) string {
+	return "_p" + strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", 1)
+}
+
+func (pp *percentilesProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		if !isHistogram(metric) {
+			if metrics != nil {
+				metrics = append(metrics, metric)
+			}
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, 0, len(md.Metrics)+len(pp.percentiles))
+			metrics = append(metrics, md.Metrics[:i]...)
+		}
+		if pp.keepHistograms {
+			metrics = append(metrics, metric)
+		}
+		for j := range pp.percentiles {
+			if gauge := pp.percentileGauge(metric, j); gauge != nil {
+				metrics = append(metrics, gauge)
+			}
+		}
+	}
+	if metrics == nil {
+		return pp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+	md.Metrics = metrics
+	return pp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+func isHistogram(metric *metricspb.Metric) bool {
+	switch metric.GetMetricDescriptor().GetType() {
+	case metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION, metricspb.MetricDescriptor_GAUGE_DISTRIBUTION:
+		return true
+	}
+	return false
+}
+
+// percentileGauge returns the gauge of the percentile at the given index for
+// the given histogram, nil if it cannot be computed for any of its points.
+func (pp *percentilesProcessor) percentileGauge(metric *metricspb.Metric, index int) *metricspb.Metric {
+	q := pp.percentiles[index] / 100
+	var timeseries []*metricspb.TimeSeries
+	for _, ts := range metric.Timeseries {
+		var points []*metricspb.Point
+		for _, point := range ts.GetPoints() {
+			value, ok := histogram.Quantile(point.GetDistributionValue(), q)
+			if !ok {
+				continue
+			}
+			points = append(points, &metricspb.Point{
+				Timestamp: point.Timestamp,
+				Value:     &metricspb.Point_DoubleValue{DoubleValue: value},
+			})
+		}
+		if len(points) > 0 {
+			timeseries = append(timeseries, &metricspb.TimeSeries{LabelValues: ts.LabelValues, Points: points})
+		}
+	}
+	if len(timeseries) == 0 {
+		return nil
+	}
+
+	desc := metric.MetricDescriptor
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        desc.Name + pp.suffixes[index],
+			Description: desc.Description,
+			Unit:        desc.Unit,
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys:   desc.LabelKeys,
+		},
+		Resource:   metric.Resource,
+		Timeseries: timeseries,
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package percentilesprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+var testTimestamp = &timestamp.Timestamp{Seconds: 1562000000}
+
+func TestNewProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestPercentileSuffix(t *testing.T) {
+	assert.Equal(t, "_p50", percentileSuffix(50))
+	assert.Equal(t, "_p99_9", percentileSuffix(99.9))
+	assert.Equal(t, "_p0", percentileSuffix(0))
+}
+
+func TestPercentileGauges(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{KeepHistograms: true})
+	require.NoError(t, err)
+
+	// 10 observations in each 10ms bucket from 0 to 100ms.
+	latency := histogramMetric("latency", []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100},
+		[]int64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 0})
+	counter := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: "requests", Type: metricspb.MetricDescriptor_CUMULATIVE_INT64},
+	}
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{counter, latency}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	metrics := got[0].Metrics
+	require.Len(t, metrics, 5)
+	assert.Equal(t, counter, metrics[0])
+	assert.Equal(t, latency, metrics[1])
+	for i, want := range []struct {
+		name  string
+		value float64
+	}{{"latency_p50", 50}, {"latency_p95", 95}, {"latency_p99", 99}} {
+		gauge := metrics[i+2]
+		assert.Equal(t, want.name, gauge.MetricDescriptor.Name)
+		assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, gauge.MetricDescriptor.Type)
+		assert.Equal(t, "ms", gauge.MetricDescriptor.Unit)
+		assert.Equal(t, latency.MetricDescriptor.LabelKeys, gauge.MetricDescriptor.LabelKeys)
+		require.Len(t, gauge.Timeseries, 1)
+		assert.Equal(t, latency.Timeseries[0].LabelValues, gauge.Timeseries[0].LabelValues)
+		require.Len(t, gauge.Timeseries[0].Points, 1)
+		assert.Equal(t, testTimestamp, gauge.Timeseries[0].Points[0].Timestamp)
+		assert.InDelta(t, want.value, gauge.Timeseries[0].Points[0].GetDoubleValue(), 1e-9, want.name)
+	}
+	// The received batch is shared with other pipelines, it is not modified.
+	assert.Len(t, md.Metrics, 2)
+}
+
+func TestReplaceHistograms(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Percentiles: []float64{99.9}})
+	require.NoError(t, err)
+
+	latency := histogramMetric("latency", []float64{1, 2}, []int64{0, 1000, 0})
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{latency}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	gauge := got[0].Metrics[0]
+	assert.Equal(t, "latency_p99_9", gauge.MetricDescriptor.Name)
+	assert.InDelta(t, 1.999, gauge.Timeseries[0].Points[0].GetDoubleValue(), 1e-9)
+}
+
+func TestOverflowBucketOnlyHistogram(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{KeepHistograms: true})
+	require.NoError(t, err)
+
+	// A histogram without explicit bounds only has the +Inf bucket, its percentiles are unknown.
+	latency := histogramMetric("latency", nil, []int64{10})
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{latency}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, []*metricspb.Metric{latency}, got[0].Metrics)
+}
+
+func TestObservationsInOverflowBucket(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Percentiles: []float64{50}})
+	require.NoError(t, err)
+
+	// The percentiles falling in the +Inf bucket are the largest explicit bound.
+	latency := histogramMetric("latency", []float64{10, 20}, []int64{1, 1, 8})
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{latency}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	assert.Equal(t, float64(20), got[0].Metrics[0].Timeseries[0].Points[0].GetDoubleValue())
+}
+
+func histogramMetric(name string, bounds []float64, counts []int64) *metricspb.Metric {
+	buckets := make([]*metricspb.DistributionValue_Bucket, len(counts))
+	var total int64
+	for i, count := range counts {
+		buckets[i] = &metricspb.DistributionValue_Bucket{Count: count}
+		total += count
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Unit:      "ms",
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION,
+			LabelKeys: []*metricspb.LabelKey{{Key: "route"}},
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			LabelValues: []*metricspb.LabelValue{{Value: "/checkout", HasValue: true}},
+			Points: []*metricspb.Point{{
+				Timestamp: testTimestamp,
+				Value: &metricspb.Point_DistributionValue{DistributionValue: &metricspb.DistributionValue{
+					Count: total,
+					BucketOptions: &metricspb.DistributionValue_BucketOptions{
+						Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+							Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: bounds},
+						},
+					},
+					Buckets: buckets,
+				}},
+			}},
+		}},
+	}
+}
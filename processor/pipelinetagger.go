@@ -0,0 +1,64 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+)
+
+// This file contains implementations of Trace/Metrics decorators that add the
+// name of the pipeline the data flows through to the observability context,
+// so that the stats recorded downstream, e.g. by an exporter shared by several
+// pipelines, are attributable per pipeline.
+
+// NewMetricsPipelineTagger wraps the first metrics consumer of a pipeline so
+// that the data it receives is tagged with the name of the pipeline.
+func NewMetricsPipelineTagger(pipelineName string, next consumer.MetricsConsumer) MetricsProcessor {
+	return &metricsPipelineTagger{pipelineName: pipelineName, next: next}
+}
+
+type metricsPipelineTagger struct {
+	pipelineName string
+	next         consumer.MetricsConsumer
+}
+
+var _ MetricsProcessor = (*metricsPipelineTagger)(nil)
+
+// ConsumeMetricsData passes the MetricsData to the wrapped consumer with the pipeline name in the context.
+func (mpt *metricsPipelineTagger) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	return mpt.next.ConsumeMetricsData(observability.ContextWithPipelineName(ctx, mpt.pipelineName), md)
+}
+
+// NewTracePipelineTagger wraps the first trace consumer of a pipeline so that
+// the data it receives is tagged with the name of the pipeline.
+func NewTracePipelineTagger(pipelineName string, next consumer.TraceConsumer) TraceProcessor {
+	return &tracePipelineTagger{pipelineName: pipelineName, next: next}
+}
+
+type tracePipelineTagger struct {
+	pipelineName string
+	next         consumer.TraceConsumer
+}
+
+var _ TraceProcessor = (*tracePipelineTagger)(nil)
+
+// ConsumeTraceData passes the TraceData to the wrapped consumer with the pipeline name in the context.
+func (tpt *tracePipelineTagger) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	return tpt.next.ConsumeTraceData(observability.ContextWithPipelineName(ctx, tpt.pipelineName), td)
+}
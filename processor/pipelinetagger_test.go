@@ -0,0 +1,75 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+)
+
+// exporterStub records the exporter stats the same way exporterhelper does.
+type exporterStub struct{}
+
+func (e *exporterStub) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	observability.RecordMetricsForTraceExporter(observability.ContextWithExporterName(ctx, "exp"), len(td.Spans), 0)
+	return nil
+}
+
+func (e *exporterStub) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	observability.RecordMetricsForMetricsExporter(observability.ContextWithExporterName(ctx, "exp"), len(md.Metrics), 0)
+	return nil
+}
+
+func TestTracePipelineTagger(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	exp := &exporterStub{}
+	ptA := NewTracePipelineTagger("traces/a", exp)
+	ptB := NewTracePipelineTagger("traces/b", exp)
+
+	ctx := observability.ContextWithReceiverName(context.Background(), "recv")
+	require.NoError(t, ptA.ConsumeTraceData(ctx, consumerdata.TraceData{Spans: make([]*tracepb.Span, 3)}))
+	require.NoError(t, ptB.ConsumeTraceData(ctx, consumerdata.TraceData{Spans: make([]*tracepb.Span, 5)}))
+	require.NoError(t, ptA.ConsumeTraceData(ctx, consumerdata.TraceData{Spans: make([]*tracepb.Span, 2)}))
+
+	require.NoError(t, observabilitytest.CheckValueViewPipelineExporterReceivedSpans("recv", "traces/a", "exp", 5))
+	require.NoError(t, observabilitytest.CheckValueViewPipelineExporterReceivedSpans("recv", "traces/b", "exp", 5))
+	require.Error(t, observabilitytest.CheckValueViewExporterReceivedSpans("recv", "exp", 10))
+}
+
+func TestMetricsPipelineTagger(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	exp := &exporterStub{}
+	ptA := NewMetricsPipelineTagger("metrics/a", exp)
+	ptB := NewMetricsPipelineTagger("metrics/b", exp)
+
+	ctx := observability.ContextWithReceiverName(context.Background(), "recv")
+	require.NoError(t, ptA.ConsumeMetricsData(ctx, consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 4)}))
+	require.NoError(t, ptB.ConsumeMetricsData(ctx, consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 1)}))
+
+	require.NoError(t, observabilitytest.CheckValueViewPipelineExporterReceivedTimeSeries("recv", "metrics/a", "exp", 4))
+	require.NoError(t, observabilitytest.CheckValueViewPipelineExporterReceivedTimeSeries("recv", "metrics/b", "exp", 1))
+}
@@ -33,6 +33,23 @@ type MetricsProcessor interface {
 	// TODO: Add processor specific functions.
 }
 
+// Shutdowner is implemented by the processors holding data to flush or
+// resources to release when the pipelines are shut down.
+type Shutdowner interface {
+	// Shutdown flushes the data held by the processor down the pipeline and
+	// releases its resources. It is called once the receivers are stopped,
+	// before the exporters are shut down.
+	Shutdown() error
+}
+
+// PipelineAware is implemented by the processors reporting stats per pipeline.
+// The service sets the name of the pipeline of such a processor once it is
+// built, before the data flows.
+type PipelineAware interface {
+	// SetPipelineName sets the name of the pipeline of the processor.
+	SetPipelineName(name string)
+}
+
 // Processor is a data consumer.
 type Processor interface {
 	consumer.DataConsumer
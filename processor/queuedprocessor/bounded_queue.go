@@ -0,0 +1,165 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queuedprocessor
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// OverflowPolicy defines what happens to a batch produced while the queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDropNewest drops the produced batch, keeping the queued ones.
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowDropOldest drops the oldest queued batch to make room for the
+	// produced one.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowBlock blocks the producer until the queue has room for the
+	// batch, pushing back on the receivers.
+	OverflowBlock OverflowPolicy = "block"
+)
+
+// parseOverflowPolicy returns the overflow policy with the given name,
+// defaulting to OverflowDropNewest.
+func parseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(name); policy {
+	case "":
+		return OverflowDropNewest, nil
+	case OverflowDropNewest, OverflowDropOldest, OverflowBlock:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown overflow_policy %q, must be one of %q, %q or %q",
+		name, OverflowDropNewest, OverflowDropOldest, OverflowBlock)
+}
+
+// boundedQueue is a FIFO queue of bounded capacity, consumed by a pool of
+// workers. Unlike the jaeger bounded queue, the producer chooses how a full
+// queue is handled, see OverflowPolicy.
+type boundedQueue struct {
+	capacity int
+	// onEvictedItem is called with the queued items dropped to make room for
+	// new ones.
+	onEvictedItem func(item interface{})
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	items    *list.List
+	stopped  bool
+	stopWG   sync.WaitGroup
+}
+
+func newBoundedQueue(capacity int, onEvictedItem func(item interface{})) *boundedQueue {
+	q := &boundedQueue{
+		capacity:      capacity,
+		onEvictedItem: onEvictedItem,
+		items:         list.New(),
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	return q
+}
+
+// StartConsumers starts the given number of goroutines consuming the items
+// from the queue and passing them to the consumer callback.
+func (q *boundedQueue) StartConsumers(num int, consumer func(item interface{})) {
+	for i := 0; i < num; i++ {
+		q.stopWG.Add(1)
+		go func() {
+			defer q.stopWG.Done()
+			for {
+				item, ok := q.take()
+				if !ok {
+					return
+				}
+				consumer(item)
+			}
+		}()
+	}
+}
+
+// take waits for an item and removes it from the queue. It returns false once
+// the queue is stopped.
+func (q *boundedQueue) take() (interface{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.items.Len() == 0 && !q.stopped {
+		q.notEmpty.Wait()
+	}
+	if q.stopped {
+		return nil, false
+	}
+	item := q.items.Remove(q.items.Front())
+	q.notFull.Signal()
+	return item, true
+}
+
+// Produce adds the item to the queue, handling a full queue according to the
+// given policy. It returns whether the item was added and whether the queue
+// was full. Items are never added once the queue is stopped.
+func (q *boundedQueue) Produce(item interface{}, policy OverflowPolicy) (added bool, overflowed bool) {
+	var evicted interface{}
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return false, false
+	}
+	if q.items.Len() >= q.capacity {
+		overflowed = true
+		switch policy {
+		case OverflowDropOldest:
+			evicted = q.items.Remove(q.items.Front())
+		case OverflowBlock:
+			for q.items.Len() >= q.capacity && !q.stopped {
+				q.notFull.Wait()
+			}
+		default:
+			q.mu.Unlock()
+			return false, true
+		}
+	}
+	if !q.stopped {
+		q.items.PushBack(item)
+		q.notEmpty.Signal()
+		added = true
+	}
+	q.mu.Unlock()
+
+	if evicted != nil && q.onEvictedItem != nil {
+		q.onEvictedItem(evicted)
+	}
+	return added, overflowed
+}
+
+// Stop stops the consumers and unblocks the blocked producers. It waits until
+// all the consumers have stopped, the items left in the queue are discarded.
+func (q *boundedQueue) Stop() {
+	q.mu.Lock()
+	q.stopped = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mu.Unlock()
+	q.stopWG.Wait()
+}
+
+// Size returns the current number of items in the queue.
+func (q *boundedQueue) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
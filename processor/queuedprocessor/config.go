@@ -32,4 +32,11 @@ type Config struct {
 	RetryOnFailure bool `mapstructure:"retry_on_failure"`
 	// BackoffDelay is the amount of time a worker waits after a failed send before retrying.
 	BackoffDelay time.Duration `mapstructure:"backoff_delay"`
+	// RetryBudget is the maximum amount of time a batch is retried after its first failed send. Once exceeded the
+	// batch is dropped and counted as lost. Zero means that batches are retried without a time limit.
+	RetryBudget time.Duration `mapstructure:"retry_budget"`
+	// OverflowPolicy defines how a batch is handled when the queue is full: "drop_newest" drops it, "drop_oldest"
+	// drops the oldest queued batch instead and "block" waits until the queue has room for it. Retried batches are
+	// dropped rather than blocking when the queue is full.
+	OverflowPolicy string `mapstructure:"overflow_policy"`
 }
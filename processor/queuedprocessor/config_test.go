@@ -51,5 +51,7 @@ func TestLoadConfig(t *testing.T) {
 			QueueSize:      10,
 			RetryOnFailure: true,
 			BackoffDelay:   time.Second * 5,
+			RetryBudget:    time.Minute,
+			OverflowPolicy: "drop_oldest",
 		})
 }
@@ -50,6 +50,7 @@ func (f *Factory) CreateDefaultConfig() configmodels.Processor {
 		QueueSize:      5000,
 		RetryOnFailure: true,
 		BackoffDelay:   time.Second * 5,
+		OverflowPolicy: string(OverflowDropNewest),
 	}
 }
 
@@ -60,11 +61,17 @@ func (f *Factory) CreateTraceProcessor(
 	cfg configmodels.Processor,
 ) (processor.TraceProcessor, error) {
 	oCfg := cfg.(*Config)
+	overflowPolicy, err := parseOverflowPolicy(oCfg.OverflowPolicy)
+	if err != nil {
+		return nil, err
+	}
 	return NewQueuedSpanProcessor(nextConsumer,
 		Options.WithNumWorkers(oCfg.NumWorkers),
 		Options.WithQueueSize(oCfg.QueueSize),
 		Options.WithRetryOnProcessingFailures(oCfg.RetryOnFailure),
 		Options.WithBackoffDelay(oCfg.BackoffDelay),
+		Options.WithRetryBudget(oCfg.RetryBudget),
+		Options.WithOverflowPolicy(overflowPolicy),
 	), nil
 }
 
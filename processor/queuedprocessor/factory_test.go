@@ -40,3 +40,13 @@ func TestCreateProcessor(t *testing.T) {
 	assert.Nil(t, mp)
 	assert.Error(t, err, "should not be able to create metric processor")
 }
+
+func TestCreateProcessorInvalidOverflowPolicy(t *testing.T) {
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.OverflowPolicy = "drop_random"
+
+	tp, err := factory.CreateTraceProcessor(zap.NewNop(), nil, cfg)
+	assert.Nil(t, tp)
+	assert.Error(t, err, "should not be able to create processor with an unknown overflow policy")
+}
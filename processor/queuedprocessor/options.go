@@ -35,6 +35,8 @@ type options struct {
 	numWorkers               int
 	queueSize                int
 	backoffDelay             time.Duration
+	retryBudget              time.Duration
+	overflowPolicy           OverflowPolicy
 	extraFormatTypes         []string
 	retryOnProcessingFailure bool
 	batchingEnabled          bool
@@ -82,6 +84,20 @@ func (options) WithBackoffDelay(backoffDelay time.Duration) Option {
 	}
 }
 
+// WithRetryBudget creates an Option that initializes the maximum time a batch is retried after its first failure
+func (options) WithRetryBudget(retryBudget time.Duration) Option {
+	return func(b *options) {
+		b.retryBudget = retryBudget
+	}
+}
+
+// WithOverflowPolicy creates an Option that initializes how batches are handled when the queue is full
+func (options) WithOverflowPolicy(overflowPolicy OverflowPolicy) Option {
+	return func(b *options) {
+		b.overflowPolicy = overflowPolicy
+	}
+}
+
 // WithExtraFormatTypes creates an Option that initializes the extra list of format types
 func (options) WithExtraFormatTypes(extraFormatTypes []string) Option {
 	return func(b *options) {
@@ -125,5 +141,8 @@ func (o options) apply(opts ...Option) options {
 	if ret.queueSize == 0 {
 		ret.queueSize = DefaultQueueSize
 	}
+	if ret.overflowPolicy == "" {
+		ret.overflowPolicy = OverflowDropNewest
+	}
 	return ret
 }
@@ -19,7 +19,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/jaegertracing/jaeger/pkg/queue"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
@@ -35,12 +34,15 @@ import (
 
 type queuedSpanProcessor struct {
 	name                     string
-	queue                    *queue.BoundedQueue
+	queue                    *boundedQueue
+	overflowPolicy           OverflowPolicy
+	overflowTags             []tag.Mutator
 	logger                   *zap.Logger
 	sender                   consumer.TraceConsumer
 	numWorkers               int
 	retryOnProcessingFailure bool
 	backoffDelay             time.Duration
+	retryBudget              time.Duration
 	stopCh                   chan struct{}
 	stopOnce                 sync.Once
 }
@@ -51,6 +53,8 @@ type queueItem struct {
 	queuedTime time.Time
 	td         consumerdata.TraceData
 	ctx        context.Context
+	// firstFailureTime is the time of the first failed send of the batch, zero if it never failed.
+	firstFailureTime time.Time
 }
 
 // NewQueuedSpanProcessor returns a span processor that maintains a bounded
@@ -91,17 +95,26 @@ func NewQueuedSpanProcessor(sender consumer.TraceConsumer, opts ...Option) consu
 }
 
 func newQueuedSpanProcessor(sender consumer.TraceConsumer, opts options) *queuedSpanProcessor {
-	boundedQueue := queue.NewBoundedQueue(opts.queueSize, func(item interface{}) {})
-	return &queuedSpanProcessor{
+	sp := &queuedSpanProcessor{
 		name:                     opts.name,
-		queue:                    boundedQueue,
+		overflowPolicy:           opts.overflowPolicy,
 		logger:                   opts.logger,
 		numWorkers:               opts.numWorkers,
 		sender:                   sender,
 		retryOnProcessingFailure: opts.retryOnProcessingFailure,
 		backoffDelay:             opts.backoffDelay,
+		retryBudget:              opts.retryBudget,
 		stopCh:                   make(chan struct{}),
+		overflowTags: []tag.Mutator{
+			tag.Upsert(processor.TagExporterNameKey, opts.name),
+			tag.Upsert(TagOverflowPolicyKey, string(opts.overflowPolicy)),
+		},
 	}
+	sp.queue = newBoundedQueue(opts.queueSize, func(item interface{}) {
+		value := item.(*queueItem)
+		sp.onItemDropped(value, processor.StatsTagsForBatch(sp.name, processor.ServiceNameForNode(value.td.Node), value.td.SourceFormat))
+	})
+	return sp
 }
 
 // Stop halts the span processor and all its goroutines.
@@ -124,13 +137,27 @@ func (sp *queuedSpanProcessor) ConsumeTraceData(ctx context.Context, td consumer
 	numSpans := len(td.Spans)
 	stats.RecordWithTags(context.Background(), statsTags, processor.StatReceivedSpanCount.M(int64(numSpans)))
 
-	addedToQueue := sp.queue.Produce(item)
-	if !addedToQueue {
+	if !sp.produce(item, sp.overflowPolicy) {
 		sp.onItemDropped(item, statsTags)
 	}
 	return nil
 }
 
+// produce adds the item to the queue according to the given overflow policy, recording the overflows of the queue.
+// It returns whether the item was added to the queue.
+func (sp *queuedSpanProcessor) produce(item *queueItem, policy OverflowPolicy) bool {
+	startTime := time.Now()
+	added, overflowed := sp.queue.Produce(item, policy)
+	if overflowed {
+		measurements := []stats.Measurement{statQueueOverflow.M(1)}
+		if policy == OverflowBlock {
+			measurements = append(measurements, statQueueBlockedLatencyMs.M(int64(time.Since(startTime)/time.Millisecond)))
+		}
+		stats.RecordWithTags(context.Background(), sp.overflowTags, measurements...)
+	}
+	return added
+}
+
 func (sp *queuedSpanProcessor) processItemFromQueue(item *queueItem) {
 	startTime := time.Now()
 	err := sp.sender.ConsumeTraceData(item.ctx, item.td)
@@ -173,14 +200,30 @@ func (sp *queuedSpanProcessor) processItemFromQueue(item *queueItem) {
 	stats.RecordWithTags(context.Background(), statsTags, statFailedSendOps.M(1))
 	batchSize := len(item.td.Spans)
 	sp.logger.Warn("Sender failed", zap.String("processor", sp.name), zap.Error(err), zap.String("spanFormat", item.td.SourceFormat))
+	if item.firstFailureTime.IsZero() {
+		item.firstFailureTime = startTime
+	}
 	if !sp.retryOnProcessingFailure {
 		// throw away the batch
 		sp.logger.Error("Failed to process batch, discarding", zap.String("processor", sp.name), zap.Int("batch-size", batchSize))
 		sp.onItemDropped(item, statsTags)
+	} else if sp.retryBudget > 0 && time.Since(item.firstFailureTime) >= sp.retryBudget {
+		// the batch has been retried for too long, consider it lost
+		sp.logger.Error("Failed to process batch within the retry budget, discarding",
+			zap.String("processor", sp.name),
+			zap.Int("batch-size", batchSize),
+			zap.Duration("retry_budget", sp.retryBudget))
+		stats.RecordWithTags(context.Background(), statsTags, statRetryBudgetExhausted.M(1))
+		sp.onItemDropped(item, statsTags)
 	} else {
 		// TODO: (@pjanotti) do not put it back on the end of the queue, retry with it directly.
 		// This will have the benefit of keeping the batch closer to related ones in time.
-		if !sp.queue.Produce(item) {
+		// The workers must not block on their own queue, a full queue drops the retried batch instead.
+		retryPolicy := sp.overflowPolicy
+		if retryPolicy == OverflowBlock {
+			retryPolicy = OverflowDropNewest
+		}
+		if !sp.produce(item, retryPolicy) {
 			sp.logger.Error("Failed to process batch and failed to re-enqueue", zap.String("processor", sp.name), zap.Int("batch-size", batchSize))
 			sp.onItemDropped(item, statsTags)
 		} else {
@@ -223,8 +266,16 @@ var (
 	statFailedSendOps  = stats.Int64("fail_send", "Number of failed send operations", stats.UnitDimensionless)
 
 	statQueueLength = stats.Int64("queue_length", "Current length of the queue (in batches)", stats.UnitDimensionless)
+
+	statRetryBudgetExhausted = stats.Int64("retry_budget_exhausted", "Number of batches dropped because they could not be sent within the retry budget", stats.UnitDimensionless)
+
+	statQueueOverflow         = stats.Int64("queue_overflow", "Number of batches produced while the queue was full", stats.UnitDimensionless)
+	statQueueBlockedLatencyMs = stats.Int64("queue_blocked_latency", "Latency (in milliseconds) that a producer was blocked on the full queue", stats.UnitMilliseconds)
 )
 
+// TagOverflowPolicyKey is the tag key of the overflow policy of the queue, see OverflowPolicy.
+var TagOverflowPolicyKey, _ = tag.NewKey("overflow_policy")
+
 // MetricViews return the metrics views according to given telemetry level.
 func MetricViews(level telemetry.Level) []*view.View {
 	if level == telemetry.None {
@@ -260,6 +311,23 @@ func MetricViews(level telemetry.Level) []*view.View {
 		Aggregation: view.Sum(),
 	}
 
+	countRetryBudgetExhaustedView := &view.View{
+		Name:        statRetryBudgetExhausted.Name(),
+		Measure:     statRetryBudgetExhausted,
+		Description: statRetryBudgetExhausted.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	overflowTagKeys := []tag.Key{processor.TagExporterNameKey, TagOverflowPolicyKey}
+	queueOverflowView := &view.View{
+		Name:        statQueueOverflow.Name(),
+		Measure:     statQueueOverflow,
+		Description: statQueueOverflow.Description(),
+		TagKeys:     overflowTagKeys,
+		Aggregation: view.Sum(),
+	}
+
 	latencyDistributionAggregation := view.Distribution(10, 25, 50, 75, 100, 250, 500, 750, 1000, 2000, 3000, 4000, 5000, 10000, 20000, 30000, 50000)
 
 	sendLatencyView := &view.View{
@@ -277,5 +345,14 @@ func MetricViews(level telemetry.Level) []*view.View {
 		Aggregation: latencyDistributionAggregation,
 	}
 
-	return []*view.View{queueLengthView, countSuccessSendView, countFailuresSendView, sendLatencyView, inQueueLatencyView}
+	queueBlockedLatencyView := &view.View{
+		Name:        statQueueBlockedLatencyMs.Name(),
+		Measure:     statQueueBlockedLatencyMs,
+		Description: "The time producers were blocked on the full queue, with the block overflow policy.",
+		TagKeys:     overflowTagKeys,
+		Aggregation: latencyDistributionAggregation,
+	}
+
+	return []*view.View{queueLengthView, countSuccessSendView, countFailuresSendView, countRetryBudgetExhaustedView,
+		queueOverflowView, sendLatencyView, inQueueLatencyView, queueBlockedLatencyView}
 }
@@ -17,6 +17,8 @@ package queuedprocessor
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -24,10 +26,17 @@ import (
 
 	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
 	"github.com/open-telemetry/opentelemetry-service/consumer"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumererror"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/exporter/jaeger/jaegerthrifthttpexporter"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
 )
 
 func TestQueuedProcessor_noEnqueueOnPermanentError(t *testing.T) {
@@ -65,6 +74,254 @@ func TestQueuedProcessor_noEnqueueOnPermanentError(t *testing.T) {
 	require.Equal(t, 1, qp.queue.Size())
 }
 
+func TestQueuedProcessor_retryByHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantRetry bool
+	}{
+		{status: http.StatusBadRequest, wantRetry: false},
+		{status: http.StatusServiceUnavailable, wantRetry: true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			exp, err := jaegerthrifthttpexporter.New(&configmodels.ExporterSettings{NameVal: "jaeger_thrift_http"}, server.URL, nil, 0)
+			require.NoError(t, err)
+			qp := NewQueuedSpanProcessor(
+				exp,
+				Options.WithRetryOnProcessingFailures(true),
+				Options.WithBackoffDelay(10*time.Millisecond),
+				Options.WithNumWorkers(1),
+				Options.WithQueueSize(2),
+			).(*queuedSpanProcessor)
+			defer qp.Stop()
+
+			td := consumerdata.TraceData{Spans: []*tracepb.Span{{
+				TraceId: []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+				SpanId:  []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			}}}
+			require.Nil(t, qp.ConsumeTraceData(context.Background(), td))
+
+			if tt.wantRetry {
+				require.Eventually(t, func() bool { return atomic.LoadInt32(&requests) >= 3 }, 5*time.Second, 10*time.Millisecond)
+				return
+			}
+			require.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 1 }, 5*time.Second, 10*time.Millisecond)
+			<-time.After(100 * time.Millisecond)
+			require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+			require.Zero(t, qp.queue.Size())
+		})
+	}
+}
+
+func TestQueuedProcessor_dropAfterRetryBudget(t *testing.T) {
+	views := append(MetricViews(telemetry.Basic), processor.MetricViews(telemetry.Basic)...)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	const name = "retry_budget"
+	qp := NewQueuedSpanProcessor(
+		exportertest.NewNopTraceExporter(exportertest.WithReturnError(errors.New("backend down"))),
+		Options.WithName(name),
+		Options.WithRetryOnProcessingFailures(true),
+		Options.WithBackoffDelay(10*time.Millisecond),
+		Options.WithRetryBudget(100*time.Millisecond),
+		Options.WithNumWorkers(1),
+		Options.WithQueueSize(2),
+	).(*queuedSpanProcessor)
+	defer qp.Stop()
+
+	td := consumerdata.TraceData{Spans: make([]*tracepb.Span, 5)}
+	start := time.Now()
+	require.Nil(t, qp.ConsumeTraceData(context.Background(), td))
+
+	// The batch must be retried until the budget is exhausted, then dropped.
+	var exhausted float64
+	for exhausted == 0 && time.Since(start) < 5*time.Second {
+		<-time.After(10 * time.Millisecond)
+		exhausted = viewSumForExporter(t, statRetryBudgetExhausted.Name(), name)
+	}
+	require.True(t, time.Since(start) >= 100*time.Millisecond)
+	require.Equal(t, float64(1), exhausted)
+	dropped := viewSumForExporter(t, processor.StatDroppedSpanCount.Name(), name)
+	require.Equal(t, float64(len(td.Spans)), dropped)
+
+	// Nothing is left to retry.
+	<-time.After(50 * time.Millisecond)
+	require.Zero(t, qp.queue.Size())
+	require.Equal(t, float64(1), viewSumForExporter(t, statRetryBudgetExhausted.Name(), name))
+}
+
+func TestQueuedProcessor_overflowPolicies(t *testing.T) {
+	views := append(MetricViews(telemetry.Basic), processor.MetricViews(telemetry.Basic)...)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	tests := []struct {
+		policy OverflowPolicy
+		// sent are the sizes of the batches sent downstream, dropped the number of dropped spans.
+		sent    []int
+		dropped float64
+	}{
+		{policy: OverflowDropNewest, sent: []int{1, 2}, dropped: 3},
+		{policy: OverflowDropOldest, sent: []int{1, 3}, dropped: 2},
+		{policy: OverflowBlock, sent: []int{1, 2, 3}, dropped: 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			name := "overflow_" + string(tt.policy)
+			c := newBlockingTraceConsumer()
+			qp := NewQueuedSpanProcessor(
+				c,
+				Options.WithName(name),
+				Options.WithOverflowPolicy(tt.policy),
+				Options.WithNumWorkers(1),
+				Options.WithQueueSize(1),
+			).(*queuedSpanProcessor)
+			defer qp.Stop()
+
+			// The worker takes the first batch and blocks on it, the second one fills the queue.
+			require.Nil(t, qp.ConsumeTraceData(context.Background(), spansBatch(1)))
+			<-c.started
+			require.Nil(t, qp.ConsumeTraceData(context.Background(), spansBatch(2)))
+
+			produced := make(chan struct{})
+			go func() {
+				defer close(produced)
+				require.Nil(t, qp.ConsumeTraceData(context.Background(), spansBatch(3)))
+			}()
+			if tt.policy == OverflowBlock {
+				select {
+				case <-produced:
+					t.Fatal("the producer shall be blocked while the queue is full")
+				case <-time.After(50 * time.Millisecond):
+				}
+				close(c.release)
+				<-produced
+			} else {
+				<-produced
+				close(c.release)
+			}
+
+			for range tt.sent[1:] {
+				<-c.started
+			}
+			require.Equal(t, tt.sent, c.sizes())
+			require.Equal(t, float64(1), viewSumForExporter(t, statQueueOverflow.Name(), name))
+			require.Equal(t, tt.dropped, viewSumForExporter(t, processor.StatDroppedSpanCount.Name(), name))
+
+			rows, err := view.RetrieveData(statQueueOverflow.Name())
+			require.NoError(t, err)
+			var policies []string
+			for _, row := range rows {
+				if hasTag(row.Tags, processor.TagExporterNameKey, name) {
+					for _, tag := range row.Tags {
+						if tag.Key == TagOverflowPolicyKey {
+							policies = append(policies, tag.Value)
+						}
+					}
+				}
+			}
+			require.Equal(t, []string{string(tt.policy)}, policies)
+		})
+	}
+}
+
+func TestQueuedProcessor_stopUnblocksProducers(t *testing.T) {
+	c := newBlockingTraceConsumer()
+	qp := NewQueuedSpanProcessor(
+		c,
+		Options.WithOverflowPolicy(OverflowBlock),
+		Options.WithNumWorkers(1),
+		Options.WithQueueSize(1),
+	).(*queuedSpanProcessor)
+
+	require.Nil(t, qp.ConsumeTraceData(context.Background(), spansBatch(1)))
+	<-c.started
+	require.Nil(t, qp.ConsumeTraceData(context.Background(), spansBatch(2)))
+
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		require.Nil(t, qp.ConsumeTraceData(context.Background(), spansBatch(3)))
+	}()
+
+	// Stop waits for the worker, release it once the queue is stopped.
+	go func() {
+		<-produced
+		close(c.release)
+	}()
+	qp.Stop()
+	require.Equal(t, []int{1}, c.sizes())
+}
+
+func spansBatch(numSpans int) consumerdata.TraceData {
+	return consumerdata.TraceData{Spans: make([]*tracepb.Span, numSpans)}
+}
+
+func hasTag(tags []tag.Tag, key tag.Key, value string) bool {
+	for _, t := range tags {
+		if t.Key == key && t.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// blockingTraceConsumer records the size of the batches it consumes, and
+// blocks on them until released.
+type blockingTraceConsumer struct {
+	started chan struct{}
+	release chan struct{}
+
+	mu      sync.Mutex
+	batches []int
+}
+
+var _ consumer.TraceConsumer = (*blockingTraceConsumer)(nil)
+
+func newBlockingTraceConsumer() *blockingTraceConsumer {
+	return &blockingTraceConsumer{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (c *blockingTraceConsumer) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	c.mu.Lock()
+	c.batches = append(c.batches, len(td.Spans))
+	c.mu.Unlock()
+	c.started <- struct{}{}
+	<-c.release
+	return nil
+}
+
+func (c *blockingTraceConsumer) sizes() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int(nil), c.batches...)
+}
+
+func viewSumForExporter(t *testing.T, viewName, exporterName string) float64 {
+	rows, err := view.RetrieveData(viewName)
+	require.NoError(t, err)
+	sum := float64(0)
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == processor.TagExporterNameKey && tag.Value == exporterName {
+				sum += row.Data.(*view.SumData).Value
+			}
+		}
+	}
+	return sum
+}
+
 type waitGroupTraceConsumer struct {
 	sync.WaitGroup
 	consumeTraceDataError error
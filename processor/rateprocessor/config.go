@@ -0,0 +1,35 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rateprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config defines configuration for the rate processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Suffix is appended to the name of a counter to name the gauge holding
+	// its rate.
+	Suffix string `mapstructure:"suffix"`
+	// ReplaceCounters exports the rate gauges instead of the counters they are
+	// computed from. By default both are exported.
+	ReplaceCounters bool `mapstructure:"replace_counters"`
+	// GCInterval is how often the series that were not updated since the
+	// previous collection are forgotten.
+	GCInterval time.Duration `mapstructure:"gc_interval"`
+}
@@ -0,0 +1,249 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rateprocessor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type rateProcessor struct {
+	nextConsumer    consumer.MetricsConsumer
+	logger          *zap.Logger
+	suffix          string
+	replaceCounters bool
+
+	mu     sync.Mutex
+	series *seriesMap
+}
+
+var _ processor.MetricsProcessor = (*rateProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that computes, for
+// every series of the cumulative counters it receives, the per-second rate
+// over the interval since the previous point of the series and exports it as
+// a gauge.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if cfg.Suffix == "" && !cfg.ReplaceCounters {
+		return nil, errors.New("suffix must be set unless replace_counters is enabled, rates would have the name of their counter")
+	}
+
+	gcInterval := cfg.GCInterval
+	if gcInterval == 0 {
+		gcInterval = defaultGCInterval
+	}
+
+	return &rateProcessor{
+		nextConsumer:    nextConsumer,
+		logger:          logger,
+		suffix:          cfg.Suffix,
+		replaceCounters: cfg.ReplaceCounters,
+		series:          newSeriesMap(gcInterval),
+	}, nil
+}
+
+func (rp *rateProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	metrics := make([]*metricspb.Metric, 0, len(md.Metrics))
+
+	rp.mu.Lock()
+	for _, metric := range md.Metrics {
+		if !isCounter(metric.GetMetricDescriptor()) {
+			metrics = append(metrics, metric)
+			continue
+		}
+		if !rp.replaceCounters {
+			metrics = append(metrics, metric)
+		}
+		resource := md.Resource
+		if metric.Resource != nil {
+			resource = metric.Resource
+		}
+		if rate := rp.rateMetric(md.Node, resource, metric); rate != nil {
+			metrics = append(metrics, rate)
+		}
+	}
+	rp.series.maybeGC(time.Now())
+	rp.mu.Unlock()
+
+	if len(metrics) == 0 && len(md.Metrics) > 0 {
+		// Only counters seen for the first time, no rate to export yet.
+		return nil
+	}
+	md.Metrics = metrics
+	return rp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// rateMetric returns the gauge holding the rates of the given counter, or nil
+// if no rate could be computed, e.g. the first time the counter is seen. The
+// resource is the one of the counter, or of its batch if it has none.
+func (rp *rateProcessor) rateMetric(node *commonpb.Node, resource *resourcepb.Resource, metric *metricspb.Metric) *metricspb.Metric {
+	desc := metric.MetricDescriptor
+	var timeseries []*metricspb.TimeSeries
+	for _, ts := range metric.Timeseries {
+		state := rp.series.get(seriesKey(node, resource, desc, ts))
+		var points []*metricspb.Point
+		for _, point := range ts.Points {
+			if rate, ok := nextRate(state, ts.StartTimestamp, point); ok {
+				points = append(points, &metricspb.Point{
+					Timestamp: point.Timestamp,
+					Value:     &metricspb.Point_DoubleValue{DoubleValue: rate},
+				})
+			}
+		}
+		if len(points) > 0 {
+			timeseries = append(timeseries, &metricspb.TimeSeries{
+				LabelValues: ts.LabelValues,
+				Points:      points,
+			})
+		}
+	}
+	if len(timeseries) == 0 {
+		return nil
+	}
+
+	unit := "1/s"
+	if desc.Unit != "" {
+		unit = desc.Unit + "/s"
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        desc.Name + rp.suffix,
+			Description: desc.Description,
+			Unit:        unit,
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys:   desc.LabelKeys,
+		},
+		Resource:   metric.Resource,
+		Timeseries: timeseries,
+	}
+}
+
+// nextRate updates the state of the series with the given point and returns
+// the per-second rate since the previous point. A counter reset, detected by a
+// decreasing value or a newer start timestamp, yields a zero rate for the
+// interval. Points without a timestamp or not after the previous point are
+// ignored.
+func nextRate(state *seriesState, start *timestamp.Timestamp, point *metricspb.Point) (float64, bool) {
+	if point.GetTimestamp() == nil {
+		return 0, false
+	}
+	value, ok := pointValue(point)
+	if !ok {
+		return 0, false
+	}
+	t := timestampNanos(point.Timestamp)
+	startNanos := timestampNanos(start)
+
+	if !state.valid {
+		state.valid, state.start, state.last, state.value = true, startNanos, t, value
+		return 0, false
+	}
+	if t <= state.last {
+		return 0, false
+	}
+
+	rate := 0.0
+	reset := value < state.value || startNanos > state.start
+	if !reset {
+		rate = (value - state.value) / (float64(t-state.last) / 1e9)
+	}
+	state.start, state.last, state.value = startNanos, t, value
+	return rate, true
+}
+
+func isCounter(desc *metricspb.MetricDescriptor) bool {
+	switch desc.GetType() {
+	case metricspb.MetricDescriptor_CUMULATIVE_INT64, metricspb.MetricDescriptor_CUMULATIVE_DOUBLE:
+		return true
+	}
+	return false
+}
+
+func pointValue(point *metricspb.Point) (float64, bool) {
+	switch v := point.Value.(type) {
+	case *metricspb.Point_Int64Value:
+		return float64(v.Int64Value), true
+	case *metricspb.Point_DoubleValue:
+		return v.DoubleValue, true
+	}
+	return 0, false
+}
+
+// seriesKey identifies a series by the node that reported it, its resource,
+// the metric name and the label keys and values. The lengths of the strings
+// are part of the key, so that separators within them cannot make two
+// different series collide.
+func seriesKey(node *commonpb.Node, resource *resourcepb.Resource, desc *metricspb.MetricDescriptor, ts *metricspb.TimeSeries) string {
+	var b strings.Builder
+	writeKeyPart(&b, processor.ServiceNameForNode(node))
+	writeKeyPart(&b, node.GetIdentifier().GetHostName())
+
+	writeKeyPart(&b, resource.GetType())
+	resourceKeys := make([]string, 0, len(resource.GetLabels()))
+	for k := range resource.GetLabels() {
+		resourceKeys = append(resourceKeys, k)
+	}
+	sort.Strings(resourceKeys)
+	b.WriteString(strconv.Itoa(len(resourceKeys)))
+	b.WriteByte(':')
+	for _, k := range resourceKeys {
+		writeKeyPart(&b, k)
+		writeKeyPart(&b, resource.Labels[k])
+	}
+
+	writeKeyPart(&b, desc.GetName())
+	for i, v := range ts.LabelValues {
+		var key string
+		if i < len(desc.GetLabelKeys()) {
+			key = desc.LabelKeys[i].GetKey()
+		}
+		writeKeyPart(&b, key)
+		var value string
+		if v.GetHasValue() {
+			value = v.Value
+		}
+		writeKeyPart(&b, value)
+	}
+	return b.String()
+}
+
+func writeKeyPart(b *strings.Builder, s string) {
+	b.WriteString(strconv.Itoa(len(s)))
+	b.WriteByte(':')
+	b.WriteString(s)
+}
+
+func timestampNanos(t *timestamp.Timestamp) int64 {
+	return t.GetSeconds()*1e9 + int64(t.GetNanos())
+}
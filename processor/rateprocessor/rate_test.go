@@ -0,0 +1,223 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rateprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), nil, Config{Suffix: defaultSuffix})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestSteadyIncrease(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{Suffix: defaultSuffix})
+	require.NoError(t, err)
+
+	// 10 more every 5 seconds.
+	for i := int64(0); i < 4; i++ {
+		md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{counter("requests", 0, 10+5*i, float64(100+10*i))}}
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 4)
+	// The first scrape only records the counter.
+	require.Len(t, got[0].Metrics, 1)
+	assert.Equal(t, "requests", got[0].Metrics[0].MetricDescriptor.Name)
+	for _, md := range got[1:] {
+		require.Len(t, md.Metrics, 2)
+		assert.Equal(t, "requests", md.Metrics[0].MetricDescriptor.Name)
+		rate := md.Metrics[1]
+		assert.Equal(t, "requests_rate", rate.MetricDescriptor.Name)
+		assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, rate.MetricDescriptor.Type)
+		assert.Equal(t, "1/s", rate.MetricDescriptor.Unit)
+		assert.Equal(t, []float64{2}, rates(rate))
+	}
+}
+
+func TestCounterReset(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{Suffix: defaultSuffix, ReplaceCounters: true})
+	require.NoError(t, err)
+
+	batches := []*metricspb.Metric{
+		counter("requests", 0, 10, 100),
+		counter("requests", 0, 20, 200),
+		// The value went down, the process restarted.
+		counter("requests", 0, 30, 50),
+		counter("requests", 0, 40, 150),
+		// The start timestamp moved, the counter was reset in between.
+		counter("requests", 45, 50, 200),
+		counter("requests", 45, 60, 300),
+	}
+	for _, metric := range batches {
+		md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric}}
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	// Nothing is exported for the first scrape, counters are replaced.
+	got := sink.AllMetrics()
+	require.Len(t, got, 5)
+	var all []float64
+	for _, md := range got {
+		require.Len(t, md.Metrics, 1)
+		assert.Equal(t, "requests_rate", md.Metrics[0].MetricDescriptor.Name)
+		all = append(all, rates(md.Metrics[0])...)
+	}
+	assert.Equal(t, []float64{10, 0, 10, 0, 10}, all)
+}
+
+func TestSeriesAreIndependent(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{Suffix: defaultSuffix, ReplaceCounters: true})
+	require.NoError(t, err)
+
+	gauge := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: "temperature", Type: metricspb.MetricDescriptor_GAUGE_DOUBLE},
+	}
+	batches := [][]*metricspb.Metric{
+		{counter("a", 0, 10, 10, "x"), counter("a", 0, 10, 100, "y"), gauge},
+		{counter("a", 0, 20, 20, "x"), counter("a", 0, 20, 300, "y"), gauge},
+	}
+	for _, metrics := range batches {
+		md := consumerdata.MetricsData{Metrics: metrics}
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	// Non counters are always forwarded as is.
+	require.Len(t, got[0].Metrics, 1)
+	assert.Equal(t, gauge, got[0].Metrics[0])
+	require.Len(t, got[1].Metrics, 3)
+	assert.Equal(t, []float64{1}, rates(got[1].Metrics[0]))
+	assert.Equal(t, []float64{20}, rates(got[1].Metrics[1]))
+	assert.Equal(t, gauge, got[1].Metrics[2])
+}
+
+func TestSeriesOfDifferentResourcesAndLabelKeys(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{Suffix: defaultSuffix, ReplaceCounters: true})
+	require.NoError(t, err)
+
+	withResource := func(metric *metricspb.Metric, pod string) *metricspb.Metric {
+		metric.Resource = &resourcepb.Resource{Type: "k8s", Labels: map[string]string{"k8s.pod.name": pod}}
+		return metric
+	}
+	withLabelKey := func(metric *metricspb.Metric, key string) *metricspb.Metric {
+		metric.MetricDescriptor.LabelKeys = []*metricspb.LabelKey{{Key: key}}
+		return metric
+	}
+	batchResource := &resourcepb.Resource{Type: "host", Labels: map[string]string{"host.name": "leeloo"}}
+	batches := []consumerdata.MetricsData{
+		{Metrics: []*metricspb.Metric{
+			withResource(counter("a", 0, 10, 10, "x"), "pod-1"),
+			withResource(counter("a", 0, 10, 100, "x"), "pod-2"),
+			withLabelKey(counter("a", 0, 10, 1000, "x"), "other"),
+		}},
+		{Metrics: []*metricspb.Metric{counter("a", 0, 10, 10000, "x")}, Resource: batchResource},
+		{Metrics: []*metricspb.Metric{
+			withResource(counter("a", 0, 20, 20, "x"), "pod-1"),
+			withResource(counter("a", 0, 20, 300, "x"), "pod-2"),
+			withLabelKey(counter("a", 0, 20, 4000, "x"), "other"),
+		}},
+		{Metrics: []*metricspb.Metric{counter("a", 0, 20, 50000, "x")}, Resource: batchResource},
+	}
+	for _, md := range batches {
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	// The series only differing by their resource or label keys have their own
+	// rates.
+	got := sink.AllMetrics()
+	require.Len(t, got, 2)
+	require.Len(t, got[0].Metrics, 3)
+	assert.Equal(t, []float64{1}, rates(got[0].Metrics[0]))
+	assert.Equal(t, "pod-1", got[0].Metrics[0].Resource.Labels["k8s.pod.name"])
+	assert.Equal(t, []float64{20}, rates(got[0].Metrics[1]))
+	assert.Equal(t, "pod-2", got[0].Metrics[1].Resource.Labels["k8s.pod.name"])
+	assert.Equal(t, []float64{300}, rates(got[0].Metrics[2]))
+	require.Len(t, got[1].Metrics, 1)
+	assert.Equal(t, []float64{4000}, rates(got[1].Metrics[0]))
+}
+
+func TestSeriesMapGC(t *testing.T) {
+	sm := newSeriesMap(time.Minute)
+	now := sm.lastGC
+
+	sm.get("a")
+	sm.get("b")
+	sm.maybeGC(now.Add(2 * time.Minute))
+	assert.Len(t, sm.series, 2, "series marked since the last collection are kept")
+
+	sm.get("a")
+	sm.maybeGC(now.Add(4 * time.Minute))
+	assert.Len(t, sm.series, 1)
+	assert.Contains(t, sm.series, "a")
+
+	// Too early for another collection.
+	sm.maybeGC(now.Add(4*time.Minute + time.Second))
+	assert.Len(t, sm.series, 1)
+}
+
+func counter(name string, startSeconds, seconds int64, value float64, labelValues ...string) *metricspb.Metric {
+	ts := &metricspb.TimeSeries{
+		StartTimestamp: &timestamp.Timestamp{Seconds: startSeconds},
+		Points: []*metricspb.Point{{
+			Timestamp: &timestamp.Timestamp{Seconds: seconds},
+			Value:     &metricspb.Point_DoubleValue{DoubleValue: value},
+		}},
+	}
+	var labelKeys []*metricspb.LabelKey
+	for _, v := range labelValues {
+		labelKeys = []*metricspb.LabelKey{{Key: "k"}}
+		ts.LabelValues = append(ts.LabelValues, &metricspb.LabelValue{Value: v, HasValue: true})
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_DOUBLE,
+			LabelKeys: labelKeys,
+		},
+		Timeseries: []*metricspb.TimeSeries{ts},
+	}
+}
+
+func rates(metric *metricspb.Metric) []float64 {
+	var got []float64
+	for _, ts := range metric.Timeseries {
+		for _, point := range ts.Points {
+			got = append(got, point.GetDoubleValue())
+		}
+	}
+	return got
+}
@@ -0,0 +1,78 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rateprocessor
+
+import (
+	"time"
+)
+
+// seriesState holds the previous point of a counter series, from which the
+// rate of its next point is computed.
+type seriesState struct {
+	mark bool
+	// valid is false until the first point of the series is seen.
+	valid bool
+	// start is the start timestamp, in nanoseconds since epoch, of the series
+	// when the previous point was seen.
+	start int64
+	// last is the timestamp, in nanoseconds since epoch, of the previous point.
+	last  int64
+	value float64
+}
+
+// seriesMap tracks the previous point of every counter series. Like the
+// prometheus receiver JobsMap it uses a mark-and-sweep strategy: every access
+// marks the series, and every gcInterval the series that were not marked since
+// the previous collection are removed. It is not safe for concurrent use.
+type seriesMap struct {
+	gcInterval time.Duration
+	lastGC     time.Time
+	series     map[string]*seriesState
+}
+
+func newSeriesMap(gcInterval time.Duration) *seriesMap {
+	return &seriesMap{
+		gcInterval: gcInterval,
+		lastGC:     time.Now(),
+		series:     make(map[string]*seriesState),
+	}
+}
+
+// get returns the state of the given series, creating it if needed.
+func (sm *seriesMap) get(key string) *seriesState {
+	state, ok := sm.series[key]
+	if !ok {
+		state = &seriesState{}
+		sm.series[key] = state
+	}
+	state.mark = true
+	return state
+}
+
+// maybeGC removes the series that have aged out if the last collection is
+// older than the gc interval.
+func (sm *seriesMap) maybeGC(now time.Time) {
+	if now.Sub(sm.lastGC) <= sm.gcInterval {
+		return
+	}
+	for key, state := range sm.series {
+		if !state.mark {
+			delete(sm.series, key)
+		} else {
+			state.mark = false
+		}
+	}
+	sm.lastGC = now
+}
@@ -0,0 +1,49 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredidentityprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Policy is how the batches missing identity attributes are handled.
+type Policy string
+
+const (
+	// DropPolicy drops the batches missing identity attributes.
+	DropPolicy Policy = "drop"
+	// DeadletterPolicy sends the batches missing identity attributes to the
+	// deadletter exporters, instead of the rest of the pipeline.
+	DeadletterPolicy Policy = "deadletter"
+)
+
+// Config defines configuration for the required identity processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Attributes are the keys of the attributes identifying the resource of
+	// every batch, with a non empty value. They are looked up in the labels of
+	// the resource and in the attributes of the node, "service.name" is also
+	// found in the service name of the node. Defaults to "service.name".
+	Attributes []string `mapstructure:"attributes"`
+	// Policy is how the batches missing identity attributes are handled:
+	// "drop" (the default) or "deadletter".
+	Policy Policy `mapstructure:"policy"`
+	// DeadletterExporters are the names of the exporters the batches missing
+	// identity attributes are sent to, with the deadletter policy.
+	DeadletterExporters []string `mapstructure:"deadletter_exporters"`
+}
+
+// FallbackExporterNames returns the names of the deadletter exporters.
+func (cfg *Config) FallbackExporterNames() []string {
+	return cfg.DeadletterExporters
+}
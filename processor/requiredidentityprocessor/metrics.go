@@ -0,0 +1,56 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredidentityprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	tagMissingKey, _ = tag.NewKey("missing_key")
+
+	statDroppedBatches    = stats.Int64("required_identity_dropped_batches", "Number of batches dropped because their resource was missing the identity attribute", stats.UnitDimensionless)
+	statDeadletterBatches = stats.Int64("required_identity_deadletter_batches", "Number of batches sent to the deadletter exporters because their resource was missing the identity attribute", stats.UnitDimensionless)
+)
+
+// MetricViews returns the metrics views related to the batches missing
+// identity attributes.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	tagKeys := []tag.Key{processor.TagExporterNameKey, tagMissingKey}
+	droppedView := &view.View{
+		Name:        statDroppedBatches.Name(),
+		Measure:     statDroppedBatches,
+		Description: statDroppedBatches.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	deadletterView := &view.View{
+		Name:        statDeadletterBatches.Name(),
+		Measure:     statDeadletterBatches,
+		Description: statDeadletterBatches.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	return []*view.View{droppedView, deadletterView}
+}
@@ -0,0 +1,189 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredidentityprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// serviceNameAttribute is the identity attribute also found in the service
+// name of the node.
+const serviceNameAttribute = "service.name"
+
+// dropReason is the reason the data dropped is recorded with, see
+// processor.RecordDropped.
+const dropReason = "missing_identity"
+
+var errNilDeadletterConsumer = errors.New("nil deadletter consumer, the deadletter policy requires deadletter exporters")
+
+// identityGuard holds the settings shared by the trace and metrics processors.
+type identityGuard struct {
+	attributes []string
+	policy     Policy
+	name       string
+}
+
+func newIdentityGuard(cfg Config, hasDeadletterConsumer bool) (*identityGuard, error) {
+	attributes := cfg.Attributes
+	if len(attributes) == 0 {
+		attributes = []string{serviceNameAttribute}
+	}
+	for _, key := range attributes {
+		if key == "" {
+			return nil, errors.New("empty identity attribute key")
+		}
+	}
+
+	policy := cfg.Policy
+	switch policy {
+	case "":
+		policy = DropPolicy
+	case DropPolicy:
+	case DeadletterPolicy:
+		if !hasDeadletterConsumer {
+			return nil, errNilDeadletterConsumer
+		}
+	default:
+		return nil, fmt.Errorf("unknown policy %q, must be either %q or %q", cfg.Policy, DropPolicy, DeadletterPolicy)
+	}
+
+	return &identityGuard{
+		attributes: attributes,
+		policy:     policy,
+		name:       cfg.Name(),
+	}, nil
+}
+
+// missing returns the identity attributes the batch does not have, or has
+// with an empty value.
+func (ig *identityGuard) missing(node *commonpb.Node, resource *resourcepb.Resource) []string {
+	var missing []string
+	for _, key := range ig.attributes {
+		if resource.GetLabels()[key] != "" || node.GetAttributes()[key] != "" {
+			continue
+		}
+		if key == serviceNameAttribute && node.GetServiceInfo().GetName() != "" {
+			continue
+		}
+		missing = append(missing, key)
+	}
+	return missing
+}
+
+// recordMissing counts the batch once per missing identity attribute.
+func (ig *identityGuard) recordMissing(missing []string) {
+	stat := statDroppedBatches
+	if ig.policy == DeadletterPolicy {
+		stat = statDeadletterBatches
+	}
+	for _, key := range missing {
+		stats.RecordWithTags(context.Background(), []tag.Mutator{
+			tag.Upsert(processor.TagExporterNameKey, ig.name),
+			tag.Upsert(tagMissingKey, key),
+		}, stat.M(1))
+	}
+}
+
+type traceRequiredIdentityProcessor struct {
+	nextConsumer       consumer.TraceConsumer
+	deadletterConsumer consumer.TraceConsumer
+	guard              *identityGuard
+}
+
+var _ processor.TraceProcessor = (*traceRequiredIdentityProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that handles the
+// batches whose resource lacks identity attributes according to the policy of
+// the config. The deadletter consumer is only required by the deadletter
+// policy.
+func NewTraceProcessor(nextConsumer, deadletterConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	guard, err := newIdentityGuard(cfg, deadletterConsumer != nil)
+	if err != nil {
+		return nil, err
+	}
+	return &traceRequiredIdentityProcessor{
+		nextConsumer:       nextConsumer,
+		deadletterConsumer: deadletterConsumer,
+		guard:              guard,
+	}, nil
+}
+
+func (trp *traceRequiredIdentityProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	missing := trp.guard.missing(td.Node, td.Resource)
+	if len(missing) == 0 {
+		return trp.nextConsumer.ConsumeTraceData(ctx, td)
+	}
+	trp.guard.recordMissing(missing)
+	if trp.guard.policy == DeadletterPolicy {
+		return trp.deadletterConsumer.ConsumeTraceData(ctx, td)
+	}
+	processor.RecordDroppedSpans(trp.guard.name, dropReason, td.Spans)
+	return nil
+}
+
+type metricsRequiredIdentityProcessor struct {
+	nextConsumer       consumer.MetricsConsumer
+	deadletterConsumer consumer.MetricsConsumer
+	guard              *identityGuard
+}
+
+var _ processor.MetricsProcessor = (*metricsRequiredIdentityProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that handles the
+// batches whose resource lacks identity attributes according to the policy of
+// the config. The deadletter consumer is only required by the deadletter
+// policy.
+func NewMetricsProcessor(nextConsumer, deadletterConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	guard, err := newIdentityGuard(cfg, deadletterConsumer != nil)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsRequiredIdentityProcessor{
+		nextConsumer:       nextConsumer,
+		deadletterConsumer: deadletterConsumer,
+		guard:              guard,
+	}, nil
+}
+
+func (mrp *metricsRequiredIdentityProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	missing := mrp.guard.missing(md.Node, md.Resource)
+	if len(missing) == 0 {
+		return mrp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+	mrp.guard.recordMissing(missing)
+	if mrp.guard.policy == DeadletterPolicy {
+		return mrp.deadletterConsumer.ConsumeMetricsData(ctx, md)
+	}
+	processor.RecordDroppedMetrics(mrp.guard.name, dropReason, md.Metrics)
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredidentityprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+)
+
+func TestDropPolicy(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkMetricsExporter)
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "required_identity/drop"},
+		Attributes:        []string{"service.name", "env"},
+	}
+	mp, err := NewMetricsProcessor(sink, nil, cfg)
+	require.NoError(t, err)
+
+	missingServiceName := testMetricsData(nil, map[string]string{"env": "prod"})
+	missingBoth := testMetricsData(nil, nil)
+	complete := testMetricsData(&commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "checkout"}},
+		map[string]string{"env": "prod"})
+	for _, md := range []consumerdata.MetricsData{missingServiceName, missingBoth, complete} {
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, complete, got[0])
+
+	assert.Equal(t, map[string]float64{"service.name": 2, "env": 1}, missingCounts(t, statDroppedBatches.Name()))
+	assert.Empty(t, missingCounts(t, statDeadletterBatches.Name()))
+}
+
+func TestDeadletterPolicy(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkTraceExporter)
+	deadletter := new(exportertest.SinkTraceExporter)
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "required_identity/deadletter"},
+		Policy:            DeadletterPolicy,
+	}
+	tp, err := NewTraceProcessor(sink, deadletter, cfg)
+	require.NoError(t, err)
+
+	missing := consumerdata.TraceData{
+		Node:     &commonpb.Node{Attributes: map[string]string{"env": "prod"}},
+		Resource: &resourcepb.Resource{Labels: map[string]string{"service.name": ""}},
+		Spans:    []*tracepb.Span{{Name: &tracepb.TruncatableString{Value: "checkout"}}},
+	}
+	fromResource := consumerdata.TraceData{
+		Resource: &resourcepb.Resource{Labels: map[string]string{"service.name": "checkout"}},
+		Spans:    []*tracepb.Span{{Name: &tracepb.TruncatableString{Value: "checkout"}}},
+	}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), missing))
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), fromResource))
+
+	// The batch with an empty service.name goes to the deadletter exporters as received.
+	assert.Equal(t, []consumerdata.TraceData{missing}, deadletter.AllTraces())
+	assert.Equal(t, []consumerdata.TraceData{fromResource}, sink.AllTraces())
+
+	assert.Equal(t, map[string]float64{"service.name": 1}, missingCounts(t, statDeadletterBatches.Name()))
+	assert.Empty(t, missingCounts(t, statDroppedBatches.Name()))
+}
+
+func TestIdentityFromNodeAttributes(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, nil, Config{Attributes: []string{"service.name", "host.name"}})
+	require.NoError(t, err)
+
+	md := testMetricsData(&commonpb.Node{Attributes: map[string]string{"service.name": "checkout", "host.name": "h1"}}, nil)
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Len(t, sink.AllMetrics(), 1)
+}
+
+func testMetricsData(node *commonpb.Node, labels map[string]string) consumerdata.MetricsData {
+	return consumerdata.MetricsData{
+		Node:     node,
+		Resource: &resourcepb.Resource{Labels: labels},
+		Metrics: []*metricspb.Metric{{
+			MetricDescriptor: &metricspb.MetricDescriptor{Name: "requests", Type: metricspb.MetricDescriptor_CUMULATIVE_INT64},
+		}},
+	}
+}
+
+// missingCounts returns the number of batches recorded by the view, keyed by missing attribute.
+func missingCounts(t *testing.T, viewName string) map[string]float64 {
+	rows, err := view.RetrieveData(viewName)
+	require.NoError(t, err)
+	counts := make(map[string]float64)
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == tagMissingKey {
+				counts[tag.Value] = row.Data.(*view.SumData).Value
+			}
+		}
+	}
+	return counts
+}
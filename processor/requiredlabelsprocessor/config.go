@@ -0,0 +1,52 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredlabelsprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Policy is how the series missing required labels are handled.
+type Policy string
+
+const (
+	// DropPolicy drops the series missing required labels.
+	DropPolicy Policy = "drop"
+	// DefaultPolicy sets the missing required labels to their default value.
+	DefaultPolicy Policy = "default"
+	// DeadletterPolicy sends the series missing required labels to the
+	// deadletter exporters, instead of the rest of the pipeline.
+	DeadletterPolicy Policy = "deadletter"
+)
+
+// Config defines configuration for the required labels processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Labels are the keys of the labels every series must have, with a non
+	// empty value.
+	Labels []string `mapstructure:"labels"`
+	// Policy is how the series missing required labels are handled: "drop"
+	// (the default), "default" or "deadletter".
+	Policy Policy `mapstructure:"policy"`
+	// Defaults are the values of the missing required labels, keyed by label,
+	// with the default policy. Every required label must have one.
+	Defaults map[string]string `mapstructure:"defaults"`
+	// DeadletterExporters are the names of the exporters the series missing
+	// required labels are sent to, with the deadletter policy.
+	DeadletterExporters []string `mapstructure:"deadletter_exporters"`
+}
+
+// FallbackExporterNames returns the names of the deadletter exporters.
+func (cfg *Config) FallbackExporterNames() []string {
+	return cfg.DeadletterExporters
+}
@@ -0,0 +1,53 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredlabelsprocessor
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var (
+	statDroppedTimeseries    = stats.Int64("required_labels_dropped_timeseries", "Number of timeseries dropped because they were missing required labels", stats.UnitDimensionless)
+	statDeadletterTimeseries = stats.Int64("required_labels_deadletter_timeseries", "Number of timeseries sent to the deadletter exporters because they were missing required labels", stats.UnitDimensionless)
+)
+
+// MetricViews returns the metrics views related to the series missing required labels.
+func MetricViews(level telemetry.Level) []*view.View {
+	if level == telemetry.None {
+		return nil
+	}
+
+	tagKeys := []tag.Key{processor.TagExporterNameKey}
+	droppedView := &view.View{
+		Name:        statDroppedTimeseries.Name(),
+		Measure:     statDroppedTimeseries,
+		Description: statDroppedTimeseries.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	deadletterView := &view.View{
+		Name:        statDeadletterTimeseries.Name(),
+		Measure:     statDeadletterTimeseries,
+		Description: statDeadletterTimeseries.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+	return []*view.View{droppedView, deadletterView}
+}
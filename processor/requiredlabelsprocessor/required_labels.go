@@ -0,0 +1,266 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredlabelsprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// dropReason is the reason the series dropped are recorded with, see
+// processor.RecordDropped.
+const dropReason = "missing_required_labels"
+
+var errNilDeadletterConsumer = errors.New("nil deadletter consumer, the deadletter policy requires deadletter exporters")
+
+type requiredLabelsProcessor struct {
+	name               string
+	nextConsumer       consumer.MetricsConsumer
+	deadletterConsumer consumer.MetricsConsumer
+	labels             []string
+	policy             Policy
+	defaults           map[string]string
+	statsTags          []tag.Mutator
+}
+
+var _ processor.MetricsProcessor = (*requiredLabelsProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that handles the
+// series missing required labels according to the policy of the config. The
+// deadletter consumer is only required by the deadletter policy.
+func NewMetricsProcessor(nextConsumer, deadletterConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if len(cfg.Labels) == 0 {
+		return nil, errors.New("labels must list at least one required label")
+	}
+	for _, key := range cfg.Labels {
+		if key == "" {
+			return nil, errors.New("empty required label key")
+		}
+	}
+
+	policy := cfg.Policy
+	switch policy {
+	case "":
+		policy = DropPolicy
+	case DropPolicy:
+	case DefaultPolicy:
+		for _, key := range cfg.Labels {
+			if cfg.Defaults[key] == "" {
+				return nil, fmt.Errorf("no default value for the required label %q", key)
+			}
+		}
+	case DeadletterPolicy:
+		if deadletterConsumer == nil {
+			return nil, errNilDeadletterConsumer
+		}
+	default:
+		return nil, fmt.Errorf("unknown policy %q, must be one of %q, %q or %q",
+			cfg.Policy, DropPolicy, DefaultPolicy, DeadletterPolicy)
+	}
+
+	return &requiredLabelsProcessor{
+		name:               cfg.Name(),
+		nextConsumer:       nextConsumer,
+		deadletterConsumer: deadletterConsumer,
+		labels:             cfg.Labels,
+		policy:             policy,
+		defaults:           cfg.Defaults,
+		statsTags:          []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (rlp *requiredLabelsProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	if rlp.policy == DefaultPolicy {
+		metrics := make([]*metricspb.Metric, 0, len(md.Metrics))
+		for _, metric := range md.Metrics {
+			metrics = append(metrics, rlp.withDefaults(metric))
+		}
+		md.Metrics = metrics
+		return rlp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	kept := make([]*metricspb.Metric, 0, len(md.Metrics))
+	var missing []*metricspb.Metric
+	numMissing := 0
+	for _, metric := range md.Metrics {
+		complete, incomplete := rlp.split(metric)
+		if complete != nil {
+			kept = append(kept, complete)
+		}
+		if incomplete != nil {
+			missing = append(missing, incomplete)
+			numMissing += len(incomplete.Timeseries)
+		}
+	}
+	if len(missing) == 0 {
+		return rlp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	var errs []error
+	if rlp.policy == DeadletterPolicy {
+		stats.RecordWithTags(context.Background(), rlp.statsTags, statDeadletterTimeseries.M(int64(numMissing)))
+		deadletter := consumerdata.MetricsData{Node: md.Node, Resource: md.Resource, Metrics: missing}
+		if err := rlp.deadletterConsumer.ConsumeMetricsData(ctx, deadletter); err != nil {
+			errs = append(errs, err)
+		}
+	} else {
+		stats.RecordWithTags(context.Background(), rlp.statsTags, statDroppedTimeseries.M(int64(numMissing)))
+		processor.RecordDroppedMetrics(rlp.name, dropReason, missing)
+	}
+
+	if len(kept) > 0 {
+		md.Metrics = kept
+		if err := rlp.nextConsumer.ConsumeMetricsData(ctx, md); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return oterr.CombineErrors(errs)
+}
+
+// labelIndexes returns the indexes of the required labels in the label keys
+// of the metric, -1 for the labels it does not have.
+func (rlp *requiredLabelsProcessor) labelIndexes(desc *metricspb.MetricDescriptor) []int {
+	indexes := make([]int, len(rlp.labels))
+	for i, label := range rlp.labels {
+		indexes[i] = -1
+		for j, key := range desc.GetLabelKeys() {
+			if key.GetKey() == label {
+				indexes[i] = j
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// hasLabel returns whether the series has a non empty value for the label at
+// the given index.
+func hasLabel(ts *metricspb.TimeSeries, index int) bool {
+	if index < 0 || index >= len(ts.LabelValues) {
+		return false
+	}
+	lv := ts.LabelValues[index]
+	return lv != nil && lv.HasValue && lv.Value != ""
+}
+
+func hasLabels(ts *metricspb.TimeSeries, indexes []int) bool {
+	for _, index := range indexes {
+		if !hasLabel(ts, index) {
+			return false
+		}
+	}
+	return true
+}
+
+// split returns a metric with the series having all the required labels and
+// one with the series missing some, nil when there are no such series. The
+func (rlp *requiredLabelsProcessor) split(metric *metricspb.Metric) (*metricspb.Metric, *metricspb.Metric) {
+	indexes := rlp.labelIndexes(metric.GetMetricDescriptor())
+	var complete, incomplete []*metricspb.TimeSeries
+	for _, ts := range metric.Timeseries {
+		if hasLabels(ts, indexes) {
+			complete = append(complete, ts)
+		} else {
+			incomplete = append(incomplete, ts)
+		}
+	}
+	if len(incomplete) == 0 {
+		return metric, nil
+	}
+
+	withSeries := func(timeseries []*metricspb.TimeSeries) *metricspb.Metric {
+		if len(timeseries) == 0 {
+			return nil
+		}
+		return &metricspb.Metric{
+			MetricDescriptor: metric.MetricDescriptor,
+			Resource:         metric.Resource,
+			Timeseries:       timeseries,
+		}
+	}
+	return withSeries(complete), withSeries(incomplete)
+}
+
+// withDefaults returns the metric with the missing required labels of its
+// series set to their default value, a copy if labels are added.
+func (rlp *requiredLabelsProcessor) withDefaults(metric *metricspb.Metric) *metricspb.Metric {
+	desc := metric.GetMetricDescriptor()
+	if desc == nil {
+		return metric
+	}
+	indexes := rlp.labelIndexes(desc)
+	complete := true
+	for _, ts := range metric.Timeseries {
+		if !hasLabels(ts, indexes) {
+			complete = false
+			break
+		}
+	}
+	if complete {
+		return metric
+	}
+
+	// The labels the metric does not have are added after its own ones.
+	descCopy := *desc
+	descCopy.LabelKeys = append([]*metricspb.LabelKey(nil), desc.LabelKeys...)
+	for i, index := range indexes {
+		if index < 0 {
+			indexes[i] = len(descCopy.LabelKeys)
+			descCopy.LabelKeys = append(descCopy.LabelKeys, &metricspb.LabelKey{Key: rlp.labels[i]})
+		}
+	}
+
+	timeseries := make([]*metricspb.TimeSeries, 0, len(metric.Timeseries))
+	for _, ts := range metric.Timeseries {
+		if hasLabels(ts, indexes) {
+			timeseries = append(timeseries, ts)
+			continue
+		}
+		tsCopy := *ts
+		tsCopy.LabelValues = make([]*metricspb.LabelValue, len(descCopy.LabelKeys))
+		copy(tsCopy.LabelValues, ts.LabelValues)
+		for i, index := range indexes {
+			if !hasLabel(&tsCopy, index) {
+				tsCopy.LabelValues[index] = &metricspb.LabelValue{Value: rlp.defaults[rlp.labels[i]], HasValue: true}
+			}
+		}
+		for i, lv := range tsCopy.LabelValues {
+			if lv == nil {
+				// The series was missing the values of some of its own labels.
+				tsCopy.LabelValues[i] = &metricspb.LabelValue{}
+			}
+		}
+		timeseries = append(timeseries, &tsCopy)
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: &descCopy,
+		Resource:         metric.Resource,
+		Timeseries:       timeseries,
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredlabelsprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+)
+
+func TestDropPolicy(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkMetricsExporter)
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "required_labels/drop"},
+		Labels:            []string{"env", "team"},
+	}
+	mp, err := NewMetricsProcessor(sink, nil, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), testMetricsData()))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	assert.Equal(t, "requests", got[0].Metrics[0].MetricDescriptor.Name)
+	assert.Equal(t, [][]string{{"prod", "checkout"}}, labelValues(got[0].Metrics[0]))
+
+	rows, err := view.RetrieveData(statDroppedTimeseries.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	cfg := Config{
+		Labels:   []string{"env", "team"},
+		Policy:   DefaultPolicy,
+		Defaults: map[string]string{"env": "unknown", "team": "unowned"},
+	}
+	mp, err := NewMetricsProcessor(sink, nil, cfg)
+	require.NoError(t, err)
+
+	md := testMetricsData()
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 2)
+	assert.Equal(t, [][]string{{"prod", "checkout"}, {"prod", "unowned"}}, labelValues(got[0].Metrics[0]))
+	// The missing label keys are added after the ones of the metric.
+	assert.Equal(t, []*metricspb.LabelKey{{Key: "host"}, {Key: "env"}, {Key: "team"}},
+		got[0].Metrics[1].MetricDescriptor.LabelKeys)
+	assert.Equal(t, [][]string{{"h1", "unknown", "unowned"}}, labelValues(got[0].Metrics[1]))
+
+	// The incoming metrics are left untouched.
+	assert.Equal(t, testMetricsData().Metrics, md.Metrics)
+}
+
+func TestDeadletterPolicy(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkMetricsExporter)
+	deadletter := new(exportertest.SinkMetricsExporter)
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "required_labels/deadletter"},
+		Labels:            []string{"env", "team"},
+		Policy:            DeadletterPolicy,
+	}
+	mp, err := NewMetricsProcessor(sink, deadletter, cfg)
+	require.NoError(t, err)
+
+	md := testMetricsData()
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 1)
+	assert.Equal(t, [][]string{{"prod", "checkout"}}, labelValues(got[0].Metrics[0]))
+
+	dead := deadletter.AllMetrics()
+	require.Len(t, dead, 1)
+	assert.Equal(t, md.Node, dead[0].Node)
+	require.Len(t, dead[0].Metrics, 2)
+	assert.Equal(t, "requests", dead[0].Metrics[0].MetricDescriptor.Name)
+	assert.Equal(t, [][]string{{"prod", ""}}, labelValues(dead[0].Metrics[0]))
+	assert.Equal(t, "cpu", dead[0].Metrics[1].MetricDescriptor.Name)
+
+	rows, err := view.RetrieveData(statDeadletterTimeseries.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestCompleteSeriesPassThrough(t *testing.T) {
+	for _, policy := range []Policy{DropPolicy, DefaultPolicy, DeadletterPolicy} {
+		t.Run(string(policy), func(t *testing.T) {
+			sink := new(exportertest.SinkMetricsExporter)
+			deadletter := new(exportertest.SinkMetricsExporter)
+			cfg := Config{Labels: []string{"env"}, Policy: policy, Defaults: map[string]string{"env": "unknown"}}
+			mp, err := NewMetricsProcessor(sink, deadletter, cfg)
+			require.NoError(t, err)
+
+			md := testMetricsData()
+			md.Metrics = md.Metrics[:1]
+			require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+			got := sink.AllMetrics()
+			require.Len(t, got, 1)
+			assert.Same(t, md.Metrics[0], got[0].Metrics[0])
+			assert.Empty(t, deadletter.AllMetrics())
+		})
+	}
+}
+
+// testMetricsData returns a batch with a series having all the env and team
+// labels, one with an empty team and a metric without both labels.
+func testMetricsData() consumerdata.MetricsData {
+	return consumerdata.MetricsData{
+		Node: &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "checkout"}},
+		Metrics: []*metricspb.Metric{
+			{
+				MetricDescriptor: &metricspb.MetricDescriptor{
+					Name:      "requests",
+					LabelKeys: []*metricspb.LabelKey{{Key: "env"}, {Key: "team"}},
+				},
+				Timeseries: []*metricspb.TimeSeries{
+					{LabelValues: []*metricspb.LabelValue{{Value: "prod", HasValue: true}, {Value: "checkout", HasValue: true}}},
+					{LabelValues: []*metricspb.LabelValue{{Value: "prod", HasValue: true}, {}}},
+				},
+			},
+			{
+				MetricDescriptor: &metricspb.MetricDescriptor{
+					Name:      "cpu",
+					LabelKeys: []*metricspb.LabelKey{{Key: "host"}},
+				},
+				Timeseries: []*metricspb.TimeSeries{
+					{LabelValues: []*metricspb.LabelValue{{Value: "h1", HasValue: true}}},
+				},
+			},
+		},
+	}
+}
+
+func labelValues(metric *metricspb.Metric) [][]string {
+	var values [][]string
+	for _, ts := range metric.Timeseries {
+		var tsValues []string
+		for _, lv := range ts.LabelValues {
+			tsValues = append(tsValues, lv.GetValue())
+		}
+		values = append(values, tsValues)
+	}
+	return values
+}
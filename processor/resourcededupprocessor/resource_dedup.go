@@ -0,0 +1,142 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcededupprocessor
+
+import (
+	"context"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type resourceDedupProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	// keys are the label keys considered, nil to consider every key.
+	keys map[string]bool
+}
+
+var _ processor.MetricsProcessor = (*resourceDedupProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that removes the
+// labels of a metric duplicating an attribute of its resource, i.e. having
+// the key of the attribute and its exact value in every series. The resource
+// of a metric is its own resource if set, otherwise the resource of the batch.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	var keys map[string]bool
+	if len(cfg.Keys) > 0 {
+		keys = make(map[string]bool, len(cfg.Keys))
+		for _, key := range cfg.Keys {
+			keys[key] = true
+		}
+	}
+	return &resourceDedupProcessor{nextConsumer: nextConsumer, keys: keys}, nil
+}
+
+func (rdp *resourceDedupProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		resource := metric.GetResource()
+		if resource == nil {
+			resource = md.Resource
+		}
+		deduped := rdp.dedupMetric(metric, resource.GetLabels())
+		if deduped == metric {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = deduped
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return rdp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// dedupMetric returns the metric without its labels duplicating the given
+// resource attributes. The metric is returned as is if it has none, otherwise
+func (rdp *resourceDedupProcessor) dedupMetric(metric *metricspb.Metric, attributes map[string]string) *metricspb.Metric {
+	desc := metric.GetMetricDescriptor()
+	if desc == nil || len(attributes) == 0 {
+		return metric
+	}
+
+	var redundant map[int]bool
+	for i, key := range desc.LabelKeys {
+		if rdp.keys != nil && !rdp.keys[key.GetKey()] {
+			continue
+		}
+		value, ok := attributes[key.GetKey()]
+		if !ok || !allSeriesHaveValue(metric.Timeseries, i, value) {
+			continue
+		}
+		if redundant == nil {
+			redundant = make(map[int]bool)
+		}
+		redundant[i] = true
+	}
+	if redundant == nil {
+		return metric
+	}
+
+	dedupedDesc := *desc
+	dedupedDesc.LabelKeys = make([]*metricspb.LabelKey, 0, len(desc.LabelKeys)-len(redundant))
+	for i, key := range desc.LabelKeys {
+		if !redundant[i] {
+			dedupedDesc.LabelKeys = append(dedupedDesc.LabelKeys, key)
+		}
+	}
+	deduped := &metricspb.Metric{
+		MetricDescriptor: &dedupedDesc,
+		Resource:         metric.Resource,
+		Timeseries:       make([]*metricspb.TimeSeries, len(metric.Timeseries)),
+	}
+	for i, ts := range metric.Timeseries {
+		dedupedTs := *ts
+		dedupedTs.LabelValues = make([]*metricspb.LabelValue, 0, len(ts.LabelValues))
+		for j, value := range ts.LabelValues {
+			if !redundant[j] {
+				dedupedTs.LabelValues = append(dedupedTs.LabelValues, value)
+			}
+		}
+		deduped.Timeseries[i] = &dedupedTs
+	}
+	return deduped
+}
+
+// allSeriesHaveValue returns whether the label at the given index has the
+// given value in every series. A label differing in a single series is not
+// redundant, the series would collide once it is removed.
+func allSeriesHaveValue(timeseries []*metricspb.TimeSeries, index int, value string) bool {
+	for _, ts := range timeseries {
+		if index >= len(ts.GetLabelValues()) {
+			return false
+		}
+		labelValue := ts.LabelValues[index]
+		if !labelValue.GetHasValue() || labelValue.Value != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcededupprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestRedundantLabelRemoved(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	input := metric([]string{"service.name", "method"},
+		[]string{"checkout", "GET"},
+		[]string{"checkout", "POST"},
+	)
+	md := consumerdata.MetricsData{
+		Resource: &resourcepb.Resource{Labels: map[string]string{"service.name": "checkout"}},
+		Metrics:  []*metricspb.Metric{input},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	deduped := got[0].Metrics[0]
+	assert.Equal(t, [][]string{{"method"}, {"GET"}, {"POST"}}, labels(deduped))
+	// The input is left untouched.
+	assert.Equal(t, [][]string{{"service.name", "method"}, {"checkout", "GET"}, {"checkout", "POST"}}, labels(input))
+}
+
+func TestLabelKept(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		resource map[string]string
+		series   [][]string
+	}{
+		{
+			name:     "different_value",
+			resource: map[string]string{"service.name": "checkout"},
+			series:   [][]string{{"cart", "GET"}},
+		},
+		{
+			name:     "different_in_one_series",
+			resource: map[string]string{"service.name": "checkout"},
+			series:   [][]string{{"checkout", "GET"}, {"cart", "GET"}},
+		},
+		{
+			name:     "no_attribute",
+			resource: map[string]string{"host.name": "checkout"},
+			series:   [][]string{{"checkout", "GET"}},
+		},
+		{
+			name:     "key_not_considered",
+			cfg:      Config{Keys: []string{"host.name"}},
+			resource: map[string]string{"service.name": "checkout"},
+			series:   [][]string{{"checkout", "GET"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &exportertest.SinkMetricsExporter{}
+			mp, err := NewMetricsProcessor(sink, tt.cfg)
+			require.NoError(t, err)
+
+			input := metric([]string{"service.name", "method"}, tt.series...)
+			md := consumerdata.MetricsData{
+				Resource: &resourcepb.Resource{Labels: tt.resource},
+				Metrics:  []*metricspb.Metric{input},
+			}
+			require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+			assert.True(t, sink.AllMetrics()[0].Metrics[0] == input, "the metric is passed as is")
+		})
+	}
+}
+
+func TestMetricResourceTakesPrecedence(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(sink, Config{Keys: []string{"service.name"}})
+	require.NoError(t, err)
+
+	own := metric([]string{"service.name"}, []string{"cart"})
+	own.Resource = &resourcepb.Resource{Labels: map[string]string{"service.name": "cart"}}
+	inherited := metric([]string{"service.name"}, []string{"cart"})
+	md := consumerdata.MetricsData{
+		Resource: &resourcepb.Resource{Labels: map[string]string{"service.name": "checkout"}},
+		Metrics:  []*metricspb.Metric{own, inherited},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()[0].Metrics
+	require.Len(t, got, 2)
+	assert.Equal(t, [][]string{{}, {}}, labels(got[0]))
+	assert.Equal(t, own.Resource, got[0].Resource)
+	assert.True(t, got[1] == inherited, "the label differs from the batch resource")
+}
+
+// metric returns a metric with the given label keys and a series per given
+// label values.
+func metric(keys []string, series ...[]string) *metricspb.Metric {
+	m := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name: "requests",
+			Type: metricspb.MetricDescriptor_CUMULATIVE_INT64,
+		},
+	}
+	for _, key := range keys {
+		m.MetricDescriptor.LabelKeys = append(m.MetricDescriptor.LabelKeys, &metricspb.LabelKey{Key: key})
+	}
+	for _, values := range series {
+		ts := &metricspb.TimeSeries{
+			Points: []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}},
+		}
+		for _, value := range values {
+			ts.LabelValues = append(ts.LabelValues, &metricspb.LabelValue{Value: value, HasValue: true})
+		}
+		m.Timeseries = append(m.Timeseries, ts)
+	}
+	return m
+}
+
+// labels returns the label keys of the metric followed by the label values of
+// every series.
+func labels(metric *metricspb.Metric) [][]string {
+	keys := []string{}
+	for _, key := range metric.MetricDescriptor.LabelKeys {
+		keys = append(keys, key.Key)
+	}
+	labels := [][]string{keys}
+	for _, ts := range metric.Timeseries {
+		values := []string{}
+		for _, value := range ts.LabelValues {
+			values = append(values, value.Value)
+		}
+		labels = append(labels, values)
+	}
+	return labels
+}
@@ -0,0 +1,35 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceenrichmentprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config defines configuration for the resource enrichment processor. The
+// processor must be added to both a metrics and a traces pipeline: the metrics
+// processor records the resource attributes, the trace processor adds them to
+// the spans.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// TTL is how long the resource attributes seen on metrics are kept after
+	// the last metrics of the service were received.
+	TTL time.Duration `mapstructure:"ttl"`
+	// MaxServices is the maximum number of services whose resource attributes
+	// are kept, the least recently updated service is evicted first.
+	MaxServices int `mapstructure:"max_services"`
+}
@@ -0,0 +1,103 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceenrichmentprocessor
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	// typeStr is the value of "type" key in configuration.
+	typeStr = "resource_enrichment"
+
+	defaultTTL         = 10 * time.Minute
+	defaultMaxServices = 1000
+)
+
+// Factory is the factory for the resource enrichment processor. The trace and
+// metrics processors created for the same configuration share their resource
+// store.
+type Factory struct {
+	mu     sync.Mutex
+	stores map[string]*resourceStore
+}
+
+// Type gets the type of the config created by this factory.
+func (f *Factory) Type() string {
+	return typeStr
+}
+
+// CreateDefaultConfig creates the default configuration for the processor.
+func (f *Factory) CreateDefaultConfig() configmodels.Processor {
+	return &Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		TTL:         defaultTTL,
+		MaxServices: defaultMaxServices,
+	}
+}
+
+// CreateTraceProcessor creates a trace processor based on this config.
+func (f *Factory) CreateTraceProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.TraceConsumer,
+	cfg configmodels.Processor,
+) (processor.TraceProcessor, error) {
+	oCfg := cfg.(*Config)
+	return newTraceProcessor(nextConsumer, f.storeFor(oCfg))
+}
+
+// CreateMetricsProcessor creates a metrics processor based on this config.
+func (f *Factory) CreateMetricsProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.MetricsConsumer,
+	cfg configmodels.Processor,
+) (processor.MetricsProcessor, error) {
+	oCfg := cfg.(*Config)
+	return newMetricsProcessor(nextConsumer, f.storeFor(oCfg))
+}
+
+// storeFor returns the resource store shared by the processors of the given config.
+func (f *Factory) storeFor(cfg *Config) *resourceStore {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stores == nil {
+		f.stores = make(map[string]*resourceStore)
+	}
+	rs, ok := f.stores[cfg.Name()]
+	if !ok {
+		ttl := cfg.TTL
+		if ttl <= 0 {
+			ttl = defaultTTL
+		}
+		maxServices := cfg.MaxServices
+		if maxServices <= 0 {
+			maxServices = defaultMaxServices
+		}
+		rs = newResourceStore(maxServices, ttl)
+		f.stores[cfg.Name()] = rs
+	}
+	return rs
+}
@@ -0,0 +1,96 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceenrichmentprocessor
+
+import (
+	"context"
+
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type metricsResourceProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	store        *resourceStore
+}
+
+var _ processor.MetricsProcessor = (*metricsResourceProcessor)(nil)
+
+func newMetricsProcessor(nextConsumer consumer.MetricsConsumer, store *resourceStore) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	return &metricsResourceProcessor{
+		nextConsumer: nextConsumer,
+		store:        store,
+	}, nil
+}
+
+// ConsumeMetricsData records the resource attributes of the batch and of its
+// metrics for the service of the batch and passes the data unchanged to the
+// next consumer.
+func (mrp *metricsResourceProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	service := processor.ServiceNameForNode(md.Node)
+	mrp.store.update(service, md.Resource.GetType(), md.Resource.GetLabels())
+	for _, metric := range md.Metrics {
+		if metric.GetResource() != nil {
+			mrp.store.update(service, metric.Resource.Type, metric.Resource.Labels)
+		}
+	}
+	return mrp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+type traceResourceProcessor struct {
+	nextConsumer consumer.TraceConsumer
+	store        *resourceStore
+}
+
+var _ processor.TraceProcessor = (*traceResourceProcessor)(nil)
+
+func newTraceProcessor(nextConsumer consumer.TraceConsumer, store *resourceStore) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	return &traceResourceProcessor{
+		nextConsumer: nextConsumer,
+		store:        store,
+	}, nil
+}
+
+// ConsumeTraceData adds to the resource of the batch the attributes seen on the
+// metrics of the same service. Attributes already present on the resource take
+// precedence.
+func (trp *traceResourceProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	resourceType, labels, ok := trp.store.get(processor.ServiceNameForNode(td.Node))
+	if !ok {
+		return trp.nextConsumer.ConsumeTraceData(ctx, td)
+	}
+
+	for k, v := range td.Resource.GetLabels() {
+		labels[k] = v
+	}
+	if t := td.Resource.GetType(); t != "" {
+		resourceType = t
+	}
+	td.Resource = &resourcepb.Resource{
+		Type:   resourceType,
+		Labels: labels,
+	}
+	return trp.nextConsumer.ConsumeTraceData(ctx, td)
+}
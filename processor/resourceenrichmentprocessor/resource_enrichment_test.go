@@ -0,0 +1,89 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceenrichmentprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+)
+
+func TestSpanGainsAttributeSeenOnMetrics(t *testing.T) {
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig()
+
+	metricsSink := new(exportertest.SinkMetricsExporter)
+	mp, err := factory.CreateMetricsProcessor(zap.NewNop(), metricsSink, cfg)
+	require.NoError(t, err)
+	traceSink := new(exportertest.SinkTraceExporter)
+	tp, err := factory.CreateTraceProcessor(zap.NewNop(), traceSink, cfg)
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Node: node("checkout"),
+		Resource: &resourcepb.Resource{
+			Type:   "k8s",
+			Labels: map[string]string{"k8s.pod.name": "checkout-1", "cloud.zone": "us-east1-b"},
+		},
+		Metrics: []*metricspb.Metric{
+			{
+				MetricDescriptor: &metricspb.MetricDescriptor{Name: "requests"},
+				Resource:         &resourcepb.Resource{Labels: map[string]string{"k8s.namespace.name": "shop"}},
+			},
+		},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+	assert.Len(t, metricsSink.AllMetrics(), 1)
+
+	spanResource := &resourcepb.Resource{Labels: map[string]string{"cloud.zone": "us-east1-c"}}
+	tds := []consumerdata.TraceData{
+		{Node: node("checkout"), Resource: spanResource, Spans: []*tracepb.Span{{}}},
+		{Node: node("cart"), Spans: []*tracepb.Span{{}}},
+	}
+	for _, td := range tds {
+		require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	}
+
+	got := traceSink.AllTraces()
+	require.Len(t, got, 2)
+	assert.Equal(t, &resourcepb.Resource{
+		Type: "k8s",
+		Labels: map[string]string{
+			"k8s.pod.name":       "checkout-1",
+			"k8s.namespace.name": "shop",
+			// Attributes of the span resource take precedence.
+			"cloud.zone": "us-east1-c",
+		},
+	}, got[0].Resource)
+	// The resource of the incoming data is left untouched.
+	assert.Equal(t, map[string]string{"cloud.zone": "us-east1-c"}, spanResource.Labels)
+
+	// Nothing was seen on metrics for the other service.
+	assert.Nil(t, got[1].Resource)
+}
+
+func node(service string) *commonpb.Node {
+	return &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: service}}
+}
@@ -0,0 +1,111 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceenrichmentprocessor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/lru"
+)
+
+type resourceEntry struct {
+	resourceType string
+	labels       map[string]string
+	updated      time.Time
+}
+
+// resourceStore is a bounded cache of the resource attributes seen on metrics,
+// keyed by service. Entries expire once they were not updated for the TTL. It
+// is safe for concurrent use.
+type resourceStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries *lru.Cache
+}
+
+func newResourceStore(maxServices int, ttl time.Duration) *resourceStore {
+	return &resourceStore{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: lru.New(maxServices),
+	}
+}
+
+// update merges the given resource type and labels into the attributes of the
+// service, the latest values take precedence.
+func (rs *resourceStore) update(service, resourceType string, labels map[string]string) {
+	if resourceType == "" && len(labels) == 0 {
+		return
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	now := rs.now()
+	var entry *resourceEntry
+	if value, ok := rs.entries.Get(service); ok {
+		entry = value.(*resourceEntry)
+		if now.Sub(entry.updated) >= rs.ttl {
+			// Do not merge into expired attributes.
+			entry.resourceType = ""
+			entry.labels = make(map[string]string, len(labels))
+		}
+	} else {
+		entry = &resourceEntry{labels: make(map[string]string, len(labels))}
+	}
+	rs.entries.Add(service, entry)
+
+	entry.updated = now
+	if resourceType != "" {
+		entry.resourceType = resourceType
+	}
+	for k, v := range labels {
+		entry.labels[k] = v
+	}
+}
+
+// get returns a copy of the resource type and labels of the service, if they
+// did not expire.
+func (rs *resourceStore) get(service string) (string, map[string]string, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	value, ok := rs.entries.Get(service)
+	if !ok {
+		return "", nil, false
+	}
+	entry := value.(*resourceEntry)
+	if rs.now().Sub(entry.updated) >= rs.ttl {
+		rs.entries.Remove(service)
+		return "", nil, false
+	}
+
+	labels := make(map[string]string, len(entry.labels))
+	for k, v := range entry.labels {
+		labels[k] = v
+	}
+	return entry.resourceType, labels, true
+}
+
+// len returns the number of services in the store, including expired ones
+// not yet evicted.
+func (rs *resourceStore) len() int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.entries.Len()
+}
@@ -0,0 +1,79 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceenrichmentprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceStoreMergesUpdates(t *testing.T) {
+	rs := newResourceStore(10, time.Minute)
+	rs.update("svc", "k8s", map[string]string{"a": "1", "b": "1"})
+	rs.update("svc", "", map[string]string{"b": "2"})
+
+	resourceType, labels, ok := rs.get("svc")
+	assert.True(t, ok)
+	assert.Equal(t, "k8s", resourceType)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, labels)
+
+	// The returned labels are a copy.
+	labels["c"] = "3"
+	_, labels, _ = rs.get("svc")
+	assert.Len(t, labels, 2)
+
+	_, _, ok = rs.get("other")
+	assert.False(t, ok)
+}
+
+func TestResourceStoreIsBounded(t *testing.T) {
+	rs := newResourceStore(2, time.Minute)
+	rs.update("a", "", map[string]string{"k": "v"})
+	rs.update("b", "", map[string]string{"k": "v"})
+	rs.update("a", "", map[string]string{"k": "v"})
+	rs.update("c", "", map[string]string{"k": "v"})
+
+	assert.Equal(t, 2, rs.len())
+	_, _, ok := rs.get("b")
+	assert.False(t, ok, "least recently updated service must be evicted")
+	_, _, ok = rs.get("a")
+	assert.True(t, ok)
+}
+
+func TestResourceStoreExpires(t *testing.T) {
+	now := time.Unix(1000, 0)
+	rs := newResourceStore(10, time.Minute)
+	rs.now = func() time.Time { return now }
+
+	rs.update("svc", "", map[string]string{"old": "v"})
+	now = now.Add(30 * time.Second)
+	_, _, ok := rs.get("svc")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, _, ok = rs.get("svc")
+	assert.False(t, ok)
+	assert.Equal(t, 0, rs.len())
+
+	// Expired attributes are not merged into new ones.
+	rs.update("svc", "", map[string]string{"old": "v"})
+	now = now.Add(2 * time.Minute)
+	rs.update("svc", "", map[string]string{"new": "v"})
+	_, labels, ok := rs.get("svc")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"new": "v"}, labels)
+}
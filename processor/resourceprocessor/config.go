@@ -0,0 +1,77 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceprocessor
+
+import (
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config specifies the list of actions applied to the resource labels of the
+// data, both the resource of the batch and the resources of the spans or
+// metrics overriding it. The actions are applied in the order specified in the
+// configuration.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+
+	// Actions specifies the list of actions applied to the resource labels.
+	// This is a required field.
+	Actions []ResourceAction `mapstructure:"actions"`
+}
+
+// ResourceAction specifies an action applied to the resource labels.
+type ResourceAction struct {
+	// Action specifies the type of action to perform.
+	// The set of values are {RENAME}. Both lower case and upper case are
+	// supported.
+	// RENAME - Moves the value of the FromKey label to the ToKey label. No
+	//          action is applied to resources without the FromKey label.
+	// This is a required field.
+	Action Action `mapstructure:"action"`
+
+	// FromKey specifies the label to rename.
+	// This is a required field.
+	FromKey string `mapstructure:"from_key"`
+
+	// ToKey specifies the new name of the label.
+	// This is a required field.
+	ToKey string `mapstructure:"to_key"`
+
+	// OnConflict specifies what to do when the resource already has a ToKey
+	// label. The set of values are {SKIP, OVERWRITE}, SKIP being the default.
+	// SKIP      - The resource is left unchanged, both labels are kept.
+	// OVERWRITE - The value of the ToKey label is replaced.
+	OnConflict ConflictPolicy `mapstructure:"on_conflict"`
+}
+
+// Action is the enum to capture the types of actions to perform on the
+// resource labels.
+type Action string
+
+const (
+	// RENAME moves the value of a label to a new key.
+	RENAME Action = "rename"
+)
+
+// ConflictPolicy is the enum to capture what to do when a rename targets an
+// existing label.
+type ConflictPolicy string
+
+const (
+	// SKIP leaves the resource unchanged.
+	SKIP ConflictPolicy = "skip"
+
+	// OVERWRITE replaces the value of the existing label.
+	OVERWRITE ConflictPolicy = "overwrite"
+)
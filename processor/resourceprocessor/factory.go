@@ -0,0 +1,111 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceprocessor
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	// typeStr is the value of "type" key in configuration.
+	typeStr = "resource"
+)
+
+// Factory is the factory for the resource processor.
+type Factory struct {
+}
+
+// Type gets the type of the config created by this factory.
+func (f *Factory) Type() string {
+	return typeStr
+}
+
+// CreateDefaultConfig creates the default configuration for the processor.
+// Note: This isn't a valid configuration because the processor would do no work.
+func (f *Factory) CreateDefaultConfig() configmodels.Processor {
+	return &Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+	}
+}
+
+// CreateTraceProcessor creates a trace processor based on this config.
+func (f *Factory) CreateTraceProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.TraceConsumer,
+	cfg configmodels.Processor,
+) (processor.TraceProcessor, error) {
+	oCfg := cfg.(*Config)
+	actions, err := buildResourceActions(*oCfg)
+	if err != nil {
+		return nil, err
+	}
+	return newTraceProcessor(nextConsumer, actions)
+}
+
+// CreateMetricsProcessor creates a metrics processor based on this config.
+func (f *Factory) CreateMetricsProcessor(
+	logger *zap.Logger,
+	nextConsumer consumer.MetricsConsumer,
+	cfg configmodels.Processor,
+) (processor.MetricsProcessor, error) {
+	oCfg := cfg.(*Config)
+	actions, err := buildResourceActions(*oCfg)
+	if err != nil {
+		return nil, err
+	}
+	return newMetricsProcessor(nextConsumer, actions)
+}
+
+// buildResourceActions validates the input configuration has all of the required fields for the processor
+// and returns the list of actions with their options normalized.
+// An error is returned if there are any invalid inputs.
+func buildResourceActions(config Config) ([]ResourceAction, error) {
+	if len(config.Actions) == 0 {
+		return nil, fmt.Errorf("error creating \"resource\" processor due to missing required field \"actions\" of processor %q", config.Name())
+	}
+
+	actions := make([]ResourceAction, 0, len(config.Actions))
+	for i, a := range config.Actions {
+		a.Action = Action(strings.ToLower(string(a.Action)))
+		switch a.Action {
+		case RENAME:
+			if a.FromKey == "" || a.ToKey == "" {
+				return nil, fmt.Errorf("error creating \"resource\" processor due to missing required field \"from_key\" or \"to_key\" at the %d-th actions of processor %q", i, config.Name())
+			}
+			a.OnConflict = ConflictPolicy(strings.ToLower(string(a.OnConflict)))
+			switch a.OnConflict {
+			case "":
+				a.OnConflict = SKIP
+			case SKIP, OVERWRITE:
+			default:
+				return nil, fmt.Errorf("error creating \"resource\" processor due to unsupported on_conflict %q at the %d-th actions of processor %q", a.OnConflict, i, config.Name())
+			}
+		default:
+			return nil, fmt.Errorf("error creating \"resource\" processor due to unsupported action %q at the %d-th actions of processor %q", a.Action, i, config.Name())
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceprocessor
+
+import (
+	"context"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type traceResourceProcessor struct {
+	nextConsumer consumer.TraceConsumer
+	actions      []ResourceAction
+}
+
+var _ processor.TraceProcessor = (*traceResourceProcessor)(nil)
+
+// newTraceProcessor returns a processor that modifies the resource labels of the spans.
+// To construct the resource processors, the use of the factory methods are required
+// in order to validate the inputs.
+func newTraceProcessor(nextConsumer consumer.TraceConsumer, actions []ResourceAction) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	return &traceResourceProcessor{
+		nextConsumer: nextConsumer,
+		actions:      actions,
+	}, nil
+}
+
+func (trp *traceResourceProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	td.Resource = applyActions(trp.actions, td.Resource)
+	var spans []*tracepb.Span
+	for i, span := range td.Spans {
+		if span == nil || span.Resource == nil {
+			continue
+		}
+		resource := applyActions(trp.actions, span.Resource)
+		if resource == span.Resource {
+			continue
+		}
+		if spans == nil {
+			spans = make([]*tracepb.Span, len(td.Spans))
+			copy(spans, td.Spans)
+		}
+		renamedSpan := *span
+		renamedSpan.Resource = resource
+		spans[i] = &renamedSpan
+	}
+	if spans != nil {
+		td.Spans = spans
+	}
+	return trp.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+type metricsResourceProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	actions      []ResourceAction
+}
+
+var _ processor.MetricsProcessor = (*metricsResourceProcessor)(nil)
+
+// newMetricsProcessor returns a processor that modifies the resource labels of the metrics.
+// To construct the resource processors, the use of the factory methods are required
+// in order to validate the inputs.
+func newMetricsProcessor(nextConsumer consumer.MetricsConsumer, actions []ResourceAction) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	return &metricsResourceProcessor{
+		nextConsumer: nextConsumer,
+		actions:      actions,
+	}, nil
+}
+
+func (mrp *metricsResourceProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	md.Resource = applyActions(mrp.actions, md.Resource)
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		if metric == nil || metric.Resource == nil {
+			continue
+		}
+		resource := applyActions(mrp.actions, metric.Resource)
+		if resource == metric.Resource {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		renamedMetric := *metric
+		renamedMetric.Resource = resource
+		metrics[i] = &renamedMetric
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return mrp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// applyActions returns the resource with the actions applied, a new one if any
+// label changes.
+func applyActions(actions []ResourceAction, resource *resourcepb.Resource) *resourcepb.Resource {
+	if len(resource.GetLabels()) == 0 {
+		return resource
+	}
+
+	var labels map[string]string
+	for _, action := range actions {
+		current := labels
+		if current == nil {
+			current = resource.Labels
+		}
+		value, ok := current[action.FromKey]
+		if !ok {
+			continue
+		}
+		if _, exists := current[action.ToKey]; exists && action.OnConflict == SKIP {
+			continue
+		}
+
+		if labels == nil {
+			labels = make(map[string]string, len(resource.Labels))
+			for k, v := range resource.Labels {
+				labels[k] = v
+			}
+		}
+		delete(labels, action.FromKey)
+		labels[action.ToKey] = value
+	}
+
+	if labels == nil {
+		return resource
+	}
+	return &resourcepb.Resource{
+		Type:   resource.Type,
+		Labels: labels,
+	}
+}
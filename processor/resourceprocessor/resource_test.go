@@ -0,0 +1,144 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewProcessorNilNext(t *testing.T) {
+	tp, err := newTraceProcessor(nil, nil)
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	mp, err := newMetricsProcessor(nil, nil)
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		name       string
+		onConflict ConflictPolicy
+		labels     map[string]string
+		want       map[string]string
+	}{
+		{
+			name:       "clean",
+			onConflict: SKIP,
+			labels:     map[string]string{"host": "a", "zone": "z"},
+			want:       map[string]string{"host.name": "a", "zone": "z"},
+		},
+		{
+			name:       "missing_key",
+			onConflict: SKIP,
+			labels:     map[string]string{"zone": "z"},
+			want:       map[string]string{"zone": "z"},
+		},
+		{
+			name:       "existing_target_skip",
+			onConflict: SKIP,
+			labels:     map[string]string{"host": "a", "host.name": "b"},
+			want:       map[string]string{"host": "a", "host.name": "b"},
+		},
+		{
+			name:       "existing_target_overwrite",
+			onConflict: OVERWRITE,
+			labels:     map[string]string{"host": "a", "host.name": "b"},
+			want:       map[string]string{"host.name": "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Actions: []ResourceAction{
+				{Action: RENAME, FromKey: "host", ToKey: "host.name", OnConflict: tt.onConflict},
+			}}
+			actions, err := buildResourceActions(cfg)
+			require.NoError(t, err)
+
+			sink := &exportertest.SinkMetricsExporter{}
+			mp, err := newMetricsProcessor(sink, actions)
+			require.NoError(t, err)
+
+			resource := &resourcepb.Resource{Type: "host", Labels: copyLabels(tt.labels)}
+			md := consumerdata.MetricsData{
+				Resource: resource,
+				Metrics: []*metricspb.Metric{
+					{Resource: &resourcepb.Resource{Labels: copyLabels(tt.labels)}},
+					{},
+				},
+			}
+			require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+			got := sink.AllMetrics()
+			require.Len(t, got, 1)
+			assert.Equal(t, "host", got[0].Resource.Type)
+			assert.Equal(t, tt.want, got[0].Resource.Labels)
+			assert.Equal(t, tt.want, got[0].Metrics[0].Resource.Labels)
+			assert.Nil(t, got[0].Metrics[1].Resource)
+			// The original resources, possibly shared, are left unchanged.
+			assert.Equal(t, tt.labels, resource.Labels)
+			assert.Equal(t, tt.labels, md.Metrics[0].Resource.Labels)
+		})
+	}
+}
+
+func TestRenameTraces(t *testing.T) {
+	actions, err := buildResourceActions(Config{Actions: []ResourceAction{
+		{Action: RENAME, FromKey: "host", ToKey: "host.name"},
+		// Actions are applied in order, so a renamed label can be renamed again.
+		{Action: RENAME, FromKey: "host.name", ToKey: "host.hostname"},
+	}})
+	require.NoError(t, err)
+
+	sink := &exportertest.SinkTraceExporter{}
+	tp, err := newTraceProcessor(sink, actions)
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{
+		Resource: &resourcepb.Resource{Labels: map[string]string{"host": "a"}},
+		Spans: []*tracepb.Span{
+			{Resource: &resourcepb.Resource{Labels: map[string]string{"host": "b"}}},
+			nil,
+		},
+	}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	assert.Equal(t, map[string]string{"host.hostname": "a"}, got[0].Resource.Labels)
+	assert.Equal(t, map[string]string{"host.hostname": "b"}, got[0].Spans[0].Resource.Labels)
+	// The spans may be shared, they must not be modified.
+	assert.Equal(t, map[string]string{"host": "b"}, td.Spans[0].Resource.Labels)
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	c := make(map[string]string, len(labels))
+	for k, v := range labels {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,52 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicegraphprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// Config defines configuration for the service graph processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// MetricsExporters are the names of the exporters the edge metrics are sent
+	// to. They are defined as the other exporters, but do not have to be part
+	// of a pipeline.
+	MetricsExporters []string `mapstructure:"metrics_exporters"`
+	// Dimensions are the keys of the span attributes added as labels to the
+	// edge metrics, after the client and server labels. The value is taken from
+	// the client span, or from the server span if the client one has none.
+	Dimensions []string `mapstructure:"dimensions"`
+	// LatencyBuckets are the bounds, in seconds, of the buckets of the call
+	// latency distribution. The default buckets span from 5ms to 10s.
+	LatencyBuckets []float64 `mapstructure:"latency_buckets"`
+	// Wait is how long a client or server span without a peer.service
+	// attribute is kept waiting for the span on the other side of the call.
+	Wait time.Duration `mapstructure:"wait"`
+	// MaxPendingSpans is the maximum number of spans kept waiting for the span
+	// on the other side of their call, the spans beyond it are not paired.
+	MaxPendingSpans int `mapstructure:"max_pending_spans"`
+}
+
+var _ processor.MetricsEmitterConfig = (*Config)(nil)
+
+// MetricsExporterNames returns the names of the exporters the edge metrics are
+// sent to.
+func (cfg *Config) MetricsExporterNames() []string {
+	return cfg.MetricsExporters
+}
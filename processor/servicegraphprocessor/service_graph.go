@@ -0,0 +1,469 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicegraphprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	// peerServiceAttribute is the attribute of the client spans naming the
+	// called service.
+	peerServiceAttribute = "peer.service"
+
+	clientLabel = "client"
+	serverLabel = "server"
+
+	requestsMetricName       = "service_graph_request_total"
+	failedRequestsMetricName = "service_graph_request_failed_total"
+	latencyMetricName        = "service_graph_request_duration_seconds"
+)
+
+// defaultLatencyBuckets are the latency buckets used when none are configured.
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var errNilMetricsConsumer = errors.New("nil metrics consumer, the service graph processor requires metrics exporters")
+
+// edge holds the cumulative stats of the calls from a client to a server
+// service with the same dimensions.
+type edge struct {
+	labelValues  []*metricspb.LabelValue
+	calls        int64
+	failedCalls  int64
+	latencySum   float64
+	bucketCounts []int64
+}
+
+// pendingSpan is a span without peer.service attribute waiting for the span on
+// the other side of its call.
+type pendingSpan struct {
+	service string
+	dims    []*metricspb.LabelValue
+	latency float64
+	failed  bool
+	// recorded is set for the client spans whose edge was recorded from their
+	// peer.service attribute, the server span only has to be discarded.
+	recorded bool
+	expires  time.Time
+}
+
+type serviceGraphProcessor struct {
+	nextConsumer    consumer.TraceConsumer
+	metricsConsumer consumer.MetricsConsumer
+	logger          *zap.Logger
+	dimensions      []string
+	bounds          []float64
+	wait            time.Duration
+	maxPending      int
+	statsTags       []tag.Mutator
+	now             func() time.Time
+	startTime       *timestamp.Timestamp
+
+	mu    sync.Mutex
+	edges map[string]*edge
+	// clients holds the client spans keyed by trace and span id, servers holds
+	// the server spans keyed by trace and parent span id.
+	clients    map[string]*pendingSpan
+	servers    map[string]*pendingSpan
+	nextExpiry time.Time
+}
+
+var _ processor.TraceProcessor = (*serviceGraphProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that derives the edges
+// of a service map from the spans, sends them as metrics to the metrics
+// consumer and passes the spans through. An edge goes from the service of a
+// client span to the service named by its peer.service attribute or, without
+// it, to the service of the server span whose parent is the client span.
+func NewTraceProcessor(logger *zap.Logger, nextConsumer consumer.TraceConsumer, metricsConsumer consumer.MetricsConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if metricsConsumer == nil {
+		return nil, errNilMetricsConsumer
+	}
+	if len(cfg.LatencyBuckets) == 0 {
+		cfg.LatencyBuckets = defaultLatencyBuckets
+	}
+	for i, bound := range cfg.LatencyBuckets {
+		if bound <= 0 || (i > 0 && bound <= cfg.LatencyBuckets[i-1]) {
+			return nil, fmt.Errorf("latency_buckets must be positive and strictly increasing, got %v", cfg.LatencyBuckets)
+		}
+	}
+	for _, key := range cfg.Dimensions {
+		if key == "" || key == clientLabel || key == serverLabel {
+			return nil, fmt.Errorf("invalid dimension %q", key)
+		}
+	}
+	if cfg.Wait <= 0 {
+		return nil, fmt.Errorf("wait must be positive, got %v", cfg.Wait)
+	}
+	if cfg.MaxPendingSpans <= 0 {
+		return nil, fmt.Errorf("max_pending_spans must be positive, got %d", cfg.MaxPendingSpans)
+	}
+
+	now := time.Now()
+	startTime, _ := ptypes.TimestampProto(now)
+	return &serviceGraphProcessor{
+		nextConsumer:    nextConsumer,
+		metricsConsumer: metricsConsumer,
+		logger:          logger,
+		dimensions:      cfg.Dimensions,
+		bounds:          cfg.LatencyBuckets,
+		wait:            cfg.Wait,
+		maxPending:      cfg.MaxPendingSpans,
+		statsTags:       []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+		now:             time.Now,
+		startTime:       startTime,
+		edges:           make(map[string]*edge),
+		clients:         make(map[string]*pendingSpan),
+		servers:         make(map[string]*pendingSpan),
+	}, nil
+}
+
+func (sgp *serviceGraphProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	service := processor.ServiceNameForNode(td.Node)
+	now := sgp.now()
+	updated := make(map[string]*edge)
+
+	sgp.mu.Lock()
+	unpaired := sgp.expire(now)
+	for _, span := range td.Spans {
+		switch span.GetKind() {
+		case tracepb.Span_CLIENT:
+			unpaired += sgp.observeClient(service, span, now, updated)
+		case tracepb.Span_SERVER:
+			unpaired += sgp.observeServer(service, span, now, updated)
+		}
+	}
+	var metrics []*metricspb.Metric
+	if len(updated) > 0 {
+		metrics = sgp.buildMetrics(updated, now)
+	}
+	sgp.mu.Unlock()
+
+	if unpaired > 0 {
+		stats.RecordWithTags(context.Background(), sgp.statsTags, statUnpairedSpans.M(int64(unpaired)))
+	}
+
+	var errs []error
+	if len(metrics) > 0 {
+		if err := sgp.metricsConsumer.ConsumeMetricsData(ctx, consumerdata.MetricsData{Metrics: metrics}); err != nil {
+			sgp.logger.Warn("Failed to send the service graph metrics", zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	if err := sgp.nextConsumer.ConsumeTraceData(ctx, td); err != nil {
+		errs = append(errs, err)
+	}
+	return oterr.CombineErrors(errs)
+}
+
+// observeClient records the edge of the client span or keeps it waiting for
+// its server span. It returns the number of spans that will not be paired.
+func (sgp *serviceGraphProcessor) observeClient(service string, span *tracepb.Span, now time.Time, updated map[string]*edge) int {
+	dims := sgp.dimensionValues(span)
+	latency, failed := spanLatency(span), spanFailed(span)
+	peer := stringAttribute(span, peerServiceAttribute)
+	if peer != "" {
+		sgp.record(service, peer, dims, latency, failed, updated)
+	}
+
+	key := spanKey(span.TraceId, span.SpanId)
+	if server, ok := sgp.servers[key]; ok {
+		delete(sgp.servers, key)
+		if peer == "" {
+			sgp.record(service, server.service, mergeDimensions(dims, server.dims), latency, failed || server.failed, updated)
+		}
+		return 0
+	}
+	return sgp.addPending(sgp.clients, key, &pendingSpan{
+		service:  service,
+		dims:     dims,
+		latency:  latency,
+		failed:   failed,
+		recorded: peer != "",
+		expires:  now.Add(sgp.wait),
+	})
+}
+
+// observeServer records the edge of the server span if its client span was
+// seen, or keeps it waiting for it. It returns the number of spans that will
+// not be paired.
+func (sgp *serviceGraphProcessor) observeServer(service string, span *tracepb.Span, now time.Time, updated map[string]*edge) int {
+	if len(span.ParentSpanId) == 0 {
+		// A root span is not called by another service.
+		return 0
+	}
+
+	dims := sgp.dimensionValues(span)
+	failed := spanFailed(span)
+	key := spanKey(span.TraceId, span.ParentSpanId)
+	if client, ok := sgp.clients[key]; ok {
+		delete(sgp.clients, key)
+		if !client.recorded {
+			sgp.record(client.service, service, mergeDimensions(client.dims, dims), client.latency, client.failed || failed, updated)
+		}
+		return 0
+	}
+	return sgp.addPending(sgp.servers, key, &pendingSpan{
+		service: service,
+		dims:    dims,
+		failed:  failed,
+		expires: now.Add(sgp.wait),
+	})
+}
+
+func (sgp *serviceGraphProcessor) addPending(pending map[string]*pendingSpan, key string, span *pendingSpan) int {
+	if len(sgp.clients)+len(sgp.servers) >= sgp.maxPending {
+		if span.recorded {
+			return 0
+		}
+		return 1
+	}
+	pending[key] = span
+	return 0
+}
+
+// expire removes the pending spans that waited for longer than the wait and
+// returns how many were never paired. The pending spans are scanned at most
+// every half wait.
+func (sgp *serviceGraphProcessor) expire(now time.Time) int {
+	if now.Before(sgp.nextExpiry) {
+		return 0
+	}
+	sgp.nextExpiry = now.Add(sgp.wait / 2)
+
+	unpaired := 0
+	for _, pending := range []map[string]*pendingSpan{sgp.clients, sgp.servers} {
+		for key, span := range pending {
+			if now.Before(span.expires) {
+				continue
+			}
+			delete(pending, key)
+			if !span.recorded {
+				unpaired++
+			}
+		}
+	}
+	return unpaired
+}
+
+// record adds a call to the stats of the edge from the client to the server.
+func (sgp *serviceGraphProcessor) record(client, server string, dims []*metricspb.LabelValue, latency float64, failed bool, updated map[string]*edge) {
+	var b strings.Builder
+	b.WriteString(client)
+	b.WriteByte(0)
+	b.WriteString(server)
+	for _, dim := range dims {
+		b.WriteByte(0)
+		if dim.HasValue {
+			// Distinguish an empty value from no value.
+			b.WriteByte(1)
+			b.WriteString(dim.Value)
+		}
+	}
+	key := b.String()
+
+	e, ok := sgp.edges[key]
+	if !ok {
+		labelValues := make([]*metricspb.LabelValue, 0, 2+len(dims))
+		labelValues = append(labelValues,
+			&metricspb.LabelValue{Value: client, HasValue: true},
+			&metricspb.LabelValue{Value: server, HasValue: true})
+		labelValues = append(labelValues, dims...)
+		e = &edge{labelValues: labelValues, bucketCounts: make([]int64, len(sgp.bounds)+1)}
+		sgp.edges[key] = e
+	}
+
+	e.calls++
+	if failed {
+		e.failedCalls++
+	}
+	e.latencySum += latency
+	e.bucketCounts[sort.Search(len(sgp.bounds), func(i int) bool { return sgp.bounds[i] > latency })]++
+	updated[key] = e
+}
+
+// buildMetrics returns the metrics of the updated edges, holding the
+// cumulative stats since the processor was created.
+func (sgp *serviceGraphProcessor) buildMetrics(updated map[string]*edge, now time.Time) []*metricspb.Metric {
+	keys := make([]string, 0, len(updated))
+	for key := range updated {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	labelKeys := make([]*metricspb.LabelKey, 0, 2+len(sgp.dimensions))
+	labelKeys = append(labelKeys, &metricspb.LabelKey{Key: clientLabel}, &metricspb.LabelKey{Key: serverLabel})
+	for _, dim := range sgp.dimensions {
+		labelKeys = append(labelKeys, &metricspb.LabelKey{Key: dim})
+	}
+	ts, _ := ptypes.TimestampProto(now)
+
+	requests := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        requestsMetricName,
+			Description: "Number of calls from the client to the server service",
+			Unit:        "1",
+			Type:        metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys:   labelKeys,
+		},
+	}
+	failedRequests := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        failedRequestsMetricName,
+			Description: "Number of failed calls from the client to the server service",
+			Unit:        "1",
+			Type:        metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys:   labelKeys,
+		},
+	}
+	latency := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        latencyMetricName,
+			Description: "Latency of the calls from the client to the server service, as seen by the client",
+			Unit:        "s",
+			Type:        metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION,
+			LabelKeys:   labelKeys,
+		},
+	}
+
+	for _, key := range keys {
+		e := updated[key]
+		requests.Timeseries = append(requests.Timeseries, sgp.timeSeries(e, ts,
+			&metricspb.Point_Int64Value{Int64Value: e.calls}))
+		failedRequests.Timeseries = append(failedRequests.Timeseries, sgp.timeSeries(e, ts,
+			&metricspb.Point_Int64Value{Int64Value: e.failedCalls}))
+
+		buckets := make([]*metricspb.DistributionValue_Bucket, len(e.bucketCounts))
+		for i, count := range e.bucketCounts {
+			buckets[i] = &metricspb.DistributionValue_Bucket{Count: count}
+		}
+		latency.Timeseries = append(latency.Timeseries, sgp.timeSeries(e, ts,
+			&metricspb.Point_DistributionValue{DistributionValue: &metricspb.DistributionValue{
+				Count: e.calls,
+				Sum:   e.latencySum,
+				BucketOptions: &metricspb.DistributionValue_BucketOptions{
+					Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+						Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: sgp.bounds},
+					},
+				},
+				Buckets: buckets,
+			}}))
+	}
+	return []*metricspb.Metric{requests, failedRequests, latency}
+}
+
+func (sgp *serviceGraphProcessor) timeSeries(e *edge, ts *timestamp.Timestamp, value interface{}) *metricspb.TimeSeries {
+	point := &metricspb.Point{Timestamp: ts}
+	switch v := value.(type) {
+	case *metricspb.Point_Int64Value:
+		point.Value = v
+	case *metricspb.Point_DistributionValue:
+		point.Value = v
+	}
+	return &metricspb.TimeSeries{
+		StartTimestamp: sgp.startTime,
+		LabelValues:    e.labelValues,
+		Points:         []*metricspb.Point{point},
+	}
+}
+
+// dimensionValues returns the values of the dimension attributes of the span.
+func (sgp *serviceGraphProcessor) dimensionValues(span *tracepb.Span) []*metricspb.LabelValue {
+	values := make([]*metricspb.LabelValue, len(sgp.dimensions))
+	for i, key := range sgp.dimensions {
+		value, ok := attributeValue(span, key)
+		values[i] = &metricspb.LabelValue{Value: value, HasValue: ok}
+	}
+	return values
+}
+
+// mergeDimensions returns the dimension values of the client span, completed
+// with the ones of the server span.
+func mergeDimensions(client, server []*metricspb.LabelValue) []*metricspb.LabelValue {
+	merged := make([]*metricspb.LabelValue, len(client))
+	for i, value := range client {
+		merged[i] = value
+		if !value.HasValue && i < len(server) {
+			merged[i] = server[i]
+		}
+	}
+	return merged
+}
+
+func attributeValue(span *tracepb.Span, key string) (string, bool) {
+	attribute := span.GetAttributes().GetAttributeMap()[key]
+	if attribute == nil {
+		return "", false
+	}
+	switch value := attribute.Value.(type) {
+	case *tracepb.AttributeValue_StringValue:
+		return value.StringValue.GetValue(), true
+	case *tracepb.AttributeValue_BoolValue:
+		return strconv.FormatBool(value.BoolValue), true
+	case *tracepb.AttributeValue_DoubleValue:
+		return strconv.FormatFloat(value.DoubleValue, 'f', -1, 64), true
+	case *tracepb.AttributeValue_IntValue:
+		return strconv.FormatInt(value.IntValue, 10), true
+	}
+	return "", false
+}
+
+func stringAttribute(span *tracepb.Span, key string) string {
+	return span.GetAttributes().GetAttributeMap()[key].GetStringValue().GetValue()
+}
+
+func spanKey(traceID, spanID []byte) string {
+	return string(traceID) + string(spanID)
+}
+
+// spanLatency returns the duration of the span in seconds, 0 if unknown.
+func spanLatency(span *tracepb.Span) float64 {
+	start, err := ptypes.Timestamp(span.StartTime)
+	if err != nil {
+		return 0
+	}
+	end, err := ptypes.Timestamp(span.EndTime)
+	if err != nil || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Seconds()
+}
+
+func spanFailed(span *tracepb.Span) bool {
+	return span.GetStatus().GetCode() != 0
+}
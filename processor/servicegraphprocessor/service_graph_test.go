@@ -0,0 +1,189 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servicegraphprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+)
+
+func TestTwoServiceTrace(t *testing.T) {
+	traceSink := new(exportertest.SinkTraceExporter)
+	metricsSink := new(exportertest.SinkMetricsExporter)
+	cfg := testConfig()
+	cfg.Dimensions = []string{"http.method"}
+	tp, err := NewTraceProcessor(zap.NewNop(), traceSink, metricsSink, cfg)
+	require.NoError(t, err)
+
+	client := span(1, 0, tracepb.Span_CLIENT, 300*time.Millisecond)
+	client.Attributes = attributes("http.method", "GET")
+	server := span(2, 1, tracepb.Span_SERVER, 200*time.Millisecond)
+	server.Status = &tracepb.Status{Code: 13}
+
+	frontend := consumerdata.TraceData{Node: node("frontend"), Spans: []*tracepb.Span{client}}
+	backend := consumerdata.TraceData{Node: node("backend"), Spans: []*tracepb.Span{server}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), frontend))
+	assert.Empty(t, metricsSink.AllMetrics(), "no edge before the server span")
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), backend))
+
+	// The spans are passed through.
+	assert.Equal(t, []consumerdata.TraceData{frontend, backend}, traceSink.AllTraces())
+
+	got := metricsSink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 3)
+	wantLabels := []*metricspb.LabelValue{
+		{Value: "frontend", HasValue: true},
+		{Value: "backend", HasValue: true},
+		{Value: "GET", HasValue: true},
+	}
+	for _, metric := range got[0].Metrics {
+		assert.Equal(t, []*metricspb.LabelKey{{Key: "client"}, {Key: "server"}, {Key: "http.method"}},
+			metric.MetricDescriptor.LabelKeys)
+		require.Len(t, metric.Timeseries, 1)
+		assert.Equal(t, wantLabels, metric.Timeseries[0].LabelValues)
+	}
+
+	assert.Equal(t, requestsMetricName, got[0].Metrics[0].MetricDescriptor.Name)
+	assert.Equal(t, int64(1), got[0].Metrics[0].Timeseries[0].Points[0].GetInt64Value())
+	assert.Equal(t, failedRequestsMetricName, got[0].Metrics[1].MetricDescriptor.Name)
+	assert.Equal(t, int64(1), got[0].Metrics[1].Timeseries[0].Points[0].GetInt64Value())
+
+	assert.Equal(t, latencyMetricName, got[0].Metrics[2].MetricDescriptor.Name)
+	dist := got[0].Metrics[2].Timeseries[0].Points[0].GetDistributionValue()
+	assert.Equal(t, int64(1), dist.Count)
+	assert.InDelta(t, 0.3, dist.Sum, 1e-9, "the latency is the one of the client span")
+	assert.Equal(t, []float64{0.1, 1}, dist.BucketOptions.GetExplicit().Bounds)
+	assert.Equal(t, []*metricspb.DistributionValue_Bucket{{Count: 0}, {Count: 1}, {Count: 0}}, dist.Buckets)
+}
+
+func TestPeerServiceAttribute(t *testing.T) {
+	metricsSink := new(exportertest.SinkMetricsExporter)
+	tp, err := NewTraceProcessor(zap.NewNop(), exportertest.NewNopTraceExporter(), metricsSink, testConfig())
+	require.NoError(t, err)
+
+	client := span(1, 0, tracepb.Span_CLIENT, 50*time.Millisecond)
+	client.Attributes = attributes(peerServiceAttribute, "database")
+	require.NoError(t, tp.ConsumeTraceData(context.Background(),
+		consumerdata.TraceData{Node: node("backend"), Spans: []*tracepb.Span{client}}))
+
+	got := metricsSink.AllMetrics()
+	require.Len(t, got, 1)
+	requests := got[0].Metrics[0]
+	require.Len(t, requests.Timeseries, 1)
+	assert.Equal(t, []*metricspb.LabelValue{{Value: "backend", HasValue: true}, {Value: "database", HasValue: true}},
+		requests.Timeseries[0].LabelValues)
+
+	// The server span of an already recorded call does not record it again.
+	server := span(2, 1, tracepb.Span_SERVER, 40*time.Millisecond)
+	require.NoError(t, tp.ConsumeTraceData(context.Background(),
+		consumerdata.TraceData{Node: node("database"), Spans: []*tracepb.Span{server}}))
+	assert.Len(t, metricsSink.AllMetrics(), 1)
+}
+
+func TestUnpairedSpans(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	metricsSink := new(exportertest.SinkMetricsExporter)
+	cfg := testConfig()
+	cfg.MaxPendingSpans = 1
+	tp, err := NewTraceProcessor(zap.NewNop(), exportertest.NewNopTraceExporter(), metricsSink, cfg)
+	require.NoError(t, err)
+	now := time.Now()
+	tp.(*serviceGraphProcessor).now = func() time.Time { return now }
+
+	// The second span does not fit in the pending spans.
+	spans := []*tracepb.Span{
+		span(1, 0, tracepb.Span_CLIENT, time.Millisecond),
+		span(3, 0, tracepb.Span_CLIENT, time.Millisecond),
+	}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(),
+		consumerdata.TraceData{Node: node("frontend"), Spans: spans}))
+
+	// The first span expires.
+	now = now.Add(cfg.Wait)
+	require.NoError(t, tp.ConsumeTraceData(context.Background(),
+		consumerdata.TraceData{Node: node("backend"), Spans: []*tracepb.Span{span(2, 1, tracepb.Span_SERVER, 0)}}))
+	assert.Empty(t, metricsSink.AllMetrics())
+
+	rows, err := view.RetrieveData(statUnpairedSpans.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestNilMetricsConsumer(t *testing.T) {
+	tp, err := NewTraceProcessor(zap.NewNop(), exportertest.NewNopTraceExporter(), nil, testConfig())
+	assert.Nil(t, tp)
+	assert.Error(t, err)
+}
+
+func testConfig() Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "service_graph/test"},
+		LatencyBuckets:    []float64{0.1, 1},
+		Wait:              time.Second,
+		MaxPendingSpans:   100,
+	}
+}
+
+func node(service string) *commonpb.Node {
+	return &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: service}}
+}
+
+func attributes(key, value string) *tracepb.Span_Attributes {
+	return &tracepb.Span_Attributes{
+		AttributeMap: map[string]*tracepb.AttributeValue{
+			key: {Value: &tracepb.AttributeValue_StringValue{StringValue: &tracepb.TruncatableString{Value: value}}},
+		},
+	}
+}
+
+func spanID(id byte) []byte {
+	if id == 0 {
+		return nil
+	}
+	return []byte{0, 0, 0, 0, 0, 0, 0, id}
+}
+
+func span(id, parent byte, kind tracepb.Span_SpanKind, duration time.Duration) *tracepb.Span {
+	start := time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+	return &tracepb.Span{
+		TraceId:      []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanId:       spanID(id),
+		ParentSpanId: spanID(parent),
+		Kind:         kind,
+		StartTime:    internal.TimeToTimestamp(start),
+		EndTime:      internal.TimeToTimestamp(start.Add(duration)),
+	}
+}
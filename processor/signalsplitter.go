@@ -0,0 +1,72 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+)
+
+// This file contains the connector splitting a combined stream of traces and
+// metrics onto the pipelines of each data type.
+
+// NewSignalSplitterConnector wraps a trace consumer and a metrics consumer, e.g. the
+// fan out connectors of the trace and metrics pipelines of a receiver, in a single
+// consumer of both signals. A nil consumer means that no pipeline of that data type
+// is attached: the data of that type is dropped.
+func NewSignalSplitterConnector(tc consumer.TraceConsumer, mc consumer.MetricsConsumer) *SignalSplitterConnector {
+	return &SignalSplitterConnector{tc: tc, mc: mc}
+}
+
+// SignalSplitterConnector dispatches the traces and the metrics it consumes to
+// separate consumers.
+type SignalSplitterConnector struct {
+	tc consumer.TraceConsumer
+	mc consumer.MetricsConsumer
+}
+
+var _ Processor = (*SignalSplitterConnector)(nil)
+
+// ConsumeTraceData sends the span data to the trace consumer.
+func (ssc *SignalSplitterConnector) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	if ssc.tc == nil {
+		return nil
+	}
+	return ssc.tc.ConsumeTraceData(ctx, td)
+}
+
+// ConsumeMetricsData sends the MetricsData to the metrics consumer.
+func (ssc *SignalSplitterConnector) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	if ssc.mc == nil {
+		return nil
+	}
+	return ssc.mc.ConsumeMetricsData(ctx, md)
+}
+
+// ConsumeData dispatches an item of a combined stream according to its type, a
+// consumerdata.TraceData or a consumerdata.MetricsData. Any other type is an error.
+func (ssc *SignalSplitterConnector) ConsumeData(ctx context.Context, data interface{}) error {
+	switch d := data.(type) {
+	case consumerdata.TraceData:
+		return ssc.ConsumeTraceData(ctx, d)
+	case consumerdata.MetricsData:
+		return ssc.ConsumeMetricsData(ctx, d)
+	default:
+		return fmt.Errorf("cannot split data of type %T, neither traces nor metrics", data)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+)
+
+func TestSignalSplitterRoutesMixedSignals(t *testing.T) {
+	tc := &mockTraceConsumer{}
+	mc := &mockMetricsConsumer{}
+	ssc := NewSignalSplitterConnector(tc, mc)
+
+	stream := []interface{}{
+		consumerdata.TraceData{Spans: make([]*tracepb.Span, 3)},
+		consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 2)},
+		consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 4)},
+		consumerdata.TraceData{Spans: make([]*tracepb.Span, 1)},
+	}
+	for _, data := range stream {
+		require.NoError(t, ssc.ConsumeData(context.Background(), data))
+	}
+	assert.Equal(t, 4, tc.TotalSpans)
+	assert.Equal(t, 6, mc.TotalMetrics)
+
+	require.NoError(t, ssc.ConsumeTraceData(context.Background(), consumerdata.TraceData{Spans: make([]*tracepb.Span, 2)}))
+	require.NoError(t, ssc.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 1)}))
+	assert.Equal(t, 6, tc.TotalSpans)
+	assert.Equal(t, 7, mc.TotalMetrics)
+
+	assert.Error(t, ssc.ConsumeData(context.Background(), "not a signal"))
+	assert.Equal(t, 6, tc.TotalSpans)
+	assert.Equal(t, 7, mc.TotalMetrics)
+}
+
+func TestSignalSplitterReturnsConsumerErrors(t *testing.T) {
+	tc := &mockTraceConsumer{MustFail: true}
+	mc := &mockMetricsConsumer{}
+	ssc := NewSignalSplitterConnector(tc, mc)
+
+	assert.Error(t, ssc.ConsumeData(context.Background(), consumerdata.TraceData{Spans: make([]*tracepb.Span, 1)}))
+	assert.NoError(t, ssc.ConsumeData(context.Background(), consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 1)}))
+	assert.Equal(t, 1, tc.TotalSpans)
+	assert.Equal(t, 1, mc.TotalMetrics)
+}
+
+func TestSignalSplitterWithoutPipeline(t *testing.T) {
+	mc := &mockMetricsConsumer{}
+	ssc := NewSignalSplitterConnector(nil, mc)
+
+	assert.NoError(t, ssc.ConsumeData(context.Background(), consumerdata.TraceData{Spans: make([]*tracepb.Span, 1)}))
+	assert.NoError(t, ssc.ConsumeData(context.Background(), consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 3)}))
+	assert.Equal(t, 3, mc.TotalMetrics)
+
+	ssc = NewSignalSplitterConnector(nil, nil)
+	assert.NoError(t, ssc.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{}))
+}
@@ -0,0 +1,143 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sortlabelsprocessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type sortLabelsProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	// leading maps the leading label keys to their rank.
+	leading map[string]int
+}
+
+var _ processor.MetricsProcessor = (*sortLabelsProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that sorts the
+// label keys of every metric, and the label values of its series accordingly,
+// the leading labels of the config first and the other labels in
+// lexicographic order.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	leading := make(map[string]int, len(cfg.LeadingLabels))
+	for i, key := range cfg.LeadingLabels {
+		if _, ok := leading[key]; ok {
+			return nil, fmt.Errorf("leading label %q is listed more than once", key)
+		}
+		leading[key] = i
+	}
+	return &sortLabelsProcessor{nextConsumer: nextConsumer, leading: leading}, nil
+}
+
+func (sp *sortLabelsProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		sorted := sp.sortMetric(metric)
+		if sorted == metric && metrics == nil {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, 0, len(md.Metrics))
+			metrics = append(metrics, md.Metrics[:i]...)
+		}
+		metrics = append(metrics, sorted)
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return sp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// sortMetric returns the metric with its labels sorted. The metric is returned
+// as is if already sorted, otherwise a copy is returned.
+func (sp *sortLabelsProcessor) sortMetric(metric *metricspb.Metric) *metricspb.Metric {
+	desc := metric.GetMetricDescriptor()
+	if desc == nil || len(desc.LabelKeys) < 2 {
+		return metric
+	}
+
+	// order holds the indexes of the keys in sorted order.
+	order := make([]int, len(desc.LabelKeys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return sp.less(desc.LabelKeys[order[i]].GetKey(), desc.LabelKeys[order[j]].GetKey())
+	})
+	if isIdentity(order) {
+		return metric
+	}
+
+	sortedDesc := *desc
+	sortedDesc.LabelKeys = make([]*metricspb.LabelKey, len(order))
+	for i, j := range order {
+		sortedDesc.LabelKeys[i] = desc.LabelKeys[j]
+	}
+	sorted := &metricspb.Metric{
+		MetricDescriptor: &sortedDesc,
+		Resource:         metric.Resource,
+		Timeseries:       make([]*metricspb.TimeSeries, len(metric.Timeseries)),
+	}
+	for i, ts := range metric.Timeseries {
+		sortedTs := *ts
+		sortedTs.LabelValues = make([]*metricspb.LabelValue, len(order))
+		for k, j := range order {
+			if j < len(ts.LabelValues) {
+				sortedTs.LabelValues[k] = ts.LabelValues[j]
+			} else {
+				sortedTs.LabelValues[k] = &metricspb.LabelValue{}
+			}
+		}
+		sorted.Timeseries[i] = &sortedTs
+	}
+	return sorted
+}
+
+// less orders the leading keys first, by rank, then the other keys
+// lexicographically.
+func (sp *sortLabelsProcessor) less(a, b string) bool {
+	rankA, leadingA := sp.leading[a]
+	rankB, leadingB := sp.leading[b]
+	switch {
+	case leadingA && leadingB:
+		return rankA < rankB
+	case leadingA:
+		return true
+	case leadingB:
+		return false
+	}
+	return a < b
+}
+
+func isIdentity(order []int) bool {
+	for i, j := range order {
+		if i != j {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sortlabelsprocessor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestSortLabels(t *testing.T) {
+	orders := [][]string{
+		{"method", "code", "path"},
+		{"path", "method", "code"},
+		{"code", "path", "method"},
+	}
+	for _, keys := range orders {
+		sink := &exportertest.SinkMetricsExporter{}
+		mp, err := NewMetricsProcessor(sink, Config{})
+		require.NoError(t, err)
+
+		// Each label value is the upper case of its key.
+		input := metric(keys, upper(keys))
+		md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{input}}
+		require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+		got := sink.AllMetrics()
+		require.Len(t, got, 1)
+		require.Len(t, got[0].Metrics, 1)
+		sorted := got[0].Metrics[0]
+		assert.Equal(t, []string{"code", "method", "path"}, labelKeys(sorted), "input order %v", keys)
+		assert.Equal(t, []string{"CODE", "METHOD", "PATH"}, labelValues(sorted.Timeseries[0]), "input order %v", keys)
+
+		// The input is left untouched.
+		assert.Equal(t, keys, labelKeys(input))
+		assert.Equal(t, upper(keys), labelValues(input.Timeseries[0]))
+	}
+}
+
+func TestSortLabelsLeading(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(sink, Config{LeadingLabels: []string{"job", "instance", "absent"}})
+	require.NoError(t, err)
+
+	keys := []string{"zone", "instance", "code", "job"}
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric(keys, upper(keys))}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	sorted := sink.AllMetrics()[0].Metrics[0]
+	assert.Equal(t, []string{"job", "instance", "code", "zone"}, labelKeys(sorted))
+	assert.Equal(t, []string{"JOB", "INSTANCE", "CODE", "ZONE"}, labelValues(sorted.Timeseries[0]))
+}
+
+func TestSortLabelsAlreadySorted(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	keys := []string{"code", "method"}
+	input := metric(keys, upper(keys))
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{input}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()[0]
+	assert.True(t, got.Metrics[0] == input, "a sorted metric is passed as is")
+}
+
+func TestSortLabelsMissingValues(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	// The series only has a value for its first key.
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{metric([]string{"method", "code"}, []string{"GET"})}}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	sorted := sink.AllMetrics()[0].Metrics[0]
+	assert.Equal(t, []string{"code", "method"}, labelKeys(sorted))
+	require.Len(t, sorted.Timeseries[0].LabelValues, 2)
+	assert.False(t, sorted.Timeseries[0].LabelValues[0].HasValue)
+	assert.Equal(t, "GET", sorted.Timeseries[0].LabelValues[1].Value)
+}
+
+func metric(keys, values []string) *metricspb.Metric {
+	labelKeys := make([]*metricspb.LabelKey, len(keys))
+	for i, key := range keys {
+		labelKeys[i] = &metricspb.LabelKey{Key: key}
+	}
+	labelValues := make([]*metricspb.LabelValue, len(values))
+	for i, value := range values {
+		labelValues[i] = &metricspb.LabelValue{Value: value, HasValue: true}
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "requests",
+			Type:      metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			LabelKeys: labelKeys,
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			LabelValues: labelValues,
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}},
+		}},
+	}
+}
+
+func upper(keys []string) []string {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = strings.ToUpper(key)
+	}
+	return values
+}
+
+func labelKeys(metric *metricspb.Metric) []string {
+	keys := make([]string, len(metric.MetricDescriptor.LabelKeys))
+	for i, key := range metric.MetricDescriptor.LabelKeys {
+		keys[i] = key.Key
+	}
+	return keys
+}
+
+func labelValues(ts *metricspb.TimeSeries) []string {
+	values := make([]string, len(ts.LabelValues))
+	for i, value := range ts.LabelValues {
+		values[i] = value.Value
+	}
+	return values
+}
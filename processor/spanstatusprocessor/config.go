@@ -0,0 +1,64 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstatusprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Status is the status a rule sets.
+type Status string
+
+const (
+	// OKStatus sets the OK status code.
+	OKStatus Status = "ok"
+	// ErrorStatus sets the unknown error status code, the generic error.
+	ErrorStatus Status = "error"
+)
+
+// Config defines configuration for the span status processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Rules are applied in order, the status of a span is set by the first
+	// rule whose conditions it matches. The spans matching no rule are left
+	// as they are.
+	Rules []Rule `mapstructure:"rules"`
+	// Overwrite applies the rules to the spans already having a status too.
+	// By default only the spans without status are rewritten.
+	Overwrite bool `mapstructure:"overwrite"`
+}
+
+// Rule sets the status of the spans matching all its conditions.
+type Rule struct {
+	// Conditions on the span attributes, all must match.
+	Conditions []Condition `mapstructure:"conditions"`
+	// Status is either "ok" or "error".
+	Status Status `mapstructure:"status"`
+	// Message is the message of the status, optional.
+	Message string `mapstructure:"message"`
+}
+
+// Condition matches the spans having an attribute whose value matches either
+// the regular expression or the comparison, exactly one of them must be set.
+type Condition struct {
+	// Key is the key of the attribute.
+	Key string `mapstructure:"key"`
+	// Regexp must match the whole value of the attribute, the values which
+	// are not strings are matched in their decimal or "true"/"false" form.
+	Regexp string `mapstructure:"regexp"`
+	// Op is one of "==", "!=", "<", "<=", ">" or ">=", comparing the
+	// numerical value of the attribute to Value. Attributes holding numbers
+	// as strings are compared too, the other attributes never match.
+	Op    string  `mapstructure:"op"`
+	Value float64 `mapstructure:"value"`
+}
@@ -0,0 +1,90 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstatusprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+)
+
+func TestCreateDefaultConfig(t *testing.T) {
+	factory := &Factory{}
+
+	cfg := factory.CreateDefaultConfig()
+	assert.NotNil(t, cfg, "failed to create default config")
+}
+
+func TestCreateProcessor(t *testing.T) {
+	factory := &Factory{}
+
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Rules = []Rule{{Status: ErrorStatus, Conditions: []Condition{{Key: "error", Regexp: "true"}}}}
+
+	tp, err := factory.CreateTraceProcessor(zap.NewNop(), exportertest.NewNopTraceExporter(), cfg)
+	assert.NotNil(t, tp)
+	assert.NoError(t, err, "cannot create trace processor")
+
+	mp, err := factory.CreateMetricsProcessor(zap.NewNop(), exportertest.NewNopMetricsExporter(), cfg)
+	assert.Nil(t, mp)
+	assert.Error(t, err, "should not be able to create metrics processor")
+}
+
+func TestCreateProcessorInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{name: "no rules"},
+		{name: "no conditions", rules: []Rule{{Status: OKStatus}}},
+		{
+			name:  "unknown status",
+			rules: []Rule{{Status: "unset", Conditions: []Condition{{Key: "error", Regexp: "true"}}}},
+		},
+		{
+			name:  "empty key",
+			rules: []Rule{{Status: OKStatus, Conditions: []Condition{{Regexp: "true"}}}},
+		},
+		{
+			name:  "regexp and op",
+			rules: []Rule{{Status: OKStatus, Conditions: []Condition{{Key: "code", Regexp: "2..", Op: "<", Value: 300}}}},
+		},
+		{
+			name:  "neither regexp nor op",
+			rules: []Rule{{Status: OKStatus, Conditions: []Condition{{Key: "code"}}}},
+		},
+		{
+			name:  "invalid regexp",
+			rules: []Rule{{Status: OKStatus, Conditions: []Condition{{Key: "code", Regexp: "2(.."}}}},
+		},
+		{
+			name:  "unknown op",
+			rules: []Rule{{Status: OKStatus, Conditions: []Condition{{Key: "code", Op: "=~", Value: 200}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &Factory{}
+			cfg := factory.CreateDefaultConfig().(*Config)
+			cfg.Rules = tt.rules
+			tp, err := factory.CreateTraceProcessor(zap.NewNop(), exportertest.NewNopTraceExporter(), cfg)
+			assert.Nil(t, tp)
+			assert.Error(t, err)
+		})
+	}
+}
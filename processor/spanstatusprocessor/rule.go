@@ -0,0 +1,166 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstatusprocessor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+
+	tracetranslator "github.com/open-telemetry/opentelemetry-service/translator/trace"
+)
+
+// rule is the compiled form of Rule.
+type rule struct {
+	conditions []condition
+	status     *tracepb.Status
+}
+
+// condition is the compiled form of Condition.
+type condition struct {
+	key     string
+	regexp  *regexp.Regexp
+	compare func(float64) bool
+}
+
+func compileRules(cfgRules []Rule) ([]rule, error) {
+	if len(cfgRules) == 0 {
+		return nil, errors.New("rules must list at least one rule")
+	}
+	rules := make([]rule, 0, len(cfgRules))
+	for i, cfgRule := range cfgRules {
+		r, err := compileRule(cfgRule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func compileRule(cfgRule Rule) (rule, error) {
+	r := rule{status: &tracepb.Status{Message: cfgRule.Message}}
+	switch cfgRule.Status {
+	case OKStatus:
+		r.status.Code = tracetranslator.OCOK
+	case ErrorStatus:
+		r.status.Code = tracetranslator.OCUnknown
+	default:
+		return rule{}, fmt.Errorf("unknown status %q, must be either %q or %q", cfgRule.Status, OKStatus, ErrorStatus)
+	}
+	if len(cfgRule.Conditions) == 0 {
+		return rule{}, errors.New("conditions must list at least one condition")
+	}
+	for _, cfgCond := range cfgRule.Conditions {
+		cond, err := compileCondition(cfgCond)
+		if err != nil {
+			return rule{}, err
+		}
+		r.conditions = append(r.conditions, cond)
+	}
+	return r, nil
+}
+
+func compileCondition(cfgCond Condition) (condition, error) {
+	if cfgCond.Key == "" {
+		return condition{}, errors.New("empty condition key")
+	}
+	if (cfgCond.Regexp == "") == (cfgCond.Op == "") {
+		return condition{}, fmt.Errorf("condition on %q must set exactly one of regexp or op", cfgCond.Key)
+	}
+
+	cond := condition{key: cfgCond.Key}
+	if cfgCond.Regexp != "" {
+		re, err := regexp.Compile("^(?:" + cfgCond.Regexp + ")$")
+		if err != nil {
+			return condition{}, fmt.Errorf("invalid regexp of the condition on %q: %v", cfgCond.Key, err)
+		}
+		cond.regexp = re
+		return cond, nil
+	}
+
+	v := cfgCond.Value
+	switch cfgCond.Op {
+	case "==":
+		cond.compare = func(x float64) bool { return x == v }
+	case "!=":
+		cond.compare = func(x float64) bool { return x != v }
+	case "<":
+		cond.compare = func(x float64) bool { return x < v }
+	case "<=":
+		cond.compare = func(x float64) bool { return x <= v }
+	case ">":
+		cond.compare = func(x float64) bool { return x > v }
+	case ">=":
+		cond.compare = func(x float64) bool { return x >= v }
+	default:
+		return condition{}, fmt.Errorf("unknown op %q of the condition on %q", cfgCond.Op, cfgCond.Key)
+	}
+	return cond, nil
+}
+
+// matches returns whether the span matches all the conditions of the rule.
+func (r *rule) matches(span *tracepb.Span) bool {
+	for i := range r.conditions {
+		if !r.conditions[i].matches(span.GetAttributes().GetAttributeMap()[r.conditions[i].key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches returns whether the attribute value matches the condition, a nil
+// value, i.e. a missing attribute, never matches.
+func (c *condition) matches(value *tracepb.AttributeValue) bool {
+	if value == nil {
+		return false
+	}
+	if c.regexp != nil {
+		s, ok := stringValue(value)
+		return ok && c.regexp.MatchString(s)
+	}
+	x, ok := numberValue(value)
+	return ok && c.compare(x)
+}
+
+func stringValue(value *tracepb.AttributeValue) (string, bool) {
+	switch v := value.Value.(type) {
+	case *tracepb.AttributeValue_StringValue:
+		return v.StringValue.GetValue(), true
+	case *tracepb.AttributeValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10), true
+	case *tracepb.AttributeValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'f', -1, 64), true
+	case *tracepb.AttributeValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue), true
+	}
+	return "", false
+}
+
+func numberValue(value *tracepb.AttributeValue) (float64, bool) {
+	switch v := value.Value.(type) {
+	case *tracepb.AttributeValue_StringValue:
+		x, err := strconv.ParseFloat(v.StringValue.GetValue(), 64)
+		return x, err == nil
+	case *tracepb.AttributeValue_IntValue:
+		return float64(v.IntValue), true
+	case *tracepb.AttributeValue_DoubleValue:
+		return v.DoubleValue, true
+	}
+	return 0, false
+}
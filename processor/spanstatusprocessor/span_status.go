@@ -0,0 +1,110 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstatusprocessor
+
+import (
+	"context"
+
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+	tracetranslator "github.com/open-telemetry/opentelemetry-service/translator/trace"
+)
+
+type spanStatusProcessor struct {
+	nextConsumer consumer.TraceConsumer
+	rules        []rule
+	overwrite    bool
+	name         string
+}
+
+var _ processor.TraceProcessor = (*spanStatusProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that sets the status of
+// the spans according to the first rule whose conditions they match.
+func NewTraceProcessor(nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return &spanStatusProcessor{
+		nextConsumer: nextConsumer,
+		rules:        rules,
+		overwrite:    cfg.Overwrite,
+		name:         cfg.Name(),
+	}, nil
+}
+
+func (ssp *spanStatusProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	var spans []*tracepb.Span
+	numOK, numError := 0, 0
+	for i, span := range td.Spans {
+		if span == nil || (span.Status != nil && !ssp.overwrite) {
+			continue
+		}
+		status := ssp.status(span)
+		if status == nil {
+			continue
+		}
+
+		if spans == nil {
+			spans = make([]*tracepb.Span, len(td.Spans))
+			copy(spans, td.Spans)
+		}
+		spanCopy, statusCopy := *span, *status
+		spanCopy.Status = &statusCopy
+		spans[i] = &spanCopy
+		if status.Code == tracetranslator.OCOK {
+			numOK++
+		} else {
+			numError++
+		}
+	}
+
+	if spans != nil {
+		td.Spans = spans
+		ssp.recordRewritten(OKStatus, numOK)
+		ssp.recordRewritten(ErrorStatus, numError)
+	}
+	return ssp.nextConsumer.ConsumeTraceData(ctx, td)
+}
+
+// status returns the status of the first rule the span matches, nil if none.
+func (ssp *spanStatusProcessor) status(span *tracepb.Span) *tracepb.Status {
+	for i := range ssp.rules {
+		if ssp.rules[i].matches(span) {
+			return ssp.rules[i].status
+		}
+	}
+	return nil
+}
+
+func (ssp *spanStatusProcessor) recordRewritten(status Status, numSpans int) {
+	if numSpans == 0 {
+		return
+	}
+	stats.RecordWithTags(context.Background(), []tag.Mutator{
+		tag.Upsert(processor.TagExporterNameKey, ssp.name),
+		tag.Upsert(tagStatusKey, string(status)),
+	}, statRewrittenSpans.M(int64(numSpans)))
+}
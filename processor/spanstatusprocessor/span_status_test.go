@@ -0,0 +1,160 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstatusprocessor
+
+import (
+	"context"
+	"testing"
+
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	tracetranslator "github.com/open-telemetry/opentelemetry-service/translator/trace"
+)
+
+// httpRules sets ERROR for the 5xx status codes and OK for the 2xx ones.
+var httpRules = []Rule{
+	{
+		Status:     ErrorStatus,
+		Message:    "server error",
+		Conditions: []Condition{{Key: "http.status_code", Op: ">=", Value: 500}},
+	},
+	{
+		Status:     OKStatus,
+		Conditions: []Condition{{Key: "http.status_code", Regexp: `2\d\d`}},
+	},
+}
+
+func TestHTTPStatusCodes(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkTraceExporter)
+	cfg := Config{ProcessorSettings: configmodels.ProcessorSettings{NameVal: "span_status/http"}, Rules: httpRules}
+	tp, err := NewTraceProcessor(sink, cfg)
+	require.NoError(t, err)
+
+	td := consumerdata.TraceData{
+		Spans: []*tracepb.Span{
+			intCodeSpan(503),
+			stringCodeSpan("500"),
+			intCodeSpan(200),
+			stringCodeSpan("204"),
+			intCodeSpan(404),
+			{Name: &tracepb.TruncatableString{Value: "no attributes"}},
+		},
+	}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	got := sink.AllTraces()
+	require.Len(t, got, 1)
+	serverError := &tracepb.Status{Code: tracetranslator.OCUnknown, Message: "server error"}
+	ok := &tracepb.Status{Code: tracetranslator.OCOK}
+	assert.Equal(t, []*tracepb.Status{serverError, serverError, ok, ok, nil, nil}, statuses(got[0]))
+	// The received spans may be shared with other pipelines, they are not modified.
+	assert.Equal(t, []*tracepb.Status{nil, nil, nil, nil, nil, nil}, statuses(td))
+	assert.Same(t, td.Spans[4], got[0].Spans[4])
+
+	rows, err := view.RetrieveData(statRewrittenSpans.Name())
+	require.NoError(t, err)
+	rewritten := make(map[string]float64)
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == tagStatusKey {
+				rewritten[tag.Value] = row.Data.(*view.SumData).Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{"error": 2, "ok": 2}, rewritten)
+}
+
+func TestExistingStatus(t *testing.T) {
+	failed := intCodeSpan(200)
+	failed.Status = &tracepb.Status{Code: tracetranslator.OCInternal}
+
+	sink := new(exportertest.SinkTraceExporter)
+	tp, err := NewTraceProcessor(sink, Config{Rules: httpRules})
+	require.NoError(t, err)
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{failed}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	assert.Equal(t, []*tracepb.Status{{Code: tracetranslator.OCInternal}}, statuses(sink.AllTraces()[0]))
+
+	sink = new(exportertest.SinkTraceExporter)
+	tp, err = NewTraceProcessor(sink, Config{Rules: httpRules, Overwrite: true})
+	require.NoError(t, err)
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+	assert.Equal(t, []*tracepb.Status{{Code: tracetranslator.OCOK}}, statuses(sink.AllTraces()[0]))
+}
+
+func TestRulesOrder(t *testing.T) {
+	rules := []Rule{
+		{
+			Status: OKStatus,
+			Conditions: []Condition{
+				{Key: "http.status_code", Op: "==", Value: 503},
+				{Key: "retry", Regexp: "true"},
+			},
+		},
+		httpRules[0],
+	}
+	sink := new(exportertest.SinkTraceExporter)
+	tp, err := NewTraceProcessor(sink, Config{Rules: rules})
+	require.NoError(t, err)
+
+	retried := intCodeSpan(503)
+	retried.Attributes.AttributeMap["retry"] = &tracepb.AttributeValue{Value: &tracepb.AttributeValue_BoolValue{BoolValue: true}}
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{retried, intCodeSpan(503)}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	// The first matching rule sets the status, all its conditions must match.
+	assert.Equal(t, []*tracepb.Status{
+		{Code: tracetranslator.OCOK},
+		{Code: tracetranslator.OCUnknown, Message: "server error"},
+	}, statuses(sink.AllTraces()[0]))
+}
+
+func intCodeSpan(code int64) *tracepb.Span {
+	return attributeSpan(&tracepb.AttributeValue{Value: &tracepb.AttributeValue_IntValue{IntValue: code}})
+}
+
+func stringCodeSpan(code string) *tracepb.Span {
+	return attributeSpan(&tracepb.AttributeValue{
+		Value: &tracepb.AttributeValue_StringValue{StringValue: &tracepb.TruncatableString{Value: code}},
+	})
+}
+
+func attributeSpan(code *tracepb.AttributeValue) *tracepb.Span {
+	return &tracepb.Span{
+		Name: &tracepb.TruncatableString{Value: "GET /"},
+		Attributes: &tracepb.Span_Attributes{
+			AttributeMap: map[string]*tracepb.AttributeValue{"http.status_code": code},
+		},
+	}
+}
+
+func statuses(td consumerdata.TraceData) []*tracepb.Status {
+	var statuses []*tracepb.Status
+	for _, span := range td.Spans {
+		statuses = append(statuses, span.Status)
+	}
+	return statuses
+}
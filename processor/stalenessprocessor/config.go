@@ -0,0 +1,35 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stalenessprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+)
+
+// Config defines configuration for the staleness processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Threshold is the time since its last update beyond which a series is
+	// flagged stale.
+	Threshold time.Duration `mapstructure:"threshold"`
+	// ExpireAfter is the time since its last update after which a series is
+	// forgotten and no longer reported. It must be above the threshold.
+	ExpireAfter time.Duration `mapstructure:"expire_after"`
+	// MaxSeries is the maximum number of series tracked. The least recently
+	// updated series are forgotten first.
+	MaxSeries int `mapstructure:"max_series"`
+}
@@ -0,0 +1,92 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stalenessprocessor
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/lru"
+)
+
+// seriesEntry is the last update of a series.
+type seriesEntry struct {
+	key      string
+	node     string
+	name     string
+	labels   string
+	lastSeen time.Time
+}
+
+// seriesTracker is a bounded set of the series last updates, indexed by node.
+// It is not safe for concurrent use.
+type seriesTracker struct {
+	entries *lru.Cache
+	nodes   map[string]map[string]*seriesEntry
+}
+
+func newSeriesTracker(maxSeries int) *seriesTracker {
+	st := &seriesTracker{
+		entries: lru.New(maxSeries),
+		nodes:   make(map[string]map[string]*seriesEntry),
+	}
+	st.entries.OnEvicted = st.onEvicted
+	return st
+}
+
+// update records an update of the given series at the given time, evicting
+// the least recently updated series as needed to stay within bounds, and
+// returns whether a series was evicted.
+func (st *seriesTracker) update(key, node, name, labels string, now time.Time) bool {
+	if value, ok := st.entries.Get(key); ok {
+		value.(*seriesEntry).lastSeen = now
+		st.entries.Add(key, value)
+		return false
+	}
+	entry := &seriesEntry{key: key, node: node, name: name, labels: labels, lastSeen: now}
+	evicted := st.entries.Add(key, entry)
+	series, ok := st.nodes[node]
+	if !ok {
+		series = make(map[string]*seriesEntry)
+		st.nodes[node] = series
+	}
+	series[key] = entry
+	return evicted
+}
+
+// expire forgets the series not updated since the given time.
+func (st *seriesTracker) expire(before time.Time) {
+	for _, value, ok := st.entries.Oldest(); ok && value.(*seriesEntry).lastSeen.Before(before); _, value, ok = st.entries.Oldest() {
+		st.entries.RemoveOldest()
+	}
+}
+
+func (st *seriesTracker) onEvicted(_ lru.Key, value interface{}) {
+	entry := value.(*seriesEntry)
+	series := st.nodes[entry.node]
+	delete(series, entry.key)
+	if len(series) == 0 {
+		delete(st.nodes, entry.node)
+	}
+}
+
+// nodeSeries returns the series of the given node, keyed by series.
+func (st *seriesTracker) nodeSeries(node string) map[string]*seriesEntry {
+	return st.nodes[node]
+}
+
+// len returns the number of series tracked.
+func (st *seriesTracker) len() int {
+	return st.entries.Len()
+}
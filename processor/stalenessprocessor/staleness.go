@@ -0,0 +1,242 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stalenessprocessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const (
+	// stalenessMetricName is the name of the gauge reporting the time since
+	// the last update of the series.
+	stalenessMetricName = "series_staleness_seconds"
+
+	metricLabel = "metric"
+	labelsLabel = "labels"
+	staleLabel  = "stale"
+)
+
+type stalenessProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	threshold    time.Duration
+	expireAfter  time.Duration
+	statsTags    []tag.Mutator
+	now          func() time.Time
+
+	mu     sync.Mutex
+	series *seriesTracker
+}
+
+var _ processor.MetricsProcessor = (*stalenessProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that tracks the
+// time of the last update of each series and, with each batch of a node,
+// reports the series of the node missing from the batch in a
+// "series_staleness_seconds" gauge. The gauge has the name of the metric, the
+// labels of the series and whether the time since its last update exceeds
+// the threshold as labels. The series not updated for longer than expire_after
+// are forgotten.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if cfg.Threshold <= 0 {
+		return nil, fmt.Errorf("threshold must be positive, got %v", cfg.Threshold)
+	}
+	if cfg.ExpireAfter <= cfg.Threshold {
+		return nil, fmt.Errorf("expire_after must be above the threshold, got %v", cfg.ExpireAfter)
+	}
+
+	maxSeries := cfg.MaxSeries
+	if maxSeries == 0 {
+		maxSeries = defaultMaxSeries
+	}
+	if maxSeries < 0 {
+		return nil, fmt.Errorf("max_series must be positive, got %d", cfg.MaxSeries)
+	}
+
+	return &stalenessProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		threshold:    cfg.Threshold,
+		expireAfter:  cfg.ExpireAfter,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+		now:          time.Now,
+		series:       newSeriesTracker(maxSeries),
+	}, nil
+}
+
+func (sp *stalenessProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	if len(md.Metrics) == 0 {
+		return sp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	now := sp.now()
+	node := nodeKey(md.Node)
+	evicted := 0
+	sp.mu.Lock()
+	sp.series.expire(now.Add(-sp.expireAfter))
+	for _, metric := range md.Metrics {
+		desc := metric.GetMetricDescriptor()
+		if desc.GetName() == "" || desc.GetName() == stalenessMetricName {
+			continue
+		}
+		for _, ts := range metric.Timeseries {
+			if ts == nil {
+				continue
+			}
+			key := seriesKey(node, desc.Name, ts)
+			if sp.series.update(key, node, desc.Name, formatLabels(desc.LabelKeys, ts.LabelValues), now) {
+				evicted++
+			}
+		}
+	}
+	staleness := sp.stalenessMetric(node, now)
+	sp.mu.Unlock()
+
+	if evicted > 0 {
+		stats.RecordWithTags(context.Background(), sp.statsTags, statEvictedSeries.M(int64(evicted)))
+	}
+	if staleness == nil {
+		return sp.nextConsumer.ConsumeMetricsData(ctx, md)
+	}
+
+	metrics := make([]*metricspb.Metric, len(md.Metrics), len(md.Metrics)+1)
+	copy(metrics, md.Metrics)
+	md.Metrics = append(metrics, staleness)
+	return sp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// stalenessMetric returns the gauge of the time since the last update of the
+// series of the node not updated at the given time, nil if there is none.
+func (sp *stalenessProcessor) stalenessMetric(node string, now time.Time) *metricspb.Metric {
+	var missing []*seriesEntry
+	for _, entry := range sp.series.nodeSeries(node) {
+		if entry.lastSeen.Before(now) {
+			missing = append(missing, entry)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].name != missing[j].name {
+			return missing[i].name < missing[j].name
+		}
+		return missing[i].labels < missing[j].labels
+	})
+
+	timestamp, _ := ptypes.TimestampProto(now)
+	timeseries := make([]*metricspb.TimeSeries, 0, len(missing))
+	stale := 0
+	for _, entry := range missing {
+		age := now.Sub(entry.lastSeen)
+		isStale := age > sp.threshold
+		if isStale {
+			stale++
+		}
+		timeseries = append(timeseries, &metricspb.TimeSeries{
+			LabelValues: []*metricspb.LabelValue{
+				{Value: entry.name, HasValue: true},
+				{Value: entry.labels, HasValue: true},
+				{Value: strconv.FormatBool(isStale), HasValue: true},
+			},
+			Points: []*metricspb.Point{{
+				Timestamp: timestamp,
+				Value:     &metricspb.Point_DoubleValue{DoubleValue: age.Seconds()},
+			}},
+		})
+	}
+	if stale > 0 {
+		sp.logger.Debug("Stale series",
+			zap.String("processor", sp.name),
+			zap.Int("series", stale))
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        stalenessMetricName,
+			Description: "Time since the last update of the series",
+			Unit:        "s",
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys: []*metricspb.LabelKey{
+				{Key: metricLabel},
+				{Key: labelsLabel},
+				{Key: staleLabel},
+			},
+		},
+		Timeseries: timeseries,
+	}
+}
+
+// nodeKey identifies the node that reported the series.
+func nodeKey(node *commonpb.Node) string {
+	return processor.ServiceNameForNode(node) + "\x00" + node.GetIdentifier().GetHostName()
+}
+
+// seriesKey identifies a series by the node that reported it, the metric name
+// and the label values.
+func seriesKey(node, name string, ts *metricspb.TimeSeries) string {
+	var b strings.Builder
+	b.WriteString(node)
+	b.WriteByte(0)
+	b.WriteString(name)
+	for _, v := range ts.LabelValues {
+		b.WriteByte(0)
+		if v.GetHasValue() {
+			b.WriteString(v.Value)
+		}
+	}
+	return b.String()
+}
+
+// formatLabels renders the labels with a value of a series as
+// key="value" pairs separated by commas.
+func formatLabels(keys []*metricspb.LabelKey, values []*metricspb.LabelValue) string {
+	var b strings.Builder
+	for i, v := range values {
+		if i >= len(keys) || !v.GetHasValue() {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(keys[i].GetKey())
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(v.Value))
+	}
+	return b.String()
+}
@@ -0,0 +1,200 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stalenessprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func newConfig() Config {
+	return Config{
+		ProcessorSettings: configmodels.ProcessorSettings{
+			TypeVal: typeStr,
+			NameVal: typeStr,
+		},
+		Threshold:   time.Minute,
+		ExpireAfter: 10 * time.Minute,
+		MaxSeries:   defaultMaxSeries,
+	}
+}
+
+var node = &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "svc"}}
+
+// gauge returns a batch with a point for each of the given queues of a gauge.
+func gauge(queues ...string) consumerdata.MetricsData {
+	metric := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      "queue_length",
+			Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+			LabelKeys: []*metricspb.LabelKey{{Key: "queue"}},
+		},
+	}
+	for _, queue := range queues {
+		metric.Timeseries = append(metric.Timeseries, &metricspb.TimeSeries{
+			LabelValues: []*metricspb.LabelValue{{Value: queue, HasValue: true}},
+			Points:      []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}},
+		})
+	}
+	return consumerdata.MetricsData{Node: node, Metrics: []*metricspb.Metric{metric}}
+}
+
+// clock is a fake clock advanced by the tests.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newProcessor(t *testing.T, cfg Config, sink *exportertest.SinkMetricsExporter) (*stalenessProcessor, *clock) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+	c := &clock{t: time.Unix(1000, 0)}
+	sp := mp.(*stalenessProcessor)
+	sp.now = c.now
+	return sp, c
+}
+
+// staleness returns the staleness metric of the last batch, nil if none.
+func staleness(t *testing.T, sink *exportertest.SinkMetricsExporter) *metricspb.Metric {
+	all := sink.AllMetrics()
+	require.NotEmpty(t, all)
+	for _, metric := range all[len(all)-1].Metrics {
+		if metric.MetricDescriptor.Name == stalenessMetricName {
+			return metric
+		}
+	}
+	return nil
+}
+
+func TestNewMetricsProcessor(t *testing.T) {
+	next := exportertest.NewNopMetricsExporter()
+
+	_, err := NewMetricsProcessor(zap.NewNop(), nil, newConfig())
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	tests := []struct {
+		name  string
+		tweak func(cfg *Config)
+	}{
+		{name: "zero threshold", tweak: func(cfg *Config) { cfg.Threshold = 0 }},
+		{name: "expire before threshold", tweak: func(cfg *Config) { cfg.ExpireAfter = 30 * time.Second }},
+		{name: "negative max series", tweak: func(cfg *Config) { cfg.MaxSeries = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig()
+			tt.tweak(&cfg)
+			mp, err := NewMetricsProcessor(zap.NewNop(), next, cfg)
+			assert.Nil(t, mp)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStalenessRisesWhenSeriesStopsUpdating(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	sp, c := newProcessor(t, newConfig(), sink)
+
+	require.NoError(t, sp.ConsumeMetricsData(context.Background(), gauge("a", "b")))
+	assert.Nil(t, staleness(t, sink), "no series is missing yet")
+
+	var last float64
+	for i, stale := range []string{"false", "false", "true", "true"} {
+		c.t = c.t.Add(30 * time.Second)
+		require.NoError(t, sp.ConsumeMetricsData(context.Background(), gauge("a")))
+
+		metric := staleness(t, sink)
+		require.NotNil(t, metric)
+		require.Len(t, metric.Timeseries, 1, "only the missing series is reported")
+		ts := metric.Timeseries[0]
+		assert.Equal(t, "queue_length", ts.LabelValues[0].Value)
+		assert.Equal(t, `queue="b"`, ts.LabelValues[1].Value)
+		assert.Equal(t, stale, ts.LabelValues[2].Value, "batch %d", i)
+		value := ts.Points[0].GetDoubleValue()
+		assert.Equal(t, float64(30*(i+1)), value)
+		assert.True(t, value > last, "staleness must rise")
+		last = value
+	}
+
+	c.t = c.t.Add(30 * time.Second)
+	require.NoError(t, sp.ConsumeMetricsData(context.Background(), gauge("a", "b")))
+	assert.Nil(t, staleness(t, sink), "the series updated again is no longer reported")
+}
+
+func TestStalenessExpire(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	sp, c := newProcessor(t, newConfig(), sink)
+
+	require.NoError(t, sp.ConsumeMetricsData(context.Background(), gauge("a", "b")))
+	c.t = c.t.Add(11 * time.Minute)
+	require.NoError(t, sp.ConsumeMetricsData(context.Background(), gauge("a")))
+	assert.Nil(t, staleness(t, sink), "the expired series must be forgotten")
+	assert.Equal(t, 1, sp.series.len())
+}
+
+func TestStalenessPerNode(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	sp, c := newProcessor(t, newConfig(), sink)
+
+	require.NoError(t, sp.ConsumeMetricsData(context.Background(), gauge("a")))
+	c.t = c.t.Add(time.Minute)
+	other := gauge("b")
+	other.Node = &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "other"}}
+	require.NoError(t, sp.ConsumeMetricsData(context.Background(), other))
+	assert.Nil(t, staleness(t, sink), "the series of other nodes must not be reported")
+}
+
+func TestStalenessDoesNotModifyInput(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	sp, c := newProcessor(t, newConfig(), sink)
+
+	require.NoError(t, sp.ConsumeMetricsData(context.Background(), gauge("a", "b")))
+	c.t = c.t.Add(time.Minute)
+	md := gauge("a")
+	require.NoError(t, sp.ConsumeMetricsData(context.Background(), md))
+	assert.Len(t, md.Metrics, 1)
+	assert.Len(t, sink.AllMetrics()[1].Metrics, 2)
+}
+
+func TestSeriesTrackerEviction(t *testing.T) {
+	st := newSeriesTracker(2)
+	now := time.Unix(0, 0)
+	assert.False(t, st.update("a", "n", "m", "", now))
+	assert.False(t, st.update("b", "n", "m", "", now))
+	assert.False(t, st.update("a", "n", "m", "", now))
+	assert.True(t, st.update("c", "n", "m", "", now))
+	assert.Equal(t, 2, st.len())
+	_, ok := st.nodeSeries("n")["b"]
+	assert.False(t, ok, "the least recently updated series must be evicted")
+
+	st.expire(now.Add(time.Second))
+	assert.Equal(t, 0, st.len())
+	assert.Nil(t, st.nodeSeries("n"))
+}
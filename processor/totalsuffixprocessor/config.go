@@ -0,0 +1,36 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package totalsuffixprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Mode is how the processor names the counters.
+type Mode string
+
+const (
+	// AddMode appends the "_total" suffix to the counters lacking it.
+	AddMode Mode = "add"
+	// StripMode removes the "_total" suffix from the counters having it.
+	StripMode Mode = "strip"
+)
+
+// Config defines configuration for the total suffix processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Mode is either "add", the default, or "strip". Every counter goes out
+	// under the same name whether it was received with the suffix or not, so
+	// that its series are not split across two names, e.g. for reset detection.
+	Mode Mode `mapstructure:"mode"`
+}
@@ -0,0 +1,137 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package totalsuffixprocessor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+const totalSuffix = "_total"
+
+type totalSuffixProcessor struct {
+	nextConsumer consumer.MetricsConsumer
+	mode         Mode
+	statsTags    []tag.Mutator
+}
+
+var _ processor.MetricsProcessor = (*totalSuffixProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that adds or strips
+// the "_total" suffix of the names of the counter metrics, depending on the mode
+// of the config. The other metrics are left untouched.
+func NewMetricsProcessor(nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	mode := cfg.Mode
+	switch mode {
+	case "":
+		mode = AddMode
+	case AddMode, StripMode:
+	default:
+		return nil, fmt.Errorf("unknown mode %q, must be either %q or %q", cfg.Mode, AddMode, StripMode)
+	}
+
+	return &totalSuffixProcessor{
+		nextConsumer: nextConsumer,
+		mode:         mode,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (tsp *totalSuffixProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	names := make(map[string]bool, len(md.Metrics))
+	for _, metric := range md.Metrics {
+		names[metric.GetMetricDescriptor().GetName()] = true
+	}
+
+	var metrics []*metricspb.Metric
+	renamed, collisions := 0, 0
+	for i, metric := range md.Metrics {
+		desc := metric.GetMetricDescriptor()
+		if !isCounter(desc) {
+			continue
+		}
+		name := tsp.rename(desc.Name)
+		if name == desc.Name {
+			continue
+		}
+		if names[name] {
+			// Another metric of the batch already has the name, renaming the
+			// counter would mix both.
+			collisions++
+			continue
+		}
+
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = withName(metric, name)
+		renamed++
+	}
+
+	if renamed > 0 {
+		stats.RecordWithTags(context.Background(), tsp.statsTags, statRenamedMetrics.M(int64(renamed)))
+		md.Metrics = metrics
+	}
+	if collisions > 0 {
+		stats.RecordWithTags(context.Background(), tsp.statsTags, statNameCollisions.M(int64(collisions)))
+	}
+	return tsp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// rename returns the name of the counter according to the mode.
+func (tsp *totalSuffixProcessor) rename(name string) string {
+	hasSuffix := strings.HasSuffix(name, totalSuffix)
+	switch {
+	case tsp.mode == AddMode && !hasSuffix:
+		return name + totalSuffix
+	case tsp.mode == StripMode && hasSuffix && len(name) > len(totalSuffix):
+		return strings.TrimSuffix(name, totalSuffix)
+	}
+	return name
+}
+
+func isCounter(desc *metricspb.MetricDescriptor) bool {
+	switch desc.GetType() {
+	case metricspb.MetricDescriptor_CUMULATIVE_INT64, metricspb.MetricDescriptor_CUMULATIVE_DOUBLE:
+		return desc.GetName() != ""
+	}
+	return false
+}
+
+// withName returns a copy of the metric with the given name.
+func withName(metric *metricspb.Metric, name string) *metricspb.Metric {
+	desc := *metric.MetricDescriptor
+	desc.Name = name
+	return &metricspb.Metric{
+		MetricDescriptor: &desc,
+		Resource:         metric.Resource,
+		Timeseries:       metric.Timeseries,
+	}
+}
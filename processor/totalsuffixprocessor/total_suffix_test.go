@@ -0,0 +1,142 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package totalsuffixprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+)
+
+func TestAddMode(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			testMetric("requests", metricspb.MetricDescriptor_CUMULATIVE_INT64),
+			testMetric("errors_total", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE),
+			testMetric("temperature", metricspb.MetricDescriptor_GAUGE_DOUBLE),
+			testMetric("latency", metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION),
+		},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, []string{"requests_total", "errors_total", "temperature", "latency"}, metricNames(got[0]))
+	// The received metric is shared with other pipelines, it is not modified.
+	assert.Equal(t, "requests", md.Metrics[0].MetricDescriptor.Name)
+	assert.Equal(t, md.Metrics[0].Timeseries, got[0].Metrics[0].Timeseries)
+}
+
+func TestStripMode(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{Mode: StripMode})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			testMetric("requests", metricspb.MetricDescriptor_CUMULATIVE_INT64),
+			testMetric("errors_total", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE),
+			testMetric("queue_total", metricspb.MetricDescriptor_GAUGE_INT64),
+			testMetric("_total", metricspb.MetricDescriptor_CUMULATIVE_INT64),
+		},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, []string{"requests", "errors", "queue_total", "_total"}, metricNames(got[0]))
+}
+
+func TestGaugeUntouched(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	mp, err := NewMetricsProcessor(sink, Config{})
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			testMetric("temperature", metricspb.MetricDescriptor_GAUGE_DOUBLE),
+		},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, md.Metrics, got[0].Metrics)
+}
+
+func TestNameCollisions(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := new(exportertest.SinkMetricsExporter)
+	cfg := Config{ProcessorSettings: configmodels.ProcessorSettings{NameVal: "total_suffix/collisions"}}
+	mp, err := NewMetricsProcessor(sink, cfg)
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			testMetric("requests", metricspb.MetricDescriptor_CUMULATIVE_INT64),
+			testMetric("requests_total", metricspb.MetricDescriptor_CUMULATIVE_INT64),
+			testMetric("errors", metricspb.MetricDescriptor_CUMULATIVE_INT64),
+		},
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, []string{"requests", "requests_total", "errors_total"}, metricNames(got[0]))
+
+	rows, err := view.RetrieveData(statNameCollisions.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+
+	rows, err = view.RetrieveData(statRenamedMetrics.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func testMetric(name string, metricType metricspb.MetricDescriptor_Type) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Type: metricType},
+		Timeseries: []*metricspb.TimeSeries{
+			{Points: []*metricspb.Point{{Value: &metricspb.Point_Int64Value{Int64Value: 1}}}},
+		},
+	}
+}
+
+func metricNames(md consumerdata.MetricsData) []string {
+	var names []string
+	for _, metric := range md.Metrics {
+		names = append(names, metric.MetricDescriptor.Name)
+	}
+	return names
+}
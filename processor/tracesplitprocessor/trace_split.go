@@ -0,0 +1,184 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracesplitprocessor
+
+import (
+	"context"
+	"fmt"
+
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type traceSplitProcessor struct {
+	name         string
+	nextConsumer consumer.TraceConsumer
+	logger       *zap.Logger
+	maxSpans     int
+	statsTags    []tag.Mutator
+}
+
+var _ processor.TraceProcessor = (*traceSplitProcessor)(nil)
+
+// NewTraceProcessor returns a processor.TraceProcessor that splits the traces
+// with more spans than the configured maximum into several batches.
+func NewTraceProcessor(logger *zap.Logger, nextConsumer consumer.TraceConsumer, cfg Config) (processor.TraceProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+	if cfg.MaxSpansPerTrace <= 0 {
+		return nil, fmt.Errorf("max_spans_per_trace must be positive, got %d", cfg.MaxSpansPerTrace)
+	}
+
+	return &traceSplitProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		maxSpans:     cfg.MaxSpansPerTrace,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+// ConsumeTraceData sends the spans of the traces within the limit in a single
+// batch, as received, and every oversized trace in batches of at most the
+// maximum number of spans.
+func (tsp *traceSplitProcessor) ConsumeTraceData(ctx context.Context, td consumerdata.TraceData) error {
+	if len(td.Spans) <= tsp.maxSpans {
+		return tsp.nextConsumer.ConsumeTraceData(ctx, td)
+	}
+
+	traces := groupByTrace(td.Spans)
+	oversized := 0
+	for _, spans := range traces {
+		if len(spans) > tsp.maxSpans {
+			oversized++
+		}
+	}
+	if oversized == 0 {
+		return tsp.nextConsumer.ConsumeTraceData(ctx, td)
+	}
+
+	tsp.logger.Debug("Splitting oversized traces",
+		zap.String("processor", tsp.name),
+		zap.Int("traces", oversized))
+	stats.RecordWithTags(context.Background(), tsp.statsTags, statSplitTraces.M(int64(oversized)))
+
+	var errs []error
+	send := func(spans []*tracepb.Span) {
+		batch := consumerdata.TraceData{
+			Node:         td.Node,
+			Resource:     td.Resource,
+			SourceFormat: td.SourceFormat,
+			Spans:        spans,
+		}
+		if err := tsp.nextConsumer.ConsumeTraceData(ctx, batch); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var small []*tracepb.Span
+	for _, spans := range traces {
+		if len(spans) <= tsp.maxSpans {
+			small = append(small, spans...)
+		}
+	}
+	if len(small) > 0 {
+		send(small)
+	}
+	for _, spans := range traces {
+		if len(spans) <= tsp.maxSpans {
+			continue
+		}
+		ordered := parentsFirst(spans)
+		for start := 0; start < len(ordered); start += tsp.maxSpans {
+			end := start + tsp.maxSpans
+			if end > len(ordered) {
+				end = len(ordered)
+			}
+			send(ordered[start:end])
+		}
+	}
+	return oterr.CombineErrors(errs)
+}
+
+// groupByTrace returns the spans grouped by trace, the traces and their spans
+// keep the order of the batch.
+func groupByTrace(spans []*tracepb.Span) [][]*tracepb.Span {
+	indexes := make(map[string]int)
+	var traces [][]*tracepb.Span
+	for _, span := range spans {
+		if span == nil {
+			continue
+		}
+		key := string(span.TraceId)
+		i, ok := indexes[key]
+		if !ok {
+			i = len(traces)
+			indexes[key] = i
+			traces = append(traces, nil)
+		}
+		traces[i] = append(traces[i], span)
+	}
+	return traces
+}
+
+// parentsFirst returns the spans of a trace ordered breadth first from its
+// roots, i.e. the spans whose parent is not in the trace, so that every span
+// comes after its parent. Splitting the result never sends a span before its
+// parent. The spans out of reach from a root, only possible with a cycle of
+// parent links, are kept at the end.
+func parentsFirst(spans []*tracepb.Span) []*tracepb.Span {
+	inTrace := make(map[string]bool, len(spans))
+	for _, span := range spans {
+		inTrace[string(span.SpanId)] = true
+	}
+
+	children := make(map[string][]*tracepb.Span, len(spans))
+	ordered := make([]*tracepb.Span, 0, len(spans))
+	for _, span := range spans {
+		parent := string(span.ParentSpanId)
+		if len(span.ParentSpanId) == 0 || !inTrace[parent] {
+			ordered = append(ordered, span)
+			continue
+		}
+		children[parent] = append(children[parent], span)
+	}
+
+	for i := 0; i < len(ordered); i++ {
+		id := string(ordered[i].SpanId)
+		ordered = append(ordered, children[id]...)
+		delete(children, id)
+	}
+
+	if len(ordered) < len(spans) {
+		added := make(map[*tracepb.Span]bool, len(ordered))
+		for _, span := range ordered {
+			added[span] = true
+		}
+		for _, span := range spans {
+			if !added[span] {
+				ordered = append(ordered, span)
+			}
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,151 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracesplitprocessor
+
+import (
+	"context"
+	"testing"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewTraceProcessorNilNext(t *testing.T) {
+	tp, err := NewTraceProcessor(zap.NewNop(), nil, Config{MaxSpansPerTrace: 1})
+	assert.Nil(t, tp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestSplitOversizedTrace(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := &exportertest.SinkTraceExporter{}
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "trace_split/small"},
+		MaxSpansPerTrace:  10,
+	}
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	// A large trace, a tree of 1 + 4 + 20 spans whose children are listed
+	// before their parents, mixed with a small trace.
+	large := []byte{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	small := []byte{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}
+	var spans []*tracepb.Span
+	for i := 0; i < 20; i++ {
+		spans = append(spans, span(large, uint16(100+i), uint16(10+i%4)))
+	}
+	spans = append(spans, span(small, 1, 0), span(small, 2, 1))
+	for i := 0; i < 4; i++ {
+		spans = append(spans, span(large, uint16(10+i), 1))
+	}
+	spans = append(spans, span(large, 1, 0))
+
+	node := &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "checkout"}}
+	td := consumerdata.TraceData{Node: node, SourceFormat: "test", Spans: spans}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	batches := sink.AllTraces()
+	require.Len(t, batches, 4)
+	// The small trace is sent as received.
+	assert.Equal(t, spans[20:22], batches[0].Spans)
+
+	sent := make(map[string]bool)
+	total := 0
+	for _, batch := range batches[1:] {
+		assert.Equal(t, node, batch.Node)
+		assert.Equal(t, "test", batch.SourceFormat)
+		assert.True(t, len(batch.Spans) <= cfg.MaxSpansPerTrace)
+		for _, s := range batch.Spans {
+			// The trace id and parent links are kept, and the parent of every
+			// span was sent in the same or a previous batch.
+			assert.Equal(t, large, s.TraceId)
+			if len(s.ParentSpanId) > 0 {
+				assert.True(t, sent[string(s.ParentSpanId)], "span %v sent before its parent", s.SpanId)
+			}
+			sent[string(s.SpanId)] = true
+			total++
+		}
+	}
+	assert.Equal(t, 25, total)
+
+	rows, err := view.RetrieveData(statSplitTraces.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestSplitKeepsSmallBatches(t *testing.T) {
+	sink := &exportertest.SinkTraceExporter{}
+	tp, err := NewTraceProcessor(zap.NewNop(), sink, Config{MaxSpansPerTrace: 2})
+	require.NoError(t, err)
+
+	a := []byte{1}
+	b := []byte{2}
+	td := consumerdata.TraceData{Spans: []*tracepb.Span{span(a, 1, 0), span(b, 1, 0), span(a, 2, 1), span(b, 2, 1)}}
+	require.NoError(t, tp.ConsumeTraceData(context.Background(), td))
+
+	// No trace exceeds the limit, the batch is passed through.
+	batches := sink.AllTraces()
+	require.Len(t, batches, 1)
+	assert.Equal(t, td, batches[0])
+}
+
+func TestParentsFirst(t *testing.T) {
+	trace := []byte{1}
+	// 3 -> 2 -> 1, 4 has a parent outside of the batch, 5 and 6 form a cycle.
+	spans := []*tracepb.Span{
+		span(trace, 3, 2),
+		span(trace, 5, 6),
+		span(trace, 2, 1),
+		span(trace, 4, 9),
+		span(trace, 6, 5),
+		span(trace, 1, 0),
+	}
+	ordered := parentsFirst(spans)
+	var ids []uint16
+	for _, s := range ordered {
+		ids = append(ids, uint16(s.SpanId[7]))
+	}
+	assert.Equal(t, []uint16{4, 1, 2, 3, 5, 6}, ids)
+}
+
+func span(traceID []byte, id, parent uint16) *tracepb.Span {
+	s := &tracepb.Span{
+		TraceId: traceID,
+		SpanId:  spanID(id),
+	}
+	if parent != 0 {
+		s.ParentSpanId = spanID(parent)
+	}
+	return s
+}
+
+func spanID(id uint16) []byte {
+	return []byte{0, 0, 0, 0, 0, 0, byte(id >> 8), byte(id)}
+}
@@ -0,0 +1,39 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeconsistencyprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// ConflictPolicy defines what the processor does with metrics whose type
+// conflicts with the first type seen for the same metric name.
+type ConflictPolicy string
+
+const (
+	// Drop removes the conflicting metrics from the batch.
+	Drop ConflictPolicy = "drop"
+	// Warn lets the conflicting metrics through, only counting the conflicts.
+	Warn ConflictPolicy = "warn"
+)
+
+// Config defines configuration for the type consistency processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// OnConflict is the policy applied to metrics with a conflicting type,
+	// either "drop" or "warn". Defaults to "drop".
+	OnConflict ConflictPolicy `mapstructure:"on_conflict"`
+	// MaxMetricNames is the maximum number of metric names whose first type is
+	// tracked. The least recently seen names are forgotten first.
+	MaxMetricNames int `mapstructure:"max_metric_names"`
+}
@@ -0,0 +1,132 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeconsistencyprocessor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/internal/lru"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type typeConsistencyProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	policy       ConflictPolicy
+	statsTags    []tag.Mutator
+
+	mu sync.Mutex
+	// firstSeen holds the first type seen for each metric name.
+	firstSeen *lru.Cache
+}
+
+var _ processor.MetricsProcessor = (*typeConsistencyProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that tracks the first
+// type seen for each metric name and applies the configured policy to metrics
+// reported later with a different type.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	policy := cfg.OnConflict
+	if policy == "" {
+		policy = Drop
+	}
+	if policy != Drop && policy != Warn {
+		return nil, fmt.Errorf("unknown on_conflict policy %q", cfg.OnConflict)
+	}
+
+	maxMetricNames := cfg.MaxMetricNames
+	if maxMetricNames == 0 {
+		maxMetricNames = defaultMaxMetricNames
+	}
+	if maxMetricNames < 0 {
+		return nil, fmt.Errorf("max_metric_names must be positive, got %d", cfg.MaxMetricNames)
+	}
+
+	return &typeConsistencyProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		policy:       policy,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+		firstSeen:    lru.New(maxMetricNames),
+	}, nil
+}
+
+func (tcp *typeConsistencyProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	kept := make([]*metricspb.Metric, 0, len(md.Metrics))
+	conflicts := 0
+
+	tcp.mu.Lock()
+	for _, metric := range md.Metrics {
+		desc := metric.GetMetricDescriptor()
+		if desc == nil {
+			kept = append(kept, metric)
+			continue
+		}
+
+		value, ok := tcp.firstSeen.Get(desc.Name)
+		if !ok {
+			tcp.firstSeen.Add(desc.Name, desc.Type)
+			kept = append(kept, metric)
+			continue
+		}
+		firstType := value.(metricspb.MetricDescriptor_Type)
+		tcp.firstSeen.Add(desc.Name, firstType)
+		if firstType == desc.Type {
+			kept = append(kept, metric)
+			continue
+		}
+
+		// A conflicting name is reported in every batch, the conflicts are
+		// counted rather than logged as warnings.
+		conflicts++
+		tcp.logger.Debug("Metric type conflicts with the first type seen for the same name",
+			zap.String("processor", tcp.name),
+			zap.String("metric", desc.Name),
+			zap.Stringer("first_type", firstType),
+			zap.Stringer("type", desc.Type),
+			zap.String("policy", string(tcp.policy)))
+		if tcp.policy == Warn {
+			kept = append(kept, metric)
+		}
+	}
+	tcp.mu.Unlock()
+
+	if conflicts > 0 {
+		stats.RecordWithTags(context.Background(), tcp.statsTags, statTypeConflicts.M(int64(conflicts)))
+	}
+
+	if len(kept) == 0 && len(md.Metrics) > 0 {
+		// Every metric in the batch was dropped.
+		return nil
+	}
+	md.Metrics = kept
+	return tcp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
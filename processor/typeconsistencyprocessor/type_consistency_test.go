@@ -0,0 +1,150 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeconsistencyprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestTypeConflicts(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	tests := []struct {
+		policy    ConflictPolicy
+		wantNames [][]string
+	}{
+		{
+			policy: Drop,
+			wantNames: [][]string{
+				{"requests", "temperature"},
+				{"other"},
+			},
+		},
+		{
+			policy: Warn,
+			wantNames: [][]string{
+				{"requests", "temperature"},
+				{"requests", "other"},
+				{"temperature"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			sink := &exportertest.SinkMetricsExporter{}
+			cfg := Config{
+				ProcessorSettings: configmodels.ProcessorSettings{NameVal: "type_consistency/" + string(tt.policy)},
+				OnConflict:        tt.policy,
+			}
+			tcp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+			require.NoError(t, err)
+
+			// The first target reports "requests" as a counter, the second as a gauge.
+			batches := []consumerdata.MetricsData{
+				{Metrics: []*metricspb.Metric{
+					metric("requests", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE),
+					metric("temperature", metricspb.MetricDescriptor_GAUGE_DOUBLE),
+				}},
+				{Metrics: []*metricspb.Metric{
+					metric("requests", metricspb.MetricDescriptor_GAUGE_DOUBLE),
+					metric("other", metricspb.MetricDescriptor_GAUGE_DOUBLE),
+				}},
+				{Metrics: []*metricspb.Metric{
+					metric("temperature", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE),
+				}},
+			}
+			for _, md := range batches {
+				require.NoError(t, tcp.ConsumeMetricsData(context.Background(), md))
+			}
+
+			got := sink.AllMetrics()
+			require.Len(t, got, len(tt.wantNames))
+			for i, names := range tt.wantNames {
+				assert.Equal(t, names, metricNames(got[i]))
+			}
+
+			rows, err := view.RetrieveData(statTypeConflicts.Name())
+			require.NoError(t, err)
+			var conflicts float64
+			for _, row := range rows {
+				if row.Tags[0].Value == cfg.Name() {
+					conflicts = row.Data.(*view.SumData).Value
+				}
+			}
+			assert.Equal(t, float64(2), conflicts)
+		})
+	}
+}
+
+func TestMaxMetricNames(t *testing.T) {
+	_, err := NewMetricsProcessor(zap.NewNop(), &exportertest.SinkMetricsExporter{}, Config{MaxMetricNames: -1})
+	assert.Error(t, err)
+
+	sink := &exportertest.SinkMetricsExporter{}
+	tcp, err := NewMetricsProcessor(zap.NewNop(), sink, Config{MaxMetricNames: 1})
+	require.NoError(t, err)
+
+	// "requests" is forgotten once "other" is seen, its new type is then the
+	// first one seen.
+	batches := []consumerdata.MetricsData{
+		{Metrics: []*metricspb.Metric{metric("requests", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE)}},
+		{Metrics: []*metricspb.Metric{metric("other", metricspb.MetricDescriptor_GAUGE_DOUBLE)}},
+		{Metrics: []*metricspb.Metric{metric("requests", metricspb.MetricDescriptor_GAUGE_DOUBLE)}},
+		{Metrics: []*metricspb.Metric{metric("requests", metricspb.MetricDescriptor_CUMULATIVE_DOUBLE)}},
+	}
+	for _, md := range batches {
+		require.NoError(t, tcp.ConsumeMetricsData(context.Background(), md))
+	}
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 3)
+	assert.Equal(t, []string{"requests"}, metricNames(got[2]))
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, got[2].Metrics[0].MetricDescriptor.Type)
+}
+
+func metric(name string, metricType metricspb.MetricDescriptor_Type) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Type: metricType},
+	}
+}
+
+func metricNames(md consumerdata.MetricsData) []string {
+	names := make([]string, 0, len(md.Metrics))
+	for _, m := range md.Metrics {
+		names = append(names, m.MetricDescriptor.Name)
+	}
+	return names
+}
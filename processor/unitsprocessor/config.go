@@ -0,0 +1,28 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitsprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Config defines configuration for the units processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// TargetUnits lists the units metrics are converted to, at most one per
+	// dimension (time, data, length and mass). Defaults to ["s", "By"].
+	TargetUnits []string `mapstructure:"target_units"`
+	// UseNameHeuristic infers the unit of the metrics without unit metadata
+	// from the suffix of their name, e.g. "request_duration_ms".
+	UseNameHeuristic bool `mapstructure:"use_name_heuristic"`
+}
@@ -0,0 +1,171 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitsprocessor
+
+import (
+	"strings"
+)
+
+// unit describes a unit by its dimension and its size expressed in the
+// smallest unit known for that dimension, so that the ratio between any two
+// units of a dimension is a ratio of integers.
+type unit struct {
+	dimension string
+	size      int64
+}
+
+const (
+	dimensionTime   = "time"
+	dimensionData   = "data"
+	dimensionLength = "length"
+	dimensionMass   = "mass"
+)
+
+// knownUnits maps the UCUM codes of the supported units to their description.
+var knownUnits = map[string]unit{
+	"ns":  {dimensionTime, 1},
+	"us":  {dimensionTime, 1e3},
+	"ms":  {dimensionTime, 1e6},
+	"s":   {dimensionTime, 1e9},
+	"min": {dimensionTime, 60 * 1e9},
+	"h":   {dimensionTime, 3600 * 1e9},
+	"d":   {dimensionTime, 86400 * 1e9},
+
+	"bit":  {dimensionData, 1},
+	"By":   {dimensionData, 8},
+	"kBy":  {dimensionData, 8 * 1e3},
+	"MBy":  {dimensionData, 8 * 1e6},
+	"GBy":  {dimensionData, 8 * 1e9},
+	"TBy":  {dimensionData, 8 * 1e12},
+	"KiBy": {dimensionData, 8 << 10},
+	"MiBy": {dimensionData, 8 << 20},
+	"GiBy": {dimensionData, 8 << 30},
+	"TiBy": {dimensionData, 8 << 40},
+
+	"mm": {dimensionLength, 1},
+	"m":  {dimensionLength, 1e3},
+	"km": {dimensionLength, 1e6},
+
+	"ng": {dimensionMass, 1},
+	"g":  {dimensionMass, 1e9},
+	"kg": {dimensionMass, 1e12},
+}
+
+// unitAliases maps common, lower case, spellings of the supported units to
+// their UCUM code. They are used for unit metadata that is not a UCUM code and
+// for the name based heuristic.
+var unitAliases = map[string]string{
+	"nanosecond": "ns", "nanoseconds": "ns", "ns": "ns",
+	"microsecond": "us", "microseconds": "us", "us": "us",
+	"millisecond": "ms", "milliseconds": "ms", "ms": "ms",
+	"second": "s", "seconds": "s", "s": "s", "sec": "s",
+	"minute": "min", "minutes": "min", "min": "min",
+	"hour": "h", "hours": "h", "h": "h",
+	"day": "d", "days": "d", "d": "d",
+
+	"bit": "bit", "bits": "bit", "bi": "bit",
+	"byte": "By", "bytes": "By", "by": "By",
+	"kilobyte": "kBy", "kilobytes": "kBy", "kby": "kBy", "kb": "kBy",
+	"megabyte": "MBy", "megabytes": "MBy", "mby": "MBy", "mb": "MBy",
+	"gigabyte": "GBy", "gigabytes": "GBy", "gby": "GBy", "gb": "GBy",
+	"terabyte": "TBy", "terabytes": "TBy", "tby": "TBy", "tb": "TBy",
+	"kibibyte": "KiBy", "kibibytes": "KiBy", "kiby": "KiBy", "kib": "KiBy",
+	"mebibyte": "MiBy", "mebibytes": "MiBy", "miby": "MiBy", "mib": "MiBy",
+	"gibibyte": "GiBy", "gibibytes": "GiBy", "giby": "GiBy", "gib": "GiBy",
+	"tebibyte": "TiBy", "tebibytes": "TiBy", "tiby": "TiBy", "tib": "TiBy",
+
+	"millimeter": "mm", "millimeters": "mm", "millimetre": "mm", "millimetres": "mm", "mm": "mm",
+	"meter": "m", "meters": "m", "metre": "m", "metres": "m", "m": "m",
+	"kilometer": "km", "kilometers": "km", "kilometre": "km", "kilometres": "km", "km": "km",
+
+	"nanogram": "ng", "nanograms": "ng", "ng": "ng",
+	"gram": "g", "grams": "g", "g": "g",
+	"kilogram": "kg", "kilograms": "kg", "kg": "kg",
+}
+
+// lookupUnit returns the UCUM code of the given unit, which is either a UCUM
+// code or one of the supported aliases.
+func lookupUnit(u string) (string, bool) {
+	if _, ok := knownUnits[u]; ok {
+		return u, true
+	}
+	code, ok := unitAliases[strings.ToLower(u)]
+	return code, ok
+}
+
+// unitFromName infers the unit of a metric from the suffix of its name, e.g.
+// "ms" for "request_duration_ms". Suffixes of a single character are ignored,
+// as in "foo_s" they are too likely to mean something else.
+func unitFromName(name string) (string, bool) {
+	i := strings.LastIndex(name, "_")
+	if i <= 0 || i >= len(name)-2 {
+		return "", false
+	}
+	code, ok := unitAliases[strings.ToLower(name[i+1:])]
+	return code, ok
+}
+
+// conversion converts values from one unit to another of the same dimension
+// by multiplying them by num/den, where num and den are coprime.
+type conversion struct {
+	from, to string
+	num, den int64
+}
+
+func newConversion(from, to string) conversion {
+	num, den := knownUnits[from].size, knownUnits[to].size
+	g := gcd(num, den)
+	return conversion{from: from, to: to, num: num / g, den: den / g}
+}
+
+// exactForInt64 returns true if the conversion maps integers to integers.
+func (c conversion) exactForInt64() bool {
+	return c.den == 1
+}
+
+// convertInt64 converts an int64 value, it returns false if the conversion
+// is not exact or overflows.
+func (c conversion) convertInt64(v int64) (int64, bool) {
+	if !c.exactForInt64() {
+		return 0, false
+	}
+	if v > 0 && v > maxInt64/c.num || v < 0 && v < -maxInt64/c.num {
+		return 0, false
+	}
+	return v * c.num, true
+}
+
+// convertDouble converts a double value. Multiplying by an integer factor is
+// exact as long as the result fits in the 53 bits of the mantissa; dividing
+// by an integer factor is correctly rounded but in general not exact, e.g.
+// 1ms is converted to 0.001s which has no exact binary representation.
+func (c conversion) convertDouble(v float64) float64 {
+	if c.num != 1 {
+		v *= float64(c.num)
+	}
+	if c.den != 1 {
+		v /= float64(c.den)
+	}
+	return v
+}
+
+const maxInt64 = int64(^uint64(0) >> 1)
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
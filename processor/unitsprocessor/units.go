@@ -0,0 +1,243 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitsprocessor
+
+import (
+	"context"
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/internal/histogram"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+var defaultTargetUnits = []string{"s", "By"}
+
+type unitsProcessor struct {
+	nextConsumer     consumer.MetricsConsumer
+	logger           *zap.Logger
+	useNameHeuristic bool
+	// targets maps every dimension to the unit its metrics are converted to.
+	targets map[string]string
+}
+
+var _ processor.MetricsProcessor = (*unitsProcessor)(nil)
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that converts the
+// values of the metrics to the configured target units and updates their unit
+// accordingly.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	targetUnits := cfg.TargetUnits
+	if len(targetUnits) == 0 {
+		targetUnits = defaultTargetUnits
+	}
+	targets := make(map[string]string, len(targetUnits))
+	for _, u := range targetUnits {
+		code, ok := lookupUnit(u)
+		if !ok {
+			return nil, fmt.Errorf("unknown target unit %q", u)
+		}
+		dimension := knownUnits[code].dimension
+		if other, ok := targets[dimension]; ok {
+			return nil, fmt.Errorf("target units %q and %q have the same dimension", other, u)
+		}
+		targets[dimension] = code
+	}
+
+	return &unitsProcessor{
+		nextConsumer:     nextConsumer,
+		logger:           logger,
+		useNameHeuristic: cfg.UseNameHeuristic,
+		targets:          targets,
+	}, nil
+}
+
+func (up *unitsProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	var metrics []*metricspb.Metric
+	for i, metric := range md.Metrics {
+		conv, ok := up.conversionFor(metric.GetMetricDescriptor())
+		if !ok {
+			continue
+		}
+		if metrics == nil {
+			metrics = make([]*metricspb.Metric, len(md.Metrics))
+			copy(metrics, md.Metrics)
+		}
+		metrics[i] = convertMetric(metric, conv)
+	}
+	if metrics != nil {
+		md.Metrics = metrics
+	}
+	return up.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// conversionFor returns the conversion to apply to the metric with the given
+// descriptor, if any. The unit of the metric is read from its descriptor or,
+// if the descriptor has no unit and the heuristic is enabled, inferred from
+// its name.
+func (up *unitsProcessor) conversionFor(desc *metricspb.MetricDescriptor) (conversion, bool) {
+	if desc == nil {
+		return conversion{}, false
+	}
+
+	var from string
+	var ok bool
+	if desc.Unit != "" {
+		from, ok = lookupUnit(desc.Unit)
+	} else if up.useNameHeuristic {
+		from, ok = unitFromName(desc.Name)
+	}
+	if !ok {
+		return conversion{}, false
+	}
+
+	to, ok := up.targets[knownUnits[from].dimension]
+	if !ok {
+		return conversion{}, false
+	}
+	return newConversion(from, to), true
+}
+
+// convertMetric returns a copy of the metric with its values converted, the
+// metric is not modified. Int64 metrics are kept as int64 when every value can
+// be converted exactly, otherwise they are turned into double metrics.
+func convertMetric(metric *metricspb.Metric, conv conversion) *metricspb.Metric {
+	convertedDesc := *metric.MetricDescriptor
+	convertedDesc.Unit = conv.to
+	convertedMetric := *metric
+	convertedMetric.MetricDescriptor = &convertedDesc
+	if conv.num == 1 && conv.den == 1 {
+		return &convertedMetric
+	}
+
+	toDouble := false
+	switch convertedDesc.Type {
+	case metricspb.MetricDescriptor_GAUGE_INT64, metricspb.MetricDescriptor_CUMULATIVE_INT64:
+		toDouble = !convertibleToInt64(metric, conv)
+		if toDouble {
+			if convertedDesc.Type == metricspb.MetricDescriptor_GAUGE_INT64 {
+				convertedDesc.Type = metricspb.MetricDescriptor_GAUGE_DOUBLE
+			} else {
+				convertedDesc.Type = metricspb.MetricDescriptor_CUMULATIVE_DOUBLE
+			}
+		}
+	}
+
+	convertedMetric.Timeseries = make([]*metricspb.TimeSeries, len(metric.Timeseries))
+	for i, ts := range metric.Timeseries {
+		if ts == nil {
+			continue
+		}
+		convertedTs := *ts
+		convertedTs.Points = make([]*metricspb.Point, len(ts.Points))
+		for j, point := range ts.Points {
+			if point != nil {
+				convertedTs.Points[j] = convertPoint(point, conv, toDouble)
+			}
+		}
+		convertedMetric.Timeseries[i] = &convertedTs
+	}
+	return &convertedMetric
+}
+
+func convertibleToInt64(metric *metricspb.Metric, conv conversion) bool {
+	for _, ts := range metric.Timeseries {
+		for _, point := range ts.Points {
+			if v, ok := point.GetValue().(*metricspb.Point_Int64Value); ok {
+				if _, ok := conv.convertInt64(v.Int64Value); !ok {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// convertPoint returns a copy of the point with its value converted.
+func convertPoint(point *metricspb.Point, conv conversion, toDouble bool) *metricspb.Point {
+	converted := &metricspb.Point{Timestamp: point.Timestamp, Value: point.Value}
+	switch v := point.Value.(type) {
+	case *metricspb.Point_Int64Value:
+		if toDouble {
+			converted.Value = &metricspb.Point_DoubleValue{DoubleValue: conv.convertDouble(float64(v.Int64Value))}
+		} else {
+			value, _ := conv.convertInt64(v.Int64Value)
+			converted.Value = &metricspb.Point_Int64Value{Int64Value: value}
+		}
+	case *metricspb.Point_DoubleValue:
+		converted.Value = &metricspb.Point_DoubleValue{DoubleValue: conv.convertDouble(v.DoubleValue)}
+	case *metricspb.Point_DistributionValue:
+		converted.Value = &metricspb.Point_DistributionValue{DistributionValue: convertDistribution(v.DistributionValue, conv)}
+	case *metricspb.Point_SummaryValue:
+		converted.Value = &metricspb.Point_SummaryValue{SummaryValue: convertSummary(v.SummaryValue, conv)}
+	}
+	return converted
+}
+
+// convertDistribution returns a copy of the distribution with its sum, bucket
+// bounds and exemplars converted. The sum of squared deviation is scaled by the
+// square of the conversion factor, the counts are left as is. The converted
+// bounds are canonicalized like any other bucket bounds.
+func convertDistribution(dv *metricspb.DistributionValue, conv conversion) *metricspb.DistributionValue {
+	if dv == nil {
+		return nil
+	}
+	dv = proto.Clone(dv).(*metricspb.DistributionValue)
+	dv.Sum = conv.convertDouble(dv.Sum)
+	dv.SumOfSquaredDeviation = conv.convertDouble(conv.convertDouble(dv.SumOfSquaredDeviation))
+	if explicit := dv.GetBucketOptions().GetExplicit(); explicit != nil {
+		for i, b := range explicit.Bounds {
+			explicit.Bounds[i] = conv.convertDouble(b)
+		}
+	}
+	for _, bucket := range dv.Buckets {
+		if bucket.GetExemplar() != nil {
+			bucket.Exemplar.Value = conv.convertDouble(bucket.Exemplar.Value)
+		}
+	}
+	histogram.CanonicalizeDistribution(dv)
+	return dv
+}
+
+// convertSummary returns a copy of the summary with its sums and percentile
+// values converted.
+func convertSummary(sv *metricspb.SummaryValue, conv conversion) *metricspb.SummaryValue {
+	if sv == nil {
+		return nil
+	}
+	sv = proto.Clone(sv).(*metricspb.SummaryValue)
+	if sv.Sum != nil {
+		sv.Sum.Value = conv.convertDouble(sv.Sum.Value)
+	}
+	if sv.Snapshot != nil {
+		if sv.Snapshot.Sum != nil {
+			sv.Snapshot.Sum.Value = conv.convertDouble(sv.Snapshot.Sum.Value)
+		}
+		for _, p := range sv.Snapshot.PercentileValues {
+			p.Value = conv.convertDouble(p.Value)
+		}
+	}
+	return sv
+}
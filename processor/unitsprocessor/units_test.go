@@ -0,0 +1,208 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitsprocessor
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessor(t *testing.T) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+
+	sink := &exportertest.SinkMetricsExporter{}
+	_, err = NewMetricsProcessor(zap.NewNop(), sink, Config{TargetUnits: []string{"s", "furlong"}})
+	assert.Error(t, err, "unknown unit")
+	_, err = NewMetricsProcessor(zap.NewNop(), sink, Config{TargetUnits: []string{"s", "ms"}})
+	assert.Error(t, err, "two units of the same dimension")
+	_, err = NewMetricsProcessor(zap.NewNop(), sink, Config{TargetUnits: []string{"seconds", "bytes"}})
+	assert.NoError(t, err, "aliases are accepted")
+}
+
+func TestMillisecondsToSeconds(t *testing.T) {
+	metrics := []*metricspb.Metric{
+		metric("latency", "ms", metricspb.MetricDescriptor_GAUGE_DOUBLE, doublePoint(1500), doublePoint(1)),
+		// 250ms can't be represented as an integer number of seconds.
+		metric("requests_time", "milliseconds", metricspb.MetricDescriptor_CUMULATIVE_INT64, int64Point(1000), int64Point(250)),
+		metric("already_seconds", "s", metricspb.MetricDescriptor_GAUGE_INT64, int64Point(3)),
+	}
+	got := process(t, Config{UseNameHeuristic: true}, metrics)
+
+	assert.Equal(t, "s", got[0].MetricDescriptor.Unit)
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, got[0].MetricDescriptor.Type)
+	assert.Equal(t, []float64{1.5, 0.001}, doubleValues(got[0]))
+
+	assert.Equal(t, "s", got[1].MetricDescriptor.Unit)
+	assert.Equal(t, metricspb.MetricDescriptor_CUMULATIVE_DOUBLE, got[1].MetricDescriptor.Type)
+	assert.Equal(t, []float64{1, 0.25}, doubleValues(got[1]))
+
+	assert.Equal(t, "s", got[2].MetricDescriptor.Unit)
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, got[2].MetricDescriptor.Type)
+	assert.Equal(t, []int64{3}, int64Values(got[2]))
+}
+
+func TestBytesToBase(t *testing.T) {
+	metrics := []*metricspb.Metric{
+		metric("heap", "KiBy", metricspb.MetricDescriptor_GAUGE_INT64, int64Point(2), int64Point(1024)),
+		metric("disk", "MBy", metricspb.MetricDescriptor_GAUGE_DOUBLE, doublePoint(1.5)),
+		metric("link", "bit", metricspb.MetricDescriptor_CUMULATIVE_INT64, int64Point(16), int64Point(12)),
+		// Multiplying overflows int64, the metric is converted to double.
+		metric("huge", "TiBy", metricspb.MetricDescriptor_GAUGE_INT64, int64Point(math.MaxInt64/1024)),
+	}
+	got := process(t, Config{}, metrics)
+
+	for _, m := range got {
+		assert.Equal(t, "By", m.MetricDescriptor.Unit)
+	}
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, got[0].MetricDescriptor.Type)
+	assert.Equal(t, []int64{2048, 1024 * 1024}, int64Values(got[0]))
+	assert.Equal(t, []float64{1.5e6}, doubleValues(got[1]))
+	assert.Equal(t, metricspb.MetricDescriptor_CUMULATIVE_DOUBLE, got[2].MetricDescriptor.Type)
+	assert.Equal(t, []float64{2, 1.5}, doubleValues(got[2]))
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, got[3].MetricDescriptor.Type)
+	assert.Equal(t, []float64{float64(math.MaxInt64/1024) * (1 << 40)}, doubleValues(got[3]))
+}
+
+func TestNameHeuristic(t *testing.T) {
+	newMetrics := func() []*metricspb.Metric {
+		return []*metricspb.Metric{
+			metric("request_duration_ms", "", metricspb.MetricDescriptor_GAUGE_DOUBLE, doublePoint(20)),
+			// Single letter suffixes are ignored.
+			metric("queue_s", "", metricspb.MetricDescriptor_GAUGE_DOUBLE, doublePoint(20)),
+			// Unknown units are left as is.
+			metric("temperature", "Cel", metricspb.MetricDescriptor_GAUGE_DOUBLE, doublePoint(20)),
+		}
+	}
+
+	got := process(t, Config{UseNameHeuristic: true}, newMetrics())
+	assert.Equal(t, "s", got[0].MetricDescriptor.Unit)
+	assert.Equal(t, []float64{0.02}, doubleValues(got[0]))
+	assert.Equal(t, "", got[1].MetricDescriptor.Unit)
+	assert.Equal(t, []float64{20}, doubleValues(got[1]))
+	assert.Equal(t, "Cel", got[2].MetricDescriptor.Unit)
+	assert.Equal(t, []float64{20}, doubleValues(got[2]))
+
+	got = process(t, Config{}, newMetrics())
+	assert.Equal(t, "", got[0].MetricDescriptor.Unit)
+	assert.Equal(t, []float64{20}, doubleValues(got[0]))
+}
+
+func TestDistributionAndSummary(t *testing.T) {
+	dist := &metricspb.Point{Value: &metricspb.Point_DistributionValue{DistributionValue: &metricspb.DistributionValue{
+		Count:                 3,
+		Sum:                   350,
+		SumOfSquaredDeviation: 20000,
+		BucketOptions: &metricspb.DistributionValue_BucketOptions{
+			Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+				Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: []float64{100, 250}},
+			},
+		},
+		Buckets: []*metricspb.DistributionValue_Bucket{
+			{Count: 1, Exemplar: &metricspb.DistributionValue_Exemplar{Value: 50}},
+			{Count: 2},
+			{Count: 0},
+		},
+	}}}
+	summary := &metricspb.Point{Value: &metricspb.Point_SummaryValue{SummaryValue: &metricspb.SummaryValue{
+		Count: &wrappers.Int64Value{Value: 3},
+		Sum:   &wrappers.DoubleValue{Value: 350},
+		Snapshot: &metricspb.SummaryValue_Snapshot{
+			Sum:              &wrappers.DoubleValue{Value: 300},
+			PercentileValues: []*metricspb.SummaryValue_Snapshot_ValueAtPercentile{{Percentile: 50, Value: 120}},
+		},
+	}}}
+	metrics := []*metricspb.Metric{
+		metric("latency", "ms", metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION, dist),
+		metric("latency_summary", "ms", metricspb.MetricDescriptor_SUMMARY, summary),
+	}
+	got := process(t, Config{}, metrics)
+
+	dv := got[0].Timeseries[0].Points[0].GetDistributionValue()
+	assert.Equal(t, int64(3), dv.Count)
+	assert.Equal(t, 0.35, dv.Sum)
+	assert.Equal(t, 0.02, dv.SumOfSquaredDeviation)
+	assert.Equal(t, []float64{0.1, 0.25}, dv.BucketOptions.GetExplicit().Bounds)
+	assert.Equal(t, 0.05, dv.Buckets[0].Exemplar.Value)
+	assert.Equal(t, int64(2), dv.Buckets[1].Count)
+
+	sv := got[1].Timeseries[0].Points[0].GetSummaryValue()
+	assert.Equal(t, int64(3), sv.Count.Value)
+	assert.Equal(t, 0.35, sv.Sum.Value)
+	assert.Equal(t, 0.3, sv.Snapshot.Sum.Value)
+	assert.Equal(t, 0.12, sv.Snapshot.PercentileValues[0].Value)
+}
+
+func process(t *testing.T, cfg Config, metrics []*metricspb.Metric) []*metricspb.Metric {
+	sink := &exportertest.SinkMetricsExporter{}
+	mp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+	originals := make([]proto.Message, len(metrics))
+	for i, m := range metrics {
+		originals[i] = proto.Clone(m)
+	}
+	require.NoError(t, mp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Metrics: metrics}))
+	for i, m := range metrics {
+		assert.True(t, proto.Equal(originals[i], m), "the metrics may be shared, they must not be modified")
+	}
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, len(metrics))
+	return got[0].Metrics
+}
+
+func metric(name, unit string, typ metricspb.MetricDescriptor_Type, points ...*metricspb.Point) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Unit: unit, Type: typ},
+		Timeseries:       []*metricspb.TimeSeries{{Points: points}},
+	}
+}
+
+func int64Point(v int64) *metricspb.Point {
+	return &metricspb.Point{Value: &metricspb.Point_Int64Value{Int64Value: v}}
+}
+
+func doublePoint(v float64) *metricspb.Point {
+	return &metricspb.Point{Value: &metricspb.Point_DoubleValue{DoubleValue: v}}
+}
+
+func int64Values(m *metricspb.Metric) []int64 {
+	var values []int64
+	for _, point := range m.Timeseries[0].Points {
+		values = append(values, point.GetInt64Value())
+	}
+	return values
+}
+
+func doubleValues(m *metricspb.Metric) []float64 {
+	var values []float64
+	for _, point := range m.Timeseries[0].Points {
+		values = append(values, point.GetDoubleValue())
+	}
+	return values
+}
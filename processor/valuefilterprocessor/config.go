@@ -0,0 +1,90 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package valuefilterprocessor
+
+import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+
+// Operator is the comparison applied to the value of the points.
+type Operator string
+
+const (
+	// Equal matches the values equal to the rule value.
+	Equal Operator = "=="
+	// NotEqual matches the values different from the rule value.
+	NotEqual Operator = "!="
+	// LessThan matches the values smaller than the rule value.
+	LessThan Operator = "<"
+	// LessThanOrEqual matches the values smaller than or equal to the rule value.
+	LessThanOrEqual Operator = "<="
+	// GreaterThan matches the values greater than the rule value.
+	GreaterThan Operator = ">"
+	// GreaterThanOrEqual matches the values greater than or equal to the rule value.
+	GreaterThanOrEqual Operator = ">="
+	// Range matches the values between the rule min and max, both included.
+	Range Operator = "range"
+)
+
+// MetricType is the kind of metrics a rule applies to.
+type MetricType string
+
+const (
+	// Gauge matches the int64 and double gauges.
+	Gauge MetricType = "gauge"
+	// Counter matches the int64 and double cumulative metrics.
+	Counter MetricType = "counter"
+	// Histogram matches the gauge and cumulative distributions.
+	Histogram MetricType = "histogram"
+	// Summary matches the summaries.
+	Summary MetricType = "summary"
+)
+
+// Field is the value of a histogram or summary point a rule compares.
+type Field string
+
+const (
+	// Count compares the number of values in the point.
+	Count Field = "count"
+	// Sum compares the sum of the values in the point.
+	Sum Field = "sum"
+)
+
+// Config defines configuration for the value filter processor.
+type Config struct {
+	configmodels.ProcessorSettings `mapstructure:",squash"`
+	// Rules are the conditions of the points to drop. A point is dropped if it
+	// matches any of the rules.
+	Rules []Rule `mapstructure:"rules"`
+}
+
+// Rule defines the points to drop by their value.
+type Rule struct {
+	// MetricNames are the names of the metrics the rule applies to. Empty
+	// means every metric.
+	MetricNames []string `mapstructure:"metric_names"`
+	// MetricTypes are the types of the metrics the rule applies to, any of
+	// "gauge", "counter", "histogram" and "summary". Empty means every type.
+	MetricTypes []MetricType `mapstructure:"metric_types"`
+	// Operator is the comparison of the point value with the rule value, one
+	// of "==", "!=", "<", "<=", ">", ">=" and "range".
+	Operator Operator `mapstructure:"operator"`
+	// Value is the value compared with, for all the operators but "range".
+	Value float64 `mapstructure:"value"`
+	// Min and Max are the bounds of the "range" operator, both included.
+	Min float64 `mapstructure:"min"`
+	Max float64 `mapstructure:"max"`
+	// Field is the value compared for histograms and summaries, which are
+	// kept or dropped as a whole: "count" (the default) or "sum".
+	Field Field `mapstructure:"field"`
+}
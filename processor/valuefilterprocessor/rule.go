@@ -0,0 +1,136 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package valuefilterprocessor
+
+import (
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+)
+
+// matcher is the compiled form of a Rule.
+type matcher struct {
+	names   map[string]bool
+	types   map[MetricType]bool
+	field   Field
+	compare func(v float64) bool
+}
+
+func newMatcher(rule Rule) (*matcher, error) {
+	m := &matcher{field: rule.Field}
+	if m.field == "" {
+		m.field = Count
+	}
+	if m.field != Count && m.field != Sum {
+		return nil, fmt.Errorf("unknown field %q, must be either %q or %q", rule.Field, Count, Sum)
+	}
+
+	if len(rule.MetricNames) > 0 {
+		m.names = make(map[string]bool, len(rule.MetricNames))
+		for _, name := range rule.MetricNames {
+			m.names[name] = true
+		}
+	}
+	if len(rule.MetricTypes) > 0 {
+		m.types = make(map[MetricType]bool, len(rule.MetricTypes))
+		for _, t := range rule.MetricTypes {
+			switch t {
+			case Gauge, Counter, Histogram, Summary:
+				m.types[t] = true
+			default:
+				return nil, fmt.Errorf("unknown metric type %q", t)
+			}
+		}
+	}
+
+	value := rule.Value
+	switch rule.Operator {
+	case Equal:
+		m.compare = func(v float64) bool { return v == value }
+	case NotEqual:
+		m.compare = func(v float64) bool { return v != value }
+	case LessThan:
+		m.compare = func(v float64) bool { return v < value }
+	case LessThanOrEqual:
+		m.compare = func(v float64) bool { return v <= value }
+	case GreaterThan:
+		m.compare = func(v float64) bool { return v > value }
+	case GreaterThanOrEqual:
+		m.compare = func(v float64) bool { return v >= value }
+	case Range:
+		min, max := rule.Min, rule.Max
+		if min > max {
+			return nil, fmt.Errorf("range min %v is greater than max %v", min, max)
+		}
+		m.compare = func(v float64) bool { return v >= min && v <= max }
+	default:
+		return nil, fmt.Errorf("unknown operator %q", rule.Operator)
+	}
+	return m, nil
+}
+
+// appliesTo returns true if the rule applies to the points of the metric.
+func (m *matcher) appliesTo(desc *metricspb.MetricDescriptor) bool {
+	if m.names != nil && !m.names[desc.GetName()] {
+		return false
+	}
+	if m.types != nil && !m.types[metricType(desc.GetType())] {
+		return false
+	}
+	return true
+}
+
+// matches returns true if the value of the point matches the rule. Points
+// without the compared value, e.g. a summary without sum, never match.
+func (m *matcher) matches(point *metricspb.Point) bool {
+	v, ok := pointValue(point, m.field)
+	return ok && m.compare(v)
+}
+
+func pointValue(point *metricspb.Point, field Field) (float64, bool) {
+	switch value := point.GetValue().(type) {
+	case *metricspb.Point_Int64Value:
+		return float64(value.Int64Value), true
+	case *metricspb.Point_DoubleValue:
+		return value.DoubleValue, true
+	case *metricspb.Point_DistributionValue:
+		if field == Sum {
+			return value.DistributionValue.GetSum(), true
+		}
+		return float64(value.DistributionValue.GetCount()), true
+	case *metricspb.Point_SummaryValue:
+		if field == Sum {
+			sum := value.SummaryValue.GetSum()
+			return sum.GetValue(), sum != nil
+		}
+		count := value.SummaryValue.GetCount()
+		return float64(count.GetValue()), count != nil
+	}
+	return 0, false
+}
+
+func metricType(t metricspb.MetricDescriptor_Type) MetricType {
+	switch t {
+	case metricspb.MetricDescriptor_GAUGE_INT64, metricspb.MetricDescriptor_GAUGE_DOUBLE:
+		return Gauge
+	case metricspb.MetricDescriptor_CUMULATIVE_INT64, metricspb.MetricDescriptor_CUMULATIVE_DOUBLE:
+		return Counter
+	case metricspb.MetricDescriptor_GAUGE_DISTRIBUTION, metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION:
+		return Histogram
+	case metricspb.MetricDescriptor_SUMMARY:
+		return Summary
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package valuefilterprocessor
+
+import (
+	"context"
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+type valueFilterProcessor struct {
+	name         string
+	nextConsumer consumer.MetricsConsumer
+	logger       *zap.Logger
+	matchers     []*matcher
+	statsTags    []tag.Mutator
+}
+
+var _ processor.MetricsProcessor = (*valueFilterProcessor)(nil)
+
+// dropReason is the reason the points dropped are recorded with, see
+// processor.RecordDropped.
+const dropReason = "value_filter"
+
+// NewMetricsProcessor returns a processor.MetricsProcessor that drops the
+// points whose value matches any of the configured rules.
+func NewMetricsProcessor(logger *zap.Logger, nextConsumer consumer.MetricsConsumer, cfg Config) (processor.MetricsProcessor, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	matchers := make([]*matcher, 0, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		m, err := newMatcher(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %v", i, err)
+		}
+		matchers = append(matchers, m)
+	}
+
+	return &valueFilterProcessor{
+		name:         cfg.Name(),
+		nextConsumer: nextConsumer,
+		logger:       logger,
+		matchers:     matchers,
+		statsTags:    []tag.Mutator{tag.Upsert(processor.TagExporterNameKey, cfg.Name())},
+	}, nil
+}
+
+func (vfp *valueFilterProcessor) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	kept := make([]*metricspb.Metric, 0, len(md.Metrics))
+	dropped := 0
+
+	for _, metric := range md.Metrics {
+		matchers := vfp.matchersFor(metric.GetMetricDescriptor())
+		if len(matchers) == 0 {
+			kept = append(kept, metric)
+			continue
+		}
+
+		timeseries := make([]*metricspb.TimeSeries, 0, len(metric.Timeseries))
+		metricDropped := 0
+		for _, ts := range metric.Timeseries {
+			filtered, n := filterPoints(matchers, ts)
+			metricDropped += n
+			if len(filtered.Points) > 0 {
+				timeseries = append(timeseries, filtered)
+			}
+		}
+		dropped += metricDropped
+		processor.RecordDropped(vfp.name, dropReason, metric.GetMetricDescriptor().GetName(), int64(metricDropped))
+		if len(timeseries) > 0 {
+			kept = append(kept, &metricspb.Metric{
+				MetricDescriptor: metric.MetricDescriptor,
+				Resource:         metric.Resource,
+				Timeseries:       timeseries,
+			})
+		}
+	}
+
+	if dropped > 0 {
+		vfp.logger.Debug("Dropped points matching a value filter",
+			zap.String("processor", vfp.name),
+			zap.Int("points", dropped))
+		stats.RecordWithTags(context.Background(), vfp.statsTags, statFilteredPoints.M(int64(dropped)))
+	}
+
+	if len(kept) == 0 && len(md.Metrics) > 0 {
+		// Every point in the batch was dropped.
+		return nil
+	}
+	md.Metrics = kept
+	return vfp.nextConsumer.ConsumeMetricsData(ctx, md)
+}
+
+// matchersFor returns the matchers of the rules applying to the metric.
+func (vfp *valueFilterProcessor) matchersFor(desc *metricspb.MetricDescriptor) []*matcher {
+	if desc == nil {
+		return nil
+	}
+	var matchers []*matcher
+	for _, m := range vfp.matchers {
+		if m.appliesTo(desc) {
+			matchers = append(matchers, m)
+		}
+	}
+	return matchers
+}
+
+// filterPoints returns the series without the points matching any of the
+// matchers, and how many points were removed. The series is returned as is if
+// no point matches.
+func filterPoints(matchers []*matcher, ts *metricspb.TimeSeries) (*metricspb.TimeSeries, int) {
+	points := make([]*metricspb.Point, 0, len(ts.Points))
+	for _, point := range ts.Points {
+		if !matchesAny(matchers, point) {
+			points = append(points, point)
+		}
+	}
+	dropped := len(ts.Points) - len(points)
+	if dropped == 0 {
+		return ts, 0
+	}
+	return &metricspb.TimeSeries{
+		StartTimestamp: ts.StartTimestamp,
+		LabelValues:    ts.LabelValues,
+		Points:         points,
+	}, dropped
+}
+
+func matchesAny(matchers []*matcher, point *metricspb.Point) bool {
+	for _, m := range matchers {
+		if m.matches(point) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package valuefilterprocessor
+
+import (
+	"context"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+func TestNewMetricsProcessorNilNext(t *testing.T) {
+	mp, err := NewMetricsProcessor(zap.NewNop(), nil, Config{})
+	assert.Nil(t, mp)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+}
+
+func TestDropZeroValuedGauges(t *testing.T) {
+	views := MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	sink := &exportertest.SinkMetricsExporter{}
+	cfg := Config{
+		ProcessorSettings: configmodels.ProcessorSettings{NameVal: "value_filter/zeros"},
+		Rules: []Rule{
+			{MetricTypes: []MetricType{Gauge}, Operator: Equal, Value: 0},
+		},
+	}
+	vfp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			metric("temperature", metricspb.MetricDescriptor_GAUGE_DOUBLE, doublePoint(0), doublePoint(21.5)),
+			metric("queue_size", metricspb.MetricDescriptor_GAUGE_INT64, int64Point(0)),
+			// Counters are not matched by the rule.
+			metric("requests", metricspb.MetricDescriptor_CUMULATIVE_INT64, int64Point(0)),
+		},
+	}
+	original := proto.Clone(md.Metrics[0])
+	require.NoError(t, vfp.ConsumeMetricsData(context.Background(), md))
+	assert.True(t, proto.Equal(original, md.Metrics[0]), "the metrics may be shared, they must not be modified")
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 2)
+	assert.Equal(t, "temperature", got[0].Metrics[0].MetricDescriptor.Name)
+	require.Len(t, got[0].Metrics[0].Timeseries, 1)
+	assert.Equal(t, []*metricspb.Point{doublePoint(21.5)}, got[0].Metrics[0].Timeseries[0].Points)
+	assert.Equal(t, "requests", got[0].Metrics[1].MetricDescriptor.Name)
+
+	rows, err := view.RetrieveData(statFilteredPoints.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, cfg.Name(), rows[0].Tags[0].Value)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestValueRangeFilter(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	cfg := Config{
+		Rules: []Rule{
+			{MetricNames: []string{"latency"}, Operator: Range, Min: 10, Max: 20},
+		},
+	}
+	vfp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			metric("latency", metricspb.MetricDescriptor_GAUGE_DOUBLE,
+				doublePoint(9.9), doublePoint(10), doublePoint(15), doublePoint(20), doublePoint(20.1)),
+			metric("other", metricspb.MetricDescriptor_GAUGE_DOUBLE, doublePoint(15)),
+		},
+	}
+	require.NoError(t, vfp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 2)
+	assert.Equal(t, []*metricspb.Point{doublePoint(9.9), doublePoint(20.1)}, got[0].Metrics[0].Timeseries[0].Points)
+	assert.Equal(t, []*metricspb.Point{doublePoint(15)}, got[0].Metrics[1].Timeseries[0].Points)
+}
+
+func TestHistogramsAndSummariesAreFilteredAsAWhole(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	cfg := Config{
+		Rules: []Rule{
+			{MetricTypes: []MetricType{Histogram}, Operator: Equal, Value: 0},
+			{MetricTypes: []MetricType{Summary}, Operator: GreaterThan, Value: 100, Field: Sum},
+		},
+	}
+	vfp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	emptyHistogram := &metricspb.Point{Value: &metricspb.Point_DistributionValue{
+		DistributionValue: &metricspb.DistributionValue{Count: 0, Sum: 0},
+	}}
+	histogram := &metricspb.Point{Value: &metricspb.Point_DistributionValue{
+		DistributionValue: &metricspb.DistributionValue{Count: 2, Sum: 0},
+	}}
+	bigSummary := &metricspb.Point{Value: &metricspb.Point_SummaryValue{
+		SummaryValue: &metricspb.SummaryValue{Count: &wrappers.Int64Value{Value: 1}, Sum: &wrappers.DoubleValue{Value: 150}},
+	}}
+	// A summary without sum is never matched by a sum rule.
+	summaryWithoutSum := &metricspb.Point{Value: &metricspb.Point_SummaryValue{
+		SummaryValue: &metricspb.SummaryValue{Count: &wrappers.Int64Value{Value: 1}},
+	}}
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			metric("hist", metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION, emptyHistogram, histogram),
+			metric("summary", metricspb.MetricDescriptor_SUMMARY, bigSummary, summaryWithoutSum),
+		},
+	}
+	require.NoError(t, vfp.ConsumeMetricsData(context.Background(), md))
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Metrics, 2)
+	assert.Equal(t, []*metricspb.Point{histogram}, got[0].Metrics[0].Timeseries[0].Points)
+	assert.Equal(t, []*metricspb.Point{summaryWithoutSum}, got[0].Metrics[1].Timeseries[0].Points)
+}
+
+func TestEveryPointDropped(t *testing.T) {
+	sink := &exportertest.SinkMetricsExporter{}
+	cfg := Config{Rules: []Rule{{Operator: LessThan, Value: 1}}}
+	vfp, err := NewMetricsProcessor(zap.NewNop(), sink, cfg)
+	require.NoError(t, err)
+
+	md := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{metric("m", metricspb.MetricDescriptor_GAUGE_INT64, int64Point(0))},
+	}
+	require.NoError(t, vfp.ConsumeMetricsData(context.Background(), md))
+	assert.Empty(t, sink.AllMetrics())
+}
+
+func metric(name string, t metricspb.MetricDescriptor_Type, points ...*metricspb.Point) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: name, Type: t},
+		Timeseries:       []*metricspb.TimeSeries{{Points: points}},
+	}
+}
+
+func doublePoint(v float64) *metricspb.Point {
+	return &metricspb.Point{Value: &metricspb.Point_DoubleValue{DoubleValue: v}}
+}
+
+func int64Point(v int64) *metricspb.Point {
+	return &metricspb.Point{Value: &metricspb.Point_Int64Value{Int64Value: v}}
+}
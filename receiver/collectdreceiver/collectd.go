@@ -0,0 +1,305 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectdreceiver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+)
+
+// collectd data source types, see types.db(5).
+const (
+	dsTypeGauge    = "gauge"
+	dsTypeDerive   = "derive"
+	dsTypeCounter  = "counter"
+	dsTypeAbsolute = "absolute"
+)
+
+const (
+	pluginInstanceLabel = "plugin_instance"
+	typeInstanceLabel   = "type_instance"
+
+	// defaultDSName is the data source name used by collectd for types with a
+	// single value, it is not added to the metric name.
+	defaultDSName = "value"
+)
+
+var (
+	errEmptyPayload    = errors.New("empty collectd payload")
+	errMissingPlugin   = errors.New("collectd record without plugin")
+	errMissingType     = errors.New("collectd record without type")
+	errValuesMismatch  = errors.New("collectd record values, dstypes and dsnames lengths differ")
+	errUnknownDataType = errors.New("unknown collectd data source type")
+)
+
+// collectdRecord is a single record sent by the write_http plugin in JSON
+// format, see https://collectd.org/wiki/index.php/Plugin:Write_HTTP.
+type collectdRecord struct {
+	Values         []*json.Number `json:"values"`
+	DSTypes        []string       `json:"dstypes"`
+	DSNames        []string       `json:"dsnames"`
+	Time           float64        `json:"time"`
+	Interval       float64        `json:"interval"`
+	Host           string         `json:"host"`
+	Plugin         string         `json:"plugin"`
+	PluginInstance string         `json:"plugin_instance"`
+	Type           string         `json:"type"`
+	TypeInstance   string         `json:"type_instance"`
+}
+
+// parsePayload decodes a write_http JSON payload.
+func parsePayload(blob []byte) ([]*collectdRecord, error) {
+	var records []*collectdRecord
+	if err := json.Unmarshal(blob, &records); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errEmptyPayload
+	}
+	for _, r := range records {
+		if r == nil {
+			return nil, errEmptyPayload
+		}
+		if r.Plugin == "" {
+			return nil, errMissingPlugin
+		}
+		if r.Type == "" {
+			return nil, errMissingType
+		}
+		if len(r.Values) != len(r.DSTypes) || len(r.Values) != len(r.DSNames) {
+			return nil, errValuesMismatch
+		}
+	}
+	return records, nil
+}
+
+// cumulativeStaleAfter is how long the start time of a cumulative series is
+// kept without receiving any of its points. Series coming back after it are
+// restarted.
+const cumulativeStaleAfter = 15 * time.Minute
+
+// cumulativeStarts tracks the start time of the cumulative series, collectd
+// does not send it. A series starts when it is first seen by the receiver and
+// restarts when its value decreases, i.e. collectd restarted or the counter
+// wrapped around.
+type cumulativeStarts struct {
+	mu        sync.Mutex
+	series    map[string]*cumulativeStart
+	lastSweep time.Time
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+}
+
+type cumulativeStart struct {
+	start *timestamp.Timestamp
+	last  int64
+	seen  time.Time
+}
+
+func newCumulativeStarts() *cumulativeStarts {
+	return &cumulativeStarts{
+		series: make(map[string]*cumulativeStart),
+		now:    time.Now,
+	}
+}
+
+// startOf returns the start time of the series identified by key for its
+// point taken at ts.
+func (cs *cumulativeStarts) startOf(key string, ts *timestamp.Timestamp, value int64) *timestamp.Timestamp {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	now := cs.now()
+	if now.Sub(cs.lastSweep) >= cumulativeStaleAfter {
+		cs.sweep(now)
+	}
+
+	s, ok := cs.series[key]
+	if !ok || value < s.last {
+		s = &cumulativeStart{start: ts}
+		cs.series[key] = s
+	}
+	s.last = value
+	s.seen = now
+	return s.start
+}
+
+// sweep forgets the series without points for cumulativeStaleAfter.
+func (cs *cumulativeStarts) sweep(now time.Time) {
+	for key, s := range cs.series {
+		if now.Sub(s.seen) >= cumulativeStaleAfter {
+			delete(cs.series, key)
+		}
+	}
+	cs.lastSweep = now
+}
+
+// recordsToMetricsData converts the collectd records into metrics data, one
+// per host. The start times of the cumulative series are taken from starts.
+// It returns the number of values that could not be converted.
+func recordsToMetricsData(records []*collectdRecord, starts *cumulativeStarts) ([]consumerdata.MetricsData, int, error) {
+	var mds []consumerdata.MetricsData
+	hostIndex := make(map[string]int)
+	dropped := 0
+	for _, r := range records {
+		idx, ok := hostIndex[r.Host]
+		if !ok {
+			idx = len(mds)
+			hostIndex[r.Host] = idx
+			mds = append(mds, consumerdata.MetricsData{
+				Node: &commonpb.Node{
+					Identifier: &commonpb.ProcessIdentifier{HostName: r.Host},
+				},
+			})
+		}
+
+		for i := range r.Values {
+			metric, err := r.toMetric(i, starts)
+			if err != nil {
+				return nil, 0, err
+			}
+			if metric == nil {
+				dropped++
+				continue
+			}
+			mds[idx].Metrics = append(mds[idx].Metrics, metric)
+		}
+	}
+	return mds, dropped, nil
+}
+
+// metricName maps the collectd plugin/type hierarchy into a metric name, e.g.
+// "disk.disk_octets.read". Instances are mapped into labels.
+func (r *collectdRecord) metricName(i int) string {
+	parts := []string{r.Plugin, r.Type}
+	if dsName := r.DSNames[i]; dsName != "" && dsName != defaultDSName {
+		parts = append(parts, dsName)
+	}
+	return strings.Join(parts, ".")
+}
+
+// seriesKey identifies the series of the i-th value of the record.
+func (r *collectdRecord) seriesKey(i int) string {
+	return strings.Join([]string{r.Host, r.metricName(i), r.PluginInstance, r.TypeInstance}, "\x00")
+}
+
+// toMetric converts the i-th value of the record. It returns a nil metric if
+// the value is missing, collectd sends null for unknown gauge values.
+func (r *collectdRecord) toMetric(i int, starts *cumulativeStarts) (*metricspb.Metric, error) {
+	if r.Values[i] == nil {
+		return nil, nil
+	}
+	raw := r.Values[i].String()
+
+	point := &metricspb.Point{Timestamp: toTimestamp(r.Time)}
+	var metricType metricspb.MetricDescriptor_Type
+	var start *timestamp.Timestamp
+	switch strings.ToLower(r.DSTypes[i]) {
+	case dsTypeGauge:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(v) {
+			return nil, nil
+		}
+		metricType = metricspb.MetricDescriptor_GAUGE_DOUBLE
+		point.Value = &metricspb.Point_DoubleValue{DoubleValue: v}
+	case dsTypeDerive:
+		// DERIVE is a signed running total, write_http sends it as is when
+		// StoreRates is false. A decrease is handled as a reset.
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		metricType = metricspb.MetricDescriptor_CUMULATIVE_INT64
+		point.Value = &metricspb.Point_Int64Value{Int64Value: v}
+		start = starts.startOf(r.seriesKey(i), point.Timestamp, v)
+	case dsTypeCounter:
+		// COUNTER is an unsigned counter that wraps around on overflow.
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		metricType = metricspb.MetricDescriptor_CUMULATIVE_INT64
+		point.Value = &metricspb.Point_Int64Value{Int64Value: int64(v & math.MaxInt64)}
+		start = starts.startOf(r.seriesKey(i), point.Timestamp, int64(v&math.MaxInt64))
+	case dsTypeAbsolute:
+		// ABSOLUTE is reset on each read, so it is the count over the interval.
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		metricType = metricspb.MetricDescriptor_GAUGE_INT64
+		point.Value = &metricspb.Point_Int64Value{Int64Value: int64(v & math.MaxInt64)}
+	default:
+		return nil, fmt.Errorf("%v: %q", errUnknownDataType, r.DSTypes[i])
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name: r.metricName(i),
+			Type: metricType,
+			LabelKeys: []*metricspb.LabelKey{
+				{Key: pluginInstanceLabel},
+				{Key: typeInstanceLabel},
+			},
+		},
+		Timeseries: []*metricspb.TimeSeries{
+			{
+				StartTimestamp: start,
+				LabelValues: []*metricspb.LabelValue{
+					toLabelValue(r.PluginInstance),
+					toLabelValue(r.TypeInstance),
+				},
+				Points: []*metricspb.Point{point},
+			},
+		},
+	}, nil
+}
+
+func toLabelValue(v string) *metricspb.LabelValue {
+	return &metricspb.LabelValue{Value: v, HasValue: v != ""}
+}
+
+// toTimestamp converts the collectd time, in seconds since epoch, to a timestamp.
+func toTimestamp(secs float64) *timestamp.Timestamp {
+	if secs <= 0 {
+		return nil
+	}
+	whole, frac := math.Modf(secs)
+	nanos := math.Round(frac * 1e9)
+	if nanos >= 1e9 {
+		// The fraction rounds up to the next second.
+		whole++
+		nanos -= 1e9
+	}
+	return &timestamp.Timestamp{
+		Seconds: int64(whole),
+		Nanos:   int32(nanos),
+	}
+}
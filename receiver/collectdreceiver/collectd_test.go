@@ -0,0 +1,214 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectdreceiver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		wantType  metricspb.MetricDescriptor_Type
+		wantStart *timestamp.Timestamp
+		wantPoint *metricspb.Point
+	}{
+		{
+			name:     "gauge",
+			payload:  `[{"values":[42.5],"dstypes":["gauge"],"dsnames":["value"],"time":1415062577.5,"plugin":"load","type":"load"}]`,
+			wantType: metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			wantPoint: &metricspb.Point{
+				Timestamp: &timestamp.Timestamp{Seconds: 1415062577, Nanos: 500000000},
+				Value:     &metricspb.Point_DoubleValue{DoubleValue: 42.5},
+			},
+		},
+		{
+			name:      "derive",
+			payload:   `[{"values":[-15],"dstypes":["derive"],"dsnames":["value"],"time":1415062577,"plugin":"cpu","type":"cpu"}]`,
+			wantType:  metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			wantStart: &timestamp.Timestamp{Seconds: 1415062577},
+			wantPoint: &metricspb.Point{
+				Timestamp: &timestamp.Timestamp{Seconds: 1415062577},
+				Value:     &metricspb.Point_Int64Value{Int64Value: -15},
+			},
+		},
+		{
+			name:      "counter",
+			payload:   `[{"values":[18446744073709551615],"dstypes":["counter"],"dsnames":["value"],"time":1415062577,"plugin":"interface","type":"if_packets"}]`,
+			wantType:  metricspb.MetricDescriptor_CUMULATIVE_INT64,
+			wantStart: &timestamp.Timestamp{Seconds: 1415062577},
+			wantPoint: &metricspb.Point{
+				Timestamp: &timestamp.Timestamp{Seconds: 1415062577},
+				Value:     &metricspb.Point_Int64Value{Int64Value: 9223372036854775807},
+			},
+		},
+		{
+			name:     "absolute",
+			payload:  `[{"values":[7],"dstypes":["ABSOLUTE"],"dsnames":["value"],"time":1415062577,"plugin":"statsd","type":"count"}]`,
+			wantType: metricspb.MetricDescriptor_GAUGE_INT64,
+			wantPoint: &metricspb.Point{
+				Timestamp: &timestamp.Timestamp{Seconds: 1415062577},
+				Value:     &metricspb.Point_Int64Value{Int64Value: 7},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := parsePayload([]byte(tt.payload))
+			require.NoError(t, err)
+			mds, dropped, err := recordsToMetricsData(records, newCumulativeStarts())
+			require.NoError(t, err)
+			assert.Equal(t, 0, dropped)
+			require.Len(t, mds, 1)
+			require.Len(t, mds[0].Metrics, 1)
+			metric := mds[0].Metrics[0]
+			assert.Equal(t, tt.wantType, metric.MetricDescriptor.Type)
+			assert.Equal(t, tt.wantStart, metric.Timeseries[0].StartTimestamp)
+			assert.Equal(t, []*metricspb.Point{tt.wantPoint}, metric.Timeseries[0].Points)
+		})
+	}
+}
+
+func TestCounterStartTimestamp(t *testing.T) {
+	starts := newCumulativeStarts()
+	startOf := func(payload string) *timestamp.Timestamp {
+		records, err := parsePayload([]byte(payload))
+		require.NoError(t, err)
+		mds, _, err := recordsToMetricsData(records, starts)
+		require.NoError(t, err)
+		require.Len(t, mds, 1)
+		require.Len(t, mds[0].Metrics, 1)
+		return mds[0].Metrics[0].Timeseries[0].StartTimestamp
+	}
+	const format = `[{"values":[%d],"dstypes":["counter"],"dsnames":["value"],"time":%d,"host":"%s","plugin":"interface","plugin_instance":"eth0","type":"if_packets"}]`
+
+	// The series starts when it is first received.
+	assert.Equal(t, &timestamp.Timestamp{Seconds: 1415062577}, startOf(fmt.Sprintf(format, 100, 1415062577, "leeloo")))
+	assert.Equal(t, &timestamp.Timestamp{Seconds: 1415062577}, startOf(fmt.Sprintf(format, 150, 1415062587, "leeloo")))
+
+	// Other hosts have their own series.
+	assert.Equal(t, &timestamp.Timestamp{Seconds: 1415062590}, startOf(fmt.Sprintf(format, 1000, 1415062590, "korben")))
+
+	// The counter was reset, the series restarts.
+	assert.Equal(t, &timestamp.Timestamp{Seconds: 1415062597}, startOf(fmt.Sprintf(format, 20, 1415062597, "leeloo")))
+	assert.Equal(t, &timestamp.Timestamp{Seconds: 1415062597}, startOf(fmt.Sprintf(format, 20, 1415062607, "leeloo")))
+}
+
+func TestCumulativeStartsEviction(t *testing.T) {
+	now := time.Unix(1415062577, 0)
+	starts := newCumulativeStarts()
+	starts.now = func() time.Time { return now }
+
+	first := &timestamp.Timestamp{Seconds: 1}
+	assert.Equal(t, first, starts.startOf("stale", first, 10))
+	assert.Equal(t, first, starts.startOf("active", first, 10))
+
+	now = now.Add(cumulativeStaleAfter / 2)
+	assert.Equal(t, first, starts.startOf("active", &timestamp.Timestamp{Seconds: 2}, 20))
+
+	// The sweep forgets the series without points since cumulativeStaleAfter.
+	now = now.Add(cumulativeStaleAfter / 2)
+	assert.Equal(t, first, starts.startOf("active", &timestamp.Timestamp{Seconds: 3}, 30))
+	assert.Len(t, starts.series, 1)
+	restart := &timestamp.Timestamp{Seconds: 4}
+	assert.Equal(t, restart, starts.startOf("stale", restart, 40))
+}
+
+func TestToTimestamp(t *testing.T) {
+	assert.Nil(t, toTimestamp(0))
+	assert.Equal(t, &timestamp.Timestamp{Seconds: 1415062577, Nanos: 250000000}, toTimestamp(1415062577.25))
+	// The fraction rounds up to a whole second, the nanos must stay below 1e9.
+	assert.Equal(t, &timestamp.Timestamp{Seconds: 2}, toTimestamp(1.9999999996))
+}
+
+func TestNullGaugeIsDropped(t *testing.T) {
+	records, err := parsePayload([]byte(`[{"values":[null,1],"dstypes":["gauge","gauge"],"dsnames":["rx","tx"],"plugin":"p","type":"t"}]`))
+	require.NoError(t, err)
+	mds, dropped, err := recordsToMetricsData(records, newCumulativeStarts())
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	require.Len(t, mds[0].Metrics, 1)
+	assert.Equal(t, "p.t.tx", mds[0].Metrics[0].MetricDescriptor.Name)
+}
+
+func TestNameMapping(t *testing.T) {
+	payload := `[
+		{"values":[197141504,175136768],"dstypes":["derive","derive"],"dsnames":["read","write"],"time":1251533299,
+		 "interval":10,"host":"leeloo","plugin":"disk","plugin_instance":"sda","type":"disk_octets","type_instance":""},
+		{"values":[0.5],"dstypes":["gauge"],"dsnames":["value"],"time":1251533299,
+		 "interval":10,"host":"korben","plugin":"cpu","plugin_instance":"0","type":"percent","type_instance":"idle"}
+	]`
+	records, err := parsePayload([]byte(payload))
+	require.NoError(t, err)
+	mds, _, err := recordsToMetricsData(records, newCumulativeStarts())
+	require.NoError(t, err)
+	require.Len(t, mds, 2)
+
+	assert.Equal(t, "leeloo", mds[0].Node.Identifier.HostName)
+	require.Len(t, mds[0].Metrics, 2)
+	assert.Equal(t, "disk.disk_octets.read", mds[0].Metrics[0].MetricDescriptor.Name)
+	assert.Equal(t, "disk.disk_octets.write", mds[0].Metrics[1].MetricDescriptor.Name)
+	assert.Equal(t, []*metricspb.LabelKey{{Key: "plugin_instance"}, {Key: "type_instance"}},
+		mds[0].Metrics[0].MetricDescriptor.LabelKeys)
+	assert.Equal(t, []*metricspb.LabelValue{{Value: "sda", HasValue: true}, {}},
+		mds[0].Metrics[0].Timeseries[0].LabelValues)
+
+	assert.Equal(t, "korben", mds[1].Node.Identifier.HostName)
+	require.Len(t, mds[1].Metrics, 1)
+	assert.Equal(t, "cpu.percent", mds[1].Metrics[0].MetricDescriptor.Name)
+	assert.Equal(t, []*metricspb.LabelValue{{Value: "0", HasValue: true}, {Value: "idle", HasValue: true}},
+		mds[1].Metrics[0].Timeseries[0].LabelValues)
+}
+
+func TestMalformedPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"not_json", `values=1`},
+		{"not_array", `{"values":[1]}`},
+		{"empty", `[]`},
+		{"null_record", `[null]`},
+		{"no_plugin", `[{"values":[1],"dstypes":["gauge"],"dsnames":["value"],"type":"t"}]`},
+		{"no_type", `[{"values":[1],"dstypes":["gauge"],"dsnames":["value"],"plugin":"p"}]`},
+		{"length_mismatch", `[{"values":[1,2],"dstypes":["gauge"],"dsnames":["value"],"plugin":"p","type":"t"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePayload([]byte(tt.payload))
+			assert.Error(t, err)
+		})
+	}
+
+	conversionErrors := []string{
+		`[{"values":[1],"dstypes":["histogram"],"dsnames":["value"],"plugin":"p","type":"t"}]`,
+		`[{"values":[1.5],"dstypes":["derive"],"dsnames":["value"],"plugin":"p","type":"t"}]`,
+		`[{"values":[-1],"dstypes":["counter"],"dsnames":["value"],"plugin":"p","type":"t"}]`,
+	}
+	for _, payload := range conversionErrors {
+		records, err := parsePayload([]byte(payload))
+		require.NoError(t, err)
+		_, _, err = recordsToMetricsData(records, newCumulativeStarts())
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectdreceiver
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/receiver"
+)
+
+// Receiver is the type used to handle metrics sent by the collectd write_http plugin.
+type Receiver struct {
+	// mu protects the fields of this struct
+	mu sync.Mutex
+
+	name         string
+	addr         string
+	logger       *zap.Logger
+	nextConsumer consumer.MetricsConsumer
+	starts       *cumulativeStarts
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	server    *http.Server
+}
+
+var _ receiver.MetricsReceiver = (*Receiver)(nil)
+var _ http.Handler = (*Receiver)(nil)
+
+// New creates a new collectdreceiver.Receiver reference.
+func New(logger *zap.Logger, name, address string, nextConsumer consumer.MetricsConsumer) (*Receiver, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	return &Receiver{
+		name:         name,
+		addr:         address,
+		logger:       logger,
+		nextConsumer: nextConsumer,
+		starts:       newCumulativeStarts(),
+	}, nil
+}
+
+const metricsSource string = "collectd"
+
+// maxPayloadSize is the size above which the payloads are rejected, write_http
+// sends at most its BufferSize, a few KiB by default.
+const maxPayloadSize = 10 << 20
+
+// MetricsSource returns the name of the metrics data source.
+func (cr *Receiver) MetricsSource() string {
+	return metricsSource
+}
+
+// StartMetricsReception spins up the receiver's HTTP server and makes the receiver start its processing.
+func (cr *Receiver) StartMetricsReception(host receiver.Host) error {
+	if host == nil {
+		return errors.New("nil host")
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	var err = oterr.ErrAlreadyStarted
+
+	cr.startOnce.Do(func() {
+		ln, lerr := net.Listen("tcp", cr.addr)
+		if lerr != nil {
+			err = lerr
+			return
+		}
+
+		server := &http.Server{Handler: cr}
+		cr.server = server
+		go func() {
+			if serr := server.Serve(ln); serr != http.ErrServerClosed {
+				host.ReportFatalError(serr)
+			}
+		}()
+
+		err = nil
+	})
+
+	return err
+}
+
+// StopMetricsReception tells the receiver that should stop reception,
+// giving it a chance to perform any necessary clean-up and shutting down
+// its HTTP server.
+func (cr *Receiver) StopMetricsReception() error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	var err = oterr.ErrAlreadyStopped
+	cr.stopOnce.Do(func() {
+		err = nil
+		if cr.server != nil {
+			err = cr.server.Close()
+		}
+	})
+	return err
+}
+
+// ServeHTTP receives the JSON payloads of the write_http plugin, converts them
+// and sends them along to the nextConsumer.
+func (cr *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := observability.ContextWithReceiverName(r.Context(), cr.name)
+
+	blob, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	_ = r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := parsePayload(blob)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mds, dropped, err := recordsToMetricsData(records, cr.starts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	received := 0
+	for _, md := range mds {
+		if cerr := cr.nextConsumer.ConsumeMetricsData(ctx, md); cerr != nil {
+			cr.logger.Warn("Failed to consume collectd metrics", zap.String("receiver", cr.name), zap.Error(cerr))
+			err = cerr
+		}
+		received += len(md.Metrics)
+	}
+	observability.RecordMetricsForMetricsReceiver(ctx, received, dropped)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
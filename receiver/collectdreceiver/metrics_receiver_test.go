@@ -0,0 +1,89 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collectdreceiver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/testutils"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestReceiverEndToEnd(t *testing.T) {
+	sink := new(exportertest.SinkMetricsExporter)
+	addr := testutils.GetAvailableLocalAddress(t)
+	cr, err := New(zap.NewNop(), "collectd", addr, sink)
+	require.NoError(t, err)
+
+	require.NoError(t, cr.StartMetricsReception(receivertest.NewMockHost()))
+	defer cr.StopMetricsReception()
+
+	payload := `[{"values":[1],"dstypes":["gauge"],"dsnames":["value"],"time":1,"host":"h","plugin":"p","type":"t"}]`
+	resp, err := http.Post("http://"+addr+"/", "application/json", strings.NewReader(payload))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	got := sink.AllMetrics()
+	require.Len(t, got, 1)
+	assert.Equal(t, "p.t", got[0].Metrics[0].MetricDescriptor.Name)
+}
+
+func TestServeHTTPStatusCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		payload  string
+		wantCode int
+	}{
+		{"ok", http.MethodPost, `[{"values":[1],"dstypes":["gauge"],"dsnames":["value"],"plugin":"p","type":"t"}]`, http.StatusOK},
+		{"malformed", http.MethodPost, `[{"values":[1]`, http.StatusBadRequest},
+		{"invalid", http.MethodPost, `[{"values":[1],"dstypes":["unknown"],"dsnames":["value"],"plugin":"p","type":"t"}]`, http.StatusBadRequest},
+		{"get", http.MethodGet, ``, http.StatusMethodNotAllowed},
+		{"too_large", http.MethodPost, `[` + strings.Repeat(" ", maxPayloadSize) + `]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := new(exportertest.SinkMetricsExporter)
+			cr, err := New(zap.NewNop(), "collectd", "", sink)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			cr.ServeHTTP(rec, httptest.NewRequest(tt.method, "/", strings.NewReader(tt.payload)))
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode != http.StatusOK {
+				assert.Empty(t, sink.AllMetrics())
+			}
+		})
+	}
+}
+
+func TestServeHTTPConsumerError(t *testing.T) {
+	cr, err := New(zap.NewNop(), "collectd", "", exportertest.NewNopMetricsExporter(exportertest.WithReturnError(assert.AnError)))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	payload := `[{"values":[1],"dstypes":["gauge"],"dsnames":["value"],"plugin":"p","type":"t"}]`
+	cr.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload)))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -51,6 +51,20 @@ type Factory interface {
 		consumer consumer.MetricsConsumer) (MetricsReceiver, error)
 }
 
+// DataFactory is implemented by the factories of the receivers getting traces
+// and metrics in the same stream. The service creates such a receiver once with
+// CreateDataReceiver, instead of CreateTraceReceiver and CreateMetricsReceiver,
+// whatever the data types of the pipelines it is attached to.
+type DataFactory interface {
+	Factory
+
+	// CreateDataReceiver creates a receiver based on this config sending the
+	// traces and the metrics it receives to the given consumer, which routes each
+	// signal to the pipelines of its data type.
+	CreateDataReceiver(ctx context.Context, logger *zap.Logger, cfg configmodels.Receiver,
+		nextConsumer consumer.DataConsumer) (DataReceiver, error)
+}
+
 // CustomUnmarshaler is a function that un-marshals a viper data into a config struct
 // in a custom way.
 type CustomUnmarshaler func(v *viper.Viper, viperKey string, intoCfg interface{}) error
@@ -15,6 +15,8 @@
 package jaegerreceiver
 
 import (
+	"fmt"
+
 	"github.com/open-telemetry/opentelemetry-service/receiver"
 )
 
@@ -56,3 +58,16 @@ func (rs *Config) IsEnabled() bool {
 	// All protocols are disabled so the entire receiver can be disabled.
 	return false
 }
+
+// Validate checks the TLS settings of every protocol.
+func (rs *Config) Validate() error {
+	for name, p := range rs.Protocols {
+		if p == nil {
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("protocol %q: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// slowConsumer takes a fixed time to consume every batch.
+type slowConsumer struct {
+	exportertest.SinkMetricsExporter
+	latency time.Duration
+}
+
+func (sc *slowConsumer) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	time.Sleep(sc.latency)
+	return sc.SinkMetricsExporter.ConsumeMetricsData(ctx, md)
+}
+
+func TestBackpressureSlowConsumer(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	target, host := newCountingTarget(t)
+	defer target.srv.Close()
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: slow
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings:        configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/backpressure"},
+		PrometheusConfig:        pCfg,
+		BackpressureThreshold:   50 * time.Millisecond,
+		BackpressureSlowCommits: 2,
+		BackpressureMaxDelay:    800 * time.Millisecond,
+	}
+	precv := newPrometheusReceiver(logger, cfg, &slowConsumer{latency: 60 * time.Millisecond})
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	defer precv.StopMetricsReception()
+
+	// The pause doubles on every scrape the consumer is still slow, up to the max delay.
+	require.Eventually(t, func() bool {
+		return observabilitytest.CheckValueViewReceiverScrapeBackoff(cfg.Name(), 800) == nil
+	}, 15*time.Second, 50*time.Millisecond)
+
+	// Without backpressure the target would be scraped about 20 times in 2s, while scraped at most once per pause.
+	scrapes := target.count()
+	time.Sleep(2 * time.Second)
+	assert.True(t, target.count()-scrapes <= 4, "scrapes did not back off: %d scrapes in 2s", target.count()-scrapes)
+}
@@ -0,0 +1,109 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// TestBearerTokenFileRotation checks that a rotated bearer token file, e.g. a projected service account token, is
+// used from the next scrape on without restarting the receiver.
+func TestBearerTokenFileRotation(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs map[string]JobSettings
+	}{
+		{name: "plain"},
+		// The job scrapes through the local proxy adding its headers.
+		{name: "custom_headers", jobs: map[string]JobSettings{
+			"token_job": {Headers: map[string]string{"x-scope-orgid": "tenant"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "bearer_token")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			tokenFile := filepath.Join(dir, "token")
+			require.NoError(t, ioutil.WriteFile(tokenFile, []byte("first-token\n"), 0600))
+
+			authorizations := make(chan string, 100)
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				select {
+				case authorizations <- req.Header.Get("Authorization"):
+				default:
+				}
+				_, _ = rw.Write([]byte("# TYPE test_gauge gauge\ntest_gauge 1\n"))
+			}))
+			defer srv.Close()
+			u, err := url.Parse(srv.URL)
+			require.NoError(t, err)
+
+			pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: token_job
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    bearer_token_file: ` + tokenFile + `
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+			require.NoError(t, err)
+
+			cfg := &Config{PrometheusConfig: pCfg, Jobs: tt.jobs}
+			require.NoError(t, validateJobSettings(cfg))
+			precv := newPrometheusReceiver(logger, cfg, new(exportertest.SinkMetricsExporter))
+			require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+			defer precv.StopMetricsReception()
+
+			waitForAuthorization(t, authorizations, "Bearer first-token")
+
+			// Rotate the token as the kubelet does, by replacing the file.
+			rotated := filepath.Join(dir, "token.new")
+			require.NoError(t, ioutil.WriteFile(rotated, []byte("second-token\n"), 0600))
+			require.NoError(t, os.Rename(rotated, tokenFile))
+
+			waitForAuthorization(t, authorizations, "Bearer second-token")
+		})
+	}
+}
+
+// waitForAuthorization waits for a scrape request with the given Authorization header.
+func waitForAuthorization(t *testing.T, authorizations <-chan string, want string) {
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case got := <-authorizations:
+			if got == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no scrape request with the Authorization header %q", want)
+		}
+	}
+}
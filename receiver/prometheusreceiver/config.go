@@ -29,4 +29,121 @@ type Config struct {
 	BufferPeriod                  time.Duration       `mapstructure:"buffer_period"`
 	BufferCount                   int                 `mapstructure:"buffer_count"`
 	IncludeFilter                 map[string][]string `mapstructure:"include_filter"`
+	// EmptyScrapePolicy defines how a successful scrape that returned no samples is handled: "success" treats it
+	// as a target without metrics yet, "warn" logs a warning for it. Empty scrapes are counted in both cases.
+	EmptyScrapePolicy string `mapstructure:"empty_scrape_policy"`
+	// TimestampPolicy defines the timestamp of the scraped samples: "honor" keeps the timestamps exposed by the
+	// targets, as prometheus does, "override" replaces them with the time the collector processes the scrape.
+	TimestampPolicy string `mapstructure:"timestamp_policy"`
+	// ExtremeQuantilePolicy defines how the extreme quantiles of the summaries, quantile="0", quantile="1" and the
+	// ones beyond, are converted: "keep" converts them as any other quantile, "drop" drops them and "clamp" moves
+	// them to 0.001 and 0.999, for the backends rejecting them. The count and the sum are kept in any case.
+	ExtremeQuantilePolicy string `mapstructure:"extreme_quantile_policy"`
+	// RepairHistograms repairs the histograms missing their _count or _sum rather than dropping them: the count is
+	// synthesized from the +Inf bucket and the sum is estimated from the buckets. The repairs are counted.
+	RepairHistograms bool `mapstructure:"repair_histograms"`
+	// NaNBucketPolicy defines how the histograms with a bucket whose value is NaN are converted: "keep" converts them
+	// as is, "drop" drops them and "previous" renormalizes them, a NaN bucket taking the cumulative value of the
+	// previous bucket. The histograms with a NaN bucket are counted.
+	NaNBucketPolicy string `mapstructure:"nan_bucket_policy"`
+	// MaxTargets is the maximum number of targets scraped per job, the discovered targets beyond it are dropped,
+	// keeping the first ones sorted by address. 0 means no limit.
+	MaxTargets int `mapstructure:"max_targets"`
+	// DefaultScrapeInterval is the scrape interval of the jobs without one, unless the prometheus config sets a
+	// global scrape interval. As for the prometheus global one, the scrape timeout of these jobs defaults to 10s,
+	// capped at their scrape interval. 0 keeps the prometheus default.
+	DefaultScrapeInterval time.Duration `mapstructure:"default_scrape_interval"`
+	// MinScrapeInterval is the smallest DefaultScrapeInterval allowed, it prevents scraping every target too
+	// often by mistake.
+	MinScrapeInterval time.Duration `mapstructure:"min_scrape_interval"`
+	// CommitBatchWindow is how long the data scraped from all the targets is buffered before being sent downstream
+	// merged in a single batch. 0 sends the data of every scrape on its own.
+	CommitBatchWindow time.Duration `mapstructure:"commit_batch_window"`
+	// CommitBatchMaxSize is the number of buffered metrics that triggers sending them before the end of the window.
+	// 0 means 1000.
+	CommitBatchMaxSize int `mapstructure:"commit_batch_max_size"`
+	// BackpressureThreshold is how long the consumer can take to consume the data of a scrape before it is slow.
+	// Once the consumer is slow for BackpressureSlowCommits scrapes in a row, the scrapes are paused, starting with
+	// a pause of the threshold which doubles while the consumer stays slow, up to BackpressureMaxDelay. The scrapes
+	// resume at their interval as soon as the consumer is fast again. 0 disables the backpressure.
+	BackpressureThreshold time.Duration `mapstructure:"backpressure_threshold"`
+	// BackpressureSlowCommits is the number of slow scrapes in a row after which the scrapes are paused. 0 means 3.
+	BackpressureSlowCommits int `mapstructure:"backpressure_slow_commits"`
+	// BackpressureMaxDelay caps the pause of the scrapes. 0 means 1m.
+	BackpressureMaxDelay time.Duration `mapstructure:"backpressure_max_delay"`
+	// MaxConcurrentScrapes caps the number of scrapes whose data is parsed and converted concurrently, trading
+	// latency for a lower CPU usage when scraping many targets. The scrape requests themselves are not capped, a
+	// scrape waits for a free slot once its page is fetched. 0 means no limit.
+	MaxConcurrentScrapes int `mapstructure:"max_concurrent_scrapes"`
+	// StreamingBatchSize streams the conversion of the scrapes: the metric families of a page are passed down the
+	// pipeline as they are completed, every StreamingBatchSize series, instead of once the whole page is converted,
+	// bounding the memory used by the conversion. The scrape loop still reads the whole page into memory. The families
+	// passed before the scrape is rolled back, e.g. on a parse error, stay downstream. The jobs with a sample_limit are
+	// not streamed. 0 disables streaming.
+	StreamingBatchSize int `mapstructure:"streaming_batch_size"`
+	// DiscoveryConcurrency is the number of discovery managers the scrape jobs are spread over, the service
+	// discovery configs of the managers being applied concurrently so that the startup with many service discovery
+	// configs is not serial. 0 means 1.
+	DiscoveryConcurrency int `mapstructure:"discovery_concurrency"`
+	// EvictFailingTargetsAfter is how long a target can fail every scrape before it is evicted from the scrapes, so
+	// that dead targets stop using scrape resources. 0 disables the eviction.
+	EvictFailingTargetsAfter time.Duration `mapstructure:"evict_failing_targets_after"`
+	// EvictedTargetsCooldown is how long an evicted target is kept out of the scrapes, it is scraped again
+	// afterwards if the service discovery still lists it. 0 means 5m.
+	EvictedTargetsCooldown time.Duration `mapstructure:"evicted_targets_cooldown"`
+	// TargetLabelsFile is the path of a JSON file, maintained by an external agent, mapping target addresses to
+	// extra labels added to the samples of these targets, e.g. {"10.0.0.1:8080": {"team": "payments"}}. The
+	// labels exposed by the targets take precedence. A missing file means no extra labels.
+	TargetLabelsFile string `mapstructure:"target_labels_file"`
+	// TargetLabelsRefreshInterval is how often the target labels file is read again. 0 means 5s.
+	TargetLabelsRefreshInterval time.Duration `mapstructure:"target_labels_refresh_interval"`
+	// EmitConfigHash records the hash of the effective prometheus config, the config without the disabled jobs, as
+	// the otelsvc/receiver/config_hash metric, for fleet auditing. The secrets are not part of the hash.
+	EmitConfigHash bool `mapstructure:"emit_config_hash"`
+	// LenientParsing commits the samples parsed before the invalid content of a scrape response, e.g. trailing
+	// garbage, instead of discarding the whole scrape. The scrape is still reported as failed. The jobs with a
+	// sample_limit are always parsed strictly. Defaults to strict parsing.
+	LenientParsing bool `mapstructure:"lenient_parsing"`
+	// RecordScrapeSizes records histograms of the size of the scrapes and of the number of series of the targets, per
+	// job, as the otelsvc/receiver/scrape_size and otelsvc/receiver/scrape_series metrics, for capacity planning.
+	RecordScrapeSizes bool `mapstructure:"record_scrape_sizes"`
+	// RecordScrapePhases records histograms of the duration of the connect, TLS handshake, first byte and body read
+	// phases of the scrape requests, per job, as the otelsvc/receiver/scrape_phase metric, to tell slow TLS from
+	// slow targets. The jobs then scrape through a local proxy, which makes the requests.
+	RecordScrapePhases bool `mapstructure:"record_scrape_phases"`
+	// EmitScrapeSuccess emits a scrape_success gauge for every scrape of the targets, 1 if the scrape fully
+	// succeeded: the target responded, its response was parsed without error, within the sample limit, and every
+	// series was converted. Unlike up, the gauge is passed down the pipeline with the metrics of the target.
+	EmitScrapeSuccess bool `mapstructure:"emit_scrape_success"`
+	// MaxAdjustedSeries caps the number of series, across all the targets, whose first point is kept to adjust the
+	// start time of the cumulative metrics and detect their resets. The least recently scraped series beyond it are
+	// evicted, bounding the memory used whatever the number of series scraped between two gcs of the idle series;
+	// the next point of an evicted series is handled as its first one. The evictions are counted. 0 means no limit.
+	MaxAdjustedSeries int `mapstructure:"max_adjusted_series"`
+	// EmitScope attributes the converted metrics to an instrumentation scope, synthesized from the job since
+	// prometheus has none. The scope of a job is set by its settings, it defaults to a generic scope named after
+	// the receiver.
+	EmitScope bool `mapstructure:"emit_scope"`
+	// Jobs holds receiver specific settings for the scrape jobs, keyed by job name.
+	Jobs map[string]JobSettings `mapstructure:"jobs"`
+}
+
+// JobSettings defines receiver specific settings for a single scrape job. These
+// complement the prometheus scrape config of the job with the same name.
+type JobSettings struct {
+	// Headers are added to every scrape request of the job. Values are expanded
+	// using environment variables, e.g. "${TENANT_ID}", so secrets can be kept
+	// out of the configuration file. Headers set by the job authentication
+	// settings take precedence.
+	Headers map[string]string `mapstructure:"headers"`
+	// Disabled keeps the job configured but not scraped.
+	Disabled bool `mapstructure:"disabled"`
+	// ScopeName and ScopeVersion are the instrumentation scope of the metrics
+	// of the job when EmitScope is set. An empty name means the generic scope.
+	ScopeName    string `mapstructure:"scope_name"`
+	ScopeVersion string `mapstructure:"scope_version"`
+	// ServerName is the TLS server name (SNI) the targets of the job are scraped with, for targets sharing an address
+	// routed by SNI. It is the default of the server_name of the job tls_config, which takes precedence. Only the
+	// jobs using the https scheme can set it.
+	ServerName string `mapstructure:"server_name"`
 }
@@ -0,0 +1,50 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/prometheus/prometheus/config"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+)
+
+// configHash returns a hash of the given prometheus config. The config is
+// hashed as rendered in YAML, which renders the secrets as "<secret>", so the
+// hash does not depend on the secrets, nor reveals them.
+func configHash(promCfg *config.Config) string {
+	sum := sha256.Sum256([]byte(promCfg.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ConfigHash returns the hash of the effective prometheus config: the config
+// of the receiver without the disabled jobs. It changes whenever the config
+// applied to the scraper does.
+func (pr *Preceiver) ConfigHash() string {
+	return configHash(pr.effectiveConfig(pr.cfg.PrometheusConfig))
+}
+
+// recordConfigHash records the hash of the effective config when
+// EmitConfigHash is set. The hash is computed on the receiver config rather
+// than on the applied one, which references the local proxies adding the job
+// headers that differ on every start.
+func (pr *Preceiver) recordConfigHash() {
+	if !pr.cfg.EmitConfigHash {
+		return
+	}
+	observability.RecordConfigHashForMetricsReceiver(pr.ctx, configHash(pr.effectiveConfig(pr.cfg.PrometheusConfig)), true)
+}
@@ -0,0 +1,91 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"fmt"
+	"testing"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+const hashedJobs = `
+scrape_configs:
+  - job_name: app
+    bearer_token: %s
+    static_configs:
+      - targets: ["localhost:9777"]
+`
+
+func TestConfigHash(t *testing.T) {
+	load := func(yaml string) *promcfg.Config {
+		pCfg, err := promcfg.Load(yaml)
+		require.NoError(t, err)
+		return pCfg
+	}
+	base := load(fmt.Sprintf(hashedJobs, "s3cr3t-token"))
+
+	hash := configHash(base)
+	assert.Len(t, hash, 16)
+	assert.Equal(t, hash, configHash(load(fmt.Sprintf(hashedJobs, "s3cr3t-token"))))
+
+	// The secrets are not part of the hash.
+	assert.Equal(t, hash, configHash(load(fmt.Sprintf(hashedJobs, "rotated-token"))))
+
+	// Adding a job changes the hash.
+	withJob := load(fmt.Sprintf(hashedJobs, "s3cr3t-token") + `
+  - job_name: db
+    static_configs:
+      - targets: ["localhost:9778"]
+`)
+	assert.NotEqual(t, hash, configHash(withJob))
+}
+
+func TestConfigHashMetric(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	pCfg, err := promcfg.Load(fmt.Sprintf(hashedJobs, "s3cr3t-token") + `
+  - job_name: db
+    static_configs:
+      - targets: ["localhost:9778"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/hash"},
+		PrometheusConfig: pCfg,
+		EmitConfigHash:   true,
+	}
+	precv := newPrometheusReceiver(logger, cfg, new(exportertest.SinkMetricsExporter))
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	defer precv.StopMetricsReception()
+
+	hash := precv.ConfigHash()
+	require.NoError(t, observabilitytest.CheckValueViewReceiverConfigHash(cfg.Name(), hash, 1))
+
+	// Disabling a job changes the effective config.
+	disabledCfg := *cfg
+	disabledCfg.Jobs = map[string]JobSettings{"db": {Disabled: true}}
+	disabled := newPrometheusReceiver(logger, &disabledCfg, new(exportertest.SinkMetricsExporter))
+	assert.NotEqual(t, hash, disabled.ConfigHash())
+}
@@ -56,4 +56,39 @@ func TestLoadConfig(t *testing.T) {
 		"localhost:9778": {"http/client/roundtrip_latency"},
 	}
 	assert.Equal(t, r1.IncludeFilter, wantFilter)
+	assert.Equal(t, "warn", r1.EmptyScrapePolicy)
+	assert.Equal(t, "override", r1.TimestampPolicy)
+	assert.Equal(t, "drop", r1.ExtremeQuantilePolicy)
+	assert.True(t, r1.RepairHistograms)
+	assert.Equal(t, "previous", r1.NaNBucketPolicy)
+	assert.Equal(t, 100, r1.MaxTargets)
+	assert.Equal(t, 200000, r1.MaxAdjustedSeries)
+	assert.Equal(t, 30*time.Second, r1.DefaultScrapeInterval)
+	assert.Equal(t, 10*time.Second, r1.MinScrapeInterval)
+	assert.Equal(t, time.Second, r1.CommitBatchWindow)
+	assert.Equal(t, 500, r1.CommitBatchMaxSize)
+	assert.Equal(t, 2*time.Second, r1.BackpressureThreshold)
+	assert.Equal(t, 5, r1.BackpressureSlowCommits)
+	assert.Equal(t, 30*time.Second, r1.BackpressureMaxDelay)
+	assert.Equal(t, 4, r1.MaxConcurrentScrapes)
+	assert.Equal(t, 1000, r1.StreamingBatchSize)
+	assert.Equal(t, time.Hour, r1.EvictFailingTargetsAfter)
+	assert.Equal(t, 10*time.Minute, r1.EvictedTargetsCooldown)
+	assert.Equal(t, "/var/run/agent/targets.json", r1.TargetLabelsFile)
+	assert.Equal(t, 30*time.Second, r1.TargetLabelsRefreshInterval)
+	assert.True(t, r1.EmitScope)
+	assert.True(t, r1.EmitConfigHash)
+	assert.True(t, r1.LenientParsing)
+	assert.True(t, r1.RecordScrapeSizes)
+	assert.True(t, r1.RecordScrapePhases)
+	assert.True(t, r1.EmitScrapeSuccess)
+	// The job without a scrape interval inherits the default one.
+	assert.Equal(t, "noisy", r1.PrometheusConfig.ScrapeConfigs[1].JobName)
+	assert.Equal(t, 30*time.Second, time.Duration(r1.PrometheusConfig.ScrapeConfigs[1].ScrapeInterval))
+	assert.Equal(t, 10*time.Second, time.Duration(r1.PrometheusConfig.ScrapeConfigs[1].ScrapeTimeout))
+	assert.Equal(t, map[string]JobSettings{
+		"demo":   {Headers: map[string]string{"x-scope-orgid": "tenant1"}, ScopeName: "demo/app", ScopeVersion: "1.0.0"},
+		"noisy":  {Disabled: true},
+		"tenant": {ServerName: "tenant.metrics.internal"},
+	}, r1.Jobs)
 }
@@ -0,0 +1,108 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestEmptyScrape(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		policy   string
+		wantWarn bool
+	}{
+		{policy: "success", wantWarn: false},
+		{policy: "warn", wantWarn: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: empty
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+			require.NoError(t, err)
+
+			cfg := &Config{
+				ReceiverSettings:  configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/" + tt.policy},
+				PrometheusConfig:  pCfg,
+				EmptyScrapePolicy: tt.policy,
+			}
+			core, logs := observer.New(zapcore.InfoLevel)
+			sink := new(exportertest.SinkMetricsExporter)
+			precv := newPrometheusReceiver(zap.New(core), cfg, sink)
+			require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+
+			require.Eventually(t, func() bool { return emptyScrapes(cfg.Name()) >= 2 }, 10*time.Second, 50*time.Millisecond)
+			require.NoError(t, precv.StopMetricsReception())
+
+			// An empty scrape produces no series.
+			assert.Empty(t, sink.AllMetrics())
+			warnings := logs.FilterMessage("scrape succeeded but returned no samples").All()
+			if tt.wantWarn {
+				require.NotEmpty(t, warnings)
+				assert.Equal(t, "empty", warnings[0].ContextMap()["job"])
+			} else {
+				assert.Empty(t, warnings)
+			}
+		})
+	}
+}
+
+func emptyScrapes(receiverName string) int64 {
+	rows, err := view.RetrieveData(observability.ViewReceiverEmptyScrapes.Name)
+	if err != nil {
+		return 0
+	}
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == observability.TagKeyReceiver && tag.Value == receiverName {
+				return int64(row.Data.(*view.SumData).Value)
+			}
+		}
+	}
+	return 0
+}
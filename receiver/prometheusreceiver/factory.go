@@ -18,6 +18,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -27,6 +31,7 @@ import (
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
 	"github.com/open-telemetry/opentelemetry-service/consumer"
 	"github.com/open-telemetry/opentelemetry-service/receiver"
+	"github.com/open-telemetry/opentelemetry-service/receiver/prometheusreceiver/internal"
 )
 
 // This file implements config V2 for Prometheus receiver.
@@ -39,6 +44,8 @@ const (
 
 	// The key for Prometheus scraping configs.
 	prometheusConfigKey = "config"
+
+	defaultMinScrapeInterval = time.Second
 )
 
 var (
@@ -67,7 +74,7 @@ func CustomUnmarshalerFunc(v *viper.Viper, viperKey string, intoCfg interface{})
 	// Unmarshal our config values (using viper's mapstructure)
 	err := v.UnmarshalKey(viperKey, intoCfg)
 	if err != nil {
-		return fmt.Errorf("prometheus receiver failed to parse config: %s", err)
+		return fmt.Errorf("prometheus receiver failed to parse config: %s", redactSecretFields(err.Error()))
 	}
 
 	// Unmarshal prometheus's config values. Since prometheus uses `yaml` tags, so use `yaml`.
@@ -75,17 +82,23 @@ func CustomUnmarshalerFunc(v *viper.Viper, viperKey string, intoCfg interface{})
 	if vSub == nil || !vSub.IsSet(prometheusConfigKey) {
 		return nil
 	}
+	config := intoCfg.(*Config)
+	if err := validateDefaultScrapeInterval(config); err != nil {
+		return err
+	}
+
 	promCfgMap := vSub.Sub(prometheusConfigKey).AllSettings()
+	applyDefaultScrapeInterval(config, promCfgMap)
 	out, err := yaml.Marshal(promCfgMap)
 	if err != nil {
-		return fmt.Errorf("prometheus receiver failed to marshal config to yaml: %s", err)
+		return fmt.Errorf("prometheus receiver failed to marshal config to yaml: %s", redactSecretFields(err.Error()))
 	}
 
-	config := intoCfg.(*Config)
-
 	err = yaml.Unmarshal(out, &config.PrometheusConfig)
 	if err != nil {
-		return fmt.Errorf("prometheus receiver failed to unmarshal yaml to prometheus config: %s", err)
+		// The config is only partially loaded, mask what can be recognized as a secret in the error.
+		return fmt.Errorf("prometheus receiver failed to unmarshal yaml to prometheus config: %s",
+			newSecretRedactor(config).redact(err.Error()))
 	}
 	if len(config.PrometheusConfig.ScrapeConfigs) == 0 {
 		return errNilScrapeConfig
@@ -101,6 +114,11 @@ func (f *Factory) CreateDefaultConfig() configmodels.Receiver {
 			NameVal:  typeStr,
 			Endpoint: "localhost:9090",
 		},
+		EmptyScrapePolicy:     string(internal.EmptyScrapeSuccess),
+		TimestampPolicy:       string(internal.TimestampHonor),
+		ExtremeQuantilePolicy: string(internal.ExtremeQuantilesKeep),
+		NaNBucketPolicy:       string(internal.NaNBucketsKeep),
+		MinScrapeInterval:     defaultMinScrapeInterval,
 	}
 }
 
@@ -125,5 +143,130 @@ func (f *Factory) CreateMetricsReceiver(
 	if config.PrometheusConfig == nil || len(config.PrometheusConfig.ScrapeConfigs) == 0 {
 		return nil, errNilScrapeConfig
 	}
+	if err := validateJobSettings(config); err != nil {
+		return nil, err
+	}
+	if _, err := emptyScrapePolicy(config); err != nil {
+		return nil, err
+	}
+	if _, err := timestampPolicy(config); err != nil {
+		return nil, err
+	}
+	if _, err := extremeQuantilePolicy(config); err != nil {
+		return nil, err
+	}
+	if _, err := nanBucketPolicy(config); err != nil {
+		return nil, err
+	}
+	if err := validateDefaultScrapeInterval(config); err != nil {
+		return nil, err
+	}
+	if config.CommitBatchWindow < 0 || config.CommitBatchMaxSize < 0 {
+		return nil, errors.New("commit_batch_window and commit_batch_max_size must be positive")
+	}
+	if config.BackpressureThreshold < 0 || config.BackpressureSlowCommits < 0 || config.BackpressureMaxDelay < 0 {
+		return nil, errors.New("backpressure_threshold, backpressure_slow_commits and backpressure_max_delay must be positive")
+	}
+	if config.MaxTargets < 0 {
+		return nil, fmt.Errorf("max_targets must be positive, got %d", config.MaxTargets)
+	}
+	if config.MaxAdjustedSeries < 0 {
+		return nil, fmt.Errorf("max_adjusted_series must be positive, got %d", config.MaxAdjustedSeries)
+	}
+	if config.EvictFailingTargetsAfter < 0 || config.EvictedTargetsCooldown < 0 {
+		return nil, errors.New("evict_failing_targets_after and evicted_targets_cooldown must be positive")
+	}
+	if config.TargetLabelsRefreshInterval < 0 {
+		return nil, fmt.Errorf("target_labels_refresh_interval must be positive, got %v", config.TargetLabelsRefreshInterval)
+	}
+	if config.MaxConcurrentScrapes < 0 {
+		return nil, fmt.Errorf("max_concurrent_scrapes must be positive, got %d", config.MaxConcurrentScrapes)
+	}
+	if config.StreamingBatchSize < 0 {
+		return nil, fmt.Errorf("streaming_batch_size must be positive, got %d", config.StreamingBatchSize)
+	}
+	if config.DiscoveryConcurrency < 0 {
+		return nil, fmt.Errorf("discovery_concurrency must be positive, got %d", config.DiscoveryConcurrency)
+	}
 	return newPrometheusReceiver(logger, config, consumer), nil
 }
+
+// emptyScrapePolicy returns the policy for empty scrapes set in the given config, defaulting to success.
+func emptyScrapePolicy(cfg *Config) (internal.EmptyScrapePolicy, error) {
+	switch policy := internal.EmptyScrapePolicy(strings.ToLower(cfg.EmptyScrapePolicy)); policy {
+	case "":
+		return internal.EmptyScrapeSuccess, nil
+	case internal.EmptyScrapeSuccess, internal.EmptyScrapeWarn:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown empty_scrape_policy %q, must be either %q or %q",
+		cfg.EmptyScrapePolicy, internal.EmptyScrapeSuccess, internal.EmptyScrapeWarn)
+}
+
+// timestampPolicy returns the timestamp policy set in the given config, defaulting to honor.
+func timestampPolicy(cfg *Config) (internal.TimestampPolicy, error) {
+	switch policy := internal.TimestampPolicy(strings.ToLower(cfg.TimestampPolicy)); policy {
+	case "":
+		return internal.TimestampHonor, nil
+	case internal.TimestampHonor, internal.TimestampOverride:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown timestamp_policy %q, must be either %q or %q",
+		cfg.TimestampPolicy, internal.TimestampHonor, internal.TimestampOverride)
+}
+
+// extremeQuantilePolicy returns the policy for the extreme quantiles of the summaries set in the given config,
+// defaulting to keep.
+func extremeQuantilePolicy(cfg *Config) (internal.ExtremeQuantilePolicy, error) {
+	switch policy := internal.ExtremeQuantilePolicy(strings.ToLower(cfg.ExtremeQuantilePolicy)); policy {
+	case "":
+		return internal.ExtremeQuantilesKeep, nil
+	case internal.ExtremeQuantilesKeep, internal.ExtremeQuantilesDrop, internal.ExtremeQuantilesClamp:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown extreme_quantile_policy %q, must be either %q, %q or %q",
+		cfg.ExtremeQuantilePolicy, internal.ExtremeQuantilesKeep, internal.ExtremeQuantilesDrop,
+		internal.ExtremeQuantilesClamp)
+}
+
+// nanBucketPolicy returns the policy for the histograms with a NaN bucket set in the given config, defaulting to
+// keep.
+func nanBucketPolicy(cfg *Config) (internal.NaNBucketPolicy, error) {
+	switch policy := internal.NaNBucketPolicy(strings.ToLower(cfg.NaNBucketPolicy)); policy {
+	case "":
+		return internal.NaNBucketsKeep, nil
+	case internal.NaNBucketsKeep, internal.NaNBucketsDrop, internal.NaNBucketsPrevious:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown nan_bucket_policy %q, must be either %q, %q or %q",
+		cfg.NaNBucketPolicy, internal.NaNBucketsKeep, internal.NaNBucketsDrop, internal.NaNBucketsPrevious)
+}
+
+// validateDefaultScrapeInterval checks that the default scrape interval, if set, is not below the configured floor.
+func validateDefaultScrapeInterval(cfg *Config) error {
+	if cfg.DefaultScrapeInterval < 0 {
+		return fmt.Errorf("default_scrape_interval must be positive, got %v", cfg.DefaultScrapeInterval)
+	}
+	if cfg.DefaultScrapeInterval > 0 && cfg.DefaultScrapeInterval < cfg.MinScrapeInterval {
+		return fmt.Errorf("default_scrape_interval %v is smaller than min_scrape_interval %v",
+			cfg.DefaultScrapeInterval, cfg.MinScrapeInterval)
+	}
+	return nil
+}
+
+// applyDefaultScrapeInterval sets the default scrape interval as the global scrape interval of the given prometheus
+// config, unless it already has one. The prometheus config loading then applies it to the jobs without a scrape
+// interval, and infers the scrape timeout of both the global config and these jobs from it.
+func applyDefaultScrapeInterval(cfg *Config, promCfgMap map[string]interface{}) {
+	if cfg.DefaultScrapeInterval == 0 {
+		return
+	}
+	global, _ := promCfgMap["global"].(map[string]interface{})
+	if global == nil {
+		global = make(map[string]interface{})
+		promCfgMap["global"] = global
+	}
+	if _, ok := global["scrape_interval"]; !ok {
+		global["scrape_interval"] = model.Duration(cfg.DefaultScrapeInterval).String()
+	}
+}
@@ -16,9 +16,14 @@ package prometheusreceiver
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/open-telemetry/opentelemetry-service/config/configerror"
@@ -44,3 +49,339 @@ func TestCreateReceiver(t *testing.T) {
 	assert.Equal(t, err, errNilScrapeConfig)
 	assert.Nil(t, mReceiver)
 }
+
+func TestCreateReceiverInvalidEmptyScrapePolicy(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.EmptyScrapePolicy = "ignore"
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+
+	cfg.EmptyScrapePolicy = "WARN"
+	mReceiver, err = factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, mReceiver)
+}
+
+func TestCreateReceiverInvalidTimestampPolicy(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.TimestampPolicy = "collector"
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+
+	cfg.TimestampPolicy = "Override"
+	mReceiver, err = factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, mReceiver)
+}
+
+func TestCreateReceiverInvalidExtremeQuantilePolicy(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.ExtremeQuantilePolicy = "round"
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+
+	cfg.ExtremeQuantilePolicy = "Clamp"
+	mReceiver, err = factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, mReceiver)
+}
+
+func TestCreateReceiverInvalidNaNBucketPolicy(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.NaNBucketPolicy = "zero"
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+
+	cfg.NaNBucketPolicy = "Previous"
+	mReceiver, err = factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, mReceiver)
+}
+
+func TestCreateReceiverNegativeMaxTargets(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.MaxTargets = -1
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+}
+
+func TestCreateReceiverNegativeMaxAdjustedSeries(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.MaxAdjustedSeries = -1
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+}
+
+func TestCreateReceiverNegativeMaxConcurrentScrapes(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.MaxConcurrentScrapes = -1
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+}
+
+func TestCreateReceiverNegativeStreamingBatchSize(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.StreamingBatchSize = -1
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+}
+
+func TestCreateReceiverNegativeDiscoveryConcurrency(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.DiscoveryConcurrency = -1
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+}
+
+func TestCreateReceiverNegativeTargetLabelsRefreshInterval(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.TargetLabelsFile = "targets.json"
+	cfg.TargetLabelsRefreshInterval = -time.Second
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+}
+
+func TestCreateReceiverNegativeTargetEviction(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	for _, tweak := range []func(cfg *Config){
+		func(cfg *Config) { cfg.EvictFailingTargetsAfter = -time.Minute },
+		func(cfg *Config) { cfg.EvictedTargetsCooldown = -time.Minute },
+	} {
+		cfg := factory.CreateDefaultConfig().(*Config)
+		cfg.PrometheusConfig = pCfg
+		tweak(cfg)
+
+		mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+		assert.Error(t, err)
+		assert.Nil(t, mReceiver)
+	}
+}
+
+func TestCreateReceiverNegativeCommitBatch(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.CommitBatchWindow = -time.Second
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+}
+
+func TestCreateReceiverNegativeBackpressure(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+	cfg.BackpressureThreshold = -time.Second
+
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.Error(t, err)
+	assert.Nil(t, mReceiver)
+}
+
+func TestCreateReceiverDefaultScrapeIntervalFloor(t *testing.T) {
+	pCfg, err := promcfg.Load("scrape_configs:\n  - job_name: test\n")
+	assert.NoError(t, err)
+
+	factory := &Factory{}
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.PrometheusConfig = pCfg
+
+	for _, interval := range []time.Duration{-time.Second, 500 * time.Millisecond} {
+		cfg.DefaultScrapeInterval = interval
+		mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+		assert.Error(t, err)
+		assert.Nil(t, mReceiver)
+	}
+
+	cfg.DefaultScrapeInterval = defaultMinScrapeInterval
+	mReceiver, err := factory.CreateMetricsReceiver(zap.NewNop(), cfg, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, mReceiver)
+}
+
+func TestCustomUnmarshalerDefaultScrapeInterval(t *testing.T) {
+	tests := []struct {
+		name         string
+		yaml         string
+		wantErr      bool
+		wantInterval time.Duration
+		wantTimeout  time.Duration
+	}{
+		{
+			name: "inherited",
+			yaml: `
+prometheus:
+  default_scrape_interval: 30s
+  config:
+    scrape_configs:
+      - job_name: test
+`,
+			wantInterval: 30 * time.Second,
+			wantTimeout:  10 * time.Second,
+		},
+		{
+			name: "timeout_capped",
+			yaml: `
+prometheus:
+  default_scrape_interval: 5s
+  config:
+    scrape_configs:
+      - job_name: test
+`,
+			wantInterval: 5 * time.Second,
+			wantTimeout:  5 * time.Second,
+		},
+		{
+			name: "explicit_timeout",
+			yaml: `
+prometheus:
+  default_scrape_interval: 30s
+  config:
+    global:
+      scrape_timeout: 20s
+    scrape_configs:
+      - job_name: test
+`,
+			wantInterval: 30 * time.Second,
+			wantTimeout:  20 * time.Second,
+		},
+		{
+			name: "global_interval_wins",
+			yaml: `
+prometheus:
+  default_scrape_interval: 30s
+  config:
+    global:
+      scrape_interval: 15s
+    scrape_configs:
+      - job_name: test
+`,
+			wantInterval: 15 * time.Second,
+			wantTimeout:  10 * time.Second,
+		},
+		{
+			name: "job_interval_wins",
+			yaml: `
+prometheus:
+  default_scrape_interval: 30s
+  config:
+    scrape_configs:
+      - job_name: test
+        scrape_interval: 2s
+`,
+			wantInterval: 2 * time.Second,
+			wantTimeout:  2 * time.Second,
+		},
+		{
+			name: "below_floor",
+			yaml: `
+prometheus:
+  default_scrape_interval: 5s
+  min_scrape_interval: 10s
+  config:
+    scrape_configs:
+      - job_name: test
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.SetConfigType("yaml")
+			require.NoError(t, v.ReadConfig(strings.NewReader(tt.yaml)))
+
+			factory := &Factory{}
+			cfg := factory.CreateDefaultConfig().(*Config)
+			err := CustomUnmarshalerFunc(v, "prometheus", cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			sc := cfg.PrometheusConfig.ScrapeConfigs[0]
+			assert.Equal(t, tt.wantInterval, time.Duration(sc.ScrapeInterval))
+			assert.Equal(t, tt.wantTimeout, time.Duration(sc.ScrapeTimeout))
+		})
+	}
+}
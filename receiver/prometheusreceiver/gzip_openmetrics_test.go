@@ -0,0 +1,150 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// openMetricsExemplars is an OpenMetrics page whose histogram buckets are annotated with exemplars. %d is replaced by
+// 0 on the first scrape, whose cumulative values are the initial ones, and 1 on the next scrapes.
+const openMetricsExemplars = `# HELP latency Request latency.
+# TYPE latency histogram
+latency_bucket{le="0.5"} %[1]d # {trace_id="4bf92f3577b34da6"} 0.25 1520879607.789
+latency_bucket{le="1"} %[2]d # {trace_id="00f067aa0ba902b7"} 0.75
+latency_bucket{le="+Inf"} %[3]d
+latency_count %[3]d
+latency_sum %[4]g
+# EOF
+`
+
+// newOpenMetricsTarget returns a target serving openMetricsExemplars, gzipped if compress is set and the scraper
+// accepts it. gzipped is set once a gzipped page was served.
+func newOpenMetricsTarget(compress bool, gzipped *int32) *httptest.Server {
+	var scrapes int32
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		n := 0
+		if atomic.AddInt32(&scrapes, 1) > 1 {
+			n = 1
+		}
+		page := fmt.Sprintf(openMetricsExemplars, 3*n, 4*n, 5*n, 3.5*float64(n))
+		rw.Header().Set("Content-Type", "application/openmetrics-text; version=0.0.1; charset=utf-8")
+		if !compress || !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+			fmt.Fprint(rw, page)
+			return
+		}
+		rw.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(rw)
+		fmt.Fprint(gw, page)
+		gw.Close()
+		atomic.StoreInt32(gzipped, 1)
+	}))
+}
+
+func TestGzipOpenMetricsScrape(t *testing.T) {
+	var gzipped, plainGzipped int32
+	gzipSrv := newOpenMetricsTarget(true, &gzipped)
+	defer gzipSrv.Close()
+	plainSrv := newOpenMetricsTarget(false, &plainGzipped)
+	defer plainSrv.Close()
+	gzipURL, err := url.Parse(gzipSrv.URL)
+	require.NoError(t, err)
+	plainURL, err := url.Parse(plainSrv.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: gzip
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + gzipURL.Host + `"]
+  - job_name: plain
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + plainURL.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: typeStr},
+		PrometheusConfig: pCfg,
+	}
+	sink := new(exportertest.SinkMetricsExporter)
+	precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	require.Eventually(t, func() bool {
+		return latencyHistogram(sink, "gzip") != nil && latencyHistogram(sink, "plain") != nil
+	}, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, precv.StopMetricsReception())
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&gzipped), "the target did not serve a gzipped page")
+	got := latencyHistogram(sink, "gzip")
+	// The gzipped page converts the same as the uncompressed one.
+	assert.Equal(t, latencyHistogram(sink, "plain"), got)
+
+	assert.Equal(t, int64(5), got.Count)
+	assert.Equal(t, 3.5, got.Sum)
+	assert.Equal(t, []float64{0.5, 1}, got.BucketOptions.GetExplicit().Bounds)
+	counts := make([]int64, 0, len(got.Buckets))
+	for _, b := range got.Buckets {
+		counts = append(counts, b.Count)
+		// The exemplars are dropped by the vendored prometheus parser, see the
+		// README. This must be updated once they are converted.
+		assert.Nil(t, b.Exemplar)
+	}
+	assert.Equal(t, []int64{3, 1, 1}, counts)
+}
+
+// latencyHistogram returns the last latency histogram adjusted from its initial value scraped from the job, nil if
+// there is none yet.
+func latencyHistogram(sink *exportertest.SinkMetricsExporter, job string) *metricspb.DistributionValue {
+	var dist *metricspb.DistributionValue
+	for _, md := range sink.AllMetrics() {
+		if md.Node.GetServiceInfo().GetName() != job {
+			continue
+		}
+		for _, metric := range md.Metrics {
+			if metric.GetMetricDescriptor().GetName() != "latency" {
+				continue
+			}
+			for _, ts := range metric.Timeseries {
+				if dv := ts.Points[0].GetDistributionValue(); dv.GetCount() != 0 {
+					dist = dv
+				}
+			}
+		}
+	}
+	return dist
+}
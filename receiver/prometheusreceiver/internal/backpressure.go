@@ -0,0 +1,140 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+)
+
+// defaultBackpressureSlowCommits is the number of consecutive slow commits making the consumer persistently slow.
+const defaultBackpressureSlowCommits = 3
+
+// defaultBackpressureMaxDelay caps the pause of the scrapes by default.
+const defaultBackpressureMaxDelay = time.Minute
+
+// BackpressureSettings defines how the scrapes slow down when the consumer is persistently slower than the scrapes.
+type BackpressureSettings struct {
+	// Threshold is how long the consumer can take to consume the data of a commit before the commit is slow. 0
+	// disables the backpressure, the scrapes are then never paused.
+	Threshold time.Duration
+	// SlowCommits is the number of consecutive slow commits after which the scrapes are paused. 0 means
+	// defaultBackpressureSlowCommits.
+	SlowCommits int
+	// MaxDelay caps the pause of the scrapes, which doubles while the consumer stays slow. 0 means
+	// defaultBackpressureMaxDelay.
+	MaxDelay time.Duration
+}
+
+// backpressure is a consumer.MetricsConsumer measuring how long the downstream consumer takes to consume the data
+// committed by the scrapes. Once the consumer is persistently slow, the transactions pause the scrape loops at
+// commit time, starting with a pause of the threshold which then doubles on every pause the consumer is still slow
+// after, up to the max delay. The pause stops as soon as the consumer is fast again.
+type backpressure struct {
+	ctx         context.Context
+	sink        consumer.MetricsConsumer
+	threshold   time.Duration
+	slowCommits int
+	maxDelay    time.Duration
+
+	mu          sync.Mutex
+	streak      int
+	delay       time.Duration
+	pausedUntil time.Time
+}
+
+func newBackpressure(ctx context.Context, sink consumer.MetricsConsumer, settings BackpressureSettings) *backpressure {
+	slowCommits := settings.SlowCommits
+	if slowCommits <= 0 {
+		slowCommits = defaultBackpressureSlowCommits
+	}
+	maxDelay := settings.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultBackpressureMaxDelay
+	}
+	return &backpressure{
+		ctx:         ctx,
+		sink:        sink,
+		threshold:   settings.Threshold,
+		slowCommits: slowCommits,
+		maxDelay:    maxDelay,
+	}
+}
+
+// ConsumeMetricsData passes the data downstream, timing how long it takes.
+func (bp *backpressure) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	start := time.Now()
+	err := bp.sink.ConsumeMetricsData(ctx, md)
+	// A consumer still running at the deadline of the commit is slow whatever the threshold.
+	bp.observe(time.Since(start), err == context.DeadlineExceeded)
+	return err
+}
+
+// observe updates the pause of the scrapes given how long the consumer took to consume the data of a commit.
+func (bp *backpressure) observe(latency time.Duration, deadlineExceeded bool) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	if latency < bp.threshold && !deadlineExceeded {
+		bp.streak = 0
+		if bp.delay > 0 {
+			// The consumer caught up.
+			bp.delay = 0
+			bp.pausedUntil = time.Time{}
+			observability.RecordScrapeBackoffForMetricsReceiver(bp.ctx, 0)
+		}
+		return
+	}
+
+	bp.streak++
+	now := time.Now()
+	if bp.streak < bp.slowCommits || now.Before(bp.pausedUntil) {
+		// Only the commits after the end of a pause extend it, the ones of the other targets during the pause
+		// come from the scrapes started before it.
+		return
+	}
+	if bp.delay == 0 {
+		bp.delay = bp.threshold
+	} else {
+		bp.delay *= 2
+	}
+	if bp.delay > bp.maxDelay {
+		bp.delay = bp.maxDelay
+	}
+	bp.pausedUntil = now.Add(bp.delay)
+	observability.RecordScrapeBackoffForMetricsReceiver(bp.ctx, bp.delay)
+}
+
+// wait blocks until the end of the current pause, if any, or until the context is done.
+func (bp *backpressure) wait(ctx context.Context) {
+	bp.mu.Lock()
+	pause := time.Until(bp.pausedUntil)
+	bp.mu.Unlock()
+	if pause <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
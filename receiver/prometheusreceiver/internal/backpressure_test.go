@@ -0,0 +1,91 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+)
+
+func TestBackpressureBacksOff(t *testing.T) {
+	bp := newBackpressure(context.Background(), &countingConsumer{},
+		BackpressureSettings{Threshold: 100 * time.Millisecond, SlowCommits: 2, MaxDelay: 300 * time.Millisecond})
+	endPause := func() { bp.pausedUntil = time.Now().Add(-time.Millisecond) }
+
+	// A single slow commit is not a persistently slow consumer.
+	bp.observe(200*time.Millisecond, false)
+	assert.Equal(t, time.Duration(0), bp.delay)
+	assert.True(t, bp.pausedUntil.IsZero())
+
+	bp.observe(200*time.Millisecond, false)
+	assert.Equal(t, 100*time.Millisecond, bp.delay)
+	assert.True(t, bp.pausedUntil.After(time.Now()))
+
+	// The commits during the pause do not extend it.
+	bp.observe(200*time.Millisecond, false)
+	assert.Equal(t, 100*time.Millisecond, bp.delay)
+
+	endPause()
+	bp.observe(200*time.Millisecond, false)
+	assert.Equal(t, 200*time.Millisecond, bp.delay)
+
+	// The pause is capped.
+	endPause()
+	bp.observe(10*time.Millisecond, true)
+	assert.Equal(t, 300*time.Millisecond, bp.delay)
+
+	// A fast commit ends the pause.
+	bp.observe(10*time.Millisecond, false)
+	assert.Equal(t, time.Duration(0), bp.delay)
+	assert.True(t, bp.pausedUntil.IsZero())
+	assert.Equal(t, 0, bp.streak)
+}
+
+func TestBackpressureWait(t *testing.T) {
+	bp := newBackpressure(context.Background(), &countingConsumer{},
+		BackpressureSettings{Threshold: 50 * time.Millisecond, SlowCommits: 1})
+
+	start := time.Now()
+	bp.wait(context.Background())
+	assert.True(t, time.Since(start) < 50*time.Millisecond, "should not wait without a pause")
+
+	bp.observe(time.Second, false)
+	start = time.Now()
+	bp.wait(context.Background())
+	assert.True(t, time.Since(start) >= 40*time.Millisecond, "should wait until the end of the pause")
+
+	// The wait stops with the context.
+	endPause := time.Now().Add(time.Hour)
+	bp.pausedUntil = endPause
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	bp.wait(ctx)
+	assert.True(t, time.Now().Before(endPause))
+}
+
+func TestBackpressureTimesConsumer(t *testing.T) {
+	sink := &countingConsumer{}
+	bp := newBackpressure(context.Background(), sink, BackpressureSettings{Threshold: time.Hour, SlowCommits: 1})
+
+	assert.NoError(t, bp.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{}))
+	assert.Len(t, sink.received(), 1)
+	// The consumer is fast.
+	assert.Equal(t, time.Duration(0), bp.delay)
+}
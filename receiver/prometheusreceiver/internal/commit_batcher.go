@@ -0,0 +1,214 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+)
+
+// defaultCommitBatchMaxSize is the number of metrics buffered by default before the commits are flushed.
+const defaultCommitBatchMaxSize = 1000
+
+// Resource labels identifying the target of the metrics merged from different targets.
+const (
+	jobResourceLabel  = "job"
+	hostResourceLabel = "host"
+)
+
+// CommitBatchSettings defines how the data committed by the scrapes is buffered before being sent downstream.
+type CommitBatchSettings struct {
+	// Window is how long the commits are buffered, starting from the first one buffered. 0 disables buffering,
+	// every commit is then sent downstream on its own.
+	Window time.Duration
+	// MaxSize is the number of metrics buffered that triggers a flush before the end of the window.
+	// 0 means defaultCommitBatchMaxSize.
+	MaxSize int
+}
+
+// commitBatcher is a consumer.MetricsConsumer buffering the data committed by the scrapes of all the targets over a
+// short window, and sending it downstream merged in a single MetricsData. This reduces the number of downstream calls
+// when scraping many small targets.
+type commitBatcher struct {
+	ctx     context.Context
+	sink    consumer.MetricsConsumer
+	window  time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	pending *pendingCommits
+	timer   *time.Timer
+}
+
+// pendingCommits is the data buffered by the commits of a window. Each commit waits for it to be sent downstream to
+// return the error of the downstream consumer.
+type pendingCommits struct {
+	data []consumerdata.MetricsData
+	size int
+	// deadline is the earliest deadline of the commits, zero if none has one.
+	deadline time.Time
+	// done is closed once the data was sent downstream and err set.
+	done chan struct{}
+	err  error
+}
+
+func newCommitBatcher(ctx context.Context, sink consumer.MetricsConsumer, settings CommitBatchSettings) *commitBatcher {
+	maxSize := settings.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultCommitBatchMaxSize
+	}
+	return &commitBatcher{
+		ctx:     ctx,
+		sink:    sink,
+		window:  settings.Window,
+		maxSize: maxSize,
+	}
+}
+
+// ConsumeMetricsData buffers the data, it is sent downstream at the end of the window, or right away if the buffer
+// is full. It returns the error of the downstream consumer once the data was sent, or the error of ctx if it is done
+// before.
+func (cb *commitBatcher) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	cb.mu.Lock()
+	if cb.pending == nil {
+		cb.pending = &pendingCommits{done: make(chan struct{})}
+	}
+	pending := cb.pending
+	pending.data = append(pending.data, md)
+	pending.size += len(md.Metrics)
+	if deadline, ok := ctx.Deadline(); ok && (pending.deadline.IsZero() || deadline.Before(pending.deadline)) {
+		pending.deadline = deadline
+	}
+	if pending.size >= cb.maxSize {
+		cb.takeLocked()
+		cb.mu.Unlock()
+		cb.send(pending)
+		return pending.err
+	}
+	if cb.timer == nil {
+		cb.timer = time.AfterFunc(cb.window, func() { cb.flushOnTimer(pending) })
+	}
+	cb.mu.Unlock()
+
+	select {
+	case <-pending.done:
+		return pending.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flushOnTimer sends the given pending data downstream, unless it was already sent because the buffer got full.
+func (cb *commitBatcher) flushOnTimer(pending *pendingCommits) {
+	cb.mu.Lock()
+	if cb.pending != pending {
+		cb.mu.Unlock()
+		return
+	}
+	cb.takeLocked()
+	cb.mu.Unlock()
+	cb.send(pending)
+}
+
+// flush sends the buffered data downstream.
+func (cb *commitBatcher) flush() error {
+	cb.mu.Lock()
+	pending := cb.takeLocked()
+	cb.mu.Unlock()
+	if pending == nil {
+		return nil
+	}
+	cb.send(pending)
+	return pending.err
+}
+
+// takeLocked removes the buffered data from the batcher and returns it, it must be called holding cb.mu.
+func (cb *commitBatcher) takeLocked() *pendingCommits {
+	if cb.timer != nil {
+		cb.timer.Stop()
+		cb.timer = nil
+	}
+	pending := cb.pending
+	cb.pending = nil
+	return pending
+}
+
+// send sends the pending data downstream, without holding cb.mu so that a slow consumer does not block the commits
+// of the next window. The downstream context has the earliest deadline of the commits.
+func (cb *commitBatcher) send(pending *pendingCommits) {
+	ctx := cb.ctx
+	if !pending.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, pending.deadline)
+		defer cancel()
+	}
+	pending.err = cb.sink.ConsumeMetricsData(ctx, mergeMetricsData(pending.data))
+	close(pending.done)
+}
+
+// mergeMetricsData merges the data of the given batches. If they have different nodes, i.e. come from different
+// targets, the merged data has no node and each metric holds the identity of its target as resource instead.
+func mergeMetricsData(batches []consumerdata.MetricsData) consumerdata.MetricsData {
+	if len(batches) == 1 {
+		return batches[0]
+	}
+
+	size := 0
+	sameNode := true
+	for _, md := range batches {
+		size += len(md.Metrics)
+		sameNode = sameNode && proto.Equal(md.Node, batches[0].Node)
+	}
+
+	merged := consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 0, size)}
+	if sameNode {
+		merged.Node = batches[0].Node
+		for _, md := range batches {
+			merged.Metrics = append(merged.Metrics, md.Metrics...)
+		}
+		return merged
+	}
+
+	for _, md := range batches {
+		resource := nodeResource(md.Node)
+		for _, metric := range md.Metrics {
+			if metric.Resource == nil {
+				metric.Resource = resource
+			}
+			merged.Metrics = append(merged.Metrics, metric)
+		}
+	}
+	return merged
+}
+
+// nodeResource returns a resource identifying the target described by the given node.
+func nodeResource(node *commonpb.Node) *resourcepb.Resource {
+	labels := make(map[string]string, len(node.GetAttributes())+2)
+	for k, v := range node.GetAttributes() {
+		labels[k] = v
+	}
+	labels[jobResourceLabel] = node.GetServiceInfo().GetName()
+	labels[hostResourceLabel] = node.GetIdentifier().GetHostName()
+	return &resourcepb.Resource{Labels: labels}
+}
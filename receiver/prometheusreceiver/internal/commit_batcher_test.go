@@ -0,0 +1,199 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+)
+
+// countingConsumer records every call it receives.
+type countingConsumer struct {
+	mu    sync.Mutex
+	calls []consumerdata.MetricsData
+}
+
+func (cc *countingConsumer) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.calls = append(cc.calls, md)
+	return nil
+}
+
+func (cc *countingConsumer) received() []consumerdata.MetricsData {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return append([]consumerdata.MetricsData(nil), cc.calls...)
+}
+
+func TestCommitBatcherMergesTargetScrapes(t *testing.T) {
+	ms := &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{
+			"test_localhost:8080": {data: map[string]scrape.MetricMetadata{}},
+			"test_localhost:9090": {data: map[string]scrape.MetricMetadata{}},
+		},
+	}
+	sink := &countingConsumer{}
+	cb := newCommitBatcher(context.Background(), sink, CommitBatchSettings{Window: 200 * time.Millisecond})
+
+	// Scrape both targets, as the scrape loops would do, within the window. The commits return once the merged data
+	// was sent.
+	var wg sync.WaitGroup
+	for _, instance := range []string{"localhost:8080", "localhost:9090"} {
+		tr := newTransaction(context.Background(), nil, ms, cb, testLogger, EmptyScrapeSuccess)
+		lbls := labels.FromStrings("__name__", "foo", "job", "test", "instance", instance)
+		_, err := tr.Add(lbls, time.Now().Unix()*1000, 1)
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, tr.Commit())
+		}()
+	}
+	wg.Wait()
+
+	calls := sink.received()
+	require.Len(t, calls, 1)
+	md := calls[0]
+	assert.Nil(t, md.Node)
+	require.Len(t, md.Metrics, 2)
+	assert.Equal(t, map[string]string{"job": "test", "host": "localhost", "port": "8080", "scheme": "http"},
+		md.Metrics[0].Resource.Labels)
+	assert.Equal(t, map[string]string{"job": "test", "host": "localhost", "port": "9090", "scheme": "http"},
+		md.Metrics[1].Resource.Labels)
+}
+
+func TestCommitBatcherSameNode(t *testing.T) {
+	node := createNode("test", "localhost:8080", "http")
+	merged := mergeMetricsData([]consumerdata.MetricsData{
+		{Node: node, Metrics: []*metricspb.Metric{{}}},
+		{Node: createNode("test", "localhost:8080", "http"), Metrics: []*metricspb.Metric{{}, {}}},
+	})
+	assert.Equal(t, node, merged.Node)
+	require.Len(t, merged.Metrics, 3)
+	for _, metric := range merged.Metrics {
+		assert.Nil(t, metric.Resource)
+	}
+}
+
+func TestCommitBatcherMaxSize(t *testing.T) {
+	sink := &countingConsumer{}
+	cb := newCommitBatcher(context.Background(), sink, CommitBatchSettings{Window: time.Hour, MaxSize: 3})
+
+	node := &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "test"}}
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- cb.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Node: node, Metrics: make([]*metricspb.Metric, 2)})
+	}()
+	require.Eventually(t, func() bool { return pendingSize(cb) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, sink.received())
+
+	// Reaching the max size flushes right away.
+	require.NoError(t, cb.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Node: node, Metrics: make([]*metricspb.Metric, 1)}))
+	require.NoError(t, <-firstErr)
+	calls := sink.received()
+	require.Len(t, calls, 1)
+	assert.Len(t, calls[0].Metrics, 3)
+}
+
+func TestCommitBatcherReturnsDownstreamError(t *testing.T) {
+	errDownstream := errors.New("downstream error")
+	var deadline time.Time
+	sink := consumerFunc(func(ctx context.Context, md consumerdata.MetricsData) error {
+		deadline, _ = ctx.Deadline()
+		return errDownstream
+	})
+	cb := newCommitBatcher(context.Background(), sink, CommitBatchSettings{Window: 10 * time.Millisecond})
+
+	// The data is sent downstream with the deadline of the commit.
+	want := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.Background(), want)
+	defer cancel()
+	err := cb.ConsumeMetricsData(ctx, consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 1)})
+	assert.Equal(t, errDownstream, err)
+	assert.Equal(t, want, deadline)
+}
+
+func TestCommitBatcherSendsWithoutLocking(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	sink := consumerFunc(func(ctx context.Context, md consumerdata.MetricsData) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+		}
+		return nil
+	})
+	cb := newCommitBatcher(context.Background(), sink, CommitBatchSettings{Window: time.Hour, MaxSize: 1})
+
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- cb.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 1)})
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// The first batch is stuck downstream, the next commits are not blocked by it.
+	require.NoError(t, cb.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Metrics: make([]*metricspb.Metric, 1)}))
+	close(release)
+	require.NoError(t, <-firstErr)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestOcaStoreCloseFlushesCommits(t *testing.T) {
+	sink := &countingConsumer{}
+	o := NewOcaStore(context.Background(), sink, testLogger, nil, EmptyScrapeSuccess, TimestampHonor,
+		CommitBatchSettings{Window: time.Hour}, BackpressureSettings{}, 0, nil).(*ocaStore)
+	o.SetScrapeManager(&scrape.Manager{})
+
+	node := &commonpb.Node{ServiceInfo: &commonpb.ServiceInfo{Name: "test"}}
+	commitErr := make(chan error, 1)
+	go func() {
+		commitErr <- o.sink.ConsumeMetricsData(context.Background(), consumerdata.MetricsData{Node: node, Metrics: make([]*metricspb.Metric, 1)})
+	}()
+	require.Eventually(t, func() bool { return pendingSize(o.batcher) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, sink.received())
+
+	require.NoError(t, o.Close())
+	require.NoError(t, <-commitErr)
+	require.Len(t, sink.received(), 1)
+}
+
+type consumerFunc func(ctx context.Context, md consumerdata.MetricsData) error
+
+func (f consumerFunc) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	return f(ctx, md)
+}
+
+// pendingSize returns the number of metrics buffered by the batcher.
+func pendingSize(cb *commitBatcher) int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.pending == nil {
+		return 0
+	}
+	return cb.pending.size
+}
@@ -0,0 +1,229 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/discovery"
+	sd_config "github.com/prometheus/prometheus/discovery/config"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+)
+
+// discoveryManager is the subset of the prometheus discovery.Manager methods
+// used by DiscoveryManagers.
+type discoveryManager interface {
+	Run() error
+	SyncCh() <-chan map[string][]*targetgroup.Group
+	ApplyConfig(cfg map[string]sd_config.ServiceDiscoveryConfig) error
+}
+
+// DiscoveryManagers runs the service discovery of the scrape jobs over several
+// prometheus discovery managers, each discovering the targets of a shard of
+// the jobs. A discovery manager creates the providers of its config one after
+// the other when the config is applied, DiscoveryManagers applies the config
+// of the shards concurrently so that the startup with dozens of service
+// discovery configs is not serial. The target sets of the shards are merged
+// in a single channel for the scrape manager.
+//
+// It records, per job, the time from applying its config to the discovery of
+// its first targets.
+type DiscoveryManagers struct {
+	ctx      context.Context
+	managers []discoveryManager
+	// shards maps each job to the index of the manager discovering it.
+	shards map[string]int
+	syncCh chan map[string][]*targetgroup.Group
+
+	mu sync.Mutex
+	// applied holds the jobs whose config was applied last.
+	applied map[string]bool
+	// pending holds the time the config of the jobs not discovered yet was
+	// applied at.
+	pending map[string]time.Time
+}
+
+// NewDiscoveryManagers creates DiscoveryManagers spreading the given jobs over
+// at most concurrency discovery managers, 0 meaning a single manager. The
+// context is used to stop the managers and to record the readiness time, so
+// it must be created using observability.ContextWithReceiverName.
+func NewDiscoveryManagers(ctx context.Context, logger log.Logger, jobs []string, concurrency int) *DiscoveryManagers {
+	return newDiscoveryManagers(ctx, jobs, concurrency, func() discoveryManager {
+		return discovery.NewManager(ctx, logger)
+	})
+}
+
+func newDiscoveryManagers(ctx context.Context, jobs []string, concurrency int, newManager func() discoveryManager) *DiscoveryManagers {
+	if concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	dm := &DiscoveryManagers{
+		ctx:      ctx,
+		managers: make([]discoveryManager, concurrency),
+		shards:   make(map[string]int, len(jobs)),
+		syncCh:   make(chan map[string][]*targetgroup.Group),
+		applied:  make(map[string]bool),
+		pending:  make(map[string]time.Time),
+	}
+	for i := range dm.managers {
+		dm.managers[i] = newManager()
+	}
+	for i, job := range jobs {
+		dm.shards[job] = i % concurrency
+	}
+	return dm
+}
+
+// Run runs the discovery managers and merges their target sets until the
+// context is done. It returns the error of the first manager returning.
+func (dm *DiscoveryManagers) Run() error {
+	errs := make(chan error, len(dm.managers))
+	updates := make(chan shardUpdate)
+	for i, manager := range dm.managers {
+		go func(manager discoveryManager) {
+			errs <- manager.Run()
+		}(manager)
+		go dm.forward(i, manager.SyncCh(), updates)
+	}
+	go dm.merge(updates)
+	return <-errs
+}
+
+// SyncCh returns the channel the merged target sets of all the jobs are sent
+// to, as by the prometheus discovery manager.
+func (dm *DiscoveryManagers) SyncCh() <-chan map[string][]*targetgroup.Group {
+	return dm.syncCh
+}
+
+// ApplyConfig applies the service discovery config of every shard
+// concurrently. The jobs missing from the config stop being discovered.
+func (dm *DiscoveryManagers) ApplyConfig(cfg map[string]sd_config.ServiceDiscoveryConfig) error {
+	shardCfgs := make([]map[string]sd_config.ServiceDiscoveryConfig, len(dm.managers))
+	for i := range shardCfgs {
+		shardCfgs[i] = make(map[string]sd_config.ServiceDiscoveryConfig)
+	}
+	for job, sdCfg := range cfg {
+		// The jobs unknown at creation go in the first shard.
+		shardCfgs[dm.shards[job]][job] = sdCfg
+	}
+
+	dm.mu.Lock()
+	now := time.Now()
+	applied := make(map[string]bool, len(cfg))
+	for job := range cfg {
+		applied[job] = true
+		if !dm.applied[job] {
+			dm.pending[job] = now
+		}
+	}
+	for job := range dm.pending {
+		if !applied[job] {
+			delete(dm.pending, job)
+		}
+	}
+	dm.applied = applied
+	dm.mu.Unlock()
+
+	errs := make([]error, len(dm.managers))
+	var wg sync.WaitGroup
+	for i, manager := range dm.managers {
+		wg.Add(1)
+		go func(i int, manager discoveryManager) {
+			defer wg.Done()
+			errs[i] = manager.ApplyConfig(shardCfgs[i])
+		}(i, manager)
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return oterr.CombineErrors(failed)
+}
+
+// shardUpdate is the target sets sent by the discovery manager of a shard.
+type shardUpdate struct {
+	shard int
+	tsets map[string][]*targetgroup.Group
+}
+
+func (dm *DiscoveryManagers) forward(shard int, in <-chan map[string][]*targetgroup.Group, out chan<- shardUpdate) {
+	for {
+		select {
+		case <-dm.ctx.Done():
+			return
+		case tsets := <-in:
+			select {
+			case out <- shardUpdate{shard: shard, tsets: tsets}:
+			case <-dm.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// merge sends the union of the last target sets of every shard each time a
+// shard sends an update, the scrape manager replacing all its target sets on
+// every update.
+func (dm *DiscoveryManagers) merge(updates <-chan shardUpdate) {
+	last := make([]map[string][]*targetgroup.Group, len(dm.managers))
+	for {
+		select {
+		case <-dm.ctx.Done():
+			return
+		case update := <-updates:
+			last[update.shard] = update.tsets
+			dm.recordReady(update.tsets)
+			merged := make(map[string][]*targetgroup.Group)
+			for _, tsets := range last {
+				for job, groups := range tsets {
+					merged[job] = groups
+				}
+			}
+			select {
+			case dm.syncCh <- merged:
+			case <-dm.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// recordReady records the readiness time of the pending jobs listed in the
+// target sets.
+func (dm *DiscoveryManagers) recordReady(tsets map[string][]*targetgroup.Group) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	for job := range tsets {
+		applied, ok := dm.pending[job]
+		if !ok {
+			continue
+		}
+		delete(dm.pending, job)
+		observability.RecordDiscoveryReadyTimeForMetricsReceiver(dm.ctx, job, time.Since(applied))
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	sd_config "github.com/prometheus/prometheus/discovery/config"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+)
+
+// providerSetupTime is how long the fake discovery managers take to create the
+// provider of a job, one job after the other as prometheus does.
+const providerSetupTime = 200 * time.Millisecond
+
+type fakeDiscoveryManager struct {
+	ctx    context.Context
+	syncCh chan map[string][]*targetgroup.Group
+	err    error
+
+	mu      sync.Mutex
+	applied []string
+}
+
+func (m *fakeDiscoveryManager) Run() error {
+	<-m.ctx.Done()
+	return m.ctx.Err()
+}
+
+func (m *fakeDiscoveryManager) SyncCh() <-chan map[string][]*targetgroup.Group {
+	return m.syncCh
+}
+
+func (m *fakeDiscoveryManager) ApplyConfig(cfg map[string]sd_config.ServiceDiscoveryConfig) error {
+	tsets := make(map[string][]*targetgroup.Group, len(cfg))
+	jobs := make([]string, 0, len(cfg))
+	for job := range cfg {
+		time.Sleep(providerSetupTime)
+		tsets[job] = []*targetgroup.Group{{
+			Source:  job,
+			Targets: []model.LabelSet{{model.AddressLabel: model.LabelValue(job + ":80")}},
+		}}
+		jobs = append(jobs, job)
+	}
+	sort.Strings(jobs)
+	m.mu.Lock()
+	m.applied = jobs
+	m.mu.Unlock()
+	go func() {
+		select {
+		case m.syncCh <- tsets:
+		case <-m.ctx.Done():
+		}
+	}()
+	return m.err
+}
+
+func newFakeDiscoveryManagers(ctx context.Context, jobs []string, concurrency int) (*DiscoveryManagers, []*fakeDiscoveryManager) {
+	var fakes []*fakeDiscoveryManager
+	dm := newDiscoveryManagers(ctx, jobs, concurrency, func() discoveryManager {
+		fake := &fakeDiscoveryManager{ctx: ctx, syncCh: make(chan map[string][]*targetgroup.Group)}
+		fakes = append(fakes, fake)
+		return fake
+	})
+	go dm.Run()
+	return dm, fakes
+}
+
+func sdConfigs(jobs ...string) map[string]sd_config.ServiceDiscoveryConfig {
+	cfg := make(map[string]sd_config.ServiceDiscoveryConfig, len(jobs))
+	for _, job := range jobs {
+		cfg[job] = sd_config.ServiceDiscoveryConfig{}
+	}
+	return cfg
+}
+
+// waitForJobs reads the target sets sent by the discovery managers until they
+// list all the given jobs, returning the last ones.
+func waitForJobs(t *testing.T, dm *DiscoveryManagers, jobs ...string) map[string][]*targetgroup.Group {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case tsets := <-dm.SyncCh():
+			complete := true
+			for _, job := range jobs {
+				if _, ok := tsets[job]; !ok {
+					complete = false
+				}
+			}
+			if complete {
+				return tsets
+			}
+		case <-timeout:
+			t.Fatalf("jobs %v not discovered", jobs)
+			return nil
+		}
+	}
+}
+
+func TestDiscoveryManagers_ConcurrentApplyConfig(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	ctx, cancel := context.WithCancel(observability.ContextWithReceiverName(context.Background(), "prometheus"))
+	defer cancel()
+	jobs := []string{"a", "b", "c", "d"}
+	dm, fakes := newFakeDiscoveryManagers(ctx, jobs, 4)
+	require.Len(t, fakes, 4)
+
+	start := time.Now()
+	require.NoError(t, dm.ApplyConfig(sdConfigs(jobs...)))
+	tsets := waitForJobs(t, dm, jobs...)
+	elapsed := time.Since(start)
+
+	// Applied one after the other, the jobs would take 4 * providerSetupTime to be ready.
+	assert.True(t, elapsed < 3*providerSetupTime, "jobs ready after %v", elapsed)
+	for i, fake := range fakes {
+		assert.Equal(t, []string{jobs[i]}, fake.applied)
+	}
+	for _, job := range jobs {
+		require.Len(t, tsets[job], 1)
+		assert.Equal(t, job, tsets[job][0].Source)
+	}
+
+	rows, err := view.RetrieveData(observability.ViewReceiverDiscoveryReadyTime.Name)
+	require.NoError(t, err)
+	readyJobs := 0
+	for _, row := range rows {
+		if !hasTag(row.Tags, tag.Tag{Key: observability.TagKeyReceiver, Value: "prometheus"}) {
+			continue
+		}
+		readyJobs++
+		readyTime := row.Data.(*view.LastValueData).Value
+		assert.True(t, readyTime >= float64(providerSetupTime/time.Millisecond), "ready time %v", readyTime)
+		assert.True(t, readyTime <= float64(elapsed/time.Millisecond), "ready time %v", readyTime)
+	}
+	assert.Equal(t, len(jobs), readyJobs)
+}
+
+func hasTag(tags []tag.Tag, want tag.Tag) bool {
+	for _, tg := range tags {
+		if tg == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiscoveryManagers_Sharding(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dm, fakes := newFakeDiscoveryManagers(ctx, []string{"a", "b", "c"}, 2)
+	require.Len(t, fakes, 2)
+
+	// The unknown jobs are discovered by the first manager.
+	require.NoError(t, dm.ApplyConfig(sdConfigs("a", "b", "c", "unknown")))
+	waitForJobs(t, dm, "a", "b", "c", "unknown")
+	assert.Equal(t, []string{"a", "c", "unknown"}, fakes[0].applied)
+	assert.Equal(t, []string{"b"}, fakes[1].applied)
+
+	// The manager without jobs is applied an empty config, stopping the
+	// discovery of its former jobs.
+	require.NoError(t, dm.ApplyConfig(sdConfigs("a")))
+	assert.Equal(t, []string{"a"}, fakes[0].applied)
+	assert.Empty(t, fakes[1].applied)
+}
+
+func TestDiscoveryManagers_ConcurrencyCapped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, fakes := newFakeDiscoveryManagers(ctx, []string{"a", "b"}, 8)
+	assert.Len(t, fakes, 2, "no more managers than jobs")
+
+	_, fakes = newFakeDiscoveryManagers(ctx, []string{"a", "b"}, 0)
+	assert.Len(t, fakes, 1, "0 means a single manager")
+
+	_, fakes = newFakeDiscoveryManagers(ctx, nil, 4)
+	assert.Len(t, fakes, 1)
+}
+
+func TestDiscoveryManagers_MergedTargetSets(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dm, fakes := newFakeDiscoveryManagers(ctx, []string{"a", "b"}, 2)
+
+	require.NoError(t, dm.ApplyConfig(sdConfigs("a", "b")))
+	waitForJobs(t, dm, "a", "b")
+
+	// An update of a manager is merged with the last target sets of the other.
+	updated := []*targetgroup.Group{{Source: "a2"}}
+	fakes[0].syncCh <- map[string][]*targetgroup.Group{"a": updated}
+	select {
+	case tsets := <-dm.SyncCh():
+		assert.Equal(t, updated, tsets["a"])
+		require.Len(t, tsets["b"], 1)
+		assert.Equal(t, "b", tsets["b"][0].Source)
+	case <-time.After(5 * time.Second):
+		t.Fatal("update not merged")
+	}
+}
+
+func TestDiscoveryManagers_ApplyConfigErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dm, fakes := newFakeDiscoveryManagers(ctx, []string{"a", "b"}, 2)
+	fakes[0].err = errors.New("first")
+	fakes[1].err = errors.New("second")
+
+	err := dm.ApplyConfig(sdConfigs("a", "b"))
+	require.Error(t, err)
+	assert.Equal(t, "[first; second]", err.Error())
+}
@@ -53,6 +53,14 @@ func (m *mockMetadataCache) SharedLabels() labels.Labels {
 	return labels.FromStrings("__scheme__", "http")
 }
 
+func (m *mockMetadataCache) TargetLabels() labels.Labels {
+	return labels.Labels{}
+}
+
+func (m *mockMetadataCache) MetricsPath() string {
+	return "/metrics"
+}
+
 func newMockConsumer() *mockConsumer {
 	return &mockConsumer{}
 }
@@ -70,7 +78,7 @@ type mockMetadataSvc struct {
 	caches map[string]*mockMetadataCache
 }
 
-func (mm *mockMetadataSvc) Get(job, instance string) (MetadataCache, error) {
+func (mm *mockMetadataSvc) Get(job, instance string, _ labels.Labels) (MetadataCache, error) {
 	if mc, ok := mm.caches[job+"_"+instance]; ok {
 		return mc, nil
 	}
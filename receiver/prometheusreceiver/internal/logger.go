@@ -19,22 +19,37 @@ import (
 	"go.uber.org/zap"
 )
 
-// NewZapToGokitLogAdapter create an adapter for zap.Logger to gokitLog.Logger
+const (
+	// prometheus names each scrape pool after the job it was created for
+	gokitScrapePoolKey = "scrape_pool"
+	jobKey             = "job"
+)
+
+// NewZapToGokitLogAdapter create an adapter for zap.Logger to gokitLog.Logger. Any field already attached to the given
+// logger (e.g. the receiver name) is carried by all the entries logged through the adapter.
 func NewZapToGokitLogAdapter(logger *zap.Logger) gokitLog.Logger {
+	return NewRedactingZapToGokitLogAdapter(logger, nil)
+}
+
+// NewRedactingZapToGokitLogAdapter is like NewZapToGokitLogAdapter, except that the string and error values logged
+// through the adapter go through the given redact function first, e.g. to mask the secrets of the config.
+func NewRedactingZapToGokitLogAdapter(logger *zap.Logger, redact func(string) string) gokitLog.Logger {
 	// need to skip two levels in order to get the correct caller
 	// one for this method, the other for gokitLog
 	logger = logger.WithOptions(zap.AddCallerSkip(2))
-	return &zapToGokitLogAdapter{l: logger.Sugar()}
+	return &zapToGokitLogAdapter{l: logger.Sugar(), redact: redact}
 }
 
 type zapToGokitLogAdapter struct {
-	l *zap.SugaredLogger
+	l      *zap.SugaredLogger
+	redact func(string) string
 }
 
 func (w *zapToGokitLogAdapter) Log(keyvals ...interface{}) error {
+	keyvals = w.redactValues(keyvals)
 	if len(keyvals)%2 == 0 {
 		// expecting key value pairs, the number of items need to be even
-		w.l.Infow("", keyvals...)
+		w.l.Infow("", withJobKey(keyvals)...)
 	} else {
 		// in case something goes wrong
 		w.l.Info(keyvals...)
@@ -42,4 +57,37 @@ func (w *zapToGokitLogAdapter) Log(keyvals ...interface{}) error {
 	return nil
 }
 
+// redactValues returns the given values with the string and error ones redacted, keeping the given slice unchanged.
+func (w *zapToGokitLogAdapter) redactValues(keyvals []interface{}) []interface{} {
+	if w.redact == nil {
+		return keyvals
+	}
+	kvs := make([]interface{}, len(keyvals))
+	for i, v := range keyvals {
+		switch v := v.(type) {
+		case string:
+			kvs[i] = w.redact(v)
+		case error:
+			kvs[i] = w.redact(v.Error())
+		default:
+			kvs[i] = v
+		}
+	}
+	return kvs
+}
+
+// withJobKey renames the scrape_pool key used by prometheus to job, so that scrape related entries can be filtered
+// by job the same way as the ones logged by the receiver itself.
+func withJobKey(keyvals []interface{}) []interface{} {
+	for i := 0; i < len(keyvals); i += 2 {
+		if k, ok := keyvals[i].(string); ok && k == gokitScrapePoolKey {
+			kvs := make([]interface{}, len(keyvals))
+			copy(kvs, keyvals)
+			kvs[i] = jobKey
+			return kvs
+		}
+	}
+	return keyvals
+}
+
 var _ gokitLog.Logger = (*zapToGokitLogAdapter)(nil)
@@ -0,0 +1,111 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/scrape"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapToGokitLogAdapter_ReceiverAndJobFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core).With(zap.String("receiver", "prometheus/test"))
+
+	l := NewZapToGokitLogAdapter(logger)
+	if err := l.Log("scrape_pool", "job1", "msg", "scrape failed"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("want 1 log entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if got := fields["receiver"]; got != "prometheus/test" {
+		t.Errorf("want receiver=prometheus/test, got %v", got)
+	}
+	if got := fields["job"]; got != "job1" {
+		t.Errorf("want job=job1, got %v", got)
+	}
+	if _, ok := fields["scrape_pool"]; ok {
+		t.Errorf("scrape_pool shall be reported as job, got %v", fields)
+	}
+}
+
+func TestRedactingZapToGokitLogAdapter(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	redact := func(s string) string { return strings.Replace(s, "s3cr3t", "<secret>", -1) }
+
+	l := NewRedactingZapToGokitLogAdapter(zap.New(core), redact)
+	keyvals := []interface{}{"msg", "error creating HTTP client", "err", errors.New("bad token s3cr3t"), "count", 1}
+	if err := l.Log(keyvals...); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("want 1 log entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if got := fields["err"]; got != "bad token <secret>" {
+		t.Errorf("want err=bad token <secret>, got %v", got)
+	}
+	if got := fields["count"]; got != int64(1) {
+		t.Errorf("want count=1, got %v", got)
+	}
+	if err, ok := keyvals[3].(error); !ok || err.Error() != "bad token s3cr3t" {
+		t.Errorf("the logged values shall not be modified, got %v", keyvals[3])
+	}
+}
+
+func TestTransaction_LoggerJobFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core).With(zap.String("receiver", "prometheus/test"))
+
+	ms := &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{
+			"test_localhost:8080": {data: map[string]scrape.MetricMetadata{}},
+		},
+	}
+	tr := newTransaction(context.Background(), nil, ms, newMockConsumer(), logger.Sugar(), EmptyScrapeSuccess)
+	ls := labels.FromStrings("__name__", "up", "job", "test", "instance", "localhost:8080")
+	if _, err := tr.Add(ls, time.Now().Unix()*1000, 1.0); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) == 0 {
+		t.Fatal("expecting the internal metric to be logged")
+	}
+	fields := entries[0].ContextMap()
+	if got := fields["receiver"]; got != "prometheus/test" {
+		t.Errorf("want receiver=prometheus/test, got %v", got)
+	}
+	if got := fields["job"]; got != "test" {
+		t.Errorf("want job=test, got %v", got)
+	}
+	if got := fields["instance"]; got != "localhost:8080" {
+		t.Errorf("want instance=localhost:8080, got %v", got)
+	}
+}
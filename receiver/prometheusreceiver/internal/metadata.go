@@ -24,35 +24,63 @@ import (
 
 // MetadataService is an adapter to scrapeManager and provide only the functionality which is needed
 type MetadataService interface {
-	Get(job, instance string) (MetadataCache, error)
+	// Get returns the target of the job with the given instance. The labels of a sample of the target tell apart
+	// the targets of the job sharing the same address, e.g. scraped on different paths.
+	Get(job, instance string, sampleLabels labels.Labels) (MetadataCache, error)
 }
 
 // MetadataCache is an adapter to prometheus' scrape.Target  and provide only the functionality which is needed
 type MetadataCache interface {
 	Metadata(metricName string) (scrape.MetricMetadata, bool)
 	SharedLabels() labels.Labels
+	// TargetLabels returns the labels attached to every sample of the target, which identify it within its job.
+	TargetLabels() labels.Labels
+	// MetricsPath returns the path the target is scraped on.
+	MetricsPath() string
 }
 
 type mService struct {
 	sm *scrape.Manager
 }
 
-func (t *mService) Get(job, instance string) (MetadataCache, error) {
+func (t *mService) Get(job, instance string, sampleLabels labels.Labels) (MetadataCache, error) {
 	targetGroup, ok := t.sm.TargetsAll()[job]
 	if !ok {
 		return nil, errors.New("unable to find a target group with job=" + job)
 	}
 
-	// from the same targetGroup, instance is not going to be duplicated
+	// Several targets of the same job can share an address, e.g. when scraped on different paths, the one whose
+	// labels were attached to the sample is preferred. The target labels may have been overridden by the ones
+	// exposed by the target with honor_labels, fall back to the first target with the address then.
+	var found *scrape.Target
 	for _, target := range targetGroup {
-		if target.DiscoveredLabels().Get(model.AddressLabel) == instance {
+		if target.DiscoveredLabels().Get(model.AddressLabel) != instance {
+			continue
+		}
+		if hasLabels(sampleLabels, target.Labels()) {
 			return &mCache{target}, nil
 		}
+		if found == nil {
+			found = target
+		}
+	}
+	if found != nil {
+		return &mCache{found}, nil
 	}
 
 	return nil, errors.New("unable to find a target with job=" + job + ", and instance=" + instance)
 }
 
+// hasLabels returns whether every label of subset is in ls with the same value.
+func hasLabels(ls, subset labels.Labels) bool {
+	for _, l := range subset {
+		if ls.Get(l.Name) != l.Value {
+			return false
+		}
+	}
+	return true
+}
+
 // adapter to get metadata from scrape.Target
 type mCache struct {
 	t *scrape.Target
@@ -65,3 +93,11 @@ func (m *mCache) Metadata(metricName string) (scrape.MetricMetadata, bool) {
 func (m *mCache) SharedLabels() labels.Labels {
 	return m.t.DiscoveredLabels()
 }
+
+func (m *mCache) TargetLabels() labels.Labels {
+	return m.t.Labels()
+}
+
+func (m *mCache) MetricsPath() string {
+	return m.t.URL().Path
+}
@@ -15,6 +15,7 @@
 package internal
 
 import (
+	"math"
 	"sort"
 	"strings"
 
@@ -32,6 +33,10 @@ type MetricFamily interface {
 	Add(metricName string, ls labels.Labels, t int64, v float64) error
 	IsSameFamily(metricName string) bool
 	ToMetric() (*metricspb.Metric, int, int)
+	// RepairedHistograms returns the number of histogram series missing their _count or _sum which were repaired.
+	RepairedHistograms() int
+	// NaNBucketHistograms returns the number of histogram series with a bucket whose value is NaN.
+	NaNBucketHistograms() int
 }
 
 type metricFamily struct {
@@ -44,13 +49,30 @@ type metricFamily struct {
 	metadata          *scrape.MetricMetadata
 	groupOrders       map[string]int
 	groups            map[string]*metricGroup
+	extremeQuantiles  ExtremeQuantilePolicy
+	// repairHistograms is whether the histogram series missing their _count or _sum are repaired rather than
+	// dropped.
+	repairHistograms   bool
+	repairedHistograms int
+	// nanBuckets defines how the histograms with a NaN bucket are converted.
+	nanBuckets          NaNBucketPolicy
+	nanBucketHistograms int
 }
 
-func newMetricFamily(metricName string, mc MetadataCache) MetricFamily {
+func newMetricFamily(metricName string, mc MetadataCache, extremeQuantiles ExtremeQuantilePolicy,
+	repairHistograms bool, nanBuckets NaNBucketPolicy) MetricFamily {
 	familyName := normalizeMetricName(metricName)
 
 	// lookup metadata based on familyName
 	metadata, ok := mc.Metadata(familyName)
+	if !ok && strings.HasSuffix(metricName, metricsSuffixInfo) {
+		// the samples of an OpenMetrics info metric have the _info suffix, unlike its family. the samples are
+		// converted to a metric keeping their name, as prometheus stores them.
+		if infoMetadata, found := mc.Metadata(strings.TrimSuffix(metricName, metricsSuffixInfo)); found &&
+			infoMetadata.Type == textparse.MetricTypeInfo {
+			metadata, ok = infoMetadata, true
+		}
+	}
 	if !ok && metricName != familyName {
 		// use the original metricName as metricFamily
 		familyName = metricName
@@ -74,6 +96,9 @@ func newMetricFamily(metricName string, mc MetadataCache) MetricFamily {
 		metadata:          &metadata,
 		groupOrders:       make(map[string]int),
 		groups:            make(map[string]*metricGroup),
+		extremeQuantiles:  extremeQuantiles,
+		repairHistograms:  repairHistograms,
+		nanBuckets:        nanBuckets,
 	}
 }
 
@@ -173,6 +198,10 @@ func (mf *metricFamily) Add(metricName string, ls labels.Labels, t int64, v floa
 			mg.complexValue = append(mg.complexValue, &dataPoint{value: v, boundary: boundary})
 		}
 	default:
+		if mf.metadata.Type == textparse.MetricTypeInfo {
+			// info metrics are constant, their value is always 1
+			v = 1
+		}
 		mg.value = v
 	}
 
@@ -233,6 +262,14 @@ func (mf *metricFamily) ToMetric() (*metricspb.Metric, int, int) {
 	return nil, mf.droppedTimeseries, mf.droppedTimeseries
 }
 
+func (mf *metricFamily) RepairedHistograms() int {
+	return mf.repairedHistograms
+}
+
+func (mf *metricFamily) NaNBucketHistograms() int {
+	return mf.nanBucketHistograms
+}
+
 type dataPoint struct {
 	value    float64
 	boundary float64
@@ -259,11 +296,75 @@ func (mg *metricGroup) sortPoints() {
 	})
 }
 
+// repairHistogram synthesizes the missing _count of the histogram from its +Inf bucket and estimates its missing
+// _sum from its buckets. It returns false if the histogram can't be repaired, having no +Inf bucket to count from.
+func (mg *metricGroup) repairHistogram() bool {
+	mg.sortPoints()
+	last := mg.complexValue[len(mg.complexValue)-1]
+	if !math.IsInf(last.boundary, 1) {
+		return false
+	}
+	if !mg.hasCount {
+		mg.count = last.value
+		mg.hasCount = true
+	}
+	if !mg.hasSum {
+		mg.sum = mg.estimateSum()
+		mg.hasSum = true
+	}
+	mg.family.repairedHistograms++
+	return true
+}
+
+// estimateSum estimates the sum of the observations of the histogram assuming the observations of a bucket are at
+// its middle, as prometheus does when computing quantiles. The observations of the first bucket are placed between 0
+// and its bound if positive, at its bound otherwise, and the ones of the +Inf bucket at the largest finite bound.
+// The buckets must be sorted.
+func (mg *metricGroup) estimateSum() float64 {
+	sum := 0.0
+	for i, point := range mg.complexValue {
+		count := point.value
+		if i != 0 {
+			count -= mg.complexValue[i-1].value
+		}
+		if count <= 0 {
+			continue
+		}
+		var observation float64
+		switch {
+		case math.IsInf(point.boundary, 1):
+			if i == 0 {
+				// A single +Inf bucket tells nothing about the observations.
+				return 0
+			}
+			observation = mg.complexValue[i-1].boundary
+		case i == 0:
+			observation = point.boundary
+			if point.boundary > 0 {
+				observation = point.boundary / 2
+			}
+		default:
+			observation = (mg.complexValue[i-1].boundary + point.boundary) / 2
+		}
+		sum += count * observation
+	}
+	return sum
+}
+
 func (mg *metricGroup) toDistributionTimeSeries(orderedLabelKeys []string) *metricspb.TimeSeries {
-	if !(mg.hasCount && mg.hasSum) || len(mg.complexValue) == 0 {
+	if len(mg.complexValue) == 0 {
 		return nil
 	}
 	mg.sortPoints()
+	if hasNaNBucket(mg.complexValue) {
+		mg.family.nanBucketHistograms++
+		if mg.complexValue = mg.family.nanBuckets.buckets(mg.complexValue); mg.complexValue == nil {
+			return nil
+		}
+	}
+	if !(mg.hasCount && mg.hasSum) && !(mg.family.repairHistograms && mg.repairHistogram()) {
+		return nil
+	}
 	// for OCAgent Proto, the bounds won't include +inf
 	bounds := make([]float64, len(mg.complexValue)-1)
 	buckets := make([]*metricspb.DistributionValue_Bucket, len(mg.complexValue))
@@ -314,8 +415,9 @@ func (mg *metricGroup) toSummaryTimeSeries(orderedLabelKeys []string) *metricspb
 		return nil
 	}
 	mg.sortPoints()
-	percentiles := make([]*metricspb.SummaryValue_Snapshot_ValueAtPercentile, len(mg.complexValue))
-	for i, p := range mg.complexValue {
+	quantiles := mg.family.extremeQuantiles.quantiles(mg.complexValue)
+	percentiles := make([]*metricspb.SummaryValue_Snapshot_ValueAtPercentile, len(quantiles))
+	for i, p := range quantiles {
 		percentiles[i] =
 			&metricspb.SummaryValue_Snapshot_ValueAtPercentile{Percentile: p.boundary * 100, Value: p.value}
 	}
@@ -15,6 +15,8 @@
 package internal
 
 import (
+	"container/list"
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -23,6 +25,8 @@ import (
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
 )
 
 // Notes on garbage collection (gc):
@@ -58,6 +62,15 @@ import (
 //    the gc of timeseriesMaps can be moved to the end of MetricsAdjuster().AdjustMetrics(). This
 //    approach requires adding 'lastGC' Time and (potentially) a gcInterval duration to
 //    timeseriesMap so the current approach is used instead.
+//
+// Max series:
+// The gc only bounds the memory used by the JobsMap to the series seen within two gc intervals, a
+// burst of high cardinality targets can exhaust the memory before the next gc. With a max number of
+// series set, the series of all the timeseriesMaps are also ordered in a seriesLRU, from the most
+// to the least recently scraped. Once a scrape is adjusted, the least recently scraped series
+// beyond the max are evicted from their timeseriesMap, their next point is then handled as an
+// initial one. The eviction happens once the timeseriesMap of the scrape is unlocked, the max can
+// only be exceeded by the new series of the scrapes being adjusted.
 
 // timeseriesinfo contains the information necessary to adjust from the initial point and to detect
 // resets.
@@ -65,6 +78,13 @@ type timeseriesinfo struct {
 	mark     bool
 	initial  *metricspb.TimeSeries
 	previous *metricspb.TimeSeries
+
+	// tsm and sig locate the timeseriesinfo to evict it, see seriesLRU. The fields below are
+	// guarded by the seriesLRU.
+	tsm     *timeseriesMap
+	sig     string
+	elem    *list.Element
+	evicted bool
 }
 
 // timeseriesMap maps from a timeseries instance (metric * label values) to the timeseries info for
@@ -73,6 +93,8 @@ type timeseriesMap struct {
 	sync.RWMutex
 	mark   bool
 	tsiMap map[string]*timeseriesinfo
+	// lru is nil without a max number of series.
+	lru *seriesLRU
 }
 
 // Get the timeseriesinfo for the timeseries associated with the metric and label values.
@@ -81,9 +103,16 @@ func (tsm *timeseriesMap) get(
 	name := metric.GetMetricDescriptor().GetName()
 	sig := getTimeseriesSignature(name, values)
 	tsi, ok := tsm.tsiMap[sig]
+	if ok && tsm.lru != nil && !tsm.lru.touch(tsi) {
+		// The series was evicted, it is now pending removal.
+		ok = false
+	}
 	if !ok {
-		tsi = &timeseriesinfo{}
+		tsi = &timeseriesinfo{tsm: tsm, sig: sig}
 		tsm.tsiMap[sig] = tsi
+		if tsm.lru != nil {
+			tsm.lru.touch(tsi)
+		}
 	}
 	tsm.mark = true
 	tsi.mark = true
@@ -101,6 +130,9 @@ func (tsm *timeseriesMap) gc() {
 	for ts, tsi := range tsm.tsiMap {
 		if !tsi.mark {
 			delete(tsm.tsiMap, ts)
+			if tsm.lru != nil {
+				tsm.lru.remove(tsi)
+			}
 		} else {
 			tsi.mark = false
 		}
@@ -108,8 +140,95 @@ func (tsm *timeseriesMap) gc() {
 	tsm.mark = false
 }
 
-func newTimeseriesMap() *timeseriesMap {
-	return &timeseriesMap{mark: true, tsiMap: map[string]*timeseriesinfo{}}
+// removeAll removes the timeseries from the seriesLRU, once the timeseriesMap is removed from the JobsMap.
+func (tsm *timeseriesMap) removeAll() {
+	if tsm.lru == nil {
+		return
+	}
+	tsm.Lock()
+	defer tsm.Unlock()
+	for _, tsi := range tsm.tsiMap {
+		tsm.lru.remove(tsi)
+	}
+}
+
+// removeEvicted removes the given evicted timeseries, unless they were replaced since.
+func (tsm *timeseriesMap) removeEvicted(evicted []*timeseriesinfo) {
+	tsm.Lock()
+	defer tsm.Unlock()
+	for _, tsi := range evicted {
+		if tsm.tsiMap[tsi.sig] == tsi {
+			delete(tsm.tsiMap, tsi.sig)
+		}
+	}
+}
+
+func newTimeseriesMap(lru *seriesLRU) *timeseriesMap {
+	return &timeseriesMap{mark: true, tsiMap: map[string]*timeseriesinfo{}, lru: lru}
+}
+
+// seriesLRU orders the timeseries of all the targets from the most to the least recently scraped, to evict the
+// least recently scraped ones beyond the max number of series.
+type seriesLRU struct {
+	sync.Mutex
+	maxSeries int
+	list      *list.List
+}
+
+func newSeriesLRU(maxSeries int) *seriesLRU {
+	return &seriesLRU{maxSeries: maxSeries, list: list.New()}
+}
+
+// touch marks the timeseries as the most recently scraped one, and returns false if it was evicted.
+func (l *seriesLRU) touch(tsi *timeseriesinfo) bool {
+	l.Lock()
+	defer l.Unlock()
+	if tsi.evicted {
+		return false
+	}
+	if tsi.elem == nil {
+		tsi.elem = l.list.PushFront(tsi)
+	} else {
+		l.list.MoveToFront(tsi.elem)
+	}
+	return true
+}
+
+// remove removes the timeseries, removed by the gc.
+func (l *seriesLRU) remove(tsi *timeseriesinfo) {
+	l.Lock()
+	defer l.Unlock()
+	if tsi.elem != nil {
+		l.list.Remove(tsi.elem)
+		tsi.elem = nil
+	}
+}
+
+// evict removes the least recently scraped timeseries beyond the max, and returns them keyed by timeseriesMap
+// for them to be removed from their map.
+func (l *seriesLRU) evict() (map[*timeseriesMap][]*timeseriesinfo, int) {
+	l.Lock()
+	defer l.Unlock()
+	if l.list.Len() <= l.maxSeries {
+		return nil, 0
+	}
+	evicted := make(map[*timeseriesMap][]*timeseriesinfo)
+	n := 0
+	for l.list.Len() > l.maxSeries {
+		tsi := l.list.Remove(l.list.Back()).(*timeseriesinfo)
+		tsi.elem = nil
+		tsi.evicted = true
+		evicted[tsi.tsm] = append(evicted[tsi.tsm], tsi)
+		n++
+	}
+	return evicted, n
+}
+
+// len returns the number of timeseries tracked.
+func (l *seriesLRU) len() int {
+	l.Lock()
+	defer l.Unlock()
+	return l.list.Len()
 }
 
 // Create a unique timeseries signature consisting of the metric name and label values.
@@ -123,12 +242,14 @@ func getTimeseriesSignature(name string, values []*metricspb.LabelValue) string
 	return fmt.Sprintf("%s,%s", name, strings.Join(labelValues, ","))
 }
 
-// JobsMap maps from a job instance to a map of timeseries instances for the job.
+// JobsMap maps from a target to a map of timeseries instances for the target.
 type JobsMap struct {
 	sync.RWMutex
 	gcInterval time.Duration
 	lastGC     time.Time
 	jobsMap    map[string]*timeseriesMap
+	// lru is nil without a max number of series.
+	lru *seriesLRU
 }
 
 // NewJobsMap creates a new (empty) JobsMap.
@@ -136,6 +257,29 @@ func NewJobsMap(gcInterval time.Duration) *JobsMap {
 	return &JobsMap{gcInterval: gcInterval, lastGC: time.Now(), jobsMap: make(map[string]*timeseriesMap)}
 }
 
+// SetMaxSeries caps the number of timeseries tracked across all the targets, evicting the least recently scraped
+// ones beyond it, see EvictSeries. It must be called before the JobsMap is used. 0 means no limit.
+func (jm *JobsMap) SetMaxSeries(maxSeries int) {
+	if maxSeries > 0 {
+		jm.lru = newSeriesLRU(maxSeries)
+	}
+}
+
+// EvictSeries evicts the least recently scraped timeseries beyond the max number of series, if any, and records
+// their number. It must not be called with a timeseriesMap locked.
+func (jm *JobsMap) EvictSeries(ctx context.Context) {
+	if jm.lru == nil {
+		return
+	}
+	evicted, n := jm.lru.evict()
+	for tsm, tsis := range evicted {
+		tsm.removeEvicted(tsis)
+	}
+	if n > 0 {
+		observability.RecordEvictedSeriesForMetricsReceiver(ctx, n)
+	}
+}
+
 // Remove jobs and timeseries that have aged out.
 func (jm *JobsMap) gc() {
 	jm.Lock()
@@ -145,6 +289,7 @@ func (jm *JobsMap) gc() {
 		for sig, tsm := range jm.jobsMap {
 			if !tsm.mark {
 				delete(jm.jobsMap, sig)
+				tsm.removeAll()
 			} else {
 				tsm.gc()
 			}
@@ -160,8 +305,8 @@ func (jm *JobsMap) maybeGC() {
 	}
 }
 
-func (jm *JobsMap) get(job, instance string) *timeseriesMap {
-	sig := job + ":" + instance
+// get returns the timeseries of the target identified by the given key, see targetKey.
+func (jm *JobsMap) get(sig string) *timeseriesMap {
 	jm.RLock()
 	tsm, ok := jm.jobsMap[sig]
 	jm.RUnlock()
@@ -175,11 +320,17 @@ func (jm *JobsMap) get(job, instance string) *timeseriesMap {
 	if ok2 {
 		return tsm2
 	}
-	tsm2 = newTimeseriesMap()
+	tsm2 = newTimeseriesMap(jm.lru)
 	jm.jobsMap[sig] = tsm2
 	return tsm2
 }
 
+// targetKey returns the key of the target in the JobsMap. The job, instance, metrics path and target labels are all
+// part of it, so that the targets sharing an address never share the timeseries used to detect resets.
+func targetKey(job, instance string, mc MetadataCache) string {
+	return job + "\xff" + instance + "\xff" + mc.MetricsPath() + "\xff" + mc.TargetLabels().String()
+}
+
 // MetricsAdjuster takes a map from a metric instance to the initial point in the metrics instance
 // and provides AdjustMetrics, which takes a sequence of metrics and adjust their values based on
 // the initial points.
@@ -15,6 +15,8 @@
 package internal
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -22,7 +24,11 @@ import (
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/prometheus/prometheus/pkg/labels"
 	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
 )
 
 func Test_gauge(t *testing.T) {
@@ -39,7 +45,7 @@ func Test_gauge(t *testing.T) {
 		[]*metricspb.Metric{gauge(k1k2, timeseries(3, v1v2, double(3, 55)))},
 		[]*metricspb.Metric{gauge(k1k2, timeseries(3, v1v2, double(3, 55)))},
 	}}
-	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job", "0"), script)
+	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job:0"), script)
 }
 
 func Test_gaugeDistribution(t *testing.T) {
@@ -56,7 +62,7 @@ func Test_gaugeDistribution(t *testing.T) {
 		[]*metricspb.Metric{gaugeDist(k1k2, timeseries(3, v1v2, dist(3, bounds0, []int64{2, 0, 1, 5})))},
 		[]*metricspb.Metric{gaugeDist(k1k2, timeseries(3, v1v2, dist(3, bounds0, []int64{2, 0, 1, 5})))},
 	}}
-	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job", "0"), script)
+	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job:0"), script)
 }
 
 func Test_cumulative(t *testing.T) {
@@ -77,7 +83,7 @@ func Test_cumulative(t *testing.T) {
 		[]*metricspb.Metric{cumulative(k1k2, timeseries(4, v1v2, double(4, 72)))},
 		[]*metricspb.Metric{cumulative(k1k2, timeseries(3, v1v2, double(4, 17)))},
 	}}
-	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job", "0"), script)
+	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job:0"), script)
 }
 
 func Test_cumulativeDistribution(t *testing.T) {
@@ -98,7 +104,7 @@ func Test_cumulativeDistribution(t *testing.T) {
 		[]*metricspb.Metric{cumulativeDist(k1k2, timeseries(4, v1v2, dist(4, bounds0, []int64{7, 4, 2, 12})))},
 		[]*metricspb.Metric{cumulativeDist(k1k2, timeseries(3, v1v2, dist(4, bounds0, []int64{2, 1, 0, 5})))},
 	}}
-	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job", "0"), script)
+	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job:0"), script)
 }
 
 func Test_summary(t *testing.T) {
@@ -119,7 +125,7 @@ func Test_summary(t *testing.T) {
 		[]*metricspb.Metric{summary(k1k2, timeseries(4, v1v2, summ(4, 14, 96, percent0, []float64{9, 47, 8})))},
 		[]*metricspb.Metric{summary(k1k2, timeseries(3, v1v2, summ(4, 2, 30, percent0, []float64{9, 47, 8})))},
 	}}
-	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job", "0"), script)
+	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job:0"), script)
 }
 
 func Test_multiMetrics(t *testing.T) {
@@ -178,7 +184,7 @@ func Test_multiMetrics(t *testing.T) {
 			summary(k1k2, timeseries(3, v1v2, summ(4, 2, 30, percent0, []float64{9, 47, 8}))),
 		},
 	}}
-	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job", "0"), script)
+	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job:0"), script)
 }
 
 func Test_multiTimeseries(t *testing.T) {
@@ -207,7 +213,7 @@ func Test_multiTimeseries(t *testing.T) {
 		[]*metricspb.Metric{
 			cumulative(k1k2, timeseries(4, v1v2, double(5, 3)), timeseries(2, v10v20, double(5, 45)), timeseries(4, v100v200, double(5, 12)))},
 	}}
-	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job", "0"), script)
+	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job:0"), script)
 }
 
 func Test_emptyLabels(t *testing.T) {
@@ -228,7 +234,7 @@ func Test_emptyLabels(t *testing.T) {
 		[]*metricspb.Metric{cumulative(k1k2k3, timeseries(3, []string{"", "", ""}, double(3, 88)))},
 		[]*metricspb.Metric{cumulative(k1k2k3, timeseries(1, []string{"", "", ""}, double(3, 44)))},
 	}}
-	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job", "0"), script)
+	runScript(t, NewJobsMap(time.Duration(time.Minute)).get("job:0"), script)
 }
 
 func Test_tsGC(t *testing.T) {
@@ -268,15 +274,15 @@ func Test_tsGC(t *testing.T) {
 	jobsMap := NewJobsMap(time.Duration(time.Minute))
 
 	// run round 1
-	runScript(t, jobsMap.get("job", "0"), script1)
+	runScript(t, jobsMap.get("job:0"), script1)
 	// gc the tsmap, unmarking all entries
-	jobsMap.get("job", "0").gc()
+	jobsMap.get("job:0").gc()
 	// run round 2 - update metrics first timeseries only
-	runScript(t, jobsMap.get("job", "0"), script2)
+	runScript(t, jobsMap.get("job:0"), script2)
 	// gc the tsmap, collecting umarked entries
-	jobsMap.get("job", "0").gc()
+	jobsMap.get("job:0").gc()
 	// run round 3 - verify that metrics second timeseries have been gc'd
-	runScript(t, jobsMap.get("job", "0"), script3)
+	runScript(t, jobsMap.get("job:0"), script3)
 }
 
 func Test_jobGC(t *testing.T) {
@@ -308,17 +314,145 @@ func Test_jobGC(t *testing.T) {
 	jobsMap := NewJobsMap(gcInterval)
 
 	// run job 1, round 1 - all entries marked
-	runScript(t, jobsMap.get("job", "0"), job1Script1)
+	runScript(t, jobsMap.get("job:0"), job1Script1)
 	// sleep longer than gcInterval to enable job gc in the next run
 	time.Sleep(2 * gcInterval)
 	// run job 2, round1 - trigger job gc, unmarking all entries
-	runScript(t, jobsMap.get("job", "1"), job2Script1)
+	runScript(t, jobsMap.get("job:1"), job2Script1)
 	// sleep longer than gcInterval to enable job gc in the next run
 	time.Sleep(2 * gcInterval)
 	// re-run job 2, round1 - trigger job gc, removing unmarked entries
-	runScript(t, jobsMap.get("job", "1"), job2Script1)
+	runScript(t, jobsMap.get("job:1"), job2Script1)
 	// run job 1, round 2 - verify that all job 1 timeseries have been gc'd
-	runScript(t, jobsMap.get("job", "0"), job1Script2)
+	runScript(t, jobsMap.get("job:0"), job1Script2)
+}
+
+func Test_maxSeries(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+	ctx := observability.ContextWithReceiverName(context.Background(), "prometheus")
+
+	jobsMap := NewJobsMap(time.Minute)
+	jobsMap.SetMaxSeries(3)
+	adjust := func(target string, script []*metricsAdjusterTest) {
+		runScript(t, jobsMap.get(target), script)
+		jobsMap.EvictSeries(ctx)
+	}
+
+	// Target 0 has two series, target 1 two more: the series of target 0 scraped first is evicted.
+	adjust("job:0", []*metricsAdjusterTest{{
+		"MaxSeries: target 0, round 1 - initial instances, adjusted should be empty",
+		[]*metricspb.Metric{cumulative(k1k2, timeseries(1, v1v2, double(1, 44)))},
+		[]*metricspb.Metric{},
+	}, {
+		"MaxSeries: target 0, round 2 - new instance, adjusted should be empty",
+		[]*metricspb.Metric{cumulative(k1k2, timeseries(1, v10v20, double(2, 20)))},
+		[]*metricspb.Metric{},
+	}})
+	adjust("job:1", []*metricsAdjusterTest{{
+		"MaxSeries: target 1, round 1 - initial instances, adjusted should be empty",
+		[]*metricspb.Metric{cumulative(k1k2, timeseries(1, v1v2, double(1, 10)), timeseries(1, v10v20, double(1, 10)))},
+		[]*metricspb.Metric{},
+	}})
+	if n := jobsMap.lru.len(); n != 3 {
+		t.Errorf("expecting the series to be capped to 3, got %d", n)
+	}
+	if n := len(jobsMap.get("job:0").tsiMap); n != 1 {
+		t.Errorf("expecting the least recently scraped series of target 0 to be removed, got %d series", n)
+	}
+	if err := observabilitytest.CheckValueViewReceiverEvictedSeries("prometheus", 1); err != nil {
+		t.Error(err)
+	}
+
+	// The evicted series starts over, the series kept is adjusted.
+	adjust("job:0", []*metricsAdjusterTest{{
+		"MaxSeries: target 0, round 3 - evicted instance is initial again, kept one is adjusted",
+		[]*metricspb.Metric{cumulative(k1k2, timeseries(3, v1v2, double(3, 66)), timeseries(3, v10v20, double(3, 30)))},
+		[]*metricspb.Metric{cumulative(k1k2, timeseries(1, v10v20, double(3, 10)))},
+	}})
+	if err := observabilitytest.CheckValueViewReceiverEvictedSeries("prometheus", 2); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_maxSeriesBurst(t *testing.T) {
+	const maxSeries = 50
+	jobsMap := NewJobsMap(time.Minute)
+	jobsMap.SetMaxSeries(maxSeries)
+	ctx := observability.ContextWithReceiverName(context.Background(), "prometheus")
+	l := zap.NewNop().Sugar()
+
+	// A burst of high cardinality targets.
+	for target := 0; target < 20; target++ {
+		var series []*metricspb.TimeSeries
+		for i := 0; i < 100; i++ {
+			series = append(series, timeseries(1, []string{"v", fmt.Sprint(i)}, double(1, 1)))
+		}
+		NewMetricsAdjuster(jobsMap.get(fmt.Sprintf("job:%d", target)), l).AdjustMetrics([]*metricspb.Metric{cumulative(k1k2, series...)})
+		jobsMap.EvictSeries(ctx)
+
+		tracked := 0
+		for _, tsm := range jobsMap.jobsMap {
+			tracked += len(tsm.tsiMap)
+		}
+		if tracked != maxSeries || jobsMap.lru.len() != maxSeries {
+			t.Fatalf("expecting %d series tracked after target %d, got %d in the maps and %d in the LRU",
+				maxSeries, target, tracked, jobsMap.lru.len())
+		}
+	}
+
+	// The gc removes the series of the targets no longer scraped from the LRU too.
+	jobsMap.lastGC = time.Time{}
+	jobsMap.gc()
+	jobsMap.lastGC = time.Time{}
+	jobsMap.gc()
+	if n := jobsMap.lru.len(); n != 0 {
+		t.Errorf("expecting the gc to empty the LRU, got %d series", n)
+	}
+}
+
+// targetCache is a MetadataCache of a target with the given path and labels.
+type targetCache struct {
+	*mockMetadataCache
+	path   string
+	labels labels.Labels
+}
+
+func (tc *targetCache) TargetLabels() labels.Labels {
+	return tc.labels
+}
+
+func (tc *targetCache) MetricsPath() string {
+	return tc.path
+}
+
+func Test_targetKey(t *testing.T) {
+	target := func(path string, ls ...string) MetadataCache {
+		return &targetCache{mockMetadataCache: newMockMetadataCache(nil), path: path, labels: labels.FromStrings(ls...)}
+	}
+	keys := []string{
+		targetKey("job", "localhost:8080", target("/metrics")),
+		targetKey("job", "localhost:8080", target("/admin/metrics")),
+		targetKey("job", "localhost:8080", target("/metrics", "shard", "1")),
+		targetKey("job", "localhost:8080", target("/metrics", "shard", "2")),
+		targetKey("other", "localhost:8080", target("/metrics")),
+		targetKey("job", "localhost:8081", target("/metrics")),
+	}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if seen[key] {
+			t.Errorf("expecting distinct targets to have distinct keys, got %q twice", key)
+		}
+		seen[key] = true
+	}
+
+	jobsMap := NewJobsMap(time.Minute)
+	if jobsMap.get(keys[0]) == jobsMap.get(keys[1]) {
+		t.Errorf("expecting distinct targets not to share their timeseries")
+	}
+	if jobsMap.get(keys[0]) != jobsMap.get(targetKey("job", "localhost:8080", target("/metrics"))) {
+		t.Errorf("expecting the same target to keep its timeseries")
+	}
 }
 
 var (
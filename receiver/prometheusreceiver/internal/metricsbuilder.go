@@ -27,11 +27,18 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/textparse"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/histogram"
 )
 
 const metricsSuffixCount = "_count"
 const metricsSuffixBucket = "_bucket"
 const metricsSuffixSum = "_sum"
+const metricsSuffixInfo = "_info"
+
+// names of the internal metrics prometheus reports for every scrape
+const upMetricName = "up"
+const scrapedSamplesMetricName = "scrape_samples_scraped"
 
 var trimmableSuffixes = []string{metricsSuffixBucket, metricsSuffixCount, metricsSuffixSum}
 var errNoDataToBuild = errors.New("there's no data to build")
@@ -43,12 +50,28 @@ var dummyMetrics = make([]*metricspb.Metric, 0)
 type metricBuilder struct {
 	hasData           bool
 	hasInternalMetric bool
+	scrapeReport      map[string]float64
+	// scrapeReportTime is the timestamp of the scrape report, in milliseconds.
+	scrapeReportTime  int64
 	mc                MetadataCache
 	metrics           []*metricspb.Metric
 	numTimeseries     int
 	droppedTimeseries int
 	logger            *zap.SugaredLogger
 	currentMf         MetricFamily
+	// extremeQuantiles defines how the extreme quantiles of the summaries are converted.
+	extremeQuantiles ExtremeQuantilePolicy
+	// repairHistograms is whether the histogram series missing their _count or _sum are repaired rather than
+	// dropped.
+	repairHistograms bool
+	// repairedHistograms is the number of histogram series of the completed families which were repaired.
+	repairedHistograms int
+	// nanBuckets defines how the histograms with a NaN bucket are converted.
+	nanBuckets NaNBucketPolicy
+	// nanBucketHistograms is the number of histogram series of the completed families with a NaN bucket.
+	nanBucketHistograms int
+	// completedSeries is the number of series of the completed families held in metrics.
+	completedSeries int
 }
 
 // newMetricBuilder creates a MetricBuilder which is allowed to feed all the datapoints from a single prometheus
@@ -74,6 +97,11 @@ func (b *metricBuilder) AddDataPoint(ls labels.Labels, t int64, v float64) error
 		return errMetricNameNotFound
 	} else if shouldSkip(metricName) {
 		b.hasInternalMetric = true
+		if b.scrapeReport == nil {
+			b.scrapeReport = make(map[string]float64)
+		}
+		b.scrapeReport[metricName] = v
+		b.scrapeReportTime = t
 		lm := ls.Map()
 		delete(lm, model.MetricNameLabel)
 		b.logger.Debugw("skip internal metric", "name", metricName, "ts", t, "value", v, "labels", lm)
@@ -86,17 +114,40 @@ func (b *metricBuilder) AddDataPoint(ls labels.Labels, t int64, v float64) error
 		m, ts, dts := b.currentMf.ToMetric()
 		b.numTimeseries += ts
 		b.droppedTimeseries += dts
+		b.repairedHistograms += b.currentMf.RepairedHistograms()
+		b.nanBucketHistograms += b.currentMf.NaNBucketHistograms()
 		if m != nil {
 			b.metrics = append(b.metrics, m)
+			b.completedSeries += len(m.Timeseries)
 		}
-		b.currentMf = newMetricFamily(metricName, b.mc)
+		b.currentMf = newMetricFamily(metricName, b.mc, b.extremeQuantiles, b.repairHistograms, b.nanBuckets)
 	} else if b.currentMf == nil {
-		b.currentMf = newMetricFamily(metricName, b.mc)
+		b.currentMf = newMetricFamily(metricName, b.mc, b.extremeQuantiles, b.repairHistograms, b.nanBuckets)
 	}
 
 	return b.currentMf.Add(metricName, ls, t, v)
 }
 
+// isEmptyScrape returns true if the added data points are the report of a successful scrape which returned no
+// samples, e.g. when the target responded with an empty body
+func (b *metricBuilder) isEmptyScrape() bool {
+	up, hasUp := b.scrapeReport[upMetricName]
+	samples, hasSamples := b.scrapeReport[scrapedSamplesMetricName]
+	return hasUp && hasSamples && up == 1 && samples == 0
+}
+
+// takeCompleted returns the metrics of the completed families, and forgets them, once they hold at least the given
+// number of series, nil otherwise. The family being added is not complete until a sample of another family is added.
+func (b *metricBuilder) takeCompleted(minSeries int) []*metricspb.Metric {
+	if len(b.metrics) == 0 || b.completedSeries < minSeries {
+		return nil
+	}
+	completed := b.metrics
+	b.metrics = make([]*metricspb.Metric, 0)
+	b.completedSeries = 0
+	return completed
+}
+
 // Build is to build an opencensus data.MetricsData based on all added data complexValue
 func (b *metricBuilder) Build() ([]*metricspb.Metric, int, int, error) {
 	if !b.hasData {
@@ -110,6 +161,8 @@ func (b *metricBuilder) Build() ([]*metricspb.Metric, int, int, error) {
 		m, ts, dts := b.currentMf.ToMetric()
 		b.numTimeseries += ts
 		b.droppedTimeseries += dts
+		b.repairedHistograms += b.currentMf.RepairedHistograms()
+		b.nanBucketHistograms += b.currentMf.NaNBucketHistograms()
 		if m != nil {
 			b.metrics = append(b.metrics, m)
 		}
@@ -135,7 +188,10 @@ func isUsefulLabel(mType metricspb.MetricDescriptor_Type, labelKey string) bool
 	case model.QuantileLabel:
 		result = mType != metricspb.MetricDescriptor_SUMMARY
 	default:
-		result = true
+		// labels with the reserved prefix, e.g. __name__ or anything left over
+		// from relabeling, are prometheus internals and must never leak into the
+		// OC label set.
+		result = !strings.HasPrefix(labelKey, model.ReservedLabelPrefix)
 	}
 	return result
 }
@@ -178,6 +234,9 @@ func getBoundary(metricType metricspb.MetricDescriptor_Type, labels labels.Label
 		return 0, errEmptyBoundaryLabel
 	}
 
+	if labelName == model.BucketLabel {
+		return histogram.ParseBound(v)
+	}
 	return strconv.ParseFloat(v, 64)
 }
 
@@ -188,6 +247,10 @@ func convToOCAMetricType(metricType textparse.MetricType) metricspb.MetricDescri
 		return metricspb.MetricDescriptor_CUMULATIVE_DOUBLE
 	case textparse.MetricTypeGauge:
 		return metricspb.MetricDescriptor_GAUGE_DOUBLE
+	// OpenMetrics info metrics are constant gauges whose labels hold the information, and every state of a stateset
+	// is a gauge series, with the state as label, whose value is 1 for the enabled states and 0 otherwise.
+	case textparse.MetricTypeInfo, textparse.MetricTypeStateset:
+		return metricspb.MetricDescriptor_GAUGE_DOUBLE
 	case textparse.MetricTypeHistogram:
 		return metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION
 	// dropping support for gaugehistogram for now until we have an official spec of its implementation
@@ -197,7 +260,7 @@ func convToOCAMetricType(metricType textparse.MetricType) metricspb.MetricDescri
 	case textparse.MetricTypeSummary:
 		return metricspb.MetricDescriptor_SUMMARY
 	default:
-		// including: textparse.MetricTypeUnknown, textparse.MetricTypeInfo, textparse.MetricTypeStateset
+		// including: textparse.MetricTypeUnknown
 		return metricspb.MetricDescriptor_UNSPECIFIED
 	}
 }
@@ -257,7 +320,7 @@ func timestampFromMs(timeAtMs int64) *timestamp.Timestamp {
 }
 
 func shouldSkip(metricName string) bool {
-	if metricName == "up" || strings.HasPrefix(metricName, "scrape_") {
+	if metricName == upMetricName || strings.HasPrefix(metricName, "scrape_") {
 		return true
 	}
 	return false
@@ -937,6 +937,118 @@ func Test_metricBuilder_histogram(t *testing.T) {
 	runBuilderTests(t, tests)
 }
 
+func Test_metricBuilder_repairHistograms(t *testing.T) {
+	tests := []struct {
+		name         string
+		pts          []*testDataPoint
+		wantCount    int64
+		wantSum      float64
+		wantRepaired int
+		wantDropped  bool
+	}{
+		{
+			name: "no-count",
+			pts: []*testDataPoint{
+				createDataPoint("hist_test", 1, "foo", "bar", "le", "10"),
+				createDataPoint("hist_test", 2, "foo", "bar", "le", "20"),
+				createDataPoint("hist_test", 3, "foo", "bar", "le", "+inf"),
+				createDataPoint("hist_test_sum", 99, "foo", "bar"),
+			},
+			wantCount:    3,
+			wantSum:      99,
+			wantRepaired: 1,
+		},
+		{
+			name: "no-sum",
+			pts: []*testDataPoint{
+				createDataPoint("hist_test", 1, "foo", "bar", "le", "10"),
+				createDataPoint("hist_test", 2, "foo", "bar", "le", "20"),
+				createDataPoint("hist_test", 3, "foo", "bar", "le", "+inf"),
+				createDataPoint("hist_test_count", 3, "foo", "bar"),
+			},
+			wantCount: 3,
+			// 1 observation at 5, 1 at 15 and 1 at 20, the largest finite bound.
+			wantSum:      40,
+			wantRepaired: 1,
+		},
+		{
+			name: "no-sum-and-count",
+			pts: []*testDataPoint{
+				createDataPoint("hist_test", 2, "foo", "bar", "le", "-1"),
+				createDataPoint("hist_test", 4, "foo", "bar", "le", "1"),
+				createDataPoint("hist_test", 4, "foo", "bar", "le", "+inf"),
+			},
+			wantCount:    4,
+			wantSum:      -2,
+			wantRepaired: 1,
+		},
+		{
+			name: "complete",
+			pts: []*testDataPoint{
+				createDataPoint("hist_test", 1, "foo", "bar", "le", "10"),
+				createDataPoint("hist_test", 3, "foo", "bar", "le", "+inf"),
+				createDataPoint("hist_test_sum", 99, "foo", "bar"),
+				createDataPoint("hist_test_count", 3, "foo", "bar"),
+			},
+			wantCount: 3,
+			wantSum:   99,
+		},
+		{
+			name: "no-inf-bucket",
+			pts: []*testDataPoint{
+				createDataPoint("hist_test", 1, "foo", "bar", "le", "10"),
+				createDataPoint("hist_test", 2, "foo", "bar", "le", "20"),
+				createDataPoint("hist_test_sum", 99, "foo", "bar"),
+			},
+			wantDropped: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMetricBuilder(newMockMetadataCache(testMetadata), testLogger)
+			b.repairHistograms = true
+			for _, pt := range tt.pts {
+				if err := b.AddDataPoint(pt.lb, startTs, pt.v); err != nil {
+					t.Fatal("unexpected error adding data", err)
+				}
+			}
+			metrics, _, dropped, err := b.Build()
+			if err != nil {
+				t.Fatal("unexpected error on build", err)
+			}
+			if b.repairedHistograms != tt.wantRepaired {
+				t.Errorf("repaired histograms = %v, want %v", b.repairedHistograms, tt.wantRepaired)
+			}
+			if tt.wantDropped {
+				if len(metrics) != 0 || dropped != 1 {
+					t.Errorf("histogram not dropped: metrics=%v dropped=%v", metrics, dropped)
+				}
+				return
+			}
+			if len(metrics) != 1 {
+				t.Fatalf("got %v metrics, want 1", len(metrics))
+			}
+			dv := metrics[0].Timeseries[0].Points[0].GetDistributionValue()
+			if dv.Count != tt.wantCount || dv.Sum != tt.wantSum {
+				t.Errorf("count=%v sum=%v, want count=%v sum=%v", dv.Count, dv.Sum, tt.wantCount, tt.wantSum)
+			}
+		})
+	}
+
+	// Without the repair, the histogram missing its count is dropped.
+	b := newMetricBuilder(newMockMetadataCache(testMetadata), testLogger)
+	for _, pt := range tests[0].pts {
+		if err := b.AddDataPoint(pt.lb, startTs, pt.v); err != nil {
+			t.Fatal("unexpected error adding data", err)
+		}
+	}
+	metrics, _, dropped, err := b.Build()
+	if err != nil || len(metrics) != 0 || dropped != 1 || b.repairedHistograms != 0 {
+		t.Errorf("histogram missing its count not dropped: metrics=%v dropped=%v err=%v", metrics, dropped, err)
+	}
+}
+
 func Test_metricBuilder_summary(t *testing.T) {
 	tests := []buildTestData{
 		{
@@ -1097,6 +1209,49 @@ func Test_metricBuilder_baddata(t *testing.T) {
 
 }
 
+func Test_metricBuilder_metricNameLabel(t *testing.T) {
+	mc := newMockMetadataCache(testMetadata)
+	b := newMetricBuilder(mc, testLogger)
+	pts := []*testDataPoint{
+		createDataPoint("counter_test", 100, "foo", "bar", "__tmp_label", "tmp"),
+		createDataPoint("gauge_test", 1, "foo", "bar"),
+		createDataPoint("hist_test", 1, "foo", "bar", "le", "10"),
+		createDataPoint("hist_test", 1, "foo", "bar", "le", "+Inf"),
+		createDataPoint("hist_test_sum", 1, "foo", "bar"),
+		createDataPoint("hist_test_count", 1, "foo", "bar"),
+		createDataPoint("summary_test", 1, "foo", "bar", "quantile", "0.5"),
+		createDataPoint("summary_test_sum", 1, "foo", "bar"),
+		createDataPoint("summary_test_count", 1, "foo", "bar"),
+	}
+	for _, pt := range pts {
+		if err := b.AddDataPoint(pt.lb, startTs, pt.v); err != nil {
+			t.Fatal("unexpected error adding data", err)
+		}
+	}
+	metrics, _, _, err := b.Build()
+	if err != nil {
+		t.Fatal("unexpected error on build", err)
+	}
+
+	wantNames := []string{"counter_test", "gauge_test", "hist_test", "summary_test"}
+	if len(metrics) != len(wantNames) {
+		t.Fatalf("got %d metrics, want %d", len(metrics), len(wantNames))
+	}
+	for i, m := range metrics {
+		if m.MetricDescriptor.Name != wantNames[i] {
+			t.Errorf("metric name = %q, want %q", m.MetricDescriptor.Name, wantNames[i])
+		}
+		if len(m.MetricDescriptor.LabelKeys) != 1 || m.MetricDescriptor.LabelKeys[0].Key != "foo" {
+			t.Errorf("metric %q has label keys %v, want only foo", m.MetricDescriptor.Name, m.MetricDescriptor.LabelKeys)
+		}
+		for _, ts := range m.Timeseries {
+			if len(ts.LabelValues) != 1 || ts.LabelValues[0].Value != "bar" {
+				t.Errorf("metric %q has label values %v, want only bar", m.MetricDescriptor.Name, ts.LabelValues)
+			}
+		}
+	}
+}
+
 func Test_isUsefulLabel(t *testing.T) {
 	type args struct {
 		mType    metricspb.MetricDescriptor_Type
@@ -1117,6 +1272,7 @@ func Test_isUsefulLabel(t *testing.T) {
 		{"bucketForCumulativeDistribution", args{metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION, model.BucketLabel}, false},
 		{"Quantile", args{metricspb.MetricDescriptor_GAUGE_DOUBLE, model.QuantileLabel}, true},
 		{"QuantileForSummay", args{metricspb.MetricDescriptor_SUMMARY, model.QuantileLabel}, false},
+		{"reservedPrefix", args{metricspb.MetricDescriptor_GAUGE_DOUBLE, "__tmp_label"}, false},
 		{"other", args{metricspb.MetricDescriptor_GAUGE_DOUBLE, "other"}, true},
 		{"empty", args{metricspb.MetricDescriptor_GAUGE_DOUBLE, ""}, true},
 	}
@@ -1189,6 +1345,7 @@ func Test_getBoundary(t *testing.T) {
 	ls := labels.FromStrings("le", "100.0", "foo", "bar", "quantile", "0.5")
 	ls2 := labels.FromStrings("foo", "bar")
 	ls3 := labels.FromStrings("le", "xyz", "foo", "bar", "quantile", "0.5")
+	ls4 := labels.FromStrings("le", "0.30000000000000004", "foo", "bar")
 	type args struct {
 		metricType metricspb.MetricDescriptor_Type
 		labels     labels.Labels
@@ -1203,6 +1360,7 @@ func Test_getBoundary(t *testing.T) {
 		{"gaugehistogram", args{metricspb.MetricDescriptor_GAUGE_DISTRIBUTION, ls}, 100.0, false},
 		{"gaugehistogram_no_label", args{metricspb.MetricDescriptor_GAUGE_DISTRIBUTION, ls2}, 0, true},
 		{"gaugehistogram_bad_value", args{metricspb.MetricDescriptor_GAUGE_DISTRIBUTION, ls3}, 0, true},
+		{"histogram_canonical_value", args{metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION, ls4}, 0.3, false},
 		{"summary", args{metricspb.MetricDescriptor_SUMMARY, ls}, 0.5, false},
 		{"otherType", args{metricspb.MetricDescriptor_GAUGE_DOUBLE, ls}, 0, true},
 	}
@@ -1231,8 +1389,8 @@ func Test_convToOCAMetricType(t *testing.T) {
 		{"histogram", textparse.MetricTypeHistogram, metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION},
 		{"guageHistogram", textparse.MetricTypeGaugeHistogram, metricspb.MetricDescriptor_UNSPECIFIED},
 		{"summary", textparse.MetricTypeSummary, metricspb.MetricDescriptor_SUMMARY},
-		{"info", textparse.MetricTypeInfo, metricspb.MetricDescriptor_UNSPECIFIED},
-		{"stateset", textparse.MetricTypeStateset, metricspb.MetricDescriptor_UNSPECIFIED},
+		{"info", textparse.MetricTypeInfo, metricspb.MetricDescriptor_GAUGE_DOUBLE},
+		{"stateset", textparse.MetricTypeStateset, metricspb.MetricDescriptor_GAUGE_DOUBLE},
 		{"unknown", textparse.MetricTypeUnknown, metricspb.MetricDescriptor_UNSPECIFIED},
 	}
 	for _, tt := range tests {
@@ -0,0 +1,62 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import "math"
+
+// NaNBucketPolicy defines how the histograms with a bucket whose value is NaN are converted. Such buckets can't be
+// converted to the counts of the OpenTelemetry buckets, which are integers.
+type NaNBucketPolicy string
+
+const (
+	// NaNBucketsKeep converts the NaN buckets as any other bucket.
+	NaNBucketsKeep NaNBucketPolicy = "keep"
+	// NaNBucketsDrop drops the histograms with a NaN bucket.
+	NaNBucketsDrop NaNBucketPolicy = "drop"
+	// NaNBucketsPrevious renormalizes the histograms with a NaN bucket, the cumulative value of a NaN bucket becoming
+	// the one of the previous bucket, or 0 for the first bucket, so that the NaN bucket is empty.
+	NaNBucketsPrevious NaNBucketPolicy = "previous"
+)
+
+// hasNaNBucket returns whether the value of any of the given buckets is NaN.
+func hasNaNBucket(points []*dataPoint) bool {
+	for _, point := range points {
+		if math.IsNaN(point.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// buckets returns the buckets of a histogram having a NaN bucket handled per policy, the given points must be sorted
+// by bound. It returns nil if the histogram must be dropped.
+func (p NaNBucketPolicy) buckets(points []*dataPoint) []*dataPoint {
+	switch p {
+	case NaNBucketsDrop:
+		return nil
+	case NaNBucketsPrevious:
+		buckets := make([]*dataPoint, len(points))
+		previous := 0.0
+		for i, point := range points {
+			if math.IsNaN(point.value) {
+				point = &dataPoint{boundary: point.boundary, value: previous}
+			}
+			buckets[i] = point
+			previous = point.value
+		}
+		return buckets
+	}
+	return points
+}
@@ -0,0 +1,127 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_metricBuilder_nanBuckets(t *testing.T) {
+	tests := []struct {
+		policy      NaNBucketPolicy
+		wantDropped bool
+		// wantBuckets are the counts of the buckets, nil if they are undefined.
+		wantBuckets []int64
+	}{
+		{
+			policy: NaNBucketsKeep,
+		},
+		{
+			policy:      NaNBucketsDrop,
+			wantDropped: true,
+		},
+		{
+			policy:      NaNBucketsPrevious,
+			wantBuckets: []int64{1, 0, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			b := newMetricBuilder(newMockMetadataCache(testMetadata), testLogger)
+			b.nanBuckets = tt.policy
+			for _, pt := range []*testDataPoint{
+				createDataPoint("hist_test", 1, "foo", "bar", "le", "10"),
+				createDataPoint("hist_test", math.NaN(), "foo", "bar", "le", "20"),
+				createDataPoint("hist_test", 3, "foo", "bar", "le", "+inf"),
+				createDataPoint("hist_test_sum", 99, "foo", "bar"),
+				createDataPoint("hist_test_count", 3, "foo", "bar"),
+			} {
+				if err := b.AddDataPoint(pt.lb, startTs, pt.v); err != nil {
+					t.Fatalf("unexpected error adding data %v", err)
+				}
+			}
+			metrics, _, dropped, err := b.Build()
+			if err != nil {
+				t.Fatalf("unexpected error on build %v", err)
+			}
+			// The histograms with a NaN bucket are counted whatever the policy.
+			if b.nanBucketHistograms != 1 {
+				t.Errorf("NaN bucket histograms = %v, want 1", b.nanBucketHistograms)
+			}
+			if tt.wantDropped {
+				if len(metrics) != 0 || dropped != 1 {
+					t.Errorf("histogram not dropped: metrics=%v dropped=%v", metrics, dropped)
+				}
+				return
+			}
+			if len(metrics) != 1 || len(metrics[0].Timeseries) != 1 || dropped != 0 {
+				t.Fatalf("expecting a single histogram, got %v dropped=%v", metrics, dropped)
+			}
+			dv := metrics[0].Timeseries[0].Points[0].GetDistributionValue()
+			if dv.Count != 3 || dv.Sum != 99 || len(dv.Buckets) != 3 {
+				t.Errorf("expecting count 3, sum 99 and 3 buckets, got %v", dv)
+			}
+			if tt.wantBuckets == nil {
+				return
+			}
+			got := make([]int64, len(dv.Buckets))
+			for i, bucket := range dv.Buckets {
+				got[i] = bucket.Count
+			}
+			if !reflect.DeepEqual(got, tt.wantBuckets) {
+				t.Errorf("buckets = %v, want %v", got, tt.wantBuckets)
+			}
+		})
+	}
+}
+
+func Test_nanBucketsPrevious(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []*dataPoint
+		want   []*dataPoint
+	}{
+		{
+			name:   "first_bucket",
+			points: []*dataPoint{{boundary: 1, value: math.NaN()}, {boundary: math.Inf(1), value: 2}},
+			want:   []*dataPoint{{boundary: 1, value: 0}, {boundary: math.Inf(1), value: 2}},
+		},
+		{
+			name: "consecutive_buckets",
+			points: []*dataPoint{{boundary: 1, value: 1}, {boundary: 2, value: math.NaN()},
+				{boundary: 3, value: math.NaN()}, {boundary: math.Inf(1), value: 2}},
+			want: []*dataPoint{{boundary: 1, value: 1}, {boundary: 2, value: 1}, {boundary: 3, value: 1},
+				{boundary: math.Inf(1), value: 2}},
+		},
+		{
+			name:   "inf_bucket",
+			points: []*dataPoint{{boundary: 1, value: 1}, {boundary: math.Inf(1), value: math.NaN()}},
+			want:   []*dataPoint{{boundary: 1, value: 1}, {boundary: math.Inf(1), value: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NaNBucketsPrevious.buckets(tt.points); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buckets = %v, want %v", got, tt.want)
+			}
+			// The points are shared with the metric group, they must not be modified.
+			if !hasNaNBucket(tt.points) {
+				t.Errorf("the given points were modified: %v", tt.points)
+			}
+		})
+	}
+}
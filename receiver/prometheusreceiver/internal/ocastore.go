@@ -20,6 +20,7 @@ import (
 	"io"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/scrape"
@@ -36,6 +37,28 @@ const (
 	runningStateStop
 )
 
+// EmptyScrapePolicy defines how a successful scrape that returned no samples, e.g. a 200 response with an empty
+// body, is handled. Empty scrapes are always counted, regardless of the policy.
+type EmptyScrapePolicy string
+
+const (
+	// EmptyScrapeSuccess treats an empty scrape as a success, the target has simply no metrics yet.
+	EmptyScrapeSuccess EmptyScrapePolicy = "success"
+	// EmptyScrapeWarn logs a warning for every empty scrape, as the target is likely broken.
+	EmptyScrapeWarn EmptyScrapePolicy = "warn"
+)
+
+// TimestampPolicy defines which timestamp is given to the scraped samples.
+type TimestampPolicy string
+
+const (
+	// TimestampHonor keeps the timestamps exposed by the target, the samples without one get the scrape time.
+	TimestampHonor TimestampPolicy = "honor"
+	// TimestampOverride gives every sample of a scrape the time the collector appends it, ignoring the timestamps
+	// exposed by the target, e.g. for backends rejecting the skewed timestamps of some targets.
+	TimestampOverride TimestampPolicy = "override"
+)
+
 var idSeq int64
 var noop = &noopAppender{}
 
@@ -44,6 +67,16 @@ type OcaStore interface {
 	scrape.Appendable
 	io.Closer
 	SetScrapeManager(*scrape.Manager)
+	SetScrapeIntervals(map[string]time.Duration)
+	SetSchemes(map[string]string)
+	SetTargetLabelsFile(*TargetLabelsFile)
+	SetLenientJobs(map[string]bool)
+	SetRecordScrapeSizes(bool)
+	SetEmitScrapeSuccess(bool)
+	SetExtremeQuantilePolicy(ExtremeQuantilePolicy)
+	SetRepairHistograms(bool)
+	SetNaNBucketPolicy(NaNBucketPolicy)
+	SetStreamingJobs(map[string]int)
 }
 
 // OpenCensus Store for prometheus
@@ -55,18 +88,67 @@ type ocaStore struct {
 	once    *sync.Once
 	ctx     context.Context
 	jobsMap *JobsMap
+	batcher *commitBatcher
+	// backpressure pauses the scrapes while the consumer is persistently slow, nil if disabled.
+	backpressure *backpressure
+	// limiter caps the number of scrapes processed concurrently, nil if unlimited.
+	limiter *scrapeLimiter
+	// scrapeIntervals holds a map[string]time.Duration of the scrape interval of each job.
+	scrapeIntervals atomic.Value
+	// schemes holds the scheme the targets of the jobs are scraped in, when it isn't the one of their scrape config.
+	schemes map[string]string
+	// targetLabelsFile holds the extra labels of the targets, nil if there are none.
+	targetLabelsFile *TargetLabelsFile
+	// lenientJobs holds a map[string]bool of the jobs whose scrapes are parsed leniently.
+	lenientJobs atomic.Value
+	// streamingJobs holds a map[string]int of the batch size of the jobs whose scrapes are streamed.
+	streamingJobs atomic.Value
+	// recordScrapeSizes is whether the size and the number of series of the scrapes are recorded.
+	recordScrapeSizes bool
+	// scrapeOutcomes holds the outcome of the scrapes of the targets, nil if the scrape success is not emitted.
+	scrapeOutcomes *scrapeOutcomes
+	scopes         map[string]Scope
+	// extremeQuantiles defines how the extreme quantiles of the summaries are converted.
+	extremeQuantiles ExtremeQuantilePolicy
+	// repairHistograms is whether the histogram series missing their _count or _sum are repaired rather than
+	// dropped.
+	repairHistograms bool
+	// nanBuckets defines how the histograms with a NaN bucket are converted.
+	nanBuckets NaNBucketPolicy
+
+	emptyScrapePolicy EmptyScrapePolicy
+	timestampPolicy   TimestampPolicy
 }
 
-// NewOcaStore returns an ocaStore instance, which can be acted as prometheus' scrape.Appendable
-func NewOcaStore(ctx context.Context, sink consumer.MetricsConsumer, logger *zap.SugaredLogger, jobsMap *JobsMap) OcaStore {
-	return &ocaStore{
-		running: runningStateInit,
-		ctx:     ctx,
-		sink:    sink,
-		logger:  logger,
-		once:    &sync.Once{},
-		jobsMap: jobsMap,
+// NewOcaStore returns an ocaStore instance, which can be acted as prometheus' scrape.Appendable. The samples are
+// timestamped according to the timestamp policy. The data committed by the scrapes is buffered according to the given
+// settings, the buffered data is flushed on Close. The scrapes are paused according to the backpressure settings
+// while the consumer is persistently slow. At most maxConcurrentScrapes scrapes are processed at a time, 0 means no
+// limit. The metrics of the jobs in scopes are attributed to the given instrumentation scope, scopes can be nil.
+func NewOcaStore(ctx context.Context, sink consumer.MetricsConsumer, logger *zap.SugaredLogger, jobsMap *JobsMap,
+	emptyScrapePolicy EmptyScrapePolicy, timestampPolicy TimestampPolicy, commitBatch CommitBatchSettings,
+	backpressure BackpressureSettings, maxConcurrentScrapes int, scopes map[string]Scope) OcaStore {
+	o := &ocaStore{
+		running:           runningStateInit,
+		ctx:               ctx,
+		sink:              sink,
+		logger:            logger,
+		once:              &sync.Once{},
+		jobsMap:           jobsMap,
+		scopes:            scopes,
+		emptyScrapePolicy: emptyScrapePolicy,
+		timestampPolicy:   timestampPolicy,
+		limiter:           newScrapeLimiter(maxConcurrentScrapes),
 	}
+	if backpressure.Threshold > 0 {
+		o.backpressure = newBackpressure(ctx, sink, backpressure)
+		o.sink = o.backpressure
+	}
+	if commitBatch.Window > 0 {
+		o.batcher = newCommitBatcher(ctx, o.sink, commitBatch)
+		o.sink = o.batcher
+	}
+	return o
 }
 
 // SetScrapeManager is used to config the underlying scrape.Manager as it's needed for OcaStore, otherwise OcaStore
@@ -77,10 +159,97 @@ func (o *ocaStore) SetScrapeManager(scrapeManager *scrape.Manager) {
 	}
 }
 
+// SetScrapeIntervals sets the scrape interval of each job. The data committed by a scrape is passed to the consumer
+// with a context whose deadline is the end of the scrape interval, measured from the start of the commit
+// transaction, so that a slow consumer cannot extend the scrape past its interval.
+func (o *ocaStore) SetScrapeIntervals(intervals map[string]time.Duration) {
+	o.scrapeIntervals.Store(intervals)
+}
+
+// SetTargetLabelsFile sets the file holding the extra labels added to the samples of the targets, it must be called
+// before the scrapes start.
+func (o *ocaStore) SetTargetLabelsFile(tlf *TargetLabelsFile) {
+	o.targetLabelsFile = tlf
+}
+
+// SetLenientJobs sets the jobs whose scrapes are parsed leniently: when the response of a target has invalid content,
+// the samples parsed before it are committed instead of being discarded.
+func (o *ocaStore) SetLenientJobs(jobs map[string]bool) {
+	o.lenientJobs.Store(jobs)
+}
+
+// SetStreamingJobs sets the jobs whose scrapes are streamed, with their batch size: the metric families of their pages
+// are passed to the consumer as they are completed, once they hold at least batch size series, instead of once the
+// whole page is converted.
+func (o *ocaStore) SetStreamingJobs(jobs map[string]int) {
+	o.streamingJobs.Store(jobs)
+}
+
+// SetSchemes sets the scheme the targets of the given jobs are scraped in, when it isn't the one of their scrape
+// config, e.g. because the job scrapes through a local proxy. It must be called before the scrapes start.
+func (o *ocaStore) SetSchemes(schemes map[string]string) {
+	o.schemes = schemes
+}
+
+// SetRecordScrapeSizes sets whether the size and the number of series of the scrapes are recorded, per job, it must
+// be called before the scrapes start.
+func (o *ocaStore) SetRecordScrapeSizes(record bool) {
+	o.recordScrapeSizes = record
+}
+
+// SetEmitScrapeSuccess sets whether a scrape_success gauge is emitted for every scrape, it must be called before the
+// scrapes start.
+func (o *ocaStore) SetEmitScrapeSuccess(emit bool) {
+	if emit {
+		o.scrapeOutcomes = newScrapeOutcomes()
+	} else {
+		o.scrapeOutcomes = nil
+	}
+}
+
+// SetExtremeQuantilePolicy sets how the extreme quantiles of the summaries are converted, it must be called before the
+// scrapes start.
+func (o *ocaStore) SetExtremeQuantilePolicy(policy ExtremeQuantilePolicy) {
+	o.extremeQuantiles = policy
+}
+
+// SetRepairHistograms sets whether the histogram series missing their _count or _sum are repaired rather than
+// dropped, it must be called before the scrapes start.
+func (o *ocaStore) SetRepairHistograms(repair bool) {
+	o.repairHistograms = repair
+}
+
+// SetNaNBucketPolicy sets how the histograms with a NaN bucket are converted, it must be called before the scrapes
+// start.
+func (o *ocaStore) SetNaNBucketPolicy(policy NaNBucketPolicy) {
+	o.nanBuckets = policy
+}
+
 func (o *ocaStore) Appender() (storage.Appender, error) {
 	state := atomic.LoadInt32(&o.running)
 	if state == runningStateReady {
-		return newTransaction(o.ctx, o.jobsMap, o.mc, o.sink, o.logger), nil
+		if o.limiter != nil && !o.limiter.acquire(o.ctx) {
+			// The receiver is shutting down.
+			return noop, nil
+		}
+		tr := newTransaction(o.ctx, o.jobsMap, o.mc, o.sink, o.logger, o.emptyScrapePolicy)
+		tr.scrapeIntervals, _ = o.scrapeIntervals.Load().(map[string]time.Duration)
+		tr.scopes = o.scopes
+		tr.backpressure = o.backpressure
+		tr.timestampPolicy = o.timestampPolicy
+		tr.targetLabelsFile = o.targetLabelsFile
+		tr.schemes = o.schemes
+		tr.lenientJobs, _ = o.lenientJobs.Load().(map[string]bool)
+		tr.streamingJobs, _ = o.streamingJobs.Load().(map[string]int)
+		tr.recordScrapeSizes = o.recordScrapeSizes
+		tr.scrapeOutcomes = o.scrapeOutcomes
+		tr.extremeQuantiles = o.extremeQuantiles
+		tr.repairHistograms = o.repairHistograms
+		tr.nanBuckets = o.nanBuckets
+		if o.limiter != nil {
+			return &limitedAppender{Appender: tr, limiter: o.limiter}, nil
+		}
+		return tr, nil
 	} else if state == runningStateInit {
 		return nil, errors.New("ScrapeManager is not set")
 	}
@@ -90,6 +259,9 @@ func (o *ocaStore) Appender() (storage.Appender, error) {
 
 func (o *ocaStore) Close() error {
 	atomic.CompareAndSwapInt32(&o.running, runningStateReady, runningStateStop)
+	if o.batcher != nil {
+		return o.batcher.flush()
+	}
 	return nil
 }
 
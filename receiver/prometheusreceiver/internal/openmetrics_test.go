@@ -0,0 +1,155 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"io"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/textparse"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openMetricsContentType = "application/openmetrics-text; version=0.0.1"
+
+// buildExposition parses the exposition the way the scrape loop does, caching the metadata and adding every sample
+// to a metric builder, and returns the built metrics.
+func buildExposition(t *testing.T, exposition string) ([]*metricspb.Metric, error) {
+	metadata := make(map[string]scrape.MetricMetadata)
+	b := newMetricBuilder(newMockMetadataCache(metadata), testLogger)
+	p := textparse.New([]byte(exposition), openMetricsContentType)
+	for {
+		entry, err := p.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch entry {
+		case textparse.EntryType:
+			name, typ := p.Type()
+			md := metadata[string(name)]
+			md.Metric, md.Type = string(name), typ
+			metadata[string(name)] = md
+		case textparse.EntryHelp:
+			name, help := p.Help()
+			md := metadata[string(name)]
+			md.Metric, md.Help = string(name), string(help)
+			metadata[string(name)] = md
+		case textparse.EntrySeries:
+			_, _, v := p.Series()
+			var ls labels.Labels
+			p.Metric(&ls)
+			require.NoError(t, b.AddDataPoint(ls, startTs, v))
+		}
+	}
+	metrics, _, _, err := b.Build()
+	return metrics, err
+}
+
+func Test_metricBuilder_openMetrics(t *testing.T) {
+	exposition := `# HELP build Build information.
+# TYPE build info
+build_info{version="1.2.0",revision="abc"} 1
+# TYPE feature stateset
+feature{feature="a"} 1
+feature{feature="b"} 0
+# TYPE temperature gauge
+temperature 21.5
+# EOF
+`
+	metrics, err := buildExposition(t, exposition)
+	require.NoError(t, err)
+
+	ts := timestampFromMs(startTs)
+	want := []*metricspb.Metric{
+		{
+			MetricDescriptor: &metricspb.MetricDescriptor{
+				Name:        "build_info",
+				Description: "Build information.",
+				Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+				LabelKeys:   []*metricspb.LabelKey{{Key: "revision"}, {Key: "version"}},
+			},
+			Timeseries: []*metricspb.TimeSeries{
+				{
+					LabelValues: []*metricspb.LabelValue{{Value: "abc", HasValue: true}, {Value: "1.2.0", HasValue: true}},
+					Points:      []*metricspb.Point{{Timestamp: ts, Value: &metricspb.Point_DoubleValue{DoubleValue: 1}}},
+				},
+			},
+		},
+		{
+			MetricDescriptor: &metricspb.MetricDescriptor{
+				Name:      "feature",
+				Type:      metricspb.MetricDescriptor_GAUGE_DOUBLE,
+				LabelKeys: []*metricspb.LabelKey{{Key: "feature"}},
+			},
+			Timeseries: []*metricspb.TimeSeries{
+				{
+					LabelValues: []*metricspb.LabelValue{{Value: "a", HasValue: true}},
+					Points:      []*metricspb.Point{{Timestamp: ts, Value: &metricspb.Point_DoubleValue{DoubleValue: 1}}},
+				},
+				{
+					LabelValues: []*metricspb.LabelValue{{Value: "b", HasValue: true}},
+					Points:      []*metricspb.Point{{Timestamp: ts, Value: &metricspb.Point_DoubleValue{DoubleValue: 0}}},
+				},
+			},
+		},
+		{
+			MetricDescriptor: &metricspb.MetricDescriptor{
+				Name:      "temperature",
+				Type:      metricspb.MetricDescriptor_GAUGE_DOUBLE,
+				LabelKeys: []*metricspb.LabelKey{},
+			},
+			Timeseries: []*metricspb.TimeSeries{
+				{
+					LabelValues: []*metricspb.LabelValue{},
+					Points:      []*metricspb.Point{{Timestamp: ts, Value: &metricspb.Point_DoubleValue{DoubleValue: 21.5}}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, want, metrics)
+}
+
+func Test_metricBuilder_openMetricsInfoValue(t *testing.T) {
+	// info metrics are constant, whatever the exposed value, and their
+	// samples can also be named after the family.
+	exposition := `# TYPE target info
+target{env="prod"} 2
+# EOF
+`
+	metrics, err := buildExposition(t, exposition)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "target", metrics[0].MetricDescriptor.Name)
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, metrics[0].MetricDescriptor.Type)
+	assert.Equal(t, 1.0, metrics[0].Timeseries[0].Points[0].GetDoubleValue())
+}
+
+func Test_metricBuilder_openMetricsEOF(t *testing.T) {
+	// the samples after the EOF terminator are rejected.
+	exposition := `# TYPE temperature gauge
+temperature 21.5
+# EOF
+temperature 22
+`
+	_, err := buildExposition(t, exposition)
+	assert.Error(t, err)
+}
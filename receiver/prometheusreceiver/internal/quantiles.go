@@ -0,0 +1,64 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+// ExtremeQuantilePolicy defines how the extreme quantiles of the summaries, quantile="0", quantile="1" and the ones
+// beyond, are converted. The count and the sum of the summaries are kept in any case.
+type ExtremeQuantilePolicy string
+
+const (
+	// ExtremeQuantilesKeep converts the extreme quantiles as any other quantile.
+	ExtremeQuantilesKeep ExtremeQuantilePolicy = "keep"
+	// ExtremeQuantilesDrop drops the extreme quantiles, for the backends rejecting them.
+	ExtremeQuantilesDrop ExtremeQuantilePolicy = "drop"
+	// ExtremeQuantilesClamp moves the extreme quantiles inside the range the backends accept, e.g. the value of
+	// quantile="1" becomes the one of quantile="0.999". A clamped quantile is dropped if the summary already has a
+	// quantile at or beyond the clamped one.
+	ExtremeQuantilesClamp ExtremeQuantilePolicy = "clamp"
+)
+
+// clampedQuantileMargin is the distance from 0 and 1 of the clamped quantiles.
+const clampedQuantileMargin = 0.001
+
+// quantiles returns the quantiles of a summary with its extreme quantiles handled per policy, the given points must
+// be sorted by quantile. The points are returned as is if there is nothing to change.
+func (p ExtremeQuantilePolicy) quantiles(points []*dataPoint) []*dataPoint {
+	if p != ExtremeQuantilesDrop && p != ExtremeQuantilesClamp {
+		return points
+	}
+	// points[low:high] are the quantiles that are not extreme.
+	low, high := 0, len(points)
+	for low < high && points[low].boundary <= 0 {
+		low++
+	}
+	for high > low && points[high-1].boundary >= 1 {
+		high--
+	}
+	if low == 0 && high == len(points) {
+		return points
+	}
+
+	quantiles := make([]*dataPoint, 0, high-low+2)
+	if p == ExtremeQuantilesClamp && low > 0 && (low == high || points[low].boundary > clampedQuantileMargin) {
+		// The highest of the low extreme quantiles is the closest to the clamped one.
+		quantiles = append(quantiles, &dataPoint{boundary: clampedQuantileMargin, value: points[low-1].value})
+	}
+	quantiles = append(quantiles, points[low:high]...)
+	if p == ExtremeQuantilesClamp && high < len(points) &&
+		(low == high || points[high-1].boundary < 1-clampedQuantileMargin) {
+		quantiles = append(quantiles, &dataPoint{boundary: 1 - clampedQuantileMargin, value: points[high].value})
+	}
+	return quantiles
+}
@@ -0,0 +1,127 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+)
+
+func Test_metricBuilder_extremeQuantiles(t *testing.T) {
+	tests := []struct {
+		policy ExtremeQuantilePolicy
+		want   []*metricspb.SummaryValue_Snapshot_ValueAtPercentile
+	}{
+		{
+			policy: ExtremeQuantilesKeep,
+			want: []*metricspb.SummaryValue_Snapshot_ValueAtPercentile{
+				{Percentile: 0, Value: 0.1},
+				{Percentile: 50, Value: 1},
+				{Percentile: 100, Value: 5},
+			},
+		},
+		{
+			policy: ExtremeQuantilesDrop,
+			want: []*metricspb.SummaryValue_Snapshot_ValueAtPercentile{
+				{Percentile: 50, Value: 1},
+			},
+		},
+		{
+			policy: ExtremeQuantilesClamp,
+			want: []*metricspb.SummaryValue_Snapshot_ValueAtPercentile{
+				{Percentile: clampedQuantileMargin * 100, Value: 0.1},
+				{Percentile: 50, Value: 1},
+				{Percentile: (1 - clampedQuantileMargin) * 100, Value: 5},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			b := newMetricBuilder(newMockMetadataCache(testMetadata), testLogger)
+			b.extremeQuantiles = tt.policy
+			for _, pt := range []*testDataPoint{
+				createDataPoint("summary_test", 0.1, "foo", "bar", "quantile", "0"),
+				createDataPoint("summary_test", 1, "foo", "bar", "quantile", "0.5"),
+				createDataPoint("summary_test", 5, "foo", "bar", "quantile", "1"),
+				createDataPoint("summary_test_sum", 100, "foo", "bar"),
+				createDataPoint("summary_test_count", 500, "foo", "bar"),
+			} {
+				if err := b.AddDataPoint(pt.lb, startTs, pt.v); err != nil {
+					t.Fatalf("unexpected error adding data %v", err)
+				}
+			}
+			metrics, _, _, err := b.Build()
+			if err != nil {
+				t.Fatalf("unexpected error on build %v", err)
+			}
+			if len(metrics) != 1 || len(metrics[0].Timeseries) != 1 {
+				t.Fatalf("expecting a single summary, got %v", metrics)
+			}
+			summary := metrics[0].Timeseries[0].Points[0].GetSummaryValue()
+			// The count and the sum are kept whatever the policy.
+			if summary.GetCount().GetValue() != 500 || summary.GetSum().GetValue() != 100 {
+				t.Errorf("expecting count 500 and sum 100, got %v", summary)
+			}
+			if got := summary.GetSnapshot().GetPercentileValues(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("percentiles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_extremeQuantilesClampCollisions(t *testing.T) {
+	points := func(boundaries ...float64) []*dataPoint {
+		pts := make([]*dataPoint, len(boundaries))
+		for i, boundary := range boundaries {
+			pts[i] = &dataPoint{boundary: boundary, value: float64(i)}
+		}
+		return pts
+	}
+	tests := []struct {
+		name   string
+		points []*dataPoint
+		want   []*dataPoint
+	}{
+		{
+			name:   "no_extreme_quantiles",
+			points: points(0.5, 0.9),
+			want:   points(0.5, 0.9),
+		},
+		{
+			name:   "quantiles_at_the_clamped_ones",
+			points: points(0, 0.001, 0.999, 1),
+			want:   []*dataPoint{{boundary: 0.001, value: 1}, {boundary: 0.999, value: 2}},
+		},
+		{
+			name:   "several_extreme_quantiles",
+			points: points(-1, 0, 0.5, 1, 2),
+			want:   []*dataPoint{{boundary: 0.001, value: 1}, {boundary: 0.5, value: 2}, {boundary: 0.999, value: 3}},
+		},
+		{
+			name:   "only_extreme_quantiles",
+			points: points(0, 1),
+			want:   []*dataPoint{{boundary: 0.001, value: 0}, {boundary: 0.999, value: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtremeQuantilesClamp.quantiles(tt.points); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("quantiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+)
+
+const (
+	// The node attributes holding the instrumentation scope, they follow the OpenTelemetry convention for the
+	// formats without a native scope, so that an OTLP conversion can restore the scope of the metrics.
+	scopeNameAttr    = "otel.scope.name"
+	scopeVersionAttr = "otel.scope.version"
+)
+
+// Scope is the instrumentation scope the metrics converted from the scrapes of a job are attributed to. Prometheus
+// has no notion of scope, so it is synthesized from the job.
+type Scope struct {
+	Name    string
+	Version string
+}
+
+// setScope adds the scope to the attributes of the node.
+func setScope(node *commonpb.Node, scope Scope) {
+	if node.Attributes == nil {
+		node.Attributes = make(map[string]string, 2)
+	}
+	node.Attributes[scopeNameAttr] = scope.Name
+	if scope.Version != "" {
+		node.Attributes[scopeVersionAttr] = scope.Version
+	}
+}
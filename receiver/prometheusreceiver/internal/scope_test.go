@@ -0,0 +1,83 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+)
+
+func TestTransactionScope(t *testing.T) {
+	ms := &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{
+			"api_localhost:8080":   {data: map[string]scrape.MetricMetadata{}},
+			"batch_localhost:8081": {data: map[string]scrape.MetricMetadata{}},
+			"other_localhost:8082": {data: map[string]scrape.MetricMetadata{}},
+		},
+	}
+	scopes := map[string]Scope{
+		"api":   {Name: "checkout/api", Version: "1.2.0"},
+		"batch": {Name: "otelsvc/prometheusreceiver"},
+	}
+
+	commit := func(job, instance string) consumerdata.MetricsData {
+		mcon := newMockConsumer()
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
+		tr.scopes = scopes
+		ls := labels.FromStrings("__name__", "foo", "job", job, "instance", instance)
+		_, err := tr.Add(ls, time.Now().Unix()*1000, 1.0)
+		require.NoError(t, err)
+		require.NoError(t, tr.Commit())
+		require.NotNil(t, mcon.md)
+		return *mcon.md
+	}
+
+	api := commit("api", "localhost:8080")
+	assert.Equal(t, map[string]string{
+		portAttr:         "8080",
+		schemeAttr:       "http",
+		scopeNameAttr:    "checkout/api",
+		scopeVersionAttr: "1.2.0",
+	}, api.Node.Attributes)
+
+	batch := commit("batch", "localhost:8081")
+	assert.Equal(t, "otelsvc/prometheusreceiver", batch.Node.Attributes[scopeNameAttr])
+	assert.NotContains(t, batch.Node.Attributes, scopeVersionAttr)
+
+	other := commit("other", "localhost:8082")
+	assert.NotContains(t, other.Node.Attributes, scopeNameAttr)
+
+	// Merging the data of several targets keeps the scope of each metric.
+	merged := mergeMetricsData([]consumerdata.MetricsData{api, batch})
+	require.Len(t, merged.Metrics, 2)
+	assert.Equal(t, "checkout/api", merged.Metrics[0].Resource.Labels[scopeNameAttr])
+	assert.Equal(t, "1.2.0", merged.Metrics[0].Resource.Labels[scopeVersionAttr])
+	assert.Equal(t, "otelsvc/prometheusreceiver", merged.Metrics[1].Resource.Labels[scopeNameAttr])
+}
+
+func TestSetScope(t *testing.T) {
+	node := &commonpb.Node{}
+	setScope(node, Scope{Name: "scope", Version: "v1"})
+	assert.Equal(t, map[string]string{scopeNameAttr: "scope", scopeVersionAttr: "v1"}, node.Attributes)
+}
@@ -0,0 +1,72 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/prometheus/storage"
+)
+
+// scrapeLimiter caps the number of scrapes whose data is processed concurrently. The scrape loops acquire a slot
+// when they get their appender, before parsing the scraped page, and release it when the transaction is committed
+// or rolled back, so that at most the given number of loops parse and convert their data at a time.
+type scrapeLimiter struct {
+	slots chan struct{}
+}
+
+// newScrapeLimiter returns a limiter allowing the given number of concurrent scrapes, nil if it is not positive.
+func newScrapeLimiter(maxConcurrentScrapes int) *scrapeLimiter {
+	if maxConcurrentScrapes <= 0 {
+		return nil
+	}
+	return &scrapeLimiter{slots: make(chan struct{}, maxConcurrentScrapes)}
+}
+
+// acquire blocks until a slot is available, it returns false if the context is done first.
+func (sl *scrapeLimiter) acquire(ctx context.Context) bool {
+	select {
+	case sl.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (sl *scrapeLimiter) release() {
+	<-sl.slots
+}
+
+// limitedAppender releases the slot of its scrape once the transaction is over.
+type limitedAppender struct {
+	storage.Appender
+	once    sync.Once
+	limiter *scrapeLimiter
+}
+
+func (la *limitedAppender) Commit() error {
+	defer la.done()
+	return la.Appender.Commit()
+}
+
+func (la *limitedAppender) Rollback() error {
+	defer la.done()
+	return la.Appender.Rollback()
+}
+
+func (la *limitedAppender) done() {
+	la.once.Do(la.limiter.release)
+}
@@ -0,0 +1,59 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapeLimiterUnlimited(t *testing.T) {
+	assert.Nil(t, newScrapeLimiter(0))
+	assert.Nil(t, newScrapeLimiter(-1))
+}
+
+func TestScrapeLimiterBlocksBeyondCap(t *testing.T) {
+	sl := newScrapeLimiter(2)
+	require.True(t, sl.acquire(context.Background()))
+	require.True(t, sl.acquire(context.Background()))
+
+	acquired := make(chan bool)
+	go func() { acquired <- sl.acquire(context.Background()) }()
+	select {
+	case <-acquired:
+		t.Fatal("acquired a slot beyond the cap")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Ending a transaction frees its slot, once whatever the number of calls.
+	la := &limitedAppender{Appender: noop, limiter: sl}
+	_ = la.Commit()
+	_ = la.Rollback()
+	require.True(t, <-acquired)
+	assert.Len(t, sl.slots, 2)
+}
+
+func TestScrapeLimiterContextDone(t *testing.T) {
+	sl := newScrapeLimiter(1)
+	require.True(t, sl.acquire(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, sl.acquire(ctx))
+}
@@ -0,0 +1,80 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+)
+
+// scrapeSuccessMetricName is the name of the gauge telling whether a scrape fully succeeded.
+const scrapeSuccessMetricName = "scrape_success"
+
+// scrapeOutcomes holds the targets whose last scraped page failed, to be reported with their scrape report. The page
+// and the report of a scrape are appended in distinct transactions, one after the other by the scrape loop of the
+// target: the page transaction fails the target when its response can't be parsed, exceeds the sample limit, or has
+// series that can't be converted, the report transaction takes the outcome.
+type scrapeOutcomes struct {
+	sync.Mutex
+	failed map[string]bool
+}
+
+func newScrapeOutcomes() *scrapeOutcomes {
+	return &scrapeOutcomes{failed: make(map[string]bool)}
+}
+
+// fail records that the page of the current scrape of the target failed.
+func (so *scrapeOutcomes) fail(target string) {
+	so.Lock()
+	so.failed[target] = true
+	so.Unlock()
+}
+
+// take returns whether the page of the current scrape of the target failed, and forgets it for the next scrape.
+func (so *scrapeOutcomes) take(target string) bool {
+	so.Lock()
+	defer so.Unlock()
+	failed := so.failed[target]
+	delete(so.failed, target)
+	return failed
+}
+
+// scrapeSuccessMetric returns the scrape_success gauge of the scrape whose report is appended by the transaction, nil
+// if the transaction appended a page. A scrape fully succeeded if the target was up and its page did not fail.
+func (tr *transaction) scrapeSuccessMetric() *metricspb.Metric {
+	up, ok := tr.metricBuilder.scrapeReport[upMetricName]
+	if !ok {
+		return nil
+	}
+	failed := tr.scrapeOutcomes.take(tr.target)
+	success := 0.0
+	if up == 1 && !failed {
+		success = 1
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        scrapeSuccessMetricName,
+			Description: "Whether the scrape of the target fully succeeded: it responded, was parsed and was within limits",
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+		},
+		Timeseries: []*metricspb.TimeSeries{{
+			Points: []*metricspb.Point{{
+				Timestamp: timestampFromMs(tr.metricBuilder.scrapeReportTime),
+				Value:     &metricspb.Point_DoubleValue{DoubleValue: success},
+			}},
+		}},
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/scrape"
+)
+
+func Test_transactionScrapeSuccess(t *testing.T) {
+	ms := &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{
+			"test_localhost:8080": {data: map[string]scrape.MetricMetadata{}},
+		},
+	}
+	sampleLabels := labels.FromStrings("__name__", "foo", "job", "test", "instance", "localhost:8080")
+	reportLabels := func(name string) labels.Labels {
+		return labels.FromStrings("__name__", name, "job", "test", "instance", "localhost:8080")
+	}
+	outcomes := newScrapeOutcomes()
+	newTr := func(mcon *mockConsumer) *transaction {
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
+		tr.scrapeOutcomes = outcomes
+		return tr
+	}
+
+	tests := []struct {
+		name string
+		// rollback is whether the scrape loop rolls back the page, as it does when the response can't be parsed or
+		// exceeds the sample limit.
+		rollback    bool
+		up          float64
+		wantSuccess float64
+	}{
+		{name: "success", up: 1, wantSuccess: 1},
+		{name: "sample_limit_exceeded", rollback: true, up: 1, wantSuccess: 0},
+		{name: "recovered", up: 1, wantSuccess: 1},
+		{name: "down", up: 0, wantSuccess: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := time.Now().Unix() * 1000
+
+			page := newTr(newMockConsumer())
+			if tt.up == 1 {
+				if _, err := page.Add(sampleLabels, ts, 1.0); err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			}
+			if tt.rollback {
+				if err := page.Rollback(); err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			} else if err := page.Commit(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			mcon := newMockConsumer()
+			report := newTr(mcon)
+			if _, err := report.Add(reportLabels("up"), ts, tt.up); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if _, err := report.Add(reportLabels("scrape_samples_scraped"), ts, tt.up); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err := report.Commit(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if mcon.md == nil || len(mcon.md.Metrics) != 1 {
+				t.Fatalf("expecting the scrape_success gauge, got %v", mcon.md)
+			}
+			m := mcon.md.Metrics[0]
+			if m.MetricDescriptor.Name != "scrape_success" || m.MetricDescriptor.Type != metricspb.MetricDescriptor_GAUGE_DOUBLE {
+				t.Fatalf("expecting the scrape_success gauge, got %v", m)
+			}
+			if got := m.Timeseries[0].Points[0].GetDoubleValue(); got != tt.wantSuccess {
+				t.Errorf("scrape_success = %v, want %v", got, tt.wantSuccess)
+			}
+			if got := mcon.md.Node.ServiceInfo.Name; got != "test" {
+				t.Errorf("expecting the gauge to be sent with the node of the target, got job %q", got)
+			}
+		})
+	}
+}
+
+func Test_transactionScrapeSuccessDisabled(t *testing.T) {
+	ms := &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{
+			"test_localhost:8080": {data: map[string]scrape.MetricMetadata{}},
+		},
+	}
+	mcon := newMockConsumer()
+	tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
+	if _, err := tr.Add(labels.FromStrings("__name__", "up", "job", "test", "instance", "localhost:8080"), time.Now().Unix()*1000, 1); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := tr.Commit(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if mcon.md != nil {
+		t.Errorf("expecting no metrics, got %v", mcon.md)
+	}
+}
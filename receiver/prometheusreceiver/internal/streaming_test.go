@@ -0,0 +1,188 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/textparse"
+	"github.com/prometheus/prometheus/scrape"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+)
+
+type testSample struct {
+	ls labels.Labels
+	v  float64
+}
+
+// streamingTestPage returns the samples of a page exposing the given number of counter families with the given
+// number of series each, and a histogram family.
+func streamingTestPage(numFamilies, numSeries int) []testSample {
+	series := func(name string, i int, extra ...string) labels.Labels {
+		return labels.FromStrings(append([]string{"__name__", name, "job", "test", "instance", "localhost:8080",
+			"series", strconv.Itoa(i)}, extra...)...)
+	}
+	var page []testSample
+	for f := 0; f < numFamilies; f++ {
+		name := "counter_" + strconv.Itoa(f)
+		for i := 0; i < numSeries; i++ {
+			page = append(page, testSample{ls: series(name, i), v: float64(f*numSeries + i)})
+		}
+	}
+	for i := 0; i < numSeries; i++ {
+		page = append(page,
+			testSample{ls: series("latency_bucket", i, "le", "0.5"), v: 1},
+			testSample{ls: series("latency_bucket", i, "le", "+Inf"), v: 3},
+			testSample{ls: series("latency_sum", i), v: 1.5},
+			testSample{ls: series("latency_count", i), v: 3},
+		)
+	}
+	return page
+}
+
+func streamingTestMetadataSvc(numFamilies int) *mockMetadataSvc {
+	metadata := map[string]scrape.MetricMetadata{
+		"latency": {Metric: "latency", Type: textparse.MetricTypeHistogram},
+	}
+	for f := 0; f < numFamilies; f++ {
+		name := "counter_" + strconv.Itoa(f)
+		metadata[name] = scrape.MetricMetadata{Metric: name, Type: textparse.MetricTypeCounter}
+	}
+	return &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{"test_localhost:8080": {data: metadata}},
+	}
+}
+
+// appendPage appends the page in a transaction streamed with the given batch size, 0 for a buffered one, and returns
+// the data passed to the sink.
+func appendPage(t *testing.T, ms MetadataService, page []testSample, batchSize int) []consumerdata.MetricsData {
+	sink := new(exportertest.SinkMetricsExporter)
+	tr := newTransaction(context.Background(), nil, ms, sink, testLogger, EmptyScrapeSuccess)
+	tr.streamingJobs = map[string]int{"test": batchSize}
+	ts := time.Now().Unix() * 1000
+	for _, s := range page {
+		if _, err := tr.Add(s.ls, ts, s.v); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if batchSize > 0 && len(sink.AllMetrics()) == 0 {
+		t.Errorf("expecting the completed families to be passed before the commit")
+	}
+	if err := tr.Commit(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	return sink.AllMetrics()
+}
+
+func Test_transactionStreaming(t *testing.T) {
+	page := streamingTestPage(10, 20)
+	ms := streamingTestMetadataSvc(10)
+
+	buffered := appendPage(t, ms, page, 0)
+	if len(buffered) != 1 {
+		t.Fatalf("expecting the buffered page to be passed at once, got %d batches", len(buffered))
+	}
+
+	for _, batchSize := range []int{1, 25, 100} {
+		t.Run(strconv.Itoa(batchSize), func(t *testing.T) {
+			streamed := appendPage(t, ms, page, batchSize)
+			var metrics []*metricspb.Metric
+			for _, md := range streamed {
+				if !reflect.DeepEqual(md.Node, buffered[0].Node) {
+					t.Errorf("node = %v, want %v", md.Node, buffered[0].Node)
+				}
+				metrics = append(metrics, md.Metrics...)
+			}
+			if !reflect.DeepEqual(metrics, buffered[0].Metrics) {
+				t.Errorf("streamed metrics differ from the buffered ones:\n got=%s\nwant=%s",
+					exportertest.ToJSON(metrics), exportertest.ToJSON(buffered[0].Metrics))
+			}
+		})
+	}
+}
+
+func Test_transactionStreamingBatches(t *testing.T) {
+	page := streamingTestPage(10, 20)
+	got := appendPage(t, streamingTestMetadataSvc(10), page, 50)
+	// Each batch holds 3 families of 20 series, the histogram of the last one is passed at commit time.
+	want := []int{3, 3, 3, 2}
+	if len(got) != len(want) {
+		t.Fatalf("expecting %d batches, got %d", len(want), len(got))
+	}
+	for i, md := range got {
+		if len(md.Metrics) != want[i] {
+			t.Errorf("batch %d has %d families, want %d", i, len(md.Metrics), want[i])
+		}
+	}
+}
+
+// BenchmarkTransactionStreaming compares the peak memory of a huge page appended in a buffered transaction and in a
+// streamed one, the live heap is sampled as the page is appended. Run with -v to see the peaks.
+func BenchmarkTransactionStreaming(b *testing.B) {
+	const numFamilies, numSeries = 200, 500
+	page := streamingTestPage(numFamilies, numSeries)
+	ms := streamingTestMetadataSvc(numFamilies)
+	ts := time.Now().Unix() * 1000
+
+	for _, bc := range []struct {
+		name      string
+		batchSize int
+	}{
+		{name: "buffered"},
+		{name: "streaming", batchSize: 1000},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				tr := newTransaction(context.Background(), nil, ms, exportertest.NewNopMetricsExporter(), testLogger,
+					EmptyScrapeSuccess)
+				tr.streamingJobs = map[string]int{"test": bc.batchSize}
+				for j, s := range page {
+					if _, err := tr.Add(s.ls, ts, s.v); err != nil {
+						b.Fatalf("unexpected error %v", err)
+					}
+					if j%10000 == 0 {
+						b.StopTimer()
+						if heap := liveHeap(); heap > peak {
+							peak = heap
+						}
+						b.StartTimer()
+					}
+				}
+				if err := tr.Commit(); err != nil {
+					b.Fatalf("unexpected error %v", err)
+				}
+			}
+			b.Logf("peak live heap: %d KiB", peak>>10)
+		})
+	}
+}
+
+func liveHeap() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
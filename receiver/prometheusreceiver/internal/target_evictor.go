@@ -0,0 +1,248 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/scrape"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+)
+
+// defaultEvictionCooldown is how long an evicted target is kept out of the scrapes by default.
+const defaultEvictionCooldown = 5 * time.Minute
+
+// evictionCheckInterval is how often the health of the targets is checked, as long as it is below the eviction
+// threshold. It matches how often the scrape manager applies the target updates.
+const evictionCheckInterval = 5 * time.Second
+
+// TargetEvictionSettings defines when the targets failing to be scraped are evicted from the scrapes.
+type TargetEvictionSettings struct {
+	// After is how long a target can fail every scrape before it is evicted. 0 disables the eviction.
+	After time.Duration
+	// Cooldown is how long an evicted target is kept out of the scrapes, it is scraped again afterwards if the
+	// service discovery still lists it. 0 means defaultEvictionCooldown.
+	Cooldown time.Duration
+}
+
+// TargetHealth is the scrape health of an active target.
+type TargetHealth struct {
+	// Address is the address of the target as discovered, before relabeling.
+	Address string
+	// Failing is whether the last scrape of the target failed.
+	Failing bool
+	// LastScrape is when the target was last scraped.
+	LastScrape time.Time
+}
+
+// TargetHealthFunc returns the health of the active targets, keyed by job.
+type TargetHealthFunc func() map[string][]TargetHealth
+
+// ScrapeManagerTargetHealth returns a TargetHealthFunc reporting the health of the active targets of the given
+// scrape manager.
+func ScrapeManagerTargetHealth(scrapeManager *scrape.Manager) TargetHealthFunc {
+	return func() map[string][]TargetHealth {
+		active := scrapeManager.TargetsActive()
+		health := make(map[string][]TargetHealth, len(active))
+		for job, targets := range active {
+			for _, t := range targets {
+				health[job] = append(health[job], TargetHealth{
+					Address:    t.DiscoveredLabels().Get(model.AddressLabel),
+					Failing:    t.Health() == scrape.HealthBad,
+					LastScrape: t.LastScrape(),
+				})
+			}
+		}
+		return health
+	}
+}
+
+type evictionKey struct {
+	job     string
+	address string
+}
+
+// TargetEvictor sits between the discovery manager and the scrape manager and evicts the targets failing every
+// scrape for longer than a threshold, so that dead targets stop using scrape resources and flooding the logs. An
+// evicted target is scraped again after a cooldown if the service discovery still lists it, and is evicted again if
+// it is still failing.
+type TargetEvictor struct {
+	ctx           context.Context
+	after         time.Duration
+	cooldown      time.Duration
+	checkInterval time.Duration
+	health        TargetHealthFunc
+	logger        *zap.SugaredLogger
+
+	// failingSince holds when the failing targets started failing.
+	failingSince map[evictionKey]time.Time
+	// evicted holds when the evicted targets were evicted.
+	evicted map[evictionKey]time.Time
+}
+
+// NewTargetEvictor creates a TargetEvictor evicting the targets according to the given settings, the health of the
+// targets being reported by the given function. The context is used to stop forwarding updates and to record the
+// number of evicted targets, so it must be created using observability.ContextWithReceiverName.
+func NewTargetEvictor(ctx context.Context, settings TargetEvictionSettings, health TargetHealthFunc,
+	logger *zap.SugaredLogger) *TargetEvictor {
+	cooldown := settings.Cooldown
+	if cooldown <= 0 {
+		cooldown = defaultEvictionCooldown
+	}
+	checkInterval := evictionCheckInterval
+	if settings.After > 0 && settings.After < checkInterval {
+		checkInterval = settings.After
+	}
+	return &TargetEvictor{
+		ctx:           ctx,
+		after:         settings.After,
+		cooldown:      cooldown,
+		checkInterval: checkInterval,
+		health:        health,
+		logger:        logger,
+		failingSince:  make(map[evictionKey]time.Time),
+		evicted:       make(map[evictionKey]time.Time),
+	}
+}
+
+// Run forwards the target sets received on the given channel, as sent by the discovery manager, to the returned
+// channel without the evicted targets. The last target sets are forwarded again whenever targets are evicted or
+// their cooldown ends.
+func (te *TargetEvictor) Run(in <-chan map[string][]*targetgroup.Group) <-chan map[string][]*targetgroup.Group {
+	if te.after <= 0 {
+		return in
+	}
+	out := make(chan map[string][]*targetgroup.Group)
+	go func() {
+		ticker := time.NewTicker(te.checkInterval)
+		defer ticker.Stop()
+		var last map[string][]*targetgroup.Group
+		for {
+			select {
+			case <-te.ctx.Done():
+				return
+			case tsets, ok := <-in:
+				if !ok {
+					close(out)
+					return
+				}
+				last = tsets
+			case now := <-ticker.C:
+				if !te.check(now) || last == nil {
+					continue
+				}
+			}
+			select {
+			case out <- te.filter(last):
+			case <-te.ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// check updates the failing and evicted targets from the health of the active targets, it returns whether targets
+// were evicted or their cooldown ended.
+func (te *TargetEvictor) check(now time.Time) bool {
+	changed := false
+	for key, evictedAt := range te.evicted {
+		if now.Sub(evictedAt) >= te.cooldown {
+			delete(te.evicted, key)
+			te.logger.Infow("Cooldown of evicted target ended, scraping it again if still discovered",
+				"job", key.job, "target", key.address)
+			changed = true
+		}
+	}
+
+	active := make(map[evictionKey]bool, len(te.failingSince))
+	numEvicted := 0
+	for job, targets := range te.health() {
+		for _, t := range targets {
+			key := evictionKey{job: job, address: t.Address}
+			active[key] = true
+			if !t.Failing {
+				delete(te.failingSince, key)
+				continue
+			}
+			since, ok := te.failingSince[key]
+			if !ok {
+				// The target started failing at the latest when it was last scraped.
+				since = t.LastScrape
+				if since.IsZero() || since.After(now) {
+					since = now
+				}
+				te.failingSince[key] = since
+			}
+			if now.Sub(since) < te.after {
+				continue
+			}
+			if _, evicted := te.evicted[key]; evicted {
+				// The target is still active until the scrape manager applies the eviction.
+				continue
+			}
+			te.evicted[key] = now
+			delete(te.failingSince, key)
+			te.logger.Warnw("Target failing to be scraped for too long, evicting it",
+				"job", job, "target", t.Address, "failing_for", now.Sub(since), "cooldown", te.cooldown)
+			numEvicted++
+		}
+	}
+	// Forget the targets no longer scraped, they start afresh when scraped again.
+	for key := range te.failingSince {
+		if !active[key] {
+			delete(te.failingSince, key)
+		}
+	}
+
+	if numEvicted > 0 {
+		observability.RecordEvictedTargetsForMetricsReceiver(te.ctx, numEvicted)
+		changed = true
+	}
+	return changed
+}
+
+// filter returns a copy of the target sets without the evicted targets, the input is left untouched.
+func (te *TargetEvictor) filter(tsets map[string][]*targetgroup.Group) map[string][]*targetgroup.Group {
+	if len(te.evicted) == 0 {
+		return tsets
+	}
+	filtered := make(map[string][]*targetgroup.Group, len(tsets))
+	for job, groups := range tsets {
+		kept := make([]*targetgroup.Group, 0, len(groups))
+		for _, g := range groups {
+			if g == nil {
+				kept = append(kept, g)
+				continue
+			}
+			// Groups are copied, even when nothing is evicted from them, so the scrape manager scrapes again the
+			// targets whose cooldown ended.
+			fg := &targetgroup.Group{Labels: g.Labels, Source: g.Source}
+			for _, t := range g.Targets {
+				if _, evicted := te.evicted[evictionKey{job: job, address: targetAddress(g, t)}]; !evicted {
+					fg.Targets = append(fg.Targets, t)
+				}
+			}
+			kept = append(kept, fg)
+		}
+		filtered[job] = kept
+	}
+	return filtered
+}
@@ -0,0 +1,157 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+)
+
+// fakeTargetHealth reports the health set by the tests.
+type fakeTargetHealth struct {
+	sync.Mutex
+	health map[string][]TargetHealth
+}
+
+func (f *fakeTargetHealth) set(job string, health ...TargetHealth) {
+	f.Lock()
+	defer f.Unlock()
+	f.health = map[string][]TargetHealth{job: health}
+}
+
+func (f *fakeTargetHealth) get() map[string][]TargetHealth {
+	f.Lock()
+	defer f.Unlock()
+	return f.health
+}
+
+func Test_targetEvictor(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	ctx := observability.ContextWithReceiverName(context.Background(), "prometheus")
+	health := &fakeTargetHealth{}
+	te := NewTargetEvictor(ctx, TargetEvictionSettings{After: time.Minute, Cooldown: 10 * time.Minute}, health.get,
+		testLogger)
+
+	discovered := map[string][]*targetgroup.Group{
+		"pods": {
+			{
+				Source: "a",
+				Targets: []model.LabelSet{
+					{model.AddressLabel: "10.0.0.1:80"},
+					{model.AddressLabel: "10.0.0.2:80"},
+					{model.AddressLabel: "10.0.0.3:80"},
+				},
+			},
+		},
+	}
+
+	t0 := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	dead := TargetHealth{Address: "10.0.0.1:80", Failing: true, LastScrape: t0}
+	flapping := TargetHealth{Address: "10.0.0.2:80", Failing: true, LastScrape: t0}
+	healthy := TargetHealth{Address: "10.0.0.3:80", LastScrape: t0}
+
+	health.set("pods", dead, flapping, healthy)
+	assert.False(t, te.check(t0.Add(10*time.Second)))
+
+	// The flapping target succeeds once, its failures start afresh.
+	dead.LastScrape = t0.Add(30 * time.Second)
+	flapping = TargetHealth{Address: "10.0.0.2:80", LastScrape: t0.Add(30 * time.Second)}
+	health.set("pods", dead, flapping, healthy)
+	assert.False(t, te.check(t0.Add(30*time.Second)))
+	flapping = TargetHealth{Address: "10.0.0.2:80", Failing: true, LastScrape: t0.Add(40 * time.Second)}
+	health.set("pods", dead, flapping, healthy)
+	assert.False(t, te.check(t0.Add(45*time.Second)))
+
+	// Only the target failing continuously for longer than the threshold is evicted.
+	assert.True(t, te.check(t0.Add(61*time.Second)))
+	got := te.filter(discovered)
+	assert.Equal(t, []string{"10.0.0.2:80", "10.0.0.3:80"}, addresses(got["pods"][0]))
+	assert.Len(t, discovered["pods"][0].Targets, 3, "discovered groups must not be modified")
+	require.NoError(t, observabilitytest.CheckValueViewReceiverEvictedTargets("prometheus", 1))
+
+	// The evicted target is not evicted twice while the scrape manager stops it.
+	assert.False(t, te.check(t0.Add(65*time.Second)))
+	health.set("pods", flapping, healthy)
+	assert.False(t, te.check(t0.Add(70*time.Second)))
+	require.NoError(t, observabilitytest.CheckValueViewReceiverEvictedTargets("prometheus", 1))
+
+	// The target is scraped again once the cooldown ended.
+	flapping.Failing = false
+	health.set("pods", flapping, healthy)
+	assert.True(t, te.check(t0.Add(61*time.Second+10*time.Minute)))
+	got = te.filter(discovered)
+	assert.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80"}, addresses(got["pods"][0]))
+}
+
+func Test_targetEvictorRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	health := &fakeTargetHealth{}
+	te := NewTargetEvictor(ctx, TargetEvictionSettings{After: 50 * time.Millisecond, Cooldown: time.Hour},
+		health.get, testLogger)
+
+	discovered := map[string][]*targetgroup.Group{
+		"static": {
+			{
+				Source: "0",
+				Targets: []model.LabelSet{
+					{model.AddressLabel: "localhost:9090"},
+					{model.AddressLabel: "localhost:9091"},
+				},
+			},
+		},
+	}
+	// The target never responding was scraped, it fails from now on.
+	health.set("static",
+		TargetHealth{Address: "localhost:9090", LastScrape: time.Now()},
+		TargetHealth{Address: "localhost:9091", Failing: true, LastScrape: time.Now()})
+
+	in := make(chan map[string][]*targetgroup.Group)
+	out := te.Run(in)
+	in <- discovered
+	got := <-out
+	assert.Equal(t, []string{"localhost:9090", "localhost:9091"}, addresses(got["static"][0]))
+
+	// The failing target is evicted once the threshold is exceeded, without a discovery update.
+	select {
+	case got = <-out:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the failing target was not evicted")
+	}
+	assert.Equal(t, []string{"localhost:9090"}, addresses(got["static"][0]))
+
+	// The discovery updates keep the target evicted.
+	in <- discovered
+	got = <-out
+	assert.Equal(t, []string{"localhost:9090"}, addresses(got["static"][0]))
+}
+
+func Test_targetEvictorDisabled(t *testing.T) {
+	in := make(chan map[string][]*targetgroup.Group)
+	te := NewTargetEvictor(context.Background(), TargetEvictionSettings{}, nil, testLogger)
+	assert.Equal(t, (<-chan map[string][]*targetgroup.Group)(in), te.Run(in))
+}
@@ -0,0 +1,132 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"go.uber.org/zap"
+)
+
+// defaultTargetLabelsRefreshInterval is how often the target labels file is read by default.
+const defaultTargetLabelsRefreshInterval = 5 * time.Second
+
+// TargetLabelsFile holds the extra labels of the targets listed in a JSON file maintained by an external agent, e.g.
+// {"10.0.0.1:8080": {"team": "payments"}}, keyed by target address. The file is read again at every refresh
+// interval so the labels follow its changes. A missing file means no extra labels, while the labels read last are
+// kept if the file cannot be parsed.
+type TargetLabelsFile struct {
+	path     string
+	interval time.Duration
+	logger   *zap.SugaredLogger
+
+	mu      sync.RWMutex
+	targets map[string]labels.Labels
+}
+
+// NewTargetLabelsFile creates a TargetLabelsFile reading the file at the given path, every interval once Run, 0
+// means defaultTargetLabelsRefreshInterval. The file is read a first time before returning.
+func NewTargetLabelsFile(path string, interval time.Duration, logger *zap.SugaredLogger) *TargetLabelsFile {
+	if interval <= 0 {
+		interval = defaultTargetLabelsRefreshInterval
+	}
+	tlf := &TargetLabelsFile{path: path, interval: interval, logger: logger}
+	tlf.refresh()
+	return tlf
+}
+
+// Run reads the file at every refresh interval until the context is done.
+func (tlf *TargetLabelsFile) Run(ctx context.Context) {
+	ticker := time.NewTicker(tlf.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tlf.refresh()
+		}
+	}
+}
+
+// Labels returns the extra labels of the target with the given address, sorted by name, nil if it has none.
+func (tlf *TargetLabelsFile) Labels(address string) labels.Labels {
+	tlf.mu.RLock()
+	defer tlf.mu.RUnlock()
+	return tlf.targets[address]
+}
+
+func (tlf *TargetLabelsFile) refresh() {
+	data, err := ioutil.ReadFile(tlf.path)
+	if os.IsNotExist(err) {
+		tlf.logger.Debugw("Target labels file not found, targets have no extra labels", "path", tlf.path)
+		tlf.set(nil)
+		return
+	}
+	if err != nil {
+		tlf.logger.Warnw("Failed to read the target labels file, keeping the current labels", "path", tlf.path,
+			"error", err)
+		return
+	}
+
+	var parsed map[string]map[string]string
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		tlf.logger.Warnw("Failed to parse the target labels file, keeping the current labels", "path", tlf.path,
+			"error", err)
+		return
+	}
+	targets := make(map[string]labels.Labels, len(parsed))
+	for address, targetLabels := range parsed {
+		ls := make(labels.Labels, 0, len(targetLabels))
+		for name, value := range targetLabels {
+			if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+				tlf.logger.Warnw("Ignoring invalid label of the target labels file", "path", tlf.path,
+					"target", address, "label", name)
+				continue
+			}
+			ls = append(ls, labels.Label{Name: name, Value: value})
+		}
+		if len(ls) > 0 {
+			targets[address] = labels.New(ls...)
+		}
+	}
+	tlf.set(targets)
+}
+
+func (tlf *TargetLabelsFile) set(targets map[string]labels.Labels) {
+	tlf.mu.Lock()
+	tlf.targets = targets
+	tlf.mu.Unlock()
+}
+
+// mergeLabels returns the sample labels with the given extra labels added, the labels of the sample taking
+// precedence over the extra ones.
+func mergeLabels(ls labels.Labels, extra labels.Labels) labels.Labels {
+	b := labels.NewBuilder(ls)
+	for _, l := range extra {
+		if ls.Get(l.Name) == "" {
+			b.Set(l.Name, l.Value)
+		}
+	}
+	return b.Labels()
+}
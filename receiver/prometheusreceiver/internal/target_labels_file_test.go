@@ -0,0 +1,67 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetLabelsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "target_labels")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "targets.json")
+
+	// A missing file is not an error, the targets have no extra labels.
+	tlf := NewTargetLabelsFile(path, 0, testLogger)
+	assert.Nil(t, tlf.Labels("10.0.0.1:8080"))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"10.0.0.1:8080": {"team": "payments", "tier": "gold", "__address__": "ignored", "bad-name": "ignored"},
+		"10.0.0.2:8080": {}
+	}`), 0600))
+	tlf.refresh()
+	assert.Equal(t, labels.FromStrings("team", "payments", "tier", "gold"), tlf.Labels("10.0.0.1:8080"))
+	assert.Nil(t, tlf.Labels("10.0.0.2:8080"))
+
+	// The labels read last are kept while the file is invalid.
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"10.0.0.1:8080": `), 0600))
+	tlf.refresh()
+	assert.Equal(t, labels.FromStrings("team", "payments", "tier", "gold"), tlf.Labels("10.0.0.1:8080"))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"10.0.0.1:8080": {"team": "checkout"}}`), 0600))
+	tlf.refresh()
+	assert.Equal(t, labels.FromStrings("team", "checkout"), tlf.Labels("10.0.0.1:8080"))
+
+	require.NoError(t, os.Remove(path))
+	tlf.refresh()
+	assert.Nil(t, tlf.Labels("10.0.0.1:8080"))
+}
+
+func TestMergeLabels(t *testing.T) {
+	ls := labels.FromStrings("__name__", "up", "job", "test", "instance", "10.0.0.1:8080", "team", "sample")
+	extra := labels.FromStrings("team", "payments", "tier", "gold")
+	// The labels of the samples take precedence.
+	assert.Equal(t,
+		labels.FromStrings("__name__", "up", "job", "test", "instance", "10.0.0.1:8080", "team", "sample", "tier", "gold"),
+		mergeLabels(ls, extra))
+}
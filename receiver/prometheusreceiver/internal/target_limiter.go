@@ -0,0 +1,157 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"sort"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+)
+
+// TargetLimiter sits between the discovery manager and the scrape manager and
+// caps the number of targets of each job, so that a runaway service discovery
+// backend can't make the receiver run out of memory. The targets kept are the
+// first ones by address, so the same targets are kept across updates.
+type TargetLimiter struct {
+	ctx        context.Context
+	maxTargets int
+	logger     *zap.SugaredLogger
+}
+
+// NewTargetLimiter creates a TargetLimiter keeping at most maxTargets targets
+// per job, 0 means no limit. The context is used to stop forwarding updates
+// and to record the number of dropped targets, so it must be created using
+// observability.ContextWithReceiverName.
+func NewTargetLimiter(ctx context.Context, maxTargets int, logger *zap.SugaredLogger) *TargetLimiter {
+	return &TargetLimiter{ctx: ctx, maxTargets: maxTargets, logger: logger}
+}
+
+// Run forwards the target sets received on the given channel, as sent by the
+// discovery manager, to the returned channel after applying the limit.
+func (tl *TargetLimiter) Run(in <-chan map[string][]*targetgroup.Group) <-chan map[string][]*targetgroup.Group {
+	if tl.maxTargets <= 0 {
+		return in
+	}
+	out := make(chan map[string][]*targetgroup.Group)
+	go func() {
+		for {
+			select {
+			case <-tl.ctx.Done():
+				return
+			case tsets, ok := <-in:
+				if !ok {
+					close(out)
+					return
+				}
+				select {
+				case out <- tl.limit(tsets):
+				case <-tl.ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// limit returns a copy of the target sets without the targets exceeding the
+// limit of their job, the input is left untouched.
+func (tl *TargetLimiter) limit(tsets map[string][]*targetgroup.Group) map[string][]*targetgroup.Group {
+	limited := make(map[string][]*targetgroup.Group, len(tsets))
+	dropped := 0
+	for job, groups := range tsets {
+		kept, n := tl.limitJob(groups)
+		limited[job] = kept
+		if n > 0 {
+			tl.logger.Warnw("Too many targets discovered, dropping the targets exceeding the limit",
+				"job", job, "max_targets", tl.maxTargets, "dropped", n)
+		}
+		dropped += n
+	}
+	observability.RecordDroppedTargetsForMetricsReceiver(tl.ctx, dropped)
+	return limited
+}
+
+func (tl *TargetLimiter) limitJob(groups []*targetgroup.Group) ([]*targetgroup.Group, int) {
+	total := 0
+	for _, g := range groups {
+		if g != nil {
+			total += len(g.Targets)
+		}
+	}
+	if total <= tl.maxTargets {
+		return groups, 0
+	}
+
+	type target struct {
+		address string
+		group   int
+		index   int
+	}
+	targets := make([]target, 0, total)
+	for i, g := range groups {
+		if g == nil {
+			continue
+		}
+		for j, t := range g.Targets {
+			targets = append(targets, target{address: targetAddress(g, t), group: i, index: j})
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].address != targets[j].address {
+			return targets[i].address < targets[j].address
+		}
+		if targets[i].group != targets[j].group {
+			return targets[i].group < targets[j].group
+		}
+		return targets[i].index < targets[j].index
+	})
+
+	keep := make(map[target]bool, tl.maxTargets)
+	for _, t := range targets[:tl.maxTargets] {
+		keep[target{group: t.group, index: t.index}] = true
+	}
+	limited := make([]*targetgroup.Group, 0, len(groups))
+	for i, g := range groups {
+		if g == nil {
+			limited = append(limited, g)
+			continue
+		}
+		// Groups are copied, even when they are emptied, so the scrape manager
+		// stops the targets dropped since the previous update.
+		lg := &targetgroup.Group{Labels: g.Labels, Source: g.Source}
+		for j, t := range g.Targets {
+			if keep[target{group: i, index: j}] {
+				lg.Targets = append(lg.Targets, t)
+			}
+		}
+		limited = append(limited, lg)
+	}
+	return limited, total - tl.maxTargets
+}
+
+// targetAddress returns the address of a target, which is either set on the
+// target itself or common to its group.
+func targetAddress(g *targetgroup.Group, t model.LabelSet) string {
+	if addr, ok := t[model.AddressLabel]; ok {
+		return string(addr)
+	}
+	return string(g.Labels[model.AddressLabel])
+}
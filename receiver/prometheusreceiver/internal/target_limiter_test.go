@@ -0,0 +1,117 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+)
+
+func Test_targetLimiter(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	ctx, cancel := context.WithCancel(observability.ContextWithReceiverName(context.Background(), "prometheus"))
+	defer cancel()
+	tl := NewTargetLimiter(ctx, 3, testLogger)
+
+	// Discovery returns the targets out of order, spread over two groups, one
+	// of them using a group level address.
+	discovered := map[string][]*targetgroup.Group{
+		"pods": {
+			{
+				Source: "a",
+				Targets: []model.LabelSet{
+					{model.AddressLabel: "10.0.0.5:80"},
+					{model.AddressLabel: "10.0.0.1:80"},
+					{model.AddressLabel: "10.0.0.4:80"},
+				},
+			},
+			{
+				Source: "b",
+				Labels: model.LabelSet{model.AddressLabel: "10.0.0.2:80"},
+				Targets: []model.LabelSet{
+					{},
+				},
+			},
+			{
+				Source: "c",
+				Targets: []model.LabelSet{
+					{model.AddressLabel: "10.0.0.6:80"},
+					{model.AddressLabel: "10.0.0.3:80"},
+				},
+			},
+		},
+		"static": {
+			{Source: "0", Targets: []model.LabelSet{{model.AddressLabel: "localhost:9090"}}},
+		},
+	}
+
+	in := make(chan map[string][]*targetgroup.Group)
+	out := tl.Run(in)
+	in <- discovered
+	got := <-out
+
+	assert.Equal(t, []string{"10.0.0.1:80"}, addresses(got["pods"][0]))
+	assert.Equal(t, []string{"10.0.0.2:80"}, addresses(got["pods"][1]))
+	assert.Equal(t, []string{"10.0.0.3:80"}, addresses(got["pods"][2]))
+	assert.Equal(t, discovered["static"], got["static"], "jobs under the limit are left as is")
+	assert.Len(t, discovered["pods"][0].Targets, 3, "discovered groups must not be modified")
+	require.NoError(t, observabilitytest.CheckValueViewReceiverDroppedTargets("prometheus", 3))
+
+	// The overflow is reset once the job is back under the limit.
+	in <- map[string][]*targetgroup.Group{"pods": discovered["pods"][1:2]}
+	got = <-out
+	assert.Equal(t, []string{"10.0.0.2:80"}, addresses(got["pods"][0]))
+	require.NoError(t, observabilitytest.CheckValueViewReceiverDroppedTargets("prometheus", 0))
+}
+
+func Test_targetLimiterLargeDiscovery(t *testing.T) {
+	tl := NewTargetLimiter(context.Background(), 100, testLogger)
+
+	var targets []model.LabelSet
+	for i := 9999; i >= 0; i-- {
+		targets = append(targets, model.LabelSet{model.AddressLabel: model.LabelValue(fmt.Sprintf("10.0.%02d.%02d:80", i/100, i%100))})
+	}
+	got := tl.limit(map[string][]*targetgroup.Group{"pods": {{Source: "sd", Targets: targets}}})
+
+	kept := addresses(got["pods"][0])
+	require.Len(t, kept, 100)
+	assert.Equal(t, "10.0.00.00:80", kept[0])
+	assert.Equal(t, "10.0.00.99:80", kept[99])
+}
+
+func Test_targetLimiterDisabled(t *testing.T) {
+	in := make(chan map[string][]*targetgroup.Group)
+	tl := NewTargetLimiter(context.Background(), 0, testLogger)
+	assert.Equal(t, (<-chan map[string][]*targetgroup.Group)(in), tl.Run(in))
+}
+
+func addresses(g *targetgroup.Group) []string {
+	var addrs []string
+	for _, t := range g.Targets {
+		addrs = append(addrs, targetAddress(g, t))
+	}
+	return addrs
+}
@@ -18,12 +18,16 @@ import (
 	"context"
 	"errors"
 	"math"
+	"strconv"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/storage"
 	"go.uber.org/zap"
 
@@ -48,28 +52,75 @@ var errNoJobInstance = errors.New("job or instance cannot be found from labels")
 // will be flush to the downstream consumer, or Rollback, which means discard all the data, is called and all data
 // points are discarded.
 type transaction struct {
-	id            int64
-	ctx           context.Context
-	isNew         bool
-	sink          consumer.MetricsConsumer
-	job           string
-	instance      string
+	id    int64
+	ctx   context.Context
+	isNew bool
+	sink  consumer.MetricsConsumer
+	// target is the key of the target, in the jobsMap and the scrape outcomes.
+	target        string
 	jobsMap       *JobsMap
 	ms            MetadataService
 	node          *commonpb.Node
 	metricBuilder *metricBuilder
 	logger        *zap.SugaredLogger
+	// start is when the scrape data started to be appended, the deadline of the commit is derived from it.
+	start time.Time
+	// scrapeIntervals holds the scrape interval of each job, the commit must complete within it.
+	scrapeIntervals map[string]time.Duration
+	deadline        time.Time
+	// schemes holds the scheme of the jobs scraped in another scheme than the one of their scrape config.
+	schemes map[string]string
+	// scopes holds the instrumentation scope of each job, nil if the metrics have no scope.
+	scopes map[string]Scope
+	// backpressure pauses the scrape loop at commit time while the consumer is persistently slow, nil if disabled.
+	backpressure *backpressure
+	// targetLabelsFile holds the extra labels of the targets, nil if there are none.
+	targetLabelsFile *TargetLabelsFile
+	// extraLabels are the extra labels of the scraped target, added to its samples.
+	extraLabels labels.Labels
+	// lenientJobs holds the jobs whose scrapes are parsed leniently, nil if all are parsed strictly.
+	lenientJobs map[string]bool
+	// lenient is whether the samples appended before a parse error are committed rather than discarded.
+	lenient bool
+	// failed is whether appending a sample failed, the samples are then discarded even in lenient mode.
+	failed bool
+	// recordScrapeSizes is whether the size and the number of series of the scrape are recorded.
+	recordScrapeSizes bool
+	// size is the size of the appended samples in the Prometheus text format.
+	size int
+	job  string
+	// scrapeOutcomes holds the outcome of the scrapes of the targets, nil if the scrape success is not emitted.
+	scrapeOutcomes *scrapeOutcomes
+
+	emptyScrapePolicy EmptyScrapePolicy
+	// timestampPolicy defines whether the timestamps exposed by the target are kept or replaced by the start time.
+	timestampPolicy TimestampPolicy
+	// extremeQuantiles defines how the extreme quantiles of the summaries are converted.
+	extremeQuantiles ExtremeQuantilePolicy
+	// repairHistograms is whether the histogram series missing their _count or _sum are repaired rather than
+	// dropped.
+	repairHistograms bool
+	// nanBuckets defines how the histograms with a NaN bucket are converted.
+	nanBuckets NaNBucketPolicy
+	// streamingJobs holds the batch size of the jobs whose scrapes are streamed, nil if none is.
+	streamingJobs map[string]int
+	// streamingBatchSize is the number of series of the completed metric families passed down the pipeline while the
+	// page is appended, 0 if the scrape is not streamed.
+	streamingBatchSize int
 }
 
-func newTransaction(ctx context.Context, jobsMap *JobsMap, ms MetadataService, sink consumer.MetricsConsumer, logger *zap.SugaredLogger) *transaction {
+func newTransaction(ctx context.Context, jobsMap *JobsMap, ms MetadataService, sink consumer.MetricsConsumer,
+	logger *zap.SugaredLogger, emptyScrapePolicy EmptyScrapePolicy) *transaction {
 	return &transaction{
-		id:      atomic.AddInt64(&idSeq, 1),
-		ctx:     ctx,
-		isNew:   true,
-		sink:    sink,
-		jobsMap: jobsMap,
-		ms:      ms,
-		logger:  logger,
+		id:                atomic.AddInt64(&idSeq, 1),
+		ctx:               ctx,
+		isNew:             true,
+		sink:              sink,
+		jobsMap:           jobsMap,
+		ms:                ms,
+		logger:            logger,
+		start:             time.Now(),
+		emptyScrapePolicy: emptyScrapePolicy,
 	}
 }
 
@@ -101,10 +152,32 @@ func (tr *transaction) AddFast(ls labels.Labels, _ uint64, t int64, v float64) e
 
 	if tr.isNew {
 		if err := tr.initTransaction(ls); err != nil {
+			tr.failed = true
 			return err
 		}
 	}
-	return tr.metricBuilder.AddDataPoint(ls, t, v)
+	if tr.recordScrapeSizes {
+		tr.size += textSampleSize(ls, v)
+	}
+	if tr.timestampPolicy == TimestampOverride {
+		t = timestamp.FromTime(tr.start)
+	}
+	if len(tr.extraLabels) > 0 {
+		ls = mergeLabels(ls, tr.extraLabels)
+	}
+	if err := tr.metricBuilder.AddDataPoint(ls, t, v); err != nil {
+		tr.failed = true
+		return err
+	}
+	if tr.streamingBatchSize > 0 {
+		if completed := tr.metricBuilder.takeCompleted(tr.streamingBatchSize); completed != nil {
+			if err := tr.consume(tr.adjust(completed)); err != nil {
+				tr.failed = true
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func (tr *transaction) initTransaction(ls labels.Labels) error {
@@ -113,16 +186,34 @@ func (tr *transaction) initTransaction(ls labels.Labels) error {
 		return errNoJobInstance
 	}
 	// discover the binding target when this method is called for the first time during a transaction
-	mc, err := tr.ms.Get(job, instance)
+	mc, err := tr.ms.Get(job, instance, ls)
 	if err != nil {
 		return err
 	}
-	if tr.jobsMap != nil {
-		tr.job = job
-		tr.instance = instance
+	tr.target = targetKey(job, instance, mc)
+	scheme := mc.SharedLabels().Get(model.SchemeLabel)
+	if s, ok := tr.schemes[job]; ok {
+		scheme = s
+	}
+	tr.node = createNode(job, instance, scheme)
+	if tr.targetLabelsFile != nil {
+		// The labels are looked up once per scrape, for all its samples to get the same ones.
+		tr.extraLabels = tr.targetLabelsFile.Labels(instance)
+	}
+	tr.job = job
+	tr.lenient = tr.lenientJobs[job]
+	tr.streamingBatchSize = tr.streamingJobs[job]
+	if scope, ok := tr.scopes[job]; ok {
+		setScope(tr.node, scope)
 	}
-	tr.node = createNode(job, instance, mc.SharedLabels().Get(model.SchemeLabel))
+	if interval, ok := tr.scrapeIntervals[job]; ok && interval > 0 {
+		tr.deadline = tr.start.Add(interval)
+	}
+	tr.logger = tr.logger.With(jobKey, job, model.InstanceLabel, instance)
 	tr.metricBuilder = newMetricBuilder(mc, tr.logger)
+	tr.metricBuilder.extremeQuantiles = tr.extremeQuantiles
+	tr.metricBuilder.repairHistograms = tr.repairHistograms
+	tr.metricBuilder.nanBuckets = tr.nanBuckets
 	tr.isNew = false
 	return nil
 }
@@ -134,28 +225,130 @@ func (tr *transaction) Commit() error {
 		// never added any data points, that the transaction has not been initialized.
 		return nil
 	}
+	if tr.backpressure != nil {
+		// Hold the scrape loop of the target, the scrapes it misses meanwhile are skipped.
+		defer tr.backpressure.wait(tr.ctx)
+	}
+
+	// An empty scrape only shows up in the scrape report, which is appended in its own transaction after the (empty)
+	// page of the target was committed.
+	if tr.metricBuilder.isEmptyScrape() {
+		tr.reportEmptyScrape()
+	}
+	if tr.recordScrapeSizes {
+		tr.recordScrapeSize()
+	}
 
 	metrics, numTimeseries, droppedTimeseries, err := tr.metricBuilder.Build()
 	observability.RecordMetricsForMetricsReceiver(tr.ctx, numTimeseries, droppedTimeseries)
+	if tr.metricBuilder.repairedHistograms > 0 {
+		observability.RecordRepairedHistogramsForMetricsReceiver(tr.ctx, tr.metricBuilder.repairedHistograms)
+	}
+	if tr.metricBuilder.nanBucketHistograms > 0 {
+		observability.RecordNaNBucketHistogramsForMetricsReceiver(tr.ctx, tr.metricBuilder.nanBucketHistograms)
+	}
+	if tr.scrapeOutcomes != nil && (err != nil || droppedTimeseries > 0) {
+		tr.scrapeOutcomes.fail(tr.target)
+	}
 	if err != nil {
 		return err
 	}
+	metrics = tr.adjust(metrics)
+	if tr.scrapeOutcomes != nil {
+		if m := tr.scrapeSuccessMetric(); m != nil {
+			metrics = append(metrics[:len(metrics):len(metrics)], m)
+		}
+	}
+	return tr.consume(metrics)
+}
+
+// adjust returns the metrics with their start times adjusted to the ones of the first points of their series.
+func (tr *transaction) adjust(metrics []*metricspb.Metric) []*metricspb.Metric {
+	if tr.jobsMap == nil {
+		return metrics
+	}
+	adjusted := NewMetricsAdjuster(tr.jobsMap.get(tr.target), tr.logger).AdjustMetrics(metrics)
+	tr.jobsMap.EvictSeries(tr.ctx)
+	return adjusted
+}
+
+// consume passes the metrics of the target to the consumer.
+func (tr *transaction) consume(metrics []*metricspb.Metric) error {
 	// Note: metrics could be empty after adjustment, which needs to be checked before passing it on to ConsumeMetricsData()
-	if tr.jobsMap != nil {
-		metrics = NewMetricsAdjuster(tr.jobsMap.get(tr.job, tr.instance), tr.logger).AdjustMetrics(metrics)
+	if len(metrics) == 0 {
+		return nil
+	}
+	md := consumerdata.MetricsData{
+		Node:    tr.node,
+		Metrics: metrics,
+	}
+	ctx := tr.ctx
+	if !tr.deadline.IsZero() {
+		// Do not let a slow consumer extend the scrape past its interval.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, tr.deadline)
+		defer cancel()
+	}
+	return tr.sink.ConsumeMetricsData(ctx, md)
+}
+
+func (tr *transaction) reportEmptyScrape() {
+	observability.RecordEmptyScrapeForMetricsReceiver(tr.ctx)
+	if tr.emptyScrapePolicy == EmptyScrapeWarn {
+		tr.logger.Warn("scrape succeeded but returned no samples")
+		return
+	}
+	tr.logger.Debug("scrape succeeded but returned no samples")
+}
+
+// recordScrapeSize records the size of the samples of a scrape, and the number of series of the target from the
+// scrape report, which is appended in its own transaction.
+func (tr *transaction) recordScrapeSize() {
+	if tr.metricBuilder.hasData {
+		observability.RecordScrapeSizeForMetricsReceiver(tr.ctx, tr.job, tr.size)
+	}
+	up := tr.metricBuilder.scrapeReport[upMetricName]
+	if samples, ok := tr.metricBuilder.scrapeReport[scrapedSamplesMetricName]; ok && up == 1 {
+		observability.RecordScrapeSeriesForMetricsReceiver(tr.ctx, tr.job, int(samples))
 	}
-	if len(metrics) > 0 {
-		md := consumerdata.MetricsData{
-			Node:    tr.node,
-			Metrics: metrics,
+}
+
+// textSampleSize returns the size of the line of the sample in the Prometheus text format, without timestamp. The
+// scrape loop doesn't expose the size of the response, it is approximated by the size of its samples, labeled with
+// the target labels.
+func textSampleSize(ls labels.Labels, v float64) int {
+	// The value is preceded by a space and followed by a new line.
+	size := len(strconv.FormatFloat(v, 'g', -1, 64)) + 2
+	numLabels := 0
+	for _, l := range ls {
+		if l.Name == model.MetricNameLabel {
+			size += len(l.Value)
+			continue
 		}
-		return tr.sink.ConsumeMetricsData(tr.ctx, md)
+		// name="value",
+		size += len(l.Name) + len(l.Value) + 4
+		numLabels++
 	}
-	return nil
+	if numLabels > 0 {
+		// The braces replace the last comma.
+		size++
+	}
+	return size
 }
 
+// Rollback discards the appended samples, unless the scrape is parsed leniently. The scrape loop rolls back when the
+// response of the target can't be parsed, in lenient mode the samples parsed before the error are then committed. The
+// samples are still discarded when appending one failed.
 func (tr *transaction) Rollback() error {
-	return nil
+	if tr.scrapeOutcomes != nil && !tr.isNew {
+		tr.scrapeOutcomes.fail(tr.target)
+	}
+	if !tr.lenient || tr.isNew || tr.failed {
+		return nil
+	}
+	observability.RecordPartialScrapeForMetricsReceiver(tr.ctx)
+	tr.logger.Warn("scrape response has invalid content, committing the samples parsed before it")
+	return tr.Commit()
 }
 
 func createNode(job, instance, scheme string) *commonpb.Node {
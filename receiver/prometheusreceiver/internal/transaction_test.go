@@ -23,6 +23,11 @@ import (
 
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/scrape"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
 )
 
 func Test_transaction(t *testing.T) {
@@ -34,7 +39,7 @@ func Test_transaction(t *testing.T) {
 
 	t.Run("Commit Without Adding", func(t *testing.T) {
 		mcon := newMockConsumer()
-		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger)
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
 		if got := tr.Commit(); got != nil {
 			t.Errorf("expecting nil from Commit() but got err %v", got)
 		}
@@ -42,7 +47,7 @@ func Test_transaction(t *testing.T) {
 
 	t.Run("Rollback dose nothing", func(t *testing.T) {
 		mcon := newMockConsumer()
-		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger)
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
 		if got := tr.Rollback(); got != nil {
 			t.Errorf("expecting nil from Rollback() but got err %v", got)
 		}
@@ -51,7 +56,7 @@ func Test_transaction(t *testing.T) {
 	badLabels := labels.Labels([]labels.Label{{Name: "foo", Value: "bar"}})
 	t.Run("Add One No Target", func(t *testing.T) {
 		mcon := newMockConsumer()
-		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger)
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
 		if _, got := tr.Add(badLabels, time.Now().Unix()*1000, 1.0); got == nil {
 			t.Errorf("expecting error from Add() but got nil")
 		}
@@ -63,7 +68,7 @@ func Test_transaction(t *testing.T) {
 		{Name: "foo", Value: "bar"}})
 	t.Run("Add One Job not found", func(t *testing.T) {
 		mcon := newMockConsumer()
-		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger)
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
 		if _, got := tr.Add(jobNotFoundLb, time.Now().Unix()*1000, 1.0); got == nil {
 			t.Errorf("expecting error from Add() but got nil")
 		}
@@ -74,7 +79,7 @@ func Test_transaction(t *testing.T) {
 		{Name: "__name__", Value: "foo"}})
 	t.Run("Add One Good", func(t *testing.T) {
 		mcon := newMockConsumer()
-		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger)
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
 		if _, got := tr.Add(goodLabels, time.Now().Unix()*1000, 1.0); got != nil {
 			t.Errorf("expecting error == nil from Add() but got: %v\n", got)
 		}
@@ -95,7 +100,7 @@ func Test_transaction(t *testing.T) {
 
 	t.Run("Drop NaN value", func(t *testing.T) {
 		mcon := newMockConsumer()
-		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger)
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
 		if _, got := tr.Add(goodLabels, time.Now().Unix()*1000, math.NaN()); got != nil {
 			t.Errorf("expecting error == nil from Add() but got: %v\n", got)
 		}
@@ -108,4 +113,225 @@ func Test_transaction(t *testing.T) {
 		}
 	})
 
+	t.Run("Honor timestamps", func(t *testing.T) {
+		mcon := newMockConsumer()
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
+		tr.timestampPolicy = TimestampHonor
+		if _, got := tr.Add(goodLabels, 1000, 1.0); got != nil {
+			t.Errorf("expecting error == nil from Add() but got: %v\n", got)
+		}
+		if got := tr.Commit(); got != nil {
+			t.Errorf("expecting nil from Commit() but got err %v", got)
+		}
+
+		if got := mcon.md.Metrics[0].Timeseries[0].Points[0].Timestamp.Seconds; got != 1 {
+			t.Errorf("expecting the sample timestamp, but got %vs\n", got)
+		}
+	})
+
+	t.Run("Override timestamps", func(t *testing.T) {
+		mcon := newMockConsumer()
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
+		tr.timestampPolicy = TimestampOverride
+		if _, got := tr.Add(goodLabels, 1000, 1.0); got != nil {
+			t.Errorf("expecting error == nil from Add() but got: %v\n", got)
+		}
+		if got := tr.Commit(); got != nil {
+			t.Errorf("expecting nil from Commit() but got err %v", got)
+		}
+
+		if got := mcon.md.Metrics[0].Timeseries[0].Points[0].Timestamp.Seconds; got != tr.start.Unix() {
+			t.Errorf("expecting the transaction start %vs, but got %vs\n", tr.start.Unix(), got)
+		}
+	})
+
+	t.Run("Extra target labels", func(t *testing.T) {
+		tlf := &TargetLabelsFile{targets: map[string]labels.Labels{
+			"localhost:8080": labels.FromStrings("team", "payments"),
+		}}
+		mcon := newMockConsumer()
+		tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
+		tr.targetLabelsFile = tlf
+		if _, got := tr.Add(goodLabels, 1000, 1.0); got != nil {
+			t.Errorf("expecting error == nil from Add() but got: %v\n", got)
+		}
+		if got := tr.Commit(); got != nil {
+			t.Errorf("expecting nil from Commit() but got err %v", got)
+		}
+
+		desc := mcon.md.Metrics[0].MetricDescriptor
+		if len(desc.LabelKeys) != 1 || desc.LabelKeys[0].Key != "team" {
+			t.Fatalf("expecting the team label key, but got %v\n", desc.LabelKeys)
+		}
+		if got := mcon.md.Metrics[0].Timeseries[0].LabelValues[0].Value; got != "payments" {
+			t.Errorf("expecting the team label value payments, but got %v\n", got)
+		}
+	})
+}
+
+func Test_transactionEmptyScrape(t *testing.T) {
+	ms := &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{
+			"test_localhost:8080": {data: map[string]scrape.MetricMetadata{}},
+		},
+	}
+	report := func(name string) labels.Labels {
+		return labels.FromStrings("__name__", name, "job", "test", "instance", "localhost:8080")
+	}
+
+	tests := []struct {
+		name     string
+		policy   EmptyScrapePolicy
+		up       float64
+		samples  float64
+		wantLogs int
+		wantWarn bool
+	}{
+		{name: "success", policy: EmptyScrapeSuccess, up: 1, samples: 0, wantLogs: 1},
+		{name: "warn", policy: EmptyScrapeWarn, up: 1, samples: 0, wantLogs: 1, wantWarn: true},
+		{name: "failed_scrape", policy: EmptyScrapeWarn, up: 0, samples: 0},
+		{name: "not_empty", policy: EmptyScrapeWarn, up: 1, samples: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			mcon := newMockConsumer()
+			tr := newTransaction(context.Background(), nil, ms, mcon, zap.New(core).Sugar(), tt.policy)
+			ts := time.Now().Unix() * 1000
+			if _, err := tr.Add(report("up"), ts, tt.up); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if _, err := tr.Add(report("scrape_samples_scraped"), ts, tt.samples); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err := tr.Commit(); err != nil {
+				t.Fatalf("expecting nil from Commit() but got err %v", err)
+			}
+			if mcon.md != nil {
+				t.Errorf("expecting no metrics from a scrape report, got %v", mcon.md)
+			}
+
+			entries := logs.FilterMessage("scrape succeeded but returned no samples").All()
+			if len(entries) != tt.wantLogs {
+				t.Fatalf("want %d empty scrape log entries, got %d", tt.wantLogs, len(entries))
+			}
+			if tt.wantLogs > 0 {
+				wantLevel := zapcore.DebugLevel
+				if tt.wantWarn {
+					wantLevel = zapcore.WarnLevel
+				}
+				if entries[0].Level != wantLevel {
+					t.Errorf("want log level %v, got %v", wantLevel, entries[0].Level)
+				}
+			}
+		})
+	}
+}
+
+func Test_transactionLenientRollback(t *testing.T) {
+	ms := &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{
+			"test_localhost:8080": {data: map[string]scrape.MetricMetadata{}},
+		},
+	}
+	goodLabels := labels.FromStrings("__name__", "foo", "job", "test", "instance", "localhost:8080")
+	noNameLabels := labels.FromStrings("job", "test", "instance", "localhost:8080")
+
+	tests := []struct {
+		name        string
+		lenientJobs map[string]bool
+		badSample   bool
+		wantMetrics bool
+	}{
+		{name: "strict", lenientJobs: nil},
+		{name: "lenient", lenientJobs: map[string]bool{"test": true}, wantMetrics: true},
+		{name: "other_job_lenient", lenientJobs: map[string]bool{"other": true}},
+		{name: "lenient_failed_append", lenientJobs: map[string]bool{"test": true}, badSample: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcon := newMockConsumer()
+			tr := newTransaction(context.Background(), nil, ms, mcon, testLogger, EmptyScrapeSuccess)
+			tr.lenientJobs = tt.lenientJobs
+			if _, err := tr.Add(goodLabels, time.Now().Unix()*1000, 1.0); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.badSample {
+				if _, err := tr.Add(noNameLabels, time.Now().Unix()*1000, 1.0); err == nil {
+					t.Fatalf("expecting error from Add() but got nil")
+				}
+			}
+			// The scrape loop rolls back on a parse error.
+			if err := tr.Rollback(); err != nil {
+				t.Fatalf("expecting nil from Rollback() but got err %v", err)
+			}
+
+			if tt.wantMetrics {
+				if mcon.md == nil || len(mcon.md.Metrics) != 1 {
+					t.Fatalf("expecting the samples parsed before the error to be committed, got %v", mcon.md)
+				}
+			} else if mcon.md != nil {
+				t.Errorf("expecting the samples to be discarded, got %v", mcon.md)
+			}
+		})
+	}
+}
+
+func Test_textSampleSize(t *testing.T) {
+	tests := []struct {
+		line string
+		ls   labels.Labels
+		v    float64
+	}{
+		{line: "up 1\n", ls: labels.FromStrings("__name__", "up"), v: 1},
+		{
+			line: `http_requests_total{code="200",method="get"} 1027.5` + "\n",
+			ls:   labels.FromStrings("__name__", "http_requests_total", "method", "get", "code", "200"),
+			v:    1027.5,
+		},
+	}
+	for _, tt := range tests {
+		if got := textSampleSize(tt.ls, tt.v); got != len(tt.line) {
+			t.Errorf("textSampleSize(%v, %v) = %d, want %d", tt.ls, tt.v, got, len(tt.line))
+		}
+	}
+}
+
+type slowConsumer struct{}
+
+func (slowConsumer) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_transactionCommitDeadline(t *testing.T) {
+	ms := &mockMetadataSvc{
+		caches: map[string]*mockMetadataCache{
+			"test_localhost:8080": {data: map[string]scrape.MetricMetadata{}},
+		},
+	}
+	goodLabels := labels.FromStrings("__name__", "foo", "job", "test", "instance", "localhost:8080")
+
+	const interval = 100 * time.Millisecond
+	tr := newTransaction(context.Background(), nil, ms, slowConsumer{}, testLogger, EmptyScrapeSuccess)
+	tr.scrapeIntervals = map[string]time.Duration{"test": interval}
+	if _, err := tr.Add(goodLabels, time.Now().Unix()*1000, 1.0); err != nil {
+		t.Fatalf("expecting error == nil from Add() but got: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tr.Commit()
+	}()
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Errorf("want %v from Commit(), got %v", context.DeadlineExceeded, err)
+		}
+		if elapsed := time.Since(tr.start); elapsed < interval {
+			t.Errorf("consume was cancelled after %v, before the scrape deadline of %v", elapsed, interval)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Commit() was not cancelled at the scrape deadline")
+	}
 }
@@ -0,0 +1,132 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// TestJobsSharingHost checks that two jobs scraping the same host on different paths track the resets of their
+// counters independently, although their counters have the same name and labels.
+func TestJobsSharingHost(t *testing.T) {
+	var mu sync.Mutex
+	scrapes := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		scrapes[req.URL.Path]++
+		n := scrapes[req.URL.Path]
+		mu.Unlock()
+
+		var value int
+		switch req.URL.Path {
+		case "/resetting":
+			// 10, 20, then reset to 5, 15, 25...
+			value = 10 * n
+			if n >= 3 {
+				value = 5 + 10*(n-3)
+			}
+		case "/growing":
+			value = 100 * n
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(rw, "# TYPE requests_total counter\nrequests_total %d\n", value)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: resetting
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    metrics_path: /resetting
+    static_configs:
+      - targets: ["` + u.Host + `"]
+  - job_name: growing
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    metrics_path: /growing
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: typeStr},
+		PrometheusConfig: pCfg,
+	}
+	sink := new(exportertest.SinkMetricsExporter)
+	precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+
+	// The start timestamps of the points sent for each job, the value of a point is the increase since its start.
+	type point struct {
+		start int64
+		value float64
+	}
+	pointsByJob := func() map[string][]point {
+		points := make(map[string][]point)
+		for _, md := range sink.AllMetrics() {
+			job := md.Node.GetServiceInfo().GetName()
+			for _, metric := range md.Metrics {
+				if metric.MetricDescriptor.Name != "requests_total" {
+					continue
+				}
+				for _, ts := range metric.Timeseries {
+					points[job] = append(points[job], point{
+						start: ts.StartTimestamp.GetSeconds()*1e9 + int64(ts.StartTimestamp.GetNanos()),
+						value: ts.Points[0].GetDoubleValue(),
+					})
+				}
+			}
+		}
+		return points
+	}
+	require.Eventually(t, func() bool {
+		points := pointsByJob()
+		return len(points["resetting"]) >= 2 && len(points["growing"]) >= 3
+	}, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, precv.StopMetricsReception())
+
+	points := pointsByJob()
+	// The reset of the first job restarts its counter.
+	resetting := points["resetting"]
+	assert.Equal(t, float64(10), resetting[0].value)
+	assert.Equal(t, float64(10), resetting[1].value)
+	assert.True(t, resetting[1].start > resetting[0].start, "expecting a new start after the reset")
+	// The second job is never reset.
+	for i, p := range points["growing"] {
+		assert.Equal(t, points["growing"][0].start, p.start)
+		assert.Equal(t, float64(100*(i+1)), p.value)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// invalidResponse is a scrape response made of valid metrics followed by garbage, as sent by a target whose
+// handler failed after writing the first metrics.
+const invalidResponse = `# HELP valid_gauge A valid gauge.
+# TYPE valid_gauge gauge
+valid_gauge 42
+<html><body>500 Internal Server Error</body></html>
+`
+
+func TestLenientParsing(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	var scrapes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&scrapes, 1)
+		fmt.Fprint(rw, invalidResponse)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		lenient bool
+	}{
+		{name: "strict", lenient: false},
+		{name: "lenient", lenient: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: garbage
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+			require.NoError(t, err)
+
+			cfg := &Config{
+				ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/" + tt.name},
+				PrometheusConfig: pCfg,
+				LenientParsing:   tt.lenient,
+			}
+			sink := new(exportertest.SinkMetricsExporter)
+			precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+			atomic.StoreInt32(&scrapes, 0)
+			require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+
+			if tt.lenient {
+				require.Eventually(t, func() bool { return partialScrapes(cfg.Name()) >= 2 }, 10*time.Second, 50*time.Millisecond)
+			} else {
+				require.Eventually(t, func() bool { return atomic.LoadInt32(&scrapes) >= 3 }, 10*time.Second, 50*time.Millisecond)
+			}
+			require.NoError(t, precv.StopMetricsReception())
+
+			var gauges []float64
+			for _, md := range sink.AllMetrics() {
+				for _, metric := range md.Metrics {
+					require.Equal(t, "valid_gauge", metric.GetMetricDescriptor().GetName())
+					gauges = append(gauges, metric.Timeseries[0].Points[0].GetDoubleValue())
+				}
+			}
+			if tt.lenient {
+				// The metrics before the garbage are committed.
+				require.NotEmpty(t, gauges)
+				assert.Equal(t, 42.0, gauges[0])
+			} else {
+				// The whole scrape is discarded.
+				assert.Empty(t, gauges)
+				assert.Equal(t, int64(0), partialScrapes(cfg.Name()))
+			}
+		})
+	}
+}
+
+func partialScrapes(receiverName string) int64 {
+	rows, err := view.RetrieveData(observability.ViewReceiverPartialScrapes.Name)
+	if err != nil {
+		return 0
+	}
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == observability.TagKeyReceiver && tag.Value == receiverName {
+				return int64(row.Data.(*view.SumData).Value)
+			}
+		}
+	}
+	return 0
+}
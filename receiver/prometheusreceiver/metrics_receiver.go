@@ -16,19 +16,19 @@ package prometheusreceiver
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/scrape"
 	"go.uber.org/zap"
 
 	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/extension/zpagesextension"
 	"github.com/open-telemetry/opentelemetry-service/observability"
 	"github.com/open-telemetry/opentelemetry-service/receiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/prometheusreceiver/internal"
-
-	sd_config "github.com/prometheus/prometheus/discovery/config"
 )
 
 type metricsMap map[string]bool
@@ -43,6 +43,18 @@ type Preceiver struct {
 	logger           *zap.Logger
 	receiverFullName string
 	includeFilterMap map[string]metricsMap
+	scrapeProxies    []*scrapeProxy
+	app              internal.OcaStore
+	redactor         *secretRedactor
+
+	// disabledJobs holds the jobs disabled with the "disabled" job setting.
+	disabledJobs map[string]bool
+
+	// jobsMtx guards the state set once the reception is started.
+	jobsMtx       sync.Mutex
+	ctx           context.Context
+	promCfg       *config.Config
+	scrapeManager *scrape.Manager
 }
 
 var _ receiver.MetricsReceiver = (*Preceiver)(nil)
@@ -64,9 +76,16 @@ func newPrometheusReceiver(logger *zap.Logger, cfg *Config, next consumer.Metric
 	pr := &Preceiver{
 		cfg:              cfg,
 		consumer:         next,
-		logger:           logger,
+		logger:           logger.With(zap.String("receiver", cfg.Name())),
 		receiverFullName: cfg.Name(),
 		includeFilterMap: parseIncludeFilter(cfg.IncludeFilter),
+		disabledJobs:     make(map[string]bool),
+		redactor:         newSecretRedactor(cfg),
+	}
+	for job, settings := range cfg.Jobs {
+		if settings.Disabled {
+			pr.disabledJobs[strings.ToLower(job)] = true
+		}
 	}
 	return pr
 }
@@ -88,21 +107,69 @@ func (pr *Preceiver) StartMetricsReception(host receiver.Host) error {
 		// TODO: Use the name from the ReceiverSettings
 		c = observability.ContextWithReceiverName(c, pr.receiverFullName)
 		jobsMap := internal.NewJobsMap(time.Duration(2 * time.Minute))
-		app := internal.NewOcaStore(c, pr.consumer, pr.logger.Sugar(), jobsMap)
+		jobsMap.SetMaxSeries(pr.cfg.MaxAdjustedSeries)
+		// the policy was already validated by the factory, an invalid one falls back to the default
+		policy, _ := emptyScrapePolicy(pr.cfg)
+		timestamps, _ := timestampPolicy(pr.cfg)
+		commitBatch := internal.CommitBatchSettings{Window: pr.cfg.CommitBatchWindow, MaxSize: pr.cfg.CommitBatchMaxSize}
+		backpressure := internal.BackpressureSettings{
+			Threshold:   pr.cfg.BackpressureThreshold,
+			SlowCommits: pr.cfg.BackpressureSlowCommits,
+			MaxDelay:    pr.cfg.BackpressureMaxDelay,
+		}
+		app := internal.NewOcaStore(c, pr.consumer, pr.logger.Sugar(), jobsMap, policy, timestamps, commitBatch,
+			backpressure, pr.cfg.MaxConcurrentScrapes, jobScopes(pr.cfg))
+		pr.app = app
+		if pr.cfg.TargetLabelsFile != "" {
+			targetLabels := internal.NewTargetLabelsFile(pr.cfg.TargetLabelsFile, pr.cfg.TargetLabelsRefreshInterval,
+				pr.logger.Sugar())
+			go targetLabels.Run(c)
+			app.SetTargetLabelsFile(targetLabels)
+		}
 		// need to use a logger with the gokitLog interface
-		l := internal.NewZapToGokitLogAdapter(pr.logger)
+		l := internal.NewRedactingZapToGokitLogAdapter(pr.logger, pr.redactor.redact)
 		scrapeManager := scrape.NewManager(l, app)
 		app.SetScrapeManager(scrapeManager)
-		discoveryManagerScrape := discovery.NewManager(ctx, l)
+		promCfg, proxies, err := applyJobSettings(c, pr.cfg, activeTargets(scrapeManager))
+		if err != nil {
+			pr.reportFatalError(host, err)
+			return
+		}
+		discoveryManagerScrape := internal.NewDiscoveryManagers(c, l, jobNames(promCfg), pr.cfg.DiscoveryConcurrency)
 		go func() {
 			if err := discoveryManagerScrape.Run(); err != nil {
-				host.ReportFatalError(err)
+				pr.reportFatalError(host, err)
 			}
 		}()
-		if err := scrapeManager.ApplyConfig(pr.cfg.PrometheusConfig); err != nil {
-			host.ReportFatalError(err)
+		pr.scrapeProxies = proxies
+		app.SetScrapeIntervals(scrapeIntervals(promCfg))
+		app.SetSchemes(proxiedSchemes(pr.cfg, promCfg))
+		app.SetLenientJobs(lenientJobs(pr.cfg, promCfg))
+		app.SetStreamingJobs(streamingJobs(pr.cfg, promCfg))
+		app.SetRecordScrapeSizes(pr.cfg.RecordScrapeSizes)
+		app.SetEmitScrapeSuccess(pr.cfg.EmitScrapeSuccess)
+		quantiles, _ := extremeQuantilePolicy(pr.cfg)
+		app.SetExtremeQuantilePolicy(quantiles)
+		app.SetRepairHistograms(pr.cfg.RepairHistograms)
+		nanBuckets, _ := nanBucketPolicy(pr.cfg)
+		app.SetNaNBucketPolicy(nanBuckets)
+
+		pr.jobsMtx.Lock()
+		pr.ctx = c
+		pr.promCfg = promCfg
+		pr.scrapeManager = scrapeManager
+		enabledCfg := pr.enabledConfig()
+		for _, sc := range promCfg.ScrapeConfigs {
+			observability.RecordScrapeJobStateForMetricsReceiver(c, sc.JobName, pr.isJobDisabled(sc.JobName))
+		}
+		pr.recordConfigHash()
+		pr.jobsMtx.Unlock()
+
+		if err := scrapeManager.ApplyConfig(enabledCfg); err != nil {
+			pr.reportFatalError(host, err)
 			return
 		}
+		zpagesextension.RegisterPage(pr.targetErrorsPagePath(), targetErrorsPage{pr: pr})
 
 		// Run the scrape manager.
 		syncConfig := make(chan bool)
@@ -111,7 +178,14 @@ func (pr *Preceiver) StartMetricsReception(host receiver.Host) error {
 			defer close(errsChan)
 			<-time.After(100 * time.Millisecond)
 			close(syncConfig)
-			if err := scrapeManager.Run(discoveryManagerScrape.SyncCh()); err != nil {
+			// The evicted targets are removed before applying the limit, leaving room for the other targets.
+			targetEvictor := internal.NewTargetEvictor(c, internal.TargetEvictionSettings{
+				After:    pr.cfg.EvictFailingTargetsAfter,
+				Cooldown: pr.cfg.EvictedTargetsCooldown,
+			}, internal.ScrapeManagerTargetHealth(scrapeManager), pr.logger.Sugar())
+			targetLimiter := internal.NewTargetLimiter(c, pr.cfg.MaxTargets, pr.logger.Sugar())
+			tsets := targetLimiter.Run(targetEvictor.Run(discoveryManagerScrape.SyncCh()))
+			if err := scrapeManager.Run(tsets); err != nil {
 				errsChan <- err
 			}
 		}()
@@ -119,19 +193,20 @@ func (pr *Preceiver) StartMetricsReception(host receiver.Host) error {
 		// By this point we've given time to the scrape manager
 		// to start applying its original configuration.
 
-		discoveryCfg := make(map[string]sd_config.ServiceDiscoveryConfig)
-		for _, scrapeConfig := range pr.cfg.PrometheusConfig.ScrapeConfigs {
-			discoveryCfg[scrapeConfig.JobName] = scrapeConfig.ServiceDiscoveryConfig
-		}
-
 		// Now trigger the discovery notification to the scrape manager.
-		if err := discoveryManagerScrape.ApplyConfig(discoveryCfg); err != nil {
+		if err := discoveryManagerScrape.ApplyConfig(discoveryConfig(enabledCfg)); err != nil {
 			errsChan <- err
 		}
 	})
 	return nil
 }
 
+// reportFatalError reports the given error to the host, with the secrets of
+// the config masked since config errors can render them.
+func (pr *Preceiver) reportFatalError(host receiver.Host, err error) {
+	host.ReportFatalError(pr.redactor.redactError(err))
+}
+
 // Flush triggers the Flush method on the underlying Prometheus scrapers and instructs
 // them to immediately sned over the metrics they've collected, to the MetricsConsumer.
 // it's not needed on the new prometheus receiver implementation, let it do nothing
@@ -141,6 +216,14 @@ func (pr *Preceiver) Flush() {
 
 // StopMetricsReception stops and cancels the underlying Prometheus scrapers.
 func (pr *Preceiver) StopMetricsReception() error {
-	pr.stopOnce.Do(pr.cancel)
+	pr.stopOnce.Do(func() {
+		// Flush the buffered data before the context of the store is canceled.
+		if err := pr.app.Close(); err != nil {
+			pr.logger.Warn("Failed to flush the scraped data", zap.Error(err))
+		}
+		pr.cancel()
+		stopScrapeProxies(pr.scrapeProxies)
+		zpagesextension.UnregisterPage(pr.targetErrorsPagePath())
+	})
 	return nil
 }
@@ -0,0 +1,109 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const redactedSecret = "<secret>"
+
+// secretFieldPattern matches the values of the secret fields of a prometheus
+// config, and of bearer credentials, when they are rendered in a message.
+var secretFieldPattern = regexp.MustCompile(
+	`(?i)(\b(?:bearer_token|password|authorization)["']?\s*[:=]\s*["']?(?:bearer\s+)?|\bbearer\s+)[^\s"',}\]]+`)
+
+// secretRedactor masks the secrets of the receiver config in the messages of
+// config related errors and logs, before they reach the logger or the host.
+type secretRedactor struct {
+	// secrets is sorted by decreasing length so that a secret containing
+	// another one is fully masked.
+	secrets []string
+}
+
+// newSecretRedactor returns a redactor of the bearer tokens and basic auth
+// passwords of the prometheus scrape configs, and of the authorization and
+// environment expanded job headers.
+func newSecretRedactor(cfg *Config) *secretRedactor {
+	seen := make(map[string]bool)
+	add := func(s string) {
+		if s != "" && s != redactedSecret {
+			seen[s] = true
+		}
+	}
+	if cfg.PrometheusConfig != nil {
+		for _, sc := range cfg.PrometheusConfig.ScrapeConfigs {
+			add(string(sc.HTTPClientConfig.BearerToken))
+			if sc.HTTPClientConfig.BasicAuth != nil {
+				add(string(sc.HTTPClientConfig.BasicAuth.Password))
+			}
+		}
+	}
+	for _, settings := range cfg.Jobs {
+		for k, v := range settings.Headers {
+			expanded := os.ExpandEnv(v)
+			if strings.EqualFold(k, authorizationHeader) {
+				add(v)
+				add(expanded)
+			} else if expanded != v {
+				add(expanded)
+			}
+		}
+	}
+
+	r := &secretRedactor{secrets: make([]string, 0, len(seen))}
+	for s := range seen {
+		r.secrets = append(r.secrets, s)
+	}
+	sort.Slice(r.secrets, func(i, j int) bool {
+		if len(r.secrets[i]) != len(r.secrets[j]) {
+			return len(r.secrets[i]) > len(r.secrets[j])
+		}
+		return r.secrets[i] < r.secrets[j]
+	})
+	return r
+}
+
+// redact returns the given message with the known secrets and the values of
+// the secret fields masked.
+func (r *secretRedactor) redact(msg string) string {
+	for _, s := range r.secrets {
+		msg = strings.Replace(msg, s, redactedSecret, -1)
+	}
+	return redactSecretFields(msg)
+}
+
+// redactError returns an error with the message of the given one redacted.
+func (r *secretRedactor) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if redacted := r.redact(msg); redacted != msg {
+		return errors.New(redacted)
+	}
+	return err
+}
+
+// redactSecretFields masks the values of the secret fields rendered in the
+// given message. It is used when the secrets are not known yet, e.g. for the
+// errors of the config parsing.
+func redactSecretFields(msg string) string {
+	return secretFieldPattern.ReplaceAllString(msg, "${1}"+redactedSecret)
+}
@@ -0,0 +1,116 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+type fatalErrorHost struct {
+	receivertest.MockHost
+	errs []error
+}
+
+func (h *fatalErrorHost) ReportFatalError(err error) {
+	h.errs = append(h.errs, err)
+}
+
+func TestReportFatalError_RedactsSecrets(t *testing.T) {
+	require.NoError(t, os.Setenv("PROM_TEST_API_KEY", "env-api-key"))
+	defer os.Unsetenv("PROM_TEST_API_KEY")
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: token_job
+    bearer_token: s3cr3t-token
+    static_configs:
+      - targets: ["localhost:9090"]
+  - job_name: basic_job
+    basic_auth:
+      username: user
+      password: s3cr3t-password
+    static_configs:
+      - targets: ["localhost:9091"]
+`)
+	require.NoError(t, err)
+	cfg := &Config{
+		PrometheusConfig: pCfg,
+		Jobs: map[string]JobSettings{
+			"basic_job": {Headers: map[string]string{"X-Api-Key": "${PROM_TEST_API_KEY}", "X-Tenant": "tenant1"}},
+		},
+	}
+	pr := newPrometheusReceiver(zap.NewNop(), cfg, new(exportertest.SinkMetricsExporter))
+
+	// The secrets of the prometheus config are plain strings when formatted.
+	host := &fatalErrorHost{}
+	pr.reportFatalError(host, fmt.Errorf("invalid http client config %+v for job %q with headers %v",
+		pCfg.ScrapeConfigs[0].HTTPClientConfig, "token_job", cfg.Jobs["basic_job"].Headers))
+	pr.reportFatalError(host, fmt.Errorf("invalid basic auth %+v", *pCfg.ScrapeConfigs[1].HTTPClientConfig.BasicAuth))
+	pr.reportFatalError(host, fmt.Errorf("request failed with header X-Api-Key: env-api-key"))
+	require.Len(t, host.errs, 3)
+
+	for _, err := range host.errs {
+		msg := err.Error()
+		for _, secret := range []string{"s3cr3t-token", "s3cr3t-password", "env-api-key"} {
+			assert.NotContains(t, msg, secret)
+		}
+		assert.Contains(t, msg, redactedSecret)
+	}
+	// The values that are not secret are kept.
+	assert.Contains(t, host.errs[0].Error(), "tenant1")
+	assert.Contains(t, host.errs[1].Error(), "user")
+}
+
+func TestSecretRedactor_KeepsErrorsWithoutSecrets(t *testing.T) {
+	r := newSecretRedactor(&Config{})
+	err := fmt.Errorf("unknown scrape job %q", "job1")
+	assert.Equal(t, err, r.redactError(err))
+	assert.Nil(t, r.redactError(nil))
+}
+
+func TestRedactSecretFields(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{msg: "bearer_token: abc123 is invalid", want: "bearer_token: <secret> is invalid"},
+		{msg: `password: "abc123"`, want: `password: "<secret>"`},
+		{msg: "map[Authorization:Bearer abc123]", want: "map[Authorization:Bearer <secret>]"},
+		{msg: "sent header Bearer abc123", want: "sent header Bearer <secret>"},
+		{
+			msg:  "at most one of bearer_token & bearer_token_file must be configured",
+			want: "at most one of bearer_token & bearer_token_file must be configured",
+		},
+		{msg: "password_file: /etc/secret", want: "password_file: /etc/secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			got := redactSecretFields(tt.msg)
+			assert.Equal(t, tt.want, got)
+			assert.False(t, strings.Contains(got, "abc123"))
+		})
+	}
+}
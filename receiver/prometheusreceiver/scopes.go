@@ -0,0 +1,41 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"github.com/open-telemetry/opentelemetry-service/internal/version"
+	"github.com/open-telemetry/opentelemetry-service/receiver/prometheusreceiver/internal"
+)
+
+// defaultScopeName is the name of the generic scope of the jobs without one.
+const defaultScopeName = "otelsvc/prometheusreceiver"
+
+// jobScopes returns the instrumentation scope of each scrape job, or nil if
+// the metrics are not attributed to a scope.
+func jobScopes(cfg *Config) map[string]internal.Scope {
+	if !cfg.EmitScope || cfg.PrometheusConfig == nil {
+		return nil
+	}
+	scopes := make(map[string]internal.Scope, len(cfg.PrometheusConfig.ScrapeConfigs))
+	for _, sc := range cfg.PrometheusConfig.ScrapeConfigs {
+		settings, _ := jobSettings(cfg, sc.JobName)
+		scope := internal.Scope{Name: settings.ScopeName, Version: settings.ScopeVersion}
+		if scope.Name == "" {
+			scope = internal.Scope{Name: defaultScopeName, Version: version.Version}
+		}
+		scopes[sc.JobName] = scope
+	}
+	return scopes
+}
@@ -0,0 +1,49 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"testing"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/internal/version"
+	"github.com/open-telemetry/opentelemetry-service/receiver/prometheusreceiver/internal"
+)
+
+func TestJobScopes(t *testing.T) {
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: Checkout
+  - job_name: batch
+`)
+	require.NoError(t, err)
+	cfg := &Config{
+		PrometheusConfig: pCfg,
+		Jobs: map[string]JobSettings{
+			"checkout": {ScopeName: "checkout/api", ScopeVersion: "1.2.0"},
+		},
+	}
+
+	assert.Nil(t, jobScopes(cfg))
+
+	cfg.EmitScope = true
+	assert.Equal(t, map[string]internal.Scope{
+		"Checkout": {Name: "checkout/api", Version: "1.2.0"},
+		"batch":    {Name: defaultScopeName, Version: version.Version},
+	}, jobScopes(cfg))
+}
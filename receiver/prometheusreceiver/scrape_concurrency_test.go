@@ -0,0 +1,108 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// concurrencyConsumer records the largest number of batches it consumed concurrently and the targets it got data
+// from.
+type concurrencyConsumer struct {
+	latency time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	targets  map[string]bool
+}
+
+func (cc *concurrencyConsumer) ConsumeMetricsData(ctx context.Context, md consumerdata.MetricsData) error {
+	cc.mu.Lock()
+	cc.inFlight++
+	if cc.inFlight > cc.peak {
+		cc.peak = cc.inFlight
+	}
+	cc.targets[md.Node.GetIdentifier().GetHostName()+":"+md.Node.GetAttributes()["port"]] = true
+	cc.mu.Unlock()
+
+	time.Sleep(cc.latency)
+
+	cc.mu.Lock()
+	cc.inFlight--
+	cc.mu.Unlock()
+	return nil
+}
+
+func (cc *concurrencyConsumer) stats() (int, int) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.peak, len(cc.targets)
+}
+
+func TestMaxConcurrentScrapes(t *testing.T) {
+	const numTargets = 20
+	hosts := make([]string, numTargets)
+	for i := range hosts {
+		target, host := newCountingTarget(t)
+		defer target.srv.Close()
+		hosts[i] = fmt.Sprintf("%q", host)
+	}
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: many
+    scrape_interval: 200ms
+    scrape_timeout: 200ms
+    static_configs:
+      - targets: [` + strings.Join(hosts, ", ") + `]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings:     configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/concurrency"},
+		PrometheusConfig:     pCfg,
+		MaxConcurrentScrapes: 3,
+	}
+	// The consumer is slow enough for the scrapes of the targets to overlap without the cap.
+	cc := &concurrencyConsumer{latency: 20 * time.Millisecond, targets: make(map[string]bool)}
+	precv := newPrometheusReceiver(logger, cfg, cc)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	defer precv.StopMetricsReception()
+
+	// Every target is still scraped, only fewer at a time.
+	require.Eventually(t, func() bool {
+		_, targets := cc.stats()
+		return targets == numTargets
+	}, 15*time.Second, 50*time.Millisecond)
+	time.Sleep(time.Second)
+
+	peak, _ := cc.stats()
+	assert.True(t, peak >= 1)
+	assert.True(t, peak <= cfg.MaxConcurrentScrapes, "%d scrapes were processed concurrently", peak)
+}
@@ -0,0 +1,250 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/scrape"
+)
+
+const (
+	authorizationHeader      = "Authorization"
+	proxyAuthorizationHeader = "Proxy-Authorization"
+	scrapeProxyUser          = "scrape"
+)
+
+// scrapeProxy is a local forward proxy the scrape requests of a job go through.
+// The prometheus scrape manager builds the HTTP clients of the jobs itself and
+// doesn't offer a way to wrap their transport, so jobs with custom headers or
+// with their scrape phases recorded scrape via this proxy.
+//
+// The job credentials and TLS settings are only used by the proxy transport,
+// nothing secret crosses the loopback connection. The proxy only serves the
+// requests authenticated by its own random password and sent to the targets
+// of its job.
+type scrapeProxy struct {
+	server   *http.Server
+	listener net.Listener
+	url      *url.URL
+}
+
+// startScrapeProxy starts a proxy of the given job on a local ephemeral port.
+// It adds the given headers, after expanding environment variables in their
+// values, except for a custom Authorization header when the job has its own
+// auth settings. The requests are sent with the given transport, and the given
+// scheme if not empty. isTarget reports whether a host is an active target of
+// a job.
+func startScrapeProxy(job string, headers map[string]string, jobAuth bool, scheme string,
+	transport http.RoundTripper, isTarget func(job, host string) bool) (*scrapeProxy, error) {
+	expanded := make(http.Header, len(headers))
+	for k, v := range headers {
+		expanded.Set(k, os.ExpandEnv(v))
+	}
+	if jobAuth {
+		expanded.Del(authorizationHeader)
+	}
+
+	password, err := newProxyPassword()
+	if err != nil {
+		return nil, err
+	}
+	proxyUser := url.UserPassword(scrapeProxyUser, password)
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte(proxyUser.String()))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	rp := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			if scheme != "" {
+				req.URL.Scheme = scheme
+			}
+			for k, v := range expanded {
+				req.Header[k] = v
+			}
+		},
+		Transport: transport,
+	}
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotAuth := req.Header.Get(proxyAuthorizationHeader)
+		if subtle.ConstantTimeCompare([]byte(gotAuth), []byte(wantAuth)) != 1 {
+			http.Error(rw, http.StatusText(http.StatusProxyAuthRequired), http.StatusProxyAuthRequired)
+			return
+		}
+		if !req.URL.IsAbs() || !isTarget(job, req.URL.Host) {
+			http.Error(rw, fmt.Sprintf("%q is not a target of job %q", req.URL.Host, job), http.StatusForbidden)
+			return
+		}
+		rp.ServeHTTP(rw, req)
+	})
+	sp := &scrapeProxy{
+		server:   &http.Server{Handler: handler},
+		listener: listener,
+		url:      &url.URL{Scheme: "http", User: proxyUser, Host: listener.Addr().String()},
+	}
+	go sp.server.Serve(listener)
+	return sp, nil
+}
+
+func (sp *scrapeProxy) stop() error {
+	return sp.server.Close()
+}
+
+func newProxyPassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// activeTargets returns a function reporting whether a host is the address of
+// an active target of a job of the scrape manager.
+func activeTargets(m *scrape.Manager) func(job, host string) bool {
+	return func(job, host string) bool {
+		for _, t := range m.TargetsActive()[job] {
+			if t.URL().Host == host {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// hasJobAuth reports whether the job sets the Authorization header itself.
+func hasJobAuth(hc config_util.HTTPClientConfig) bool {
+	return hc.BasicAuth != nil || hc.BearerToken != "" || hc.BearerTokenFile != ""
+}
+
+// validateJobSettings checks that the receiver job settings refer to existing
+// scrape jobs and can be applied to them.
+func validateJobSettings(cfg *Config) error {
+	known := make(map[string]bool, len(cfg.Jobs))
+	for _, sc := range cfg.PrometheusConfig.ScrapeConfigs {
+		settings, ok := jobSettings(cfg, sc.JobName)
+		if !ok {
+			continue
+		}
+		known[strings.ToLower(sc.JobName)] = true
+		if settings.ServerName != "" && sc.Scheme != "https" {
+			return fmt.Errorf("server_name is only supported for jobs using https, job %q uses %s", sc.JobName, sc.Scheme)
+		}
+	}
+	for job := range cfg.Jobs {
+		if !known[strings.ToLower(job)] {
+			return fmt.Errorf("settings defined for unknown scrape job %q", job)
+		}
+	}
+	return nil
+}
+
+// jobSettings returns the receiver settings of the given job. The config loader
+// lower cases map keys so job names are also matched in lower case.
+func jobSettings(cfg *Config, job string) (JobSettings, bool) {
+	if settings, ok := cfg.Jobs[job]; ok {
+		return settings, true
+	}
+	settings, ok := cfg.Jobs[strings.ToLower(job)]
+	return settings, ok
+}
+
+// applyJobSettings returns a copy of the prometheus config with the receiver
+// job settings applied, together with the scrape proxies it started. The
+// scrape phases are recorded using ctx, it must be created using
+// observability.ContextWithReceiverName. isTarget reports whether a host is an
+// active target of a job, the proxies reject the requests to other hosts.
+func applyJobSettings(ctx context.Context, cfg *Config, isTarget func(job, host string) bool) (*config.Config, []*scrapeProxy, error) {
+	promCfg := *cfg.PrometheusConfig
+	promCfg.ScrapeConfigs = make([]*config.ScrapeConfig, 0, len(cfg.PrometheusConfig.ScrapeConfigs))
+	var proxies []*scrapeProxy
+	for _, sc := range cfg.PrometheusConfig.ScrapeConfigs {
+		settings, _ := jobSettings(cfg, sc.JobName)
+		if settings.ServerName != "" && sc.HTTPClientConfig.TLSConfig.ServerName == "" {
+			scWithServerName := *sc
+			scWithServerName.HTTPClientConfig.TLSConfig.ServerName = settings.ServerName
+			sc = &scWithServerName
+		}
+		if len(settings.Headers) == 0 && !cfg.RecordScrapePhases {
+			promCfg.ScrapeConfigs = append(promCfg.ScrapeConfigs, sc)
+			continue
+		}
+
+		// The proxy connects to the targets with the client settings of the
+		// job, including its credentials, the job only connects to the proxy.
+		transport, err := config_util.NewRoundTripperFromConfig(sc.HTTPClientConfig, sc.JobName)
+		if err != nil {
+			stopScrapeProxies(proxies)
+			return nil, nil, err
+		}
+		if cfg.RecordScrapePhases {
+			transport = newPhaseTransport(ctx, sc.JobName, transport)
+		}
+		scCopy := *sc
+		scCopy.HTTPClientConfig = config_util.HTTPClientConfig{}
+		// The job scrapes the proxy in plain http for the TLS handshake to be
+		// made, and traced, by the proxy.
+		var scheme string
+		if sc.Scheme == "https" {
+			scheme = "https"
+			scCopy.Scheme = "http"
+		}
+
+		sp, err := startScrapeProxy(sc.JobName, settings.Headers, hasJobAuth(sc.HTTPClientConfig), scheme, transport,
+			isTarget)
+		if err != nil {
+			stopScrapeProxies(proxies)
+			return nil, nil, err
+		}
+		proxies = append(proxies, sp)
+
+		scCopy.HTTPClientConfig.ProxyURL = config_util.URL{URL: sp.url}
+		promCfg.ScrapeConfigs = append(promCfg.ScrapeConfigs, &scCopy)
+	}
+	return &promCfg, proxies, nil
+}
+
+// proxiedSchemes returns the scheme of the jobs scraping through a proxy in a
+// different scheme, keyed by job name.
+func proxiedSchemes(cfg *Config, promCfg *config.Config) map[string]string {
+	schemes := make(map[string]string)
+	for i, sc := range promCfg.ScrapeConfigs {
+		if scheme := cfg.PrometheusConfig.ScrapeConfigs[i].Scheme; sc.Scheme != scheme {
+			schemes[sc.JobName] = scheme
+		}
+	}
+	return schemes
+}
+
+func stopScrapeProxies(proxies []*scrapeProxy) {
+	for _, sp := range proxies {
+		_ = sp.stop()
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestScrapeWithCustomHeaders(t *testing.T) {
+	require.NoError(t, os.Setenv("PROM_TEST_TENANT", "tenant-secret"))
+	defer os.Unsetenv("PROM_TEST_TENANT")
+
+	reqHeaders := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case reqHeaders <- req.Header:
+		default:
+		}
+		_, _ = rw.Write([]byte("# TYPE test_gauge gauge\ntest_gauge 1\n"))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: tenant_job
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    basic_auth:
+      username: user
+      password: pass
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		PrometheusConfig: pCfg,
+		Jobs: map[string]JobSettings{
+			"tenant_job": {Headers: map[string]string{
+				"x-scope-orgid": "${PROM_TEST_TENANT}",
+				"Authorization": "must not override basic auth",
+			}},
+		},
+	}
+	require.NoError(t, validateJobSettings(cfg))
+
+	precv := newPrometheusReceiver(logger, cfg, new(exportertest.SinkMetricsExporter))
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	defer precv.StopMetricsReception()
+
+	select {
+	case h := <-reqHeaders:
+		assert.Equal(t, "tenant-secret", h.Get("X-Scope-OrgID"))
+		req := &http.Request{Header: h}
+		user, pass, ok := req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", pass)
+	case <-time.After(10 * time.Second):
+		t.Fatal("scrape request was not received")
+	}
+}
+
+func TestScrapeWithCustomHeadersHTTPSAndProxy(t *testing.T) {
+	reqHeaders := make(chan http.Header, 2)
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case reqHeaders <- req.Header:
+		default:
+		}
+		_, _ = rw.Write([]byte("# TYPE test_gauge gauge\ntest_gauge 1\n"))
+	})
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+	secureURL, err := url.Parse(secure.URL)
+	require.NoError(t, err)
+
+	// The proxy of the job serves the metrics itself, the target does not exist.
+	proxyHeaders := make(chan http.Header, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case proxyHeaders <- req.Header:
+		default:
+		}
+		_, _ = rw.Write([]byte("# TYPE test_gauge gauge\ntest_gauge 1\n"))
+	}))
+	defer proxy.Close()
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: secure
+    scheme: https
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    tls_config:
+      insecure_skip_verify: true
+    static_configs:
+      - targets: ["` + secureURL.Host + `"]
+  - job_name: proxied
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    proxy_url: "` + proxy.URL + `"
+    static_configs:
+      - targets: ["metrics.internal:9090"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		PrometheusConfig: pCfg,
+		Jobs: map[string]JobSettings{
+			"secure":  {Headers: map[string]string{"X-Scope-OrgID": "secure-tenant"}},
+			"proxied": {Headers: map[string]string{"X-Scope-OrgID": "proxied-tenant"}},
+		},
+	}
+	require.NoError(t, validateJobSettings(cfg))
+
+	precv := newPrometheusReceiver(logger, cfg, new(exportertest.SinkMetricsExporter))
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	defer precv.StopMetricsReception()
+
+	for _, tt := range []struct {
+		headers <-chan http.Header
+		want    string
+	}{
+		{reqHeaders, "secure-tenant"},
+		{proxyHeaders, "proxied-tenant"},
+	} {
+		select {
+		case h := <-tt.headers:
+			assert.Equal(t, tt.want, h.Get("X-Scope-OrgID"))
+		case <-time.After(10 * time.Second):
+			t.Fatalf("scrape request with %q was not received", tt.want)
+		}
+	}
+}
+
+func TestScrapeProxyRejectsOtherRequests(t *testing.T) {
+	reqHeaders := make(chan http.Header, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		reqHeaders <- req.Header
+	}))
+	defer target.Close()
+	targetURL, err := url.Parse(target.URL)
+	require.NoError(t, err)
+
+	isTarget := func(job, host string) bool {
+		return job == "tenant" && host == targetURL.Host
+	}
+	headers := map[string]string{"X-Scope-OrgID": "tenant", "Authorization": "Bearer custom"}
+	sp, err := startScrapeProxy("tenant", headers, true, "", http.DefaultTransport, isTarget)
+	require.NoError(t, err)
+	defer sp.stop()
+
+	unauthenticated := *sp.url
+	unauthenticated.User = nil
+	other := httptest.NewServer(http.NotFoundHandler())
+	defer other.Close()
+
+	tests := []struct {
+		name     string
+		proxyURL *url.URL
+		target   string
+		want     int
+	}{
+		{"unauthenticated", &unauthenticated, target.URL, http.StatusProxyAuthRequired},
+		{"other_host", sp.url, other.URL, http.StatusForbidden},
+		{"target", sp.url, target.URL, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(tt.proxyURL)}}
+			resp, err := client.Get(tt.target)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+
+	h := <-reqHeaders
+	assert.Equal(t, "tenant", h.Get("X-Scope-OrgID"))
+	// The job has its own auth settings, they take precedence.
+	assert.Equal(t, "", h.Get("Authorization"))
+	// The proxy credentials are not forwarded to the target.
+	assert.Equal(t, "", h.Get("Proxy-Authorization"))
+}
+
+func TestValidateJobSettings(t *testing.T) {
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: plain
+  - job_name: Secure
+    scheme: https
+  - job_name: proxied
+    proxy_url: http://proxy.internal:3128
+`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		jobs    map[string]JobSettings
+		wantErr bool
+	}{
+		{"no_settings", nil, false},
+		{"headers", map[string]JobSettings{"plain": {Headers: map[string]string{"a": "b"}}}, false},
+		{"lower_cased_job", map[string]JobSettings{"secure": {}}, false},
+		{"unknown_job", map[string]JobSettings{"other": {}}, true},
+		{"https_headers", map[string]JobSettings{"secure": {Headers: map[string]string{"a": "b"}}}, false},
+		{"proxy_url_headers", map[string]JobSettings{"proxied": {Headers: map[string]string{"a": "b"}}}, false},
+		{"https_server_name", map[string]JobSettings{"secure": {ServerName: "metrics.internal"}}, false},
+		{"http_server_name", map[string]JobSettings{"plain": {ServerName: "metrics.internal"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJobSettings(&Config{PrometheusConfig: pCfg, Jobs: tt.jobs})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/config"
+
+	sd_config "github.com/prometheus/prometheus/discovery/config"
+)
+
+// isJobDisabled reports whether the job is disabled with the "disabled" job
+// setting.
+func (pr *Preceiver) isJobDisabled(job string) bool {
+	return pr.disabledJobs[strings.ToLower(job)]
+}
+
+// enabledConfig returns a copy of the applied prometheus config without the
+// disabled jobs. It must be called with jobsMtx held.
+func (pr *Preceiver) enabledConfig() *config.Config {
+	return pr.effectiveConfig(pr.promCfg)
+}
+
+// effectiveConfig returns a copy of the given prometheus config without the
+// disabled jobs.
+func (pr *Preceiver) effectiveConfig(promCfg *config.Config) *config.Config {
+	enabledCfg := *promCfg
+	enabledCfg.ScrapeConfigs = make([]*config.ScrapeConfig, 0, len(promCfg.ScrapeConfigs))
+	for _, sc := range promCfg.ScrapeConfigs {
+		if pr.isJobDisabled(sc.JobName) {
+			continue
+		}
+		enabledCfg.ScrapeConfigs = append(enabledCfg.ScrapeConfigs, sc)
+	}
+	return &enabledCfg
+}
+
+func discoveryConfig(promCfg *config.Config) map[string]sd_config.ServiceDiscoveryConfig {
+	discoveryCfg := make(map[string]sd_config.ServiceDiscoveryConfig, len(promCfg.ScrapeConfigs))
+	for _, scrapeConfig := range promCfg.ScrapeConfigs {
+		discoveryCfg[scrapeConfig.JobName] = scrapeConfig.ServiceDiscoveryConfig
+	}
+	return discoveryCfg
+}
+
+// scrapeIntervals returns the scrape interval of each job, it bounds the time
+// given to the consumer to accept the data of a scrape.
+func scrapeIntervals(promCfg *config.Config) map[string]time.Duration {
+	intervals := make(map[string]time.Duration, len(promCfg.ScrapeConfigs))
+	for _, scrapeConfig := range promCfg.ScrapeConfigs {
+		intervals[scrapeConfig.JobName] = time.Duration(scrapeConfig.ScrapeInterval)
+	}
+	return intervals
+}
+
+// jobNames returns the names of the scrape jobs in the order of the config.
+func jobNames(promCfg *config.Config) []string {
+	names := make([]string, 0, len(promCfg.ScrapeConfigs))
+	for _, scrapeConfig := range promCfg.ScrapeConfigs {
+		names = append(names, scrapeConfig.JobName)
+	}
+	return names
+}
+
+// lenientJobs returns the jobs whose scrapes are parsed leniently. The jobs with a sample limit are parsed strictly,
+// a scrape exceeding the limit must be discarded and its failure cannot be told apart from invalid content.
+func lenientJobs(cfg *Config, promCfg *config.Config) map[string]bool {
+	jobs := make(map[string]bool)
+	if !cfg.LenientParsing {
+		return jobs
+	}
+	for _, scrapeConfig := range promCfg.ScrapeConfigs {
+		if scrapeConfig.SampleLimit == 0 {
+			jobs[scrapeConfig.JobName] = true
+		}
+	}
+	return jobs
+}
+
+// streamingJobs returns the batch size of the jobs whose scrapes are streamed. The jobs with a sample limit are not
+// streamed, a scrape exceeding the limit must be discarded as a whole.
+func streamingJobs(cfg *Config, promCfg *config.Config) map[string]int {
+	jobs := make(map[string]int)
+	if cfg.StreamingBatchSize <= 0 {
+		return jobs
+	}
+	for _, scrapeConfig := range promCfg.ScrapeConfigs {
+		if scrapeConfig.SampleLimit == 0 {
+			jobs[scrapeConfig.JobName] = cfg.StreamingBatchSize
+		}
+	}
+	return jobs
+}
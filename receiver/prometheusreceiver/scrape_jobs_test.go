@@ -0,0 +1,93 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestJobDisabledFromConfig(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	noisy, noisyHost := newCountingTarget(t)
+	defer noisy.srv.Close()
+	quiet, quietHost := newCountingTarget(t)
+	defer quiet.srv.Close()
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: noisy
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + noisyHost + `"]
+  - job_name: quiet
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + quietHost + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/disabled"},
+		PrometheusConfig: pCfg,
+		Jobs:             map[string]JobSettings{"noisy": {Disabled: true}},
+	}
+	precv := newPrometheusReceiver(logger, cfg, new(exportertest.SinkMetricsExporter))
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	defer precv.StopMetricsReception()
+
+	// The disabled job is never scraped, while the other jobs are.
+	require.Eventually(t, func() bool { return quiet.count() >= 3 }, 15*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int64(0), noisy.count())
+	require.NoError(t, observabilitytest.CheckValueViewReceiverScrapeJobDisabled(cfg.Name(), "noisy", 1))
+	require.NoError(t, observabilitytest.CheckValueViewReceiverScrapeJobDisabled(cfg.Name(), "quiet", 0))
+}
+
+type countingTarget struct {
+	srv      *httptest.Server
+	requests int64
+}
+
+func (ct *countingTarget) count() int64 {
+	return atomic.LoadInt64(&ct.requests)
+}
+
+func newCountingTarget(t *testing.T) (*countingTarget, string) {
+	ct := &countingTarget{}
+	ct.srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&ct.requests, 1)
+		_, _ = rw.Write([]byte("# TYPE test_gauge gauge\ntest_gauge 1\n"))
+	}))
+	u, err := url.Parse(ct.srv.URL)
+	require.NoError(t, err)
+	return ct, u.Host
+}
@@ -0,0 +1,143 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/observability"
+)
+
+// The phases of the scrape requests whose duration is recorded.
+const (
+	phaseConnect      = "connect"
+	phaseTLSHandshake = "tls_handshake"
+	phaseFirstByte    = "first_byte"
+	phaseBodyRead     = "body_read"
+)
+
+// phaseTransport is the transport of the scrape proxy of a job, it records the
+// duration of the phases of the scrape requests of the job.
+type phaseTransport struct {
+	// ctx is used to record the durations, it must be created using
+	// observability.ContextWithReceiverName.
+	ctx  context.Context
+	job  string
+	next http.RoundTripper
+}
+
+// newPhaseTransport returns the transport recording the phases of the scrape
+// requests of the job sent with next.
+func newPhaseTransport(ctx context.Context, job string, next http.RoundTripper) *phaseTransport {
+	return &phaseTransport{ctx: ctx, job: job, next: next}
+}
+
+func (pt *phaseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := &roundTripPhases{pt: pt, connectStarts: make(map[string]time.Time)}
+	resp, err := pt.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), rt.clientTrace())))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &phaseBody{ReadCloser: resp.Body, pt: pt, start: time.Now()}
+	return resp, nil
+}
+
+func (pt *phaseTransport) record(phase string, start time.Time) {
+	observability.RecordScrapePhaseForMetricsReceiver(pt.ctx, pt.job, phase, time.Since(start))
+}
+
+// roundTripPhases tracks the phases of a single request. The connect and TLS
+// handshake phases are only recorded when a new connection is opened.
+type roundTripPhases struct {
+	pt *phaseTransport
+
+	// The trace hooks can be called concurrently, e.g. when dialing several
+	// addresses of the target.
+	mtx           sync.Mutex
+	connectStarts map[string]time.Time
+	tlsStart      time.Time
+	wroteRequest  time.Time
+}
+
+func (rt *roundTripPhases) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			rt.mtx.Lock()
+			rt.connectStarts[network+"/"+addr] = time.Now()
+			rt.mtx.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			rt.mtx.Lock()
+			start, ok := rt.connectStarts[network+"/"+addr]
+			rt.mtx.Unlock()
+			if ok && err == nil {
+				rt.pt.record(phaseConnect, start)
+			}
+		},
+		TLSHandshakeStart: func() {
+			rt.mtx.Lock()
+			rt.tlsStart = time.Now()
+			rt.mtx.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			rt.mtx.Lock()
+			start := rt.tlsStart
+			rt.mtx.Unlock()
+			if !start.IsZero() && err == nil {
+				rt.pt.record(phaseTLSHandshake, start)
+			}
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err != nil {
+				return
+			}
+			rt.mtx.Lock()
+			rt.wroteRequest = time.Now()
+			rt.mtx.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			rt.mtx.Lock()
+			start := rt.wroteRequest
+			rt.mtx.Unlock()
+			if !start.IsZero() {
+				rt.pt.record(phaseFirstByte, start)
+			}
+		},
+	}
+}
+
+// phaseBody records the time taken to read a response body, from the headers
+// to the end of the body. Bodies not read to the end are not recorded.
+type phaseBody struct {
+	io.ReadCloser
+	pt    *phaseTransport
+	start time.Time
+	done  bool
+}
+
+func (pb *phaseBody) Read(p []byte) (int, error) {
+	n, err := pb.ReadCloser.Read(p)
+	if err == io.EOF && !pb.done {
+		pb.done = true
+		pb.pt.record(phaseBodyRead, pb.start)
+	}
+	return n, err
+}
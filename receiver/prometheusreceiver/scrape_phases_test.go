@@ -0,0 +1,155 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestRecordScrapePhases(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, exposition(2))
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+	plainURL, err := url.Parse(plain.URL)
+	require.NoError(t, err)
+	secureURL, err := url.Parse(secure.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: plain
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + plainURL.Host + `"]
+  - job_name: secure
+    scheme: https
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    tls_config:
+      insecure_skip_verify: true
+    static_configs:
+      - targets: ["` + secureURL.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings:   configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: typeStr},
+		PrometheusConfig:   pCfg,
+		RecordScrapePhases: true,
+	}
+	sink := new(exportertest.SinkMetricsExporter)
+	precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+
+	require.Eventually(t, func() bool {
+		for _, job := range []string{"plain", "secure"} {
+			for _, phase := range []string{phaseConnect, phaseFirstByte, phaseBodyRead} {
+				if scrapePhaseDistribution(cfg.Name(), job, phase) == nil {
+					return false
+				}
+			}
+		}
+		return scrapePhaseDistribution(cfg.Name(), "secure", phaseTLSHandshake) != nil
+	}, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, precv.StopMetricsReception())
+
+	// The plain target is scraped without TLS.
+	assert.Nil(t, scrapePhaseDistribution(cfg.Name(), "plain", phaseTLSHandshake))
+	// The secure target is scraped in https by the proxy, its metrics keep their scheme.
+	schemes := make(map[string]string)
+	for _, md := range sink.AllMetrics() {
+		schemes[md.Node.ServiceInfo.Name] = md.Node.Attributes["scheme"]
+	}
+	assert.Equal(t, map[string]string{"plain": "http", "secure": "https"}, schemes)
+}
+
+func TestRecordScrapePhasesDisabled(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, exposition(2))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: plain
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: typeStr},
+		PrometheusConfig: pCfg,
+	}
+	sink := new(exportertest.SinkMetricsExporter)
+	precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	require.Eventually(t, func() bool { return len(sink.AllMetrics()) > 0 }, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, precv.StopMetricsReception())
+
+	assert.Nil(t, scrapePhaseDistribution(cfg.Name(), "plain", phaseConnect))
+}
+
+// scrapePhaseDistribution returns the histogram of the phase for the job, nil if nothing was recorded.
+func scrapePhaseDistribution(receiverName, job, phase string) *view.DistributionData {
+	rows, err := view.RetrieveData(observability.ViewReceiverScrapePhase.Name)
+	if err != nil {
+		return nil
+	}
+	for _, row := range rows {
+		var receiverMatches, jobMatches, phaseMatches bool
+		for _, tag := range row.Tags {
+			receiverMatches = receiverMatches || (tag.Key == observability.TagKeyReceiver && tag.Value == receiverName)
+			jobMatches = jobMatches || (tag.Key == observability.TagKeyScrapeJob && tag.Value == job)
+			phaseMatches = phaseMatches || (tag.Key == observability.TagKeyScrapePhase && tag.Value == phase)
+		}
+		if receiverMatches && jobMatches && phaseMatches {
+			return row.Data.(*view.DistributionData)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// exposition returns a page exposing a gauge with the given number of series.
+func exposition(numSeries int) string {
+	var b strings.Builder
+	b.WriteString("# TYPE queue_length gauge\n")
+	for i := 0; i < numSeries; i++ {
+		fmt.Fprintf(&b, "queue_length{queue=\"queue-%d\"} %d\n", i, i)
+	}
+	return b.String()
+}
+
+func TestRecordScrapeSizes(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	small := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, exposition(2))
+	}))
+	defer small.Close()
+	large := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, exposition(500))
+	}))
+	defer large.Close()
+	smallURL, err := url.Parse(small.URL)
+	require.NoError(t, err)
+	largeURL, err := url.Parse(large.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: small
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + smallURL.Host + `"]
+  - job_name: large
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + largeURL.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings:  configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: typeStr},
+		PrometheusConfig:  pCfg,
+		RecordScrapeSizes: true,
+	}
+	precv := newPrometheusReceiver(zap.NewNop(), cfg, new(exportertest.SinkMetricsExporter))
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+
+	require.Eventually(t, func() bool {
+		return scrapeDistribution(observability.ViewReceiverScrapeSize, cfg.Name(), "small") != nil &&
+			scrapeDistribution(observability.ViewReceiverScrapeSize, cfg.Name(), "large") != nil &&
+			scrapeDistribution(observability.ViewReceiverScrapeSeries, cfg.Name(), "small") != nil &&
+			scrapeDistribution(observability.ViewReceiverScrapeSeries, cfg.Name(), "large") != nil
+	}, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, precv.StopMetricsReception())
+
+	// Each target exposes a fixed page, the histograms of each job hold a single value.
+	smallSize := scrapeDistribution(observability.ViewReceiverScrapeSize, cfg.Name(), "small")
+	largeSize := scrapeDistribution(observability.ViewReceiverScrapeSize, cfg.Name(), "large")
+	assert.Equal(t, smallSize.Min, smallSize.Max)
+	assert.Equal(t, largeSize.Min, largeSize.Max)
+	// The size is the one of the samples, labeled with the target labels, without the comments.
+	assert.True(t, smallSize.Min > 0)
+	assert.True(t, largeSize.Min > 100*smallSize.Min, "want the large target above 100 times the small one, got %v and %v",
+		largeSize.Min, smallSize.Min)
+
+	assert.Equal(t, 2.0, scrapeDistribution(observability.ViewReceiverScrapeSeries, cfg.Name(), "small").Max)
+	assert.Equal(t, 500.0, scrapeDistribution(observability.ViewReceiverScrapeSeries, cfg.Name(), "large").Max)
+}
+
+func TestRecordScrapeSizesDisabled(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, exposition(2))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: small
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: typeStr},
+		PrometheusConfig: pCfg,
+	}
+	sink := new(exportertest.SinkMetricsExporter)
+	precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	require.Eventually(t, func() bool { return len(sink.AllMetrics()) > 0 }, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, precv.StopMetricsReception())
+
+	assert.Nil(t, scrapeDistribution(observability.ViewReceiverScrapeSize, cfg.Name(), "small"))
+	assert.Nil(t, scrapeDistribution(observability.ViewReceiverScrapeSeries, cfg.Name(), "small"))
+}
+
+// scrapeDistribution returns the histogram of the view for the job, nil if nothing was recorded.
+func scrapeDistribution(v *view.View, receiverName, job string) *view.DistributionData {
+	rows, err := view.RetrieveData(v.Name)
+	if err != nil {
+		return nil
+	}
+	for _, row := range rows {
+		var receiverMatches, jobMatches bool
+		for _, tag := range row.Tags {
+			receiverMatches = receiverMatches || (tag.Key == observability.TagKeyReceiver && tag.Value == receiverName)
+			jobMatches = jobMatches || (tag.Key == observability.TagKeyScrapeJob && tag.Value == job)
+		}
+		if receiverMatches && jobMatches {
+			return row.Data.(*view.DistributionData)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// sniServerName is the name the certificate of the httptest TLS servers is
+// valid for, besides the loopback addresses.
+const sniServerName = "example.com"
+
+func TestScrapeWithServerName(t *testing.T) {
+	// The target is only served for its server name, like the targets behind
+	// a shared address routed by SNI.
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.TLS.ServerName != sniServerName {
+			rw.WriteHeader(http.StatusMisdirectedRequest)
+			return
+		}
+		fmt.Fprint(rw, exposition(1))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	caFile, err := ioutil.TempFile("", "sni-ca")
+	require.NoError(t, err)
+	defer os.Remove(caFile.Name())
+	require.NoError(t, pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	require.NoError(t, caFile.Close())
+
+	// The target is dialed by a name its certificate is not valid for.
+	target := "localhost:" + port
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: sni
+    scheme: https
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    tls_config:
+      ca_file: ` + caFile.Name() + `
+    static_configs:
+      - targets: ["` + target + `"]
+`)
+	require.NoError(t, err)
+
+	t.Run("missing_server_name", func(t *testing.T) {
+		cfg := &Config{
+			ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/nosni"},
+			PrometheusConfig: pCfg,
+		}
+		precv := newPrometheusReceiver(logger, cfg, new(exportertest.SinkMetricsExporter))
+		require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+		defer precv.StopMetricsReception()
+
+		key := "sni https://" + target + "/metrics"
+		require.Eventually(t, func() bool { return precv.TargetErrors()[key] != "" }, 10*time.Second, 50*time.Millisecond)
+		assert.Contains(t, precv.TargetErrors()[key], "x509: certificate is valid for")
+		assert.Contains(t, precv.TargetErrors()[key], sniHint)
+	})
+
+	t.Run("job_server_name", func(t *testing.T) {
+		cfg := &Config{
+			ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/sni"},
+			PrometheusConfig: pCfg,
+			Jobs:             map[string]JobSettings{"sni": {ServerName: sniServerName}},
+		}
+		require.NoError(t, validateJobSettings(cfg))
+		sink := new(exportertest.SinkMetricsExporter)
+		precv := newPrometheusReceiver(logger, cfg, sink)
+		require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+		defer precv.StopMetricsReception()
+
+		require.Eventually(t, func() bool {
+			for _, md := range sink.AllMetrics() {
+				for _, metric := range md.Metrics {
+					if metric.GetMetricDescriptor().GetName() == "queue_length" {
+						return true
+					}
+				}
+			}
+			return false
+		}, 10*time.Second, 50*time.Millisecond)
+		assert.Empty(t, precv.TargetErrors())
+	})
+}
+
+func TestApplyJobSettingsServerName(t *testing.T) {
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: default
+    scheme: https
+  - job_name: explicit
+    scheme: https
+    tls_config:
+      server_name: explicit.internal
+`)
+	require.NoError(t, err)
+	cfg := &Config{
+		PrometheusConfig: pCfg,
+		Jobs: map[string]JobSettings{
+			"default":  {ServerName: "default.internal"},
+			"explicit": {ServerName: "default.internal"},
+		},
+	}
+
+	promCfg, proxies, err := applyJobSettings(context.Background(), cfg, nil)
+	require.NoError(t, err)
+	assert.Empty(t, proxies)
+	assert.Equal(t, "default.internal", promCfg.ScrapeConfigs[0].HTTPClientConfig.TLSConfig.ServerName)
+	// The server_name of the job tls_config takes precedence.
+	assert.Equal(t, "explicit.internal", promCfg.ScrapeConfigs[1].HTTPClientConfig.TLSConfig.ServerName)
+	// The receiver config is not modified.
+	assert.Equal(t, "", pCfg.ScrapeConfigs[0].HTTPClientConfig.TLSConfig.ServerName)
+}
+
+func TestExplainScrapeError(t *testing.T) {
+	tests := []struct {
+		err      string
+		wantHint bool
+	}{
+		{`Get https://10.0.0.1:9100/metrics: remote error: tls: unrecognized name`, true},
+		{`Get https://10.0.0.1:9100/metrics: x509: certificate is valid for a.internal, not 10.0.0.1`, true},
+		{`server returned HTTP status 421 Misdirected Request`, true},
+		{`server returned HTTP status 500 Internal Server Error`, false},
+		{`Get https://10.0.0.1:9100/metrics: context deadline exceeded`, false},
+	}
+	for _, tt := range tests {
+		got := explainScrapeError(errors.New(tt.err))
+		if tt.wantHint {
+			assert.Equal(t, tt.err+" ("+sniHint+")", got)
+		} else {
+			assert.Equal(t, tt.err, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// scrapeSuccesses returns the values of the scrape_success gauges received for the given job.
+func scrapeSuccesses(sink *exportertest.SinkMetricsExporter, job string) []float64 {
+	var values []float64
+	for _, md := range sink.AllMetrics() {
+		if md.Node.GetServiceInfo().GetName() != job {
+			continue
+		}
+		for _, m := range md.Metrics {
+			if m.GetMetricDescriptor().GetName() != "scrape_success" {
+				continue
+			}
+			for _, ts := range m.Timeseries {
+				for _, p := range ts.Points {
+					values = append(values, p.GetDoubleValue())
+				}
+			}
+		}
+	}
+	return values
+}
+
+func TestEmitScrapeSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, exposition(5))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	// Both jobs scrape the same target, the limited one rejects its page for exceeding the sample limit.
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: healthy
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+  - job_name: limited
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    sample_limit: 2
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings:  configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: typeStr},
+		PrometheusConfig:  pCfg,
+		EmitScrapeSuccess: true,
+	}
+	sink := new(exportertest.SinkMetricsExporter)
+	precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	require.Eventually(t, func() bool {
+		return len(scrapeSuccesses(sink, "healthy")) > 0 && len(scrapeSuccesses(sink, "limited")) > 0
+	}, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, precv.StopMetricsReception())
+
+	// The target responded to both jobs, only the scrapes within the sample limit fully succeeded.
+	require.Contains(t, scrapeSuccesses(sink, "healthy"), 1.0)
+	for _, v := range scrapeSuccesses(sink, "limited") {
+		require.Equal(t, 0.0, v)
+	}
+}
+
+func TestEmitScrapeSuccessDisabled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, exposition(2))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: healthy
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: typeStr},
+		PrometheusConfig: pCfg,
+	}
+	sink := new(exportertest.SinkMetricsExporter)
+	precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	require.Eventually(t, func() bool { return len(sink.AllMetrics()) > 0 }, 10*time.Second, 50*time.Millisecond)
+	require.NoError(t, precv.StopMetricsReception())
+
+	require.Empty(t, scrapeSuccesses(sink, "healthy"))
+}
@@ -0,0 +1,100 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// targetErrorsPagePathPrefix is the prefix of the path of the zPage listing the
+// target errors, followed by the receiver name.
+const targetErrorsPagePathPrefix = "/debug/targeterrorz/"
+
+// TargetErrors returns the error of the last scrape of every target whose last
+// scrape failed, keyed by job name and target URL, e.g.
+// "node http://host:9100/metrics", since several jobs may scrape the same
+// address. The error of a target is cleared once it is scraped successfully.
+func (pr *Preceiver) TargetErrors() map[string]string {
+	pr.jobsMtx.Lock()
+	scrapeManager := pr.scrapeManager
+	pr.jobsMtx.Unlock()
+
+	targetErrors := make(map[string]string)
+	if scrapeManager == nil {
+		return targetErrors
+	}
+	for job, targets := range scrapeManager.TargetsActive() {
+		for _, target := range targets {
+			if err := target.LastError(); err != nil {
+				targetErrors[job+" "+target.URL().String()] = explainScrapeError(err)
+			}
+		}
+	}
+	return targetErrors
+}
+
+// sniErrors are the parts of the scrape errors caused by the target expecting
+// another TLS server name (SNI): a TLS alert, a certificate for other names, or
+// the status of the servers routing by SNI.
+var sniErrors = []string{
+	"tls: unrecognized name",
+	"x509: certificate is valid for",
+	"x509: certificate is not valid for any names",
+	"421 Misdirected Request",
+}
+
+// sniHint is added to the scrape errors caused by the target expecting another
+// TLS server name.
+const sniHint = "the target may require a specific TLS server name (SNI), " +
+	"set the server_name of the job tls_config or of the job settings"
+
+// explainScrapeError returns the message of the scrape error, with a hint for
+// the errors caused by a missing or wrong TLS server name.
+func explainScrapeError(err error) string {
+	msg := err.Error()
+	for _, sniErr := range sniErrors {
+		if strings.Contains(msg, sniErr) {
+			return msg + " (" + sniHint + ")"
+		}
+	}
+	return msg
+}
+
+func (pr *Preceiver) targetErrorsPagePath() string {
+	return targetErrorsPagePathPrefix + pr.receiverFullName
+}
+
+// targetErrorsPage is the zPage listing the target errors of a receiver.
+type targetErrorsPage struct {
+	pr *Preceiver
+}
+
+func (tep targetErrorsPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	targetErrors := tep.pr.TargetErrors()
+	targets := make([]string, 0, len(targetErrors))
+	for target := range targetErrors {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "Targets of receiver %q failing to be scraped: %d\n\n", tep.pr.receiverFullName, len(targets))
+	for _, target := range targets {
+		fmt.Fprintf(w, "%s\t%s\n", target, targetErrors[target])
+	}
+}
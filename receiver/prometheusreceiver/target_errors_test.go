@@ -0,0 +1,87 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestTargetErrors(t *testing.T) {
+	var healthy int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = rw.Write([]byte("# TYPE test_gauge gauge\ntest_gauge 1\n"))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: flaky
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+  - job_name: flaky_other
+    metrics_path: /other
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/errors"},
+		PrometheusConfig: pCfg,
+	}
+	precv := newPrometheusReceiver(logger, cfg, new(exportertest.SinkMetricsExporter))
+	assert.Empty(t, precv.TargetErrors())
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	defer precv.StopMetricsReception()
+
+	// The targets of both jobs have the same address, they are listed apart.
+	target := "flaky " + srv.URL + "/metrics"
+	otherTarget := "flaky_other " + srv.URL + "/other"
+	require.Eventually(t, func() bool { return len(precv.TargetErrors()) == 2 }, 10*time.Second, 50*time.Millisecond)
+	assert.Contains(t, precv.TargetErrors()[target], "500")
+	assert.Contains(t, precv.TargetErrors()[otherTarget], "500")
+
+	rec := httptest.NewRecorder()
+	targetErrorsPage{pr: precv}.ServeHTTP(rec, httptest.NewRequest("GET", precv.targetErrorsPagePath(), nil))
+	assert.Contains(t, rec.Body.String(), target)
+	assert.Contains(t, rec.Body.String(), otherTarget)
+
+	// The error is cleared once the target recovers.
+	atomic.StoreInt32(&healthy, 1)
+	require.Eventually(t, func() bool { return len(precv.TargetErrors()) == 0 }, 10*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,99 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestTargetLabelsFile(t *testing.T) {
+	target, host := newCountingTarget(t)
+	defer target.srv.Close()
+
+	dir, err := ioutil.TempDir("", "target_labels")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "targets.json")
+	writeTargetLabels := func(team string) {
+		// Write the file atomically, as agents do, for the receiver not to read it half written.
+		tmp := path + ".tmp"
+		require.NoError(t, ioutil.WriteFile(tmp, []byte(`{"`+host+`": {"team": "`+team+`"}}`), 0600))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	writeTargetLabels("payments")
+
+	pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: sidecar
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + host + `"]
+`)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		ReceiverSettings:            configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/sidecar"},
+		PrometheusConfig:            pCfg,
+		TargetLabelsFile:            path,
+		TargetLabelsRefreshInterval: 50 * time.Millisecond,
+	}
+	sink := new(exportertest.SinkMetricsExporter)
+	precv := newPrometheusReceiver(logger, cfg, sink)
+	require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+	defer precv.StopMetricsReception()
+
+	// lastTeam returns the team label of the gauge of the last scrape, empty if none.
+	lastTeam := func() string {
+		all := sink.AllMetrics()
+		if len(all) == 0 {
+			return ""
+		}
+		for _, metric := range all[len(all)-1].Metrics {
+			if metric.GetMetricDescriptor().GetName() != "test_gauge" {
+				continue
+			}
+			for i, key := range metric.MetricDescriptor.LabelKeys {
+				if key.Key == "team" && len(metric.Timeseries) > 0 {
+					return metric.Timeseries[0].LabelValues[i].Value
+				}
+			}
+		}
+		return ""
+	}
+
+	require.Eventually(t, func() bool { return lastTeam() == "payments" }, 10*time.Second, 50*time.Millisecond)
+
+	writeTargetLabels("checkout")
+	require.Eventually(t, func() bool { return lastTeam() == "checkout" }, 10*time.Second, 50*time.Millisecond)
+
+	// Without the file the target has no extra labels, the scrapes go on.
+	require.NoError(t, os.Remove(path))
+	require.Eventually(t, func() bool {
+		all := sink.AllMetrics()
+		return len(all) > 0 && lastTeam() == "" && len(all[len(all)-1].Metrics) > 0
+	}, 10*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,91 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusreceiver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	promcfg "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+// explicitTimestamp is the timestamp exposed by the target, skewed far in the past.
+var explicitTimestamp = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestTimestampPolicy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("# TYPE requests_total counter\n" +
+			"requests_total{path=\"/\"} 10 1546300800000\n"))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		policy       string
+		wantExplicit bool
+	}{
+		{policy: "honor", wantExplicit: true},
+		{policy: "override", wantExplicit: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			pCfg, err := promcfg.Load(`
+scrape_configs:
+  - job_name: timestamps
+    scrape_interval: 100ms
+    scrape_timeout: 100ms
+    static_configs:
+      - targets: ["` + u.Host + `"]
+`)
+			require.NoError(t, err)
+
+			cfg := &Config{
+				ReceiverSettings: configmodels.ReceiverSettings{TypeVal: typeStr, NameVal: "prometheus/" + tt.policy},
+				PrometheusConfig: pCfg,
+				TimestampPolicy:  tt.policy,
+			}
+			sink := new(exportertest.SinkMetricsExporter)
+			precv := newPrometheusReceiver(zap.NewNop(), cfg, sink)
+			start := time.Now()
+			require.NoError(t, precv.StartMetricsReception(receivertest.NewMockHost()))
+			// The counter is only sent from the second scrape, once its start time is known.
+			require.Eventually(t, func() bool { return len(sink.AllMetrics()) > 0 }, 10*time.Second, 50*time.Millisecond)
+			require.NoError(t, precv.StopMetricsReception())
+
+			md := sink.AllMetrics()[0]
+			require.NotEmpty(t, md.Metrics)
+			metric := md.Metrics[0]
+			assert.Equal(t, "requests_total", metric.MetricDescriptor.Name)
+			require.NotEmpty(t, metric.Timeseries)
+			ts := time.Unix(metric.Timeseries[0].Points[0].Timestamp.Seconds, 0)
+			if tt.wantExplicit {
+				assert.Equal(t, explicitTimestamp, ts.UTC())
+			} else {
+				assert.False(t, ts.Before(start.Truncate(time.Second)), "expecting the collector time, got %v", ts)
+			}
+		})
+	}
+}
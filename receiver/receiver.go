@@ -72,3 +72,19 @@ type MetricsReceiver interface {
 	// giving it a chance to perform any necessary clean-up.
 	StopMetricsReception() error
 }
+
+// A DataReceiver receives traces and metrics in the same stream, e.g. from an
+// agent multiplexing both on a single connection. It feeds a consumer.DataConsumer
+// with data.
+type DataReceiver interface {
+	// DataSource returns the name of the data source.
+	DataSource() string
+
+	// StartDataReception tells the receiver to start its processing.
+	// By convention the consumer of the data received is set at creation time.
+	StartDataReception(host Host) error
+
+	// StopDataReception tells the receiver that should stop reception,
+	// giving it a chance to perform any necessary clean-up.
+	StopDataReception() error
+}
@@ -15,6 +15,9 @@
 package receiver
 
 import (
+	"crypto/tls"
+	"fmt"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
@@ -29,6 +32,11 @@ type SecureReceiverSettings struct {
 	TLSCredentials *TLSCredentials `mapstructure:"tls_credentials, omitempty"`
 }
 
+// Validate checks the TLS settings.
+func (s *SecureReceiverSettings) Validate() error {
+	return s.TLSCredentials.Validate()
+}
+
 // TLSCredentials contains path information for a certificate and key to be used for TLS
 type TLSCredentials struct {
 	// CertFile is the file path containing the TLS certificate.
@@ -36,6 +44,97 @@ type TLSCredentials struct {
 
 	// KeyFile is the file path containing the TLS key.
 	KeyFile string `mapstructure:"key_file"`
+
+	// MinVersion is the minimum TLS version accepted by the server, one of
+	// "1.0", "1.1", "1.2" or "1.3". The default is the minimum version
+	// supported by the Go TLS library.
+	MinVersion string `mapstructure:"min_version"`
+
+	// CipherSuites is the list of cipher suites accepted by the server, using
+	// their IANA names, e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". The
+	// default is the list of cipher suites of the Go TLS library. TLS 1.3
+	// cipher suites are not configurable.
+	CipherSuites []string `mapstructure:"cipher_suites"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var tlsCipherSuites = map[string]uint16{
+	"TLS_RSA_WITH_RC4_128_SHA":                tls.TLS_RSA_WITH_RC4_128_SHA,
+	"TLS_RSA_WITH_3DES_EDE_CBC_SHA":           tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA256":         tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_RC4_128_SHA":        tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_RC4_128_SHA":          tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA":     tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305":    tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305":  tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+}
+
+// Validate checks that the TLS version and cipher suites are known, without
+// loading the certificate and key. A nil TLSCredentials is valid.
+func (tlsCreds *TLSCredentials) Validate() error {
+	_, _, err := tlsCreds.versionAndCipherSuites()
+	return err
+}
+
+func (tlsCreds *TLSCredentials) versionAndCipherSuites() (minVersion uint16, cipherSuites []uint16, err error) {
+	if tlsCreds == nil {
+		return 0, nil, nil
+	}
+	if tlsCreds.MinVersion != "" {
+		var ok bool
+		if minVersion, ok = tlsVersions[tlsCreds.MinVersion]; !ok {
+			return 0, nil, fmt.Errorf("invalid TLS min_version %q", tlsCreds.MinVersion)
+		}
+	}
+	for _, name := range tlsCreds.CipherSuites {
+		id, ok := tlsCipherSuites[name]
+		if !ok {
+			return 0, nil, fmt.Errorf("invalid TLS cipher suite %q", name)
+		}
+		cipherSuites = append(cipherSuites, id)
+	}
+	return minVersion, cipherSuites, nil
+}
+
+// ToTLSConfig creates a server tls.Config from TLSCredentials. If TLSCredentials is nil, returns nil.
+func (tlsCreds *TLSCredentials) ToTLSConfig() (*tls.Config, error) {
+	if tlsCreds == nil {
+		return nil, nil
+	}
+
+	minVersion, cipherSuites, err := tlsCreds.versionAndCipherSuites()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(tlsCreds.CertFile, tlsCreds.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+	}, nil
 }
 
 // ToGrpcServerOption creates a gRPC ServerOption from TLSCredentials. If TLSCredentials is nil, returns empty option.
@@ -44,10 +143,10 @@ func (tlsCreds *TLSCredentials) ToGrpcServerOption() (opt grpc.ServerOption, err
 		return grpc.EmptyServerOption{}, nil
 	}
 
-	transportCreds, err := credentials.NewServerTLSFromFile(tlsCreds.CertFile, tlsCreds.KeyFile)
+	tlsCfg, err := tlsCreds.ToTLSConfig()
 	if err != nil {
 		return nil, err
 	}
-	gRPCCredsOpt := grpc.Creds(transportCreds)
+	gRPCCredsOpt := grpc.Creds(credentials.NewTLS(tlsCfg))
 	return gRPCCredsOpt, nil
 }
@@ -15,11 +15,13 @@
 package receiver
 
 import (
+	"crypto/tls"
 	"os"
 	"path"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/sys/unix"
 )
 
@@ -66,3 +68,76 @@ func TestToGrpcServerOption(t *testing.T) {
 		assert.Equal(t, c.err, err)
 	}
 }
+
+func TestTLSCredentialsValidate(t *testing.T) {
+	var nilCreds *TLSCredentials
+	assert.NoError(t, nilCreds.Validate())
+
+	valid := &TLSCredentials{
+		MinVersion:   "1.2",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+	}
+	assert.NoError(t, valid.Validate())
+
+	assert.Error(t, (&TLSCredentials{MinVersion: "1.4"}).Validate())
+	assert.Error(t, (&TLSCredentials{CipherSuites: []string{"TLS_UNKNOWN"}}).Validate())
+}
+
+func TestToTLSConfig(t *testing.T) {
+	tlsCreds := &TLSCredentials{
+		CertFile:     path.Join(".", "jaegerreceiver/testdata", "certificate.pem"),
+		KeyFile:      path.Join(".", "jaegerreceiver/testdata", "key.pem"),
+		MinVersion:   "1.2",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+	cfg, err := tlsCreds.ToTLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, cfg.CipherSuites)
+	assert.Len(t, cfg.Certificates, 1)
+
+	var nilCreds *TLSCredentials
+	cfg, err = nilCreds.ToTLSConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+}
+
+func TestTLSMinVersionRejectsOlderHandshake(t *testing.T) {
+	tlsCreds := &TLSCredentials{
+		CertFile:   path.Join(".", "jaegerreceiver/testdata", "certificate.pem"),
+		KeyFile:    path.Join(".", "jaegerreceiver/testdata", "key.pem"),
+		MinVersion: "1.2",
+	}
+	serverCfg, err := tlsCreds.ToTLSConfig()
+	require.NoError(t, err)
+
+	ln, err := tls.Listen("tcp", "localhost:0", serverCfg)
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			// Complete the handshake, if possible, before closing.
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	dial := func(maxVersion uint16) error {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS10,
+			MaxVersion:         maxVersion,
+		})
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	assert.Error(t, dial(tls.VersionTLS11), "handshake below the min version must be rejected")
+	assert.NoError(t, dial(tls.VersionTLS12))
+}
@@ -0,0 +1,152 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selfmetricsreceiver
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/internal"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/receiver"
+)
+
+// serviceName is the service name of the node of the emitted metrics.
+const serviceName = "otelsvc"
+
+// Receiver is the type used to periodically emit the process metrics of the collector.
+type Receiver struct {
+	mu sync.Mutex
+
+	name         string
+	interval     time.Duration
+	logger       *zap.Logger
+	nextConsumer consumer.MetricsConsumer
+	process      *processMetrics
+	node         *commonpb.Node
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+var _ receiver.MetricsReceiver = (*Receiver)(nil)
+
+// New creates a new selfmetricsreceiver.Receiver reference.
+func New(logger *zap.Logger, name string, interval time.Duration, nextConsumer consumer.MetricsConsumer) (*Receiver, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	process := newProcessMetrics()
+	hostName, _ := os.Hostname()
+	return &Receiver{
+		name:         name,
+		interval:     interval,
+		logger:       logger,
+		nextConsumer: nextConsumer,
+		process:      process,
+		node: &commonpb.Node{
+			Identifier: &commonpb.ProcessIdentifier{
+				HostName:       hostName,
+				Pid:            uint32(process.pid),
+				StartTimestamp: internal.TimeToTimestamp(process.startTime),
+			},
+			ServiceInfo: &commonpb.ServiceInfo{Name: serviceName},
+		},
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}, nil
+}
+
+const metricsSource string = "SelfMetrics"
+
+// MetricsSource returns the name of the metrics data source.
+func (sr *Receiver) MetricsSource() string {
+	return metricsSource
+}
+
+// StartMetricsReception emits the process metrics once and then at every collection interval.
+func (sr *Receiver) StartMetricsReception(host receiver.Host) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	var err = oterr.ErrAlreadyStarted
+	sr.startOnce.Do(func() {
+		go sr.run()
+		err = nil
+	})
+	return err
+}
+
+// StopMetricsReception stops the collection of the process metrics.
+func (sr *Receiver) StopMetricsReception() error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	var err = oterr.ErrAlreadyStopped
+	sr.stopOnce.Do(func() {
+		close(sr.stopCh)
+		// Wait for the collection goroutine if it was started.
+		started := true
+		sr.startOnce.Do(func() { started = false })
+		if started {
+			<-sr.doneCh
+		}
+		err = nil
+	})
+	return err
+}
+
+func (sr *Receiver) run() {
+	defer close(sr.doneCh)
+
+	ticker := time.NewTicker(sr.interval)
+	defer ticker.Stop()
+
+	sr.emit()
+	for {
+		select {
+		case <-ticker.C:
+			sr.emit()
+		case <-sr.stopCh:
+			return
+		}
+	}
+}
+
+func (sr *Receiver) emit() {
+	ctx := observability.ContextWithReceiverName(context.Background(), sr.name)
+	md := consumerdata.MetricsData{
+		Node:    sr.node,
+		Metrics: sr.process.collect(),
+	}
+	err := sr.nextConsumer.ConsumeMetricsData(ctx, md)
+	if err != nil {
+		sr.logger.Warn("Failed to send the process metrics", zap.Error(err))
+		observability.RecordMetricsForMetricsReceiver(ctx, len(md.Metrics), len(md.Metrics))
+		return
+	}
+	observability.RecordMetricsForMetricsReceiver(ctx, len(md.Metrics), 0)
+}
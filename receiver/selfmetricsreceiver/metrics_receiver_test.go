@@ -0,0 +1,89 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selfmetricsreceiver
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestNewNilNextConsumer(t *testing.T) {
+	sr, err := New(zap.NewNop(), typeStr, time.Second, nil)
+	assert.Equal(t, oterr.ErrNilNextConsumer, err)
+	assert.Nil(t, sr)
+}
+
+func TestEmitsProcessMetrics(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	sink := new(exportertest.SinkMetricsExporter)
+	sr, err := New(zap.NewNop(), typeStr, 10*time.Millisecond, sink)
+	require.NoError(t, err)
+
+	require.NoError(t, sr.StartMetricsReception(receivertest.NewMockHost()))
+	require.Eventually(t, func() bool { return len(sink.AllMetrics()) >= 2 }, 5*time.Second, 5*time.Millisecond)
+	require.NoError(t, sr.StopMetricsReception())
+	assert.Equal(t, oterr.ErrAlreadyStopped, sr.StopMetricsReception())
+
+	md := sink.AllMetrics()[0]
+	assert.Equal(t, serviceName, md.Node.ServiceInfo.Name)
+	metrics := make(map[string]*metricspb.Metric)
+	for _, metric := range md.Metrics {
+		metrics[metric.MetricDescriptor.Name] = metric
+	}
+
+	goroutines := metrics[metricGoroutines.Name]
+	require.NotNil(t, goroutines)
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, goroutines.MetricDescriptor.Type)
+	assert.True(t, goroutines.Timeseries[0].Points[0].GetInt64Value() > 0)
+
+	heap := metrics[metricHeapAlloc.Name]
+	require.NotNil(t, heap)
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, heap.MetricDescriptor.Type)
+	assert.True(t, heap.Timeseries[0].Points[0].GetInt64Value() > 0)
+
+	gcCount := metrics[metricGCCount.Name]
+	require.NotNil(t, gcCount)
+	assert.NotNil(t, gcCount.Timeseries[0].StartTimestamp)
+
+	if runtime.GOOS == "linux" {
+		rss := metrics[metricResidentMemory.Name]
+		require.NotNil(t, rss)
+		assert.True(t, rss.Timeseries[0].Points[0].GetInt64Value() > 0)
+		require.NotNil(t, metrics[metricCPUSeconds.Name])
+	}
+
+	assert.NoError(t, observabilitytest.CheckValueViewReceiverReceivedTimeSeries(typeStr,
+		len(sink.AllMetrics())*len(md.Metrics)))
+}
+
+func TestStopWithoutStart(t *testing.T) {
+	sr, err := New(zap.NewNop(), typeStr, time.Second, exportertest.NewNopMetricsExporter())
+	require.NoError(t, err)
+	assert.NoError(t, sr.StopMetricsReception())
+	assert.Equal(t, oterr.ErrAlreadyStarted, sr.StartMetricsReception(receivertest.NewMockHost()))
+}
@@ -0,0 +1,144 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selfmetricsreceiver
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/prometheus/procfs"
+
+	"github.com/open-telemetry/opentelemetry-service/internal"
+)
+
+// Process metric descriptors, named after the telemetry metrics of the collector.
+
+var metricCPUSeconds = &metricspb.MetricDescriptor{
+	Name:        "otelsvc/process/cpu_seconds",
+	Description: "Total user and system CPU time spent by the collector",
+	Unit:        "s",
+	Type:        metricspb.MetricDescriptor_CUMULATIVE_DOUBLE,
+}
+
+var metricResidentMemory = &metricspb.MetricDescriptor{
+	Name:        "otelsvc/process/resident_memory",
+	Description: "Resident memory size of the collector",
+	Unit:        "By",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+}
+
+var metricGoroutines = &metricspb.MetricDescriptor{
+	Name:        "otelsvc/process/goroutines",
+	Description: "Number of goroutines of the collector",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+}
+
+var metricHeapAlloc = &metricspb.MetricDescriptor{
+	Name:        "otelsvc/process/heap_alloc",
+	Description: "Number of bytes of allocated heap objects",
+	Unit:        "By",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+}
+
+var metricSysMemory = &metricspb.MetricDescriptor{
+	Name:        "otelsvc/process/sys_memory",
+	Description: "Number of bytes obtained from the OS by the Go runtime",
+	Unit:        "By",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+}
+
+var metricGCCount = &metricspb.MetricDescriptor{
+	Name:        "otelsvc/process/gc_count",
+	Description: "Number of completed GC cycles",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_CUMULATIVE_INT64,
+}
+
+var metricGCPause = &metricspb.MetricDescriptor{
+	Name:        "otelsvc/process/gc_pause",
+	Description: "Total time the collector was paused by GC cycles",
+	Unit:        "s",
+	Type:        metricspb.MetricDescriptor_CUMULATIVE_DOUBLE,
+}
+
+// processMetrics collects the process metrics of the collector. The metrics read
+// from procfs are skipped where it is not available, e.g. on other platforms than linux.
+type processMetrics struct {
+	startTime time.Time
+	pid       int
+}
+
+func newProcessMetrics() *processMetrics {
+	return &processMetrics{
+		startTime: time.Now(),
+		pid:       os.Getpid(),
+	}
+}
+
+func (pm *processMetrics) collect() []*metricspb.Metric {
+	now := time.Now()
+	ms := &runtime.MemStats{}
+	runtime.ReadMemStats(ms)
+
+	metrics := []*metricspb.Metric{
+		pm.int64Metric(metricGoroutines, int64(runtime.NumGoroutine()), now),
+		pm.int64Metric(metricHeapAlloc, int64(ms.HeapAlloc), now),
+		pm.int64Metric(metricSysMemory, int64(ms.Sys), now),
+		pm.int64Metric(metricGCCount, int64(ms.NumGC), now),
+		pm.doubleMetric(metricGCPause, time.Duration(ms.PauseTotalNs).Seconds(), now),
+	}
+
+	proc, err := procfs.NewProc(pm.pid)
+	if err != nil {
+		return metrics
+	}
+	procStat, err := proc.NewStat()
+	if err != nil {
+		return metrics
+	}
+	return append(metrics,
+		pm.doubleMetric(metricCPUSeconds, procStat.CPUTime(), now),
+		pm.int64Metric(metricResidentMemory, int64(procStat.ResidentMemory()), now),
+	)
+}
+
+func (pm *processMetrics) int64Metric(descriptor *metricspb.MetricDescriptor, val int64, now time.Time) *metricspb.Metric {
+	return pm.metric(descriptor, &metricspb.Point{
+		Timestamp: internal.TimeToTimestamp(now),
+		Value:     &metricspb.Point_Int64Value{Int64Value: val},
+	})
+}
+
+func (pm *processMetrics) doubleMetric(descriptor *metricspb.MetricDescriptor, val float64, now time.Time) *metricspb.Metric {
+	return pm.metric(descriptor, &metricspb.Point{
+		Timestamp: internal.TimeToTimestamp(now),
+		Value:     &metricspb.Point_DoubleValue{DoubleValue: val},
+	})
+}
+
+func (pm *processMetrics) metric(descriptor *metricspb.MetricDescriptor, point *metricspb.Point) *metricspb.Metric {
+	ts := &metricspb.TimeSeries{Points: []*metricspb.Point{point}}
+	switch descriptor.Type {
+	case metricspb.MetricDescriptor_CUMULATIVE_INT64, metricspb.MetricDescriptor_CUMULATIVE_DOUBLE:
+		ts.StartTimestamp = internal.TimeToTimestamp(pm.startTime)
+	}
+	return &metricspb.Metric{
+		MetricDescriptor: descriptor,
+		Timeseries:       []*metricspb.TimeSeries{ts},
+	}
+}
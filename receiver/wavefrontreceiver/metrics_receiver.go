@@ -0,0 +1,236 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wavefrontreceiver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/observability"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/receiver"
+)
+
+// maxBatchSize is the maximum number of metrics sent in a single call to the
+// next consumer. Lines are otherwise batched until the connection has no more
+// data immediately available.
+const maxBatchSize = 1000
+
+// Receiver is the type used to handle metrics sent in the Wavefront data format.
+type Receiver struct {
+	// mu protects the fields of this struct
+	mu sync.Mutex
+
+	name         string
+	addr         string
+	logger       *zap.Logger
+	nextConsumer consumer.MetricsConsumer
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	listener  net.Listener
+
+	// connsMu protects conns, it is separate from mu so connections can
+	// complete while the receiver is being stopped. done is closed once the
+	// receiver is being stopped.
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	wg      sync.WaitGroup
+	done    chan struct{}
+}
+
+var _ receiver.MetricsReceiver = (*Receiver)(nil)
+
+// New creates a new wavefrontreceiver.Receiver reference.
+func New(logger *zap.Logger, name, address string, nextConsumer consumer.MetricsConsumer) (*Receiver, error) {
+	if nextConsumer == nil {
+		return nil, oterr.ErrNilNextConsumer
+	}
+
+	return &Receiver{
+		name:         name,
+		addr:         address,
+		logger:       logger,
+		nextConsumer: nextConsumer,
+		conns:        make(map[net.Conn]struct{}),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+const metricsSource string = "Wavefront"
+
+// MetricsSource returns the name of the metrics data source.
+func (wr *Receiver) MetricsSource() string {
+	return metricsSource
+}
+
+// StartMetricsReception starts listening for TCP connections and makes the receiver start its processing.
+func (wr *Receiver) StartMetricsReception(host receiver.Host) error {
+	if host == nil {
+		return errors.New("nil host")
+	}
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	var err = oterr.ErrAlreadyStarted
+
+	wr.startOnce.Do(func() {
+		ln, lerr := net.Listen("tcp", wr.addr)
+		if lerr != nil {
+			err = lerr
+			return
+		}
+		wr.listener = ln
+
+		wr.wg.Add(1)
+		go wr.serve(host, ln)
+
+		err = nil
+	})
+
+	return err
+}
+
+// StopMetricsReception tells the receiver that should stop reception,
+// giving it a chance to perform any necessary clean-up and closing the
+// open connections.
+func (wr *Receiver) StopMetricsReception() error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	var err = oterr.ErrAlreadyStopped
+	wr.stopOnce.Do(func() {
+		err = nil
+		close(wr.done)
+		if wr.listener == nil {
+			return
+		}
+		err = wr.listener.Close()
+
+		wr.connsMu.Lock()
+		for conn := range wr.conns {
+			_ = conn.Close()
+		}
+		wr.connsMu.Unlock()
+
+		wr.wg.Wait()
+	})
+	return err
+}
+
+func (wr *Receiver) serve(host receiver.Host, ln net.Listener) {
+	defer wr.wg.Done()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			if !wr.stopping() {
+				host.ReportFatalError(err)
+			}
+			return
+		}
+
+		wr.connsMu.Lock()
+		if wr.stopping() {
+			wr.connsMu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		wr.conns[conn] = struct{}{}
+		wr.connsMu.Unlock()
+
+		wr.wg.Add(1)
+		go wr.handleConn(conn)
+	}
+}
+
+// stopping returns true once StopMetricsReception was called.
+func (wr *Receiver) stopping() bool {
+	select {
+	case <-wr.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleConn reads the lines sent on the connection, converts them and sends
+// them along to the nextConsumer. Malformed lines are counted as dropped and
+// skipped, they do not close the connection.
+func (wr *Receiver) handleConn(conn net.Conn) {
+	defer wr.wg.Done()
+	defer func() {
+		wr.connsMu.Lock()
+		delete(wr.conns, conn)
+		wr.connsMu.Unlock()
+		_ = conn.Close()
+	}()
+
+	ctx := observability.ContextWithReceiverName(context.Background(), wr.name)
+	reader := bufio.NewReader(conn)
+	var batch []*metricspb.Metric
+	dropped := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			metric, perr := parseLine(line, time.Now())
+			switch {
+			case perr != nil:
+				dropped++
+				wr.logger.Debug("Dropped malformed Wavefront line",
+					zap.String("receiver", wr.name), zap.String("line", line), zap.Error(perr))
+			case metric != nil:
+				batch = append(batch, metric)
+			}
+		}
+
+		if err != nil || reader.Buffered() == 0 || len(batch) >= maxBatchSize {
+			wr.flush(ctx, batch, dropped)
+			batch, dropped = nil, 0
+		}
+		if err != nil {
+			if err != io.EOF && !wr.stopping() {
+				wr.logger.Debug("Wavefront connection failed", zap.String("receiver", wr.name), zap.Error(err))
+			}
+			return
+		}
+	}
+}
+
+func (wr *Receiver) flush(ctx context.Context, batch []*metricspb.Metric, dropped int) {
+	if len(batch) == 0 && dropped == 0 {
+		return
+	}
+	observability.RecordMetricsForMetricsReceiver(ctx, len(batch), dropped)
+	if len(batch) == 0 {
+		return
+	}
+	if err := wr.nextConsumer.ConsumeMetricsData(ctx, consumerdata.MetricsData{Metrics: batch}); err != nil {
+		wr.logger.Warn("Failed to consume Wavefront metrics", zap.String("receiver", wr.name), zap.Error(err))
+	}
+}
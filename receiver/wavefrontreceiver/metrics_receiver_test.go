@@ -0,0 +1,86 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wavefrontreceiver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
+	"github.com/open-telemetry/opentelemetry-service/internal/testutils"
+	"github.com/open-telemetry/opentelemetry-service/observability/observabilitytest"
+	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
+)
+
+func TestReceiverEndToEnd(t *testing.T) {
+	doneFn := observabilitytest.SetupRecordedMetricsTest()
+	defer doneFn()
+
+	sink := new(exportertest.SinkMetricsExporter)
+	addr := testutils.GetAvailableLocalAddress(t)
+	wr, err := New(zap.NewNop(), "wavefront", addr, sink)
+	require.NoError(t, err)
+
+	require.NoError(t, wr.StartMetricsReception(receivertest.NewMockHost()))
+	defer wr.StopMetricsReception()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("cpu 1 1560000000 source=h\nmalformed\n!M #1 2 latency source=h\nmem 2 source=h"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, func() bool { return countMetrics(sink) == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, observabilitytest.CheckValueViewReceiverReceivedTimeSeries("wavefront", 3))
+	assert.NoError(t, observabilitytest.CheckValueViewReceiverDroppedTimeSeries("wavefront", 1))
+}
+
+func TestStopClosesConnections(t *testing.T) {
+	addr := testutils.GetAvailableLocalAddress(t)
+	wr, err := New(zap.NewNop(), "wavefront", addr, exportertest.NewNopMetricsExporter())
+	require.NoError(t, err)
+	require.NoError(t, wr.StartMetricsReception(receivertest.NewMockHost()))
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("cpu 1 source=h\n"))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		assert.NoError(t, wr.StopMetricsReception())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop did not close the open connection")
+	}
+	assert.Error(t, wr.StopMetricsReception())
+}
+
+func countMetrics(sink *exportertest.SinkMetricsExporter) int {
+	n := 0
+	for _, md := range sink.AllMetrics() {
+		n += len(md.Metrics)
+	}
+	return n
+}
@@ -0,0 +1,294 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wavefrontreceiver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"github.com/open-telemetry/opentelemetry-service/internal"
+)
+
+const (
+	// sourceLabel is the label the source of a point is mapped to.
+	sourceLabel = "source"
+	// hostTag is accepted by Wavefront as an alias of the source tag.
+	hostTag = "host"
+
+	// histogramPrefix starts the lines in the distribution format, followed by
+	// the aggregation interval: M(inute), H(our) or D(ay).
+	histogramPrefix = "!"
+	// centroidPrefix starts the count of a centroid in the distribution format.
+	centroidPrefix = "#"
+)
+
+var (
+	errUnterminatedQuote = errors.New("unterminated quoted string")
+	errMissingValue      = errors.New("line without metric name or value")
+	errMissingSource     = errors.New("line without source")
+	errInvalidTag        = errors.New("tag is not of the form key=value")
+	errMissingCentroids  = errors.New("histogram without centroids")
+	errUnknownInterval   = errors.New("unknown histogram interval")
+)
+
+// parseLine parses a single line in the Wavefront data format, either a point:
+//
+//	<metricName> <metricValue> [<timestamp>] source=<source> [<tagKey>=<tagValue> ...]
+//
+// or a histogram in the distribution format:
+//
+//	!M [<timestamp>] #<count> <centroid> [#<count> <centroid> ...] <metricName> source=<source> [<tagKey>=<tagValue> ...]
+//
+// Timestamps are in seconds since epoch, now is used for lines without one.
+// Empty lines and comments return a nil metric and no error.
+func parseLine(line string, now time.Time) (*metricspb.Metric, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	tokens, err := tokenize(line)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(tokens[0], histogramPrefix) {
+		return parseHistogram(tokens, now)
+	}
+	return parsePoint(tokens, now)
+}
+
+func parsePoint(tokens []string, now time.Time) (*metricspb.Metric, error) {
+	if len(tokens) < 2 {
+		return nil, errMissingValue
+	}
+	name := tokens[0]
+	value, err := strconv.ParseFloat(tokens[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q: %v", tokens[1], err)
+	}
+
+	ts, tags, err := parseTimestamp(tokens[2:], now)
+	if err != nil {
+		return nil, err
+	}
+	keys, values, err := parseTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Type:      metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			LabelKeys: keys,
+		},
+		Timeseries: []*metricspb.TimeSeries{
+			{
+				LabelValues: values,
+				Points: []*metricspb.Point{
+					{Timestamp: ts, Value: &metricspb.Point_DoubleValue{DoubleValue: value}},
+				},
+			},
+		},
+	}, nil
+}
+
+// parseHistogram maps a histogram in the distribution format to a gauge
+// distribution, as each histogram only covers its aggregation interval. The
+// centroids are used as the bucket boundaries, so the count of each centroid
+// falls into the bucket starting at its value.
+func parseHistogram(tokens []string, now time.Time) (*metricspb.Metric, error) {
+	switch tokens[0] {
+	case "!M", "!H", "!D":
+	default:
+		return nil, errUnknownInterval
+	}
+
+	ts, rest, err := parseTimestamp(tokens[1:], now)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[float64]int64)
+	for len(rest) >= 2 && strings.HasPrefix(rest[0], centroidPrefix) {
+		count, err := strconv.ParseInt(strings.TrimPrefix(rest[0], centroidPrefix), 10, 64)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid centroid count %q", rest[0])
+		}
+		centroid, err := strconv.ParseFloat(rest[1], 64)
+		if err != nil || math.IsNaN(centroid) || math.IsInf(centroid, 0) {
+			return nil, fmt.Errorf("invalid centroid %q", rest[1])
+		}
+		counts[centroid] += count
+		rest = rest[2:]
+	}
+	if len(counts) == 0 {
+		return nil, errMissingCentroids
+	}
+	if len(rest) == 0 {
+		return nil, errMissingValue
+	}
+
+	name := rest[0]
+	keys, values, err := parseTags(rest[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:      name,
+			Type:      metricspb.MetricDescriptor_GAUGE_DISTRIBUTION,
+			LabelKeys: keys,
+		},
+		Timeseries: []*metricspb.TimeSeries{
+			{
+				LabelValues: values,
+				Points: []*metricspb.Point{
+					{Timestamp: ts, Value: &metricspb.Point_DistributionValue{DistributionValue: centroidsToDistribution(counts)}},
+				},
+			},
+		},
+	}, nil
+}
+
+func centroidsToDistribution(counts map[float64]int64) *metricspb.DistributionValue {
+	bounds := make([]float64, 0, len(counts))
+	for centroid := range counts {
+		bounds = append(bounds, centroid)
+	}
+	sort.Float64s(bounds)
+
+	dv := &metricspb.DistributionValue{
+		BucketOptions: &metricspb.DistributionValue_BucketOptions{
+			Type: &metricspb.DistributionValue_BucketOptions_Explicit_{
+				Explicit: &metricspb.DistributionValue_BucketOptions_Explicit{Bounds: bounds},
+			},
+		},
+		// The bucket below the lowest centroid is always empty.
+		Buckets: []*metricspb.DistributionValue_Bucket{{}},
+	}
+	for _, centroid := range bounds {
+		count := counts[centroid]
+		dv.Count += count
+		dv.Sum += float64(count) * centroid
+		dv.Buckets = append(dv.Buckets, &metricspb.DistributionValue_Bucket{Count: count})
+	}
+	if dv.Count > 0 {
+		mean := dv.Sum / float64(dv.Count)
+		for _, centroid := range bounds {
+			dev := centroid - mean
+			dv.SumOfSquaredDeviation += float64(counts[centroid]) * dev * dev
+		}
+	}
+	return dv
+}
+
+// parseTimestamp parses the optional timestamp at the start of the given
+// tokens and returns it along with the remaining tokens.
+func parseTimestamp(tokens []string, now time.Time) (*timestamp.Timestamp, []string, error) {
+	if len(tokens) == 0 || strings.Contains(tokens[0], "=") || strings.HasPrefix(tokens[0], centroidPrefix) {
+		return internal.TimeToTimestamp(now), tokens, nil
+	}
+	secs, err := strconv.ParseInt(tokens[0], 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp %q: %v", tokens[0], err)
+	}
+	return &timestamp.Timestamp{Seconds: secs}, tokens[1:], nil
+}
+
+// parseTags parses the source and point tags into label keys and values,
+// sorted by key. The source is required and mapped to the "source" label.
+func parseTags(tokens []string) ([]*metricspb.LabelKey, []*metricspb.LabelValue, error) {
+	tags := make(map[string]string, len(tokens))
+	hasSource := false
+	for _, token := range tokens {
+		eq := strings.Index(token, "=")
+		if eq <= 0 {
+			return nil, nil, errInvalidTag
+		}
+		key, value := token[:eq], token[eq+1:]
+		if key == hostTag {
+			key = sourceLabel
+		}
+		if key == sourceLabel {
+			hasSource = true
+		}
+		tags[key] = value
+	}
+	if !hasSource {
+		return nil, nil, errMissingSource
+	}
+
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keys := make([]*metricspb.LabelKey, 0, len(names))
+	values := make([]*metricspb.LabelValue, 0, len(names))
+	for _, name := range names {
+		keys = append(keys, &metricspb.LabelKey{Key: name})
+		values = append(values, &metricspb.LabelValue{Value: tags[name], HasValue: true})
+	}
+	return keys, values, nil
+}
+
+// tokenize splits the line on whitespace. Double quotes group characters,
+// including whitespace, into a token and are removed, e.g. tag="a b" becomes
+// the token `tag=a b`. Within quotes a backslash escapes the next character.
+func tokenize(line string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	inToken, inQuote, escaped := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			token.WriteRune(r)
+			escaped = false
+		case inQuote && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+			inToken = true
+		case !inQuote && unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inQuote {
+		return nil, errUnterminatedQuote
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wavefrontreceiver
+
+import (
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePoint(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tests := []struct {
+		name      string
+		line      string
+		wantName  string
+		wantValue float64
+		wantTime  int64
+		wantTags  map[string]string
+	}{
+		{
+			name:      "full",
+			line:      "system.cpu.usage 42.5 1560000000 source=host1 env=prod\n",
+			wantName:  "system.cpu.usage",
+			wantValue: 42.5,
+			wantTime:  1560000000,
+			wantTags:  map[string]string{"source": "host1", "env": "prod"},
+		},
+		{
+			name:      "no_timestamp",
+			line:      "requests 7 source=host1",
+			wantName:  "requests",
+			wantValue: 7,
+			wantTime:  1000,
+			wantTags:  map[string]string{"source": "host1"},
+		},
+		{
+			name:      "host_alias",
+			line:      "requests -1e3 host=host2",
+			wantName:  "requests",
+			wantValue: -1000,
+			wantTime:  1000,
+			wantTags:  map[string]string{"source": "host2"},
+		},
+		{
+			name:      "quoted",
+			line:      `"my metric" 1 1560000000 source="host 1" "dc"="us \"west\""`,
+			wantName:  "my metric",
+			wantValue: 1,
+			wantTime:  1560000000,
+			wantTags:  map[string]string{"source": "host 1", "dc": `us "west"`},
+		},
+		{
+			name:      "tag_value_with_equal",
+			line:      "requests 1 source=h query=a=b",
+			wantName:  "requests",
+			wantValue: 1,
+			wantTime:  1000,
+			wantTags:  map[string]string{"source": "h", "query": "a=b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric, err := parseLine(tt.line, now)
+			require.NoError(t, err)
+			require.NotNil(t, metric)
+
+			assert.Equal(t, tt.wantName, metric.MetricDescriptor.Name)
+			assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, metric.MetricDescriptor.Type)
+			assert.Equal(t, tt.wantTags, labels(metric))
+
+			require.Len(t, metric.Timeseries, 1)
+			require.Len(t, metric.Timeseries[0].Points, 1)
+			point := metric.Timeseries[0].Points[0]
+			assert.Equal(t, tt.wantValue, point.GetDoubleValue())
+			assert.Equal(t, tt.wantTime, point.Timestamp.Seconds)
+		})
+	}
+}
+
+func TestParseTagsAreSorted(t *testing.T) {
+	metric, err := parseLine("m 1 zone=b source=h app=a", time.Now())
+	require.NoError(t, err)
+
+	var keys []string
+	for _, k := range metric.MetricDescriptor.LabelKeys {
+		keys = append(keys, k.Key)
+	}
+	assert.Equal(t, []string{"app", "source", "zone"}, keys)
+}
+
+func TestParseHistogram(t *testing.T) {
+	metric, err := parseLine("!M 1560000000 #20 30.0 #10 5.1 #5 30 request.latency source=appServer1 region=us-west", time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, "request.latency", metric.MetricDescriptor.Name)
+	assert.Equal(t, metricspb.MetricDescriptor_GAUGE_DISTRIBUTION, metric.MetricDescriptor.Type)
+	assert.Equal(t, map[string]string{"source": "appServer1", "region": "us-west"}, labels(metric))
+
+	point := metric.Timeseries[0].Points[0]
+	assert.Equal(t, &timestamp.Timestamp{Seconds: 1560000000}, point.Timestamp)
+	dv := point.GetDistributionValue()
+	require.NotNil(t, dv)
+	assert.Equal(t, int64(35), dv.Count)
+	assert.InDelta(t, 25*30.0+10*5.1, dv.Sum, 1e-9)
+	assert.Equal(t, []float64{5.1, 30}, dv.BucketOptions.GetExplicit().Bounds)
+	var counts []int64
+	for _, b := range dv.Buckets {
+		counts = append(counts, b.Count)
+	}
+	assert.Equal(t, []int64{0, 10, 25}, counts)
+	mean := dv.Sum / 35
+	assert.InDelta(t, 10*(5.1-mean)*(5.1-mean)+25*(30-mean)*(30-mean), dv.SumOfSquaredDeviation, 1e-9)
+}
+
+func TestParseHistogramWithoutTimestamp(t *testing.T) {
+	metric, err := parseLine("!H #1 2 latency source=h", time.Unix(1000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), metric.Timeseries[0].Points[0].Timestamp.Seconds)
+}
+
+func TestParseSkippedLines(t *testing.T) {
+	for _, line := range []string{"", "  \n", "# a comment"} {
+		metric, err := parseLine(line, time.Now())
+		assert.NoError(t, err)
+		assert.Nil(t, metric)
+	}
+}
+
+func TestParseMalformedLines(t *testing.T) {
+	lines := []string{
+		"only.name",
+		"name notanumber source=h",
+		"name 1 source=h badtag",
+		"name 1 source=h =value",
+		"name 1 notatime source=h",
+		"name 1 1560000000",
+		"name 1 env=prod",
+		`name 1 source="unterminated`,
+		"!X #1 2 name source=h",
+		"!M 1560000000 name source=h",
+		"!M #x 2 name source=h",
+		"!M #1 y name source=h",
+		"!M #1 2",
+	}
+	for _, line := range lines {
+		t.Run(line, func(t *testing.T) {
+			metric, err := parseLine(line, time.Now())
+			assert.Error(t, err)
+			assert.Nil(t, metric)
+		})
+	}
+}
+
+func labels(metric *metricspb.Metric) map[string]string {
+	got := make(map[string]string)
+	for i, k := range metric.MetricDescriptor.LabelKeys {
+		got[k.Key] = metric.Timeseries[0].LabelValues[i].Value
+	}
+	return got
+}
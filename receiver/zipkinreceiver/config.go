@@ -14,9 +14,9 @@
 
 package zipkinreceiver
 
-import "github.com/open-telemetry/opentelemetry-service/config/configmodels"
+import "github.com/open-telemetry/opentelemetry-service/receiver"
 
 // Config defines configuration for Zipkin receiver.
 type Config struct {
-	configmodels.ReceiverSettings `mapstructure:",squash"`
+	receiver.SecureReceiverSettings `mapstructure:",squash"`
 }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/open-telemetry/opentelemetry-service/config"
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/receiver"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -44,10 +45,25 @@ func TestLoadConfig(t *testing.T) {
 	r1 := cfg.Receivers["zipkin/customname"].(*Config)
 	assert.Equal(t, r1,
 		&Config{
-			ReceiverSettings: configmodels.ReceiverSettings{
-				TypeVal:  typeStr,
-				NameVal:  "zipkin/customname",
-				Endpoint: "localhost:8765",
+			SecureReceiverSettings: receiver.SecureReceiverSettings{
+				ReceiverSettings: configmodels.ReceiverSettings{
+					TypeVal:  typeStr,
+					NameVal:  "zipkin/customname",
+					Endpoint: "localhost:8765",
+				},
 			},
 		})
 }
+
+func TestLoadConfigInvalidCipherSuite(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+
+	factory := &Factory{}
+	factories.Receivers[typeStr] = factory
+	cfg, err := config.LoadConfigFile(t, path.Join(".", "testdata", "invalid_cipher_suite.yaml"), factories)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TLS_NOT_A_CIPHER")
+	assert.Nil(t, cfg)
+}
@@ -16,6 +16,7 @@ package zipkinreceiver
 
 import (
 	"context"
+	"fmt"
 
 	"go.uber.org/zap"
 
@@ -51,10 +52,12 @@ func (f *Factory) CustomUnmarshaler() receiver.CustomUnmarshaler {
 // CreateDefaultConfig creates the default configuration for Jaeger receiver.
 func (f *Factory) CreateDefaultConfig() configmodels.Receiver {
 	return &Config{
-		ReceiverSettings: configmodels.ReceiverSettings{
-			TypeVal:  typeStr,
-			NameVal:  typeStr,
-			Endpoint: defaultBindEndpoint,
+		SecureReceiverSettings: receiver.SecureReceiverSettings{
+			ReceiverSettings: configmodels.ReceiverSettings{
+				TypeVal:  typeStr,
+				NameVal:  typeStr,
+				Endpoint: defaultBindEndpoint,
+			},
 		},
 	}
 }
@@ -68,7 +71,15 @@ func (f *Factory) CreateTraceReceiver(
 ) (receiver.TraceReceiver, error) {
 
 	rCfg := cfg.(*Config)
-	return New(rCfg.Endpoint, nextConsumer)
+	zr, err := New(rCfg.Endpoint, nextConsumer)
+	if err != nil {
+		return nil, err
+	}
+	zr.tlsConfig, err = rCfg.TLSCredentials.ToTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %v", err)
+	}
+	return zr, nil
 }
 
 // CreateMetricsReceiver creates a metrics receiver based on provided config.
@@ -17,6 +17,7 @@ package zipkinreceiver
 import (
 	"compress/gzip"
 	"compress/zlib"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -36,6 +37,7 @@ import (
 	zipkinproto "github.com/openzipkin/zipkin-go/proto/v2"
 	"go.opencensus.io/trace"
 
+	"github.com/open-telemetry/opentelemetry-service/compression"
 	"github.com/open-telemetry/opentelemetry-service/consumer"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
 	"github.com/open-telemetry/opentelemetry-service/internal"
@@ -56,6 +58,9 @@ type ZipkinReceiver struct {
 	host         receiver.Host
 	nextConsumer consumer.TraceConsumer
 
+	// tlsConfig, if set, makes the HTTP server use TLS.
+	tlsConfig *tls.Config
+
 	startOnce sync.Once
 	stopOnce  sync.Once
 	server    *http.Server
@@ -111,6 +116,9 @@ func (zr *ZipkinReceiver) StartTraceReception(host receiver.Host) error {
 			err = lerr
 			return
 		}
+		if zr.tlsConfig != nil {
+			ln = tls.NewListener(ln, zr.tlsConfig)
+		}
 
 		zr.host = host
 		server := &http.Server{Handler: zr}
@@ -245,7 +253,8 @@ func (zr *ZipkinReceiver) StopTraceReception() error {
 // a compression such as "gzip", "deflate", "zlib", is found, the body will
 // be uncompressed accordingly or return the body untouched if otherwise.
 // Clients such as Zipkin-Java do this behavior e.g.
-//    send "Content-Encoding":"gzip" of the JSON content.
+//
+//	send "Content-Encoding":"gzip" of the JSON content.
 func processBodyIfNecessary(req *http.Request) io.Reader {
 	switch req.Header.Get("Content-Encoding") {
 	default:
@@ -260,12 +269,22 @@ func processBodyIfNecessary(req *http.Request) io.Reader {
 }
 
 func gunzippedBodyIfPossible(r io.Reader) io.Reader {
-	gzr, err := gzip.NewReader(r)
+	gzr, err := compression.DefaultGzipPool.GetReader(r)
 	if err != nil {
 		// Just return the old body as was
 		return r
 	}
-	return gzr
+	return pooledGzipReader{gzr}
+}
+
+// pooledGzipReader returns the gzip reader to the pool when closed.
+type pooledGzipReader struct {
+	*gzip.Reader
+}
+
+func (pr pooledGzipReader) Close() error {
+	compression.DefaultGzipPool.PutReader(pr.Reader)
+	return nil
 }
 
 func zlibUncompressedbody(r io.Reader) io.Reader {
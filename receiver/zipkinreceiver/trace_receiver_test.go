@@ -34,6 +34,7 @@ import (
 	zhttp "github.com/openzipkin/zipkin-go/reporter/http"
 	"github.com/stretchr/testify/require"
 
+	"github.com/open-telemetry/opentelemetry-service/compression"
 	"github.com/open-telemetry/opentelemetry-service/consumer"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
 	"github.com/open-telemetry/opentelemetry-service/exporter/exportertest"
@@ -556,3 +557,23 @@ func TestStartTraceReception(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessBodyIfNecessaryGzip(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		payload := []byte(fmt.Sprintf(`[{"traceId":"%d"}]`, i))
+		var buf bytes.Buffer
+		require.NoError(t, compression.DefaultGzipPool.Compress(&buf, payload))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/spans", &buf)
+		req.Header.Set("Content-Encoding", "gzip")
+		pr := processBodyIfNecessary(req)
+		got, err := ioutil.ReadAll(pr)
+		require.NoError(t, err)
+		require.Equal(t, payload, got)
+
+		// Closing the body returns the reader to the pool.
+		c, ok := pr.(io.Closer)
+		require.True(t, ok)
+		require.NoError(t, c.Close())
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
 	"github.com/open-telemetry/opentelemetry-service/exporter"
 	"github.com/open-telemetry/opentelemetry-service/oterr"
+	"github.com/open-telemetry/opentelemetry-service/processor"
 )
 
 // builtExporter is an exporter that is built based on a config. It can have
@@ -136,6 +137,70 @@ func (eb *ExportersBuilder) calcExportersRequiredDataTypes() exportersRequiredDa
 			// pipeline the requirement is coming from.
 			result[exporter][pipeline.InputType] = dataTypeRequirement{pipeline}
 		}
+
+		// The fallback exporters of the processors receive the data type of the
+		// pipeline too.
+		for _, procName := range pipeline.Processors {
+			fallbackCfg, ok := eb.config.Processors[procName].(processor.FallbackConfig)
+			if !ok {
+				continue
+			}
+			for _, expName := range fallbackCfg.FallbackExporterNames() {
+				exporter := eb.config.Exporters[expName]
+				if exporter == nil {
+					// Reported when building the processor.
+					continue
+				}
+				if result[exporter] == nil {
+					result[exporter] = make(dataTypeRequirements)
+				}
+				result[exporter][pipeline.InputType] = dataTypeRequirement{pipeline}
+			}
+		}
+
+		// So do the exporters the processors route the data to.
+		for _, procName := range pipeline.Processors {
+			routerCfg, ok := eb.config.Processors[procName].(processor.RouterConfig)
+			if !ok {
+				continue
+			}
+			for _, names := range routerCfg.RouteExporterNames() {
+				for _, expName := range names {
+					exporter := eb.config.Exporters[expName]
+					if exporter == nil {
+						// Reported when building the processor.
+						continue
+					}
+					if result[exporter] == nil {
+						result[exporter] = make(dataTypeRequirements)
+					}
+					result[exporter][pipeline.InputType] = dataTypeRequirement{pipeline}
+				}
+			}
+		}
+
+		// The metrics exporters of the trace processors deriving metrics from the
+		// spans receive metrics.
+		if pipeline.InputType != configmodels.TracesDataType {
+			continue
+		}
+		for _, procName := range pipeline.Processors {
+			emitterCfg, ok := eb.config.Processors[procName].(processor.MetricsEmitterConfig)
+			if !ok {
+				continue
+			}
+			for _, expName := range emitterCfg.MetricsExporterNames() {
+				exporter := eb.config.Exporters[expName]
+				if exporter == nil {
+					// Reported when building the processor.
+					continue
+				}
+				if result[exporter] == nil {
+					result[exporter] = make(dataTypeRequirements)
+				}
+				result[exporter][configmodels.MetricsDataType] = dataTypeRequirement{pipeline}
+			}
+		}
 	}
 	return result
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
 	"github.com/open-telemetry/opentelemetry-service/consumer"
+	"github.com/open-telemetry/opentelemetry-service/oterr"
 	"github.com/open-telemetry/opentelemetry-service/processor"
 )
 
@@ -29,12 +30,34 @@ import (
 type builtProcessor struct {
 	tc consumer.TraceConsumer
 	mc consumer.MetricsConsumer
+	// shutdowners are the processors of the pipeline to shut down, in
+	// pipeline order.
+	shutdowners []processor.Shutdowner
+}
+
+// Shutdown the processors of the pipeline, in pipeline order so that the data
+// flushed by a processor goes through the following ones.
+func (bp *builtProcessor) Shutdown() error {
+	var errors []error
+	for _, s := range bp.shutdowners {
+		if err := s.Shutdown(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return oterr.CombineErrors(errors)
 }
 
 // PipelineProcessors is a map of entry-point processors created from pipeline configs.
 // Each element of the map points to the first processor of the pipeline.
 type PipelineProcessors map[*configmodels.Pipeline]*builtProcessor
 
+// ShutdownAll shuts down the processors of all pipelines.
+func (pps PipelineProcessors) ShutdownAll() {
+	for _, bp := range pps {
+		bp.Shutdown()
+	}
+}
+
 // PipelinesBuilder builds pipelines from config.
 type PipelinesBuilder struct {
 	logger    *zap.Logger
@@ -81,6 +104,7 @@ func (pb *PipelinesBuilder) buildPipeline(
 	// First create a consumer junction point that fans out the data to all exporters.
 	var tc consumer.TraceConsumer
 	var mc consumer.MetricsConsumer
+	var shutdowners []processor.Shutdowner
 
 	switch pipelineCfg.InputType {
 	case configmodels.TracesDataType:
@@ -103,22 +127,160 @@ func (pb *PipelinesBuilder) buildPipeline(
 		// it becomes the next for the previous one (previous in the pipeline,
 		// which we will build in the next loop iteration).
 		var err error
-		switch pipelineCfg.InputType {
-		case configmodels.TracesDataType:
-			tc, err = factory.CreateTraceProcessor(pb.logger, tc, procCfg)
-		case configmodels.MetricsDataType:
-			mc, err = factory.CreateMetricsProcessor(pb.logger, mc, procCfg)
+		fallbackFactory, isFallbackFactory := factory.(processor.FallbackFactory)
+		fallbackCfg, isFallbackCfg := procCfg.(processor.FallbackConfig)
+		emitterFactory, isEmitterFactory := factory.(processor.MetricsEmitterFactory)
+		emitterCfg, isEmitterCfg := procCfg.(processor.MetricsEmitterConfig)
+		routerFactory, isRouterFactory := factory.(processor.RouterFactory)
+		routerCfg, isRouterCfg := procCfg.(processor.RouterConfig)
+		if isFallbackFactory && isFallbackCfg {
+			tc, mc, err = pb.buildFallbackProcessor(pipelineCfg, fallbackFactory, procCfg, fallbackCfg, tc, mc)
+		} else if isRouterFactory && isRouterCfg {
+			tc, mc, err = pb.buildRouterProcessor(pipelineCfg, routerFactory, procCfg, routerCfg, tc, mc)
+		} else if isEmitterFactory && isEmitterCfg && pipelineCfg.InputType == configmodels.TracesDataType {
+			tc, err = pb.buildMetricsEmitterProcessor(emitterFactory, procCfg, emitterCfg, tc)
+		} else {
+			switch pipelineCfg.InputType {
+			case configmodels.TracesDataType:
+				tc, err = factory.CreateTraceProcessor(pb.logger, tc, procCfg)
+			case configmodels.MetricsDataType:
+				mc, err = factory.CreateMetricsProcessor(pb.logger, mc, procCfg)
+			}
 		}
 
 		if err != nil {
 			return nil, fmt.Errorf("error creating processor %q in pipeline %q: %v",
 				procName, pipelineCfg.Name, err)
 		}
+
+		var created interface{} = tc
+		if pipelineCfg.InputType == configmodels.MetricsDataType {
+			created = mc
+		}
+		if pa, ok := created.(processor.PipelineAware); ok {
+			pa.SetPipelineName(pipelineCfg.Name)
+		}
+		if s, ok := created.(processor.Shutdowner); ok {
+			shutdowners = append([]processor.Shutdowner{s}, shutdowners...)
+		}
+	}
+
+	// Tag the data entering the pipeline with its name, so that the stats of the
+	// processors and exporters are attributable per pipeline.
+	switch pipelineCfg.InputType {
+	case configmodels.TracesDataType:
+		tc = processor.NewTracePipelineTagger(pipelineCfg.Name, tc)
+	case configmodels.MetricsDataType:
+		mc = processor.NewMetricsPipelineTagger(pipelineCfg.Name, mc)
 	}
 
 	pb.logger.Info("Pipeline is enabled.", zap.String("pipelines", pipelineCfg.Name))
 
-	return &builtProcessor{tc, mc}, nil
+	return &builtProcessor{tc, mc, shutdowners}, nil
+}
+
+// buildFallbackProcessor creates a processor forwarding the data to the fallback
+// exporters named by its config, as needed. Without fallback exporters the
+// processor is given a nil fallback consumer, it reports whether it needs one.
+func (pb *PipelinesBuilder) buildFallbackProcessor(
+	pipelineCfg *configmodels.Pipeline,
+	factory processor.FallbackFactory,
+	procCfg configmodels.Processor,
+	fallbackCfg processor.FallbackConfig,
+	tc consumer.TraceConsumer,
+	mc consumer.MetricsConsumer,
+) (consumer.TraceConsumer, consumer.MetricsConsumer, error) {
+	fallbackNames := fallbackCfg.FallbackExporterNames()
+	for _, name := range fallbackNames {
+		if pb.config.Exporters[name] == nil {
+			return nil, nil, fmt.Errorf("processor %q references fallback exporter %q which does not exist",
+				procCfg.Name(), name)
+		}
+	}
+
+	var err error
+	switch pipelineCfg.InputType {
+	case configmodels.TracesDataType:
+		var fallback consumer.TraceConsumer
+		if len(fallbackNames) > 0 {
+			fallback = pb.buildFanoutExportersTraceConsumer(fallbackNames)
+		}
+		tc, err = factory.CreateTraceFallbackProcessor(pb.logger, tc, fallback, procCfg)
+	case configmodels.MetricsDataType:
+		var fallback consumer.MetricsConsumer
+		if len(fallbackNames) > 0 {
+			fallback = pb.buildFanoutExportersMetricsConsumer(fallbackNames)
+		}
+		mc, err = factory.CreateMetricsFallbackProcessor(pb.logger, mc, fallback, procCfg)
+	}
+	return tc, mc, err
+}
+
+// buildRouterProcessor creates a processor routing the data to the exporters
+// of the routes named by its config.
+func (pb *PipelinesBuilder) buildRouterProcessor(
+	pipelineCfg *configmodels.Pipeline,
+	factory processor.RouterFactory,
+	procCfg configmodels.Processor,
+	routerCfg processor.RouterConfig,
+	tc consumer.TraceConsumer,
+	mc consumer.MetricsConsumer,
+) (consumer.TraceConsumer, consumer.MetricsConsumer, error) {
+	routeNames := routerCfg.RouteExporterNames()
+	for route, names := range routeNames {
+		for _, name := range names {
+			if pb.config.Exporters[name] == nil {
+				return nil, nil, fmt.Errorf("processor %q references exporter %q in route %q which does not exist",
+					procCfg.Name(), name, route)
+			}
+		}
+	}
+
+	var err error
+	switch pipelineCfg.InputType {
+	case configmodels.TracesDataType:
+		routes := make(map[string]consumer.TraceConsumer, len(routeNames))
+		for route, names := range routeNames {
+			if len(names) > 0 {
+				routes[route] = pb.buildFanoutExportersTraceConsumer(names)
+			}
+		}
+		tc, err = factory.CreateTraceRouterProcessor(pb.logger, tc, routes, procCfg)
+	case configmodels.MetricsDataType:
+		routes := make(map[string]consumer.MetricsConsumer, len(routeNames))
+		for route, names := range routeNames {
+			if len(names) > 0 {
+				routes[route] = pb.buildFanoutExportersMetricsConsumer(names)
+			}
+		}
+		mc, err = factory.CreateMetricsRouterProcessor(pb.logger, mc, routes, procCfg)
+	}
+	return tc, mc, err
+}
+
+// buildMetricsEmitterProcessor creates a trace processor sending the metrics it
+// derives from the spans to the metrics exporters named by its config. Without
+// metrics exporters the processor is given a nil metrics consumer, it reports
+// whether it needs one.
+func (pb *PipelinesBuilder) buildMetricsEmitterProcessor(
+	factory processor.MetricsEmitterFactory,
+	procCfg configmodels.Processor,
+	emitterCfg processor.MetricsEmitterConfig,
+	tc consumer.TraceConsumer,
+) (consumer.TraceConsumer, error) {
+	exporterNames := emitterCfg.MetricsExporterNames()
+	for _, name := range exporterNames {
+		if pb.config.Exporters[name] == nil {
+			return nil, fmt.Errorf("processor %q references metrics exporter %q which does not exist",
+				procCfg.Name(), name)
+		}
+	}
+
+	var mc consumer.MetricsConsumer
+	if len(exporterNames) > 0 {
+		mc = pb.buildFanoutExportersMetricsConsumer(exporterNames)
+	}
+	return factory.CreateTraceMetricsEmitterProcessor(pb.logger, tc, mc, procCfg)
 }
 
 // Converts the list of exporter names to a list of corresponding builtExporters.
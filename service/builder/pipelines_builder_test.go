@@ -22,13 +22,22 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
 
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	tracepb "github.com/census-instrumentation/opencensus-proto/gen-go/trace/v1"
 
 	"github.com/open-telemetry/opentelemetry-service/config"
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
+	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
+	"github.com/open-telemetry/opentelemetry-service/observability"
 	"github.com/open-telemetry/opentelemetry-service/processor/attributesprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/failoverprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/heartbeatprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/mergeprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/metrictyperouterprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/servicegraphprocessor"
 )
 
 func TestPipelinesBuilder_Build(t *testing.T) {
@@ -145,3 +154,241 @@ func TestPipelinesBuilder_Error(t *testing.T) {
 
 	assert.NotNil(t, err)
 }
+
+func TestPipelinesBuilder_FallbackExporters(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	failoverFactory := &failoverprocessor.Factory{}
+	factories.Processors[failoverFactory.Type()] = failoverFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_failover.yaml", factories)
+	require.Nil(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+
+	// The fallback exporter is not in any pipeline but is built for the data
+	// types of the pipelines of the processor.
+	fallback := allExporters[cfg.Exporters["exampleexporter/local"]]
+	require.NotNil(t, fallback)
+	assert.NotNil(t, fallback.te)
+	assert.NotNil(t, fallback.me)
+
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	assert.NoError(t, err)
+	processor := pipelineProcessors[cfg.Pipelines["traces"]]
+	require.NotNil(t, processor)
+
+	// The primary exporter does not fail so the data does not reach the fallback one.
+	traceData := consumerdata.TraceData{Spans: []*tracepb.Span{{}}}
+	require.NoError(t, processor.tc.ConsumeTraceData(context.Background(), traceData))
+	primary := allExporters[cfg.Exporters["exampleexporter"]].te.(*config.ExampleExporterConsumer)
+	assert.Equal(t, 1, len(primary.Traces))
+	assert.Equal(t, 0, len(fallback.te.(*config.ExampleExporterConsumer).Traces))
+}
+
+func TestPipelinesBuilder_NoFallbackExporters(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	failoverFactory := &failoverprocessor.Factory{}
+	factories.Processors[failoverFactory.Type()] = failoverFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_failover.yaml", factories)
+	require.Nil(t, err)
+
+	// The failover processor requires fallback exporters.
+	cfg.Processors["failover"].(*failoverprocessor.Config).FallbackExporters = nil
+
+	exporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+	_, err = NewPipelinesBuilder(zap.NewNop(), cfg, exporters, factories.Processors).Build()
+	assert.Error(t, err)
+}
+
+func TestPipelinesBuilder_UnknownFallbackExporter(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	failoverFactory := &failoverprocessor.Factory{}
+	factories.Processors[failoverFactory.Type()] = failoverFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_failover.yaml", factories)
+	require.Nil(t, err)
+
+	cfg.Processors["failover"].(*failoverprocessor.Config).FallbackExporters = []string{"exampleexporter/unknown"}
+
+	exporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+	_, err = NewPipelinesBuilder(zap.NewNop(), cfg, exporters, factories.Processors).Build()
+	assert.Error(t, err)
+}
+
+func TestPipelinesBuilder_MetricsExporters(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	serviceGraphFactory := &servicegraphprocessor.Factory{}
+	factories.Processors[serviceGraphFactory.Type()] = serviceGraphFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_service_graph.yaml", factories)
+	require.Nil(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+
+	// The metrics exporter is not in any pipeline but is built for metrics.
+	metricsExporter := allExporters[cfg.Exporters["exampleexporter/metrics"]]
+	require.NotNil(t, metricsExporter)
+	assert.Nil(t, metricsExporter.te)
+	require.NotNil(t, metricsExporter.me)
+
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	assert.NoError(t, err)
+	processor := pipelineProcessors[cfg.Pipelines["traces"]]
+	require.NotNil(t, processor)
+
+	spans := []*tracepb.Span{{
+		TraceId: []byte{1},
+		SpanId:  []byte{2},
+		Kind:    tracepb.Span_CLIENT,
+		Attributes: &tracepb.Span_Attributes{
+			AttributeMap: map[string]*tracepb.AttributeValue{
+				"peer.service": {Value: &tracepb.AttributeValue_StringValue{
+					StringValue: &tracepb.TruncatableString{Value: "backend"},
+				}},
+			},
+		},
+	}}
+	require.NoError(t, processor.tc.ConsumeTraceData(context.Background(), consumerdata.TraceData{Spans: spans}))
+	assert.Equal(t, 1, len(allExporters[cfg.Exporters["exampleexporter"]].te.(*config.ExampleExporterConsumer).Traces))
+	assert.Equal(t, 1, len(metricsExporter.me.(*config.ExampleExporterConsumer).Metrics))
+}
+
+func TestPipelinesBuilder_UnknownMetricsExporter(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	serviceGraphFactory := &servicegraphprocessor.Factory{}
+	factories.Processors[serviceGraphFactory.Type()] = serviceGraphFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_service_graph.yaml", factories)
+	require.Nil(t, err)
+
+	cfg.Processors["service_graph"].(*servicegraphprocessor.Config).MetricsExporters = []string{"exampleexporter/unknown"}
+
+	exporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+	_, err = NewPipelinesBuilder(zap.NewNop(), cfg, exporters, factories.Processors).Build()
+	assert.Error(t, err)
+}
+
+func TestPipelinesBuilder_RouteExporters(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	routerFactory := &metrictyperouterprocessor.Factory{}
+	factories.Processors[routerFactory.Type()] = routerFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_metric_type_router.yaml", factories)
+	require.Nil(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+
+	// The route exporter is not in any pipeline but is built for metrics.
+	histogramsExporter := allExporters[cfg.Exporters["exampleexporter/histograms"]]
+	require.NotNil(t, histogramsExporter)
+	assert.Nil(t, histogramsExporter.te)
+	require.NotNil(t, histogramsExporter.me)
+
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	assert.NoError(t, err)
+	processor := pipelineProcessors[cfg.Pipelines["metrics"]]
+	require.NotNil(t, processor)
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{
+		{MetricDescriptor: &metricspb.MetricDescriptor{Name: "latency", Type: metricspb.MetricDescriptor_CUMULATIVE_DISTRIBUTION}},
+		{MetricDescriptor: &metricspb.MetricDescriptor{Name: "requests", Type: metricspb.MetricDescriptor_CUMULATIVE_INT64}},
+	}}
+	require.NoError(t, processor.mc.ConsumeMetricsData(context.Background(), md))
+
+	routed := histogramsExporter.me.(*config.ExampleExporterConsumer).Metrics
+	require.Equal(t, 1, len(routed))
+	assert.Equal(t, "latency", routed[0].Metrics[0].MetricDescriptor.Name)
+	passed := allExporters[cfg.Exporters["exampleexporter"]].me.(*config.ExampleExporterConsumer).Metrics
+	require.Equal(t, 1, len(passed))
+	assert.Equal(t, "requests", passed[0].Metrics[0].MetricDescriptor.Name)
+}
+
+func TestPipelinesBuilder_UnknownRouteExporter(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	routerFactory := &metrictyperouterprocessor.Factory{}
+	factories.Processors[routerFactory.Type()] = routerFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_metric_type_router.yaml", factories)
+	require.Nil(t, err)
+
+	cfg.Processors["metric_type_router"].(*metrictyperouterprocessor.Config).Routes = map[string][]string{
+		"histogram": {"exampleexporter/unknown"},
+	}
+
+	exporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+	_, err = NewPipelinesBuilder(zap.NewNop(), cfg, exporters, factories.Processors).Build()
+	assert.Error(t, err)
+}
+
+func TestPipelinesBuilder_ShutdownProcessors(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	mergeFactory := &mergeprocessor.Factory{}
+	factories.Processors[mergeFactory.Type()] = mergeFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_merge.yaml", factories)
+	require.Nil(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	assert.NoError(t, err)
+	processor := pipelineProcessors[cfg.Pipelines["metrics"]]
+	require.NotNil(t, processor)
+	require.Equal(t, 1, len(processor.shutdowners))
+
+	md := consumerdata.MetricsData{Metrics: []*metricspb.Metric{
+		{MetricDescriptor: &metricspb.MetricDescriptor{Name: "requests", Type: metricspb.MetricDescriptor_CUMULATIVE_INT64}},
+	}}
+	require.NoError(t, processor.mc.ConsumeMetricsData(context.Background(), md))
+	exported := allExporters[cfg.Exporters["exampleexporter"]].me.(*config.ExampleExporterConsumer)
+	assert.Equal(t, 0, len(exported.Metrics))
+
+	// The data held by the processor is flushed to the exporters on shutdown.
+	pipelineProcessors.ShutdownAll()
+	require.Equal(t, 1, len(exported.Metrics))
+	assert.Equal(t, "requests", exported.Metrics[0].Metrics[0].MetricDescriptor.Name)
+}
+
+func TestPipelinesBuilder_HeartbeatPerPipeline(t *testing.T) {
+	views := heartbeatprocessor.MetricViews(telemetry.Detailed)
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	factories, err := config.ExampleComponents()
+	assert.Nil(t, err)
+	heartbeatFactory := &heartbeatprocessor.Factory{}
+	factories.Processors[heartbeatFactory.Type()] = heartbeatFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_heartbeat.yaml", factories)
+	require.Nil(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	assert.NoError(t, err)
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	assert.NoError(t, err)
+	// The heartbeat of each pipeline is stopped when the pipelines are shut down.
+	defer pipelineProcessors.ShutdownAll()
+	for _, name := range []string{"traces", "metrics"} {
+		require.Equal(t, 1, len(pipelineProcessors[cfg.Pipelines[name]].shutdowners), name)
+	}
+
+	// The heartbeat processor shared by the pipelines reports a heartbeat per pipeline.
+	rows, err := view.RetrieveData("heartbeat_timestamp")
+	require.NoError(t, err)
+	pipelines := make(map[string]bool)
+	for _, row := range rows {
+		for _, tg := range row.Tags {
+			if tg.Key == observability.TagKeyPipeline {
+				pipelines[tg.Value] = true
+			}
+		}
+	}
+	assert.Equal(t, map[string]bool{"traces": true, "metrics": true}, pipelines)
+}
@@ -29,10 +29,11 @@ import (
 )
 
 // builtReceiver is a receiver that is built based on a config. It can have
-// a trace and/or a metrics component.
+// a trace and/or a metrics component, or a single data component getting both.
 type builtReceiver struct {
 	trace   receiver.TraceReceiver
 	metrics receiver.MetricsReceiver
+	data    receiver.DataReceiver
 }
 
 // Stop the receiver.
@@ -52,6 +53,13 @@ func (rcv *builtReceiver) Stop() error {
 		}
 	}
 
+	if rcv.data != nil {
+		err := rcv.data.StopDataReception()
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
 	return oterr.CombineErrors(errors)
 }
 
@@ -72,6 +80,13 @@ func (rcv *builtReceiver) Start(host receiver.Host) error {
 		}
 	}
 
+	if rcv.data != nil {
+		err := rcv.data.StartDataReception(host)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
 	return oterr.CombineErrors(errors)
 }
 
@@ -228,6 +243,9 @@ func (rb *ReceiversBuilder) buildReceiver(config configmodels.Receiver) (*builtR
 	if factory == nil {
 		return nil, fmt.Errorf("receiver factory not found for type: %s", config.Type())
 	}
+	if dataFactory, ok := factory.(receiver.DataFactory); ok {
+		return rb.buildDataReceiver(dataFactory, config, pipelinesToAttach)
+	}
 	rcv := &builtReceiver{}
 
 	// Now we have list of pipelines broken down by data type. Iterate for each data type.
@@ -248,6 +266,40 @@ func (rb *ReceiversBuilder) buildReceiver(config configmodels.Receiver) (*builtR
 	return rcv, nil
 }
 
+// buildDataReceiver creates a receiver getting traces and metrics in the same
+// stream. The stream is split by data type, each signal is fanned out to the
+// attached pipelines of its type and dropped if there are none.
+func (rb *ReceiversBuilder) buildDataReceiver(
+	factory receiver.DataFactory,
+	config configmodels.Receiver,
+	pipelinesToAttach attachedPipelines,
+) (*builtReceiver, error) {
+	var tc consumer.TraceConsumer
+	if pipelines := pipelinesToAttach[configmodels.TracesDataType]; len(pipelines) > 0 {
+		tc = buildFanoutTraceConsumer(pipelines)
+	}
+	var mc consumer.MetricsConsumer
+	if pipelines := pipelinesToAttach[configmodels.MetricsDataType]; len(pipelines) > 0 {
+		mc = buildFanoutMetricConsumer(pipelines)
+	}
+	if tc == nil && mc == nil {
+		// No pipelines are attached to this receiver.
+		return &builtReceiver{}, nil
+	}
+
+	junction := processor.NewSignalSplitterConnector(tc, mc)
+	data, err := factory.CreateDataReceiver(context.Background(), rb.logger, config, junction)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create receiver %s: %s", config.Name(), err.Error())
+	}
+
+	rb.logger.Info("Receiver is enabled.",
+		zap.String("receiver", config.Name()),
+		zap.Bool("traces", tc != nil), zap.Bool("metrics", mc != nil))
+
+	return &builtReceiver{data: data}, nil
+}
+
 func buildFanoutTraceConsumer(pipelineFrontProcessors []*builtProcessor) consumer.TraceConsumer {
 	// Optimize for the case when there is only one processor, no need to create junction point.
 	if len(pipelineFrontProcessors) == 1 {
@@ -28,8 +28,10 @@ import (
 
 	"github.com/open-telemetry/opentelemetry-service/config"
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/consumer"
 	"github.com/open-telemetry/opentelemetry-service/consumer/consumerdata"
 	"github.com/open-telemetry/opentelemetry-service/processor/attributesprocessor"
+	"github.com/open-telemetry/opentelemetry-service/receiver"
 	"github.com/open-telemetry/opentelemetry-service/receiver/receivertest"
 )
 
@@ -270,3 +272,102 @@ func TestReceiversBuilder_StopAll(t *testing.T) {
 	assert.Equal(t, true, receiver.TraceStopped)
 	assert.Equal(t, true, receiver.MetricsStopped)
 }
+
+// exampleDataReceiverFactory creates the "examplereceiver" receivers as
+// receivers of a combined stream of traces and metrics.
+type exampleDataReceiverFactory struct {
+	config.ExampleReceiverFactory
+}
+
+var _ receiver.DataFactory = (*exampleDataReceiverFactory)(nil)
+
+func (f *exampleDataReceiverFactory) CreateDataReceiver(
+	ctx context.Context,
+	logger *zap.Logger,
+	cfg configmodels.Receiver,
+	nextConsumer consumer.DataConsumer,
+) (receiver.DataReceiver, error) {
+	return &exampleDataReceiver{nextConsumer: nextConsumer}, nil
+}
+
+type exampleDataReceiver struct {
+	nextConsumer consumer.DataConsumer
+	started      bool
+	stopped      bool
+}
+
+func (edr *exampleDataReceiver) DataSource() string {
+	return ""
+}
+
+func (edr *exampleDataReceiver) StartDataReception(host receiver.Host) error {
+	edr.started = true
+	return nil
+}
+
+func (edr *exampleDataReceiver) StopDataReception() error {
+	edr.stopped = true
+	return nil
+}
+
+func TestReceiversBuilder_DataReceiver(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	require.Nil(t, err)
+
+	attrFactory := &attributesprocessor.Factory{}
+	factories.Processors[attrFactory.Type()] = attrFactory
+	dataFactory := &exampleDataReceiverFactory{}
+	factories.Receivers[dataFactory.Type()] = dataFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_builder.yaml", factories)
+	require.Nil(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	require.NoError(t, err)
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	require.NoError(t, err)
+	receivers, err := NewReceiversBuilder(zap.NewNop(), cfg, pipelineProcessors, factories.Receivers).Build()
+	require.NoError(t, err)
+
+	// The receiver is created once for both data types.
+	rcv := receivers[cfg.Receivers["examplereceiver"]]
+	require.NotNil(t, rcv)
+	assert.Nil(t, rcv.trace)
+	assert.Nil(t, rcv.metrics)
+	require.NotNil(t, rcv.data)
+	dataReceiver := rcv.data.(*exampleDataReceiver)
+
+	require.NoError(t, receivers.StartAll(zap.NewNop(), receivertest.NewMockHost()))
+	assert.True(t, dataReceiver.started)
+
+	// Send a combined stream, each signal goes to the pipelines of its type:
+	// "traces" and "metrics", both exporting to "exampleexporter".
+	name := tracepb.TruncatableString{Value: "testspanname"}
+	traceData := consumerdata.TraceData{Spans: []*tracepb.Span{{Name: &name}}}
+	metricsData := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			{MetricDescriptor: &metricspb.MetricDescriptor{Name: "testmetric"}},
+		},
+	}
+	require.NoError(t, dataReceiver.nextConsumer.ConsumeMetricsData(context.Background(), metricsData))
+	require.NoError(t, dataReceiver.nextConsumer.ConsumeTraceData(context.Background(), traceData))
+
+	traceExporter := allExporters[cfg.Exporters["exampleexporter"]].te.(*config.ExampleExporterConsumer)
+	require.Equal(t, 1, len(traceExporter.Traces))
+	assert.Equal(t, int64(12345), traceExporter.Traces[0].Spans[0].Attributes.AttributeMap["attr1"].GetIntValue())
+	metricsExporter := allExporters[cfg.Exporters["exampleexporter"]].me.(*config.ExampleExporterConsumer)
+	require.Equal(t, 1, len(metricsExporter.Metrics))
+	assert.Equal(t, metricsData, metricsExporter.Metrics[0])
+
+	// "examplereceiver/3" is only attached to metrics pipelines, its traces are
+	// dropped.
+	metricsOnly := receivers[cfg.Receivers["examplereceiver/3"]].data.(*exampleDataReceiver)
+	require.NoError(t, metricsOnly.nextConsumer.ConsumeTraceData(context.Background(), traceData))
+	require.NoError(t, metricsOnly.nextConsumer.ConsumeMetricsData(context.Background(), metricsData))
+	assert.Equal(t, 1, len(traceExporter.Traces))
+	assert.Equal(t, 2, len(metricsExporter.Metrics))
+	assert.Equal(t, 0, len(allExporters[cfg.Exporters["exampleexporter/2"]].te.(*config.ExampleExporterConsumer).Traces))
+	assert.Equal(t, 1, len(allExporters[cfg.Exporters["exampleexporter/2"]].me.(*config.ExampleExporterConsumer).Metrics))
+
+	receivers.StopAll()
+	assert.True(t, dataReceiver.stopped)
+}
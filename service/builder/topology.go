@@ -0,0 +1,308 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
+	"github.com/open-telemetry/opentelemetry-service/processor"
+)
+
+// redactedValue replaces the value of the settings holding secrets.
+const redactedValue = "<secret>"
+
+// secretKeyRegexp matches the keys of the settings holding secrets.
+var secretKeyRegexp = regexp.MustCompile(`(?i)(password|passwd|secret|token|authorization|api[-_]?key|credential)`)
+
+// headersKeyRegexp matches the keys of the settings holding request headers.
+// Any header may carry credentials or tenant ids, e.g. X-Scope-OrgID, so all
+// their values are redacted.
+var headersKeyRegexp = regexp.MustCompile(`(?i)headers$`)
+
+// Topology is the assembled pipeline graph: the components used by the built
+// pipelines and how the pipelines wire them together.
+type Topology struct {
+	Receivers  []TopologyComponent `json:"receivers"`
+	Processors []TopologyComponent `json:"processors"`
+	Exporters  []TopologyComponent `json:"exporters"`
+	Pipelines  []TopologyPipeline  `json:"pipelines"`
+}
+
+// TopologyComponent is a component of the topology with its effective
+// settings, secrets redacted.
+type TopologyComponent struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Settings interface{} `json:"settings,omitempty"`
+	// FallbackExporters are the exporters a processor forwards the data it
+	// does not pass down the pipeline to.
+	FallbackExporters []string `json:"fallback_exporters,omitempty"`
+	// MetricsExporters are the exporters a trace processor sends the metrics
+	// it derives from the spans to.
+	MetricsExporters []string `json:"metrics_exporters,omitempty"`
+	// RouteExporters are the exporters of each route of a processor routing
+	// the data, keyed by route.
+	RouteExporters map[string][]string `json:"route_exporters,omitempty"`
+}
+
+// TopologyPipeline is a pipeline of the topology, the components are listed
+// by name in the order the data goes through them.
+type TopologyPipeline struct {
+	Name       string   `json:"name"`
+	DataType   string   `json:"data_type"`
+	Receivers  []string `json:"receivers"`
+	Processors []string `json:"processors"`
+	Exporters  []string `json:"exporters"`
+}
+
+// NewTopology returns the topology of the pipelines built via PipelinesBuilder
+// from the given config. The components and the pipelines are sorted by name.
+func NewTopology(config *configmodels.Config, pipelines PipelineProcessors) *Topology {
+	topology := &Topology{
+		Receivers:  []TopologyComponent{},
+		Processors: []TopologyComponent{},
+		Exporters:  []TopologyComponent{},
+		Pipelines:  []TopologyPipeline{},
+	}
+
+	receivers := make(map[string]bool)
+	processors := make(map[string]bool)
+	exporters := make(map[string]bool)
+	for pipelineCfg := range pipelines {
+		topology.Pipelines = append(topology.Pipelines, TopologyPipeline{
+			Name:       pipelineCfg.Name,
+			DataType:   pipelineCfg.InputType.GetString(),
+			Receivers:  nonNil(pipelineCfg.Receivers),
+			Processors: nonNil(pipelineCfg.Processors),
+			Exporters:  nonNil(pipelineCfg.Exporters),
+		})
+		for _, name := range pipelineCfg.Receivers {
+			receivers[name] = true
+		}
+		for _, name := range pipelineCfg.Processors {
+			processors[name] = true
+		}
+		for _, name := range pipelineCfg.Exporters {
+			exporters[name] = true
+		}
+	}
+	sort.Slice(topology.Pipelines, func(i, j int) bool {
+		return topology.Pipelines[i].Name < topology.Pipelines[j].Name
+	})
+
+	for _, name := range sortedNames(processors) {
+		cfg := config.Processors[name]
+		if cfg == nil {
+			continue
+		}
+		component := newTopologyComponent(name, cfg.Type(), cfg)
+		if fallbackCfg, ok := cfg.(processor.FallbackConfig); ok {
+			component.FallbackExporters = fallbackCfg.FallbackExporterNames()
+			for _, exporterName := range component.FallbackExporters {
+				exporters[exporterName] = true
+			}
+		}
+		if emitterCfg, ok := cfg.(processor.MetricsEmitterConfig); ok {
+			component.MetricsExporters = emitterCfg.MetricsExporterNames()
+			for _, exporterName := range component.MetricsExporters {
+				exporters[exporterName] = true
+			}
+		}
+		if routerCfg, ok := cfg.(processor.RouterConfig); ok {
+			component.RouteExporters = routerCfg.RouteExporterNames()
+			for _, names := range component.RouteExporters {
+				for _, exporterName := range names {
+					exporters[exporterName] = true
+				}
+			}
+		}
+		topology.Processors = append(topology.Processors, component)
+	}
+	for _, name := range sortedNames(receivers) {
+		if cfg := config.Receivers[name]; cfg != nil {
+			topology.Receivers = append(topology.Receivers, newTopologyComponent(name, cfg.Type(), cfg))
+		}
+	}
+	for _, name := range sortedNames(exporters) {
+		if cfg := config.Exporters[name]; cfg != nil {
+			topology.Exporters = append(topology.Exporters, newTopologyComponent(name, cfg.Type(), cfg))
+		}
+	}
+
+	return topology
+}
+
+// JSON returns the topology as indented JSON.
+func (t *Topology) JSON() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// Keep the redacted values readable.
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(t); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newTopologyComponent(name, typeStr string, cfg interface{}) TopologyComponent {
+	return TopologyComponent{
+		Name:     name,
+		Type:     typeStr,
+		Settings: settingsValue(reflect.ValueOf(cfg)),
+	}
+}
+
+func sortedNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func nonNil(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// settingsValue converts a config value to a JSON friendly value keyed as in
+// the config file, that is following the mapstructure tags of the structs.
+func settingsValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return settingsValue(v.Elem())
+	case reflect.Struct:
+		settings := make(map[string]interface{})
+		addStructSettings(settings, v)
+		return settings
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		settings := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			keyStr := fmt.Sprint(key.Interface())
+			settings[keyStr] = redactSetting(keyStr, v.MapIndex(key))
+		}
+		return settings
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		settings := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			settings[i] = settingsValue(v.Index(i))
+		}
+		return settings
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	}
+	return v.Interface()
+}
+
+// addStructSettings adds the settings of the exported fields of the struct,
+// the fields of the squashed structs being added to the settings as is.
+func addStructSettings(settings map[string]interface{}, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// Unexported field.
+			continue
+		}
+		tagParts := strings.Split(field.Tag.Get("mapstructure"), ",")
+		key := tagParts[0]
+		if key == "-" {
+			continue
+		}
+		fieldValue := v.Field(i)
+		if isSquashed(tagParts[1:]) {
+			for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				addStructSettings(settings, fieldValue)
+				continue
+			}
+		}
+		if key == "" {
+			key = field.Name
+		}
+		settings[key] = redactSetting(key, fieldValue)
+	}
+}
+
+func isSquashed(tagOptions []string) bool {
+	for _, option := range tagOptions {
+		if option == "squash" {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSetting returns the value of the setting, redacted if its key names a
+// secret and it is set. The values of the headers settings are all redacted.
+func redactSetting(key string, v reflect.Value) interface{} {
+	if secretKeyRegexp.MatchString(key) && isSet(v) {
+		return redactedValue
+	}
+	if headersKeyRegexp.MatchString(key) {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Map && !v.IsNil() {
+			headers := make(map[string]interface{}, v.Len())
+			for _, name := range v.MapKeys() {
+				value := v.MapIndex(name)
+				if isSet(value) {
+					headers[fmt.Sprint(name.Interface())] = redactedValue
+				} else {
+					headers[fmt.Sprint(name.Interface())] = settingsValue(value)
+				}
+			}
+			return headers
+		}
+	}
+	return settingsValue(v)
+}
+
+func isSet(v reflect.Value) bool {
+	return v.IsValid() && !reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
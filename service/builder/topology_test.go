@@ -0,0 +1,196 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-service/config"
+	"github.com/open-telemetry/opentelemetry-service/processor/failoverprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/metrictyperouterprocessor"
+)
+
+func TestNewTopology_TwoExportersMetricsPipeline(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	require.NoError(t, err)
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_topology.yaml", factories)
+	require.NoError(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	require.NoError(t, err)
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	require.NoError(t, err)
+
+	got, err := NewTopology(cfg, pipelineProcessors).JSON()
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"receivers": [
+			{
+				"name": "examplereceiver",
+				"type": "examplereceiver",
+				"settings": {
+					"disabled": false,
+					"endpoint": "localhost:1000",
+					"extra": "some string",
+					"extra_list": null,
+					"extra_map": {"authorization": "<secret>"}
+				}
+			}
+		],
+		"processors": [],
+		"exporters": [
+			{
+				"name": "exampleexporter",
+				"type": "exampleexporter",
+				"settings": {
+					"ExporterShutdown": false,
+					"disabled": false,
+					"max_concurrent_requests": 0,
+					"concurrency_policy": "",
+					"extra": "some export string",
+					"extra_int": 5,
+					"extra_list": null,
+					"extra_map": {"api_key": "<secret>", "region": "us-west-1"}
+				}
+			},
+			{
+				"name": "exampleexporter/2",
+				"type": "exampleexporter",
+				"settings": {
+					"ExporterShutdown": false,
+					"disabled": false,
+					"max_concurrent_requests": 0,
+					"concurrency_policy": "",
+					"extra": "second exporter",
+					"extra_int": 0,
+					"extra_list": null,
+					"extra_map": null
+				}
+			}
+		],
+		"pipelines": [
+			{
+				"name": "metrics",
+				"data_type": "metrics",
+				"receivers": ["examplereceiver"],
+				"processors": [],
+				"exporters": ["exampleexporter", "exampleexporter/2"]
+			}
+		]
+	}`, string(got))
+	assert.NotContains(t, string(got), "some-api-key")
+	assert.NotContains(t, string(got), "some-token")
+}
+
+func TestNewTopology_FallbackExporters(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	require.NoError(t, err)
+	failoverFactory := &failoverprocessor.Factory{}
+	factories.Processors[failoverFactory.Type()] = failoverFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_failover.yaml", factories)
+	require.NoError(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	require.NoError(t, err)
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	require.NoError(t, err)
+
+	topology := NewTopology(cfg, pipelineProcessors)
+	require.Len(t, topology.Processors, 1)
+	assert.Equal(t, "failover", topology.Processors[0].Name)
+	assert.Equal(t, []string{"exampleexporter/local"}, topology.Processors[0].FallbackExporters)
+
+	var exporterNames []string
+	for _, exp := range topology.Exporters {
+		exporterNames = append(exporterNames, exp.Name)
+	}
+	assert.Equal(t, []string{"exampleexporter", "exampleexporter/local"}, exporterNames)
+
+	var pipelineNames []string
+	for _, pipeline := range topology.Pipelines {
+		pipelineNames = append(pipelineNames, pipeline.Name)
+	}
+	assert.Equal(t, []string{"metrics", "traces"}, pipelineNames)
+}
+
+func TestNewTopology_RouteExporters(t *testing.T) {
+	factories, err := config.ExampleComponents()
+	require.NoError(t, err)
+	routerFactory := &metrictyperouterprocessor.Factory{}
+	factories.Processors[routerFactory.Type()] = routerFactory
+	cfg, err := config.LoadConfigFile(t, "testdata/pipelines_metric_type_router.yaml", factories)
+	require.NoError(t, err)
+
+	allExporters, err := NewExportersBuilder(zap.NewNop(), cfg, factories.Exporters).Build()
+	require.NoError(t, err)
+	pipelineProcessors, err := NewPipelinesBuilder(zap.NewNop(), cfg, allExporters, factories.Processors).Build()
+	require.NoError(t, err)
+
+	topology := NewTopology(cfg, pipelineProcessors)
+	require.Len(t, topology.Processors, 1)
+	assert.Equal(t, map[string][]string{"histogram": {"exampleexporter/histograms"}}, topology.Processors[0].RouteExporters)
+
+	var exporterNames []string
+	for _, exp := range topology.Exporters {
+		exporterNames = append(exporterNames, exp.Name)
+	}
+	assert.Equal(t, []string{"exampleexporter", "exampleexporter/histograms"}, exporterNames)
+}
+
+func TestSettingsValue(t *testing.T) {
+	type Auth struct {
+		Password string `mapstructure:"password"`
+	}
+	type settings struct {
+		Auth     `mapstructure:",squash"`
+		Timeout  time.Duration     `mapstructure:"timeout"`
+		Token    string            `mapstructure:"bearer_token"`
+		Headers  map[string]string `mapstructure:"headers"`
+		Extra    map[string]string `mapstructure:"extra"`
+		Skipped  string            `mapstructure:"-"`
+		internal string
+	}
+
+	got := settingsValue(reflect.ValueOf(&settings{
+		Auth:     Auth{Password: "pass"},
+		Timeout:  5 * time.Second,
+		Headers:  map[string]string{"Authorization": "Basic abc", "X-Scope-OrgID": "tenant", "X-Empty": ""},
+		Extra:    map[string]string{"X-Api-Key": "key", "region": "us-west-1"},
+		Skipped:  "skipped",
+		internal: "internal",
+	}))
+	assert.Equal(t, map[string]interface{}{
+		"password":     "<secret>",
+		"timeout":      "5s",
+		"bearer_token": "",
+		// Every header value is redacted.
+		"headers": map[string]interface{}{
+			"Authorization": "<secret>",
+			"X-Scope-OrgID": "<secret>",
+			"X-Empty":       "",
+		},
+		"extra": map[string]interface{}{
+			"X-Api-Key": "<secret>",
+			"region":    "us-west-1",
+		},
+	}, got)
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/open-telemetry/opentelemetry-service/config"
 	"github.com/open-telemetry/opentelemetry-service/config/configmodels"
 	"github.com/open-telemetry/opentelemetry-service/extension"
+	"github.com/open-telemetry/opentelemetry-service/extension/zpagesextension"
 	"github.com/open-telemetry/opentelemetry-service/internal/config/viperutils"
 	"github.com/open-telemetry/opentelemetry-service/receiver"
 	"github.com/open-telemetry/opentelemetry-service/service/builder"
@@ -42,6 +43,8 @@ type Application struct {
 	logger         *zap.Logger
 	exporters      builder.Exporters
 	builtReceivers builder.Receivers
+	pipelines      builder.PipelineProcessors
+	topology       *builder.Topology
 
 	factories config.Factories
 	config    *configmodels.Config
@@ -185,13 +188,16 @@ func (app *Application) setupPipelines() {
 
 	// Create pipelines and their processors and plug exporters to the
 	// end of the pipelines.
-	pipelines, err := builder.NewPipelinesBuilder(app.logger, app.config, app.exporters, app.factories.Processors).Build()
+	app.pipelines, err = builder.NewPipelinesBuilder(app.logger, app.config, app.exporters, app.factories.Processors).Build()
 	if err != nil {
 		log.Fatalf("Cannot load configuration: %v", err)
 	}
 
+	app.topology = builder.NewTopology(app.config, app.pipelines)
+	zpagesextension.RegisterPage(topologyPagePath, topologyPage{app: app})
+
 	// Create receivers and plug them into the start of the pipelines.
-	app.builtReceivers, err = builder.NewReceiversBuilder(app.logger, app.config, pipelines, app.factories.Receivers).Build()
+	app.builtReceivers, err = builder.NewReceiversBuilder(app.logger, app.config, app.pipelines, app.factories.Receivers).Build()
 	if err != nil {
 		log.Fatalf("Cannot load configuration: %v", err)
 	}
@@ -241,7 +247,10 @@ func (app *Application) shutdownPipelines() {
 	app.logger.Info("Stopping receivers...")
 	app.builtReceivers.StopAll()
 
-	// TODO: shutdown processors
+	zpagesextension.UnregisterPage(topologyPagePath)
+
+	app.logger.Info("Shutting down processors...")
+	app.pipelines.ShutdownAll()
 
 	app.logger.Info("Shutting down exporters...")
 	app.exporters.ShutdownAll()
@@ -21,6 +21,7 @@ import (
 	"strconv"
 
 	"contrib.go.opencensus.io/exporter/prometheus"
+	prometheus_golang "github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 	"go.opencensus.io/stats/view"
 	"go.uber.org/zap"
@@ -28,14 +29,41 @@ import (
 	"github.com/open-telemetry/opentelemetry-service/internal/collector/telemetry"
 	"github.com/open-telemetry/opentelemetry-service/observability"
 	"github.com/open-telemetry/opentelemetry-service/processor"
+	"github.com/open-telemetry/opentelemetry-service/processor/anomalyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/dropsummaryprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/failoverprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/heartbeatprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/intcoercionprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/labelcaseprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/labelconsistencyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/loadbalancingprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/maxpayloadprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/mergeprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/metriccatalogprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/metrictyperouterprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/mindurationprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/monotonicprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/nameescapingprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/nodebatcherprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/queuedprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/requiredidentityprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/requiredlabelsprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/servicegraphprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/spanstatusprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/stalenessprocessor"
 	"github.com/open-telemetry/opentelemetry-service/processor/tailsamplingprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/totalsuffixprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/tracesplitprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/typeconsistencyprocessor"
+	"github.com/open-telemetry/opentelemetry-service/processor/valuefilterprocessor"
 )
 
 const (
 	metricsPortCfg  = "metrics-port"
 	metricsLevelCfg = "metrics-level"
+	// metricsMaxTagValuesCfg caps the cardinality of the tags of the telemetry metrics whose values come from the
+	// data or the config, e.g. the service and the scrape job tags.
+	metricsMaxTagValuesCfg = "metrics-max-tag-values"
 )
 
 var (
@@ -51,6 +79,7 @@ func telemetryFlags(flags *flag.FlagSet) {
 	flags.String(metricsLevelCfg, "BASIC", "Output level of telemetry metrics (NONE, BASIC, NORMAL, DETAILED)")
 	// At least until we can use a generic, i.e.: OpenCensus, metrics exporter we default to Prometheus at port 8888, if not otherwise specified.
 	flags.Uint(metricsPortCfg, 8888, "Port exposing collector telemetry.")
+	flags.Uint(metricsMaxTagValuesCfg, 0, "Maximum number of distinct values of each tag coming from the data or the config, e.g. service names and prometheus jobs, recorded in telemetry metrics, others are recorded as "+observability.OtherTagValue+". The first values seen are kept until the collector restarts (0 for no limit).")
 }
 
 func (tel *appTelemetry) init(asyncErrorChannel chan<- error, ballastSizeBytes uint64, v *viper.Viper, logger *zap.Logger) error {
@@ -64,12 +93,37 @@ func (tel *appTelemetry) init(asyncErrorChannel chan<- error, ballastSizeBytes u
 	}
 
 	port := v.GetInt(metricsPortCfg)
+	observability.SetMaxTagValues(v.GetInt(metricsMaxTagValuesCfg))
 
 	views := processor.MetricViews(level)
 	views = append(views, queuedprocessor.MetricViews(level)...)
 	views = append(views, nodebatcherprocessor.MetricViews(level)...)
 	views = append(views, observability.AllViews...)
 	views = append(views, tailsamplingprocessor.SamplingProcessorMetricViews(level)...)
+	views = append(views, typeconsistencyprocessor.MetricViews(level)...)
+	views = append(views, monotonicprocessor.MetricViews(level)...)
+	views = append(views, valuefilterprocessor.MetricViews(level)...)
+	views = append(views, mindurationprocessor.MetricViews(level)...)
+	views = append(views, failoverprocessor.MetricViews(level)...)
+	views = append(views, tracesplitprocessor.MetricViews(level)...)
+	views = append(views, requiredlabelsprocessor.MetricViews(level)...)
+	views = append(views, totalsuffixprocessor.MetricViews(level)...)
+	views = append(views, servicegraphprocessor.MetricViews(level)...)
+	views = append(views, maxpayloadprocessor.MetricViews(level)...)
+	views = append(views, heartbeatprocessor.MetricViews(level)...)
+	views = append(views, labelcaseprocessor.MetricViews(level)...)
+	views = append(views, metriccatalogprocessor.MetricViews(level)...)
+	views = append(views, metrictyperouterprocessor.MetricViews(level)...)
+	views = append(views, anomalyprocessor.MetricViews(level)...)
+	views = append(views, intcoercionprocessor.MetricViews(level)...)
+	views = append(views, mergeprocessor.MetricViews(level)...)
+	views = append(views, loadbalancingprocessor.MetricViews(level)...)
+	views = append(views, nameescapingprocessor.MetricViews(level)...)
+	views = append(views, requiredidentityprocessor.MetricViews(level)...)
+	views = append(views, spanstatusprocessor.MetricViews(level)...)
+	views = append(views, stalenessprocessor.MetricViews(level)...)
+	views = append(views, dropsummaryprocessor.MetricViews(level)...)
+	views = append(views, labelconsistencyprocessor.MetricViews(level)...)
 	processMetricsViews := telemetry.NewProcessMetricsViews(ballastSizeBytes)
 	views = append(views, processMetricsViews.Views()...)
 	tel.views = views
@@ -80,8 +134,10 @@ func (tel *appTelemetry) init(asyncErrorChannel chan<- error, ballastSizeBytes u
 	processMetricsViews.StartCollection()
 
 	// Until we can use a generic metrics exporter, default to Prometheus.
+	registry := prometheus_golang.NewRegistry()
 	opts := prometheus.Options{
 		Namespace: "oc_collector",
+		Registry:  registry,
 	}
 	pe, err := prometheus.NewExporter(opts)
 	if err != nil {
@@ -93,7 +149,8 @@ func (tel *appTelemetry) init(asyncErrorChannel chan<- error, ballastSizeBytes u
 	logger.Info("Serving Prometheus metrics", zap.Int("port", port))
 	go func() {
 		mux := http.NewServeMux()
-		mux.Handle("/metrics", pe)
+		// Serve OpenMetrics to the scrapers asking for it, Prometheus text otherwise.
+		mux.Handle("/metrics", telemetry.NewMetricsHandler(registry, pe))
 		serveErr := http.ListenAndServe(":"+strconv.Itoa(port), mux)
 		if serveErr != nil && serveErr != http.ErrServerClosed {
 			asyncErrorChannel <- serveErr
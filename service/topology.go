@@ -0,0 +1,51 @@
+// Copyright 2019, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+
+	"github.com/open-telemetry/opentelemetry-service/service/builder"
+)
+
+// topologyPagePath is the path of the zPage serving the pipeline topology.
+const topologyPagePath = "/debug/pipelinez"
+
+// Topology returns the topology of the pipelines built by the application, nil
+// until the pipelines are built. The secrets in the settings of the components
+// are redacted.
+func (app *Application) Topology() *builder.Topology {
+	return app.topology
+}
+
+// topologyPage is the zPage serving the pipeline topology as JSON.
+type topologyPage struct {
+	app *Application
+}
+
+func (tp topologyPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	topology := tp.app.Topology()
+	if topology == nil {
+		http.Error(w, "pipelines are not built", http.StatusServiceUnavailable)
+		return
+	}
+	body, err := topology.JSON()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}